@@ -129,7 +129,8 @@ type Alertmanager struct {
 	// hence we need to generate the metric ourselves.
 	configHashMetric prometheus.Gauge
 
-	rateLimitedNotifications *prometheus.CounterVec
+	rateLimitedNotifications      *prometheus.CounterVec
+	rateLimitDelayedNotifications *prometheus.CounterVec
 
 	requestDuration *prometheus.HistogramVec
 }
@@ -171,6 +172,10 @@ func New(cfg *Config, reg *prometheus.Registry) (*Alertmanager, error) {
 			Name: "alertmanager_notification_rate_limited_total",
 			Help: "Number of rate-limited notifications per integration.",
 		}, []string{"integration"}), // "integration" is consistent with other alertmanager metrics.
+		rateLimitDelayedNotifications: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "alertmanager_notification_rate_limit_delayed_total",
+			Help: "Number of rate-limited notifications per integration delayed to wait for the rate limit.",
+		}, []string{"integration"}),
 
 		requestDuration: promauto.With(reg).NewHistogramVec(
 			prometheus.HistogramOpts{
@@ -367,7 +372,7 @@ func (am *Alertmanager) ApplyConfig(userID string, conf *config.Config, rawCfg s
 	}
 
 	// Create a firewall binded to the per-tenant config.
-	firewallDialer := util_net.NewFirewallDialer(newFirewallDialerConfigProvider(userID, am.cfg.Limits))
+	firewallDialer := util_net.NewFirewallDialer(newFirewallDialerConfigProvider(userID, am.cfg.Limits), am.logger)
 
 	integrationsMap, err := buildIntegrationsMap(conf.Receivers, tmpl, firewallDialer, am.logger, func(integrationName string, notifier notify.Notifier) notify.Notifier {
 		if am.cfg.Limits != nil {
@@ -377,7 +382,7 @@ func (am *Alertmanager) ApplyConfig(userID string, conf *config.Config, rawCfg s
 				integration: integrationName,
 			}
 
-			return newRateLimitedNotifier(notifier, rl, 10*time.Second, am.rateLimitedNotifications.WithLabelValues(integrationName))
+			return newRateLimitedNotifier(notifier, rl, 10*time.Second, am.rateLimitedNotifications.WithLabelValues(integrationName), am.rateLimitDelayedNotifications.WithLabelValues(integrationName), log.With(am.logger, "integration", integrationName))
 		}
 		return notifier
 	})
@@ -674,6 +679,18 @@ func (p firewallDialerConfigProvider) BlockPrivateAddresses() bool {
 	return p.limits.AlertmanagerReceiversBlockPrivateAddresses(p.userID)
 }
 
+func (p firewallDialerConfigProvider) BlockHosts() []string {
+	return p.limits.AlertmanagerReceiversBlockHosts(p.userID)
+}
+
+func (p firewallDialerConfigProvider) AllowCIDRNetworks() []flagext.CIDR {
+	return p.limits.AlertmanagerReceiversAllowCIDRNetworks(p.userID)
+}
+
+func (p firewallDialerConfigProvider) AllowHosts() []string {
+	return p.limits.AlertmanagerReceiversAllowHosts(p.userID)
+}
+
 type tenantRateLimits struct {
 	tenant      string
 	integration string
@@ -688,6 +705,10 @@ func (t *tenantRateLimits) Burst() int {
 	return t.limits.NotificationBurstSize(t.tenant, t.integration)
 }
 
+func (t *tenantRateLimits) QueueSize() int {
+	return t.limits.NotificationRateLimitQueueSize(t.tenant)
+}
+
 type dispatcherLimits struct {
 	tenant string
 	limits Limits
@@ -17,6 +17,8 @@ type Config struct {
 	ConfigDB      client.Config            `yaml:"configdb"`
 	Local         local.StoreConfig        `yaml:"local"`
 	UsersScanner  users.UsersScannerConfig `yaml:"users_scanner"`
+
+	BaseConfigPrefix string `yaml:"base_config_prefix"`
 }
 
 // RegisterFlags registers the backend storage config.
@@ -28,6 +30,7 @@ func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
 	cfg.Local.RegisterFlagsWithPrefix(prefix, f)
 	cfg.RegisterFlagsWithPrefix(prefix, f)
 	cfg.UsersScanner.RegisterFlagsWithPrefix(prefix, f)
+	f.StringVar(&cfg.BaseConfigPrefix, prefix+"base-config-prefix", "", "[Experimental] If set, the alertmanager configurations stored in the bucket at <prefix>/alerts/<tenant> are read-only base configurations, layered below the configurations managed via the API. The configuration of a tenant having both is their merge, where the base configuration takes precedence on conflicts: receivers, time intervals and templates are merged by name, the inhibition rules are concatenated and the API-managed route is appended as a catch-all child route of the base route. The API serves the API-managed configuration only, and validates its merge with the base configuration when it's uploaded. Supported only by the object storage backends.")
 }
 
 // IsFullStateSupported returns if the given configuration supports access to FullState objects.
@@ -0,0 +1,267 @@
+package alertstore
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"slices"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"gopkg.in/yaml.v2"
+
+	"github.com/cortexproject/cortex/pkg/alertmanager/alertspb"
+)
+
+// LayeredAlertStore serves the alertmanager configurations layered from a read-only base store
+// and an override store. The writes and the alertmanager state go to the override store. The
+// configuration of a tenant having both a base and an override configuration is the merge of the
+// two, where the base takes precedence (see mergeAlertConfigs).
+//
+// Only GetAlertConfigs, which loads the configurations run by the alertmanagers, returns the merged
+// configurations. GetAlertConfig returns the override configuration, which is the one managed by the
+// tenant through the API, so that reading and writing it back doesn't copy the base configuration
+// into the override one.
+type LayeredAlertStore struct {
+	AlertStore
+
+	base      AlertStore
+	logger    log.Logger
+	conflicts *prometheus.GaugeVec
+}
+
+// NewLayeredAlertStore returns an AlertStore layering the configurations of the override store
+// on top of the ones of the base store.
+func NewLayeredAlertStore(base, override AlertStore, logger log.Logger, reg prometheus.Registerer) *LayeredAlertStore {
+	return &LayeredAlertStore{
+		AlertStore: override,
+		base:       base,
+		logger:     logger,
+		conflicts: promauto.With(reg).NewGaugeVec(prometheus.GaugeOpts{
+			Name: "cortex_alertmanager_config_merge_conflicts",
+			Help: "Number of conflicts found merging the base and the override alertmanager configuration of the user, at the last merge.",
+		}, []string{"user"}),
+	}
+}
+
+// ListAllUsers implements AlertStore.
+func (s *LayeredAlertStore) ListAllUsers(ctx context.Context) ([]string, error) {
+	baseUsers, err := s.base.ListAllUsers(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	userIDs, err := s.AlertStore.ListAllUsers(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, userID := range baseUsers {
+		if !slices.Contains(userIDs, userID) {
+			userIDs = append(userIDs, userID)
+		}
+	}
+	return userIDs, nil
+}
+
+// GetAlertConfigs implements AlertStore.
+func (s *LayeredAlertStore) GetAlertConfigs(ctx context.Context, userIDs []string) (map[string]alertspb.AlertConfigDesc, error) {
+	baseCfgs, err := s.base.GetAlertConfigs(ctx, userIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	cfgs, err := s.AlertStore.GetAlertConfigs(ctx, userIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	for userID, baseCfg := range baseCfgs {
+		if cfg, ok := cfgs[userID]; ok {
+			cfgs[userID] = s.merge(userID, baseCfg, cfg)
+		} else {
+			cfgs[userID] = baseCfg
+		}
+	}
+	return cfgs, nil
+}
+
+// MergeBaseAlertConfig returns the configuration the alertmanager of the tenant runs with the given
+// override configuration, that is its merge with the base configuration of the tenant, if any.
+func (s *LayeredAlertStore) MergeBaseAlertConfig(ctx context.Context, cfg alertspb.AlertConfigDesc) (alertspb.AlertConfigDesc, error) {
+	baseCfg, err := s.base.GetAlertConfig(ctx, cfg.User)
+	if err == alertspb.ErrNotFound {
+		return cfg, nil
+	}
+	if err != nil {
+		return alertspb.AlertConfigDesc{}, err
+	}
+
+	merged, _ := mergeAlertConfigs(baseCfg, cfg)
+	return merged, nil
+}
+
+func (s *LayeredAlertStore) merge(userID string, base, override alertspb.AlertConfigDesc) alertspb.AlertConfigDesc {
+	merged, conflicts := mergeAlertConfigs(base, override)
+	if len(conflicts) == 0 {
+		s.conflicts.DeleteLabelValues(userID)
+		return merged
+	}
+
+	for _, conflict := range conflicts {
+		level.Warn(s.logger).Log("msg", "conflict merging the base and the override alertmanager configuration, the base takes precedence", "user", userID, "conflict", conflict)
+	}
+	s.conflicts.WithLabelValues(userID).Set(float64(len(conflicts)))
+	return merged
+}
+
+// mergeAlertConfigs merges the base and the override alertmanager configurations of a tenant, and
+// returns the conflicts found. The base takes precedence on conflicts, so that it can enforce
+// settings which the tenant can't change:
+//   - The receivers, time intervals and templates are the union of the two, by name. A conflict is
+//     reported for each name defined differently in both.
+//   - The route is the base route, with the override route appended as a catch-all child route.
+//     The alerts are routed through the base child routes first, then through the override route.
+//   - The inhibition rules are the concatenation of the two.
+//   - Any other setting defined differently in both is a conflict.
+//
+// If a configuration can't be parsed, the base configuration is returned with a conflict.
+func mergeAlertConfigs(base, override alertspb.AlertConfigDesc) (alertspb.AlertConfigDesc, []string) {
+	var baseCfg, overrideCfg yaml.MapSlice
+	if err := yaml.Unmarshal([]byte(base.RawConfig), &baseCfg); err != nil {
+		return base, []string{fmt.Sprintf("the base configuration can't be parsed: %v", err)}
+	}
+	if err := yaml.Unmarshal([]byte(override.RawConfig), &overrideCfg); err != nil {
+		return base, []string{fmt.Sprintf("the override configuration can't be parsed: %v", err)}
+	}
+
+	var (
+		merged    yaml.MapSlice
+		conflicts []string
+	)
+
+	keys := make([]any, 0, len(baseCfg)+len(overrideCfg))
+	for _, item := range append(slices.Clone(baseCfg), overrideCfg...) {
+		if !slices.Contains(keys, item.Key) {
+			keys = append(keys, item.Key)
+		}
+	}
+
+	for _, key := range keys {
+		baseValue, inBase := lookupMapSlice(baseCfg, key)
+		overrideValue, inOverride := lookupMapSlice(overrideCfg, key)
+
+		value := baseValue
+		switch {
+		case !inBase:
+			value = overrideValue
+		case !inOverride || reflect.DeepEqual(baseValue, overrideValue):
+		case key == "route":
+			value = mergeRoutes(baseValue, overrideValue)
+		case key == "inhibit_rules":
+			value = append(toSlice(baseValue), toSlice(overrideValue)...)
+		case key == "templates":
+			value = baseValue
+			for _, tmpl := range toSlice(overrideValue) {
+				if !slices.Contains(toSlice(baseValue), tmpl) {
+					value = append(toSlice(value), tmpl)
+				}
+			}
+		case key == "receivers" || key == "time_intervals" || key == "mute_time_intervals":
+			var namedConflicts []string
+			value, namedConflicts = mergeNamedItems(fmt.Sprint(key), toSlice(baseValue), toSlice(overrideValue))
+			conflicts = append(conflicts, namedConflicts...)
+		default:
+			conflicts = append(conflicts, fmt.Sprintf("%v is defined differently in both configurations", key))
+		}
+
+		merged = append(merged, yaml.MapItem{Key: key, Value: value})
+	}
+
+	out, err := yaml.Marshal(merged)
+	if err != nil {
+		return base, []string{fmt.Sprintf("the merged configuration can't be serialized: %v", err)}
+	}
+
+	templates := slices.Clone(base.Templates)
+	for _, tmpl := range override.Templates {
+		idx := slices.IndexFunc(templates, func(t *alertspb.TemplateDesc) bool { return t.Filename == tmpl.Filename })
+		switch {
+		case idx < 0:
+			templates = append(templates, tmpl)
+		case templates[idx].Body != tmpl.Body:
+			conflicts = append(conflicts, fmt.Sprintf("the template file %s is defined differently in both configurations", tmpl.Filename))
+		}
+	}
+
+	return alertspb.AlertConfigDesc{
+		User:      override.User,
+		RawConfig: string(out),
+		Templates: templates,
+	}, conflicts
+}
+
+// mergeRoutes returns the base route with the override route appended to its child routes. Since
+// the override route has no matchers, it matches all the alerts not stopped by a base child route.
+func mergeRoutes(base, override any) any {
+	baseRoute, ok := base.(yaml.MapSlice)
+	if !ok {
+		return base
+	}
+
+	children, _ := lookupMapSlice(baseRoute, "routes")
+	children = append(toSlice(children), override)
+
+	merged := make(yaml.MapSlice, 0, len(baseRoute)+1)
+	for _, item := range baseRoute {
+		if item.Key != "routes" {
+			merged = append(merged, item)
+		}
+	}
+	return append(merged, yaml.MapItem{Key: "routes", Value: children})
+}
+
+// mergeNamedItems returns the union by name of the base and override items, keeping the base item
+// for the names defined in both.
+func mergeNamedItems(key string, base, override []any) ([]any, []string) {
+	var (
+		merged    = slices.Clone(base)
+		conflicts []string
+	)
+
+	for _, item := range override {
+		name := itemName(item)
+		idx := slices.IndexFunc(merged, func(m any) bool { return itemName(m) == name })
+		switch {
+		case idx < 0:
+			merged = append(merged, item)
+		case !reflect.DeepEqual(merged[idx], item):
+			conflicts = append(conflicts, fmt.Sprintf("%s %v is defined differently in both configurations", key, name))
+		}
+	}
+	return merged, conflicts
+}
+
+func itemName(item any) any {
+	if m, ok := item.(yaml.MapSlice); ok {
+		name, _ := lookupMapSlice(m, "name")
+		return name
+	}
+	return nil
+}
+
+func lookupMapSlice(m yaml.MapSlice, key any) (any, bool) {
+	for _, item := range m {
+		if item.Key == key {
+			return item.Value, true
+		}
+	}
+	return nil, false
+}
+
+func toSlice(v any) []any {
+	s, _ := v.([]any)
+	return s
+}
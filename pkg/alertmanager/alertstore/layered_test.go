@@ -0,0 +1,189 @@
+package alertstore
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/alertmanager/config"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/objstore"
+
+	"github.com/cortexproject/cortex/pkg/alertmanager/alertspb"
+	"github.com/cortexproject/cortex/pkg/alertmanager/alertstore/bucketclient"
+	"github.com/cortexproject/cortex/pkg/storage/bucket"
+	"github.com/cortexproject/cortex/pkg/util/users"
+)
+
+const (
+	layeredBaseConfig = `
+route:
+  receiver: org-pager
+  routes:
+    - receiver: org-pager
+      matchers: ['severity="critical"']
+      continue: true
+receivers:
+  - name: org-pager
+    webhook_configs:
+      - url: http://org-pager/
+  - name: shared
+    webhook_configs:
+      - url: http://org-shared/
+inhibit_rules:
+  - source_matchers: ['alertname="Base"']
+    target_matchers: ['severity="warning"']
+`
+
+	layeredOverrideConfig = `
+route:
+  receiver: team
+receivers:
+  - name: team
+    webhook_configs:
+      - url: http://team/
+  - name: shared
+    webhook_configs:
+      - url: http://team-shared/
+inhibit_rules:
+  - source_matchers: ['alertname="Override"']
+    target_matchers: ['severity="info"']
+`
+)
+
+func TestMergeAlertConfigs(t *testing.T) {
+	base := alertspb.AlertConfigDesc{
+		User:      "user-1",
+		RawConfig: layeredBaseConfig,
+		Templates: []*alertspb.TemplateDesc{{Filename: "org.tmpl", Body: "org"}, {Filename: "common.tmpl", Body: "base"}},
+	}
+	override := alertspb.AlertConfigDesc{
+		User:      "user-1",
+		RawConfig: layeredOverrideConfig,
+		Templates: []*alertspb.TemplateDesc{{Filename: "team.tmpl", Body: "team"}, {Filename: "common.tmpl", Body: "override"}},
+	}
+
+	merged, conflicts := mergeAlertConfigs(base, override)
+	assert.Equal(t, []string{
+		"receivers shared is defined differently in both configurations",
+		"the template file common.tmpl is defined differently in both configurations",
+	}, conflicts)
+	assert.Equal(t, []*alertspb.TemplateDesc{
+		{Filename: "org.tmpl", Body: "org"},
+		{Filename: "common.tmpl", Body: "base"},
+		{Filename: "team.tmpl", Body: "team"},
+	}, merged.Templates)
+
+	cfg, err := config.Load(merged.RawConfig)
+	require.NoError(t, err)
+
+	receivers := make([]string, 0, len(cfg.Receivers))
+	for _, r := range cfg.Receivers {
+		receivers = append(receivers, r.Name)
+	}
+	assert.Equal(t, []string{"org-pager", "shared", "team"}, receivers)
+	assert.Equal(t, "http://org-shared/", string(cfg.Receivers[1].WebhookConfigs[0].URL))
+
+	require.Len(t, cfg.Route.Routes, 2)
+	assert.Equal(t, "org-pager", cfg.Route.Receiver)
+	assert.Equal(t, "org-pager", cfg.Route.Routes[0].Receiver)
+	assert.Equal(t, "team", cfg.Route.Routes[1].Receiver)
+	assert.Empty(t, cfg.Route.Routes[1].Matchers)
+
+	assert.Len(t, cfg.InhibitRules, 2)
+
+	// Merging the same configurations again must give the same result.
+	again, _ := mergeAlertConfigs(base, override)
+	assert.Equal(t, merged, again)
+}
+
+func TestMergeAlertConfigs_InvalidConfig(t *testing.T) {
+	base := alertspb.AlertConfigDesc{User: "user-1", RawConfig: layeredBaseConfig}
+
+	merged, conflicts := mergeAlertConfigs(base, alertspb.AlertConfigDesc{User: "user-1", RawConfig: "route: ["})
+	assert.Equal(t, base, merged)
+	require.Len(t, conflicts, 1)
+	assert.True(t, strings.HasPrefix(conflicts[0], "the override configuration can't be parsed"))
+}
+
+func TestLayeredAlertStore(t *testing.T) {
+	ctx := context.Background()
+	bkt := &MockBucket{Bucket: objstore.NewInMemBucket()}
+	usersScannerCfg := users.UsersScannerConfig{Strategy: users.UserScanStrategyList}
+
+	baseStore, err := bucketclient.NewBucketAlertStore(bucket.NewPrefixedBucketClient(bkt, "base"), usersScannerCfg, nil, log.NewNopLogger(), nil)
+	require.NoError(t, err)
+	overrideStore, err := bucketclient.NewBucketAlertStore(bkt, usersScannerCfg, nil, log.NewNopLogger(), nil)
+	require.NoError(t, err)
+
+	reg := prometheus.NewPedanticRegistry()
+	store := NewLayeredAlertStore(baseStore, overrideStore, log.NewNopLogger(), reg)
+
+	require.NoError(t, baseStore.SetAlertConfig(ctx, alertspb.AlertConfigDesc{User: "user-1", RawConfig: layeredBaseConfig}))
+	require.NoError(t, baseStore.SetAlertConfig(ctx, alertspb.AlertConfigDesc{User: "user-2", RawConfig: layeredBaseConfig}))
+
+	// The writes go to the override store.
+	require.NoError(t, store.SetAlertConfig(ctx, alertspb.AlertConfigDesc{User: "user-1", RawConfig: layeredOverrideConfig}))
+	require.NoError(t, store.SetAlertConfig(ctx, alertspb.AlertConfigDesc{User: "user-3", RawConfig: layeredOverrideConfig}))
+	exists, err := bkt.Exists(ctx, "alerts/user-1")
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	userIDs, err := store.ListAllUsers(ctx)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"user-1", "user-2", "user-3"}, userIDs)
+
+	expectedMerged, _ := mergeAlertConfigs(
+		alertspb.AlertConfigDesc{User: "user-1", RawConfig: layeredBaseConfig},
+		alertspb.AlertConfigDesc{User: "user-1", RawConfig: layeredOverrideConfig},
+	)
+
+	cfgs, err := store.GetAlertConfigs(ctx, []string{"user-1", "user-2", "user-3", "user-4"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]alertspb.AlertConfigDesc{
+		"user-1": expectedMerged,
+		"user-2": {User: "user-2", RawConfig: layeredBaseConfig},
+		"user-3": {User: "user-3", RawConfig: layeredOverrideConfig},
+	}, cfgs)
+
+	// A single configuration is read from the override store only, since it's the one managed by the tenant.
+	for userID, expected := range map[string]alertspb.AlertConfigDesc{
+		"user-1": {User: "user-1", RawConfig: layeredOverrideConfig},
+		"user-3": {User: "user-3", RawConfig: layeredOverrideConfig},
+	} {
+		cfg, err := store.GetAlertConfig(ctx, userID)
+		require.NoError(t, err)
+		assert.Equal(t, expected, cfg)
+	}
+
+	for _, userID := range []string{"user-2", "user-4"} {
+		_, err = store.GetAlertConfig(ctx, userID)
+		assert.Equal(t, alertspb.ErrNotFound, err)
+	}
+
+	// An override configuration is merged with the base configuration of the tenant, if any.
+	for userID, expected := range map[string]alertspb.AlertConfigDesc{
+		"user-1": expectedMerged,
+		"user-3": {User: "user-3", RawConfig: layeredOverrideConfig},
+	} {
+		merged, err := store.MergeBaseAlertConfig(ctx, alertspb.AlertConfigDesc{User: userID, RawConfig: layeredOverrideConfig})
+		require.NoError(t, err)
+		assert.Equal(t, expected, merged)
+	}
+
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP cortex_alertmanager_config_merge_conflicts Number of conflicts found merging the base and the override alertmanager configuration of the user, at the last merge.
+		# TYPE cortex_alertmanager_config_merge_conflicts gauge
+		cortex_alertmanager_config_merge_conflicts{user="user-1"} 1
+	`), "cortex_alertmanager_config_merge_conflicts"))
+
+	// Deleting the override configuration falls back to the base one.
+	require.NoError(t, store.DeleteAlertConfig(ctx, "user-1"))
+	cfgs, err = store.GetAlertConfigs(ctx, []string{"user-1"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]alertspb.AlertConfigDesc{"user-1": {User: "user-1", RawConfig: layeredBaseConfig}}, cfgs)
+}
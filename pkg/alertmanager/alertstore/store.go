@@ -20,6 +20,8 @@ import (
 
 var (
 	errAccessDenied = fmt.Errorf("access denied")
+
+	errBaseConfigPrefixUnsupported = fmt.Errorf("the alertmanager base configurations are supported only by the object storage backends")
 )
 
 // AlertStore stores and configures users rule configs
@@ -61,6 +63,10 @@ type AlertStore interface {
 
 // NewAlertStore returns a alertmanager store backend client based on the provided cfg.
 func NewAlertStore(ctx context.Context, cfg Config, cfgProvider bucket.TenantConfigProvider, logger log.Logger, reg prometheus.Registerer) (AlertStore, error) {
+	if cfg.BaseConfigPrefix != "" && !cfg.IsFullStateSupported() {
+		return nil, errBaseConfigPrefixUnsupported
+	}
+
 	if cfg.Backend == configdb.Name {
 		c, err := client.New(cfg.ConfigDB)
 		if err != nil {
@@ -78,7 +84,20 @@ func NewAlertStore(ctx context.Context, cfg Config, cfgProvider bucket.TenantCon
 		return nil, err
 	}
 
-	return bucketclient.NewBucketAlertStore(bucketClient, cfg.UsersScanner, cfgProvider, logger, reg)
+	store, err := bucketclient.NewBucketAlertStore(bucketClient, cfg.UsersScanner, cfgProvider, logger, reg)
+	if err != nil || cfg.BaseConfigPrefix == "" {
+		return store, err
+	}
+
+	// The base configurations are listed without exporting the users scanner metrics, which
+	// would clash with the ones of the API-managed configurations.
+	baseBucketClient := bucket.NewPrefixedBucketClient(bucketClient, cfg.BaseConfigPrefix)
+	baseStore, err := bucketclient.NewBucketAlertStore(baseBucketClient, users.UsersScannerConfig{Strategy: users.UserScanStrategyList}, cfgProvider, logger, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewLayeredAlertStore(baseStore, store, logger, reg), nil
 }
 
 type MockBucket struct {
@@ -5,10 +5,12 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
 	"reflect"
 	"strings"
+	"time"
 
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
@@ -17,31 +19,44 @@ import (
 	amcommoncfg "github.com/prometheus/alertmanager/config/common"
 	"github.com/prometheus/alertmanager/template"
 	amtracing "github.com/prometheus/alertmanager/tracing"
+	"github.com/prometheus/alertmanager/types"
 	commoncfg "github.com/prometheus/common/config"
+	"github.com/prometheus/common/model"
 	"gopkg.in/yaml.v2"
 
 	"github.com/cortexproject/cortex/pkg/alertmanager/alertspb"
 	"github.com/cortexproject/cortex/pkg/util"
 	"github.com/cortexproject/cortex/pkg/util/concurrency"
 	util_log "github.com/cortexproject/cortex/pkg/util/log"
+	util_net "github.com/cortexproject/cortex/pkg/util/net"
 	"github.com/cortexproject/cortex/pkg/util/users"
 )
 
 const (
-	errMarshallingYAML       = "error marshalling YAML Alertmanager config"
-	errValidatingConfig      = "error validating Alertmanager config"
-	errReadingConfiguration  = "unable to read the Alertmanager config"
-	errStoringConfiguration  = "unable to store the Alertmanager config"
-	errDeletingConfiguration = "unable to delete the Alertmanager config"
-	errNoOrgID               = "unable to determine the OrgID"
-	errListAllUser           = "unable to list the Alertmanager users"
-	errConfigurationTooBig   = "Alertmanager configuration is too big, limit: %d bytes"
-	errTooManyTemplates      = "too many templates in the configuration: %d (limit: %d)"
-	errTemplateTooBig        = "template %s is too big: %d bytes (limit: %d bytes)"
+	errMarshallingYAML        = "error marshalling YAML Alertmanager config"
+	errValidatingConfig       = "error validating Alertmanager config"
+	errValidatingMergedConfig = "error validating Alertmanager config merged with the base config"
+	errMergingBaseConfig      = "unable to merge the Alertmanager config with the base config"
+	errReadingConfiguration   = "unable to read the Alertmanager config"
+	errStoringConfiguration   = "unable to store the Alertmanager config"
+	errDeletingConfiguration  = "unable to delete the Alertmanager config"
+	errNoOrgID                = "unable to determine the OrgID"
+	errListAllUser            = "unable to list the Alertmanager users"
+	errConfigurationTooBig    = "Alertmanager configuration is too big, limit: %d bytes"
+	errTooManyTemplates       = "too many templates in the configuration: %d (limit: %d)"
+	errTemplateTooBig         = "template %s is too big: %d bytes (limit: %d bytes)"
+	errEmptyTestTemplate      = "template to render is empty"
+	errRenderingTemplate      = "error rendering template"
 
 	fetchConcurrency = 16
 )
 
+// baseAlertConfigMerger is implemented by the alert stores layering the tenant configurations
+// on top of base configurations.
+type baseAlertConfigMerger interface {
+	MergeBaseAlertConfig(ctx context.Context, cfg alertspb.AlertConfigDesc) (alertspb.AlertConfigDesc, error)
+}
+
 var (
 	// errBearerTokenAndCredentialsFileNotAllowed covers both bearer_token_file and credentials_file
 	// because prometheus/common normalizes bearer_token_file to authorization.credentials_file
@@ -82,6 +97,28 @@ type UserConfig struct {
 	AlertmanagerConfig string            `yaml:"alertmanager_config"`
 }
 
+// TestTemplateRequest is used to render a template against sample alerts.
+type TestTemplateRequest struct {
+	Template    string              `yaml:"template"`
+	Receiver    string              `yaml:"receiver"`
+	GroupLabels map[string]string   `yaml:"group_labels"`
+	Alerts      []TestTemplateAlert `yaml:"alerts"`
+}
+
+// TestTemplateAlert is a sample alert used to render a template.
+type TestTemplateAlert struct {
+	Labels       map[string]string `yaml:"labels"`
+	Annotations  map[string]string `yaml:"annotations"`
+	StartsAt     time.Time         `yaml:"starts_at"`
+	EndsAt       time.Time         `yaml:"ends_at"`
+	GeneratorURL string            `yaml:"generator_url"`
+}
+
+// TestTemplateResponse holds the output of a rendered template.
+type TestTemplateResponse struct {
+	Output string `yaml:"output"`
+}
+
 func (am *MultitenantAlertmanager) GetUserConfig(w http.ResponseWriter, r *http.Request) {
 	logger := util_log.WithContext(r.Context(), am.logger)
 
@@ -171,6 +208,21 @@ func (am *MultitenantAlertmanager) SetUserConfig(w http.ResponseWriter, r *http.
 		return
 	}
 
+	// The alertmanager runs the configuration merged with the base one, so it must be valid too.
+	if merger, ok := am.store.(baseAlertConfigMerger); ok {
+		merged, err := merger.MergeBaseAlertConfig(r.Context(), cfgDesc)
+		if err != nil {
+			level.Error(logger).Log("msg", errMergingBaseConfig, "err", err.Error())
+			http.Error(w, fmt.Sprintf("%s: %s", errMergingBaseConfig, err.Error()), http.StatusInternalServerError)
+			return
+		}
+		if err := validateUserConfig(logger, merged, am.limits, userID); err != nil {
+			level.Warn(logger).Log("msg", errValidatingMergedConfig, "err", err.Error())
+			http.Error(w, fmt.Sprintf("%s: %s", errValidatingMergedConfig, err.Error()), http.StatusBadRequest)
+			return
+		}
+	}
+
 	err = am.store.SetAlertConfig(r.Context(), cfgDesc)
 	if err != nil {
 		level.Error(logger).Log("msg", errStoringConfiguration, "err", err.Error())
@@ -202,6 +254,122 @@ func (am *MultitenantAlertmanager) DeleteUserConfig(w http.ResponseWriter, r *ht
 	w.WriteHeader(http.StatusOK)
 }
 
+// TestTemplate renders a template against sample alerts. Templates defined in the tenant's
+// stored configuration can be referenced by the rendered template. The stored configuration
+// is never modified.
+func (am *MultitenantAlertmanager) TestTemplate(w http.ResponseWriter, r *http.Request) {
+	logger := util_log.WithContext(r.Context(), am.logger)
+	userID, err := users.TenantID(r.Context())
+	if err != nil {
+		level.Error(logger).Log("msg", errNoOrgID, "err", err.Error())
+		http.Error(w, fmt.Sprintf("%s: %s", errNoOrgID, err.Error()), http.StatusUnauthorized)
+		return
+	}
+
+	payload, err := io.ReadAll(r.Body)
+	if err != nil {
+		level.Error(logger).Log("msg", errReadingConfiguration, "err", err.Error())
+		http.Error(w, fmt.Sprintf("%s: %s", errReadingConfiguration, err.Error()), http.StatusBadRequest)
+		return
+	}
+
+	req := &TestTemplateRequest{}
+	if err := yaml.Unmarshal(payload, req); err != nil {
+		level.Error(logger).Log("msg", errMarshallingYAML, "err", err.Error())
+		http.Error(w, fmt.Sprintf("%s: %s", errMarshallingYAML, err.Error()), http.StatusBadRequest)
+		return
+	}
+
+	if req.Template == "" {
+		http.Error(w, errEmptyTestTemplate, http.StatusBadRequest)
+		return
+	}
+
+	if maxSize := am.limits.AlertmanagerMaxTemplateSize(userID); maxSize > 0 && len(req.Template) > maxSize {
+		http.Error(w, fmt.Sprintf(errTemplateTooBig, "to render", len(req.Template), maxSize), http.StatusBadRequest)
+		return
+	}
+
+	tmpl, err := am.loadStoredUserTemplates(r.Context(), logger, userID)
+	if err != nil {
+		switch err {
+		case alertspb.ErrAccessDenied:
+			http.Error(w, err.Error(), http.StatusForbidden)
+		default:
+			level.Error(logger).Log("msg", errReadingConfiguration, "err", err.Error())
+			http.Error(w, fmt.Sprintf("%s: %s", errReadingConfiguration, err.Error()), http.StatusInternalServerError)
+		}
+		return
+	}
+	tmpl.ExternalURL = am.cfg.ExternalURL.URL
+
+	now := time.Now()
+	alerts := make([]*types.Alert, 0, len(req.Alerts))
+	for _, a := range req.Alerts {
+		alert := &types.Alert{Alert: model.Alert{
+			Labels:       toLabelSet(a.Labels),
+			Annotations:  toLabelSet(a.Annotations),
+			StartsAt:     a.StartsAt,
+			EndsAt:       a.EndsAt,
+			GeneratorURL: a.GeneratorURL,
+		}}
+		if alert.StartsAt.IsZero() {
+			alert.StartsAt = now
+		}
+		alerts = append(alerts, alert)
+	}
+
+	data := tmpl.Data(req.Receiver, toLabelSet(req.GroupLabels), "", alerts...)
+	output, err := tmpl.ExecuteTextString(req.Template, data)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("%s: %s", errRenderingTemplate, err.Error()), http.StatusBadRequest)
+		return
+	}
+
+	d, err := yaml.Marshal(&TestTemplateResponse{Output: output})
+	if err != nil {
+		level.Error(logger).Log("msg", errMarshallingYAML, "err", err, "user", userID)
+		http.Error(w, fmt.Sprintf("%s: %s", errMarshallingYAML, err.Error()), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/yaml")
+	if _, err := w.Write(d); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// loadStoredUserTemplates parses the templates of the tenant's stored configuration. If the
+// tenant has no configuration, only the default Alertmanager templates are loaded.
+func (am *MultitenantAlertmanager) loadStoredUserTemplates(ctx context.Context, logger log.Logger, userID string) (*template.Template, error) {
+	// Load the configuration the same way as the alertmanagers do, so that the templates of
+	// the base configuration are loaded too when the alert store is layered.
+	cfgs, err := am.store.GetAlertConfigs(ctx, []string{userID})
+	if err != nil {
+		return nil, err
+	}
+	cfg, ok := cfgs[userID]
+	if !ok {
+		return template.FromGlobs(nil)
+	}
+
+	amCfg, err := config.Load(cfg.RawConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	return loadUserTemplates(logger, cfg, amCfg)
+}
+
+func toLabelSet(m map[string]string) model.LabelSet {
+	ls := make(model.LabelSet, len(m))
+	for k, v := range m {
+		ls[model.LabelName(k)] = model.LabelValue(v)
+	}
+	return ls
+}
+
 // Partially copied from: https://github.com/prometheus/alertmanager/blob/8e861c646bf67599a1704fc843c6a94d519ce312/cli/check_config.go#L65-L96
 func validateUserConfig(logger log.Logger, cfg alertspb.AlertConfigDesc, limits Limits, user string) error {
 	// We don't have a valid use case for empty configurations. If a tenant does not have a
@@ -222,6 +390,12 @@ func validateUserConfig(logger log.Logger, cfg alertspb.AlertConfigDesc, limits
 		return err
 	}
 
+	// Validate the receivers targets against the receivers firewall. The targets are checked
+	// again when sending the notifications, because their DNS resolution can change.
+	if err := validateReceiversFirewall(amCfg, limits, user); err != nil {
+		return err
+	}
+
 	// Validate templates referenced in the alertmanager config.
 	for _, name := range amCfg.Templates {
 		if err := validateTemplateFilename(name); err != nil {
@@ -249,6 +423,21 @@ func validateUserConfig(logger log.Logger, cfg alertspb.AlertConfigDesc, limits
 		}
 	}
 
+	if _, err := loadUserTemplates(logger, cfg, amCfg); err != nil {
+		return err
+	}
+
+	// Note: Not validating the MultitenantAlertmanager.transformConfig function as that
+	// that function shouldn't break configuration. Only way it can fail is if the base
+	// autoWebhookURL itself is broken. In that case, I would argue, we should accept the config
+	// not reject it.
+
+	return nil
+}
+
+// loadUserTemplates parses the templates referenced by the Alertmanager config, together with
+// the default Alertmanager templates, without touching the tenant's data directory.
+func loadUserTemplates(logger log.Logger, cfg alertspb.AlertConfigDesc, amCfg *config.Config) (*template.Template, error) {
 	// Create templates on disk in a temporary directory.
 	// Note: This means the validation will succeed if we can write to tmp but
 	// not to configured data dir, and on the flipside, it'll fail if we can't write
@@ -256,7 +445,7 @@ func validateUserConfig(logger log.Logger, cfg alertspb.AlertConfigDesc, limits
 	// we see this in the wild.
 	userTempDir, err := os.MkdirTemp("", "validate-config-"+cfg.User)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer os.RemoveAll(userTempDir)
 
@@ -264,12 +453,12 @@ func validateUserConfig(logger log.Logger, cfg alertspb.AlertConfigDesc, limits
 		templateFilepath, err := safeTemplateFilepath(userTempDir, tmpl.Filename)
 		if err != nil {
 			level.Error(logger).Log("msg", "unable to create template file path", "err", err, "user", cfg.User)
-			return err
+			return nil, err
 		}
 
 		if _, err = storeTemplateFile(templateFilepath, tmpl.Body); err != nil {
 			level.Error(logger).Log("msg", "unable to store template file", "err", err, "user", cfg.User)
-			return fmt.Errorf("unable to store template file '%s'", tmpl.Filename)
+			return nil, fmt.Errorf("unable to store template file '%s'", tmpl.Filename)
 		}
 	}
 
@@ -278,17 +467,7 @@ func validateUserConfig(logger log.Logger, cfg alertspb.AlertConfigDesc, limits
 		templateFiles[i] = filepath.Join(userTempDir, t)
 	}
 
-	_, err = template.FromGlobs(templateFiles)
-	if err != nil {
-		return err
-	}
-
-	// Note: Not validating the MultitenantAlertmanager.transformConfig function as that
-	// that function shouldn't break configuration. Only way it can fail is if the base
-	// autoWebhookURL itself is broken. In that case, I would argue, we should accept the config
-	// not reject it.
-
-	return nil
+	return template.FromGlobs(templateFiles)
 }
 
 func (am *MultitenantAlertmanager) ListAllConfigs(w http.ResponseWriter, r *http.Request) {
@@ -378,12 +557,12 @@ var configValidators = map[reflect.Type]func(any) error{
 // first error or nil if validation succeeds.
 func validateAlertmanagerConfig(cfg any) error {
 	v := reflect.ValueOf(cfg)
-	t := v.Type()
 
-	// Skip invalid, the zero value or a nil pointer (checked by zero value).
+	// Skip invalid (eg. a nil interface), the zero value or a nil pointer (checked by zero value).
 	if !v.IsValid() || v.IsZero() {
 		return nil
 	}
+	t := v.Type()
 
 	// If the input config is a pointer then we need to get its value.
 	// At this point the pointer value can't be nil.
@@ -448,6 +627,76 @@ func validateAlertmanagerConfig(cfg any) error {
 	return nil
 }
 
+// validateReceiversFirewall returns an error if the host of any URL or SMTP smarthost in the
+// config is blocked by the receivers firewall of the user.
+func validateReceiversFirewall(amCfg *config.Config, limits Limits, user string) error {
+	cfgProvider := newFirewallDialerConfigProvider(user, limits)
+	for _, host := range receiverHosts(reflect.ValueOf(amCfg.Receivers)) {
+		if err := util_net.CheckFirewallHost(cfgProvider, host); err != nil {
+			return fmt.Errorf("receiver target %s is not allowed: %w", host, err)
+		}
+	}
+	return nil
+}
+
+// templateURLHosts returns the host of the template URL, unless it's templated: the templated hosts
+// are only known, and checked, when sending the notifications.
+func templateURLHosts(rawURL string) []string {
+	if strings.Contains(rawURL, "{{") {
+		return nil
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil
+	}
+	return []string{u.Hostname()}
+}
+
+// receiverHosts recursively scans the input config and returns the hosts of the URLs and SMTP smarthosts.
+func receiverHosts(v reflect.Value) []string {
+	// Skip invalid, the zero value or values which can't be converted to interface.
+	if !v.IsValid() || v.IsZero() || !v.CanInterface() {
+		return nil
+	}
+
+	switch u := v.Interface().(type) {
+	case amcommoncfg.URL:
+		return []string{u.Hostname()}
+	case amcommoncfg.SecretURL:
+		return []string{u.Hostname()}
+	case commoncfg.URL:
+		return []string{u.Hostname()}
+	case config.HostPort:
+		return []string{u.Host}
+	case config.SlackConfig:
+		// The app URL is always set from the global config, but only used as the API URL with app tokens.
+		return append(receiverHosts(reflect.ValueOf(u.APIURL)), receiverHosts(reflect.ValueOf(u.HTTPConfig))...)
+	case config.SecretTemplateURL:
+		return templateURLHosts(string(u))
+	case amcommoncfg.SecretTemplateURL:
+		return templateURLHosts(string(u))
+	}
+
+	var hosts []string
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		hosts = receiverHosts(v.Elem())
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			hosts = append(hosts, receiverHosts(v.Field(i))...)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			hosts = append(hosts, receiverHosts(v.Index(i))...)
+		}
+	case reflect.Map:
+		for _, key := range v.MapKeys() {
+			hosts = append(hosts, receiverHosts(v.MapIndex(key))...)
+		}
+	}
+	return hosts
+}
+
 // validateReceiverHTTPConfig validates the HTTP config and returns an error if it contains
 // settings not allowed by Cortex.
 func validateReceiverHTTPConfig(cfg commoncfg.HTTPClientConfig) error {
@@ -7,6 +7,7 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"testing"
 
 	"github.com/go-kit/log"
@@ -38,6 +39,9 @@ func TestAMConfigValidationAPI(t *testing.T) {
 		maxTemplates    int
 		maxTemplateSize int
 
+		receiversBlockPrivateAddresses bool
+		receiversAllowHosts            []string
+
 		response string
 		err      error
 	}{
@@ -1094,6 +1098,67 @@ alertmanager_config: |
     receiver: 'default-receiver'
 `,
 		},
+		{
+			name: "Should return error if a webhook targets a private address blocked by the receivers firewall",
+			cfg: `
+alertmanager_config: |
+  receivers:
+    - name: default-receiver
+      webhook_configs:
+        - url: http://10.0.0.1:8080/hook
+  route:
+    receiver: 'default-receiver'
+`,
+			receiversBlockPrivateAddresses: true,
+			err:                            fmt.Errorf("error validating Alertmanager config: receiver target 10.0.0.1 is not allowed: private address 10.0.0.1 is blocked: blocked address"),
+		},
+		{
+			name: "Should return error if an email smarthost targets a private address blocked by the receivers firewall",
+			cfg: `
+alertmanager_config: |
+  receivers:
+    - name: default-receiver
+      email_configs:
+        - to: test@example.com
+          from: test@example.com
+          smarthost: 127.0.0.1:25
+  route:
+    receiver: 'default-receiver'
+`,
+			receiversBlockPrivateAddresses: true,
+			err:                            fmt.Errorf("error validating Alertmanager config: receiver target 127.0.0.1 is not allowed: private address 127.0.0.1 is blocked: blocked address"),
+		},
+		{
+			name: "Should return error if a receiver targets a host not allowed by the receivers firewall",
+			cfg: `
+alertmanager_config: |
+  receivers:
+    - name: default-receiver
+      slack_configs:
+        - api_url: https://hooks.slack.com/services/test
+          channel: test
+      webhook_configs:
+        - url: http://internal.example.com/hook
+  route:
+    receiver: 'default-receiver'
+`,
+			receiversAllowHosts: []string{"hooks.slack.com"},
+			err:                 fmt.Errorf("error validating Alertmanager config: receiver target internal.example.com is not allowed: host internal.example.com is not allowed: blocked address"),
+		},
+		{
+			name: "Should pass if the receivers target the hosts allowed by the receivers firewall",
+			cfg: `
+alertmanager_config: |
+  receivers:
+    - name: default-receiver
+      slack_configs:
+        - api_url: https://hooks.slack.com/services/test
+          channel: test
+  route:
+    receiver: 'default-receiver'
+`,
+			receiversAllowHosts: []string{"hooks.slack.com"},
+		},
 	}
 
 	limits := &mockAlertManagerLimits{}
@@ -1109,6 +1174,8 @@ alertmanager_config: |
 			limits.maxConfigSize = tc.maxConfigSize
 			limits.maxTemplatesCount = tc.maxTemplates
 			limits.maxSizeOfTemplate = tc.maxTemplateSize
+			limits.receiversBlockPrivateAddresses = tc.receiversBlockPrivateAddresses
+			limits.receiversAllowHosts = tc.receiversAllowHosts
 
 			req := httptest.NewRequest(http.MethodPost, "http://alertmanager/api/v1/alerts", bytes.NewReader([]byte(tc.cfg)))
 			ctx := user.InjectOrgID(req.Context(), "testing")
@@ -1130,6 +1197,62 @@ alertmanager_config: |
 	}
 }
 
+func TestMultitenantAlertmanager_SetUserConfig_ShouldValidateTheConfigMergedWithTheBaseConfig(t *testing.T) {
+	ctx := user.InjectOrgID(context.Background(), "user-1")
+	baseStore, err := prepareInMemoryAlertStore()
+	require.NoError(t, err)
+	overrideStore, err := prepareInMemoryAlertStore()
+	require.NoError(t, err)
+
+	require.NoError(t, baseStore.SetAlertConfig(ctx, alertspb.AlertConfigDesc{
+		User:      "user-1",
+		RawConfig: "route:\n  receiver: org\nreceivers:\n  - name: org\n",
+		Templates: []*alertspb.TemplateDesc{{Filename: "org.tmpl", Body: `{{ define "org" }}org{{ end }}`}},
+	}))
+
+	am := &MultitenantAlertmanager{
+		store:  alertstore.NewLayeredAlertStore(baseStore, overrideStore, log.NewNopLogger(), nil),
+		logger: util_log.Logger,
+		limits: &mockAlertManagerLimits{maxTemplatesCount: 1},
+	}
+
+	setConfig := func(templates string) *http.Response {
+		cfg := `
+alertmanager_config: |
+  route:
+    receiver: team
+  receivers:
+    - name: team
+` + templates
+		req := httptest.NewRequest(http.MethodPost, "http://alertmanager/api/v1/alerts", bytes.NewReader([]byte(cfg)))
+		w := httptest.NewRecorder()
+		am.SetUserConfig(w, req.WithContext(ctx))
+		return w.Result()
+	}
+
+	// The tenant config is valid alone, but the merged config exceeds the templates limit.
+	resp := setConfig(`
+template_files:
+  team.tmpl: '{{ define "team" }}team{{ end }}'
+`)
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	require.Equal(t, errValidatingMergedConfig+": "+fmt.Sprintf(errTooManyTemplates, 2, 1)+"\n", string(body))
+
+	_, err = overrideStore.GetAlertConfig(ctx, "user-1")
+	require.Equal(t, alertspb.ErrNotFound, err)
+
+	resp = setConfig("")
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	// The stored config is the tenant one, not the merged one.
+	cfg, err := am.store.GetAlertConfig(ctx, "user-1")
+	require.NoError(t, err)
+	assert.Empty(t, cfg.Templates)
+	assert.NotContains(t, cfg.RawConfig, "org")
+}
+
 func TestMultitenantAlertmanager_DeleteUserConfig(t *testing.T) {
 	storage := objstore.NewInMemBucket()
 	bkt := &alertstore.MockBucket{Bucket: storage}
@@ -1180,6 +1303,118 @@ func TestMultitenantAlertmanager_DeleteUserConfig(t *testing.T) {
 	}
 }
 
+func TestMultitenantAlertmanager_TestTemplate(t *testing.T) {
+	store, err := prepareInMemoryAlertStore()
+	require.NoError(t, err)
+
+	externalURL, err := url.Parse("http://alertmanager.example.org")
+	require.NoError(t, err)
+
+	limits := &mockAlertManagerLimits{}
+	am := &MultitenantAlertmanager{
+		cfg:    &MultitenantAlertmanagerConfig{ExternalURL: flagext.URLValue{URL: externalURL}},
+		store:  store,
+		logger: util_log.Logger,
+		limits: limits,
+	}
+
+	cfg := alertspb.ToProto(`
+templates:
+  - 'custom.tpl'
+route:
+  receiver: 'default-receiver'
+receivers:
+  - name: default-receiver
+`, map[string]string{"custom.tpl": `{{ define "custom.title" }}[{{ .Status }}] {{ .CommonLabels.alertname }}{{ end }}`}, "user-with-config")
+	require.NoError(t, store.SetAlertConfig(context.Background(), cfg))
+
+	testCases := map[string]struct {
+		user             string
+		body             string
+		maxTemplateSize  int
+		expectedStatus   int
+		expectedResponse string
+	}{
+		"should render a template against sample alerts": {
+			user: "user-without-config",
+			body: `
+template: '{{ .Receiver }}: {{ len .Alerts.Firing }} firing, {{ .CommonAnnotations.summary }} ({{ .ExternalURL }})'
+receiver: receiver
+alerts:
+  - labels:
+      alertname: HighLatency
+    annotations:
+      summary: Latency is high
+`,
+			expectedStatus:   http.StatusOK,
+			expectedResponse: "output: 'receiver: 1 firing, Latency is high (http://alertmanager.example.org)'\n",
+		},
+		"should resolve templates defined in the tenant configuration": {
+			user: "user-with-config",
+			body: `
+template: '{{ template "custom.title" . }}'
+alerts:
+  - labels:
+      alertname: HighLatency
+    starts_at: 2024-01-01T00:00:00Z
+    ends_at: 2024-01-01T01:00:00Z
+`,
+			expectedStatus:   http.StatusOK,
+			expectedResponse: "output: '[resolved] HighLatency'\n",
+		},
+		"should return error if the template references undefined templates": {
+			user:             "user-without-config",
+			body:             `template: '{{ template "custom.title" . }}'`,
+			expectedStatus:   http.StatusBadRequest,
+			expectedResponse: `error rendering template: template: :1:12: executing "" at <{{template "custom.title" .}}>: template "custom.title" not defined` + "\n",
+		},
+		"should return error if the template references missing fields": {
+			user:             "user-without-config",
+			body:             `template: '{{ .Missing }}'`,
+			expectedStatus:   http.StatusBadRequest,
+			expectedResponse: `error rendering template: template: :1:3: executing "" at <.Missing>: can't evaluate field Missing in type *template.Data` + "\n",
+		},
+		"should return error if the template is empty": {
+			user:             "user-without-config",
+			body:             `receiver: receiver`,
+			expectedStatus:   http.StatusBadRequest,
+			expectedResponse: "template to render is empty\n",
+		},
+		"should return error if the template is too big": {
+			user:             "user-without-config",
+			body:             `template: '{{ .Receiver }}'`,
+			maxTemplateSize:  5,
+			expectedStatus:   http.StatusBadRequest,
+			expectedResponse: "template to render is too big: 15 bytes (limit: 5 bytes)\n",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			limits.maxSizeOfTemplate = tc.maxTemplateSize
+
+			req := httptest.NewRequest(http.MethodPost, "http://alertmanager/api/v1/alerts/test_template", bytes.NewReader([]byte(tc.body)))
+			ctx := user.InjectOrgID(req.Context(), tc.user)
+			w := httptest.NewRecorder()
+			am.TestTemplate(w, req.WithContext(ctx))
+			resp := w.Result()
+
+			body, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedStatus, resp.StatusCode)
+			require.Equal(t, tc.expectedResponse, string(body))
+		})
+	}
+
+	// The tenant configuration is left untouched.
+	storedCfg, err := store.GetAlertConfig(context.Background(), "user-with-config")
+	require.NoError(t, err)
+	require.Equal(t, cfg, storedCfg)
+
+	_, err = store.GetAlertConfig(context.Background(), "user-without-config")
+	require.Equal(t, alertspb.ErrNotFound, err)
+}
+
 func TestAMConfigListUserConfig(t *testing.T) {
 	testCases := map[string]*UserConfig{
 		"user1": {
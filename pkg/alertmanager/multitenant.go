@@ -84,6 +84,8 @@ type MultitenantAlertmanagerConfig struct {
 	APIConcurrency int           `yaml:"api_concurrency"`
 	GCInterval     time.Duration `yaml:"gc_interval"`
 
+	SilencePrincipalHeader string `yaml:"silence_principal_header"`
+
 	// For distributor.
 	AlertmanagerClient ClientConfig `yaml:"alertmanager_client"`
 
@@ -124,6 +126,7 @@ func (cfg *MultitenantAlertmanagerConfig) RegisterFlags(f *flag.FlagSet) {
 	f.BoolVar(&cfg.EnableAPI, "alertmanager.enable-api", false, "Enable the alertmanager config api.")
 	f.IntVar(&cfg.APIConcurrency, "alertmanager.api-concurrency", 0, "Maximum number of concurrent GET API requests before returning an error.")
 	f.DurationVar(&cfg.GCInterval, "alertmanager.alerts-gc-interval", 30*time.Minute, "Alertmanager alerts Garbage collection interval.")
+	f.StringVar(&cfg.SilencePrincipalHeader, "alertmanager.silence-principal-header", "", "EXPERIMENTAL: Name of the HTTP header carrying the identity of the principal issuing the request. If set, the principal creating or updating a silence is recorded in the `"+SilenceCreatorAnnotation+"` annotation of the silence, and the principal expiring a silence is logged. The principal is \"unknown\" if the request doesn't carry the header.")
 	f.BoolVar(&cfg.ShardingEnabled, "alertmanager.sharding-enabled", false, "Shard tenants across multiple alertmanager instances.")
 	f.Var(&cfg.EnabledTenants, "alertmanager.enabled-tenants", "Comma separated list of tenants whose alerts this alertmanager can process. If specified, only these tenants will be handled by alertmanager, otherwise this alertmanager can process alerts from all tenants.")
 	f.Var(&cfg.DisabledTenants, "alertmanager.disabled-tenants", "Comma separated list of tenants whose alerts this alertmanager cannot process. If specified, a alertmanager that would normally pick the specified tenant(s) for processing will ignore them instead.")
@@ -199,6 +202,20 @@ type Limits interface {
 	// in the Alertmanager receivers for the given user.
 	AlertmanagerReceiversBlockPrivateAddresses(user string) bool
 
+	// AlertmanagerReceiversBlockHosts returns the list of hosts that should be blocked
+	// in the Alertmanager receivers for the given user.
+	AlertmanagerReceiversBlockHosts(user string) []string
+
+	// AlertmanagerReceiversAllowCIDRNetworks returns the list of network CIDRs allowed
+	// in the Alertmanager receivers for the given user. If empty, all networks are allowed
+	// unless AlertmanagerReceiversAllowHosts is not empty.
+	AlertmanagerReceiversAllowCIDRNetworks(user string) []flagext.CIDR
+
+	// AlertmanagerReceiversAllowHosts returns the list of hosts allowed in the Alertmanager
+	// receivers for the given user. If empty, all hosts are allowed unless
+	// AlertmanagerReceiversAllowCIDRNetworks is not empty.
+	AlertmanagerReceiversAllowHosts(user string) []string
+
 	// NotificationRateLimit methods return limit used by rate-limiter for given integration.
 	// If set to 0, no notifications are allowed.
 	// rate.Inf = all notifications are allowed.
@@ -211,6 +228,10 @@ type Limits interface {
 	// when limit == rate.Inf.
 	NotificationBurstSize(tenant string, integration string) int
 
+	// NotificationRateLimitQueueSize returns the maximum number of rate-limited notifications per integration which wait
+	// for the rate limit to allow them, instead of being dropped immediately. 0 = rate-limited notifications are dropped.
+	NotificationRateLimitQueueSize(tenant string) int
+
 	// AlertmanagerMaxConfigSize returns max size of configuration file that user is allowed to upload. If 0, there is no limit.
 	AlertmanagerMaxConfigSize(tenant string) int
 
@@ -1006,6 +1027,10 @@ func (am *MultitenantAlertmanager) serveRequest(w http.ResponseWriter, req *http
 		http.Error(w, "Tenant is not allowed", http.StatusUnauthorized)
 		return
 	}
+	if am.cfg.SilencePrincipalHeader != "" {
+		am.auditSilenceRequest(userID, req)
+	}
+
 	am.alertmanagersMtx.Lock()
 	userAM, ok := am.alertmanagers[userID]
 	am.alertmanagersMtx.Unlock()
@@ -2472,6 +2472,7 @@ func (f *passthroughAlertmanagerClientPool) GetClientFor(addr string) (Client, e
 type mockAlertManagerLimits struct {
 	emailNotificationRateLimit     rate.Limit
 	emailNotificationBurst         int
+	notificationRateLimitQueueSize int
 	maxConfigSize                  int
 	maxTemplatesCount              int
 	maxSizeOfTemplate              int
@@ -2480,6 +2481,10 @@ type mockAlertManagerLimits struct {
 	maxAlertsSizeBytes             int
 	maxSilencesCount               int
 	maxSilencesSizeBytes           int
+	receiversBlockPrivateAddresses bool
+	receiversBlockHosts            []string
+	receiversAllowCIDRNetworks     []flagext.CIDR
+	receiversAllowHosts            []string
 }
 
 func (m *mockAlertManagerLimits) AlertmanagerMaxConfigSize(tenant string) int {
@@ -2495,11 +2500,23 @@ func (m *mockAlertManagerLimits) AlertmanagerMaxTemplateSize(tenant string) int
 }
 
 func (m *mockAlertManagerLimits) AlertmanagerReceiversBlockCIDRNetworks(user string) []flagext.CIDR {
-	panic("implement me")
+	return nil
 }
 
 func (m *mockAlertManagerLimits) AlertmanagerReceiversBlockPrivateAddresses(user string) bool {
-	panic("implement me")
+	return m.receiversBlockPrivateAddresses
+}
+
+func (m *mockAlertManagerLimits) AlertmanagerReceiversBlockHosts(user string) []string {
+	return m.receiversBlockHosts
+}
+
+func (m *mockAlertManagerLimits) AlertmanagerReceiversAllowCIDRNetworks(user string) []flagext.CIDR {
+	return m.receiversAllowCIDRNetworks
+}
+
+func (m *mockAlertManagerLimits) AlertmanagerReceiversAllowHosts(user string) []string {
+	return m.receiversAllowHosts
 }
 
 func (m *mockAlertManagerLimits) NotificationRateLimit(_ string, integration string) rate.Limit {
@@ -2510,6 +2527,10 @@ func (m *mockAlertManagerLimits) NotificationBurstSize(_ string, integration str
 	return m.emailNotificationBurst
 }
 
+func (m *mockAlertManagerLimits) NotificationRateLimitQueueSize(_ string) int {
+	return m.notificationRateLimitQueueSize
+}
+
 func (m *mockAlertManagerLimits) AlertmanagerMaxDispatcherAggregationGroups(_ string) int {
 	return m.maxDispatcherAggregationGroups
 }
@@ -5,6 +5,8 @@ import (
 	"errors"
 	"time"
 
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
 	"github.com/prometheus/alertmanager/alert"
 	"github.com/prometheus/alertmanager/notify"
 	"github.com/prometheus/client_golang/prometheus"
@@ -15,23 +17,32 @@ import (
 type rateLimits interface {
 	RateLimit() rate.Limit
 	Burst() int
+	// QueueSize returns the max number of rate-limited notifications waiting for the rate limit. 0 = no queue.
+	QueueSize() int
 }
 
 type rateLimitedNotifier struct {
-	upstream notify.Notifier
-	counter  prometheus.Counter
+	upstream       notify.Notifier
+	counter        prometheus.Counter
+	delayedCounter prometheus.Counter
+	logger         log.Logger
 
 	limiter *rate.Limiter
 	limits  rateLimits
 
+	// Number of rate-limited notifications currently waiting for the rate limit.
+	queued atomic.Int64
+
 	recheckInterval time.Duration
 	recheckAt       atomic.Int64 // unix nanoseconds timestamp
 }
 
-func newRateLimitedNotifier(upstream notify.Notifier, limits rateLimits, recheckInterval time.Duration, counter prometheus.Counter) *rateLimitedNotifier {
+func newRateLimitedNotifier(upstream notify.Notifier, limits rateLimits, recheckInterval time.Duration, counter, delayedCounter prometheus.Counter, logger log.Logger) *rateLimitedNotifier {
 	return &rateLimitedNotifier{
 		upstream:        upstream,
 		counter:         counter,
+		delayedCounter:  delayedCounter,
+		logger:          logger,
 		limits:          limits,
 		limiter:         rate.NewLimiter(limits.RateLimit(), limits.Burst()),
 		recheckInterval: recheckInterval,
@@ -55,11 +66,37 @@ func (r *rateLimitedNotifier) Notify(ctx context.Context, alerts ...*alert.Alert
 	}
 
 	// This counts as single notification, no matter how many alerts there are in it.
-	if !r.limiter.AllowN(now, 1) {
+	if !r.limiter.AllowN(now, 1) && !r.waitInQueue(ctx) {
 		r.counter.Inc()
+		level.Warn(r.logger).Log("msg", "dropped notification due to rate limits", "alerts", len(alerts))
 		// Don't retry this notification later.
 		return false, errRateLimited
 	}
 
 	return r.upstream.Notify(ctx, alerts...)
 }
+
+// waitInQueue waits until the rate limit allows the notification to be sent, as long as there's
+// room in the queue. Returns false if the notification should be dropped, because the queue is
+// full or the notification can't be sent before the context deadline.
+func (r *rateLimitedNotifier) waitInQueue(ctx context.Context) bool {
+	queueSize := int64(r.limits.QueueSize())
+	for {
+		queued := r.queued.Load()
+		if queued >= queueSize {
+			return false
+		}
+		if r.queued.CompareAndSwap(queued, queued+1) {
+			break
+		}
+	}
+	defer r.queued.Dec()
+
+	// Wait returns an error immediately if the notification can't be sent before the context deadline.
+	if err := r.limiter.Wait(ctx); err != nil {
+		return false
+	}
+
+	r.delayedCounter.Inc()
+	return true
+}
@@ -2,14 +2,19 @@ package alertmanager
 
 import (
 	"context"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/go-kit/log"
 	"github.com/prometheus/alertmanager/alert"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"golang.org/x/time/rate"
+
+	"github.com/cortexproject/cortex/pkg/util/test"
 )
 
 func TestRateLimitedNotifier(t *testing.T) {
@@ -18,7 +23,7 @@ func TestRateLimitedNotifier(t *testing.T) {
 
 	// Initial limits.
 	limiter := &limiter{limit: 5, burst: 5}
-	rateLimitedNotifier := newRateLimitedNotifier(mock, limiter, 10*time.Second, counter)
+	rateLimitedNotifier := newRateLimitedNotifier(mock, limiter, 10*time.Second, counter, prometheus.NewCounter(prometheus.CounterOpts{}), log.NewNopLogger())
 
 	runNotifications(t, rateLimitedNotifier, counter, 10, 5, 5, 5)
 
@@ -38,6 +43,61 @@ func TestRateLimitedNotifier(t *testing.T) {
 	runNotifications(t, rateLimitedNotifier, counter, 10, 5, 5, 20)
 }
 
+func TestRateLimitedNotifier_ShouldQueueRateLimitedNotifications(t *testing.T) {
+	mock := &mockNotifier{}
+	counter := prometheus.NewCounter(prometheus.CounterOpts{})
+	delayedCounter := prometheus.NewCounter(prometheus.CounterOpts{})
+
+	// Allow 1 notification every 100ms, and queue up to 2 rate-limited notifications.
+	limiter := &limiter{limit: 10, burst: 1, queueSize: 2}
+	rateLimitedNotifier := newRateLimitedNotifier(mock, limiter, 10*time.Second, counter, delayedCounter, log.NewNopLogger())
+
+	// The first notification is allowed by the burst.
+	_, err := rateLimitedNotifier.Notify(context.Background(), &alert.Alert{})
+	require.NoError(t, err)
+
+	// Rate-limited notifications wait in the queue, while the queue isn't full.
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	wg := sync.WaitGroup{}
+	errs := make(chan error, 2)
+	for range 2 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := rateLimitedNotifier.Notify(ctx, &alert.Alert{})
+			errs <- err
+		}()
+	}
+
+	test.Poll(t, time.Second, int64(2), func() any {
+		return rateLimitedNotifier.queued.Load()
+	})
+
+	// Once the queue is full, rate-limited notifications are dropped.
+	_, err = rateLimitedNotifier.Notify(ctx, &alert.Alert{})
+	assert.Equal(t, errRateLimited, err)
+	assert.Equal(t, 1, int(testutil.ToFloat64(counter)))
+
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, 2, int(testutil.ToFloat64(delayedCounter)))
+	assert.Equal(t, int64(0), rateLimitedNotifier.queued.Load())
+
+	// Queued notifications which can't be sent before the context deadline are dropped.
+	shortCtx, shortCancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer shortCancel()
+
+	_, err = rateLimitedNotifier.Notify(shortCtx, &alert.Alert{})
+	assert.Equal(t, errRateLimited, err)
+	assert.Equal(t, 2, int(testutil.ToFloat64(counter)))
+	assert.Equal(t, 2, int(testutil.ToFloat64(delayedCounter)))
+}
+
 func runNotifications(t *testing.T, rateLimitedNotifier *rateLimitedNotifier, counter prometheus.Counter, count, expectedSuccess, expectedRateLimited, expectedCounter int) {
 	rateLimitedNotifier.recheckAt.Store(0) // Force recheck of limits.
 
@@ -70,8 +130,9 @@ func (m *mockNotifier) Notify(ctx context.Context, alert ...*alert.Alert) (bool,
 }
 
 type limiter struct {
-	limit rate.Limit
-	burst int
+	limit     rate.Limit
+	burst     int
+	queueSize int
 }
 
 func (l *limiter) RateLimit() rate.Limit {
@@ -81,3 +142,7 @@ func (l *limiter) RateLimit() rate.Limit {
 func (l *limiter) Burst() int {
 	return l.burst
 }
+
+func (l *limiter) QueueSize() int {
+	return l.queueSize
+}
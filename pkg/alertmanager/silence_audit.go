@@ -0,0 +1,84 @@
+package alertmanager
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/go-kit/log/level"
+)
+
+const (
+	// SilenceCreatorAnnotation is the annotation of the silences recording the principal
+	// who created (or last updated) the silence.
+	SilenceCreatorAnnotation = "cortex_created_by"
+
+	unknownPrincipal = "unknown"
+)
+
+// requestPrincipal returns the principal the request has been issued by.
+func (am *MultitenantAlertmanager) requestPrincipal(req *http.Request) string {
+	if principal := req.Header.Get(am.cfg.SilencePrincipalHeader); principal != "" {
+		return principal
+	}
+	return unknownPrincipal
+}
+
+// auditSilenceRequest records the principal creating or updating a silence in the silence
+// annotations, which are part of the silences state and therefore persisted and replicated
+// along with the silence. Expiring a silence doesn't change its annotations, so it's only logged.
+func (am *MultitenantAlertmanager) auditSilenceRequest(userID string, req *http.Request) {
+	switch {
+	case req.Method == http.MethodPost && strings.HasSuffix(req.URL.Path, "/v2/silences"):
+		principal := am.requestPrincipal(req)
+		if err := setSilenceCreator(req, principal); err != nil {
+			// The request is served as is, so that the Alertmanager API returns the error.
+			level.Debug(am.logger).Log("msg", "unable to record the silence creator", "user", userID, "err", err)
+			return
+		}
+		level.Info(am.logger).Log("msg", "silence created or updated", "user", userID, "principal", principal)
+
+	case req.Method == http.MethodDelete && strings.HasSuffix(path.Dir(req.URL.Path), "/v2/silence"):
+		level.Info(am.logger).Log("msg", "silence expired", "user", userID, "silence_id", path.Base(req.URL.Path), "principal", am.requestPrincipal(req))
+	}
+}
+
+// setSilenceCreator sets the SilenceCreatorAnnotation of the silence in the request body.
+// The request body is restored unchanged if it's not a valid silence.
+func setSilenceCreator(req *http.Request, principal string) error {
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return err
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+
+	silence := map[string]json.RawMessage{}
+	if err := json.Unmarshal(body, &silence); err != nil {
+		return err
+	}
+
+	annotations := map[string]string{}
+	if raw, ok := silence["annotations"]; ok {
+		if err := json.Unmarshal(raw, &annotations); err != nil {
+			return err
+		}
+		if annotations == nil {
+			annotations = map[string]string{}
+		}
+	}
+	annotations[SilenceCreatorAnnotation] = principal
+
+	if silence["annotations"], err = json.Marshal(annotations); err != nil {
+		return err
+	}
+	if body, err = json.Marshal(silence); err != nil {
+		return err
+	}
+
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	req.ContentLength = int64(len(body))
+	return nil
+}
@@ -0,0 +1,123 @@
+package alertmanager
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+
+	"github.com/cortexproject/cortex/pkg/alertmanager/alertspb"
+	"github.com/cortexproject/cortex/pkg/util/flagext"
+	"github.com/cortexproject/cortex/pkg/util/services"
+	"github.com/cortexproject/cortex/pkg/util/validation"
+)
+
+func TestMultitenantAlertmanager_ShouldRecordSilenceCreator(t *testing.T) {
+	store, err := prepareInMemoryAlertStore()
+	require.NoError(t, err)
+
+	externalURL := flagext.URLValue{}
+	require.NoError(t, externalURL.Set("http://localhost:8080/alertmanager"))
+
+	amConfig := mockAlertmanagerConfig(t)
+	amConfig.ExternalURL = externalURL
+	amConfig.SilencePrincipalHeader = "X-Forwarded-User"
+
+	var limits validation.Limits
+	flagext.DefaultValues(&limits)
+
+	am, err := createMultitenantAlertmanager(amConfig, nil, nil, store, nil, validation.NewOverrides(limits, nil), log.NewNopLogger(), prometheus.NewPedanticRegistry())
+	require.NoError(t, err)
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), am))
+	defer services.StopAndAwaitTerminated(context.Background(), am) //nolint:errcheck
+
+	ctx := user.InjectOrgID(context.Background(), "user1")
+	require.NoError(t, store.SetAlertConfig(ctx, alertspb.AlertConfigDesc{
+		User:      "user1",
+		RawConfig: simpleConfigTwo,
+		Templates: []*alertspb.TemplateDesc{},
+	}))
+	require.NoError(t, am.loadAndSyncConfigs(context.Background(), reasonPeriodic))
+
+	createSilence := func(comment, principal string) {
+		now := time.Now()
+		data, err := json.Marshal(map[string]any{
+			"matchers": []map[string]any{
+				{"name": "instance", "value": "prometheus-one", "isRegex": false, "isEqual": true},
+			},
+			"comment":     comment,
+			"createdBy":   "test",
+			"startsAt":    now.UTC().Format(time.RFC3339),
+			"endsAt":      now.Add(time.Hour).UTC().Format(time.RFC3339),
+			"annotations": map[string]string{"team": "a-team"},
+		})
+		require.NoError(t, err)
+
+		req := httptest.NewRequest(http.MethodPost, externalURL.String()+"/api/v2/silences", bytes.NewReader(data))
+		req.Header.Set("content-type", "application/json")
+		if principal != "" {
+			req.Header.Set("X-Forwarded-User", principal)
+		}
+
+		w := httptest.NewRecorder()
+		am.ServeHTTP(w, req.WithContext(ctx))
+		require.Equal(t, http.StatusOK, w.Code)
+	}
+
+	createSilence("first", "alice")
+	createSilence("second", "")
+
+	req := httptest.NewRequest(http.MethodGet, externalURL.String()+"/api/v2/silences", nil)
+	w := httptest.NewRecorder()
+	am.ServeHTTP(w, req.WithContext(ctx))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	body, err := io.ReadAll(w.Result().Body)
+	require.NoError(t, err)
+
+	var silences []struct {
+		Comment     string            `json:"comment"`
+		Annotations map[string]string `json:"annotations"`
+	}
+	require.NoError(t, json.Unmarshal(body, &silences))
+
+	creators := map[string]map[string]string{}
+	for _, s := range silences {
+		creators[s.Comment] = s.Annotations
+	}
+	assert.Equal(t, map[string]map[string]string{
+		"first":  {"team": "a-team", SilenceCreatorAnnotation: "alice"},
+		"second": {"team": "a-team", SilenceCreatorAnnotation: unknownPrincipal},
+	}, creators)
+}
+
+func TestSetSilenceCreator(t *testing.T) {
+	t.Run("should override the creator annotation", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/api/v2/silences", bytes.NewReader([]byte(`{"comment":"foo","annotations":{"cortex_created_by":"bob"}}`)))
+		require.NoError(t, setSilenceCreator(req, "alice"))
+
+		body, err := io.ReadAll(req.Body)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"comment":"foo","annotations":{"cortex_created_by":"alice"}}`, string(body))
+		assert.Equal(t, int64(len(body)), req.ContentLength)
+	})
+
+	t.Run("should restore the request body if it's not a valid silence", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/api/v2/silences", bytes.NewReader([]byte(`not json`)))
+		require.Error(t, setSilenceCreator(req, "alice"))
+
+		body, err := io.ReadAll(req.Body)
+		require.NoError(t, err)
+		assert.Equal(t, "not json", string(body))
+	})
+}
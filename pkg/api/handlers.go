@@ -34,6 +34,7 @@ import (
 	"github.com/cortexproject/cortex/pkg/util"
 	util_log "github.com/cortexproject/cortex/pkg/util/log"
 	"github.com/cortexproject/cortex/pkg/util/request_tracker"
+	"github.com/cortexproject/cortex/pkg/util/validation"
 )
 
 const (
@@ -167,6 +168,8 @@ func NewQuerierHandler(
 	exemplarQueryable storage.ExemplarQueryable,
 	engine engine.QueryEngine,
 	metadataQuerier querier.MetadataQuerier,
+	queryCostEstimator *querier.QueryCostEstimator,
+	limits *validation.Overrides,
 	reg prometheus.Registerer,
 	logger log.Logger,
 ) http.Handler {
@@ -300,7 +303,7 @@ func NewQuerierHandler(
 		TotalTimeout:      querierCfg.TimeoutClassificationDeadline,
 		EvalTimeThreshold: querierCfg.TimeoutClassificationEvalThreshold,
 		Enabled:           querierCfg.TimeoutClassificationEnabled,
-	})
+	}, limits)
 
 	requestTracker := request_tracker.NewRequestTracker(querierCfg.ActiveQueryTrackerDir, "apis.active", querierCfg.MaxConcurrent, util_log.GoKitLogToSlog(logger))
 	var apiHandler http.Handler
@@ -342,6 +345,7 @@ func NewQuerierHandler(
 	router.Path(path.Join(prefix, "/api/v1/label/{name}/values")).Methods("GET").Handler(apiHandler)
 	router.Path(path.Join(prefix, "/api/v1/series")).Methods("GET", "POST", "DELETE").Handler(apiHandler)
 	router.Path(path.Join(prefix, "/api/v1/metadata")).Methods("GET").Handler(apiHandler)
+	router.Path(path.Join(prefix, "/api/v1/query_cost")).Methods("GET", "POST").Handler(querier.QueryCostHandler(queryCostEstimator))
 
 	// TODO(gotjosh): This custom handler is temporary until we're able to vendor the changes in:
 	// https://github.com/prometheus/prometheus/pull/7125/files
@@ -357,6 +361,7 @@ func NewQuerierHandler(
 	router.Path(path.Join(legacyPrefix, "/api/v1/label/{name}/values")).Methods("GET").Handler(legacyAPIHandler)
 	router.Path(path.Join(legacyPrefix, "/api/v1/series")).Methods("GET", "POST", "DELETE").Handler(legacyAPIHandler)
 	router.Path(path.Join(legacyPrefix, "/api/v1/metadata")).Methods("GET").Handler(legacyAPIHandler)
+	router.Path(path.Join(legacyPrefix, "/api/v1/query_cost")).Methods("GET", "POST").Handler(querier.QueryCostHandler(queryCostEstimator))
 
 	if cfg.buildInfoEnabled {
 		router.Path(path.Join(prefix, "/api/v1/status/buildinfo")).Methods("GET").Handler(promRouter)
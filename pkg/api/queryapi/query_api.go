@@ -25,6 +25,7 @@ import (
 	"github.com/cortexproject/cortex/pkg/util"
 	"github.com/cortexproject/cortex/pkg/util/api"
 	"github.com/cortexproject/cortex/pkg/util/requestmeta"
+	"github.com/cortexproject/cortex/pkg/util/validation"
 )
 
 type QueryAPI struct {
@@ -36,6 +37,7 @@ type QueryAPI struct {
 	codecs                []v1.Codec
 	CORSOrigin            *regexp.Regexp
 	timeoutClassification stats.PhaseTrackerConfig
+	limits                *validation.Overrides
 }
 
 func NewQueryAPI(
@@ -46,6 +48,7 @@ func NewQueryAPI(
 	codecs []v1.Codec,
 	CORSOrigin *regexp.Regexp,
 	timeoutClassification stats.PhaseTrackerConfig,
+	limits *validation.Overrides,
 ) *QueryAPI {
 	return &QueryAPI{
 		queryEngine:           qe,
@@ -56,6 +59,7 @@ func NewQueryAPI(
 		CORSOrigin:            CORSOrigin,
 		now:                   time.Now,
 		timeoutClassification: timeoutClassification,
+		limits:                limits,
 	}
 }
 
@@ -114,13 +118,17 @@ func (q *QueryAPI) RangeQueryHandler(r *http.Request) (result apiFuncResult) {
 		return *earlyResult
 	}
 
-	opts, err := extractQueryOpts(r)
+	opts, err := q.extractQueryOpts(r)
 	if err != nil {
 		return apiFuncResult{nil, &apiError{errorBadData, err}, nil, nil}
 	}
 
 	ctx = engine.AddEngineTypeToContext(ctx, r)
 	ctx = querier.AddBlockStoreTypeToContext(ctx, r.Header.Get(querier.BlockStoreTypeHeader))
+	ctx, err = querier.AddQueryBlocksToContext(ctx, r.Header.Get(querier.QueryBlocksHeader))
+	if err != nil {
+		return apiFuncResult{nil, &apiError{errorBadData, err}, nil, nil}
+	}
 
 	var qry promql.Query
 	startTime := convertMsToTime(start)
@@ -210,13 +218,17 @@ func (q *QueryAPI) InstantQueryHandler(r *http.Request) (result apiFuncResult) {
 		return *earlyResult
 	}
 
-	opts, err := extractQueryOpts(r)
+	opts, err := q.extractQueryOpts(r)
 	if err != nil {
 		return apiFuncResult{nil, &apiError{errorBadData, err}, nil, nil}
 	}
 
 	ctx = engine.AddEngineTypeToContext(ctx, r)
 	ctx = querier.AddBlockStoreTypeToContext(ctx, r.Header.Get(querier.BlockStoreTypeHeader))
+	ctx, err = querier.AddQueryBlocksToContext(ctx, r.Header.Get(querier.QueryBlocksHeader))
+	if err != nil {
+		return apiFuncResult{nil, &apiError{errorBadData, err}, nil, nil}
+	}
 
 	var qry promql.Query
 	tsTime := convertMsToTime(ts)
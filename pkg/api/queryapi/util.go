@@ -13,6 +13,8 @@ import (
 	"github.com/weaveworks/common/httpgrpc"
 
 	"github.com/cortexproject/cortex/pkg/util"
+	"github.com/cortexproject/cortex/pkg/util/users"
+	"github.com/cortexproject/cortex/pkg/util/validation"
 )
 
 var (
@@ -22,7 +24,7 @@ var (
 	ErrUpstreamRequestTimeout = "upstream request timeout"
 )
 
-func extractQueryOpts(r *http.Request) (promql.QueryOpts, error) {
+func (q *QueryAPI) extractQueryOpts(r *http.Request) (promql.QueryOpts, error) {
 	var duration time.Duration
 
 	if strDuration := r.FormValue("lookback_delta"); strDuration != "" {
@@ -31,6 +33,11 @@ func extractQueryOpts(r *http.Request) (promql.QueryOpts, error) {
 			return nil, httpgrpc.Errorf(http.StatusBadRequest, "error parsing lookback delta duration: %v", err)
 		}
 		duration = convertMsToDuration(parsedDuration)
+	} else if q.limits != nil {
+		// Fallback to the tenant lookback delta, if any. With multiple tenants, the largest one is used.
+		if tenantIDs, err := users.TenantIDs(r.Context()); err == nil {
+			duration = validation.MaxDurationPerTenant(tenantIDs, q.limits.QueryLookbackDelta)
+		}
 	}
 
 	return promql.NewPrometheusQueryOpts(r.FormValue("stats") == "all", duration), nil
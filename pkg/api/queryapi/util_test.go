@@ -1,10 +1,18 @@
 package queryapi
 
 import (
+	"context"
+	"net/http/httptest"
 	"testing"
 	"time"
 
+	"github.com/prometheus/common/model"
 	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+
+	"github.com/cortexproject/cortex/pkg/util/flagext"
+	"github.com/cortexproject/cortex/pkg/util/users"
+	"github.com/cortexproject/cortex/pkg/util/validation"
 )
 
 func Test_Convert(t *testing.T) {
@@ -13,3 +21,80 @@ func Test_Convert(t *testing.T) {
 	require.Equal(t, time, convertMsToTime(time).UnixMilli())
 	require.Equal(t, time, convertMsToDuration(time).Milliseconds())
 }
+
+func Test_ExtractQueryOpts_LookbackDelta(t *testing.T) {
+	users.WithDefaultResolver(users.NewMultiResolver())
+	defer users.WithDefaultResolver(users.NewSingleResolver())
+
+	defaults := validation.Limits{}
+	flagext.DefaultValues(&defaults)
+
+	tenantLimits := map[string]*validation.Limits{}
+	for userID, lookbackDelta := range map[string]time.Duration{"user-1": time.Minute, "user-2": 10 * time.Minute} {
+		limits := defaults
+		limits.QueryLookbackDelta = model.Duration(lookbackDelta)
+		tenantLimits[userID] = &limits
+	}
+	overrides := validation.NewOverrides(defaults, &mockTenantLimits{limits: tenantLimits})
+
+	tests := map[string]struct {
+		orgID                 string
+		path                  string
+		limits                *validation.Overrides
+		expectedLookbackDelta time.Duration
+	}{
+		"should use the engine lookback delta if the tenant has none": {
+			orgID:                 "user-3",
+			path:                  "/api/v1/query?query=up",
+			limits:                overrides,
+			expectedLookbackDelta: 0,
+		},
+		"should use the tenant lookback delta": {
+			orgID:                 "user-1",
+			path:                  "/api/v1/query?query=up",
+			limits:                overrides,
+			expectedLookbackDelta: time.Minute,
+		},
+		"should use the largest lookback delta of the tenants": {
+			orgID:                 "user-1|user-2",
+			path:                  "/api/v1/query?query=up",
+			limits:                overrides,
+			expectedLookbackDelta: 10 * time.Minute,
+		},
+		"should use the lookback delta query parameter over the tenant one": {
+			orgID:                 "user-1",
+			path:                  "/api/v1/query?query=up&lookback_delta=30s",
+			limits:                overrides,
+			expectedLookbackDelta: 30 * time.Second,
+		},
+		"should use the engine lookback delta without limits": {
+			orgID:                 "user-1",
+			path:                  "/api/v1/query?query=up",
+			expectedLookbackDelta: 0,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", test.path, nil)
+			req = req.WithContext(user.InjectOrgID(context.Background(), test.orgID))
+
+			q := &QueryAPI{limits: test.limits}
+			opts, err := q.extractQueryOpts(req)
+			require.NoError(t, err)
+			require.Equal(t, test.expectedLookbackDelta, opts.LookbackDelta())
+		})
+	}
+}
+
+type mockTenantLimits struct {
+	limits map[string]*validation.Limits
+}
+
+func (m *mockTenantLimits) ByUserID(userID string) *validation.Limits {
+	return m.limits[userID]
+}
+
+func (m *mockTenantLimits) AllByUserID() map[string]*validation.Limits {
+	return m.limits
+}
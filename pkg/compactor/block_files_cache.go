@@ -0,0 +1,373 @@
+package compactor
+
+import (
+	"context"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/oklog/ulid/v2"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/thanos-io/objstore"
+	"github.com/thanos-io/thanos/pkg/block"
+	"github.com/thanos-io/thanos/pkg/block/metadata"
+)
+
+const blockFilesCacheTmpSuffix = ".tmp"
+
+// blockFilesCache is a local disk cache of the files of the blocks downloaded for compaction.
+// Unlike the compaction working directory, the cache is kept across compaction retries and
+// compactor restarts, so the source blocks of a retried compaction aren't downloaded again.
+// The files are stored by block ULID, and the least recently used blocks are evicted once
+// the max size is exceeded.
+type blockFilesCache struct {
+	dir          string
+	maxSizeBytes int64
+	logger       log.Logger
+
+	mtx       sync.Mutex
+	blocks    map[ulid.ULID]*cachedBlock
+	sizeBytes int64
+
+	hits          prometheus.Counter
+	misses        prometheus.Counter
+	evictedBlocks prometheus.Counter
+	cachedBlocks  prometheus.Gauge
+	cachedBytes   prometheus.Gauge
+}
+
+type cachedBlock struct {
+	sizeBytes  int64
+	lastAccess time.Time
+}
+
+// newBlockFilesCache makes a new blockFilesCache, loading the blocks already cached in dir.
+func newBlockFilesCache(dir string, maxSizeBytes int64, logger log.Logger, reg prometheus.Registerer) (*blockFilesCache, error) {
+	c := &blockFilesCache{
+		dir:          dir,
+		maxSizeBytes: maxSizeBytes,
+		logger:       logger,
+		blocks:       map[ulid.ULID]*cachedBlock{},
+		hits: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_compactor_block_files_cache_hits_total",
+			Help: "Total number of block index and chunks files read from the compactor block files cache.",
+		}),
+		misses: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_compactor_block_files_cache_misses_total",
+			Help: "Total number of block index and chunks files not found in the compactor block files cache, and downloaded from the storage.",
+		}),
+		evictedBlocks: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_compactor_block_files_cache_evicted_blocks_total",
+			Help: "Total number of blocks evicted from the compactor block files cache because the max size has been exceeded.",
+		}),
+		cachedBlocks: promauto.With(reg).NewGauge(prometheus.GaugeOpts{
+			Name: "cortex_compactor_block_files_cache_blocks",
+			Help: "Number of blocks in the compactor block files cache.",
+		}),
+		cachedBytes: promauto.With(reg).NewGauge(prometheus.GaugeOpts{
+			Name: "cortex_compactor_block_files_cache_size_bytes",
+			Help: "Size in bytes of the files in the compactor block files cache.",
+		}),
+	}
+
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		return nil, errors.Wrap(err, "create block files cache dir")
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, errors.Wrap(err, "read block files cache dir")
+	}
+
+	for _, entry := range entries {
+		id, err := ulid.Parse(entry.Name())
+		if err != nil || !entry.IsDir() {
+			continue
+		}
+
+		blockDir := filepath.Join(dir, entry.Name())
+		info, err := entry.Info()
+		if err != nil {
+			return nil, errors.Wrapf(err, "stat cached block %s", id)
+		}
+		cached := &cachedBlock{lastAccess: info.ModTime()}
+
+		// Remove the files whose download didn't complete before the compactor stopped.
+		err = filepath.WalkDir(blockDir, func(file string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return err
+			}
+			if strings.HasSuffix(file, blockFilesCacheTmpSuffix) {
+				return os.Remove(file)
+			}
+			fileInfo, err := d.Info()
+			if err != nil {
+				return err
+			}
+			cached.sizeBytes += fileInfo.Size()
+			return nil
+		})
+		if err != nil {
+			return nil, errors.Wrapf(err, "load cached block %s", id)
+		}
+
+		c.blocks[id] = cached
+		c.sizeBytes += cached.sizeBytes
+	}
+
+	c.mtx.Lock()
+	c.evict()
+	c.mtx.Unlock()
+
+	level.Info(logger).Log("msg", "loaded block files cache", "dir", dir, "blocks", len(c.blocks), "size_bytes", c.sizeBytes)
+	return c, nil
+}
+
+// wrap returns a bucket reading the blocks files through the cache.
+func (c *blockFilesCache) wrap(bkt objstore.InstrumentedBucket) objstore.InstrumentedBucket {
+	return &blockFilesCachingBucket{InstrumentedBucket: bkt, cache: c}
+}
+
+// open returns the cached block file, if it can be trusted.
+func (c *blockFilesCache) open(id ulid.ULID, relPath string) (io.ReadCloser, bool) {
+	c.mtx.Lock()
+	cached, ok := c.blocks[id]
+	if ok {
+		cached.lastAccess = time.Now()
+	}
+	c.mtx.Unlock()
+
+	if !ok || !c.verify(id, relPath) {
+		c.misses.Inc()
+		return nil, false
+	}
+
+	f, err := os.Open(c.path(id, relPath))
+	if err != nil {
+		// The block has been evicted meanwhile.
+		c.misses.Inc()
+		return nil, false
+	}
+
+	// Persist the last access, so that the least recently used blocks are evicted first after a restart too.
+	now := time.Now()
+	_ = os.Chtimes(filepath.Join(c.dir, id.String()), now, now)
+
+	c.hits.Inc()
+	return f, true
+}
+
+// verify returns whether the cached block file can be trusted. The files are moved to the
+// cache only once fully downloaded, but the block is complete only once its meta.json and
+// index have been cached, and the cached file size must match the one in the meta.json.
+func (c *blockFilesCache) verify(id ulid.ULID, relPath string) bool {
+	blockDir := filepath.Join(c.dir, id.String())
+
+	meta, err := metadata.ReadFromDir(blockDir)
+	if err != nil {
+		return false
+	}
+	if _, err := os.Stat(filepath.Join(blockDir, block.IndexFilename)); err != nil {
+		return false
+	}
+
+	info, err := os.Stat(c.path(id, relPath))
+	if err != nil {
+		return false
+	}
+
+	for _, f := range meta.Thanos.Files {
+		if f.RelPath == relPath && f.SizeBytes > 0 && f.SizeBytes != info.Size() {
+			level.Warn(c.logger).Log("msg", "cached block file size doesn't match the block meta.json, downloading it again", "block", id, "file", relPath, "expected_size", f.SizeBytes, "actual_size", info.Size())
+			return false
+		}
+	}
+	return true
+}
+
+// store returns a reader caching the block file once r has been fully read.
+func (c *blockFilesCache) store(id ulid.ULID, relPath string, r io.ReadCloser) io.ReadCloser {
+	dst := c.path(id, relPath)
+	if err := os.MkdirAll(filepath.Dir(dst), 0750); err != nil {
+		level.Warn(c.logger).Log("msg", "failed to create cached block dir", "block", id, "err", err)
+		return r
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(dst), filepath.Base(dst)+".*"+blockFilesCacheTmpSuffix)
+	if err != nil {
+		level.Warn(c.logger).Log("msg", "failed to create cached block file", "block", id, "file", relPath, "err", err)
+		return r
+	}
+
+	return &blockFileCachingReader{ReadCloser: r, cache: c, id: id, dst: dst, tmp: tmp}
+}
+
+// add moves the downloaded block file to the cache, evicting the least recently used blocks if the max size is exceeded.
+func (c *blockFilesCache) add(id ulid.ULID, tmp, dst string, size int64) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	var prevSize int64
+	if info, err := os.Stat(dst); err == nil {
+		prevSize = info.Size()
+	}
+
+	// The rename fails if the block has been evicted while the file was downloaded.
+	if err := os.Rename(tmp, dst); err != nil {
+		_ = os.Remove(tmp)
+		return
+	}
+
+	cached, ok := c.blocks[id]
+	if !ok {
+		cached = &cachedBlock{}
+		c.blocks[id] = cached
+	}
+	cached.sizeBytes += size - prevSize
+	cached.lastAccess = time.Now()
+	c.sizeBytes += size - prevSize
+
+	c.evict()
+}
+
+// evict removes the least recently used blocks until the cache size is within the max size.
+// Must be called with the lock held.
+func (c *blockFilesCache) evict() {
+	for c.sizeBytes > c.maxSizeBytes && len(c.blocks) > 0 {
+		var (
+			oldestID ulid.ULID
+			oldest   *cachedBlock
+		)
+		for id, cached := range c.blocks {
+			if oldest == nil || cached.lastAccess.Before(oldest.lastAccess) {
+				oldestID, oldest = id, cached
+			}
+		}
+
+		if err := os.RemoveAll(filepath.Join(c.dir, oldestID.String())); err != nil {
+			level.Warn(c.logger).Log("msg", "failed to remove evicted block from the block files cache", "block", oldestID, "err", err)
+		}
+		delete(c.blocks, oldestID)
+		c.sizeBytes -= oldest.sizeBytes
+		c.evictedBlocks.Inc()
+	}
+
+	c.cachedBlocks.Set(float64(len(c.blocks)))
+	c.cachedBytes.Set(float64(c.sizeBytes))
+}
+
+func (c *blockFilesCache) path(id ulid.ULID, relPath string) string {
+	return filepath.Join(c.dir, id.String(), filepath.FromSlash(relPath))
+}
+
+// cacheableBlockFile returns the block ID and the file path relative to the block
+// if the object is a block meta.json, index or chunks file.
+func cacheableBlockFile(name string) (ulid.ULID, string, bool) {
+	blockID, relPath, ok := strings.Cut(name, objstore.DirDelim)
+	if !ok {
+		return ulid.ULID{}, "", false
+	}
+	id, err := ulid.Parse(blockID)
+	if err != nil {
+		return ulid.ULID{}, "", false
+	}
+
+	if relPath != block.MetaFilename && relPath != block.IndexFilename && path.Dir(relPath) != block.ChunksDirname {
+		return ulid.ULID{}, "", false
+	}
+	return id, relPath, true
+}
+
+// blockFilesCachingBucket reads the index and chunks files of the blocks from the cache when
+// available, and caches the meta.json, index and chunks files read from the bucket otherwise.
+// The meta.json is always read from the bucket, so blocks deleted from the storage meanwhile
+// are never downloaded from the cache, and the cached blocks are verified against the latest one.
+type blockFilesCachingBucket struct {
+	objstore.InstrumentedBucket
+
+	cache *blockFilesCache
+}
+
+// Get implements objstore.Bucket.
+func (b *blockFilesCachingBucket) Get(ctx context.Context, name string) (io.ReadCloser, error) {
+	id, relPath, ok := cacheableBlockFile(name)
+	if !ok {
+		return b.InstrumentedBucket.Get(ctx, name)
+	}
+
+	if relPath != block.MetaFilename {
+		if r, ok := b.cache.open(id, relPath); ok {
+			return r, nil
+		}
+	}
+
+	r, err := b.InstrumentedBucket.Get(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	return b.cache.store(id, relPath, r), nil
+}
+
+// blockFileCachingReader writes the read block file to a temporary file, which is moved
+// to the cache once the block file has been fully read.
+type blockFileCachingReader struct {
+	io.ReadCloser
+
+	cache *blockFilesCache
+	id    ulid.ULID
+	dst   string
+	tmp   *os.File
+	size  int64
+	eof   bool
+}
+
+func (r *blockFileCachingReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	if n > 0 && r.tmp != nil {
+		if _, writeErr := r.tmp.Write(p[:n]); writeErr != nil {
+			level.Warn(r.cache.logger).Log("msg", "failed to write cached block file", "block", r.id, "err", writeErr)
+			r.discard()
+		}
+		r.size += int64(n)
+	}
+	if errors.Is(err, io.EOF) {
+		r.eof = true
+	}
+	return n, err
+}
+
+func (r *blockFileCachingReader) Close() error {
+	err := r.ReadCloser.Close()
+	if r.tmp == nil {
+		return err
+	}
+
+	// Only the fully read files are cached.
+	if !r.eof {
+		r.discard()
+		return err
+	}
+	if closeErr := r.tmp.Close(); closeErr != nil {
+		_ = os.Remove(r.tmp.Name())
+		r.tmp = nil
+		return err
+	}
+	r.cache.add(r.id, r.tmp.Name(), r.dst, r.size)
+	r.tmp = nil
+	return err
+}
+
+func (r *blockFileCachingReader) discard() {
+	_ = r.tmp.Close()
+	_ = os.Remove(r.tmp.Name())
+	r.tmp = nil
+}
@@ -0,0 +1,154 @@
+package compactor
+
+import (
+	"context"
+	"os"
+	"path"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/oklog/ulid/v2"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/objstore"
+	"github.com/thanos-io/thanos/pkg/block"
+
+	"github.com/cortexproject/cortex/pkg/storage/bucket"
+	cortex_testutil "github.com/cortexproject/cortex/pkg/util/testutil"
+)
+
+func TestBlockFilesCache(t *testing.T) {
+	ctx := context.Background()
+	logger := log.NewNopLogger()
+	cacheDir := t.TempDir()
+
+	bkt := &cortex_testutil.MockBucketFailure{Bucket: objstore.NewInMemBucket()}
+	blockID := createTSDBBlock(t, bkt, "user-1", 10, 20, nil)
+	userBkt := bucket.NewUserBucketClient("user-1", bkt, nil)
+
+	cache, err := newBlockFilesCache(cacheDir, 1024*1024, logger, prometheus.NewPedanticRegistry())
+	require.NoError(t, err)
+
+	// The block files are downloaded from the storage and cached.
+	require.NoError(t, block.Download(ctx, logger, cache.wrap(userBkt), blockID, filepath.Join(t.TempDir(), blockID.String())))
+	assert.Equal(t, float64(0), testutil.ToFloat64(cache.hits))
+	assert.Equal(t, float64(2), testutil.ToFloat64(cache.misses))
+	assert.Equal(t, float64(1), testutil.ToFloat64(cache.cachedBlocks))
+	assert.FileExists(t, filepath.Join(cacheDir, blockID.String(), block.MetaFilename))
+	assert.FileExists(t, filepath.Join(cacheDir, blockID.String(), block.IndexFilename))
+	assert.FileExists(t, filepath.Join(cacheDir, blockID.String(), block.ChunksDirname, "000001"))
+
+	// The index and chunks files are read from the cache, while the meta.json is still read from the storage.
+	bkt.GetFailures = map[string]error{
+		path.Join("user-1", blockID.String(), block.IndexFilename): errors.New("mocked get failure"),
+		path.Join("user-1", blockID.String(), block.ChunksDirname): errors.New("mocked get failure"),
+	}
+	dst := filepath.Join(t.TempDir(), blockID.String())
+	require.NoError(t, block.Download(ctx, logger, cache.wrap(userBkt), blockID, dst))
+	assert.Equal(t, float64(2), testutil.ToFloat64(cache.hits))
+	assertSameFileContent(t, filepath.Join(cacheDir, blockID.String(), block.IndexFilename), filepath.Join(dst, block.IndexFilename))
+
+	t.Run("should reuse the cached blocks after a restart", func(t *testing.T) {
+		restarted, err := newBlockFilesCache(cacheDir, 1024*1024, logger, prometheus.NewPedanticRegistry())
+		require.NoError(t, err)
+		assert.Equal(t, float64(1), testutil.ToFloat64(restarted.cachedBlocks))
+		assert.Equal(t, testutil.ToFloat64(cache.cachedBytes), testutil.ToFloat64(restarted.cachedBytes))
+
+		require.NoError(t, block.Download(ctx, logger, restarted.wrap(userBkt), blockID, filepath.Join(t.TempDir(), blockID.String())))
+		assert.Equal(t, float64(2), testutil.ToFloat64(restarted.hits))
+	})
+
+	t.Run("should not trust a cached block without index", func(t *testing.T) {
+		require.NoError(t, os.Remove(filepath.Join(cacheDir, blockID.String(), block.IndexFilename)))
+
+		err := block.Download(ctx, logger, cache.wrap(userBkt), blockID, filepath.Join(t.TempDir(), blockID.String()))
+		require.ErrorContains(t, err, "mocked get failure")
+
+		bkt.GetFailures = nil
+		require.NoError(t, block.Download(ctx, logger, cache.wrap(userBkt), blockID, filepath.Join(t.TempDir(), blockID.String())))
+		assert.FileExists(t, filepath.Join(cacheDir, blockID.String(), block.IndexFilename))
+	})
+
+	t.Run("should not cache partially read files", func(t *testing.T) {
+		otherID := createTSDBBlock(t, bkt, "user-1", 20, 30, nil)
+
+		r, err := cache.wrap(userBkt).Get(ctx, path.Join(otherID.String(), block.IndexFilename))
+		require.NoError(t, err)
+		_, err = r.Read(make([]byte, 1))
+		require.NoError(t, err)
+		require.NoError(t, r.Close())
+
+		entries, err := os.ReadDir(filepath.Join(cacheDir, otherID.String()))
+		require.NoError(t, err)
+		assert.Empty(t, entries)
+	})
+}
+
+func TestBlockFilesCache_Eviction(t *testing.T) {
+	ctx := context.Background()
+	logger := log.NewNopLogger()
+	cacheDir := t.TempDir()
+
+	bkt := objstore.NewInMemBucket()
+	userBkt := bucket.NewUserBucketClient("user-1", bkt, nil)
+	firstID := createTSDBBlock(t, bkt, "user-1", 10, 20, nil)
+	secondID := createTSDBBlock(t, bkt, "user-1", 20, 30, nil)
+
+	// Get the size of a block.
+	cache, err := newBlockFilesCache(t.TempDir(), 1024*1024, logger, prometheus.NewPedanticRegistry())
+	require.NoError(t, err)
+	require.NoError(t, block.Download(ctx, logger, cache.wrap(userBkt), firstID, filepath.Join(t.TempDir(), firstID.String())))
+	blockSize := int64(testutil.ToFloat64(cache.cachedBytes))
+
+	// The cache can hold a single block.
+	cache, err = newBlockFilesCache(cacheDir, blockSize+blockSize/2, logger, prometheus.NewPedanticRegistry())
+	require.NoError(t, err)
+
+	require.NoError(t, block.Download(ctx, logger, cache.wrap(userBkt), firstID, filepath.Join(t.TempDir(), firstID.String())))
+	require.NoError(t, block.Download(ctx, logger, cache.wrap(userBkt), secondID, filepath.Join(t.TempDir(), secondID.String())))
+
+	assert.Equal(t, float64(1), testutil.ToFloat64(cache.evictedBlocks))
+	assert.Equal(t, float64(1), testutil.ToFloat64(cache.cachedBlocks))
+	assert.LessOrEqual(t, testutil.ToFloat64(cache.cachedBytes), float64(blockSize+blockSize/2))
+	assert.NoDirExists(t, filepath.Join(cacheDir, firstID.String()))
+	assert.DirExists(t, filepath.Join(cacheDir, secondID.String()))
+
+	// A smaller max size after a restart evicts the cached blocks exceeding it.
+	cache, err = newBlockFilesCache(cacheDir, 1, logger, prometheus.NewPedanticRegistry())
+	require.NoError(t, err)
+	assert.Equal(t, float64(0), testutil.ToFloat64(cache.cachedBlocks))
+	assert.NoDirExists(t, filepath.Join(cacheDir, secondID.String()))
+}
+
+func TestCacheableBlockFile(t *testing.T) {
+	blockID := ulid.MustNew(1, nil)
+
+	for name, expected := range map[string]bool{
+		path.Join(blockID.String(), block.MetaFilename):            true,
+		path.Join(blockID.String(), block.IndexFilename):           true,
+		path.Join(blockID.String(), block.ChunksDirname, "000001"): true,
+		path.Join(blockID.String(), "deletion-mark.json"):          false,
+		path.Join(blockID.String(), "visit-mark.json"):             false,
+		path.Join("markers", "deletion-mark.json"):                 false,
+		block.IndexFilename: false,
+	} {
+		id, relPath, ok := cacheableBlockFile(name)
+		assert.Equal(t, expected, ok, name)
+		if ok {
+			assert.Equal(t, blockID, id)
+			assert.Equal(t, name, path.Join(id.String(), relPath))
+		}
+	}
+}
+
+func assertSameFileContent(t *testing.T, expectedFile, actualFile string) {
+	expected, err := os.ReadFile(expectedFile)
+	require.NoError(t, err)
+	actual, err := os.ReadFile(actualFile)
+	require.NoError(t, err)
+	assert.Equal(t, expected, actual)
+}
@@ -0,0 +1,144 @@
+package compactor
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/oklog/ulid/v2"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/tsdb"
+	"github.com/prometheus/prometheus/tsdb/chunkenc"
+	"github.com/prometheus/prometheus/tsdb/chunks"
+	"github.com/prometheus/prometheus/tsdb/index"
+	"github.com/thanos-io/objstore"
+	"github.com/thanos-io/thanos/pkg/block"
+	"github.com/thanos-io/thanos/pkg/block/metadata"
+	"github.com/thanos-io/thanos/pkg/compact"
+
+	util_log "github.com/cortexproject/cortex/pkg/util/log"
+)
+
+const reasonValueFailedVerification = "failed-verification"
+
+// blockVerificationCallback wraps a compact.CompactionLifecycleCallback to verify each compacted block
+// once it has been uploaded. The post compaction callback runs before the source blocks are marked
+// for deletion, so if the verification fails the source blocks are kept, the compacted block is
+// marked for deletion and the compaction is retried.
+type blockVerificationCallback struct {
+	compact.CompactionLifecycleCallback
+
+	bkt                     objstore.Bucket
+	dir                     string
+	verificationFailures    prometheus.Counter
+	blocksMarkedForDeletion prometheus.Counter
+}
+
+func newBlockVerificationCallback(callback compact.CompactionLifecycleCallback, bkt objstore.Bucket, dir string, verificationFailures, blocksMarkedForDeletion prometheus.Counter) *blockVerificationCallback {
+	return &blockVerificationCallback{
+		CompactionLifecycleCallback: callback,
+		bkt:                         bkt,
+		dir:                         dir,
+		verificationFailures:        verificationFailures,
+		blocksMarkedForDeletion:     blocksMarkedForDeletion,
+	}
+}
+
+func (c *blockVerificationCallback) PostCompactionCallback(ctx context.Context, logger log.Logger, g *compact.Group, blockID ulid.ULID) error {
+	if err := verifyUploadedBlock(ctx, logger, c.bkt, blockID, filepath.Join(c.dir, "verify", blockID.String())); err != nil {
+		c.verificationFailures.Inc()
+		level.Error(logger).Log("msg", "verification of uploaded compacted block failed, marking it for deletion", "block", blockID, "err", err)
+
+		if markErr := block.MarkForDeletion(ctx, logger, c.bkt, blockID, "compacted block failed verification", c.blocksMarkedForDeletion); markErr != nil {
+			return errors.Wrapf(markErr, "mark compacted block %s which failed verification for deletion", blockID)
+		}
+		return errors.Wrapf(err, "verify uploaded compacted block %s", blockID)
+	}
+
+	return c.CompactionLifecycleCallback.PostCompactionCallback(ctx, logger, g, blockID)
+}
+
+// verifyUploadedBlock downloads the block from the bucket to dir, and checks its index and chunks
+// can be fully read and the number of series matches the one in meta.json.
+func verifyUploadedBlock(ctx context.Context, logger log.Logger, bkt objstore.Bucket, id ulid.ULID, dir string) error {
+	defer func() {
+		if rmErr := os.RemoveAll(dir); rmErr != nil {
+			level.Warn(logger).Log("msg", "failed to remove block verification directory", "dir", dir, "err", rmErr)
+		}
+	}()
+
+	if err := block.Download(ctx, logger, bkt, id, dir); err != nil {
+		return errors.Wrap(err, "download block")
+	}
+
+	meta, err := metadata.ReadFromDir(dir)
+	if err != nil {
+		return errors.Wrap(err, "read meta.json")
+	}
+
+	b, err := tsdb.OpenBlock(util_log.GoKitLogToSlog(logger), dir, nil, tsdb.DefaultPostingsDecoderFactory)
+	if err != nil {
+		return errors.Wrap(err, "open block")
+	}
+	defer b.Close()
+
+	ir, err := b.Index()
+	if err != nil {
+		return errors.Wrap(err, "open index")
+	}
+	defer ir.Close()
+
+	cr, err := b.Chunks()
+	if err != nil {
+		return errors.Wrap(err, "open chunks")
+	}
+	defer cr.Close()
+
+	k, v := index.AllPostingsKey()
+	postings, err := ir.Postings(ctx, k, v)
+	if err != nil {
+		return errors.Wrap(err, "read postings")
+	}
+
+	var (
+		numSeries uint64
+		builder   labels.ScratchBuilder
+		chks      []chunks.Meta
+		it        chunkenc.Iterator
+	)
+	for postings.Next() {
+		if err := ir.Series(postings.At(), &builder, &chks); err != nil {
+			return errors.Wrap(err, "read series")
+		}
+
+		for _, chk := range chks {
+			c, iterable, err := cr.ChunkOrIterable(chk)
+			if err != nil {
+				return errors.Wrapf(err, "read chunk %d of series %s", chk.Ref, builder.Labels())
+			}
+			if c != nil {
+				it = c.Iterator(it)
+			} else {
+				it = iterable.Iterator(it)
+			}
+			for it.Next() != chunkenc.ValNone {
+			}
+			if err := it.Err(); err != nil {
+				return errors.Wrapf(err, "iterate chunk %d of series %s", chk.Ref, builder.Labels())
+			}
+		}
+		numSeries++
+	}
+	if err := postings.Err(); err != nil {
+		return errors.Wrap(err, "iterate postings")
+	}
+
+	if numSeries != meta.Stats.NumSeries {
+		return errors.Errorf("number of series in the index (%d) doesn't match the one in meta.json (%d)", numSeries, meta.Stats.NumSeries)
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package compactor
+
+import (
+	"bytes"
+	"context"
+	"path"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/objstore"
+	"github.com/thanos-io/thanos/pkg/block"
+	"github.com/thanos-io/thanos/pkg/block/metadata"
+	"github.com/thanos-io/thanos/pkg/compact"
+
+	"github.com/cortexproject/cortex/pkg/storage/bucket"
+)
+
+func TestBlockVerificationCallback(t *testing.T) {
+	ctx := context.Background()
+	logger := log.NewNopLogger()
+
+	bkt := objstore.NewInMemBucket()
+	userBkt := bucket.NewUserBucketClient("user-1", bkt, nil)
+
+	group, err := compact.NewGroup(logger, nil, "group-1", labels.EmptyLabels(), 0, false, true, nil, nil, nil, nil, nil, nil, nil, nil, metadata.NoneFunc, 1, 1)
+	require.NoError(t, err)
+
+	t.Run("valid block", func(t *testing.T) {
+		verificationFailures := prometheus.NewCounter(prometheus.CounterOpts{})
+		blocksMarkedForDeletion := prometheus.NewCounter(prometheus.CounterOpts{})
+		tracker := newCompactionProgressTracker()
+		callback := newBlockVerificationCallback(newCompactionProgressCallback(compact.DefaultCompactionLifecycleCallback{}, "user-1", tracker), userBkt, t.TempDir(), verificationFailures, blocksMarkedForDeletion)
+
+		blockID := createTSDBBlock(t, bkt, "user-1", 10, 20, nil)
+
+		require.NoError(t, callback.PreCompactionCallback(ctx, logger, group, nil))
+		require.NoError(t, callback.PostCompactionCallback(ctx, logger, group, blockID))
+
+		assert.Equal(t, float64(0), testutil.ToFloat64(verificationFailures))
+		assert.Equal(t, float64(0), testutil.ToFloat64(blocksMarkedForDeletion))
+
+		// The wrapped callback has been called.
+		_, ok := tracker.averageDuration("user-1")
+		assert.True(t, ok)
+
+		exists, err := userBkt.Exists(ctx, path.Join(blockID.String(), metadata.DeletionMarkFilename))
+		require.NoError(t, err)
+		assert.False(t, exists)
+	})
+
+	t.Run("block with a series count not matching meta.json", func(t *testing.T) {
+		verificationFailures := prometheus.NewCounter(prometheus.CounterOpts{})
+		blocksMarkedForDeletion := prometheus.NewCounter(prometheus.CounterOpts{})
+		tracker := newCompactionProgressTracker()
+		callback := newBlockVerificationCallback(newCompactionProgressCallback(compact.DefaultCompactionLifecycleCallback{}, "user-1", tracker), userBkt, t.TempDir(), verificationFailures, blocksMarkedForDeletion)
+
+		blockID := createTSDBBlock(t, bkt, "user-1", 30, 40, nil)
+
+		meta, err := block.DownloadMeta(ctx, logger, userBkt, blockID)
+		require.NoError(t, err)
+		meta.Stats.NumSeries++
+		var buf bytes.Buffer
+		require.NoError(t, meta.Write(&buf))
+		require.NoError(t, userBkt.Upload(ctx, path.Join(blockID.String(), metadata.MetaFilename), &buf))
+
+		require.NoError(t, callback.PreCompactionCallback(ctx, logger, group, nil))
+		err = callback.PostCompactionCallback(ctx, logger, group, blockID)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "doesn't match the one in meta.json")
+
+		assert.Equal(t, float64(1), testutil.ToFloat64(verificationFailures))
+		assert.Equal(t, float64(1), testutil.ToFloat64(blocksMarkedForDeletion))
+
+		// The wrapped callback has not been called.
+		_, ok := tracker.averageDuration("user-1")
+		assert.False(t, ok)
+
+		exists, err := userBkt.Exists(ctx, path.Join(blockID.String(), metadata.DeletionMarkFilename))
+		require.NoError(t, err)
+		assert.True(t, exists)
+	})
+}
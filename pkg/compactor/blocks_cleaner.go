@@ -38,6 +38,9 @@ const (
 
 type BlocksCleanerConfig struct {
 	DeletionDelay                      time.Duration
+	DeletionDelayFromStoreGateway      bool
+	IgnoreDeletionMarksDelay           time.Duration // Store-gateway default delay after which blocks marked for deletion are filtered out.
+	StoreGatewaySyncInterval           time.Duration
 	CleanupInterval                    time.Duration
 	CleanupConcurrency                 int
 	BlockDeletionMarksMigrationEnabled bool          // TODO Discuss whether we should remove it in Cortex 1.8.0 and document that upgrading to 1.7.0 before 1.8.0 is required.
@@ -45,6 +48,8 @@ type BlocksCleanerConfig struct {
 	ShardingStrategy                   string
 	CompactionStrategy                 string
 	BlockRanges                        []int64
+	EventLogEnabled                    bool
+	IgnoreIncompleteBlocks             bool // Whether the blocks whose files are missing or partially uploaded are kept out of the bucket index.
 }
 
 type BlocksCleaner struct {
@@ -72,6 +77,7 @@ type BlocksCleaner struct {
 	runsLastSuccess                   *prometheus.GaugeVec
 	blocksCleanedTotal                prometheus.Counter
 	blocksFailedTotal                 prometheus.Counter
+	eventLogWriteFailures             prometheus.Counter
 	blocksMarkedForDeletion           *prometheus.CounterVec
 	tenantBlocks                      *prometheus.GaugeVec
 	tenantParquetBlocks               *prometheus.GaugeVec
@@ -150,6 +156,10 @@ func NewBlocksCleaner(
 			Name: "cortex_compactor_block_cleanup_failures_total",
 			Help: "Total number of blocks failed to be deleted.",
 		}),
+		eventLogWriteFailures: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_compactor_block_cleanup_event_log_write_failures_total",
+			Help: "Total number of failures writing blocks deletion events to the compaction event log. Only available when the compaction event log is enabled.",
+		}),
 		blocksMarkedForDeletion: blocksMarkedForDeletion,
 
 		// The following metrics don't have the "cortex_compactor" prefix because not strictly related to
@@ -520,6 +530,7 @@ func (c *BlocksCleaner) deleteUserMarkedForDeletion(ctx context.Context, userLog
 	}
 
 	var deletedBlocks, failed atomic.Int64
+	events := newDeletedBlocksEvents(userID)
 	err = concurrency.ForEach(ctx, blocksToDelete, defaultDeleteBlocksConcurrency, func(ctx context.Context, job any) error {
 		blockID := job.(ulid.ULID)
 		err := block.Delete(ctx, userLogger, userBucket, blockID)
@@ -530,12 +541,14 @@ func (c *BlocksCleaner) deleteUserMarkedForDeletion(ctx context.Context, userLog
 			return nil // Continue with other blocks.
 		}
 
+		events.add(blockID, compactionEventReasonTenantDeletion)
 		deletedBlocks.Add(1)
 		c.blocksCleanedTotal.Inc()
 		c.tenantBlocksCleanedTotal.WithLabelValues(userID).Inc()
 		level.Info(userLogger).Log("msg", "deleted block", "block", blockID)
 		return nil
 	})
+	c.logDeletedBlocks(ctx, userLogger, userBucket, events)
 	if err != nil {
 		return err
 	}
@@ -604,6 +617,13 @@ func (c *BlocksCleaner) deleteNonDataFiles(ctx context.Context, userLogger log.L
 		level.Info(userLogger).Log("msg", "deleted files under "+block.DebugMetas+" for tenant marked for deletion", "count", deleted, "duration", time.Since(begin), "duration_ms", time.Since(begin).Milliseconds())
 	}
 
+	begin = time.Now()
+	if deleted, err := bucket.DeletePrefix(ctx, userBucket, CompactionEventLogDirectory, userLogger, defaultDeleteBlocksConcurrency); err != nil {
+		return errors.Wrap(err, "failed to delete "+CompactionEventLogDirectory)
+	} else if deleted > 0 {
+		level.Info(userLogger).Log("msg", "deleted files under "+CompactionEventLogDirectory+" for tenant marked for deletion", "count", deleted, "duration", time.Since(begin), "duration_ms", time.Since(begin).Milliseconds())
+	}
+
 	if c.cfg.CompactionStrategy == util.CompactionStrategyPartitioning {
 		begin = time.Now()
 		// Clean up partitioned group info files
@@ -616,6 +636,21 @@ func (c *BlocksCleaner) deleteNonDataFiles(ctx context.Context, userLogger log.L
 	return nil
 }
 
+// deletionDelay returns the delay after which the blocks of the user marked for deletion are deleted.
+// If the deletion delay is read from the store-gateway config, it's never shorter than the time the
+// store-gateways need to filter out the blocks marked for deletion.
+func (c *BlocksCleaner) deletionDelay(userID string) time.Duration {
+	if !c.cfg.DeletionDelayFromStoreGateway {
+		return c.cfg.DeletionDelay
+	}
+
+	ignoreDeletionMarksDelay := c.cfg.IgnoreDeletionMarksDelay
+	if delay := c.cfgProvider.IgnoreDeletionMarksDelay(userID); delay > 0 {
+		ignoreDeletionMarksDelay = delay
+	}
+	return max(c.cfg.DeletionDelay, ignoreDeletionMarksDelay+c.cfg.StoreGatewaySyncInterval)
+}
+
 func (c *BlocksCleaner) cleanUser(ctx context.Context, userLogger log.Logger, userBucket objstore.InstrumentedBucket, userID string, firstRun bool) (returnErr error) {
 	c.blocksMarkedForDeletion.WithLabelValues(userID, reasonValueRetention)
 	startTime := time.Now()
@@ -715,6 +750,9 @@ func (c *BlocksCleaner) cleanUser(ctx context.Context, userLogger log.Logger, us
 	if parquetEnabled {
 		w.EnableParquet()
 	}
+	if c.cfg.IgnoreIncompleteBlocks {
+		w.EnableIncompleteBlocksCheck()
+	}
 
 	idx, partials, totalBlocksBlocksMarkedForNoCompaction, err := w.UpdateIndex(ctx, idx)
 	if err != nil {
@@ -728,8 +766,9 @@ func (c *BlocksCleaner) cleanUser(ctx context.Context, userLogger log.Logger, us
 	begin = time.Now()
 	blocksToDelete := make([]any, 0, len(idx.BlockDeletionMarks))
 	var mux sync.Mutex
+	deletionDelay := c.deletionDelay(userID)
 	for _, mark := range idx.BlockDeletionMarks.Clone() {
-		if time.Since(mark.GetDeletionTime()).Seconds() <= c.cfg.DeletionDelay.Seconds() {
+		if time.Since(mark.GetDeletionTime()).Seconds() <= deletionDelay.Seconds() {
 			continue
 		}
 		blocksToDelete = append(blocksToDelete, mark.ID)
@@ -738,6 +777,7 @@ func (c *BlocksCleaner) cleanUser(ctx context.Context, userLogger log.Logger, us
 
 	// Concurrently deletes blocks marked for deletion, and removes blocks from index.
 	begin = time.Now()
+	events := newDeletedBlocksEvents(userID)
 	_ = concurrency.ForEach(ctx, blocksToDelete, defaultDeleteBlocksConcurrency, func(ctx context.Context, job any) error {
 		blockID := job.(ulid.ULID)
 
@@ -752,6 +792,7 @@ func (c *BlocksCleaner) cleanUser(ctx context.Context, userLogger log.Logger, us
 		idx.RemoveBlock(blockID)
 		mux.Unlock()
 
+		events.add(blockID, compactionEventReasonDeletionMark)
 		c.blocksCleanedTotal.Inc()
 		c.tenantBlocksCleanedTotal.WithLabelValues(userID).Inc()
 		level.Info(userLogger).Log("msg", "deleted block marked for deletion", "block", blockID)
@@ -763,10 +804,12 @@ func (c *BlocksCleaner) cleanUser(ctx context.Context, userLogger log.Logger, us
 	// error if the cleanup of partial blocks fail.
 	if len(partials) > 0 {
 		begin = time.Now()
-		c.cleanUserPartialBlocks(ctx, userID, partials, idx, userBucket, userLogger)
+		c.cleanUserPartialBlocks(ctx, userID, partials, idx, userBucket, userLogger, events)
 		level.Info(userLogger).Log("msg", "finish cleaning partial blocks", "duration", time.Since(begin), "duration_ms", time.Since(begin).Milliseconds())
 	}
 
+	c.logDeletedBlocks(ctx, userLogger, userBucket, events)
+
 	if idx.IsEmpty() && len(partials) == 0 {
 		level.Info(userLogger).Log("msg", "deleting bucket index since it is empty")
 		if err := bucketindex.DeleteIndex(ctx, c.bucketClient, userID, c.cfgProvider); err != nil {
@@ -940,7 +983,7 @@ func (c *BlocksCleaner) iterPartitionGroups(ctx context.Context, userBucket objs
 
 // cleanUserPartialBlocks delete partial blocks which are safe to be deleted. The provided partials map
 // and index are updated accordingly.
-func (c *BlocksCleaner) cleanUserPartialBlocks(ctx context.Context, userID string, partials map[ulid.ULID]error, idx *bucketindex.Index, userBucket objstore.InstrumentedBucket, userLogger log.Logger) {
+func (c *BlocksCleaner) cleanUserPartialBlocks(ctx context.Context, userID string, partials map[ulid.ULID]error, idx *bucketindex.Index, userBucket objstore.InstrumentedBucket, userLogger log.Logger, events *deletedBlocksEvents) {
 	// Collect all blocks with missing meta.json into buffered channel.
 	blocks := make([]any, 0, len(partials))
 
@@ -994,6 +1037,8 @@ func (c *BlocksCleaner) cleanUserPartialBlocks(ctx context.Context, userID strin
 		delete(partials, blockID)
 		mux.Unlock()
 
+		events.add(blockID, compactionEventReasonPartialBlock)
+
 		c.blocksCleanedTotal.Inc()
 		c.tenantBlocksCleanedTotal.WithLabelValues(userID).Inc()
 		level.Info(userLogger).Log("msg", "deleted partial block marked for deletion", "block", blockID)
@@ -1001,6 +1046,14 @@ func (c *BlocksCleaner) cleanUserPartialBlocks(ctx context.Context, userID strin
 	})
 }
 
+// logDeletedBlocks writes the deleted blocks events to the compaction event log of the tenant, if enabled.
+func (c *BlocksCleaner) logDeletedBlocks(ctx context.Context, userLogger log.Logger, userBucket objstore.Bucket, events *deletedBlocksEvents) {
+	if !c.cfg.EventLogEnabled {
+		return
+	}
+	logCompactionEvents(ctx, userLogger, userBucket, events.events, c.eventLogWriteFailures)
+}
+
 // applyUserRetentionPeriod marks blocks for deletion which have aged past the retention period.
 func (c *BlocksCleaner) applyUserRetentionPeriod(ctx context.Context, idx *bucketindex.Index, retention time.Duration, userBucket objstore.Bucket, userLogger log.Logger, userID string) {
 	// The retention period of zero is a special value indicating to never delete.
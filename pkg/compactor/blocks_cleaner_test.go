@@ -949,6 +949,28 @@ func TestBlocksCleaner_ShouldRemoveBlocksOutsideRetentionPeriod(t *testing.T) {
 	}
 }
 
+func TestBlocksCleaner_DeletionDelay(t *testing.T) {
+	cfgProvider := newMockConfigProvider()
+	cfgProvider.ignoreDeletionMarksDelays["user-2"] = 24 * time.Hour
+
+	cfg := BlocksCleanerConfig{
+		DeletionDelay:            12 * time.Hour,
+		IgnoreDeletionMarksDelay: 6 * time.Hour,
+		StoreGatewaySyncInterval: 15 * time.Minute,
+	}
+	cleaner := &BlocksCleaner{cfg: cfg, cfgProvider: cfgProvider}
+	assert.Equal(t, 12*time.Hour, cleaner.deletionDelay("user-1"))
+	assert.Equal(t, 12*time.Hour, cleaner.deletionDelay("user-2"))
+
+	// The deletion delay is at least the store-gateway delay of the tenant plus the sync interval.
+	cleaner.cfg.DeletionDelayFromStoreGateway = true
+	assert.Equal(t, 12*time.Hour, cleaner.deletionDelay("user-1"))
+	assert.Equal(t, 24*time.Hour+15*time.Minute, cleaner.deletionDelay("user-2"))
+
+	cleaner.cfg.DeletionDelay = 0
+	assert.Equal(t, 6*time.Hour+15*time.Minute, cleaner.deletionDelay("user-1"))
+}
+
 func TestBlocksCleaner_CleanPartitionedGroupInfo(t *testing.T) {
 	bucketClient, _ := cortex_testutil.PrepareFilesystemBucket(t)
 	bucketClient = bucketindex.BucketWithGlobalMarkers(bucketClient)
@@ -1354,8 +1376,9 @@ func TestBlocksCleaner_EmitUserMetrics(t *testing.T) {
 }
 
 type mockConfigProvider struct {
-	userRetentionPeriods    map[string]time.Duration
-	parquetConverterEnabled map[string]bool
+	userRetentionPeriods      map[string]time.Duration
+	parquetConverterEnabled   map[string]bool
+	ignoreDeletionMarksDelays map[string]time.Duration
 }
 
 func (m *mockConfigProvider) ParquetConverterEnabled(userID string) bool {
@@ -1367,8 +1390,9 @@ func (m *mockConfigProvider) ParquetConverterEnabled(userID string) bool {
 
 func newMockConfigProvider() *mockConfigProvider {
 	return &mockConfigProvider{
-		userRetentionPeriods:    make(map[string]time.Duration),
-		parquetConverterEnabled: make(map[string]bool),
+		userRetentionPeriods:      make(map[string]time.Duration),
+		parquetConverterEnabled:   make(map[string]bool),
+		ignoreDeletionMarksDelays: make(map[string]time.Duration),
 	}
 }
 
@@ -1379,6 +1403,13 @@ func (m *mockConfigProvider) CompactorBlocksRetentionPeriod(user string) time.Du
 	return 0
 }
 
+func (m *mockConfigProvider) IgnoreDeletionMarksDelay(user string) time.Duration {
+	if result, ok := m.ignoreDeletionMarksDelays[user]; ok {
+		return result
+	}
+	return 0
+}
+
 func (m *mockConfigProvider) S3SSEType(user string) string {
 	return ""
 }
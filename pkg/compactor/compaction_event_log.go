@@ -0,0 +1,165 @@
+package compactor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/oklog/ulid/v2"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/thanos-io/objstore"
+	"github.com/thanos-io/thanos/pkg/block/metadata"
+	"github.com/thanos-io/thanos/pkg/compact"
+)
+
+// CompactionEventLogDirectory is the directory, relative to the tenant location, where the
+// compaction event log is stored. Each object in the directory is a JSONL file containing
+// one CompactionEvent per line, and is named after a ULID so that objects are sorted by time.
+const CompactionEventLogDirectory = "_compaction_log"
+
+const (
+	// CompactionEventBlockCreated is the type of the event recorded when a compacted block is uploaded.
+	CompactionEventBlockCreated = "created"
+
+	// CompactionEventBlockDeleted is the type of the event recorded when a block is deleted from the storage.
+	CompactionEventBlockDeleted = "deleted"
+
+	compactionEventReasonCompaction     = "compaction"
+	compactionEventReasonDeletionMark   = "deletion-mark"
+	compactionEventReasonPartialBlock   = "partial-block"
+	compactionEventReasonTenantDeletion = "tenant-deletion"
+)
+
+// CompactionEvent is a single entry of the compaction event log.
+type CompactionEvent struct {
+	// Unix timestamp, in milliseconds, of the event.
+	Time int64 `json:"time"`
+
+	Tenant string    `json:"tenant"`
+	Type   string    `json:"type"`
+	Block  ulid.ULID `json:"block"`
+
+	// Blocks the created block has been compacted from.
+	Sources []ulid.ULID `json:"sources,omitempty"`
+
+	// Human readable reason of the event.
+	Reason string `json:"reason"`
+}
+
+func newCompactionEvent(userID, typ string, blockID ulid.ULID, sources []ulid.ULID, reason string) CompactionEvent {
+	return CompactionEvent{
+		Time:    time.Now().UnixMilli(),
+		Tenant:  userID,
+		Type:    typ,
+		Block:   blockID,
+		Sources: sources,
+		Reason:  reason,
+	}
+}
+
+// writeCompactionEvents uploads the events as a new object of the compaction event log of the tenant.
+func writeCompactionEvents(ctx context.Context, userBkt objstore.Bucket, events []CompactionEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	buf := bytes.Buffer{}
+	enc := json.NewEncoder(&buf)
+	for _, e := range events {
+		if err := enc.Encode(e); err != nil {
+			return errors.Wrap(err, "serialize compaction event")
+		}
+	}
+
+	name := path.Join(CompactionEventLogDirectory, ulid.Make().String()+".jsonl")
+	return errors.Wrap(userBkt.Upload(ctx, name, &buf), "upload compaction events")
+}
+
+// logCompactionEvents writes the events to the compaction event log of the tenant. The event log
+// is best effort, so failures are only logged and tracked by the failures counter.
+func logCompactionEvents(ctx context.Context, logger log.Logger, userBkt objstore.Bucket, events []CompactionEvent, failures prometheus.Counter) {
+	if err := writeCompactionEvents(ctx, userBkt, events); err != nil {
+		failures.Inc()
+		level.Warn(logger).Log("msg", "failed to write compaction event log", "events", len(events), "err", err)
+	}
+}
+
+// deletedBlocksEvents collects the events of the blocks deleted concurrently by the blocks cleaner.
+type deletedBlocksEvents struct {
+	userID string
+
+	mtx    sync.Mutex
+	events []CompactionEvent
+}
+
+func newDeletedBlocksEvents(userID string) *deletedBlocksEvents {
+	return &deletedBlocksEvents{userID: userID}
+}
+
+func (e *deletedBlocksEvents) add(blockID ulid.ULID, reason string) {
+	event := newCompactionEvent(e.userID, CompactionEventBlockDeleted, blockID, nil, reason)
+
+	e.mtx.Lock()
+	e.events = append(e.events, event)
+	e.mtx.Unlock()
+}
+
+// compactionEventLogCallback wraps a compact.CompactionLifecycleCallback to record the creation
+// of each compacted block, along with its source blocks, in the compaction event log.
+type compactionEventLogCallback struct {
+	compact.CompactionLifecycleCallback
+
+	userID   string
+	bkt      objstore.Bucket
+	failures prometheus.Counter
+
+	// Groups are compacted concurrently, so we track the source blocks of each group.
+	mtx     sync.Mutex
+	sources map[string][]ulid.ULID
+}
+
+func newCompactionEventLogCallback(callback compact.CompactionLifecycleCallback, userID string, bkt objstore.Bucket, failures prometheus.Counter) *compactionEventLogCallback {
+	return &compactionEventLogCallback{
+		CompactionLifecycleCallback: callback,
+		userID:                      userID,
+		bkt:                         bkt,
+		failures:                    failures,
+		sources:                     map[string][]ulid.ULID{},
+	}
+}
+
+func (c *compactionEventLogCallback) PreCompactionCallback(ctx context.Context, logger log.Logger, g *compact.Group, toCompactBlocks []*metadata.Meta) error {
+	if err := c.CompactionLifecycleCallback.PreCompactionCallback(ctx, logger, g, toCompactBlocks); err != nil {
+		return err
+	}
+
+	sources := make([]ulid.ULID, 0, len(toCompactBlocks))
+	for _, m := range toCompactBlocks {
+		sources = append(sources, m.ULID)
+	}
+
+	c.mtx.Lock()
+	c.sources[g.Key()] = sources
+	c.mtx.Unlock()
+	return nil
+}
+
+func (c *compactionEventLogCallback) PostCompactionCallback(ctx context.Context, logger log.Logger, g *compact.Group, blockID ulid.ULID) error {
+	if err := c.CompactionLifecycleCallback.PostCompactionCallback(ctx, logger, g, blockID); err != nil {
+		return err
+	}
+
+	c.mtx.Lock()
+	sources := c.sources[g.Key()]
+	c.mtx.Unlock()
+
+	event := newCompactionEvent(c.userID, CompactionEventBlockCreated, blockID, sources, compactionEventReasonCompaction)
+	logCompactionEvents(ctx, logger, c.bkt, []CompactionEvent{event}, c.failures)
+	return nil
+}
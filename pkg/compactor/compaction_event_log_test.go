@@ -0,0 +1,155 @@
+package compactor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/oklog/ulid/v2"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/tsdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/objstore"
+	"github.com/thanos-io/thanos/pkg/block/metadata"
+	"github.com/thanos-io/thanos/pkg/compact"
+
+	"github.com/cortexproject/cortex/pkg/storage/bucket"
+	"github.com/cortexproject/cortex/pkg/storage/tsdb/bucketindex"
+	"github.com/cortexproject/cortex/pkg/util/services"
+	cortex_testutil "github.com/cortexproject/cortex/pkg/util/testutil"
+	"github.com/cortexproject/cortex/pkg/util/users"
+)
+
+func TestCompactionEventLogCallback(t *testing.T) {
+	ctx := context.Background()
+	logger := log.NewNopLogger()
+
+	group, err := compact.NewGroup(logger, nil, "group-1", labels.EmptyLabels(), 0, false, true, nil, nil, nil, nil, nil, nil, nil, nil, metadata.NoneFunc, 1, 1)
+	require.NoError(t, err)
+
+	sources := []*metadata.Meta{
+		{BlockMeta: tsdb.BlockMeta{ULID: ulid.MustNew(1, nil), MinTime: 0, MaxTime: 10}},
+		{BlockMeta: tsdb.BlockMeta{ULID: ulid.MustNew(2, nil), MinTime: 10, MaxTime: 20}},
+	}
+	compacted := ulid.MustNew(3, nil)
+
+	t.Run("should record the compacted block and its sources", func(t *testing.T) {
+		userBkt := bucket.NewUserBucketClient("user-1", objstore.NewInMemBucket(), nil)
+		failures := prometheus.NewCounter(prometheus.CounterOpts{})
+		callback := newCompactionEventLogCallback(compact.DefaultCompactionLifecycleCallback{}, "user-1", userBkt, failures)
+
+		require.NoError(t, callback.PreCompactionCallback(ctx, logger, group, sources))
+		require.NoError(t, callback.PostCompactionCallback(ctx, logger, group, compacted))
+
+		events := readCompactionEvents(t, userBkt)
+		require.Len(t, events, 1)
+		assert.Equal(t, "user-1", events[0].Tenant)
+		assert.Equal(t, CompactionEventBlockCreated, events[0].Type)
+		assert.Equal(t, compacted, events[0].Block)
+		assert.Equal(t, []ulid.ULID{sources[0].ULID, sources[1].ULID}, events[0].Sources)
+		assert.Equal(t, compactionEventReasonCompaction, events[0].Reason)
+		assert.Equal(t, float64(0), testutil.ToFloat64(failures))
+	})
+
+	t.Run("should not fail the compaction if the event log can't be written", func(t *testing.T) {
+		failures := prometheus.NewCounter(prometheus.CounterOpts{})
+		callback := newCompactionEventLogCallback(compact.DefaultCompactionLifecycleCallback{}, "user-1", &uploadFailingBucket{Bucket: objstore.NewInMemBucket()}, failures)
+
+		require.NoError(t, callback.PreCompactionCallback(ctx, logger, group, sources))
+		require.NoError(t, callback.PostCompactionCallback(ctx, logger, group, compacted))
+		assert.Equal(t, float64(1), testutil.ToFloat64(failures))
+	})
+}
+
+func TestBlocksCleaner_ShouldRecordDeletedBlocksInEventLog(t *testing.T) {
+	const userID = "user-1"
+
+	bucketClient, _ := cortex_testutil.PrepareFilesystemBucket(t)
+	bucketClient = bucketindex.BucketWithGlobalMarkers(bucketClient)
+
+	ctx := context.Background()
+	deletionDelay := 12 * time.Hour
+	block1 := createTSDBBlock(t, bucketClient, userID, 10, 20, nil)
+	block2 := createTSDBBlock(t, bucketClient, userID, 20, 30, nil)
+	createDeletionMark(t, bucketClient, userID, block2, time.Now().Add(-deletionDelay).Add(-time.Hour))
+
+	cfg := BlocksCleanerConfig{
+		DeletionDelay:      deletionDelay,
+		CleanupInterval:    time.Minute,
+		CleanupConcurrency: 1,
+		EventLogEnabled:    true,
+	}
+
+	logger := log.NewNopLogger()
+	reg := prometheus.NewRegistry()
+	scanner, err := users.NewScanner(users.UsersScannerConfig{
+		Strategy: users.UserScanStrategyList,
+	}, bucketClient, logger, reg)
+	require.NoError(t, err)
+	blocksMarkedForDeletion := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: blocksMarkedForDeletionName,
+		Help: blocksMarkedForDeletionHelp,
+	}, append(commonLabels, reasonLabelName))
+	dummyGaugeVec := prometheus.NewGaugeVec(prometheus.GaugeOpts{}, []string{"test"})
+
+	cleaner := NewBlocksCleaner(cfg, bucketClient, scanner, 60*time.Second, newMockConfigProvider(), logger, "test-cleaner", nil, time.Minute, 30*time.Second, blocksMarkedForDeletion, dummyGaugeVec)
+	require.NoError(t, services.StartAndAwaitRunning(ctx, cleaner))
+	defer services.StopAndAwaitTerminated(ctx, cleaner) //nolint:errcheck
+
+	events := readCompactionEvents(t, bucket.NewUserBucketClient(userID, bucketClient, nil))
+	require.Len(t, events, 1)
+	assert.Equal(t, userID, events[0].Tenant)
+	assert.Equal(t, CompactionEventBlockDeleted, events[0].Type)
+	assert.Equal(t, block2, events[0].Block)
+	assert.Equal(t, compactionEventReasonDeletionMark, events[0].Reason)
+	assert.Equal(t, float64(0), testutil.ToFloat64(cleaner.eventLogWriteFailures))
+
+	// The event log isn't a block, so it's not tracked in the bucket index.
+	idx, err := bucketindex.ReadIndex(ctx, bucketClient, userID, nil, logger)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []ulid.ULID{block1}, idx.Blocks.GetULIDs())
+}
+
+func readCompactionEvents(t *testing.T, userBkt objstore.Bucket) []CompactionEvent {
+	var events []CompactionEvent
+	require.NoError(t, userBkt.Iter(context.Background(), CompactionEventLogDirectory, func(name string) error {
+		r, err := userBkt.Get(context.Background(), name)
+		if err != nil {
+			return err
+		}
+		defer r.Close()
+
+		content, err := io.ReadAll(r)
+		if err != nil {
+			return err
+		}
+
+		for line := range strings.Lines(string(content)) {
+			event := CompactionEvent{}
+			if err := json.Unmarshal([]byte(line), &event); err != nil {
+				return err
+			}
+			events = append(events, event)
+		}
+		assert.Equal(t, ".jsonl", path.Ext(name))
+		return nil
+	}))
+	return events
+}
+
+type uploadFailingBucket struct {
+	objstore.Bucket
+}
+
+func (b *uploadFailingBucket) Upload(_ context.Context, _ string, _ io.Reader, _ ...objstore.ObjectUploadOption) error {
+	return errors.New("failed upload")
+}
@@ -0,0 +1,167 @@
+package compactor
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+
+	"github.com/go-kit/log/level"
+	"github.com/oklog/ulid/v2"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/thanos-io/thanos/pkg/block"
+	"github.com/thanos-io/thanos/pkg/block/metadata"
+
+	"github.com/cortexproject/cortex/pkg/storage/bucket"
+	"github.com/cortexproject/cortex/pkg/util"
+	util_log "github.com/cortexproject/cortex/pkg/util/log"
+	"github.com/cortexproject/cortex/pkg/util/services"
+)
+
+var (
+	errCompactionPlanNotSupported = errors.New("the compaction plan is not supported by the partitioning compaction strategy")
+	errCompactionPlanNotOwned     = errors.New("the tenant is not owned by the compactor")
+)
+
+// plannedGroup is a group of blocks the compactor plans to compact together.
+type plannedGroup struct {
+	Key       string      `json:"key"`
+	Sources   []ulid.ULID `json:"sources"`
+	SizeBytes int64       `json:"size_bytes"`
+
+	// Time range of the block resulting from the compaction.
+	MinTime int64 `json:"min_time"`
+	MaxTime int64 `json:"max_time"`
+}
+
+type compactionPlanResponse struct {
+	Tenant string         `json:"tenant"`
+	Groups []plannedGroup `json:"groups"`
+}
+
+// PlanHandler returns the groups of blocks the compactor would compact for the tenant in the "tenant"
+// parameter, in the order they would be compacted. The blocks are fetched and grouped the same way
+// as the compaction does, but the compaction isn't run and nothing is written to the bucket. The
+// partitioning compaction strategy isn't supported, and the shuffle sharding groups don't go
+// through the planner, as documented in the API reference.
+func (c *Compactor) PlanHandler(w http.ResponseWriter, req *http.Request) {
+	userID := req.FormValue("tenant")
+	if userID == "" {
+		http.Error(w, "missing tenant parameter", http.StatusBadRequest)
+		return
+	}
+
+	if c.State() != services.Running {
+		http.Error(w, "compactor is not running yet", http.StatusServiceUnavailable)
+		return
+	}
+
+	groups, err := c.planUser(req.Context(), userID)
+	if err != nil {
+		level.Warn(util_log.WithUserID(userID, c.logger)).Log("msg", "failed to plan the compaction of user blocks", "err", err)
+
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, errCompactionPlanNotSupported):
+			status = http.StatusNotImplemented
+		case errors.Is(err, errCompactionPlanNotOwned):
+			status = http.StatusNotFound
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	util.WriteJSONResponse(w, compactionPlanResponse{Tenant: userID, Groups: groups})
+}
+
+// planUser returns the groups of blocks of the tenant the compactor would compact. Unlike compactUser,
+// it doesn't garbage collect the blocks, write the visit markers nor halt the compaction on unexpected
+// overlapping blocks.
+func (c *Compactor) planUser(ctx context.Context, userID string) ([]plannedGroup, error) {
+	if c.compactorCfg.ShardingStrategy == util.ShardingStrategyShuffle && c.compactorCfg.CompactionStrategy == util.CompactionStrategyPartitioning {
+		return nil, errCompactionPlanNotSupported
+	}
+
+	if owned, err := c.ownUserForCompaction(userID); err != nil {
+		return nil, err
+	} else if !owned {
+		return nil, errCompactionPlanNotOwned
+	}
+
+	bucket := bucket.NewUserBucketClient(userID, c.bucketClient, c.limits)
+	ulogger := util_log.WithUserID(userID, c.logger)
+
+	// The metadata isn't cached on disk, so that the planning doesn't interfere with a running compaction.
+	fetcher, err := c.newUserMetaFetcher(userID, bucket, ulogger, prometheus.NewRegistry(), "", block.NewBaseFetcherMetrics(nil), block.NewFetcherMetrics(nil, nil, nil))
+	if err != nil {
+		return nil, err
+	}
+	metas, _, err := fetcher.Fetch(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	planner := c.blocksPlannerFactory(ctx, bucket, ulogger, c.compactorCfg, fetcher.noCompactMarkerFilter, c.ringLifecycler, userID, c.blockVisitMarkerReadFailed, c.blockVisitMarkerWriteFailed, c.compactorMetrics, fetcher.ignoreDeletionMarkFilter)
+	grouper := c.blocksGrouperFactory(ctx, c.compactorCfg, bucket, ulogger, c.BlocksMarkedForNoCompaction, c.blockVisitMarkerReadFailed, c.blockVisitMarkerWriteFailed, c.compactorMetrics.getSyncerMetrics(userID), c.compactorMetrics, c.ring, c.ringLifecycler, c.limits, userID, fetcher.noCompactMarkerFilter, c.ingestionReplicationFactor)
+
+	var planned []plannedGroup
+
+	// The shuffle sharding grouper marks the blocks of its groups as visited, and the shuffle sharding
+	// planner only plans the blocks visited by the compactor, so the groups are taken from the grouper
+	// before being marked, skipping the ones visited by another compactor like Groups does.
+	if grouper, ok := grouper.(*ShuffleShardingGrouper); ok {
+		groups, _, _, err := grouper.ownedGroups(metas)
+		if err != nil {
+			return nil, err
+		}
+		for _, group := range groups {
+			if visited, err := grouper.isGroupVisited(group.blocks, grouper.ringLifecyclerID); err != nil {
+				return nil, err
+			} else if visited {
+				continue
+			}
+			planned = append(planned, newPlannedGroup(createGroupKey(hashGroup(userID, group.rangeStart, group.rangeEnd), group), group.blocks))
+		}
+		return planned, nil
+	}
+
+	groups, err := grouper.Groups(metas)
+	if err != nil {
+		return nil, err
+	}
+	for _, group := range groups {
+		groupMetas := make([]*metadata.Meta, 0, len(group.IDs()))
+		for _, id := range group.IDs() {
+			groupMetas = append(groupMetas, metas[id])
+		}
+		sortMetasByMinTime(groupMetas)
+
+		toCompact, err := planner.Plan(ctx, groupMetas, nil, group.Extensions())
+		if err != nil {
+			return nil, err
+		}
+		if len(toCompact) > 0 {
+			planned = append(planned, newPlannedGroup(group.Key(), toCompact))
+		}
+	}
+	return planned, nil
+}
+
+func newPlannedGroup(key string, metas []*metadata.Meta) plannedGroup {
+	group := plannedGroup{
+		Key:     key,
+		Sources: make([]ulid.ULID, 0, len(metas)),
+		MinTime: metas[0].MinTime,
+		MaxTime: metas[0].MaxTime,
+	}
+	for _, m := range metas {
+		group.Sources = append(group.Sources, m.ULID)
+		for _, f := range m.Thanos.Files {
+			group.SizeBytes += f.SizeBytes
+		}
+		group.MinTime = min(group.MinTime, m.MinTime)
+		group.MaxTime = max(group.MaxTime, m.MaxTime)
+	}
+	slices.SortFunc(group.Sources, func(a, b ulid.ULID) int { return a.Compare(b) })
+	return group
+}
@@ -0,0 +1,139 @@
+package compactor
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/oklog/ulid/v2"
+	prom_testutil "github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/thanos/pkg/block"
+	"github.com/thanos-io/thanos/pkg/block/metadata"
+
+	"github.com/cortexproject/cortex/pkg/ring"
+	"github.com/cortexproject/cortex/pkg/ring/kv/consul"
+	"github.com/cortexproject/cortex/pkg/storage/bucket"
+	"github.com/cortexproject/cortex/pkg/storage/tsdb/bucketindex"
+	"github.com/cortexproject/cortex/pkg/util"
+	"github.com/cortexproject/cortex/pkg/util/services"
+	cortex_testutil "github.com/cortexproject/cortex/pkg/util/test"
+	"github.com/cortexproject/cortex/pkg/util/testutil"
+)
+
+func TestCompactor_PlanHandler(t *testing.T) {
+	bucketClient, _ := testutil.PrepareFilesystemBucket(t)
+	bucketClient = bucketindex.BucketWithGlobalMarkers(bucketClient)
+
+	b1 := createTSDBBlock(t, bucketClient, "user-1", 10, 20, nil)
+	b2 := createTSDBBlock(t, bucketClient, "user-1", 20, 30, nil)
+	createTSDBBlock(t, bucketClient, "user-1", 30, 40, map[string]string{"zone": "a"})
+
+	c, _, tsdbPlanner, _, _ := prepare(t, prepareConfig(), bucketClient, nil)
+
+	// Mock the planner as if there's no compaction to do, until the first run has completed.
+	noCompactionCall := tsdbPlanner.On("Plan", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return([]*metadata.Meta{}, nil)
+
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), c))
+	t.Cleanup(func() {
+		require.NoError(t, services.StopAndAwaitTerminated(context.Background(), c))
+	})
+	cortex_testutil.Poll(t, 5*time.Second, 1.0, func() any {
+		return prom_testutil.ToFloat64(c.CompactionRunsCompleted)
+	})
+
+	// The planner plans the group with the two blocks without external labels.
+	userBkt := bucket.NewPrefixedBucketClient(bucketClient, "user-1")
+	m1, err := block.DownloadMeta(context.Background(), log.NewNopLogger(), userBkt, b1)
+	require.NoError(t, err)
+	m2, err := block.DownloadMeta(context.Background(), log.NewNopLogger(), userBkt, b2)
+	require.NoError(t, err)
+	m1.Thanos.Files = []metadata.File{{RelPath: block.IndexFilename, SizeBytes: 100}, {RelPath: block.MetaFilename, SizeBytes: 10}}
+	m2.Thanos.Files = []metadata.File{{RelPath: block.IndexFilename, SizeBytes: 200}, {RelPath: block.MetaFilename, SizeBytes: 10}}
+
+	noCompactionCall.Unset()
+	tsdbPlanner.On("Plan", mock.Anything, mock.MatchedBy(func(metas []*metadata.Meta) bool { return len(metas) == 2 }), mock.Anything, mock.Anything).Return([]*metadata.Meta{&m1, &m2}, nil)
+	tsdbPlanner.On("Plan", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return([]*metadata.Meta{}, nil)
+
+	resp := requestCompactionPlan(t, c, "user-1")
+	require.Len(t, resp.Groups, 1)
+	assert.Equal(t, plannedGroup{
+		Key:       m1.Thanos.GroupKey(),
+		Sources:   sortedULIDs(b1, b2),
+		SizeBytes: 320,
+		MinTime:   10,
+		MaxTime:   30,
+	}, resp.Groups[0])
+
+	// The tenant parameter is required.
+	rec := httptest.NewRecorder()
+	c.PlanHandler(rec, httptest.NewRequest(http.MethodGet, "/compactor/plan", nil))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestCompactor_PlanHandler_ShuffleSharding(t *testing.T) {
+	bucketClient, _ := testutil.PrepareFilesystemBucket(t)
+	bucketClient = bucketindex.BucketWithGlobalMarkers(bucketClient)
+
+	twoHours := 2 * time.Hour.Milliseconds()
+	b1 := createTSDBBlock(t, bucketClient, "user-1", 10, 20, nil)
+	b2 := createTSDBBlock(t, bucketClient, "user-1", 20, 30, nil)
+
+	// The blocks of the next range are visited by another compactor.
+	b3 := createTSDBBlock(t, bucketClient, "user-1", twoHours+10, twoHours+20, nil)
+	createTSDBBlock(t, bucketClient, "user-1", twoHours+20, twoHours+30, nil)
+	createBlockVisitMarker(t, bucketClient, "user-1", b3)
+
+	ringStore, closer := consul.NewInMemoryClient(ring.GetCodec(), log.NewNopLogger(), nil)
+	t.Cleanup(func() { assert.NoError(t, closer.Close()) })
+
+	cfg := prepareConfig()
+	cfg.ShardingEnabled = true
+	cfg.ShardingStrategy = util.ShardingStrategyShuffle
+	cfg.ShardingRing.InstanceID = "compactor-1"
+	cfg.ShardingRing.InstanceAddr = "1.2.3.4"
+	cfg.ShardingRing.KVStore.Mock = ringStore
+
+	c, _, tsdbPlanner, _, _ := prepare(t, cfg, bucketClient, nil)
+
+	// The planner isn't called by the compaction plan, which takes the groups from the grouper.
+	tsdbPlanner.On("Plan", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return([]*metadata.Meta{}, nil)
+
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), c))
+	t.Cleanup(func() {
+		require.NoError(t, services.StopAndAwaitTerminated(context.Background(), c))
+	})
+	cortex_testutil.Poll(t, 5*time.Second, 1.0, func() any {
+		return prom_testutil.ToFloat64(c.CompactionRunsCompleted)
+	})
+
+	resp := requestCompactionPlan(t, c, "user-1")
+	require.Len(t, resp.Groups, 1)
+	assert.Equal(t, sortedULIDs(b1, b2), resp.Groups[0].Sources)
+	assert.Equal(t, int64(10), resp.Groups[0].MinTime)
+	assert.Equal(t, int64(30), resp.Groups[0].MaxTime)
+	assert.NotEmpty(t, resp.Groups[0].Key)
+}
+
+func requestCompactionPlan(t *testing.T, c *Compactor, userID string) compactionPlanResponse {
+	rec := httptest.NewRecorder()
+	c.PlanHandler(rec, httptest.NewRequest(http.MethodGet, "/compactor/plan?tenant="+userID, nil))
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	resp := compactionPlanResponse{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, userID, resp.Tenant)
+	return resp
+}
+
+func sortedULIDs(ids ...ulid.ULID) []ulid.ULID {
+	slices.SortFunc(ids, func(a, b ulid.ULID) int { return a.Compare(b) })
+	return ids
+}
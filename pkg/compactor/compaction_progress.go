@@ -0,0 +1,112 @@
+package compactor
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/oklog/ulid/v2"
+	"github.com/thanos-io/thanos/pkg/block/metadata"
+	"github.com/thanos-io/thanos/pkg/compact"
+)
+
+// compactionDurationsWindowSize is the number of most recent compactions of a tenant
+// used to compute its average compaction duration.
+const compactionDurationsWindowSize = 10
+
+// compactionProgressTracker keeps track of the most recent compaction durations of each
+// tenant, used to estimate how long it will take to complete the planned compactions.
+type compactionProgressTracker struct {
+	mtx       sync.Mutex
+	durations map[string][]time.Duration
+}
+
+func newCompactionProgressTracker() *compactionProgressTracker {
+	return &compactionProgressTracker{
+		durations: map[string][]time.Duration{},
+	}
+}
+
+// observe records the duration of a completed compaction for the tenant.
+func (t *compactionProgressTracker) observe(userID string, d time.Duration) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	durations := append(t.durations[userID], d)
+	if len(durations) > compactionDurationsWindowSize {
+		durations = durations[len(durations)-compactionDurationsWindowSize:]
+	}
+	t.durations[userID] = durations
+}
+
+// averageDuration returns the rolling average of the recent compaction durations of the tenant,
+// and false if no compaction has been observed for the tenant yet.
+func (t *compactionProgressTracker) averageDuration(userID string) (time.Duration, bool) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	durations := t.durations[userID]
+	if len(durations) == 0 {
+		return 0, false
+	}
+
+	var sum time.Duration
+	for _, d := range durations {
+		sum += d
+	}
+	return sum / time.Duration(len(durations)), true
+}
+
+func (t *compactionProgressTracker) deleteUser(userID string) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	delete(t.durations, userID)
+}
+
+// compactionProgressCallback wraps a compact.CompactionLifecycleCallback to record the
+// duration of each successful group compaction in the compactionProgressTracker.
+type compactionProgressCallback struct {
+	compact.CompactionLifecycleCallback
+
+	userID  string
+	tracker *compactionProgressTracker
+
+	// Groups are compacted concurrently, so we track the start time of each group.
+	mtx        sync.Mutex
+	startTimes map[string]time.Time
+}
+
+func newCompactionProgressCallback(callback compact.CompactionLifecycleCallback, userID string, tracker *compactionProgressTracker) *compactionProgressCallback {
+	return &compactionProgressCallback{
+		CompactionLifecycleCallback: callback,
+		userID:                      userID,
+		tracker:                     tracker,
+		startTimes:                  map[string]time.Time{},
+	}
+}
+
+func (c *compactionProgressCallback) PreCompactionCallback(ctx context.Context, logger log.Logger, g *compact.Group, toCompactBlocks []*metadata.Meta) error {
+	if err := c.CompactionLifecycleCallback.PreCompactionCallback(ctx, logger, g, toCompactBlocks); err != nil {
+		return err
+	}
+
+	c.mtx.Lock()
+	c.startTimes[g.Key()] = time.Now()
+	c.mtx.Unlock()
+	return nil
+}
+
+func (c *compactionProgressCallback) PostCompactionCallback(ctx context.Context, logger log.Logger, g *compact.Group, blockID ulid.ULID) error {
+	c.mtx.Lock()
+	startTime, ok := c.startTimes[g.Key()]
+	delete(c.startTimes, g.Key())
+	c.mtx.Unlock()
+
+	if ok {
+		c.tracker.observe(c.userID, time.Since(startTime))
+	}
+
+	return c.CompactionLifecycleCallback.PostCompactionCallback(ctx, logger, g, blockID)
+}
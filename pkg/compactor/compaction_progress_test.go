@@ -0,0 +1,67 @@
+package compactor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/oklog/ulid/v2"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/thanos/pkg/block/metadata"
+	"github.com/thanos-io/thanos/pkg/compact"
+)
+
+func TestCompactionProgressTracker(t *testing.T) {
+	tracker := newCompactionProgressTracker()
+
+	_, ok := tracker.averageDuration("user-1")
+	assert.False(t, ok)
+
+	tracker.observe("user-1", 10*time.Second)
+	tracker.observe("user-1", 20*time.Second)
+	tracker.observe("user-2", time.Minute)
+
+	avg, ok := tracker.averageDuration("user-1")
+	require.True(t, ok)
+	assert.Equal(t, 15*time.Second, avg)
+
+	// Only the most recent compactions are taken into account.
+	for i := 0; i < compactionDurationsWindowSize; i++ {
+		tracker.observe("user-1", 30*time.Second)
+	}
+	avg, ok = tracker.averageDuration("user-1")
+	require.True(t, ok)
+	assert.Equal(t, 30*time.Second, avg)
+
+	avg, ok = tracker.averageDuration("user-2")
+	require.True(t, ok)
+	assert.Equal(t, time.Minute, avg)
+
+	tracker.deleteUser("user-2")
+	_, ok = tracker.averageDuration("user-2")
+	assert.False(t, ok)
+}
+
+func TestCompactionProgressCallback(t *testing.T) {
+	tracker := newCompactionProgressTracker()
+	callback := newCompactionProgressCallback(compact.DefaultCompactionLifecycleCallback{}, "user-1", tracker)
+
+	group, err := compact.NewGroup(log.NewNopLogger(), nil, "group-1", labels.EmptyLabels(), 0, false, true, nil, nil, nil, nil, nil, nil, nil, nil, metadata.NoneFunc, 1, 1)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	require.NoError(t, callback.PreCompactionCallback(ctx, log.NewNopLogger(), group, nil))
+	_, ok := tracker.averageDuration("user-1")
+	assert.False(t, ok)
+
+	require.NoError(t, callback.PostCompactionCallback(ctx, log.NewNopLogger(), group, ulid.MustNew(1, nil)))
+	_, ok = tracker.averageDuration("user-1")
+	assert.True(t, ok)
+
+	// A compaction which has not been started is not tracked.
+	require.NoError(t, callback.PostCompactionCallback(ctx, log.NewNopLogger(), group, ulid.MustNew(2, nil)))
+	assert.Len(t, tracker.durations["user-1"], 1)
+}
@@ -61,6 +61,10 @@ var (
 	supportedCompactionStrategies            = []string{util.CompactionStrategyDefault, util.CompactionStrategyPartitioning}
 	errInvalidCompactionStrategy             = errors.New("invalid compaction strategy")
 	errInvalidCompactionStrategyPartitioning = errors.New("compaction strategy partitioning can only be enabled when shuffle sharding is enabled")
+	errInvalidTenantPriority                 = errors.New("invalid tenant priority")
+	errInvalidHaltOnOverlappingBlocks        = errors.New("halting compaction on overlapping blocks is not supported by the partitioning compaction strategy")
+	errInvalidBlockFilesCacheDir             = errors.New("the block files cache dir must not be within the compact/ directory of the compactor data dir")
+	errInvalidBlockFilesCacheMaxSize         = errors.New("the block files cache max size must be greater than 0")
 
 	DefaultBlocksGrouperFactory = func(ctx context.Context, cfg Config, bkt objstore.InstrumentedBucket, logger log.Logger, blocksMarkedForNoCompaction prometheus.Counter, _ prometheus.Counter, _ prometheus.Counter, syncerMetrics *compact.SyncerMetrics, compactorMetrics *compactorMetrics, _ *ring.Ring, _ *ring.Lifecycler, _ Limits, _ string, _ *compact.GatherNoCompactionMarkFilter, _ int) compact.Grouper {
 		return compact.NewDefaultGrouperWithMetrics(
@@ -250,6 +254,9 @@ type Limits interface {
 	CompactorTenantShardSize(userID string) float64
 	CompactorPartitionIndexSizeBytes(userID string) int64
 	CompactorPartitionSeriesCount(userID string) int64
+	CompactorMaxCompactionLevel(userID string) int
+	CompactorVerticalCompactionOnly(userID string) bool
+	CompactorMinBlockAge(userID string) time.Duration
 }
 
 // Config holds the Compactor config.
@@ -265,10 +272,16 @@ type Config struct {
 	CleanupInterval                       time.Duration            `yaml:"cleanup_interval"`
 	CleanupConcurrency                    int                      `yaml:"cleanup_concurrency"`
 	DeletionDelay                         time.Duration            `yaml:"deletion_delay"`
+	DeletionDelayFromStoreGateway         bool                     `yaml:"deletion_delay_from_store_gateway"`
 	TenantCleanupDelay                    time.Duration            `yaml:"tenant_cleanup_delay"`
 	SkipBlocksWithOutOfOrderChunksEnabled bool                     `yaml:"skip_blocks_with_out_of_order_chunks_enabled"`
+	VerifyUploadedBlocks                  bool                     `yaml:"verify_uploaded_blocks"`
+	HaltOnOverlappingBlocks               bool                     `yaml:"halt_on_overlapping_blocks"`
+	EventLogEnabled                       bool                     `yaml:"event_log_enabled"`
 	BlockFilesConcurrency                 int                      `yaml:"block_files_concurrency"`
 	BlocksFetchConcurrency                int                      `yaml:"blocks_fetch_concurrency"`
+	BlockFilesCacheDir                    string                   `yaml:"block_files_cache_dir"`
+	BlockFilesCacheMaxSizeBytes           int64                    `yaml:"block_files_cache_max_size_bytes"`
 
 	// Whether the migration of block deletion marks to the global markers location is enabled.
 	BlockDeletionMarksMigrationEnabled bool `yaml:"block_deletion_marks_migration_enabled"`
@@ -285,6 +298,9 @@ type Config struct {
 	// Compaction strategy.
 	CompactionStrategy string `yaml:"compaction_strategy"`
 
+	// Order in which the tenants are compacted.
+	TenantPriority string `yaml:"tenant_priority"`
+
 	// No need to add options to customize the retry backoff,
 	// given the defaults should be fine, but allow to override
 	// it in tests.
@@ -329,14 +345,21 @@ func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
 	f.BoolVar(&cfg.ShardingEnabled, "compactor.sharding-enabled", false, "Shard tenants across multiple compactor instances. Sharding is required if you run multiple compactor instances, in order to coordinate compactions and avoid race conditions leading to the same tenant blocks simultaneously compacted by different instances.")
 	f.StringVar(&cfg.ShardingStrategy, "compactor.sharding-strategy", util.ShardingStrategyDefault, fmt.Sprintf("The sharding strategy to use. Supported values are: %s.", strings.Join(supportedShardingStrategies, ", ")))
 	f.StringVar(&cfg.CompactionStrategy, "compactor.compaction-strategy", util.CompactionStrategyDefault, fmt.Sprintf("The compaction strategy to use. Supported values are: %s.", strings.Join(supportedCompactionStrategies, ", ")))
+	f.StringVar(&cfg.TenantPriority, "compactor.tenant-priority", TenantPriorityRandom, fmt.Sprintf("The order in which the tenants are compacted in each compaction run. Supported values are: %s. With %s, the tenant whose oldest block shorter than the largest block range is the oldest is compacted first, based on the tenants bucket index. The blocks marked for deletion or no compaction, and the blocks which are the only one of their largest block range, are ignored.", strings.Join(supportedTenantPriorities, ", "), TenantPriorityOldestUncompactedFirst))
 	f.DurationVar(&cfg.DeletionDelay, "compactor.deletion-delay", 12*time.Hour, "Time before a block marked for deletion is deleted from bucket. "+
 		"If not 0, blocks will be marked for deletion and compactor component will permanently delete blocks marked for deletion from the bucket. "+
 		"If 0, blocks will be deleted straight away. Note that deleting blocks immediately can cause query failures.")
+	f.BoolVar(&cfg.DeletionDelayFromStoreGateway, "compactor.deletion-delay-from-store-gateway", false, "When enabled, the deletion delay of each tenant is at least the delay after which the store-gateways filter out the tenant blocks marked for deletion, configured by -store-gateway.ignore-deletion-marks-delay or -blocks-storage.bucket-store.ignore-deletion-marks-delay, plus the -blocks-storage.bucket-store.sync-interval. This prevents deleting blocks still queried through the store-gateways. The -compactor.deletion-delay is used as minimum deletion delay.")
 	f.DurationVar(&cfg.TenantCleanupDelay, "compactor.tenant-cleanup-delay", 6*time.Hour, "For tenants marked for deletion, this is time between deleting of last block, and doing final cleanup (marker files, debug files) of the tenant.")
 	f.BoolVar(&cfg.BlockDeletionMarksMigrationEnabled, "compactor.block-deletion-marks-migration-enabled", false, "When enabled, at compactor startup the bucket will be scanned and all found deletion marks inside the block location will be copied to the markers global location too. This option can (and should) be safely disabled as soon as the compactor has successfully run at least once.")
 	f.BoolVar(&cfg.SkipBlocksWithOutOfOrderChunksEnabled, "compactor.skip-blocks-with-out-of-order-chunks-enabled", false, "When enabled, mark blocks containing index with out-of-order chunks for no compact instead of halting the compaction.")
+	f.BoolVar(&cfg.VerifyUploadedBlocks, "compactor.verify-uploaded-blocks", false, "When enabled, each compacted block is downloaded back from the storage and verified once uploaded, before marking its source blocks for deletion. If the verification fails, the compacted block is marked for deletion and the compaction is retried.")
+	f.BoolVar(&cfg.HaltOnOverlappingBlocks, "compactor.halt-on-overlapping-blocks", false, "When enabled, the compaction of a tenant is halted if overlapping compacted blocks are found, instead of merging them. Overlapping blocks uploaded by ingesters or containing out-of-order samples are expected and don't halt the compaction. While halted, the "+CompactionHaltMarkFilename+" marker is stored in the tenant markers location, and the compaction of the tenant resumes once the marker is deleted.")
+	f.BoolVar(&cfg.EventLogEnabled, "compactor.event-log-enabled", false, "EXPERIMENTAL: When enabled, the compactor records every compacted block created and every block deleted, in JSONL objects stored under the "+CompactionEventLogDirectory+"/ directory of the tenant location. The event log is best effort, and failing to write it doesn't fail the compaction or the cleanup.")
 	f.IntVar(&cfg.BlockFilesConcurrency, "compactor.block-files-concurrency", 10, "Number of goroutines to use when fetching/uploading block files from object storage.")
 	f.IntVar(&cfg.BlocksFetchConcurrency, "compactor.blocks-fetch-concurrency", 3, "Number of goroutines to use when fetching blocks from object storage when compacting.")
+	f.StringVar(&cfg.BlockFilesCacheDir, "compactor.block-files-cache-dir", "", "EXPERIMENTAL: Directory in which to persist the files of the blocks downloaded for compaction, so that they're not downloaded again by retried compactions and after a compactor restart. A cached block is used only if its meta.json and index are cached. The directory must not be within the compact/ directory of -compactor.data-dir. If empty, the block files cache is disabled.")
+	f.Int64Var(&cfg.BlockFilesCacheMaxSizeBytes, "compactor.block-files-cache-max-size-bytes", 10*1024*1024*1024, "EXPERIMENTAL: Max size in bytes of the block files cache. Once exceeded, the least recently used blocks are evicted.")
 
 	f.Var(&cfg.EnabledTenants, "compactor.enabled-tenants", "Comma separated list of tenants that can be compacted. If specified, only these tenants will be compacted by compactor, otherwise all tenants can be compacted. Subject to sharding.")
 	f.Var(&cfg.DisabledTenants, "compactor.disabled-tenants", "Comma separated list of tenants that cannot be compacted by this compactor. If specified, and compactor would normally pick given tenant for compaction (via -compactor.enabled-tenants or sharding), it will be ignored instead.")
@@ -385,14 +408,52 @@ func (cfg *Config) Validate(limits validation.Limits) error {
 		return errInvalidCompactionStrategyPartitioning
 	}
 
+	if cfg.HaltOnOverlappingBlocks && cfg.CompactionStrategy == util.CompactionStrategyPartitioning {
+		return errInvalidHaltOnOverlappingBlocks
+	}
+
+	if !slices.Contains(supportedTenantPriorities, cfg.TenantPriority) {
+		return errInvalidTenantPriority
+	}
+
+	if cfg.BlockFilesCacheDir != "" {
+		// The compact/ directory is removed at the end of each successful compaction.
+		compactRootDir := filepath.Join(cfg.DataDir, "compact")
+		if rel, err := filepath.Rel(compactRootDir, cfg.BlockFilesCacheDir); err == nil && !strings.HasPrefix(rel, "..") {
+			return errInvalidBlockFilesCacheDir
+		}
+		if cfg.BlockFilesCacheMaxSizeBytes <= 0 {
+			return errInvalidBlockFilesCacheMaxSize
+		}
+	}
+
 	return nil
 }
 
+// ValidateDeletionDelay logs a warning if the blocks marked for deletion can be deleted from the bucket
+// while the store-gateways still consider them queryable, because the deletion delay is shorter than
+// the store-gateway ignore deletion marks delay of the default limits.
+func (cfg *Config) ValidateDeletionDelay(bucketStoreCfg cortex_tsdb.BucketStoreConfig, limits validation.Limits, logger log.Logger) {
+	if cfg.DeletionDelayFromStoreGateway {
+		return
+	}
+
+	ignoreDeletionMarksDelay := bucketStoreCfg.IgnoreDeletionMarksDelay
+	if delay := time.Duration(limits.IgnoreDeletionMarksDelay); delay > 0 {
+		ignoreDeletionMarksDelay = delay
+	}
+	if cfg.DeletionDelay < ignoreDeletionMarksDelay {
+		level.Warn(logger).Log("msg", "the compactor deletion delay is shorter than the store-gateway ignore deletion marks delay, so blocks still queried through the store-gateways can be deleted; consider enabling -compactor.deletion-delay-from-store-gateway",
+			"deletion_delay", cfg.DeletionDelay, "ignore_deletion_marks_delay", ignoreDeletionMarksDelay)
+	}
+}
+
 // ConfigProvider defines the per-tenant config provider for the Compactor.
 type ConfigProvider interface {
 	bucket.TenantConfigProvider
 	ParquetConverterEnabled(userID string) bool
 	CompactorBlocksRetentionPeriod(user string) time.Duration
+	IgnoreDeletionMarksDelay(userID string) time.Duration
 }
 
 // Compactor is a multi-tenant TSDB blocks compactor based on Thanos.
@@ -433,6 +494,9 @@ type Compactor struct {
 	// Client used to run operations on the bucket storing blocks.
 	bucketClient objstore.InstrumentedBucket
 
+	// Local cache of the files of the blocks downloaded for compaction, nil if disabled.
+	blockFilesCache *blockFilesCache
+
 	// Ring used for sharding compactions.
 	ringLifecycler         *ring.Lifecycler
 	ring                   *ring.Ring
@@ -670,6 +734,13 @@ func (c *Compactor) starting(ctx context.Context) error {
 		}
 	}
 
+	if c.compactorCfg.BlockFilesCacheDir != "" {
+		c.blockFilesCache, err = newBlockFilesCache(c.compactorCfg.BlockFilesCacheDir, c.compactorCfg.BlockFilesCacheMaxSizeBytes, c.logger, c.registerer)
+		if err != nil {
+			return errors.Wrap(err, "failed to create block files cache")
+		}
+	}
+
 	// Create the users scanner.
 	c.usersScanner, err = users.NewScanner(c.storageCfg.UsersScanner, c.bucketClient, c.logger, extprom.WrapRegistererWith(prometheus.Labels{"component": "compactor"}, c.registerer))
 	if err != nil {
@@ -746,6 +817,9 @@ func (c *Compactor) starting(ctx context.Context) error {
 	// Create the blocks cleaner (service).
 	c.blocksCleaner = NewBlocksCleaner(BlocksCleanerConfig{
 		DeletionDelay:                      c.compactorCfg.DeletionDelay,
+		DeletionDelayFromStoreGateway:      c.compactorCfg.DeletionDelayFromStoreGateway,
+		IgnoreDeletionMarksDelay:           c.storageCfg.BucketStore.IgnoreDeletionMarksDelay,
+		StoreGatewaySyncInterval:           c.storageCfg.BucketStore.SyncInterval,
 		CleanupInterval:                    util.DurationWithJitter(c.compactorCfg.CleanupInterval, 0.1),
 		CleanupConcurrency:                 c.compactorCfg.CleanupConcurrency,
 		BlockDeletionMarksMigrationEnabled: c.compactorCfg.BlockDeletionMarksMigrationEnabled,
@@ -753,6 +827,8 @@ func (c *Compactor) starting(ctx context.Context) error {
 		ShardingStrategy:                   c.compactorCfg.ShardingStrategy,
 		CompactionStrategy:                 c.compactorCfg.CompactionStrategy,
 		BlockRanges:                        c.compactorCfg.BlockRanges.ToMilliseconds(),
+		EventLogEnabled:                    c.compactorCfg.EventLogEnabled,
+		IgnoreIncompleteBlocks:             c.storageCfg.BucketStore.IgnoreIncompleteBlocks,
 	}, cleanerBucketClient, cleanerUsersScanner, c.compactorCfg.CompactionVisitMarkerTimeout, c.limits, c.parentLogger, cleanerRingLifecyclerID, c.registerer, c.compactorCfg.CleanerVisitMarkerTimeout, c.compactorCfg.CleanerVisitMarkerFileUpdateInterval,
 		c.compactorMetrics.syncerBlocksMarkedForDeletion, c.compactorMetrics.remainingPlannedCompactions)
 
@@ -863,6 +939,11 @@ func (c *Compactor) compactUsers(ctx context.Context) {
 		userIDs[i], userIDs[j] = userIDs[j], userIDs[i]
 	})
 
+	// The shuffled order is kept between the users with the same priority.
+	if c.compactorCfg.TenantPriority == TenantPriorityOldestUncompactedFirst {
+		userIDs = c.sortUsersByOldestUncompactedBlock(ctx, userIDs)
+	}
+
 	// Keep track of users owned by this shard, so that we can delete the local files for all other users.
 	ownedUsers := map[string]struct{}{}
 	for _, userID := range userIDs {
@@ -895,6 +976,20 @@ func (c *Compactor) compactUsers(ctx context.Context) {
 
 		ownedUsers[userID] = struct{}{}
 
+		if c.compactorCfg.HaltOnOverlappingBlocks {
+			if halted, err := compactionHaltMarkExists(ctx, bucket.NewUserBucketClient(userID, c.bucketClient, c.limits)); err != nil {
+				c.CompactionRunSkippedTenants.Inc()
+				level.Warn(c.logger).Log("msg", "unable to check if user compaction is halted", "user", userID, "err", err)
+				continue
+			} else if halted {
+				c.CompactionRunSkippedTenants.Inc()
+				c.compactorMetrics.overlappingBlocksHalt.WithLabelValues(c.compactorMetrics.getCommonLabelValues(userID)...).Set(1)
+				level.Warn(c.logger).Log("msg", "skipping user because its compaction is halted, delete the compaction halt marker to resume it", "user", userID, "marker", GetCompactionHaltMarkPath())
+				continue
+			}
+			c.compactorMetrics.overlappingBlocksHalt.WithLabelValues(c.compactorMetrics.getCommonLabelValues(userID)...).Set(0)
+		}
+
 		if markedForDeletion, err := users.TenantDeletionMarkExists(ctx, c.bucketClient, userID); err != nil {
 			c.CompactionRunSkippedTenants.Inc()
 			level.Warn(c.logger).Log("msg", "unable to check if user is marked for deletion", "user", userID, "err", err)
@@ -982,6 +1077,11 @@ func (c *Compactor) compactUserWithRetries(ctx context.Context, userID string) e
 			c.compactorMetrics.compactionErrorsCount.WithLabelValues(userID, haltError).Inc()
 			return lastErr
 		}
+		if errors.Is(lastErr, errOverlappingBlocksHalt) {
+			level.Error(c.logger).Log("msg", "compaction halted because of unexpected overlapping blocks", "user", userID, "err", lastErr)
+			c.compactorMetrics.compactionErrorsCount.WithLabelValues(userID, haltError).Inc()
+			return lastErr
+		}
 		c.compactorMetrics.compactionErrorsCount.WithLabelValues(userID, retriableError).Inc()
 
 		retries.Wait()
@@ -1003,6 +1103,95 @@ func (c *Compactor) compactUser(ctx context.Context, userID string) error {
 	ulogger := util_log.WithUserID(userID, c.logger)
 	ulogger = util_log.WithExecutionID(ulid.MustNew(ulid.Now(), crypto_rand.Reader).String(), ulogger)
 
+	fetcher, err := c.newUserMetaFetcher(userID, bucket, ulogger, reg, c.metaSyncDirForUser(userID), c.compactorMetrics.getBaseFetcherMetrics(), c.compactorMetrics.getMetaFetcherMetrics())
+	if err != nil {
+		return err
+	}
+
+	syncerMetrics := c.compactorMetrics.getSyncerMetrics(userID)
+	syncer, err := compact.NewMetaSyncerWithMetrics(
+		ulogger,
+		syncerMetrics,
+		bucket,
+		fetcher,
+		fetcher.deduplicateBlocksFilter,
+		fetcher.ignoreDeletionMarkFilter,
+		c.compactorCfg.CompactionInterval,
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to create syncer")
+	}
+
+	currentCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var compactionLifecycleCallback compact.CompactionLifecycleCallback = newCompactionProgressCallback(c.compactionLifecycleCallbackFactory(currentCtx, bucket, ulogger, c.compactorCfg.MetaSyncConcurrency, c.compactDirForUser(userID), userID, c.compactorMetrics), userID, c.compactorMetrics.compactionProgress)
+	if c.compactorCfg.EventLogEnabled {
+		compactionLifecycleCallback = newCompactionEventLogCallback(compactionLifecycleCallback, userID, bucket,
+			c.compactorMetrics.eventLogWriteFailures.WithLabelValues(c.compactorMetrics.getCommonLabelValues(userID)...))
+	}
+	if c.compactorCfg.VerifyUploadedBlocks {
+		labelValues := c.compactorMetrics.getCommonLabelValues(userID)
+		compactionLifecycleCallback = newBlockVerificationCallback(compactionLifecycleCallback, bucket, c.compactDirForUser(userID),
+			c.compactorMetrics.compactedBlockVerificationFailures.WithLabelValues(labelValues...),
+			c.compactorMetrics.syncerBlocksMarkedForDeletion.WithLabelValues(append(labelValues, reasonValueFailedVerification)...))
+	}
+
+	planner := c.blocksPlannerFactory(currentCtx, bucket, ulogger, c.compactorCfg, fetcher.noCompactMarkerFilter, c.ringLifecycler, userID, c.blockVisitMarkerReadFailed, c.blockVisitMarkerWriteFailed, c.compactorMetrics, fetcher.ignoreDeletionMarkFilter)
+	if c.compactorCfg.HaltOnOverlappingBlocks {
+		planner = newOverlappingBlocksHaltPlanner(planner, bucket, ulogger, c.compactorMetrics.overlappingBlocksHalt.WithLabelValues(c.compactorMetrics.getCommonLabelValues(userID)...))
+	}
+
+	// The compaction groups download the source blocks using the bucket given to the grouper.
+	groupsBucket := bucket
+	if c.blockFilesCache != nil {
+		groupsBucket = c.blockFilesCache.wrap(bucket)
+	}
+
+	compactor, err := compact.NewBucketCompactorWithCheckerAndCallback(
+		ulogger,
+		syncer,
+		c.blocksGrouperFactory(currentCtx, c.compactorCfg, groupsBucket, ulogger, c.BlocksMarkedForNoCompaction, c.blockVisitMarkerReadFailed, c.blockVisitMarkerWriteFailed, syncerMetrics, c.compactorMetrics, c.ring, c.ringLifecycler, c.limits, userID, fetcher.noCompactMarkerFilter, c.ingestionReplicationFactor),
+		planner,
+		c.blocksCompactor,
+		c.blockDeletableCheckerFactory(currentCtx, bucket, ulogger),
+		compactionLifecycleCallback,
+		c.compactDirForUser(userID),
+		bucket,
+		c.compactorCfg.CompactionConcurrency,
+		c.compactorCfg.SkipBlocksWithOutOfOrderChunksEnabled,
+		nil, // Pass nil for blocksCleaner to maintain current behavior.
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to create bucket compactor")
+	}
+
+	if err := compactor.Compact(ctx); err != nil {
+		level.Warn(ulogger).Log("msg", "compaction failed with error", "err", err)
+		return errors.Wrap(err, "compaction")
+	}
+
+	// Remove all files on the compact root dir
+	// We do this only if there is no error because potentially on the next run we would not have to download
+	// everything again.
+	if err := os.RemoveAll(c.compactRootDir()); err != nil {
+		level.Error(c.logger).Log("msg", "failed to remove compaction work directory", "path", c.compactRootDir(), "err", err)
+	}
+
+	return nil
+}
+
+// userMetaFetcher fetches the metadata of the blocks of a tenant to compact, along with the filters
+// whose results are used by the compaction.
+type userMetaFetcher struct {
+	*block.MetaFetcher
+
+	deduplicateBlocksFilter  CortexMetadataFilter
+	ignoreDeletionMarkFilter *block.IgnoreDeletionMarkFilter
+	noCompactMarkerFilter    *compact.GatherNoCompactionMarkFilter
+}
+
+func (c *Compactor) newUserMetaFetcher(userID string, bucket objstore.InstrumentedBucket, ulogger log.Logger, reg prometheus.Registerer, dir string, baseFetcherMetrics *block.BaseFetcherMetrics, fetcherMetrics *block.FetcherMetrics) (*userMetaFetcher, error) {
 	// Filters out duplicate blocks that can be formed from two or more overlapping
 	// blocks that fully submatches the source blocks of the older blocks.
 	var deduplicateBlocksFilter CortexMetadataFilter
@@ -1033,11 +1222,11 @@ func (c *Compactor) compactUser(ctx context.Context, userID string) error {
 		blockLister = block.NewRecursiveLister(ulogger, bucket)
 	case cortex_tsdb.BucketIndexDiscovery:
 		if !c.storageCfg.BucketStore.BucketIndex.Enabled {
-			return cortex_tsdb.ErrInvalidBucketIndexBlockDiscoveryStrategy
+			return nil, cortex_tsdb.ErrInvalidBucketIndexBlockDiscoveryStrategy
 		}
 		blockLister = bucketindex.NewBlockLister(ulogger, c.bucketClient, userID, c.limits)
 	default:
-		return cortex_tsdb.ErrBlockDiscoveryStrategy
+		return nil, cortex_tsdb.ErrBlockDiscoveryStrategy
 	}
 
 	// List of filters to apply (order matters).
@@ -1065,62 +1254,21 @@ func (c *Compactor) compactUser(ctx context.Context, userID string) error {
 		c.compactorCfg.MetaSyncConcurrency,
 		bucket,
 		blockLister,
-		c.metaSyncDirForUser(userID),
-		c.compactorMetrics.getBaseFetcherMetrics(),
-		c.compactorMetrics.getMetaFetcherMetrics(),
+		dir,
+		baseFetcherMetrics,
+		fetcherMetrics,
 		filterList,
 	)
 	if err != nil {
-		return err
-	}
-
-	syncerMetrics := c.compactorMetrics.getSyncerMetrics(userID)
-	syncer, err := compact.NewMetaSyncerWithMetrics(
-		ulogger,
-		syncerMetrics,
-		bucket,
-		fetcher,
-		deduplicateBlocksFilter,
-		ignoreDeletionMarkFilter,
-		c.compactorCfg.CompactionInterval,
-	)
-	if err != nil {
-		return errors.Wrap(err, "failed to create syncer")
-	}
-
-	currentCtx, cancel := context.WithCancel(ctx)
-	defer cancel()
-	compactor, err := compact.NewBucketCompactorWithCheckerAndCallback(
-		ulogger,
-		syncer,
-		c.blocksGrouperFactory(currentCtx, c.compactorCfg, bucket, ulogger, c.BlocksMarkedForNoCompaction, c.blockVisitMarkerReadFailed, c.blockVisitMarkerWriteFailed, syncerMetrics, c.compactorMetrics, c.ring, c.ringLifecycler, c.limits, userID, noCompactMarkerFilter, c.ingestionReplicationFactor),
-		c.blocksPlannerFactory(currentCtx, bucket, ulogger, c.compactorCfg, noCompactMarkerFilter, c.ringLifecycler, userID, c.blockVisitMarkerReadFailed, c.blockVisitMarkerWriteFailed, c.compactorMetrics, ignoreDeletionMarkFilter),
-		c.blocksCompactor,
-		c.blockDeletableCheckerFactory(currentCtx, bucket, ulogger),
-		c.compactionLifecycleCallbackFactory(currentCtx, bucket, ulogger, c.compactorCfg.MetaSyncConcurrency, c.compactDirForUser(userID), userID, c.compactorMetrics),
-		c.compactDirForUser(userID),
-		bucket,
-		c.compactorCfg.CompactionConcurrency,
-		c.compactorCfg.SkipBlocksWithOutOfOrderChunksEnabled,
-		nil, // Pass nil for blocksCleaner to maintain current behavior.
-	)
-	if err != nil {
-		return errors.Wrap(err, "failed to create bucket compactor")
-	}
-
-	if err := compactor.Compact(ctx); err != nil {
-		level.Warn(ulogger).Log("msg", "compaction failed with error", "err", err)
-		return errors.Wrap(err, "compaction")
-	}
-
-	// Remove all files on the compact root dir
-	// We do this only if there is no error because potentially on the next run we would not have to download
-	// everything again.
-	if err := os.RemoveAll(c.compactRootDir()); err != nil {
-		level.Error(c.logger).Log("msg", "failed to remove compaction work directory", "path", c.compactRootDir(), "err", err)
+		return nil, err
 	}
 
-	return nil
+	return &userMetaFetcher{
+		MetaFetcher:              fetcher,
+		deduplicateBlocksFilter:  deduplicateBlocksFilter,
+		ignoreDeletionMarkFilter: ignoreDeletionMarkFilter,
+		noCompactMarkerFilter:    noCompactMarkerFilter,
+	}, nil
 }
 
 func (c *Compactor) discoverUsersWithRetries(ctx context.Context) ([]string, error) {
@@ -43,6 +43,15 @@ type compactorMetrics struct {
 	partitionCount              *prometheus.GaugeVec
 	compactionsNotPlanned       *prometheus.CounterVec
 	compactionDuration          *prometheus.GaugeVec
+
+	compactedBlockVerificationFailures *prometheus.CounterVec
+	overlappingBlocksHalt              *prometheus.GaugeVec
+	eventLogWriteFailures              *prometheus.CounterVec
+
+	tenantPendingCompactions        *prometheus.GaugeVec
+	tenantEstimatedSecondsRemaining *prometheus.GaugeVec
+	blocksTooYoungForCompaction     *prometheus.GaugeVec
+	compactionProgress              *compactionProgressTracker
 }
 
 const (
@@ -187,6 +196,31 @@ func newCompactorMetricsWithLabels(reg prometheus.Registerer, commonLabels []str
 		Name: "cortex_compact_group_compaction_duration_seconds",
 		Help: "Duration of completed compactions in seconds",
 	}, compactionLabels)
+	m.compactedBlockVerificationFailures = promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+		Name: "cortex_compactor_compacted_block_verification_failures_total",
+		Help: "Total number of compacted blocks which failed verification once uploaded to the storage.",
+	}, commonLabels)
+	m.overlappingBlocksHalt = promauto.With(reg).NewGaugeVec(prometheus.GaugeOpts{
+		Name: "cortex_compactor_blocks_overlapping_halt",
+		Help: "Whether the compaction of the tenant is halted because of unexpected overlapping blocks (1) or not (0). Only available when halting on overlapping blocks is enabled.",
+	}, commonLabels)
+	m.eventLogWriteFailures = promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+		Name: "cortex_compactor_event_log_write_failures_total",
+		Help: "Total number of failures writing compacted blocks creation events to the compaction event log. Only available when the compaction event log is enabled.",
+	}, commonLabels)
+	m.tenantPendingCompactions = promauto.With(reg).NewGaugeVec(prometheus.GaugeOpts{
+		Name: "cortex_compactor_tenant_pending_compactions",
+		Help: "Number of source blocks pending compaction for the tenant. Only available with shuffle-sharding strategy",
+	}, commonLabels)
+	m.tenantEstimatedSecondsRemaining = promauto.With(reg).NewGaugeVec(prometheus.GaugeOpts{
+		Name: "cortex_compactor_tenant_estimated_seconds_remaining",
+		Help: "Estimated time in seconds to complete the planned compactions for the tenant, based on the average duration of its recent compactions. Only available with shuffle-sharding strategy",
+	}, commonLabels)
+	m.blocksTooYoungForCompaction = promauto.With(reg).NewGaugeVec(prometheus.GaugeOpts{
+		Name: "cortex_compactor_blocks_too_young_for_compaction",
+		Help: "Number of blocks excluded from the last compaction planning of the tenant because they are younger than the minimum block age. Only available with shuffle-sharding strategy",
+	}, commonLabels)
+	m.compactionProgress = newCompactionProgressTracker()
 
 	return &m
 }
@@ -253,4 +287,11 @@ func (m *compactorMetrics) deleteMetricsForDeletedTenant(userID string) {
 	m.partitionCount.DeleteLabelValues(userID)
 	m.compactionsNotPlanned.DeleteLabelValues(userID)
 	m.compactionDuration.DeleteLabelValues(userID)
+	m.compactedBlockVerificationFailures.DeleteLabelValues(userID)
+	m.overlappingBlocksHalt.DeleteLabelValues(userID)
+	m.eventLogWriteFailures.DeleteLabelValues(userID)
+	m.tenantPendingCompactions.DeleteLabelValues(userID)
+	m.tenantEstimatedSecondsRemaining.DeleteLabelValues(userID)
+	m.blocksTooYoungForCompaction.DeleteLabelValues(userID)
+	m.compactionProgress.deleteUser(userID)
 }
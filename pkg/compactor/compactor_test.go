@@ -25,6 +25,7 @@ import (
 	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
 	prom_testutil "github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/common/model"
 	"github.com/prometheus/prometheus/model/labels"
 	"github.com/prometheus/prometheus/tsdb"
 	"github.com/stretchr/testify/assert"
@@ -145,6 +146,47 @@ func TestConfig_Validate(t *testing.T) {
 			initLimits: func(_ *validation.Limits) {},
 			expected:   errInvalidTenantShardSize.Error(),
 		},
+		"should fail with halt on overlapping blocks and partitioning compaction strategy": {
+			setup: func(cfg *Config) {
+				cfg.ShardingStrategy = util.ShardingStrategyShuffle
+				cfg.ShardingEnabled = true
+				cfg.CompactionStrategy = util.CompactionStrategyPartitioning
+				cfg.HaltOnOverlappingBlocks = true
+			},
+			initLimits: func(limits *validation.Limits) {
+				limits.CompactorTenantShardSize = 1
+			},
+			expected: errInvalidHaltOnOverlappingBlocks.Error(),
+		},
+		"should fail with an unsupported tenant priority": {
+			setup: func(cfg *Config) {
+				cfg.TenantPriority = "newest-first"
+			},
+			initLimits: func(_ *validation.Limits) {},
+			expected:   errInvalidTenantPriority.Error(),
+		},
+		"should pass with the block files cache dir outside of the compact dir": {
+			setup: func(cfg *Config) {
+				cfg.BlockFilesCacheDir = filepath.Join(cfg.DataDir, "block-files-cache")
+			},
+			initLimits: func(_ *validation.Limits) {},
+			expected:   "",
+		},
+		"should fail with the block files cache dir within the compact dir": {
+			setup: func(cfg *Config) {
+				cfg.BlockFilesCacheDir = filepath.Join(cfg.DataDir, "compact", "cache")
+			},
+			initLimits: func(_ *validation.Limits) {},
+			expected:   errInvalidBlockFilesCacheDir.Error(),
+		},
+		"should fail with a block files cache max size of zero": {
+			setup: func(cfg *Config) {
+				cfg.BlockFilesCacheDir = filepath.Join(cfg.DataDir, "block-files-cache")
+				cfg.BlockFilesCacheMaxSizeBytes = 0
+			},
+			initLimits: func(_ *validation.Limits) {},
+			expected:   errInvalidBlockFilesCacheMaxSize.Error(),
+		},
 	}
 
 	for testName, testData := range tests {
@@ -164,6 +206,43 @@ func TestConfig_Validate(t *testing.T) {
 	}
 }
 
+func TestConfig_ValidateDeletionDelay(t *testing.T) {
+	tests := map[string]struct {
+		deletionDelay                 time.Duration
+		deletionDelayFromStoreGateway bool
+		limitsDelay                   time.Duration
+		expectedWarning               bool
+	}{
+		"deletion delay longer than the ignore deletion marks delay": {
+			deletionDelay: 12 * time.Hour,
+		},
+		"deletion delay shorter than the ignore deletion marks delay": {
+			deletionDelay:   time.Hour,
+			expectedWarning: true,
+		},
+		"deletion delay shorter than the ignore deletion marks delay of the default limits": {
+			deletionDelay:   12 * time.Hour,
+			limitsDelay:     24 * time.Hour,
+			expectedWarning: true,
+		},
+		"deletion delay read from the store-gateway config": {
+			deletionDelay:                 time.Hour,
+			deletionDelayFromStoreGateway: true,
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			cfg := &Config{DeletionDelay: testData.deletionDelay, DeletionDelayFromStoreGateway: testData.deletionDelayFromStoreGateway}
+			limits := validation.Limits{IgnoreDeletionMarksDelay: model.Duration(testData.limitsDelay)}
+
+			logs := &concurrency.SyncBuffer{}
+			cfg.ValidateDeletionDelay(cortex_tsdb.BucketStoreConfig{IgnoreDeletionMarksDelay: 6 * time.Hour}, limits, log.NewLogfmtLogger(logs))
+			assert.Equal(t, testData.expectedWarning, strings.Contains(logs.String(), "the compactor deletion delay is shorter than the store-gateway ignore deletion marks delay"))
+		})
+	}
+}
+
 func TestCompactor_SkipCompactionWhenCmkError(t *testing.T) {
 	t.Parallel()
 	userID := "user-1"
@@ -986,6 +1065,42 @@ func TestCompactor_ShouldSkipOutOrOrderBlocks(t *testing.T) {
 		`), "cortex_compactor_blocks_marked_for_no_compaction_total"))
 }
 
+func TestCompactor_ShouldNotCompactUsersWithCompactionHalted(t *testing.T) {
+	bucketClient, _ := testutil.PrepareFilesystemBucket(t)
+	bucketClient = bucketindex.BucketWithGlobalMarkers(bucketClient)
+
+	for _, userID := range []string{"user-1", "user-2"} {
+		createTSDBBlock(t, bucketClient, userID, 10, 20, nil)
+		createTSDBBlock(t, bucketClient, userID, 20, 30, nil)
+	}
+	require.NoError(t, writeCompactionHaltMark(context.Background(), bucket.NewPrefixedBucketClient(bucketClient, "user-1"), &CompactionHaltMark{HaltTime: 1}))
+
+	cfg := prepareConfig()
+	cfg.HaltOnOverlappingBlocks = true
+	c, _, tsdbPlanner, _, registry := prepare(t, cfg, bucketClient, nil)
+
+	tsdbPlanner.On("Plan", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return([]*metadata.Meta{}, nil)
+
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), c))
+
+	// Wait until a run has completed.
+	cortex_testutil.Poll(t, 5*time.Second, 1.0, func() any {
+		return prom_testutil.ToFloat64(c.CompactionRunsCompleted)
+	})
+
+	require.NoError(t, services.StopAndAwaitTerminated(context.Background(), c))
+
+	// Only the user whose compaction is not halted is compacted.
+	tsdbPlanner.AssertNumberOfCalls(t, "Plan", 1)
+
+	assert.NoError(t, prom_testutil.GatherAndCompare(registry, strings.NewReader(`
+		# HELP cortex_compactor_blocks_overlapping_halt Whether the compaction of the tenant is halted because of unexpected overlapping blocks (1) or not (0). Only available when halting on overlapping blocks is enabled.
+		# TYPE cortex_compactor_blocks_overlapping_halt gauge
+		cortex_compactor_blocks_overlapping_halt{user="user-1"} 1
+		cortex_compactor_blocks_overlapping_halt{user="user-2"} 0
+	`), "cortex_compactor_blocks_overlapping_halt"))
+}
+
 func TestCompactor_ShouldCompactAllUsersOnShardingEnabledButOnlyOneInstanceRunning(t *testing.T) {
 	t.Parallel()
 
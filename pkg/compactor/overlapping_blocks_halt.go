@@ -0,0 +1,135 @@
+package compactor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"path"
+	"slices"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/oklog/ulid/v2"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/thanos-io/objstore"
+	"github.com/thanos-io/thanos/pkg/block/metadata"
+	"github.com/thanos-io/thanos/pkg/compact"
+)
+
+// CompactionHaltMarkFilename is the name of the marker stored in the tenant markers location
+// when the compaction of the tenant has been halted.
+const CompactionHaltMarkFilename = "compaction-halt-mark.json"
+
+var errOverlappingBlocksHalt = errors.New("compaction halted because of unexpected overlapping blocks")
+
+// CompactionHaltMark is the content of the compaction halt marker.
+type CompactionHaltMark struct {
+	// Unix timestamp when the compaction has been halted.
+	HaltTime int64 `json:"halt_time"`
+
+	// Human readable reason the compaction has been halted.
+	Reason string `json:"reason"`
+
+	// Blocks which caused the compaction to be halted.
+	Blocks []ulid.ULID `json:"blocks"`
+}
+
+// GetCompactionHaltMarkPath returns the path of the compaction halt marker, relative to the tenant location.
+func GetCompactionHaltMarkPath() string {
+	return path.Join("markers", CompactionHaltMarkFilename)
+}
+
+// compactionHaltMarkExists checks for the compaction halt marker in the given tenant bucket.
+func compactionHaltMarkExists(ctx context.Context, userBkt objstore.BucketReader) (bool, error) {
+	return userBkt.Exists(ctx, GetCompactionHaltMarkPath())
+}
+
+// writeCompactionHaltMark uploads the compaction halt marker to the given tenant bucket.
+func writeCompactionHaltMark(ctx context.Context, userBkt objstore.Bucket, mark *CompactionHaltMark) error {
+	data, err := json.Marshal(mark)
+	if err != nil {
+		return errors.Wrap(err, "serialize compaction halt mark")
+	}
+
+	return errors.Wrap(userBkt.Upload(ctx, GetCompactionHaltMarkPath(), bytes.NewReader(data)), "upload compaction halt mark")
+}
+
+// overlappingBlocksHaltPlanner wraps a compact.Planner to halt the compaction of a tenant when
+// unexpected overlapping blocks are found, instead of merging them.
+type overlappingBlocksHaltPlanner struct {
+	compact.Planner
+
+	userBkt objstore.Bucket
+	logger  log.Logger
+	halted  prometheus.Gauge
+}
+
+func newOverlappingBlocksHaltPlanner(planner compact.Planner, userBkt objstore.Bucket, logger log.Logger, halted prometheus.Gauge) *overlappingBlocksHaltPlanner {
+	return &overlappingBlocksHaltPlanner{
+		Planner: planner,
+		userBkt: userBkt,
+		logger:  logger,
+		halted:  halted,
+	}
+}
+
+func (p *overlappingBlocksHaltPlanner) Plan(ctx context.Context, metasByMinTime []*metadata.Meta, errChan chan error, extensions any) ([]*metadata.Meta, error) {
+	overlapping := findUnexpectedOverlappingBlocks(metasByMinTime)
+	if len(overlapping) == 0 {
+		return p.Planner.Plan(ctx, metasByMinTime, errChan, extensions)
+	}
+
+	level.Error(p.logger).Log("msg", "found unexpected overlapping blocks, halting compaction", "blocks", ulidsToString(overlapping))
+
+	mark := &CompactionHaltMark{
+		HaltTime: time.Now().Unix(),
+		Reason:   "unexpected overlapping blocks",
+		Blocks:   overlapping,
+	}
+	if err := writeCompactionHaltMark(ctx, p.userBkt, mark); err != nil {
+		return nil, err
+	}
+	p.halted.Set(1)
+
+	return nil, errors.Wrapf(errOverlappingBlocksHalt, "blocks %s", ulidsToString(overlapping))
+}
+
+// findUnexpectedOverlappingBlocks returns the blocks overlapping with other blocks in a way which can't be explained
+// by the normal operation of the ingesters. Blocks uploaded by ingesters (compaction level 1) overlap because of
+// the replication, and blocks from out-of-order samples overlap with in-order ones, so they're not taken into account.
+// The input blocks have to be sorted by min time.
+func findUnexpectedOverlappingBlocks(metasByMinTime []*metadata.Meta) []ulid.ULID {
+	var (
+		candidates  []*metadata.Meta
+		overlapping []ulid.ULID
+	)
+	for _, m := range metasByMinTime {
+		if m.Compaction.Level > 1 && !m.Compaction.FromOutOfOrder() {
+			candidates = append(candidates, m)
+		}
+	}
+
+	for i, a := range candidates {
+		for _, b := range candidates[i+1:] {
+			if b.MinTime >= a.MaxTime {
+				break
+			}
+			for _, id := range []ulid.ULID{a.ULID, b.ULID} {
+				if !slices.Contains(overlapping, id) {
+					overlapping = append(overlapping, id)
+				}
+			}
+		}
+	}
+	return overlapping
+}
+
+func ulidsToString(ids []ulid.ULID) []string {
+	out := make([]string, 0, len(ids))
+	for _, id := range ids {
+		out = append(out, id.String())
+	}
+	return out
+}
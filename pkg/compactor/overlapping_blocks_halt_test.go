@@ -0,0 +1,106 @@
+package compactor
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/oklog/ulid/v2"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/prometheus/tsdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/objstore"
+	"github.com/thanos-io/thanos/pkg/block/metadata"
+)
+
+func TestFindUnexpectedOverlappingBlocks(t *testing.T) {
+	newMeta := func(id uint64, minT, maxT int64, level int, hints ...string) *metadata.Meta {
+		return &metadata.Meta{BlockMeta: tsdb.BlockMeta{
+			ULID:       ulid.MustNew(id, nil),
+			MinTime:    minT,
+			MaxTime:    maxT,
+			Compaction: tsdb.BlockMetaCompaction{Level: level, Hints: hints},
+		}}
+	}
+
+	tests := map[string]struct {
+		metas    []*metadata.Meta
+		expected []ulid.ULID
+	}{
+		"no blocks": {},
+		"adjacent compacted blocks": {
+			metas:    []*metadata.Meta{newMeta(1, 0, 10, 2), newMeta(2, 10, 20, 2)},
+			expected: nil,
+		},
+		"overlapping blocks uploaded by ingesters": {
+			metas:    []*metadata.Meta{newMeta(1, 0, 10, 1), newMeta(2, 0, 10, 1), newMeta(3, 5, 15, 1)},
+			expected: nil,
+		},
+		"block uploaded by ingesters overlapping a compacted block": {
+			metas:    []*metadata.Meta{newMeta(1, 0, 20, 2), newMeta(2, 10, 20, 1)},
+			expected: nil,
+		},
+		"compacted block overlapping a compacted out-of-order block": {
+			metas:    []*metadata.Meta{newMeta(1, 0, 20, 2), newMeta(2, 10, 20, 2, tsdb.CompactionHintFromOutOfOrder)},
+			expected: nil,
+		},
+		"overlapping compacted blocks": {
+			metas:    []*metadata.Meta{newMeta(1, 0, 20, 2), newMeta(2, 10, 30, 1), newMeta(3, 15, 25, 3), newMeta(4, 30, 40, 2)},
+			expected: []ulid.ULID{ulid.MustNew(1, nil), ulid.MustNew(3, nil)},
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			assert.Equal(t, testData.expected, findUnexpectedOverlappingBlocks(testData.metas))
+		})
+	}
+}
+
+func TestOverlappingBlocksHaltPlanner(t *testing.T) {
+	ctx := context.Background()
+	bkt := objstore.NewInMemBucket()
+	halted := prometheus.NewGauge(prometheus.GaugeOpts{})
+
+	metas := []*metadata.Meta{
+		{BlockMeta: tsdb.BlockMeta{ULID: ulid.MustNew(1, nil), MinTime: 0, MaxTime: 20, Compaction: tsdb.BlockMetaCompaction{Level: 2}}},
+		{BlockMeta: tsdb.BlockMeta{ULID: ulid.MustNew(2, nil), MinTime: 20, MaxTime: 40, Compaction: tsdb.BlockMetaCompaction{Level: 2}}},
+	}
+
+	plannerMock := &tsdbPlannerMock{}
+	plannerMock.On("Plan", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(metas, nil)
+	planner := newOverlappingBlocksHaltPlanner(plannerMock, bkt, log.NewNopLogger(), halted)
+
+	// Blocks not overlapping are planned by the wrapped planner.
+	plan, err := planner.Plan(ctx, metas, nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, metas, plan)
+	assert.Equal(t, float64(0), testutil.ToFloat64(halted))
+
+	exists, err := compactionHaltMarkExists(ctx, bkt)
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	// Overlapping compacted blocks halt the compaction.
+	overlapping := []*metadata.Meta{
+		metas[0],
+		{BlockMeta: tsdb.BlockMeta{ULID: ulid.MustNew(3, nil), MinTime: 10, MaxTime: 30, Compaction: tsdb.BlockMetaCompaction{Level: 2}}},
+		metas[1],
+	}
+	_, err = planner.Plan(ctx, overlapping, nil, nil)
+	require.ErrorIs(t, err, errOverlappingBlocksHalt)
+	assert.Equal(t, float64(1), testutil.ToFloat64(halted))
+	plannerMock.AssertNumberOfCalls(t, "Plan", 1)
+
+	r, err := bkt.Get(ctx, GetCompactionHaltMarkPath())
+	require.NoError(t, err)
+	defer r.Close()
+
+	mark := CompactionHaltMark{}
+	require.NoError(t, json.NewDecoder(r).Decode(&mark))
+	assert.ElementsMatch(t, []ulid.ULID{ulid.MustNew(1, nil), ulid.MustNew(2, nil), ulid.MustNew(3, nil)}, mark.Blocks)
+}
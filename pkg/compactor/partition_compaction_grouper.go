@@ -127,13 +127,24 @@ func (g *PartitionCompactionGrouper) Groups(blocks map[ulid.ULID]*metadata.Meta)
 		return nil, nil
 	}
 
-	// Filter out no compact blocks
+	// Filter out no compact blocks, blocks which have already reached the max compaction level
+	// and blocks younger than the min block age.
 	noCompactMarked := g.noCompBlocksFunc()
+	maxCompactionLevel := g.limits.CompactorMaxCompactionLevel(g.userID)
+	minBlockAge := g.limits.CompactorMinBlockAge(g.userID)
+	now := time.Now()
+	tooYoungBlocks := 0
 	for id, b := range blocks {
 		if _, excluded := noCompactMarked[b.ULID]; excluded {
 			delete(blocks, id)
+		} else if reachedMaxCompactionLevel(b, maxCompactionLevel) {
+			delete(blocks, id)
+		} else if tooYoungForCompaction(b, minBlockAge, now) {
+			tooYoungBlocks++
+			delete(blocks, id)
 		}
 	}
+	g.compactorMetrics.blocksTooYoungForCompaction.WithLabelValues(g.userID).Set(float64(tooYoungBlocks))
 
 	partitionCompactionJobs, err := g.generateCompactionJobs(blocks)
 	if err != nil {
@@ -248,6 +259,7 @@ func (g *PartitionCompactionGrouper) groupBlocksByCompactableRanges(blocks []*me
 
 func (g *PartitionCompactionGrouper) groupBlocksByRange(blocks []*metadata.Meta, tr int64) []blocksGroupWithPartition {
 	var ret []blocksGroupWithPartition
+	verticalCompactionOnly := g.limits.CompactorVerticalCompactionOnly(g.userID)
 
 	for i := 0; i < len(blocks); {
 		var (
@@ -282,7 +294,8 @@ func (g *PartitionCompactionGrouper) groupBlocksByRange(blocks []*metadata.Meta,
 			group.blocks = append(group.blocks, blocks[i])
 		}
 
-		if len(group.blocks) > 1 {
+		// Groups requiring a horizontal compaction are excluded, if not allowed.
+		if len(group.blocks) > 1 && (!verticalCompactionOnly || group.overlappingOnly()) {
 			if time.UnixMilli(group.rangeEnd).Before(time.Now().Add(-2 * g.compactorCfg.CleanupInterval)) {
 				ret = append(ret, group)
 			}
@@ -106,87 +106,42 @@ func NewShuffleShardingGrouper(
 
 // Groups function modified from https://github.com/cortexproject/cortex/pull/2616
 func (g *ShuffleShardingGrouper) Groups(blocks map[ulid.ULID]*metadata.Meta) (res []*compact.Group, err error) {
-	noCompactMarked := g.noCompBlocksFunc()
-	// First of all we have to group blocks using the Thanos default
-	// grouping (based on downsample resolution + external labels).
-	mainGroups := map[string][]*metadata.Meta{}
-	for _, b := range blocks {
-		if _, excluded := noCompactMarked[b.ULID]; !excluded {
-			key := b.Thanos.GroupKey()
-			mainGroups[key] = append(mainGroups[key], b)
-		}
-	}
-
-	// For each group, we have to further split it into set of blocks
-	// which we can parallelly compact.
 	var outGroups []*compact.Group
 
-	// Check if this compactor is on the subring.
+	ownedGroups, tooYoungBlocks, onSubring, err := g.ownedGroups(blocks)
+	if err != nil {
+		return nil, err
+	}
 	// If the compactor is not on the subring when using the userID as a identifier
 	// no plans generated below will be owned by the compactor so we can just return an empty array
 	// as there will be no planned groups
-	onSubring, err := g.checkSubringForCompactor()
-	if err != nil {
-		return nil, errors.Wrap(err, "unable to check sub-ring for compactor ownership")
-	}
 	if !onSubring {
 		level.Debug(g.logger).Log("msg", "compactor is not on the current sub-ring skipping user", "user", g.userID)
 		return outGroups, nil
 	}
+	g.compactorMetrics.blocksTooYoungForCompaction.WithLabelValues(g.userID).Set(float64(tooYoungBlocks))
+
 	// Metrics for the remaining planned compactions
 	var remainingCompactions = 0.
 	defer func() {
 		g.compactorMetrics.remainingPlannedCompactions.WithLabelValues(g.userID).Set(remainingCompactions)
 	}()
 
-	var groups []blocksGroup
-	for _, mainBlocks := range mainGroups {
-		groups = append(groups, groupBlocksByCompactableRanges(mainBlocks, g.compactorCfg.BlockRanges.ToMilliseconds())...)
+	// Track the progress of the compaction of the owned groups.
+	var pendingCompactions, pendingBlocks = 0., 0.
+	for _, group := range ownedGroups {
+		pendingCompactions++
+		pendingBlocks += float64(len(group.blocks))
 	}
 
-	// Ensure groups are sorted by smallest range, oldest min time first. The rationale
-	// is that we want to favor smaller ranges first (ie. to deduplicate samples sooner
-	// than later) and older ones are more likely to be "complete" (no missing block still
-	// to be uploaded).
-	sort.SliceStable(groups, func(i, j int) bool {
-		iGroup := groups[i]
-		jGroup := groups[j]
-		iMinTime := iGroup.minTime()
-		iMaxTime := iGroup.maxTime()
-		jMinTime := jGroup.minTime()
-		jMaxTime := jGroup.maxTime()
-		iLength := iMaxTime - iMinTime
-		jLength := jMaxTime - jMinTime
-
-		if iLength != jLength {
-			return iLength < jLength
-		}
-		if iMinTime != jMinTime {
-			return iMinTime < jMinTime
-		}
-
-		iGroupHash := hashGroup(g.userID, iGroup.rangeStart, iGroup.rangeEnd)
-		iGroupKey := createGroupKey(iGroupHash, iGroup)
-		jGroupHash := hashGroup(g.userID, jGroup.rangeStart, jGroup.rangeEnd)
-		jGroupKey := createGroupKey(jGroupHash, jGroup)
-		// Guarantee stable sort for tests.
-		return iGroupKey < jGroupKey
-	})
+	g.compactorMetrics.tenantPendingCompactions.WithLabelValues(g.userID).Set(pendingBlocks)
+	// The estimate is only available once at least one compaction of the tenant has completed.
+	if avg, ok := g.compactorMetrics.compactionProgress.averageDuration(g.userID); ok {
+		g.compactorMetrics.tenantEstimatedSecondsRemaining.WithLabelValues(g.userID).Set(pendingCompactions * avg.Seconds())
+	}
 
 mainLoop:
-	for _, group := range groups {
-		var blockIds []string
-		for _, block := range group.blocks {
-			blockIds = append(blockIds, block.ULID.String())
-		}
-		blocksInfo := strings.Join(blockIds, ",")
-		level.Info(g.logger).Log("msg", "check group", "blocks", blocksInfo)
-
-		// Nothing to do if we don't have at least 2 blocks.
-		if len(group.blocks) < 2 {
-			continue
-		}
-
+	for _, group := range ownedGroups {
 		groupHash := hashGroup(g.userID, group.rangeStart, group.rangeEnd)
 
 		if isVisited, err := g.isGroupVisited(group.blocks, g.ringLifecyclerID); err != nil {
@@ -258,6 +213,110 @@ mainLoop:
 	return outGroups, nil
 }
 
+// ownedGroups returns the groups of blocks owned by this compactor, in the order they're compacted, and
+// the number of blocks too young to be compacted. onSubring is false when this compactor isn't part of
+// the tenant sub-ring. Unlike Groups, it doesn't read nor write the visit markers of the blocks.
+func (g *ShuffleShardingGrouper) ownedGroups(blocks map[ulid.ULID]*metadata.Meta) (owned []blocksGroup, tooYoungBlocks int, onSubring bool, err error) {
+	noCompactMarked := g.noCompBlocksFunc()
+	maxCompactionLevel := g.limits.CompactorMaxCompactionLevel(g.userID)
+	verticalCompactionOnly := g.limits.CompactorVerticalCompactionOnly(g.userID)
+	minBlockAge := g.limits.CompactorMinBlockAge(g.userID)
+	now := time.Now()
+	// First of all we have to group blocks using the Thanos default
+	// grouping (based on downsample resolution + external labels).
+	mainGroups := map[string][]*metadata.Meta{}
+	for _, b := range blocks {
+		if _, excluded := noCompactMarked[b.ULID]; excluded {
+			continue
+		}
+		if reachedMaxCompactionLevel(b, maxCompactionLevel) {
+			continue
+		}
+		if tooYoungForCompaction(b, minBlockAge, now) {
+			tooYoungBlocks++
+			continue
+		}
+		key := b.Thanos.GroupKey()
+		mainGroups[key] = append(mainGroups[key], b)
+	}
+
+	// Check if this compactor is on the subring.
+	subRing, onSubring, err := g.checkSubringForCompactor()
+	if err != nil {
+		return nil, 0, false, errors.Wrap(err, "unable to check sub-ring for compactor ownership")
+	}
+	if !onSubring {
+		return nil, tooYoungBlocks, false, nil
+	}
+
+	// For each group, we have to further split it into set of blocks
+	// which we can parallelly compact.
+	var groups []blocksGroup
+	for _, mainBlocks := range mainGroups {
+		groups = append(groups, groupBlocksByCompactableRanges(mainBlocks, g.compactorCfg.BlockRanges.ToMilliseconds(), verticalCompactionOnly)...)
+	}
+
+	// Ensure groups are sorted by smallest range, oldest min time first. The rationale
+	// is that we want to favor smaller ranges first (ie. to deduplicate samples sooner
+	// than later) and older ones are more likely to be "complete" (no missing block still
+	// to be uploaded).
+	sort.SliceStable(groups, func(i, j int) bool {
+		iGroup := groups[i]
+		jGroup := groups[j]
+		iMinTime := iGroup.minTime()
+		iMaxTime := iGroup.maxTime()
+		jMinTime := jGroup.minTime()
+		jMaxTime := jGroup.maxTime()
+		iLength := iMaxTime - iMinTime
+		jLength := jMaxTime - jMinTime
+
+		if iLength != jLength {
+			return iLength < jLength
+		}
+		if iMinTime != jMinTime {
+			return iMinTime < jMinTime
+		}
+
+		iGroupHash := hashGroup(g.userID, iGroup.rangeStart, iGroup.rangeEnd)
+		iGroupKey := createGroupKey(iGroupHash, iGroup)
+		jGroupHash := hashGroup(g.userID, jGroup.rangeStart, jGroup.rangeEnd)
+		jGroupKey := createGroupKey(jGroupHash, jGroup)
+		// Guarantee stable sort for tests.
+		return iGroupKey < jGroupKey
+	})
+
+	// Keep only the groups owned by this compactor.
+	for _, group := range groups {
+		var blockIds []string
+		for _, block := range group.blocks {
+			blockIds = append(blockIds, block.ULID.String())
+		}
+		blocksInfo := strings.Join(blockIds, ",")
+		level.Info(g.logger).Log("msg", "check group", "blocks", blocksInfo)
+
+		// Nothing to do if we don't have at least 2 blocks.
+		if len(group.blocks) < 2 {
+			continue
+		}
+
+		groupHash := hashGroup(g.userID, group.rangeStart, group.rangeEnd)
+
+		// Only the compactor owning the group hash within the tenant sub-ring plans the group,
+		// so that two compactors on the same sub-ring don't pick up the same group.
+		if owned, err := g.ownGroup(subRing, groupHash); err != nil {
+			level.Warn(g.logger).Log("msg", "unable to check if group is owned by compactor", "group_hash", groupHash, "err", err, "group", group.String())
+			continue
+		} else if !owned {
+			level.Debug(g.logger).Log("msg", "skipping group because it is not owned by the compactor", "group_hash", groupHash)
+			continue
+		}
+
+		owned = append(owned, group)
+	}
+
+	return owned, tooYoungBlocks, true, nil
+}
+
 func (g *ShuffleShardingGrouper) isGroupVisited(blocks []*metadata.Meta, compactorID string) (bool, error) {
 	for _, block := range blocks {
 		blockID := block.ULID.String()
@@ -279,16 +338,64 @@ func (g *ShuffleShardingGrouper) isGroupVisited(blocks []*metadata.Meta, compact
 }
 
 // Check whether this compactor exists on the subring based on user ID
-func (g *ShuffleShardingGrouper) checkSubringForCompactor() (bool, error) {
+func (g *ShuffleShardingGrouper) checkSubringForCompactor() (ring.ReadRing, bool, error) {
 	shardSize := util.DynamicShardSize(g.limits.CompactorTenantShardSize(g.userID), g.ring.InstancesCount())
 	subRing := g.ring.ShuffleShard(g.userID, shardSize)
 
 	rs, err := subRing.GetAllHealthy(RingOp)
+	if err != nil {
+		return nil, false, err
+	}
+
+	return subRing, rs.Includes(g.ringLifecyclerAddr), nil
+}
+
+// Check whether this compactor is the owner of the group hash within the tenant sub-ring. The owner is the
+// first healthy compactor found walking the sub-ring from the group hash, so that the groups owned by an
+// unhealthy compactor are planned by the next healthy one.
+func (g *ShuffleShardingGrouper) ownGroup(subRing ring.ReadRing, groupHash uint32) (bool, error) {
+	healthy, _, err := subRing.GetAllInstanceDescs(RingOp)
 	if err != nil {
 		return false, err
 	}
 
-	return rs.Includes(g.ringLifecyclerAddr), nil
+	var (
+		ownerAddr     string
+		ownerDistance uint32
+		found         bool
+	)
+	for _, instance := range healthy {
+		for _, token := range instance.Tokens {
+			// The distance wraps around the ring.
+			if distance := token - groupHash; !found || distance < ownerDistance {
+				ownerAddr, ownerDistance, found = instance.Addr, distance, true
+			}
+		}
+	}
+	if !found {
+		return false, errors.New("no healthy compactor in the sub-ring")
+	}
+
+	return ownerAddr == g.ringLifecyclerAddr, nil
+}
+
+// reachedMaxCompactionLevel returns whether the block has already reached the max compaction level,
+// and so shouldn't be merged any further. A max compaction level of 0 means no limit.
+func reachedMaxCompactionLevel(b *metadata.Meta, maxCompactionLevel int) bool {
+	return maxCompactionLevel > 0 && b.Compaction.Level >= maxCompactionLevel
+}
+
+// tooYoungForCompaction returns whether the block, unless produced by the compactor, was created
+// less than minBlockAge ago.
+func tooYoungForCompaction(b *metadata.Meta, minBlockAge time.Duration, now time.Time) bool {
+	if minBlockAge <= 0 {
+		return false
+	}
+	switch b.Thanos.Source {
+	case metadata.CompactorSource, metadata.CompactorRepairSource, metadata.BucketRepairSource:
+		return false
+	}
+	return now.Sub(ulid.Time(b.ULID.Time())) < minBlockAge
 }
 
 // hashGroup Get the hash of a group based on the UserID, and the starting and ending time of the group's range.
@@ -376,11 +483,28 @@ func (g blocksGroup) maxTime() int64 {
 	return max
 }
 
+// overlappingOnly returns whether the blocks in the group overlap with each other in a way
+// that they can be merged without merging adjacent time ranges, which is the case when no
+// block starts after all the previous ones have ended.
+func (g blocksGroup) overlappingOnly() bool {
+	// Blocks are expected to be sorted by MinTime.
+	maxTime := g.blocks[0].MaxTime
+	for _, b := range g.blocks[1:] {
+		if b.MinTime >= maxTime {
+			return false
+		}
+		maxTime = max(maxTime, b.MaxTime)
+	}
+
+	return true
+}
+
 // groupBlocksByCompactableRanges groups input blocks by compactable ranges, giving preference
 // to smaller ranges. If a smaller range contains more than 1 block (and thus it should
 // be compacted), the larger range block group is not generated until each of its
-// smaller ranges have 1 block each at most.
-func groupBlocksByCompactableRanges(blocks []*metadata.Meta, ranges []int64) []blocksGroup {
+// smaller ranges have 1 block each at most. If verticalCompactionOnly is true, the groups
+// which would require merging adjacent time ranges are excluded.
+func groupBlocksByCompactableRanges(blocks []*metadata.Meta, ranges []int64, verticalCompactionOnly bool) []blocksGroup {
 	if len(blocks) == 0 {
 		return nil
 	}
@@ -399,6 +523,11 @@ func groupBlocksByCompactableRanges(blocks []*metadata.Meta, ranges []int64) []b
 				continue
 			}
 
+			// Exclude groups requiring a horizontal compaction, if not allowed.
+			if verticalCompactionOnly && !group.overlappingOnly() {
+				continue
+			}
+
 			// Ensure this group's range does not overlap with any group already scheduled
 			// for compaction by a smaller range, because we need to guarantee that smaller ranges
 			// are compacted first.
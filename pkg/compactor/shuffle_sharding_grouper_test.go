@@ -3,6 +3,7 @@ package compactor
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
 	"path"
 	"testing"
 	"time"
@@ -11,6 +12,7 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/common/model"
 	"github.com/prometheus/prometheus/tsdb"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -20,6 +22,7 @@ import (
 
 	"github.com/cortexproject/cortex/pkg/ring"
 	"github.com/cortexproject/cortex/pkg/storage/bucket"
+	cortex_testutil "github.com/cortexproject/cortex/pkg/util/testutil"
 	"github.com/cortexproject/cortex/pkg/util/validation"
 )
 
@@ -41,6 +44,8 @@ func TestShuffleShardingGrouper_Groups(t *testing.T) {
 	block0hto1h30mExt1Ulid := ulid.MustNew(15, nil)
 	blocklast1hExt1Ulid := ulid.MustNew(16, nil)
 	blocklast1hExt1UlidCopy := ulid.MustNew(17, nil)
+	blockFresh1hto2hExt1Ulid := ulid.MustNew(ulid.Now(), nil)
+	blockFreshCompacted2hto3hExt1Ulid := ulid.MustNew(ulid.Now()-1, nil)
 
 	blocks :=
 		map[ulid.ULID]*metadata.Meta{
@@ -112,6 +117,14 @@ func TestShuffleShardingGrouper_Groups(t *testing.T) {
 				BlockMeta: tsdb.BlockMeta{ULID: blocklast1hExt1UlidCopy, MinTime: int64(ulid.Now()) - 1*time.Hour.Milliseconds(), MaxTime: int64(ulid.Now())},
 				Thanos:    metadata.Thanos{Labels: map[string]string{"external": "1"}},
 			},
+			blockFresh1hto2hExt1Ulid: {
+				BlockMeta: tsdb.BlockMeta{ULID: blockFresh1hto2hExt1Ulid, MinTime: 1 * time.Hour.Milliseconds(), MaxTime: 2 * time.Hour.Milliseconds()},
+				Thanos:    metadata.Thanos{Labels: map[string]string{"external": "1"}, Source: metadata.ReceiveSource},
+			},
+			blockFreshCompacted2hto3hExt1Ulid: {
+				BlockMeta: tsdb.BlockMeta{ULID: blockFreshCompacted2hto3hExt1Ulid, MinTime: 2 * time.Hour.Milliseconds(), MaxTime: 3 * time.Hour.Milliseconds()},
+				Thanos:    metadata.Thanos{Labels: map[string]string{"external": "1"}, Source: metadata.CompactorSource},
+			},
 		}
 
 	testCompactorID := "test-compactor"
@@ -126,9 +139,13 @@ func TestShuffleShardingGrouper_Groups(t *testing.T) {
 			compactorID string
 			isExpired   bool
 		}
-		expected        [][]ulid.ULID
-		metrics         string
-		noCompactBlocks map[ulid.ULID]*metadata.NoCompactMark
+		expected               [][]ulid.ULID
+		metrics                string
+		noCompactBlocks        map[ulid.ULID]*metadata.NoCompactMark
+		maxCompactionLevel     int
+		verticalCompactionOnly bool
+		minBlockAge            time.Duration
+		expectedTooYoung       float64
 	}{
 		"test basic grouping": {
 			concurrency: 3,
@@ -320,6 +337,68 @@ func TestShuffleShardingGrouper_Groups(t *testing.T) {
 `,
 			noCompactBlocks: map[ulid.ULID]*metadata.NoCompactMark{block2hto3hExt1Ulid: {}},
 		},
+		"test should skip blocks which reached the max compaction level": {
+			concurrency: 2,
+			ranges:      []time.Duration{4 * time.Hour},
+			blocks: map[ulid.ULID]*metadata.Meta{
+				block1hto2hExt1Ulid: {
+					BlockMeta: tsdb.BlockMeta{ULID: block1hto2hExt1Ulid, MinTime: 1 * time.Hour.Milliseconds(), MaxTime: 2 * time.Hour.Milliseconds(), Compaction: tsdb.BlockMetaCompaction{Level: 2}},
+					Thanos:    metadata.Thanos{Labels: map[string]string{"external": "1"}},
+				},
+				block0hto1hExt1Ulid: {
+					BlockMeta: tsdb.BlockMeta{ULID: block0hto1hExt1Ulid, MinTime: 0 * time.Hour.Milliseconds(), MaxTime: 1 * time.Hour.Milliseconds(), Compaction: tsdb.BlockMetaCompaction{Level: 3}},
+					Thanos:    metadata.Thanos{Labels: map[string]string{"external": "1"}},
+				},
+				block2hto3hExt1Ulid: {
+					BlockMeta: tsdb.BlockMeta{ULID: block2hto3hExt1Ulid, MinTime: 2 * time.Hour.Milliseconds(), MaxTime: 3 * time.Hour.Milliseconds(), Compaction: tsdb.BlockMetaCompaction{Level: 1}},
+					Thanos:    metadata.Thanos{Labels: map[string]string{"external": "1"}},
+				},
+				block1hto2hExt2Ulid: {
+					BlockMeta: tsdb.BlockMeta{ULID: block1hto2hExt2Ulid, MinTime: 1 * time.Hour.Milliseconds(), MaxTime: 2 * time.Hour.Milliseconds(), Compaction: tsdb.BlockMetaCompaction{Level: 3}},
+					Thanos:    metadata.Thanos{Labels: map[string]string{"external": "2"}},
+				},
+				block0hto1hExt2Ulid: {
+					BlockMeta: tsdb.BlockMeta{ULID: block0hto1hExt2Ulid, MinTime: 0 * time.Hour.Milliseconds(), MaxTime: 1 * time.Hour.Milliseconds(), Compaction: tsdb.BlockMetaCompaction{Level: 4}},
+					Thanos:    metadata.Thanos{Labels: map[string]string{"external": "2"}},
+				},
+			},
+			expected: [][]ulid.ULID{
+				{block1hto2hExt1Ulid, block2hto3hExt1Ulid},
+			},
+			metrics: `# HELP cortex_compactor_remaining_planned_compactions Total number of plans that remain to be compacted. Only available with shuffle-sharding strategy
+        	          # TYPE cortex_compactor_remaining_planned_compactions gauge
+        	          cortex_compactor_remaining_planned_compactions{user="test-user"} 1
+`,
+			maxCompactionLevel: 3,
+		},
+		"test should only compact overlapping blocks when vertical compaction only is enabled": {
+			concurrency: 3,
+			ranges:      []time.Duration{2 * time.Hour, 4 * time.Hour},
+			blocks:      map[ulid.ULID]*metadata.Meta{block0hto1h30mExt1Ulid: blocks[block0hto1h30mExt1Ulid], block1hto2hExt1Ulid: blocks[block1hto2hExt1Ulid], block1hto2hExt1UlidCopy: blocks[block1hto2hExt1UlidCopy], block2hto3hExt1Ulid: blocks[block2hto3hExt1Ulid], block3hto4hExt1Ulid: blocks[block3hto4hExt1Ulid], block4hto6hExt2Ulid: blocks[block4hto6hExt2Ulid], block6hto8hExt2Ulid: blocks[block6hto8hExt2Ulid]},
+			expected: [][]ulid.ULID{
+				{block1hto2hExt1Ulid, block1hto2hExt1UlidCopy, block0hto1h30mExt1Ulid},
+			},
+			metrics: `# HELP cortex_compactor_remaining_planned_compactions Total number of plans that remain to be compacted. Only available with shuffle-sharding strategy
+        	          # TYPE cortex_compactor_remaining_planned_compactions gauge
+        	          cortex_compactor_remaining_planned_compactions{user="test-user"} 1
+`,
+			verticalCompactionOnly: true,
+		},
+		"test should skip the blocks younger than the min block age, unless produced by the compactor": {
+			concurrency: 3,
+			ranges:      []time.Duration{2 * time.Hour, 4 * time.Hour},
+			blocks:      map[ulid.ULID]*metadata.Meta{block1hto2hExt1Ulid: blocks[block1hto2hExt1Ulid], block0hto1hExt1Ulid: blocks[block0hto1hExt1Ulid], blockFresh1hto2hExt1Ulid: blocks[blockFresh1hto2hExt1Ulid], blockFreshCompacted2hto3hExt1Ulid: blocks[blockFreshCompacted2hto3hExt1Ulid], block3hto4hExt1Ulid: blocks[block3hto4hExt1Ulid]},
+			expected: [][]ulid.ULID{
+				{block1hto2hExt1Ulid, block0hto1hExt1Ulid},
+				{block3hto4hExt1Ulid, blockFreshCompacted2hto3hExt1Ulid},
+			},
+			metrics: `# HELP cortex_compactor_remaining_planned_compactions Total number of plans that remain to be compacted. Only available with shuffle-sharding strategy
+        	          # TYPE cortex_compactor_remaining_planned_compactions gauge
+        	          cortex_compactor_remaining_planned_compactions{user="test-user"} 2
+`,
+			minBlockAge:      time.Hour,
+			expectedTooYoung: 1,
+		},
 	}
 
 	for testName, testData := range tests {
@@ -328,7 +407,7 @@ func TestShuffleShardingGrouper_Groups(t *testing.T) {
 				BlockRanges: testData.ranges,
 			}
 
-			limits := &validation.Limits{}
+			limits := &validation.Limits{CompactorMaxCompactionLevel: testData.maxCompactionLevel, CompactorVerticalCompactionOnly: testData.verticalCompactionOnly, CompactorMinBlockAge: model.Duration(testData.minBlockAge)}
 			overrides := validation.NewOverrides(*limits, nil)
 
 			// Setup mocking of the ring so that the grouper will own all the shards
@@ -340,6 +419,7 @@ func TestShuffleShardingGrouper_Groups(t *testing.T) {
 			subring := &ring.RingMock{}
 			subring.On("GetAllHealthy", mock.Anything).Return(rs, nil)
 			subring.On("Get", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(rs, nil)
+			subring.On("GetAllInstanceDescs", mock.Anything).Return([]ring.InstanceDesc{{Addr: "test-addr", Tokens: []uint32{0}}}, nil)
 
 			ring := &ring.RingMock{}
 			ring.On("ShuffleShard", mock.Anything, mock.Anything).Return(subring, nil)
@@ -414,10 +494,212 @@ func TestShuffleShardingGrouper_Groups(t *testing.T) {
 
 			err = testutil.GatherAndCompare(registerer, bytes.NewBufferString(testData.metrics), "cortex_compactor_remaining_planned_compactions")
 			require.NoError(t, err)
+			assert.Equal(t, testData.expectedTooYoung, testutil.ToFloat64(metrics.blocksTooYoungForCompaction.WithLabelValues("test-user")))
 		})
 	}
 }
 
+func TestShuffleShardingGrouper_GroupsShouldNotBePlannedByMultipleCompactorsOnRingChange(t *testing.T) {
+	const userID = "test-user"
+
+	blocks, groupHashes := createShuffleShardingGrouperTestGroups(userID, 4)
+
+	// Before the ring change, compactor-1 is the only compactor in the tenant sub-ring.
+	compactor1 := ring.InstanceDesc{Addr: "compactor-1", Tokens: groupHashes}
+	ringBefore := &ring.RingMock{}
+	subRingBefore := &ring.RingMock{}
+	subRingBefore.On("GetAllHealthy", mock.Anything).Return(ring.ReplicationSet{Instances: []ring.InstanceDesc{compactor1}}, nil)
+	subRingBefore.On("GetAllInstanceDescs", mock.Anything).Return([]ring.InstanceDesc{compactor1}, nil)
+	ringBefore.On("ShuffleShard", mock.Anything, mock.Anything).Return(subRingBefore, nil)
+
+	// After the ring change, compactor-2 has joined the tenant sub-ring and owns half of the groups.
+	compactor1 = ring.InstanceDesc{Addr: "compactor-1", Tokens: []uint32{groupHashes[0], groupHashes[2]}}
+	compactor2 := ring.InstanceDesc{Addr: "compactor-2", Tokens: []uint32{groupHashes[1], groupHashes[3]}}
+	ringAfter := &ring.RingMock{}
+	subRingAfter := &ring.RingMock{}
+	subRingAfter.On("GetAllHealthy", mock.Anything).Return(ring.ReplicationSet{Instances: []ring.InstanceDesc{compactor1, compactor2}}, nil)
+	subRingAfter.On("GetAllInstanceDescs", mock.Anything).Return([]ring.InstanceDesc{compactor1, compactor2}, nil)
+	ringAfter.On("ShuffleShard", mock.Anything, mock.Anything).Return(subRingAfter, nil)
+
+	plannedBy := map[string]string{}
+	plan := func(t *testing.T, bkt objstore.InstrumentedBucket, r ring.ReadRing, compactor ring.InstanceDesc) {
+		groups, err := newShuffleShardingGrouperForTest(t, bkt, r, compactor, userID, len(groupHashes)).Groups(blocks)
+		require.NoError(t, err)
+
+		for _, group := range groups {
+			key := fmt.Sprintf("%v", group.IDs())
+			if other, ok := plannedBy[key]; ok {
+				require.Equal(t, compactor.Addr, other, "group %s has been planned by more than one compactor", key)
+			}
+			plannedBy[key] = compactor.Addr
+		}
+	}
+
+	// Right after the ring change both compactors plan concurrently, so neither of them
+	// can see the visit markers written by the other one yet.
+	bkt1, _ := cortex_testutil.PrepareFilesystemBucket(t)
+	bkt2, _ := cortex_testutil.PrepareFilesystemBucket(t)
+	plan(t, bkt1, ringAfter, compactor1)
+	plan(t, bkt2, ringAfter, compactor2)
+
+	// A compactor which hasn't observed the ring change yet doesn't pick up the groups
+	// already planned by their new owner.
+	plan(t, bkt2, ringBefore, compactor1)
+
+	assert.Len(t, plannedBy, len(groupHashes))
+}
+
+func TestShuffleShardingGrouper_GroupsOwnedByAnUnhealthyCompactorShouldBePlannedByTheNextHealthyOne(t *testing.T) {
+	const userID = "test-user"
+
+	blocks, groupHashes := createShuffleShardingGrouperTestGroups(userID, 4)
+
+	// compactor-2 owns half of the groups, but it's unhealthy so it's not returned among the healthy instances.
+	compactor1 := ring.InstanceDesc{Addr: "compactor-1", Tokens: []uint32{groupHashes[0], groupHashes[2]}}
+	compactor2 := ring.InstanceDesc{Addr: "compactor-2", Tokens: []uint32{groupHashes[1], groupHashes[3]}}
+	r := &ring.RingMock{}
+	subRing := &ring.RingMock{}
+	subRing.On("GetAllHealthy", mock.Anything).Return(ring.ReplicationSet{Instances: []ring.InstanceDesc{compactor1}}, nil)
+	subRing.On("GetAllInstanceDescs", mock.Anything).Return([]ring.InstanceDesc{compactor1}, nil)
+	r.On("ShuffleShard", mock.Anything, mock.Anything).Return(subRing, nil)
+
+	bkt, _ := cortex_testutil.PrepareFilesystemBucket(t)
+	groups, err := newShuffleShardingGrouperForTest(t, bkt, r, compactor1, userID, len(groupHashes)).Groups(blocks)
+	require.NoError(t, err)
+	assert.Len(t, groups, len(groupHashes))
+
+	groups, err = newShuffleShardingGrouperForTest(t, bkt, r, compactor2, userID, len(groupHashes)).Groups(blocks)
+	require.NoError(t, err)
+	assert.Empty(t, groups)
+}
+
+// createShuffleShardingGrouperTestGroups returns the blocks of the given number of groups, each made of
+// two 1h blocks within the same 2h range, and the hashes of the groups.
+func createShuffleShardingGrouperTestGroups(userID string, numGroups int) (map[ulid.ULID]*metadata.Meta, []uint32) {
+	blocks := map[ulid.ULID]*metadata.Meta{}
+	var groupHashes []uint32
+	for i := range numGroups {
+		rangeStart := int64(i) * 2 * time.Hour.Milliseconds()
+		rangeEnd := rangeStart + 2*time.Hour.Milliseconds()
+		groupHashes = append(groupHashes, hashGroup(userID, rangeStart, rangeEnd))
+
+		for j := range 2 {
+			id := ulid.MustNew(uint64(i*2+j+1), nil)
+			blocks[id] = &metadata.Meta{
+				BlockMeta: tsdb.BlockMeta{ULID: id, MinTime: rangeStart + int64(j)*time.Hour.Milliseconds(), MaxTime: rangeStart + int64(j+1)*time.Hour.Milliseconds()},
+				Thanos:    metadata.Thanos{Labels: map[string]string{"external": "1"}},
+			}
+		}
+	}
+	return blocks, groupHashes
+}
+
+func newShuffleShardingGrouperForTest(t *testing.T, bkt objstore.InstrumentedBucket, r ring.ReadRing, compactor ring.InstanceDesc, userID string, maxCompactionGroups int) *ShuffleShardingGrouper {
+	registerer := prometheus.NewPedanticRegistry()
+	metrics := newCompactorMetrics(registerer)
+
+	return NewShuffleShardingGrouper(
+		t.Context(),
+		nil,
+		bkt,
+		false, // Do not accept malformed indexes
+		true,  // Enable vertical compaction
+		nil,
+		metadata.NoneFunc,
+		metrics.getSyncerMetrics(userID),
+		metrics,
+		Config{BlockRanges: []time.Duration{2 * time.Hour}},
+		r,
+		compactor.Addr,
+		compactor.Addr,
+		validation.NewOverrides(validation.Limits{}, nil),
+		userID,
+		10,
+		3,
+		maxCompactionGroups,
+		5*time.Minute,
+		prometheus.NewCounter(prometheus.CounterOpts{}),
+		prometheus.NewCounter(prometheus.CounterOpts{}),
+		func() map[ulid.ULID]*metadata.NoCompactMark { return nil },
+	)
+}
+
+func TestShuffleShardingGrouper_ShouldExposeTenantCompactionProgress(t *testing.T) {
+	const userID = "test-user"
+
+	// Create 3 groups, each made of two 1h blocks within the same 2h range.
+	blocks := map[ulid.ULID]*metadata.Meta{}
+	for i := 0; i < 6; i++ {
+		id := ulid.MustNew(uint64(i+1), nil)
+		blocks[id] = &metadata.Meta{
+			BlockMeta: tsdb.BlockMeta{ULID: id, MinTime: int64(i) * time.Hour.Milliseconds(), MaxTime: int64(i+1) * time.Hour.Milliseconds()},
+			Thanos:    metadata.Thanos{Labels: map[string]string{"external": "1"}},
+		}
+	}
+
+	rs := ring.ReplicationSet{Instances: []ring.InstanceDesc{{Addr: "test-addr"}}}
+	subring := &ring.RingMock{}
+	subring.On("GetAllHealthy", mock.Anything).Return(rs, nil)
+	subring.On("GetAllInstanceDescs", mock.Anything).Return([]ring.InstanceDesc{{Addr: "test-addr", Tokens: []uint32{0}}}, nil)
+	r := &ring.RingMock{}
+	r.On("ShuffleShard", mock.Anything, mock.Anything).Return(subring, nil)
+
+	registerer := prometheus.NewPedanticRegistry()
+	metrics := newCompactorMetrics(registerer)
+
+	plan := func() {
+		bkt, _ := cortex_testutil.PrepareFilesystemBucket(t)
+		g := NewShuffleShardingGrouper(
+			t.Context(),
+			nil,
+			bkt,
+			false, // Do not accept malformed indexes
+			true,  // Enable vertical compaction
+			nil,
+			metadata.NoneFunc,
+			metrics.getSyncerMetrics(userID),
+			metrics,
+			Config{BlockRanges: []time.Duration{2 * time.Hour}},
+			r,
+			"test-addr",
+			"test-compactor",
+			validation.NewOverrides(validation.Limits{}, nil),
+			userID,
+			10,
+			3,
+			1,
+			5*time.Minute,
+			prometheus.NewCounter(prometheus.CounterOpts{}),
+			prometheus.NewCounter(prometheus.CounterOpts{}),
+			func() map[ulid.ULID]*metadata.NoCompactMark { return nil },
+		)
+		actual, err := g.Groups(blocks)
+		require.NoError(t, err)
+		require.Len(t, actual, 1)
+	}
+
+	// No compaction has completed yet, so the estimate is not available.
+	plan()
+	require.NoError(t, testutil.GatherAndCompare(registerer, bytes.NewBufferString(`
+		# HELP cortex_compactor_tenant_pending_compactions Number of source blocks pending compaction for the tenant. Only available with shuffle-sharding strategy
+		# TYPE cortex_compactor_tenant_pending_compactions gauge
+		cortex_compactor_tenant_pending_compactions{user="test-user"} 6
+	`), "cortex_compactor_tenant_pending_compactions", "cortex_compactor_tenant_estimated_seconds_remaining"))
+
+	metrics.compactionProgress.observe(userID, 30*time.Second)
+	metrics.compactionProgress.observe(userID, 90*time.Second)
+
+	plan()
+	require.NoError(t, testutil.GatherAndCompare(registerer, bytes.NewBufferString(`
+		# HELP cortex_compactor_tenant_estimated_seconds_remaining Estimated time in seconds to complete the planned compactions for the tenant, based on the average duration of its recent compactions. Only available with shuffle-sharding strategy
+		# TYPE cortex_compactor_tenant_estimated_seconds_remaining gauge
+		cortex_compactor_tenant_estimated_seconds_remaining{user="test-user"} 180
+		# HELP cortex_compactor_tenant_pending_compactions Number of source blocks pending compaction for the tenant. Only available with shuffle-sharding strategy
+		# TYPE cortex_compactor_tenant_pending_compactions gauge
+		cortex_compactor_tenant_pending_compactions{user="test-user"} 6
+	`), "cortex_compactor_tenant_pending_compactions", "cortex_compactor_tenant_estimated_seconds_remaining"))
+}
+
 func TestGroupBlocksByCompactableRanges(t *testing.T) {
 	tests := map[string]struct {
 		ranges   []int64
@@ -600,7 +882,7 @@ func TestGroupBlocksByCompactableRanges(t *testing.T) {
 
 	for testName, testData := range tests {
 		t.Run(testName, func(t *testing.T) {
-			assert.Equal(t, testData.expected, groupBlocksByCompactableRanges(testData.blocks, testData.ranges))
+			assert.Equal(t, testData.expected, groupBlocksByCompactableRanges(testData.blocks, testData.ranges, false))
 		})
 	}
 }
@@ -735,3 +1017,47 @@ func TestBlocksGroup_overlaps(t *testing.T) {
 		assert.Equal(t, tc.expected, tc.second.overlaps(tc.first))
 	}
 }
+
+func TestBlocksGroup_overlappingOnly(t *testing.T) {
+	tests := map[string]struct {
+		blocks   []*metadata.Meta
+		expected bool
+	}{
+		"blocks with the same range": {
+			blocks: []*metadata.Meta{
+				{BlockMeta: tsdb.BlockMeta{MinTime: 10, MaxTime: 20}},
+				{BlockMeta: tsdb.BlockMeta{MinTime: 10, MaxTime: 20}},
+			},
+			expected: true,
+		},
+		"adjacent blocks": {
+			blocks: []*metadata.Meta{
+				{BlockMeta: tsdb.BlockMeta{MinTime: 10, MaxTime: 20}},
+				{BlockMeta: tsdb.BlockMeta{MinTime: 20, MaxTime: 30}},
+			},
+			expected: false,
+		},
+		"chain of overlapping blocks": {
+			blocks: []*metadata.Meta{
+				{BlockMeta: tsdb.BlockMeta{MinTime: 10, MaxTime: 30}},
+				{BlockMeta: tsdb.BlockMeta{MinTime: 15, MaxTime: 20}},
+				{BlockMeta: tsdb.BlockMeta{MinTime: 25, MaxTime: 40}},
+			},
+			expected: true,
+		},
+		"overlapping blocks followed by a non overlapping one": {
+			blocks: []*metadata.Meta{
+				{BlockMeta: tsdb.BlockMeta{MinTime: 10, MaxTime: 20}},
+				{BlockMeta: tsdb.BlockMeta{MinTime: 15, MaxTime: 25}},
+				{BlockMeta: tsdb.BlockMeta{MinTime: 30, MaxTime: 40}},
+			},
+			expected: false,
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			assert.Equal(t, testData.expected, blocksGroup{blocks: testData.blocks}.overlappingOnly())
+		})
+	}
+}
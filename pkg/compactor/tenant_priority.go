@@ -0,0 +1,130 @@
+package compactor
+
+import (
+	"context"
+	"math"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/go-kit/log/level"
+	"github.com/oklog/ulid/v2"
+
+	"github.com/cortexproject/cortex/pkg/storage/bucket"
+	"github.com/cortexproject/cortex/pkg/storage/tsdb/bucketindex"
+	util_log "github.com/cortexproject/cortex/pkg/util/log"
+)
+
+const (
+	// TenantPriorityRandom compacts the tenants in a random order.
+	TenantPriorityRandom = "random"
+	// TenantPriorityOldestUncompactedFirst compacts first the tenant whose oldest uncompacted block is the oldest.
+	TenantPriorityOldestUncompactedFirst = "oldest-uncompacted-first"
+)
+
+var supportedTenantPriorities = []string{TenantPriorityRandom, TenantPriorityOldestUncompactedFirst}
+
+// sortUsersByOldestUncompactedBlock sorts the users owned by the compactor by the min time of their
+// oldest uncompacted block, read from their bucket index. A block is considered uncompacted if its
+// time range is shorter than the largest block range and it can still be compacted with another block
+// of the same largest range. The users not owned, without bucket index or without uncompacted blocks
+// are moved at the end, keeping their order.
+func (c *Compactor) sortUsersByOldestUncompactedBlock(ctx context.Context, userIDs []string) []string {
+	blockRanges := c.compactorCfg.BlockRanges.ToMilliseconds()
+	if len(blockRanges) == 0 {
+		return userIDs
+	}
+	largestRange := blockRanges[len(blockRanges)-1]
+
+	oldest := make(map[string]int64, len(userIDs))
+	for _, userID := range userIDs {
+		oldest[userID] = math.MaxInt64
+
+		if owned, err := c.ownUserForCompaction(userID); err != nil || !owned {
+			continue
+		}
+
+		idx, err := bucketindex.ReadIndex(ctx, c.bucketClient, userID, c.limits, c.logger)
+		if err != nil {
+			level.Warn(util_log.WithUserID(userID, c.logger)).Log("msg", "unable to read the bucket index to prioritize the user compaction", "err", err)
+			continue
+		}
+
+		noCompact, err := c.listNoCompactMarkedBlocks(ctx, userID)
+		if err != nil {
+			level.Warn(util_log.WithUserID(userID, c.logger)).Log("msg", "unable to list the blocks marked for no compaction to prioritize the user compaction", "err", err)
+			continue
+		}
+		oldest[userID] = oldestUncompactedBlockMinTime(idx, noCompact, largestRange)
+	}
+
+	sorted := slices.Clone(userIDs)
+	slices.SortStableFunc(sorted, func(a, b string) int {
+		switch {
+		case oldest[a] < oldest[b]:
+			return -1
+		case oldest[a] > oldest[b]:
+			return 1
+		default:
+			return 0
+		}
+	})
+
+	level.Debug(c.logger).Log("msg", "sorted users by oldest uncompacted block", "users", strings.Join(sorted, ","))
+	return sorted
+}
+
+// listNoCompactMarkedBlocks returns the blocks of the user marked for no compaction, listed from the
+// global markers location.
+func (c *Compactor) listNoCompactMarkedBlocks(ctx context.Context, userID string) (map[ulid.ULID]struct{}, error) {
+	noCompact := map[ulid.ULID]struct{}{}
+	err := bucket.NewUserBucketClient(userID, c.bucketClient, c.limits).Iter(ctx, bucketindex.MarkersPathname+"/", func(name string) error {
+		if id, ok := bucketindex.IsBlockNoCompactMarkFilename(path.Base(name)); ok {
+			noCompact[id] = struct{}{}
+		}
+		return nil
+	})
+	return noCompact, err
+}
+
+// oldestUncompactedBlockMinTime returns the min time of the oldest block whose time range is shorter
+// than the largest block range, or math.MaxInt64 if there's none. The blocks marked for deletion or
+// for no compaction are ignored, and so are the blocks which can't grow further because they're the
+// only block of their largest range (e.g. the sparse days of a tenant).
+func oldestUncompactedBlockMinTime(idx *bucketindex.Index, noCompact map[ulid.ULID]struct{}, largestRange int64) int64 {
+	deleted := make(map[ulid.ULID]struct{}, len(idx.BlockDeletionMarks))
+	for _, m := range idx.BlockDeletionMarks {
+		deleted[m.ID] = struct{}{}
+	}
+
+	// The blocks which could be compacted, and the number of them within each largest range.
+	var candidates []*bucketindex.Block
+	perRange := map[int64]int{}
+	for _, b := range idx.Blocks {
+		if _, ok := deleted[b.ID]; ok {
+			continue
+		}
+		if _, ok := noCompact[b.ID]; ok {
+			continue
+		}
+		candidates = append(candidates, b)
+		perRange[largestRangeStart(b.MinTime, largestRange)]++
+	}
+
+	oldest := int64(math.MaxInt64)
+	for _, b := range candidates {
+		if b.MaxTime-b.MinTime >= largestRange || perRange[largestRangeStart(b.MinTime, largestRange)] < 2 {
+			continue
+		}
+		oldest = min(oldest, b.MinTime)
+	}
+	return oldest
+}
+
+// largestRangeStart returns the start of the largest range the input timestamp belongs to.
+func largestRangeStart(t, largestRange int64) int64 {
+	if t >= 0 {
+		return largestRange * (t / largestRange)
+	}
+	return largestRange * ((t - largestRange + 1) / largestRange)
+}
@@ -0,0 +1,80 @@
+package compactor
+
+import (
+	"context"
+	"math"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/objstore"
+
+	"github.com/cortexproject/cortex/pkg/storage/tsdb/bucketindex"
+)
+
+func TestCompactor_SortUsersByOldestUncompactedBlock(t *testing.T) {
+	ctx := context.Background()
+	bucketClient := objstore.WithNoopInstr(objstore.NewInMemBucket())
+
+	hour := time.Hour.Milliseconds()
+	for userID, blocks := range map[string]bucketindex.Blocks{
+		// Only the blocks compacted up to the largest block range.
+		"user-1": {{ID: ulid.MustNew(1, nil), MinTime: 0, MaxTime: 24 * hour}},
+		"user-2": {
+			{ID: ulid.MustNew(2, nil), MinTime: 0, MaxTime: 24 * hour},
+			{ID: ulid.MustNew(3, nil), MinTime: 24 * hour, MaxTime: 26 * hour},
+			{ID: ulid.MustNew(4, nil), MinTime: 26 * hour, MaxTime: 28 * hour},
+		},
+		"user-3": {
+			{ID: ulid.MustNew(5, nil), MinTime: 12 * hour, MaxTime: 14 * hour},
+			{ID: ulid.MustNew(6, nil), MinTime: 14 * hour, MaxTime: 16 * hour},
+		},
+		// The oldest uncompacted block is marked for no compaction.
+		"user-5": {
+			{ID: ulid.MustNew(7, nil), MinTime: 0, MaxTime: 2 * hour},
+			{ID: ulid.MustNew(8, nil), MinTime: 2 * hour, MaxTime: 4 * hour},
+		},
+	} {
+		require.NoError(t, bucketindex.WriteIndex(ctx, bucketClient, userID, nil, &bucketindex.Index{
+			Version: bucketindex.IndexVersion1,
+			Blocks:  blocks,
+		}))
+	}
+	require.NoError(t, bucketClient.Upload(ctx, path.Join("user-5", bucketindex.NoCompactMarkFilenameMarkFilepath(ulid.MustNew(7, nil))), strings.NewReader("{}")))
+
+	cfg := prepareConfig()
+	cfg.TenantPriority = TenantPriorityOldestUncompactedFirst
+	c, _, _, _, _ := prepare(t, cfg, bucketClient, nil)
+	c.bucketClient = bucketClient
+
+	// The user-4 has no bucket index.
+	sorted := c.sortUsersByOldestUncompactedBlock(ctx, []string{"user-4", "user-1", "user-2", "user-5", "user-3"})
+	assert.Equal(t, []string{"user-3", "user-2", "user-4", "user-1", "user-5"}, sorted)
+}
+
+func TestOldestUncompactedBlockMinTime(t *testing.T) {
+	hour := time.Hour.Milliseconds()
+	idx := &bucketindex.Index{
+		Blocks: bucketindex.Blocks{
+			{ID: ulid.MustNew(1, nil), MinTime: 0, MaxTime: 24 * hour},
+			{ID: ulid.MustNew(2, nil), MinTime: 24 * hour, MaxTime: 26 * hour},
+			{ID: ulid.MustNew(3, nil), MinTime: 26 * hour, MaxTime: 28 * hour},
+			{ID: ulid.MustNew(4, nil), MinTime: 28 * hour, MaxTime: 30 * hour},
+			// The only block of its largest range can't grow further.
+			{ID: ulid.MustNew(5, nil), MinTime: 48 * hour, MaxTime: 50 * hour},
+		},
+	}
+	assert.Equal(t, 24*hour, oldestUncompactedBlockMinTime(idx, nil, 24*hour))
+
+	// The blocks marked for deletion are ignored.
+	idx.BlockDeletionMarks = bucketindex.BlockDeletionMarks{{ID: ulid.MustNew(2, nil)}}
+	assert.Equal(t, 26*hour, oldestUncompactedBlockMinTime(idx, nil, 24*hour))
+
+	// The blocks marked for no compaction are ignored, and the remaining block can't grow further.
+	noCompact := map[ulid.ULID]struct{}{ulid.MustNew(3, nil): {}}
+	assert.Equal(t, int64(math.MaxInt64), oldestUncompactedBlockMinTime(idx, noCompact, 24*hour))
+}
@@ -72,6 +72,7 @@ import (
 var (
 	errInvalidHTTPPrefix                       = errors.New("HTTP prefix should be empty or start with /")
 	errTimeoutClassificationRequiresQueryStats = errors.New("timeout classification requires query stats to be enabled (frontend.query-stats-enabled)")
+	errBucketFederationRequiresNoBucketIndex   = errors.New("store-gateway bucket federation requires the bucket index to be disabled (blocks-storage.bucket-store.bucket-index.enabled)")
 )
 
 // The design pattern for Cortex is a series of config objects, which are
@@ -241,15 +242,24 @@ func (c *Config) Validate(log log.Logger) error {
 	if err := c.Worker.Validate(log); err != nil {
 		return errors.Wrap(err, "invalid frontend_worker config")
 	}
+	if err := c.Frontend.Validate(); err != nil {
+		return errors.Wrap(err, "invalid frontend config")
+	}
 	if err := c.QueryRange.Validate(c.Querier); err != nil {
 		return errors.Wrap(err, "invalid query_range config")
 	}
 	if err := c.StoreGateway.Validate(c.LimitsConfig, c.ResourceMonitor.Resources); err != nil {
 		return errors.Wrap(err, "invalid store-gateway config")
 	}
+	if c.StoreGateway.BucketFederation.Enabled && c.BlocksStorage.BucketStore.BucketIndex.Enabled {
+		return errBucketFederationRequiresNoBucketIndex
+	}
 	if err := c.Compactor.Validate(c.LimitsConfig); err != nil {
 		return errors.Wrap(err, "invalid compactor config")
 	}
+	if c.isModuleEnabled(Compactor) || c.isModuleEnabled(All) {
+		c.Compactor.ValidateDeletionDelay(c.BlocksStorage.BucketStore, c.LimitsConfig, log)
+	}
 	if err := c.AlertmanagerStorage.Validate(); err != nil {
 		return errors.Wrap(err, "invalid alertmanager storage config")
 	}
@@ -263,6 +263,28 @@ func TestConfigValidation(t *testing.T) {
 			},
 			expectedError: nil,
 		},
+		{
+			name: "should fail when store-gateway bucket federation is enabled together with the bucket index",
+			getTestConfig: func() *Config {
+				configuration := newDefaultConfig()
+				configuration.StoreGateway.BucketFederation.Enabled = true
+				configuration.StoreGateway.BucketFederation.SecondaryBucket.Backend = bucket.Filesystem
+				configuration.BlocksStorage.BucketStore.BucketIndex.Enabled = true
+				return configuration
+			},
+			expectedError: errBucketFederationRequiresNoBucketIndex,
+		},
+		{
+			name: "should pass when store-gateway bucket federation is enabled and the bucket index is disabled",
+			getTestConfig: func() *Config {
+				configuration := newDefaultConfig()
+				configuration.StoreGateway.BucketFederation.Enabled = true
+				configuration.StoreGateway.BucketFederation.SecondaryBucket.Backend = bucket.Filesystem
+				configuration.BlocksStorage.BucketStore.BucketIndex.Enabled = false
+				return configuration
+			},
+			expectedError: nil,
+		},
 		{
 			name: "should pass when timeout classification is disabled and query stats is disabled",
 			getTestConfig: func() *Config {
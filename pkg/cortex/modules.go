@@ -403,6 +403,8 @@ func (t *Cortex) initQuerier() (serv services.Service, err error) {
 		t.ExemplarQueryable,
 		t.QuerierEngine,
 		t.MetadataQuerier,
+		querier.NewQueryCostEstimator(t.Distributor, t.Cfg.Querier.LookbackDelta),
+		t.OverridesConfig,
 		prometheus.DefaultRegisterer,
 		util_log.Logger,
 	)
@@ -554,9 +556,9 @@ func (t *Cortex) initQueryFrontendTripperware() (serv services.Service, err erro
 	}
 
 	// PrometheusCodec is a codec to encode and decode Prometheus query range requests and responses.
-	prometheusCodec := queryrange.NewPrometheusCodec(false, t.Cfg.Querier.ResponseCompression, t.Cfg.API.QuerierDefaultCodec)
+	prometheusCodec := queryrange.NewPrometheusCodec(false, t.Cfg.Querier.ResponseCompression, t.Cfg.API.QuerierDefaultCodec, t.Cfg.QueryRange.StreamMatrixResponses)
 	// ShardedPrometheusCodec is same as PrometheusCodec but to be used on the sharded queries (it sum up the stats)
-	shardedPrometheusCodec := queryrange.NewPrometheusCodec(true, t.Cfg.Querier.ResponseCompression, t.Cfg.API.QuerierDefaultCodec)
+	shardedPrometheusCodec := queryrange.NewPrometheusCodec(true, t.Cfg.Querier.ResponseCompression, t.Cfg.API.QuerierDefaultCodec, false)
 	instantQueryCodec := instantquery.NewInstantQueryCodec(t.Cfg.Querier.ResponseCompression, t.Cfg.API.QuerierDefaultCodec)
 
 	if t.Cfg.TenantFederation.Enabled && t.Cfg.TenantFederation.RegexMatcherEnabled {
@@ -604,7 +606,14 @@ func (t *Cortex) initQueryFrontendTripperware() (serv services.Service, err erro
 		return nil, err
 	}
 
-	t.QueryFrontendTripperware = tripperware.NewQueryTripperware(util_log.Logger,
+	if t.Cfg.QueryRange.QueryCoalescingEnabled {
+		// The queries are coalesced before any other middleware, so that the identical queries are executed once.
+		coalescing := tripperware.NewCoalescingMiddleware(t.Cfg.QueryRange.QueryCoalescingFollowerTimeout, prometheus.DefaultRegisterer)
+		queryRangeMiddlewares = append([]tripperware.Middleware{coalescing}, queryRangeMiddlewares...)
+		instantQueryMiddlewares = append([]tripperware.Middleware{coalescing}, instantQueryMiddlewares...)
+	}
+
+	queryTripperware := tripperware.NewQueryTripperware(util_log.Logger,
 		prometheus.DefaultRegisterer,
 		t.Cfg.QueryRange.ForwardHeaders,
 		queryRangeMiddlewares,
@@ -617,6 +626,10 @@ func (t *Cortex) initQueryFrontendTripperware() (serv services.Service, err erro
 		t.Cfg.Querier.MaxSubQuerySteps,
 		t.Cfg.Querier.LookbackDelta,
 	)
+	queryCostTripperware := queryrange.NewQueryCostTripperware(t.Cfg.QueryRange, t.OverridesConfig, queryAnalyzer, t.Cfg.Querier.LookbackDelta)
+	t.QueryFrontendTripperware = func(next http.RoundTripper) http.RoundTripper {
+		return queryCostTripperware(queryTripperware(next))
+	}
 
 	return services.NewIdleService(nil, func(_ error) error {
 		if cache != nil {
@@ -726,7 +739,7 @@ func (t *Cortex) initRuler() (serv services.Service, err error) {
 				return t.Cfg.Querier.DefaultEvaluationInterval.Milliseconds()
 			},
 		}
-		queryEngine = engine.New(opts, t.Cfg.Ruler.ThanosEngine, rulerRegisterer)
+		queryEngine = engine.New(opts, t.Cfg.Ruler.ThanosEngine, t.OverridesConfig, rulerRegisterer)
 	} else {
 		// TODO: Consider wrapping logger to differentiate from querier module logger
 		queryable, _, queryEngine, _ = querier.New(t.Cfg.Querier, t.OverridesConfig, t.Distributor, t.StoreQueryables, rulerRegisterer, util_log.Logger, t.OverridesConfig.RulesPartialData, nil)
@@ -0,0 +1,125 @@
+package distributor
+
+import (
+	"context"
+
+	"github.com/prometheus/prometheus/model/labels"
+
+	ingester_client "github.com/cortexproject/cortex/pkg/ingester/client"
+)
+
+// CardinalityStat is the number of series or label values of a metric name, label name
+// or label name/value pair.
+type CardinalityStat struct {
+	Name  string `json:"name"`
+	Value uint64 `json:"value"`
+}
+
+// CardinalityResponse is the cardinality analysis of the active series of a tenant,
+// modeled after the Prometheus TSDB stats.
+type CardinalityResponse struct {
+	NumSeries                   uint64            `json:"numSeries"`
+	SeriesCountByMetricName     []CardinalityStat `json:"seriesCountByMetricName"`
+	LabelValueCountByLabelName  []CardinalityStat `json:"labelValueCountByLabelName"`
+	SeriesCountByLabelValuePair []CardinalityStat `json:"seriesCountByLabelValuePair"`
+}
+
+// Cardinality returns the top limit metric names, label names and label name/value pairs of the
+// tenant's in-memory series matching the input matchers.
+func (d *Distributor) Cardinality(ctx context.Context, limit int, matchers ...*labels.Matcher) (*CardinalityResponse, error) {
+	resp, err := d.HeadCardinality(ctx, limit, matchers...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CardinalityResponse{
+		NumSeries:                   resp.NumSeries,
+		SeriesCountByMetricName:     toCardinalityStats(resp.SeriesCountByMetricName),
+		LabelValueCountByLabelName:  toCardinalityStats(resp.LabelValueCountByLabelName),
+		SeriesCountByLabelValuePair: toCardinalityStats(resp.SeriesCountByLabelValuePair),
+	}, nil
+}
+
+// HeadCardinality returns the cardinality stats of the ingesters TSDB head for the series matching
+// the input matchers. Each ingester computes the top limit stats of its TSDB head, and they're merged:
+// the series and chunks counts are summed and divided by the replication factor, and the label values
+// counts are the max across the ingesters. The merged stats are approximated when a stat isn't in the
+// top stats of every ingester.
+func (d *Distributor) HeadCardinality(ctx context.Context, limit int, matchers ...*labels.Matcher) (*ingester_client.CardinalityResponse, error) {
+	replicationSet, err := d.GetIngestersForMetadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Make sure we get a successful response from all of them.
+	replicationSet.MaxErrors = 0
+
+	req, err := ingester_client.ToCardinalityRequest(limit, matchers)
+	if err != nil {
+		return nil, err
+	}
+
+	resps, err := d.ForReplicationSet(ctx, replicationSet, false, false, func(ctx context.Context, client ingester_client.IngesterClient) (any, error) {
+		return client.Cardinality(ctx, req)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		merged                     = &ingester_client.CardinalityResponse{}
+		hasTimeRange               bool
+		seriesByMetricName         = map[string]uint64{}
+		labelValueCountByLabelName = map[string]uint64{}
+		seriesByLabelValuePair     = map[string]uint64{}
+	)
+	for _, resp := range resps {
+		r := resp.(*ingester_client.CardinalityResponse)
+		merged.NumSeries += r.NumSeries
+		merged.NumChunks += r.NumChunks
+		for _, s := range r.SeriesCountByMetricName {
+			seriesByMetricName[s.Name] += s.Value
+		}
+		for _, s := range r.LabelValueCountByLabelName {
+			labelValueCountByLabelName[s.Name] = max(labelValueCountByLabelName[s.Name], s.Value)
+		}
+		for _, s := range r.SeriesCountByLabelValuePair {
+			seriesByLabelValuePair[s.Name] += s.Value
+		}
+
+		// The time range is unset by the ingesters whose head is empty.
+		if r.MinTimestampMs == 0 && r.MaxTimestampMs == 0 {
+			continue
+		}
+		if !hasTimeRange {
+			merged.MinTimestampMs, merged.MaxTimestampMs = r.MinTimestampMs, r.MaxTimestampMs
+			hasTimeRange = true
+			continue
+		}
+		merged.MinTimestampMs = min(merged.MinTimestampMs, r.MinTimestampMs)
+		merged.MaxTimestampMs = max(merged.MaxTimestampMs, r.MaxTimestampMs)
+	}
+
+	factor := uint64(d.ingestersRing.ReplicationFactor())
+	for name := range seriesByMetricName {
+		seriesByMetricName[name] /= factor
+	}
+	for name := range seriesByLabelValuePair {
+		seriesByLabelValuePair[name] /= factor
+	}
+
+	merged.NumSeries /= factor
+	merged.NumChunks /= factor
+	merged.SeriesCountByMetricName = ingester_client.TopCardinalityStats(seriesByMetricName, limit)
+	merged.LabelValueCountByLabelName = ingester_client.TopCardinalityStats(labelValueCountByLabelName, limit)
+	merged.SeriesCountByLabelValuePair = ingester_client.TopCardinalityStats(seriesByLabelValuePair, limit)
+	return merged, nil
+}
+
+func toCardinalityStats(stats []ingester_client.CardinalityStat) []CardinalityStat {
+	result := make([]CardinalityStat, 0, len(stats))
+	for _, s := range stats {
+		result = append(result, CardinalityStat{Name: s.Name, Value: s.Value})
+	}
+	return result
+}
@@ -0,0 +1,117 @@
+package distributor
+
+import (
+	"math"
+	"strconv"
+
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/histogram"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/value"
+
+	"github.com/cortexproject/cortex/pkg/cortexpb"
+)
+
+// classicHistogramSeries materializes the classic histogram representation of the native histograms
+// in the given series: a <name>_bucket series for each of the given upper bounds (plus +Inf), a <name>_count
+// and a <name>_sum series. The upper bounds must be in increasing order.
+//
+// The native histogram buckets don't necessarily align with the classic ones, so each native bucket
+// is counted in the first classic bucket whose upper bound is greater than or equal to the native bucket
+// upper bound. Series without a metric name are not materialized.
+func classicHistogramSeries(ts cortexpb.PreallocTimeseries, upperBounds []float64) []cortexpb.PreallocTimeseries {
+	metricName := ""
+	for _, l := range ts.Labels {
+		if l.Name == model.MetricNameLabel {
+			metricName = l.Value
+			break
+		}
+	}
+	if metricName == "" {
+		return nil
+	}
+
+	les := make([]string, 0, len(upperBounds)+1)
+	for _, b := range upperBounds {
+		les = append(les, strconv.FormatFloat(b, 'g', -1, 64))
+	}
+	les = append(les, "+Inf")
+
+	buckets := make([]cortexpb.PreallocTimeseries, 0, len(les))
+	for _, le := range les {
+		buckets = append(buckets, newClassicHistogramSeries(ts.Labels, metricName+"_bucket", le, len(ts.Histograms)))
+	}
+	count := newClassicHistogramSeries(ts.Labels, metricName+"_count", "", len(ts.Histograms))
+	sum := newClassicHistogramSeries(ts.Labels, metricName+"_sum", "", len(ts.Histograms))
+
+	cumulative := make([]float64, len(les))
+	for _, h := range ts.Histograms {
+		var fh *histogram.FloatHistogram
+		if h.IsFloatHistogram() {
+			fh = cortexpb.FloatHistogramProtoToFloatHistogram(h.Histogram)
+		} else {
+			fh = cortexpb.HistogramProtoToHistogram(h.Histogram).ToFloat(nil)
+		}
+
+		// Stale markers are propagated to all the classic series.
+		if value.IsStaleNaN(fh.Sum) {
+			for i := range buckets {
+				buckets[i].Samples = append(buckets[i].Samples, cortexpb.Sample{TimestampMs: h.TimestampMs, Value: math.Float64frombits(value.StaleNaN)})
+			}
+			count.Samples = append(count.Samples, cortexpb.Sample{TimestampMs: h.TimestampMs, Value: math.Float64frombits(value.StaleNaN)})
+			sum.Samples = append(sum.Samples, cortexpb.Sample{TimestampMs: h.TimestampMs, Value: math.Float64frombits(value.StaleNaN)})
+			continue
+		}
+
+		clear(cumulative)
+		it := fh.AllBucketIterator()
+		for it.Next() {
+			b := it.At()
+			for i, bound := range upperBounds {
+				if b.Upper <= bound {
+					cumulative[i] += b.Count
+					break
+				}
+			}
+		}
+		for i := 1; i < len(upperBounds); i++ {
+			cumulative[i] += cumulative[i-1]
+		}
+		cumulative[len(les)-1] = fh.Count
+
+		for i := range buckets {
+			buckets[i].Samples = append(buckets[i].Samples, cortexpb.Sample{TimestampMs: h.TimestampMs, Value: cumulative[i]})
+		}
+		count.Samples = append(count.Samples, cortexpb.Sample{TimestampMs: h.TimestampMs, Value: fh.Count})
+		sum.Samples = append(sum.Samples, cortexpb.Sample{TimestampMs: h.TimestampMs, Value: fh.Sum})
+	}
+
+	return append(buckets, count, sum)
+}
+
+// newClassicHistogramSeries returns an empty series with the given labels, the metric name replaced
+// and the le label set, if not empty.
+func newClassicHistogramSeries(lbls []cortexpb.LabelAdapter, metricName, le string, numSamples int) cortexpb.PreallocTimeseries {
+	newLabels := make([]cortexpb.LabelAdapter, 0, len(lbls)+1)
+	for _, l := range lbls {
+		switch l.Name {
+		case model.MetricNameLabel:
+			newLabels = append(newLabels, cortexpb.LabelAdapter{Name: model.MetricNameLabel, Value: metricName})
+		case labels.BucketLabel:
+			// Overridden below, if required.
+		default:
+			newLabels = append(newLabels, l)
+		}
+	}
+	if le != "" {
+		newLabels = append(newLabels, cortexpb.LabelAdapter{Name: labels.BucketLabel, Value: le})
+	}
+	sortLabelsIfNeeded(newLabels)
+
+	return cortexpb.PreallocTimeseries{
+		TimeSeries: &cortexpb.TimeSeries{
+			Labels:  newLabels,
+			Samples: make([]cortexpb.Sample, 0, numSamples),
+		},
+	}
+}
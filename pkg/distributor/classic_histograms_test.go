@@ -0,0 +1,76 @@
+package distributor
+
+import (
+	"math"
+	"testing"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/value"
+	"github.com/prometheus/prometheus/tsdb/tsdbutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cortexproject/cortex/pkg/cortexpb"
+)
+
+func TestClassicHistogramSeries(t *testing.T) {
+	upperBounds := []float64{-1, 0, 1, 5}
+	lbls := labels.FromStrings("__name__", "request_duration_seconds", "job", "test")
+
+	expected := map[string]float64{
+		`{__name__="request_duration_seconds_bucket", job="test", le="-1"}`:   7,
+		`{__name__="request_duration_seconds_bucket", job="test", le="0"}`:    9,
+		`{__name__="request_duration_seconds_bucket", job="test", le="1"}`:    14,
+		`{__name__="request_duration_seconds_bucket", job="test", le="5"}`:    21,
+		`{__name__="request_duration_seconds_bucket", job="test", le="+Inf"}`: 21,
+		`{__name__="request_duration_seconds_count", job="test"}`:             21,
+		`{__name__="request_duration_seconds_sum", job="test"}`:               36.8,
+	}
+
+	for name, h := range map[string]cortexpb.Histogram{
+		"integer histogram": cortexpb.HistogramToHistogramProto(1000, tsdbutil.GenerateTestHistogram(1)),
+		"float histogram":   cortexpb.FloatHistogramToHistogramProto(1000, tsdbutil.GenerateTestFloatHistogram(1)),
+	} {
+		t.Run(name, func(t *testing.T) {
+			ts := cortexpb.PreallocTimeseries{TimeSeries: &cortexpb.TimeSeries{
+				Labels:     cortexpb.FromLabelsToLabelAdapters(lbls),
+				Histograms: []cortexpb.WrappedHistogram{cortexpb.WrapHistogram(h)},
+			}}
+
+			series := classicHistogramSeries(ts, upperBounds)
+			require.Len(t, series, len(upperBounds)+3)
+
+			actual := map[string]float64{}
+			for _, s := range series {
+				require.Len(t, s.Samples, 1)
+				assert.Equal(t, int64(1000), s.Samples[0].TimestampMs)
+				actual[cortexpb.FromLabelAdaptersToLabels(s.Labels).String()] = s.Samples[0].Value
+			}
+			assert.InDeltaMapValues(t, expected, actual, 1e-9)
+		})
+	}
+
+	t.Run("stale markers are propagated to all the series", func(t *testing.T) {
+		h := tsdbutil.GenerateTestHistogram(1)
+		h.Sum = math.Float64frombits(value.StaleNaN)
+		ts := cortexpb.PreallocTimeseries{TimeSeries: &cortexpb.TimeSeries{
+			Labels:     cortexpb.FromLabelsToLabelAdapters(lbls),
+			Histograms: []cortexpb.WrappedHistogram{cortexpb.WrapHistogram(cortexpb.HistogramToHistogramProto(1000, h))},
+		}}
+
+		series := classicHistogramSeries(ts, upperBounds)
+		require.Len(t, series, len(upperBounds)+3)
+		for _, s := range series {
+			require.Len(t, s.Samples, 1)
+			assert.True(t, value.IsStaleNaN(s.Samples[0].Value))
+		}
+	})
+
+	t.Run("series without metric name are not materialized", func(t *testing.T) {
+		ts := cortexpb.PreallocTimeseries{TimeSeries: &cortexpb.TimeSeries{
+			Labels:     cortexpb.FromLabelsToLabelAdapters(labels.FromStrings("job", "test")),
+			Histograms: []cortexpb.WrappedHistogram{cortexpb.WrapHistogram(cortexpb.HistogramToHistogramProto(1000, tsdbutil.GenerateTestHistogram(1)))},
+		}}
+		assert.Empty(t, classicHistogramSeries(ts, upperBounds))
+	})
+}
@@ -5,6 +5,7 @@ import (
 	"flag"
 	"fmt"
 	"io"
+	"math"
 	"net/http"
 	"slices"
 	"sort"
@@ -54,8 +55,9 @@ var (
 	supportedShardingStrategies = []string{util.ShardingStrategyDefault, util.ShardingStrategyShuffle}
 
 	// Validation errors.
-	errInvalidShardingStrategy = errors.New("invalid sharding strategy")
-	errInvalidTenantShardSize  = errors.New("invalid tenant shard size. The value must be greater than or equal to 0")
+	errInvalidShardingStrategy               = errors.New("invalid sharding strategy")
+	errInvalidTenantShardSize                = errors.New("invalid tenant shard size. The value must be greater than or equal to 0")
+	errInvalidOTLPDeltaToCumulativeMaxSeries = errors.New("invalid OTLP delta to cumulative max series. The value must be greater than 0")
 )
 
 const (
@@ -99,6 +101,10 @@ type Distributor struct {
 	// For handling HA replicas.
 	HATracker *ha.HATracker
 
+	// Counts the push requests rejected by the push handler because their body exceeded
+	// the per-user max request body size.
+	PushRequestsBodyTooLarge *prometheus.CounterVec
+
 	// Per-user rate limiter.
 	ingestionRateLimiter                *limiter.RateLimiter
 	nativeHistogramIngestionRateLimiter *limiter.RateLimiter
@@ -109,6 +115,12 @@ type Distributor struct {
 
 	activeUsers *users.ActiveUsersCleanupService
 
+	// Mirrors the write requests to a secondary remote write endpoint, if enabled.
+	writeMirror *writeMirror
+
+	// Exports the accepted series to Kafka, if enabled.
+	kafkaExporter *kafkaExporter
+
 	ingestionRate          *util_math.EwmaRate
 	inflightPushRequests   atomic.Int64
 	inflightClientRequests atomic.Int64
@@ -123,6 +135,8 @@ type Distributor struct {
 	incomingExemplars                *prometheus.CounterVec
 	incomingMetadata                 *prometheus.CounterVec
 	nonHASamples                     *prometheus.CounterVec
+	classicHistogramSamples          *prometheus.CounterVec
+	clampedSamples                   *prometheus.CounterVec
 	dedupedSamples                   *prometheus.CounterVec
 	receivedHistogramBuckets         *prometheus.HistogramVec
 	labelsHistogram                  prometheus.Histogram
@@ -134,9 +148,14 @@ type Distributor struct {
 	replicationFactor                prometheus.Gauge
 	latestSeenSampleTimestampPerUser *prometheus.GaugeVec
 	distributorIngesterPushTimeout   prometheus.Counter
+	ingestionLag                     *ingestionLagMetrics
 
 	validateMetrics *validation.ValidateMetrics
 
+	// Metrics updated by the validation of the write requests through ValidateWriteRequest.
+	// They're not registered, so that validating a write request doesn't affect the exported metrics.
+	dryRunValidationMetrics seriesValidationMetrics
+
 	asyncExecutor util.AsyncExecutor
 	queryWorkers  util.AsyncExecutor
 
@@ -196,6 +215,10 @@ type Config struct {
 	// OTLPConfig
 	OTLPConfig OTLPConfig `yaml:"otlp"`
 
+	Mirror MirrorConfig `yaml:"mirror"`
+
+	KafkaExport KafkaExportConfig `yaml:"kafka_export"`
+
 	// Inject from global config
 	NameValidationScheme model.ValidationScheme `yaml:"-"`
 }
@@ -212,6 +235,9 @@ type OTLPConfig struct {
 	AllowDeltaTemporality   bool `yaml:"allow_delta_temporality"`
 	EnableTypeAndUnitLabels bool `yaml:"enable_type_and_unit_labels"`
 	AddMetricSuffixes       bool `yaml:"add_metric_suffixes"`
+
+	DeltaToCumulativeMaxSeries int           `yaml:"delta_to_cumulative_max_series"`
+	DeltaToCumulativeStateTTL  time.Duration `yaml:"delta_to_cumulative_state_ttl"`
 }
 
 // RegisterFlags adds the flags required to config this to the given FlagSet
@@ -219,6 +245,8 @@ func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
 	cfg.PoolConfig.RegisterFlags(f)
 	cfg.HATrackerConfig.RegisterFlagsWithPrefix("distributor.", "", f)
 	cfg.DistributorRing.RegisterFlags(f)
+	cfg.Mirror.RegisterFlags(f)
+	cfg.KafkaExport.RegisterFlags(f)
 
 	f.IntVar(&cfg.MaxRecvMsgSize, "distributor.max-recv-msg-size", 100<<20, "remote_write API max receive message size (bytes).")
 	f.IntVar(&cfg.OTLPMaxRecvMsgSize, "distributor.otlp-max-recv-msg-size", 100<<20, "Maximum OTLP request size in bytes that the Distributor can accept.")
@@ -245,6 +273,8 @@ func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
 	f.BoolVar(&cfg.OTLPConfig.AllowDeltaTemporality, "distributor.otlp.allow-delta-temporality", false, "EXPERIMENTAL: If true, delta temporality otlp metrics to be ingested.")
 	f.BoolVar(&cfg.OTLPConfig.EnableTypeAndUnitLabels, "distributor.otlp.enable-type-and-unit-labels", false, "Deprecated: Use `-distributor.enable-type-and-unit-labels` flag instead.")
 	f.BoolVar(&cfg.OTLPConfig.AddMetricSuffixes, "distributor.otlp.add-metric-suffixes", true, "If true, suffixes will be added to the metrics for name normalization.")
+	f.IntVar(&cfg.OTLPConfig.DeltaToCumulativeMaxSeries, "distributor.otlp.delta-to-cumulative-max-series", 100000, "EXPERIMENTAL: Maximum number of series per tenant whose running total is kept in memory by the distributor to convert delta temporality OTLP metrics to cumulative, for the tenants with -distributor.otlp.convert-delta-to-cumulative enabled. The data points of the new series of a tenant are dropped once the tenant reaches the limit.")
+	f.DurationVar(&cfg.OTLPConfig.DeltaToCumulativeStateTTL, "distributor.otlp.delta-to-cumulative-state-ttl", 10*time.Minute, "EXPERIMENTAL: Period after which the running total of a series not receiving delta temporality OTLP data points is evicted from memory. The running total restarts from zero once the series receives data points again.")
 }
 
 // Validate config and returns error on failure
@@ -261,6 +291,18 @@ func (cfg *Config) Validate(limits validation.Limits) error {
 		return err
 	}
 
+	if err := cfg.Mirror.Validate(); err != nil {
+		return err
+	}
+
+	if err := cfg.KafkaExport.Validate(); err != nil {
+		return err
+	}
+
+	if cfg.OTLPConfig.DeltaToCumulativeMaxSeries <= 0 {
+		return errInvalidOTLPDeltaToCumulativeMaxSeries
+	}
+
 	return nil
 }
 
@@ -393,6 +435,21 @@ func New(cfg Config, clientConfig ingester_client.Config, limits *validation.Ove
 			Name:      "distributor_non_ha_samples_received_total",
 			Help:      "The total number of received samples for a user that has HA tracking turned on, but the sample didn't contain both HA labels.",
 		}, []string{"user"}),
+		classicHistogramSamples: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Namespace: "cortex",
+			Name:      "distributor_classic_histogram_samples_materialized_total",
+			Help:      "The total number of classic histogram samples materialized from the received native histograms.",
+		}, []string{"user"}),
+		PushRequestsBodyTooLarge: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Namespace: "cortex",
+			Name:      "distributor_push_requests_body_too_large_total",
+			Help:      "Total number of push requests rejected because their body exceeded the per-user max request body size.",
+		}, []string{"user"}),
+		clampedSamples: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Namespace: "cortex",
+			Name:      "distributor_clamped_samples_total",
+			Help:      "The total number of received samples whose timestamp in the future has been clamped to the current time.",
+		}, []string{"user"}),
 		dedupedSamples: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
 			Namespace: "cortex",
 			Name:      "distributor_deduped_samples_total",
@@ -446,9 +503,16 @@ func New(cfg Config, clientConfig ingester_client.Config, limits *validation.Ove
 			Help: "The total number of push requests to ingesters that were canceled due to timeout.",
 		}),
 
+		ingestionLag: newIngestionLagMetrics(reg),
+
 		validateMetrics: validation.NewValidateMetrics(reg),
 		asyncExecutor:   util.NewNoOpExecutor(),
 		queryWorkers:    util.NewNoOpExecutor(),
+		dryRunValidationMetrics: seriesValidationMetrics{
+			validate:                 validation.NewValidateMetrics(prometheus.NewRegistry()),
+			labelsHistogram:          prometheus.NewHistogram(prometheus.HistogramOpts{Name: "labels_per_sample"}),
+			receivedHistogramBuckets: prometheus.NewHistogramVec(prometheus.HistogramOpts{Name: "distributor_received_histogram_buckets"}, []string{"user"}),
+		},
 	}
 
 	d.labelSetTracker = labelset.NewLabelSetTracker()
@@ -503,6 +567,17 @@ func New(cfg Config, clientConfig ingester_client.Config, limits *validation.Ove
 	d.activeUsers = users.NewActiveUsersCleanupWithDefaultValues(d.cleanupInactiveUser)
 
 	subservices = append(subservices, d.ingesterPool, d.activeUsers)
+	if cfg.Mirror.IsEnabled() {
+		d.writeMirror = newWriteMirror(cfg.Mirror, limits, reg, log)
+		subservices = append(subservices, d.writeMirror)
+	}
+	if cfg.KafkaExport.IsEnabled() {
+		d.kafkaExporter, err = newKafkaExporter(cfg.KafkaExport, limits, reg, log)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to create the Kafka export client")
+		}
+		subservices = append(subservices, d.kafkaExporter)
+	}
 	d.subservices, err = services.NewManager(subservices...)
 	if err != nil {
 		return nil, err
@@ -566,7 +641,11 @@ func (d *Distributor) cleanupInactiveUser(userID string) {
 	d.incomingExemplars.DeleteLabelValues(userID)
 	d.incomingMetadata.DeleteLabelValues(userID)
 	d.nonHASamples.DeleteLabelValues(userID)
+	d.classicHistogramSamples.DeleteLabelValues(userID)
+	d.clampedSamples.DeleteLabelValues(userID)
+	d.PushRequestsBodyTooLarge.DeleteLabelValues(userID)
 	d.latestSeenSampleTimestampPerUser.DeleteLabelValues(userID)
+	d.ingestionLag.deleteUser(userID)
 
 	if err := util.DeleteMatchingLabels(d.dedupedSamples, map[string]string{"user": userID}); err != nil {
 		level.Warn(d.log).Log("msg", "failed to remove cortex_distributor_deduped_samples_total metric for user", "user", userID, "err", err)
@@ -577,6 +656,13 @@ func (d *Distributor) cleanupInactiveUser(userID string) {
 	}
 
 	validation.DeletePerUserValidationMetrics(d.validateMetrics, userID, d.log)
+
+	if d.writeMirror != nil {
+		d.writeMirror.cleanupUser(userID)
+	}
+	if d.kafkaExporter != nil {
+		d.kafkaExporter.cleanupUser(userID)
+	}
 }
 
 // Called after distributor is asked to stop via StopAsync.
@@ -680,14 +766,282 @@ func (d *Distributor) checkSample(ctx context.Context, userID, cluster, replica
 	return true, nil
 }
 
+// prepareSeriesLabels applies the per-tenant relabeling and label dropping to the input series, and
+// checks its metric name is valid and allowed. If the series has to be discarded, it returns the discard reason and, if the
+// series has been rejected because invalid, the validation error.
+func (d *Distributor) prepareSeriesLabels(ts *cortexpb.PreallocTimeseries, limits *validation.Limits, removeReplica bool) (string, validation.ValidationError) {
+	if mrc := limits.MetricRelabelConfigs; len(mrc) > 0 {
+		lbls := cortexpb.FromLabelAdaptersToLabels(ts.Labels)
+		l, _ := relabel.Process(lbls, mrc...)
+
+		// A series whose metric name has been removed by relabeling is dropped too, instead of
+		// being rejected by the metric name validation.
+		if l.Len() == 0 || (l.Get(labels.MetricName) == "" && lbls.Get(labels.MetricName) != "") {
+			// all labels are gone, samples and exemplars will be discarded
+			return validation.DroppedByRelabelConfiguration, nil
+		}
+		ts.Labels = cortexpb.FromLabelsToLabelAdapters(l)
+	}
+
+	// If we found both the cluster and replica labels, we only want to include the cluster label when
+	// storing series in Cortex. If we kept the replica label we would end up with another series for the same
+	// series we're trying to dedupe when HA tracking moves over to a different replica.
+	if removeReplica {
+		removeLabel(limits.HAReplicaLabel, &ts.Labels)
+	}
+
+	for _, labelName := range limits.DropLabels {
+		removeLabel(labelName, &ts.Labels)
+	}
+
+	// Reject series with missing or empty metric name before removeEmptyLabels (which would strip __name__="").
+	if validationErr, reason := validation.ValidateMetricName(limits, ts.Labels, d.cfg.NameValidationScheme); reason != "" {
+		return reason, validationErr
+	}
+
+	if validationErr, reason := validation.ValidateMetricNameAllowed(limits, ts.Labels); reason != "" {
+		return reason, validationErr
+	}
+
+	// Make sure no label with empty value is sent to the Ingester.
+	removeEmptyLabels(&ts.Labels)
+
+	if len(ts.Labels) == 0 {
+		return validation.DroppedByUserConfigurationOverride, nil
+	}
+
+	return "", nil
+}
+
+const (
+	seriesValidationAccepted = "accepted"
+	seriesValidationDropped  = "dropped"
+	seriesValidationRejected = "rejected"
+)
+
+// WriteRequestValidationReport is the outcome of validating a write request without ingesting it.
+type WriteRequestValidationReport struct {
+	AcceptedSeries int                      `json:"accepted_series"`
+	DroppedSeries  int                      `json:"dropped_series"`
+	RejectedSeries int                      `json:"rejected_series"`
+	Series         []SeriesValidationResult `json:"series"`
+}
+
+// SeriesValidationResult is the validation outcome of a single series of a write request.
+type SeriesValidationResult struct {
+	// Labels of the series, as received in the write request.
+	Labels     string `json:"labels"`
+	Status     string `json:"status"`
+	Reason     string `json:"reason,omitempty"`
+	Error      string `json:"error,omitempty"`
+	Samples    int    `json:"samples"`
+	Histograms int    `json:"histograms"`
+	Exemplars  int    `json:"exemplars"`
+}
+
+// ValidateWriteRequest runs the distributor's series validation against the input write request and
+// reports the outcome for each series, without forwarding anything to the ingesters. The HA tracker
+// deduplication, the ingestion rate limit and the checks done by the ingesters (eg. out-of-order samples
+// and series limits) are not evaluated. Validation doesn't track discarded samples metrics.
+func (d *Distributor) ValidateWriteRequest(ctx context.Context, req *cortexpb.WriteRequest) (*WriteRequestValidationReport, error) {
+	userID, err := users.TenantID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	limits := d.limits.GetOverridesForUser(userID)
+	skipLabelNameValidation := d.cfg.SkipLabelNameValidation || req.GetSkipLabelNameValidation()
+
+	// The per-user series of the unregistered metrics are removed once done, so that they don't pile up.
+	defer d.dryRunValidationMetrics.deleteUser(userID, d.log)
+
+	report := &WriteRequestValidationReport{Series: make([]SeriesValidationResult, 0, len(req.Timeseries))}
+	for i := range req.Timeseries {
+		ts := &req.Timeseries[i]
+		result := SeriesValidationResult{
+			Labels:     cortexpb.FromLabelAdaptersToLabels(ts.Labels).String(),
+			Status:     seriesValidationAccepted,
+			Samples:    len(ts.Samples),
+			Histograms: len(ts.Histograms),
+			Exemplars:  len(ts.Exemplars),
+		}
+
+		if len(ts.Labels) == 0 {
+			result.Status = seriesValidationRejected
+			result.Error = "empty labels found"
+			report.add(result)
+			continue
+		}
+
+		removeReplica := false
+		if limits.AcceptHASamples {
+			cluster, replica := findHALabels(limits.HAReplicaLabel, limits.HAClusterLabel, ts.Labels)
+			removeReplica = cluster != "" && replica != ""
+		}
+
+		if reason, err := d.prepareSeriesLabels(ts, limits, removeReplica); reason != "" {
+			result.Status = seriesValidationDropped
+			result.Reason = reason
+			if err != nil {
+				result.Status = seriesValidationRejected
+				result.Error = err.Error()
+			}
+			report.add(result)
+			continue
+		}
+
+		sortLabelsIfNeeded(ts.Labels)
+
+		if _, err := d.validateSeries(*ts, userID, skipLabelNameValidation, limits, d.dryRunValidationMetrics); err != nil {
+			result.Status = seriesValidationRejected
+			result.Error = err.Error()
+		}
+		report.add(result)
+	}
+
+	return report, nil
+}
+
+func (r *WriteRequestValidationReport) add(result SeriesValidationResult) {
+	switch result.Status {
+	case seriesValidationAccepted:
+		r.AcceptedSeries++
+	case seriesValidationDropped:
+		r.DroppedSeries++
+	case seriesValidationRejected:
+		r.RejectedSeries++
+	}
+	r.Series = append(r.Series, result)
+}
+
+// ingestersSubRingForWrite returns the ingesters ring, or the tenant's shard of it when shuffle sharding
+// is enabled, used to select the ingesters series are written to.
+func (d *Distributor) ingestersSubRingForWrite(userID string, limits *validation.Limits) ring.ReadRing {
+	if d.cfg.ShardingStrategy == util.ShardingStrategyShuffle {
+		return d.ingestersRing.ShuffleShard(userID, limits.IngestionTenantShardSize)
+	}
+	return d.ingestersRing
+}
+
+// writeOperation returns the ring operation used to select the ingesters series are written to.
+func (d *Distributor) writeOperation() ring.Operation {
+	if d.cfg.ExtendWrites {
+		return ring.Write
+	}
+	return ring.WriteNoExtend
+}
+
+// SeriesOwners is the set of ingesters a series is written to.
+type SeriesOwners struct {
+	// Labels of the series, after relabeling and dropping labels configured for the tenant.
+	Labels              string              `json:"labels"`
+	Token               uint32              `json:"token"`
+	MaxErrors           int                 `json:"max_errors"`
+	MaxUnavailableZones int                 `json:"max_unavailable_zones"`
+	Ingesters           []SeriesOwnerDetail `json:"ingesters"`
+}
+
+// SeriesOwnerDetail describes an ingester owning a series.
+type SeriesOwnerDetail struct {
+	ID        string `json:"id"`
+	Addr      string `json:"addr"`
+	Zone      string `json:"zone"`
+	State     string `json:"state"`
+	Heartbeat int64  `json:"heartbeat"`
+}
+
+// GetSeriesOwners returns the ingesters the input series of the tenant would be written to. The series labels
+// are prepared and hashed like in Push(), and the ingesters are looked up in the same (sub)ring.
+func (d *Distributor) GetSeriesOwners(ctx context.Context, lbls labels.Labels) (*SeriesOwners, error) {
+	userID, err := users.TenantID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	limits := d.limits.GetOverridesForUser(userID)
+	ts := &cortexpb.PreallocTimeseries{TimeSeries: &cortexpb.TimeSeries{Labels: cortexpb.FromLabelsToLabelAdapters(lbls)}}
+
+	removeReplica := false
+	if limits.AcceptHASamples {
+		cluster, replica := findHALabels(limits.HAReplicaLabel, limits.HAClusterLabel, ts.Labels)
+		removeReplica = cluster != "" && replica != ""
+	}
+
+	if reason, err := d.prepareSeriesLabels(ts, limits, removeReplica); reason != "" {
+		if err != nil {
+			return nil, httpgrpc.Errorf(http.StatusBadRequest, "%s", err.Error())
+		}
+		return nil, httpgrpc.Errorf(http.StatusBadRequest, "the series would be dropped: %s", reason)
+	}
+
+	sortLabelsIfNeeded(ts.Labels)
+
+	key, err := d.tokenForLabels(userID, ts.Labels)
+	if err != nil {
+		return nil, httpgrpc.Errorf(http.StatusBadRequest, "%s", err.Error())
+	}
+
+	subRing := d.ingestersSubRingForWrite(userID, limits)
+	replicationSet, err := subRing.Get(key, d.writeOperation(), nil, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	owners := &SeriesOwners{
+		Labels:              cortexpb.FromLabelAdaptersToLabels(ts.Labels).String(),
+		Token:               key,
+		MaxErrors:           replicationSet.MaxErrors,
+		MaxUnavailableZones: replicationSet.MaxUnavailableZones,
+		Ingesters:           make([]SeriesOwnerDetail, 0, len(replicationSet.Instances)),
+	}
+	for _, instance := range replicationSet.Instances {
+		id, err := d.ingestersRing.GetInstanceIdByAddr(instance.Addr)
+		if err != nil {
+			return nil, err
+		}
+		owners.Ingesters = append(owners.Ingesters, SeriesOwnerDetail{
+			ID:        id,
+			Addr:      instance.Addr,
+			Zone:      instance.Zone,
+			State:     instance.State.String(),
+			Heartbeat: instance.Timestamp,
+		})
+	}
+
+	return owners, nil
+}
+
+// seriesValidationMetrics are the metrics updated by the validation of the series of a write request.
+type seriesValidationMetrics struct {
+	validate                 *validation.ValidateMetrics
+	labelsHistogram          prometheus.Histogram
+	receivedHistogramBuckets *prometheus.HistogramVec
+}
+
+func (m seriesValidationMetrics) deleteUser(userID string, logger log.Logger) {
+	validation.DeletePerUserValidationMetrics(m.validate, userID, logger)
+	m.receivedHistogramBuckets.DeleteLabelValues(userID)
+}
+
+// seriesValidationMetrics returns the metrics updated by the validation of the series of the pushed write requests.
+func (d *Distributor) seriesValidationMetrics() seriesValidationMetrics {
+	return seriesValidationMetrics{
+		validate:                 d.validateMetrics,
+		labelsHistogram:          d.labelsHistogram,
+		receivedHistogramBuckets: d.receivedHistogramBuckets,
+	}
+}
+
 // Validates a single series from a write request. Will remove labels if
 // any are configured to be dropped for the user ID.
 // Returns the validated series with it's labels/samples, and any error.
 // The returned error may retain the series labels.
-func (d *Distributor) validateSeries(ts cortexpb.PreallocTimeseries, userID string, skipLabelNameValidation bool, limits *validation.Limits) (cortexpb.PreallocTimeseries, validation.ValidationError) {
-	d.labelsHistogram.Observe(float64(len(ts.Labels)))
+func (d *Distributor) validateSeries(ts cortexpb.PreallocTimeseries, userID string, skipLabelNameValidation bool, limits *validation.Limits, metrics seriesValidationMetrics) (cortexpb.PreallocTimeseries, validation.ValidationError) {
+	validateMetrics := metrics.validate
+	metrics.labelsHistogram.Observe(float64(len(ts.Labels)))
 
-	if err := validation.ValidateLabels(d.validateMetrics, limits, userID, ts.Labels, skipLabelNameValidation, d.cfg.NameValidationScheme); err != nil {
+	// All the samples and exemplars of the series are discarded because of its labels.
+	if err := validation.ValidateSeriesLabels(validateMetrics, limits, userID, ts.Labels, len(ts.Samples)+len(ts.Histograms), len(ts.Exemplars), skipLabelNameValidation, d.cfg.NameValidationScheme); err != nil {
 		return emptyPreallocSeries, err
 	}
 
@@ -696,7 +1050,7 @@ func (d *Distributor) validateSeries(ts cortexpb.PreallocTimeseries, userID stri
 		// Only alloc when data present
 		samples = make([]cortexpb.Sample, 0, len(ts.Samples))
 		for _, s := range ts.Samples {
-			if err := validation.ValidateSampleTimestamp(d.validateMetrics, limits, userID, ts.Labels, s.TimestampMs); err != nil {
+			if err := validation.ValidateSampleTimestamp(validateMetrics, limits, userID, ts.Labels, s.TimestampMs); err != nil {
 				return emptyPreallocSeries, err
 			}
 			samples = append(samples, s)
@@ -708,7 +1062,7 @@ func (d *Distributor) validateSeries(ts cortexpb.PreallocTimeseries, userID stri
 		// Only alloc when data present
 		exemplars = make([]cortexpb.Exemplar, 0, len(ts.Exemplars))
 		for _, e := range ts.Exemplars {
-			if err := validation.ValidateExemplar(d.validateMetrics, userID, ts.Labels, e); err != nil {
+			if err := validation.ValidateExemplar(validateMetrics, userID, ts.Labels, e); err != nil {
 				// An exemplar validation error prevents ingesting samples
 				// in the same series object. However, because the current Prometheus
 				// remote write implementation only populates one or the other,
@@ -723,13 +1077,13 @@ func (d *Distributor) validateSeries(ts cortexpb.PreallocTimeseries, userID stri
 	if len(ts.Histograms) > 0 {
 		// Only alloc when data present
 		histograms = make([]cortexpb.WrappedHistogram, 0, len(ts.Histograms))
-		receivedBucketsObserver := d.receivedHistogramBuckets.WithLabelValues(userID)
+		receivedBucketsObserver := metrics.receivedHistogramBuckets.WithLabelValues(userID)
 		for i, h := range ts.Histograms {
-			if err := validation.ValidateSampleTimestamp(d.validateMetrics, limits, userID, ts.Labels, h.TimestampMs); err != nil {
+			if err := validation.ValidateSampleTimestamp(validateMetrics, limits, userID, ts.Labels, h.TimestampMs); err != nil {
 				return emptyPreallocSeries, err
 			}
 			receivedBucketsObserver.Observe(float64(h.BucketCount()))
-			convertedHistogram, err := validation.ValidateNativeHistogram(d.validateMetrics, limits, userID, ts.Labels, h.Histogram)
+			convertedHistogram, err := validation.ValidateNativeHistogram(validateMetrics, limits, userID, ts.Labels, h.Histogram)
 			if err != nil {
 				return emptyPreallocSeries, err
 			}
@@ -749,6 +1103,36 @@ func (d *Distributor) validateSeries(ts cortexpb.PreallocTimeseries, userID stri
 		nil
 }
 
+// clampFutureTimestamps sets to nowMs the timestamp of the samples and histograms of the series
+// which are after nowMs but not after maxTimestampMs. Returns the number of clamped samples.
+func clampFutureTimestamps(ts *cortexpb.PreallocTimeseries, nowMs, maxTimestampMs int64) int {
+	var clampedSamples, clampedHistograms int
+	ts.Samples, clampedSamples = clampTimestamps(ts.Samples, func(s *cortexpb.Sample) *int64 { return &s.TimestampMs }, nowMs, maxTimestampMs)
+	ts.Histograms, clampedHistograms = clampTimestamps(ts.Histograms, func(h *cortexpb.WrappedHistogram) *int64 { return &h.TimestampMs }, nowMs, maxTimestampMs)
+	return clampedSamples + clampedHistograms
+}
+
+// clampTimestamps sets to nowMs the timestamp of the time-ordered samples which are after nowMs but
+// not after maxTimestampMs. The ingesters reject different samples with the same timestamp, so when
+// multiple samples end up at nowMs only the newest one is kept.
+func clampTimestamps[T any](samples []T, timestamp func(*T) *int64, nowMs, maxTimestampMs int64) ([]T, int) {
+	clamped := 0
+	out := samples[:0]
+	for i := range samples {
+		if t := timestamp(&samples[i]); *t > nowMs && *t <= maxTimestampMs {
+			*t = nowMs
+			clamped++
+
+			if n := len(out); n > 0 && *timestamp(&out[n-1]) == nowMs {
+				out[n-1] = samples[i]
+				continue
+			}
+		}
+		out = append(out, samples[i])
+	}
+	return out, clamped
+}
+
 // Push implements client.IngesterServer
 func (d *Distributor) Push(ctx context.Context, req *cortexpb.WriteRequest) (*cortexpb.WriteResponse, error) {
 	var validationError = true
@@ -861,7 +1245,14 @@ func (d *Distributor) Push(ctx context.Context, req *cortexpb.WriteRequest) (*co
 
 	totalSamples := validatedFloatSamples + validatedHistogramSamples
 	totalN := totalSamples + validatedExemplars + len(validatedMetadata)
-	if !d.ingestionRateLimiter.AllowN(now, userID, totalN) {
+
+	// The native histogram samples may be weighted by their number of buckets in the ingestion rate limit.
+	rateLimitedN := totalN
+	if bucketWeight := d.limits.IngestionRateNativeHistogramBucketWeight(userID); bucketWeight > 0 {
+		rateLimitedN += nativeHistogramIngestionRateCost(nhValidatedTimeseries, bucketWeight) - validatedHistogramSamples
+	}
+
+	if !d.ingestionRateLimiter.AllowN(now, userID, rateLimitedN) {
 		d.validateMetrics.DiscardedSamples.WithLabelValues(validation.RateLimited, userID).Add(float64(totalSamples))
 		d.validateMetrics.DiscardedExemplars.WithLabelValues(validation.RateLimited, userID).Add(float64(validatedExemplars))
 		d.validateMetrics.DiscardedMetadata.WithLabelValues(validation.RateLimited, userID).Add(float64(len(validatedMetadata)))
@@ -885,12 +1276,7 @@ func (d *Distributor) Push(ctx context.Context, req *cortexpb.WriteRequest) (*co
 		validatedTimeseries = append(validatedTimeseries, nhValidatedTimeseries...)
 	}
 
-	subRing := d.ingestersRing
-
-	// Obtain a subring if required.
-	if d.cfg.ShardingStrategy == util.ShardingStrategyShuffle {
-		subRing = d.ingestersRing.ShuffleShard(userID, limits.IngestionTenantShardSize)
-	}
+	subRing := d.ingestersSubRingForWrite(userID, limits)
 
 	keys := append(seriesKeys, metadataKeys...)
 	initialMetadataIndex := len(seriesKeys)
@@ -899,13 +1285,29 @@ func (d *Distributor) Push(ctx context.Context, req *cortexpb.WriteRequest) (*co
 		return nil, nativeHistogramErr
 	}
 
+	cleanup := func() {
+		cortexpb.ReuseSlice(req.Timeseries)
+		req.Free()
+	}
+	if d.writeMirror != nil {
+		d.writeMirror.mirror(userID, validatedTimeseries, req.Source)
+	}
+	if d.kafkaExporter != nil {
+		// The request is reused once both the ingesters requests and the export are done with it.
+		cleanup = d.kafkaExporter.exportSeries(userID, validatedTimeseries, req.Source, cleanup)
+	}
+
+	// The series may be released before doBatch returns, so the newest timestamp is computed beforehand.
+	acceptedSampleTimestampMs := latestSampleTimestampMs(validatedTimeseries)
+
 	//DoBatch will be responsible to call cleanup after all async ingester requests finish.
 	validationError = false
 
-	err = d.doBatch(ctx, req, subRing, keys, initialMetadataIndex, validatedMetadata, validatedTimeseries, userID)
+	err = d.doBatch(ctx, req, subRing, keys, initialMetadataIndex, validatedMetadata, validatedTimeseries, userID, cleanup)
 	if err != nil {
 		return nil, err
 	}
+	d.ingestionLag.observe(userID, acceptedSampleTimestampMs)
 
 	resp := &cortexpb.WriteResponse{}
 	if d.cfg.RemoteWriteV2Enabled {
@@ -983,7 +1385,7 @@ func (d *Distributor) cleanStaleIngesterMetrics() {
 	}
 }
 
-func (d *Distributor) doBatch(ctx context.Context, req *cortexpb.WriteRequest, subRing ring.ReadRing, keys []uint32, initialMetadataIndex int, validatedMetadata []*cortexpb.MetricMetadata, validatedTimeseries []cortexpb.PreallocTimeseries, userID string) error {
+func (d *Distributor) doBatch(ctx context.Context, req *cortexpb.WriteRequest, subRing ring.ReadRing, keys []uint32, initialMetadataIndex int, validatedMetadata []*cortexpb.MetricMetadata, validatedTimeseries []cortexpb.PreallocTimeseries, userID string, cleanup func()) error {
 	span, _ := opentracing.StartSpanFromContext(ctx, "doBatch")
 	defer span.Finish()
 
@@ -1007,12 +1409,7 @@ func (d *Distributor) doBatch(ctx context.Context, req *cortexpb.WriteRequest, s
 	source := util.GetSourceIPsFromOutgoingCtx(ctx)
 	localCtx = util.AddSourceIPsToOutgoingContext(localCtx, source)
 
-	op := ring.WriteNoExtend
-	if d.cfg.ExtendWrites {
-		op = ring.Write
-	}
-
-	return ring.DoBatch(ctx, op, subRing, d.asyncExecutor, keys, func(ingester ring.InstanceDesc, indexes []int) error {
+	return ring.DoBatch(ctx, d.writeOperation(), subRing, d.asyncExecutor, keys, func(ingester ring.InstanceDesc, indexes []int) error {
 		timeseries := make([]cortexpb.PreallocTimeseries, 0, len(indexes))
 		var metadata []*cortexpb.MetricMetadata
 
@@ -1026,8 +1423,7 @@ func (d *Distributor) doBatch(ctx context.Context, req *cortexpb.WriteRequest, s
 
 		return d.send(localCtx, ingester, timeseries, metadata, req.Source, req.DiscardOutOfOrder)
 	}, func() {
-		cortexpb.ReuseSlice(req.Timeseries)
-		req.Free()
+		cleanup()
 		cancel()
 	})
 }
@@ -1070,6 +1466,19 @@ func countNHCB(histograms []cortexpb.WrappedHistogram) int {
 	return n
 }
 
+// nativeHistogramIngestionRateCost returns the number of samples the native histogram samples
+// count as in the ingestion rate limit. Each sample counts as 1 plus the bucket weight multiplied
+// by its number of buckets, rounded up.
+func nativeHistogramIngestionRateCost(series []cortexpb.PreallocTimeseries, bucketWeight float64) int {
+	cost := 0
+	for _, ts := range series {
+		for _, h := range ts.Histograms {
+			cost += int(math.Ceil(1 + bucketWeight*float64(h.BucketCount())))
+		}
+	}
+	return cost
+}
+
 func (d *Distributor) prepareSeriesKeys(ctx context.Context, req *cortexpb.WriteRequest, userID string, limits *validation.Limits, removeReplica bool) ([]uint32, []uint32, []cortexpb.PreallocTimeseries, []cortexpb.PreallocTimeseries, int, int, int, int, error, error) {
 	pSpan, _ := opentracing.StartSpanFromContext(ctx, "prepareSeriesKeys")
 	defer pSpan.Finish()
@@ -1084,6 +1493,8 @@ func (d *Distributor) prepareSeriesKeys(ctx context.Context, req *cortexpb.Write
 	validatedHistogramSamples := 0
 	validatedNHCBSamples := 0
 	validatedExemplars := 0
+	classicSamples := 0
+	clampedSamples := 0
 	limitsPerLabelSet := d.limits.LimitsPerLabelSet(userID)
 
 	var (
@@ -1098,6 +1509,20 @@ func (d *Distributor) prepareSeriesKeys(ctx context.Context, req *cortexpb.Write
 			d.latestSeenSampleTimestampPerUser.WithLabelValues(userID).Set(float64(latestSampleTimestampMs) / 1000)
 		}
 	}()
+	defer func() {
+		if classicSamples > 0 {
+			d.classicHistogramSamples.WithLabelValues(userID).Add(float64(classicSamples))
+		}
+	}()
+	defer func() {
+		if clampedSamples > 0 {
+			d.clampedSamples.WithLabelValues(userID).Add(float64(clampedSamples))
+		}
+	}()
+
+	// Samples in the future within the clamp tolerance get the current time as timestamp.
+	nowMs := time.Now().UnixMilli()
+	clampMaxTimestampMs := nowMs + time.Duration(limits.FutureSampleClampTolerance).Milliseconds()
 
 	// For each timeseries, compute a hash to distribute across ingesters;
 	// check each sample and discard if outside limits.
@@ -1131,6 +1556,12 @@ func (d *Distributor) prepareSeriesKeys(ctx context.Context, req *cortexpb.Write
 			}
 		}
 
+		// Clamp the timestamps before the validation, so that the following checks
+		// (including the out-of-order ones in the ingesters) see the adjusted timestamps.
+		if limits.FutureSampleClampTolerance > 0 {
+			clampedSamples += clampFutureTimestamps(ts, nowMs, clampMaxTimestampMs)
+		}
+
 		// Use timestamp of latest sample in the series. If samples for series are not ordered, metric for user may be wrong.
 		if len(ts.Samples) > 0 {
 			latestSampleTimestampMs = max(latestSampleTimestampMs, ts.Samples[len(ts.Samples)-1].TimestampMs)
@@ -1139,61 +1570,12 @@ func (d *Distributor) prepareSeriesKeys(ctx context.Context, req *cortexpb.Write
 			latestSampleTimestampMs = max(latestSampleTimestampMs, ts.Histograms[len(ts.Histograms)-1].TimestampMs)
 		}
 
-		if mrc := limits.MetricRelabelConfigs; len(mrc) > 0 {
-			l, _ := relabel.Process(cortexpb.FromLabelAdaptersToLabels(ts.Labels), mrc...)
-			if l.Len() == 0 {
-				// all labels are gone, samples will be discarded
-				d.validateMetrics.DiscardedSamples.WithLabelValues(
-					validation.DroppedByRelabelConfiguration,
-					userID,
-				).Add(float64(len(ts.Samples) + len(ts.Histograms)))
-
-				// all labels are gone, exemplars will be discarded
-				d.validateMetrics.DiscardedExemplars.WithLabelValues(
-					validation.DroppedByRelabelConfiguration,
-					userID,
-				).Add(float64(len(ts.Exemplars)))
-				continue
-			}
-			ts.Labels = cortexpb.FromLabelsToLabelAdapters(l)
-		}
-
-		// If we found both the cluster and replica labels, we only want to include the cluster label when
-		// storing series in Cortex. If we kept the replica label we would end up with another series for the same
-		// series we're trying to dedupe when HA tracking moves over to a different replica.
-		if removeReplica {
-			removeLabel(limits.HAReplicaLabel, &ts.Labels)
-		}
-
-		for _, labelName := range limits.DropLabels {
-			removeLabel(labelName, &ts.Labels)
-		}
-
-		// Reject series with missing or empty metric name before removeEmptyLabels (which would strip __name__="").
-		if validationErr, reason := validation.ValidateMetricName(limits, ts.Labels, d.cfg.NameValidationScheme); reason != "" {
-			samplesCount := float64(len(ts.Samples) + len(ts.Histograms))
-			exemplarsCount := float64(len(ts.Exemplars))
-			if firstPartialErr == nil {
-				firstPartialErr = httpgrpc.Errorf(http.StatusBadRequest, "%s", validationErr.Error())
+		if reason, err := d.prepareSeriesLabels(ts, limits, removeReplica); reason != "" {
+			if err != nil && firstPartialErr == nil {
+				firstPartialErr = httpgrpc.Errorf(http.StatusBadRequest, "%s", err.Error())
 			}
-			d.validateMetrics.DiscardedSamples.WithLabelValues(reason, userID).Add(samplesCount)
-			d.validateMetrics.DiscardedExemplars.WithLabelValues(reason, userID).Add(exemplarsCount)
-			continue
-		}
-
-		// Make sure no label with empty value is sent to the Ingester.
-		removeEmptyLabels(&ts.Labels)
-
-		if len(ts.Labels) == 0 {
-			d.validateMetrics.DiscardedSamples.WithLabelValues(
-				validation.DroppedByUserConfigurationOverride,
-				userID,
-			).Add(float64(len(ts.Samples) + len(ts.Histograms)))
-
-			d.validateMetrics.DiscardedExemplars.WithLabelValues(
-				validation.DroppedByUserConfigurationOverride,
-				userID,
-			).Add(float64(len(ts.Exemplars)))
+			d.validateMetrics.DiscardedSamples.WithLabelValues(reason, userID).Add(float64(len(ts.Samples) + len(ts.Histograms)))
+			d.validateMetrics.DiscardedExemplars.WithLabelValues(reason, userID).Add(float64(len(ts.Exemplars)))
 			continue
 		}
 
@@ -1210,7 +1592,7 @@ func (d *Distributor) prepareSeriesKeys(ctx context.Context, req *cortexpb.Write
 		if err != nil {
 			return nil, nil, nil, nil, 0, 0, 0, 0, nil, err
 		}
-		validatedSeries, validationErr := d.validateSeries(*ts, userID, skipLabelNameValidation, limits)
+		validatedSeries, validationErr := d.validateSeries(*ts, userID, skipLabelNameValidation, limits, d.seriesValidationMetrics())
 
 		// Errors in validation are considered non-fatal, as one series in a request may contain
 		// invalid data but all the remaining series could be perfectly valid.
@@ -1247,6 +1629,20 @@ func (d *Distributor) prepareSeriesKeys(ctx context.Context, req *cortexpb.Write
 		if len(ts.Histograms) > 0 {
 			nhSeriesKeys = append(nhSeriesKeys, key)
 			nhValidatedTimeseries = append(nhValidatedTimeseries, validatedSeries)
+
+			if len(limits.NativeHistogramClassicBuckets) > 0 {
+				classicSeries := classicHistogramSeries(validatedSeries, limits.NativeHistogramClassicBuckets)
+				for _, cs := range classicSeries {
+					classicKey, err := d.tokenForLabels(userID, cs.Labels)
+					if err != nil {
+						return nil, nil, nil, nil, 0, 0, 0, 0, nil, err
+					}
+					seriesKeys = append(seriesKeys, classicKey)
+					validatedTimeseries = append(validatedTimeseries, cs)
+					validatedFloatSamples += len(cs.Samples)
+					classicSamples += len(cs.Samples)
+				}
+			}
 		} else {
 			seriesKeys = append(seriesKeys, key)
 			validatedTimeseries = append(validatedTimeseries, validatedSeries)
@@ -1,13 +1,17 @@
 package distributor
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"maps"
 	"math"
 	"math/rand"
 	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"sort"
 	"strconv"
 	"strings"
@@ -18,6 +22,7 @@ import (
 	"google.golang.org/grpc/codes"
 
 	"github.com/go-kit/log"
+	"github.com/golang/snappy"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/testutil"
 	dto "github.com/prometheus/client_model/go"
@@ -114,6 +119,37 @@ func TestConfig_Validate(t *testing.T) {
 			},
 			expected: errInvalidTenantShardSize,
 		},
+		"should fail because the OTLP delta to cumulative max series is a non-positive number": {
+			initConfig: func(cfg *Config) {
+				cfg.OTLPConfig.DeltaToCumulativeMaxSeries = 0
+			},
+			initLimits: func(_ *validation.Limits) {},
+			expected:   errInvalidOTLPDeltaToCumulativeMaxSeries,
+		},
+		"should fail because the mirror queue capacity is a non-positive number": {
+			initConfig: func(cfg *Config) {
+				require.NoError(t, cfg.Mirror.URL.Set("http://localhost/api/v1/push"))
+				cfg.Mirror.QueueCapacity = 0
+			},
+			initLimits: func(_ *validation.Limits) {},
+			expected:   errInvalidMirrorQueueCapacity,
+		},
+		"should fail because the Kafka export topic is not set": {
+			initConfig: func(cfg *Config) {
+				cfg.KafkaExport.Addresses = []string{"localhost:9092"}
+			},
+			initLimits: func(_ *validation.Limits) {},
+			expected:   errInvalidKafkaExportTopic,
+		},
+		"should fail because the Kafka export max record size is too small": {
+			initConfig: func(cfg *Config) {
+				cfg.KafkaExport.Addresses = []string{"localhost:9092"}
+				cfg.KafkaExport.Topic = "series"
+				cfg.KafkaExport.MaxRecordSizeBytes = 1024
+			},
+			initLimits: func(_ *validation.Limits) {},
+			expected:   errInvalidKafkaExportMaxRecordSize,
+		},
 	}
 
 	for testName, testData := range tests {
@@ -431,6 +467,47 @@ func TestDistributor_Push(t *testing.T) {
 	}
 }
 
+func TestDistributor_Push_ShouldTrackTheIngestionLagOfTheAcceptedSamples(t *testing.T) {
+	t.Parallel()
+	limits := &validation.Limits{}
+	flagext.DefaultValues(limits)
+	limits.IngestionRate = 20
+	limits.IngestionBurstSize = 20
+
+	ds, _, regs, _ := prepare(t, prepConfig{
+		numIngesters:    3,
+		happyIngesters:  3,
+		numDistributors: 1,
+		limits:          limits,
+	})
+	d := ds[0]
+	d.ingestionLag.now = func() time.Time { return time.UnixMilli(123456799000) }
+	ctx := user.InjectOrgID(context.Background(), "user-1")
+
+	expectedLag := `
+		# HELP cortex_distributor_ingestion_lag_seconds Time elapsed since the timestamp of the newest sample accepted for the user. It is negative if samples with a timestamp in the future are accepted.
+		# TYPE cortex_distributor_ingestion_lag_seconds gauge
+		cortex_distributor_ingestion_lag_seconds{user="user-1"} 9.996
+	`
+
+	_, err := d.Push(ctx, makeWriteRequest(123456789000, 5, 0, 0))
+	require.NoError(t, err)
+	require.NoError(t, testutil.GatherAndCompare(regs[0], strings.NewReader(expectedLag), "cortex_distributor_ingestion_lag_seconds"))
+
+	// The samples rejected by the rate limit are not accounted.
+	_, err = d.Push(ctx, makeWriteRequest(123456798000, 25, 0, 0))
+	require.Equal(t, codes.Code(http.StatusTooManyRequests), status.Code(err))
+	require.NoError(t, testutil.GatherAndCompare(regs[0], strings.NewReader(expectedLag), "cortex_distributor_ingestion_lag_seconds"))
+
+	// Older samples don't increase the lag.
+	_, err = d.Push(ctx, makeWriteRequest(123456780000, 1, 0, 0))
+	require.NoError(t, err)
+	require.NoError(t, testutil.GatherAndCompare(regs[0], strings.NewReader(expectedLag), "cortex_distributor_ingestion_lag_seconds"))
+
+	d.cleanupInactiveUser("user-1")
+	require.NoError(t, testutil.GatherAndCompare(regs[0], strings.NewReader(""), "cortex_distributor_ingestion_lag_seconds"))
+}
+
 func TestDistributor_Push_DiscardOutOfOrder(t *testing.T) {
 	t.Parallel()
 
@@ -551,6 +628,7 @@ func TestDistributor_MetricsCleanup(t *testing.T) {
 		"cortex_distributor_non_ha_samples_received_total",
 		"cortex_distributor_latest_seen_sample_timestamp_seconds",
 		"cortex_distributor_received_samples_per_labelset_total",
+		"cortex_distributor_push_requests_body_too_large_total",
 	}
 
 	allMetrics := append(removedMetrics, permanentMetrics...)
@@ -572,6 +650,7 @@ func TestDistributor_MetricsCleanup(t *testing.T) {
 	d.latestSeenSampleTimestampPerUser.WithLabelValues("userA").Set(1111)
 	d.receivedSamplesPerLabelSet.WithLabelValues("userA", sampleMetricTypeFloat, "{}").Add(5)
 	d.receivedSamplesPerLabelSet.WithLabelValues("userA", sampleMetricTypeHistogram, "{}").Add(10)
+	d.PushRequestsBodyTooLarge.WithLabelValues("userA").Inc()
 
 	h, _, _ := r.GetAllInstanceDescs(ring.WriteNoExtend)
 	ingId0, _ := r.GetInstanceIdByAddr(h[0].Addr)
@@ -602,6 +681,10 @@ func TestDistributor_MetricsCleanup(t *testing.T) {
 		# TYPE cortex_distributor_non_ha_samples_received_total counter
 		cortex_distributor_non_ha_samples_received_total{user="userA"} 5
 
+		# HELP cortex_distributor_push_requests_body_too_large_total Total number of push requests rejected because their body exceeded the per-user max request body size.
+		# TYPE cortex_distributor_push_requests_body_too_large_total counter
+		cortex_distributor_push_requests_body_too_large_total{user="userA"} 1
+
 		# HELP cortex_distributor_received_metadata_total The total number of received metadata, excluding rejected.
 		# TYPE cortex_distributor_received_metadata_total counter
 		cortex_distributor_received_metadata_total{user="userA"} 5
@@ -826,6 +909,7 @@ func TestDistributor_PushIngestionRateLimiter_Histograms(t *testing.T) {
 		nativeHistogramIngestionRateStrategy string
 		nativeHistogramIngestionRate         float64
 		nativeHistogramIngestionBurstSize    int
+		nativeHistogramBucketWeight          float64
 		pushes                               []testPush
 	}{
 		"local strategy: native histograms limit should be set to each distributor": {
@@ -927,6 +1011,21 @@ func TestDistributor_PushIngestionRateLimiter_Histograms(t *testing.T) {
 				{samples: 3, nhSamples: 3, metadata: 2, expectedError: nil},
 			},
 		},
+		"local strategy: native histogram samples should be weighted by their number of buckets in the ingestion rate limit": {
+			distributors:                      2,
+			ingestionRateStrategy:             validation.LocalIngestionRateStrategy,
+			ingestionRate:                     20,
+			ingestionBurstSize:                20,
+			nativeHistogramIngestionRate:      100,
+			nativeHistogramIngestionBurstSize: 100,
+			nativeHistogramBucketWeight:       0.5,
+			pushes: []testPush{
+				// Each native histogram sample has 9 buckets (including the zero bucket), so it counts as 6 samples.
+				{nhSamples: 2, expectedError: nil},
+				{samples: 2, nhSamples: 1, expectedError: nil},
+				{nhSamples: 1, expectedError: httpgrpc.Errorf(http.StatusTooManyRequests, "ingestion rate limit (20) exceeded while adding 1 samples and 0 metadata")},
+			},
+		},
 	}
 
 	for testName, testData := range tests {
@@ -940,6 +1039,7 @@ func TestDistributor_PushIngestionRateLimiter_Histograms(t *testing.T) {
 			limits.IngestionBurstSize = testData.ingestionBurstSize
 			limits.NativeHistogramIngestionRate = testData.nativeHistogramIngestionRate
 			limits.NativeHistogramIngestionBurstSize = testData.nativeHistogramIngestionBurstSize
+			limits.IngestionRateNativeHistogramBucketWeight = testData.nativeHistogramBucketWeight
 
 			// Start all expected distributors
 			distributors, _, _, _ := prepare(t, prepConfig{
@@ -2189,6 +2289,131 @@ func TestDistributor_Push_LabelRemoval_RemovingNameLabelWillError(t *testing.T)
 	assert.Equal(t, "rpc error: code = Code(400) desc = sample missing metric name", err.Error())
 }
 
+func TestDistributor_Push_NativeHistogramClassicBuckets(t *testing.T) {
+	t.Parallel()
+	ctx := user.InjectOrgID(context.Background(), "user")
+	lbls := labels.FromStrings("__name__", "request_duration_seconds", "job", "test")
+
+	for _, classicBuckets := range [][]float64{nil, {0.5, 1, 5}} {
+		var limits validation.Limits
+		flagext.DefaultValues(&limits)
+		limits.NativeHistogramClassicBuckets = classicBuckets
+
+		ds, ingesters, _, _ := prepare(t, prepConfig{
+			numIngesters:     3,
+			happyIngesters:   3,
+			numDistributors:  1,
+			shardByAllLabels: true,
+			limits:           &limits,
+		})
+
+		_, err := ds[0].Push(ctx, mockWriteRequest([]labels.Labels{lbls}, 1, 1000, true))
+		require.NoError(t, err)
+
+		received := map[string]struct{}{}
+		for i := range ingesters {
+			for _, ts := range ingesters[i].series() {
+				received[cortexpb.FromLabelAdaptersToLabels(ts.Labels).String()] = struct{}{}
+			}
+		}
+
+		expected := map[string]struct{}{lbls.String(): {}}
+		if len(classicBuckets) > 0 {
+			for _, le := range []string{"0.5", "1", "5", "+Inf"} {
+				expected[labels.FromStrings("__name__", "request_duration_seconds_bucket", "job", "test", "le", le).String()] = struct{}{}
+			}
+			expected[labels.FromStrings("__name__", "request_duration_seconds_count", "job", "test").String()] = struct{}{}
+			expected[labels.FromStrings("__name__", "request_duration_seconds_sum", "job", "test").String()] = struct{}{}
+		}
+		assert.Equal(t, expected, received)
+
+		materialized := len(expected) - 1
+		assert.Equal(t, float64(materialized), testutil.ToFloat64(ds[0].classicHistogramSamples.WithLabelValues("user")))
+		assert.Equal(t, float64(materialized), testutil.ToFloat64(ds[0].receivedSamples.WithLabelValues("user", sampleMetricTypeFloat)))
+		assert.Equal(t, float64(1), testutil.ToFloat64(ds[0].receivedSamples.WithLabelValues("user", sampleMetricTypeHistogram)))
+	}
+}
+
+func TestDistributor_Push_FutureSampleClampTolerance(t *testing.T) {
+	t.Parallel()
+	ctx := user.InjectOrgID(context.Background(), "user")
+
+	var limits validation.Limits
+	flagext.DefaultValues(&limits)
+	limits.CreationGracePeriod = model.Duration(10 * time.Minute)
+	limits.FutureSampleClampTolerance = model.Duration(time.Hour)
+
+	ds, ingesters, _, _ := prepare(t, prepConfig{
+		numIngesters:     3,
+		happyIngesters:   3,
+		numDistributors:  1,
+		shardByAllLabels: true,
+		limits:           &limits,
+	})
+
+	now := time.Now()
+	pastTs := now.Add(-time.Minute).UnixMilli()
+	req := cortexpb.ToWriteRequest(
+		[]labels.Labels{
+			labels.FromStrings("__name__", "past"),
+			labels.FromStrings("__name__", "within_tolerance"),
+			labels.FromStrings("__name__", "beyond_tolerance"),
+		},
+		[]cortexpb.Sample{
+			{TimestampMs: pastTs, Value: 1},
+			{TimestampMs: now.Add(30 * time.Minute).UnixMilli(), Value: 2},
+			{TimestampMs: now.Add(2 * time.Hour).UnixMilli(), Value: 3},
+		},
+		nil, nil, cortexpb.API)
+
+	_, err := ds[0].Push(ctx, req)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "timestamp too new")
+
+	received := map[string]int64{}
+	for i := range ingesters {
+		for _, ts := range ingesters[i].series() {
+			for _, s := range ts.Samples {
+				received[cortexpb.FromLabelAdaptersToLabels(ts.Labels).Get(model.MetricNameLabel)] = s.TimestampMs
+			}
+		}
+	}
+
+	require.Len(t, received, 2)
+	assert.Equal(t, pastTs, received["past"])
+	assert.GreaterOrEqual(t, received["within_tolerance"], now.UnixMilli())
+	assert.LessOrEqual(t, received["within_tolerance"], time.Now().UnixMilli())
+	assert.Equal(t, float64(1), testutil.ToFloat64(ds[0].clampedSamples.WithLabelValues("user")))
+}
+
+func TestClampFutureTimestamps_ShouldKeepOnlyTheNewestClampedSample(t *testing.T) {
+	t.Parallel()
+
+	const nowMs, maxTimestampMs = int64(1000), int64(2000)
+	ts := cortexpb.PreallocTimeseries{TimeSeries: &cortexpb.TimeSeries{
+		Samples: []cortexpb.Sample{
+			{TimestampMs: 900, Value: 1},
+			{TimestampMs: 1000, Value: 2},
+			{TimestampMs: 1100, Value: 3},
+			{TimestampMs: 1200, Value: 4},
+			{TimestampMs: 2500, Value: 5},
+		},
+		Histograms: []cortexpb.WrappedHistogram{
+			{Histogram: cortexpb.HistogramToHistogramProto(1100, tsdbutil.GenerateTestHistogram(1))},
+			{Histogram: cortexpb.HistogramToHistogramProto(1200, tsdbutil.GenerateTestHistogram(2))},
+		},
+	}}
+
+	assert.Equal(t, 4, clampFutureTimestamps(&ts, nowMs, maxTimestampMs))
+	assert.Equal(t, []cortexpb.Sample{
+		{TimestampMs: 900, Value: 1},
+		{TimestampMs: 1000, Value: 4},
+		{TimestampMs: 2500, Value: 5},
+	}, ts.Samples)
+	require.Len(t, ts.Histograms, 1)
+	assert.Equal(t, cortexpb.HistogramToHistogramProto(nowMs, tsdbutil.GenerateTestHistogram(2)), ts.Histograms[0].Histogram)
+}
+
 func TestDistributor_Push_ShouldGuaranteeShardingTokenConsistencyOverTheTime(t *testing.T) {
 	t.Parallel()
 	ctx := user.InjectOrgID(context.Background(), "user")
@@ -4074,6 +4299,68 @@ func (i *mockIngester) AllUserStats(ctx context.Context, in *client.UserStatsReq
 	return &i.stats, nil
 }
 
+func (i *mockIngester) Cardinality(ctx context.Context, req *client.CardinalityRequest, opts ...grpc.CallOption) (*client.CardinalityResponse, error) {
+	i.Lock()
+	defer i.Unlock()
+
+	i.trackCall("Cardinality")
+
+	if !i.happy.Load() {
+		return nil, errFail
+	}
+
+	limit, matchers, err := client.FromCardinalityRequest(storecache.NoopMatchersCache, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var numSeries, numChunks uint64
+	minT, maxT := int64(math.MaxInt64), int64(math.MinInt64)
+	seriesByMetricName := map[string]uint64{}
+	seriesByLabelValuePair := map[string]uint64{}
+	labelValues := map[string]map[string]struct{}{}
+	for _, ts := range i.timeseries {
+		for _, s := range ts.Samples {
+			minT, maxT = min(minT, s.TimestampMs), max(maxT, s.TimestampMs)
+		}
+		if !match(ts.Labels, matchers) {
+			continue
+		}
+		numSeries++
+		if len(matchers) > 0 {
+			// The mock stores the samples of a series in a single chunk.
+			numChunks++
+		}
+		for _, l := range ts.Labels {
+			if l.Name == labels.MetricName {
+				seriesByMetricName[l.Value]++
+			}
+			seriesByLabelValuePair[l.Name+"="+l.Value]++
+			if labelValues[l.Name] == nil {
+				labelValues[l.Name] = map[string]struct{}{}
+			}
+			labelValues[l.Name][l.Value] = struct{}{}
+		}
+	}
+
+	labelValueCountByLabelName := map[string]uint64{}
+	for name, values := range labelValues {
+		labelValueCountByLabelName[name] = uint64(len(values))
+	}
+
+	resp := &client.CardinalityResponse{
+		NumSeries:                   numSeries,
+		NumChunks:                   numChunks,
+		SeriesCountByMetricName:     client.TopCardinalityStats(seriesByMetricName, limit),
+		LabelValueCountByLabelName:  client.TopCardinalityStats(labelValueCountByLabelName, limit),
+		SeriesCountByLabelValuePair: client.TopCardinalityStats(seriesByLabelValuePair, limit),
+	}
+	if minT <= maxT {
+		resp.MinTimestampMs, resp.MaxTimestampMs = minT, maxT
+	}
+	return resp, nil
+}
+
 func match(labels []cortexpb.LabelAdapter, matchers []*labels.Matcher) bool {
 outer:
 	for _, matcher := range matchers {
@@ -4394,6 +4681,24 @@ func TestDistributor_Push_Relabel(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "with replace action removing the metric name",
+			inputSeries: []labels.Labels{
+				labels.FromStrings("__name__", "foo", "cluster", "one"),
+				labels.FromStrings("__name__", "bar", "cluster", "two"),
+			},
+			expectedSeries: labels.FromStrings("__name__", "bar", "cluster", "two"),
+			metricRelabelConfigs: []*relabel.Config{
+				{
+					SourceLabels:         []model.LabelName{"__name__"},
+					Action:               relabel.Replace,
+					Regex:                relabel.MustNewRegexp("(foo)"),
+					TargetLabel:          "__name__",
+					Replacement:          "",
+					NameValidationScheme: model.LegacyValidation,
+				},
+			},
+		},
 	}
 
 	for _, tc := range cases {
@@ -4554,6 +4859,258 @@ func TestDistributor_Push_EmptyLabel(t *testing.T) {
 	}
 }
 
+func TestDistributor_ValidateHandler(t *testing.T) {
+	t.Parallel()
+
+	var limits validation.Limits
+	flagext.DefaultValues(&limits)
+	limits.MaxLabelNamesPerSeries = 3
+	limits.MetricRelabelConfigs = []*relabel.Config{
+		{
+			SourceLabels:         []model.LabelName{"__name__"},
+			Action:               relabel.Drop,
+			Regex:                relabel.MustNewRegexp("(dropped)"),
+			NameValidationScheme: model.LegacyValidation,
+		},
+	}
+
+	ds, ingesters, _, _ := prepare(t, prepConfig{
+		numIngesters:     2,
+		happyIngesters:   2,
+		numDistributors:  1,
+		shardByAllLabels: true,
+		limits:           &limits,
+	})
+
+	req := mockWriteRequest([]labels.Labels{
+		labels.FromStrings("__name__", "valid", "cluster", "one"),
+		labels.FromStrings("__name__", "dropped", "cluster", "one"),
+		labels.FromStrings("__name__", "too_many_labels", "a", "1", "b", "2", "c", "3"),
+		labels.FromStrings("cluster", "one"),
+	}, 1, time.Now().UnixMilli(), false)
+
+	body, err := req.Marshal()
+	require.NoError(t, err)
+
+	httpReq := httptest.NewRequest(http.MethodPost, "/distributor/validate", bytes.NewReader(snappy.Encode(nil, body)))
+	httpReq = httpReq.WithContext(user.InjectOrgID(context.Background(), "user"))
+	rec := httptest.NewRecorder()
+	ds[0].ValidateHandler(rec, httpReq)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	report := WriteRequestValidationReport{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &report))
+
+	assert.Equal(t, 1, report.AcceptedSeries)
+	assert.Equal(t, 1, report.DroppedSeries)
+	assert.Equal(t, 2, report.RejectedSeries)
+	require.Len(t, report.Series, 4)
+
+	assert.Equal(t, `{__name__="valid", cluster="one"}`, report.Series[0].Labels)
+	assert.Equal(t, seriesValidationAccepted, report.Series[0].Status)
+	assert.Equal(t, 1, report.Series[0].Samples)
+
+	assert.Equal(t, seriesValidationDropped, report.Series[1].Status)
+	assert.Equal(t, validation.DroppedByRelabelConfiguration, report.Series[1].Reason)
+
+	assert.Equal(t, seriesValidationRejected, report.Series[2].Status)
+	assert.Contains(t, report.Series[2].Error, "series has too many labels")
+
+	assert.Equal(t, seriesValidationRejected, report.Series[3].Status)
+	assert.Equal(t, "missing_metric_name", report.Series[3].Reason)
+	assert.NotEmpty(t, report.Series[3].Error)
+
+	// Nothing should have been ingested nor tracked as discarded.
+	for _, ing := range ingesters {
+		assert.Empty(t, ing.series())
+	}
+	assert.Equal(t, 0.0, testutil.ToFloat64(ds[0].validateMetrics.DiscardedSamples.WithLabelValues(validation.DroppedByRelabelConfiguration, "user")))
+
+	labelsPerSample := &dto.Metric{}
+	require.NoError(t, ds[0].labelsHistogram.Write(labelsPerSample))
+	assert.Zero(t, labelsPerSample.GetHistogram().GetSampleCount())
+}
+
+func TestDistributor_SeriesOwnersHandler(t *testing.T) {
+	t.Parallel()
+
+	var limits validation.Limits
+	flagext.DefaultValues(&limits)
+	limits.DropLabels = []string{"pod"}
+
+	ds, ingesters, _, _ := prepare(t, prepConfig{
+		numIngesters:        6,
+		happyIngesters:      6,
+		numDistributors:     1,
+		shardByAllLabels:    true,
+		shuffleShardEnabled: true,
+		shuffleShardSize:    3,
+		limits:              &limits,
+	})
+
+	ctx := user.InjectOrgID(context.Background(), "user")
+	seriesOwners := func(t *testing.T, series string) (int, SeriesOwners) {
+		httpReq := httptest.NewRequest(http.MethodGet, "/distributor/series_owners?series="+url.QueryEscape(series), nil)
+		httpReq = httpReq.WithContext(ctx)
+		rec := httptest.NewRecorder()
+		ds[0].SeriesOwnersHandler(rec, httpReq)
+
+		owners := SeriesOwners{}
+		if rec.Code == http.StatusOK {
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &owners))
+		}
+		return rec.Code, owners
+	}
+
+	// Push a series, to find out which ingesters it's actually written to.
+	_, err := ds[0].Push(ctx, mockWriteRequest([]labels.Labels{labels.FromStrings("__name__", "foo", "job", "test", "pod", "p-1")}, 1, time.Now().UnixMilli(), false))
+	require.NoError(t, err)
+
+	// The push returns once the quorum is reached, so we wait until all the replicas received the series.
+	var expectedIDs []string
+	test.Poll(t, time.Second, 3, func() any {
+		expectedIDs = expectedIDs[:0]
+		for i, ing := range ingesters {
+			if len(ing.series()) > 0 {
+				expectedIDs = append(expectedIDs, fmt.Sprintf("ingester-%d", i))
+			}
+		}
+		return len(expectedIDs)
+	})
+
+	code, owners := seriesOwners(t, `foo{job="test", pod="p-2"}`)
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, `{__name__="foo", job="test"}`, owners.Labels)
+	assert.Equal(t, shardByAllLabels("user", []cortexpb.LabelAdapter{{Name: "__name__", Value: "foo"}, {Name: "job", Value: "test"}}), owners.Token)
+	assert.Equal(t, 1, owners.MaxErrors)
+
+	actualIDs := make([]string, 0, len(owners.Ingesters))
+	for _, ing := range owners.Ingesters {
+		assert.Equal(t, ring.ACTIVE.String(), ing.State)
+		actualIDs = append(actualIDs, ing.ID)
+	}
+	assert.ElementsMatch(t, expectedIDs, actualIDs)
+
+	// Invalid series.
+	code, _ = seriesOwners(t, `foo{`)
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	// Series without metric name.
+	code, _ = seriesOwners(t, `{job="test"}`)
+	assert.Equal(t, http.StatusBadRequest, code)
+}
+
+func TestDistributor_CardinalityHandler(t *testing.T) {
+	t.Parallel()
+
+	ds, _, _, _ := prepare(t, prepConfig{
+		numIngesters:     3,
+		happyIngesters:   3,
+		numDistributors:  1,
+		shardByAllLabels: true,
+	})
+
+	ctx := user.InjectOrgID(context.Background(), "user")
+	series := []labels.Labels{
+		labels.FromStrings("__name__", "foo", "job", "a", "pod", "p-1"),
+		labels.FromStrings("__name__", "foo", "job", "a", "pod", "p-2"),
+		labels.FromStrings("__name__", "foo", "job", "b", "pod", "p-3"),
+		labels.FromStrings("__name__", "bar", "job", "a"),
+	}
+	now := time.Now().UnixMilli()
+	_, err := ds[0].Push(ctx, mockWriteRequest(series, 1, now, false))
+	require.NoError(t, err)
+
+	cardinality := func(t *testing.T, params url.Values) (int, CardinalityResponse) {
+		httpReq := httptest.NewRequest(http.MethodGet, "/api/v1/cardinality?"+params.Encode(), nil)
+		httpReq = httpReq.WithContext(ctx)
+		rec := httptest.NewRecorder()
+		ds[0].CardinalityHandler(rec, httpReq)
+
+		resp := CardinalityResponse{}
+		if rec.Code == http.StatusOK {
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		}
+		return rec.Code, resp
+	}
+
+	t.Run("should return the cardinality of all the series", func(t *testing.T) {
+		code, resp := cardinality(t, url.Values{"limit": []string{"2"}})
+		require.Equal(t, http.StatusOK, code)
+		assert.Equal(t, CardinalityResponse{
+			NumSeries:                   4,
+			SeriesCountByMetricName:     []CardinalityStat{{Name: "foo", Value: 3}, {Name: "bar", Value: 1}},
+			LabelValueCountByLabelName:  []CardinalityStat{{Name: "pod", Value: 3}, {Name: "__name__", Value: 2}},
+			SeriesCountByLabelValuePair: []CardinalityStat{{Name: "__name__=foo", Value: 3}, {Name: "job=a", Value: 3}},
+		}, resp)
+	})
+
+	t.Run("should return the cardinality of the series matching the selector", func(t *testing.T) {
+		code, resp := cardinality(t, url.Values{"selector": []string{`foo{job="a"}`}})
+		require.Equal(t, http.StatusOK, code)
+		assert.Equal(t, CardinalityResponse{
+			NumSeries:                   2,
+			SeriesCountByMetricName:     []CardinalityStat{{Name: "foo", Value: 2}},
+			LabelValueCountByLabelName:  []CardinalityStat{{Name: "pod", Value: 2}, {Name: "__name__", Value: 1}, {Name: "job", Value: 1}},
+			SeriesCountByLabelValuePair: []CardinalityStat{{Name: "__name__=foo", Value: 2}, {Name: "job=a", Value: 2}, {Name: "pod=p-1", Value: 1}, {Name: "pod=p-2", Value: 1}},
+		}, resp)
+	})
+
+	t.Run("should merge the chunks and time range of the ingesters head", func(t *testing.T) {
+		resp, err := ds[0].HeadCardinality(ctx, 1, labels.MustNewMatcher(labels.MatchEqual, labels.MetricName, "foo"))
+		require.NoError(t, err)
+		assert.Equal(t, uint64(3), resp.NumSeries)
+		assert.Equal(t, uint64(3), resp.NumChunks)
+		assert.Equal(t, now, resp.MinTimestampMs)
+		assert.Equal(t, now, resp.MaxTimestampMs)
+		assert.Equal(t, []client.CardinalityStat{{Name: "foo", Value: 3}}, resp.SeriesCountByMetricName)
+	})
+
+	t.Run("should fail on invalid parameters", func(t *testing.T) {
+		code, _ := cardinality(t, url.Values{"selector": []string{`foo{`}})
+		assert.Equal(t, http.StatusBadRequest, code)
+
+		code, _ = cardinality(t, url.Values{"limit": []string{"0"}})
+		assert.Equal(t, http.StatusBadRequest, code)
+	})
+}
+
+func TestDistributor_Push_MetricNameAllowlist(t *testing.T) {
+	t.Parallel()
+
+	inputSeries := []labels.Labels{
+		labels.FromStrings("__name__", "node_cpu_seconds_total", "cluster", "one"),
+		labels.FromStrings("__name__", "node_network_receive_bytes_total", "cluster", "one"),
+		labels.FromStrings("__name__", "process_cpu_seconds_total", "cluster", "one"),
+	}
+
+	var limits validation.Limits
+	flagext.DefaultValues(&limits)
+	limits.MetricNameAllowlist = []string{"node_*"}
+	limits.MetricNameDenylist = []string{"node_network_*"}
+
+	ds, ingesters, _, _ := prepare(t, prepConfig{
+		numIngesters:     2,
+		happyIngesters:   2,
+		numDistributors:  1,
+		shardByAllLabels: true,
+		limits:           &limits,
+	})
+
+	ctx := user.InjectOrgID(context.Background(), "user")
+	_, err := ds[0].Push(ctx, mockWriteRequest(inputSeries, 1, 1, false))
+	resp, ok := httpgrpc.HTTPResponseFromError(err)
+	require.True(t, ok)
+	assert.Equal(t, int32(http.StatusBadRequest), resp.Code)
+	assert.Contains(t, string(resp.Body), `metric name not allowed by the tenant metric names denylist: "node_network_receive_bytes_total"`)
+
+	// The allowed series is ingested, while the others are rejected.
+	for i := range ingesters {
+		assert.Equal(t, 1, len(ingesters[i].series()))
+	}
+	assert.Equal(t, float64(2), testutil.ToFloat64(ds[0].validateMetrics.DiscardedSamples.WithLabelValues("metric_name_not_allowed", "user")))
+}
+
 func TestDistributor_Push_RelabelDropWillExportMetricOfDroppedSamples(t *testing.T) {
 	t.Parallel()
 	metricRelabelConfigs := []*relabel.Config{
@@ -4614,6 +5171,44 @@ func TestDistributor_Push_RelabelDropWillExportMetricOfDroppedSamples(t *testing
 	}
 }
 
+func TestDistributor_Push_InvalidLabelsWillExportMetricOfDiscardedSamples(t *testing.T) {
+	t.Parallel()
+	inputSeries := []labels.Labels{
+		labels.FromStrings("__name__", "foo", "cluster", "too-long"),
+		labels.FromStrings("__name__", "bar", "cluster", "one"),
+	}
+
+	var limits validation.Limits
+	flagext.DefaultValues(&limits)
+	limits.MaxLabelValueLength = 5
+
+	ds, ingesters, _, _ := prepare(t, prepConfig{
+		numIngesters:     2,
+		happyIngesters:   2,
+		numDistributors:  1,
+		shardByAllLabels: true,
+		limits:           &limits,
+	})
+
+	id := "user"
+	req := mockWriteRequest(inputSeries, 1, 1, false)
+	req.Timeseries[0].Samples = append(req.Timeseries[0].Samples, cortexpb.Sample{Value: 2, TimestampMs: 2}, cortexpb.Sample{Value: 3, TimestampMs: 3})
+	req.Timeseries[0].Exemplars = []cortexpb.Exemplar{
+		{Labels: cortexpb.FromLabelsToLabelAdapters(labels.FromStrings("test", "a")), Value: 1, TimestampMs: 1},
+	}
+	ctx := user.InjectOrgID(context.Background(), id)
+	_, err := ds[0].Push(ctx, req)
+	require.Error(t, err)
+
+	for i := range ingesters {
+		assert.Equal(t, 1, len(ingesters[i].series()))
+	}
+
+	// All the samples and exemplars of the rejected series are accounted for.
+	require.Equal(t, float64(3), testutil.ToFloat64(ds[0].validateMetrics.DiscardedSamples.WithLabelValues("label_value_too_long", id)))
+	require.Equal(t, float64(1), testutil.ToFloat64(ds[0].validateMetrics.DiscardedExemplars.WithLabelValues("label_value_too_long", id)))
+}
+
 func TestDistributor_PushLabelSetMetrics(t *testing.T) {
 	t.Parallel()
 	inputSeries := []labels.Labels{
@@ -2,7 +2,13 @@ package distributor
 
 import (
 	"net/http"
+	"strconv"
 
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql/parser"
+	"github.com/weaveworks/common/httpgrpc"
+
+	"github.com/cortexproject/cortex/pkg/cortexpb"
 	"github.com/cortexproject/cortex/pkg/util"
 )
 
@@ -16,3 +22,77 @@ func (d *Distributor) UserStatsHandler(w http.ResponseWriter, r *http.Request) {
 
 	util.WriteJSONResponse(w, stats)
 }
+
+// CardinalityHandler returns the cardinality analysis of the tenant's in-memory series matching
+// the optional "selector" parameter, limited to the top "limit" (default 10) entries of each stat.
+func (d *Distributor) CardinalityHandler(w http.ResponseWriter, r *http.Request) {
+	var matchers []*labels.Matcher
+	if selector := r.FormValue("selector"); selector != "" {
+		var err error
+		if matchers, err = parser.ParseMetricSelector(selector); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	limit := 10
+	if value := r.FormValue("limit"); value != "" {
+		var err error
+		if limit, err = strconv.Atoi(value); err != nil || limit <= 0 {
+			http.Error(w, "limit must be a positive number", http.StatusBadRequest)
+			return
+		}
+	}
+
+	resp, err := d.Cardinality(r.Context(), limit, matchers...)
+	if err != nil {
+		if resp, ok := httpgrpc.HTTPResponseFromError(err); ok {
+			http.Error(w, string(resp.Body), int(resp.Code))
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	util.WriteJSONResponse(w, resp)
+}
+
+// ValidateHandler validates the remote write request in input without ingesting it,
+// and returns the validation outcome of each series.
+func (d *Distributor) ValidateHandler(w http.ResponseWriter, r *http.Request) {
+	var req cortexpb.WriteRequest
+	if err := util.ParseProtoReader(r.Context(), r.Body, int(r.ContentLength), d.cfg.MaxRecvMsgSize, &req, util.RawSnappy); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	report, err := d.ValidateWriteRequest(r.Context(), &req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	util.WriteJSONResponse(w, report)
+}
+
+// SeriesOwnersHandler returns the ingesters owning the series given in the "series" parameter
+// (eg. `up{job="node"}`), in the same way they're selected when the series is pushed.
+func (d *Distributor) SeriesOwnersHandler(w http.ResponseWriter, r *http.Request) {
+	lbls, err := parser.ParseMetric(r.FormValue("series"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	owners, err := d.GetSeriesOwners(r.Context(), lbls)
+	if err != nil {
+		if resp, ok := httpgrpc.HTTPResponseFromError(err); ok {
+			http.Error(w, string(resp.Body), int(resp.Code))
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	util.WriteJSONResponse(w, owners)
+}
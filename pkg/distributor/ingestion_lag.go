@@ -0,0 +1,92 @@
+package distributor
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/atomic"
+
+	"github.com/cortexproject/cortex/pkg/cortexpb"
+)
+
+// ingestionLagMetrics tracks, per user, the timestamp of the newest accepted sample and exposes
+// the time elapsed since then as a gauge, computed when the metrics are collected.
+type ingestionLagMetrics struct {
+	now func() time.Time
+
+	// Map of user ID to *atomic.Int64, holding the newest accepted sample timestamp in milliseconds.
+	users sync.Map
+
+	lag *prometheus.Desc
+}
+
+func newIngestionLagMetrics(reg prometheus.Registerer) *ingestionLagMetrics {
+	m := &ingestionLagMetrics{
+		now: time.Now,
+		lag: prometheus.NewDesc(
+			"cortex_distributor_ingestion_lag_seconds",
+			"Time elapsed since the timestamp of the newest sample accepted for the user. It is negative if samples with a timestamp in the future are accepted.",
+			[]string{"user"}, nil),
+	}
+	if reg != nil {
+		reg.MustRegister(m)
+	}
+	return m
+}
+
+// observe records the newest sample timestamp of the series accepted for the user. The tracked
+// timestamp never moves backward, so that backfilling old samples doesn't increase the lag.
+func (m *ingestionLagMetrics) observe(userID string, timestampMs int64) {
+	if timestampMs <= 0 {
+		return
+	}
+
+	v, ok := m.users.Load(userID)
+	if !ok {
+		v, _ = m.users.LoadOrStore(userID, atomic.NewInt64(0))
+	}
+	latest := v.(*atomic.Int64)
+
+	for {
+		current := latest.Load()
+		if timestampMs <= current || latest.CompareAndSwap(current, timestampMs) {
+			return
+		}
+	}
+}
+
+func (m *ingestionLagMetrics) deleteUser(userID string) {
+	m.users.Delete(userID)
+}
+
+// Describe implements prometheus.Collector.
+func (m *ingestionLagMetrics) Describe(out chan<- *prometheus.Desc) {
+	out <- m.lag
+}
+
+// Collect implements prometheus.Collector.
+func (m *ingestionLagMetrics) Collect(out chan<- prometheus.Metric) {
+	nowMs := m.now().UnixMilli()
+
+	m.users.Range(func(key, value any) bool {
+		lag := float64(nowMs-value.(*atomic.Int64).Load()) / 1000
+		out <- prometheus.MustNewConstMetric(m.lag, prometheus.GaugeValue, lag, key.(string))
+		return true
+	})
+}
+
+// latestSampleTimestampMs returns the newest timestamp across the samples and histograms of the
+// series, assuming that the samples of each series are ordered by timestamp.
+func latestSampleTimestampMs(series []cortexpb.PreallocTimeseries) int64 {
+	latest := int64(0)
+	for _, ts := range series {
+		if len(ts.Samples) > 0 {
+			latest = max(latest, ts.Samples[len(ts.Samples)-1].TimestampMs)
+		}
+		if len(ts.Histograms) > 0 {
+			latest = max(latest, ts.Histograms[len(ts.Histograms)-1].TimestampMs)
+		}
+	}
+	return latest
+}
@@ -0,0 +1,377 @@
+package distributor
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"github.com/twmb/franz-go/pkg/sasl"
+	"github.com/twmb/franz-go/pkg/sasl/plain"
+	"github.com/twmb/franz-go/pkg/sasl/scram"
+	"go.uber.org/atomic"
+
+	"github.com/cortexproject/cortex/pkg/cortexpb"
+	"github.com/cortexproject/cortex/pkg/util"
+	"github.com/cortexproject/cortex/pkg/util/flagext"
+	util_log "github.com/cortexproject/cortex/pkg/util/log"
+	"github.com/cortexproject/cortex/pkg/util/services"
+	"github.com/cortexproject/cortex/pkg/util/tls"
+	"github.com/cortexproject/cortex/pkg/util/validation"
+)
+
+const (
+	kafkaExportOutcomeSuccess = "success"
+	kafkaExportOutcomeFailure = "failure"
+
+	// Supported SASL mechanisms of the Kafka export.
+	kafkaSASLMechanismPlain       = "PLAIN"
+	kafkaSASLMechanismScramSHA256 = "SCRAM-SHA-256"
+	kafkaSASLMechanismScramSHA512 = "SCRAM-SHA-512"
+
+	// kafkaRecordOverheadBytes is the room left in a produce batch for the record key and the
+	// batch headers, on top of the encoded write request.
+	kafkaRecordOverheadBytes = 1024
+)
+
+var (
+	errInvalidKafkaExportTopic         = errors.New("the distributor.kafka-export.topic must be set when the Kafka export is enabled")
+	errInvalidKafkaExportQueueCapacity = errors.New("the distributor.kafka-export.queue-capacity must be greater than 0")
+	errInvalidKafkaExportConcurrency   = errors.New("the distributor.kafka-export.concurrency must be greater than 0")
+	errInvalidKafkaExportMaxRecordSize = errors.New("the distributor.kafka-export.max-record-size-bytes must be greater than 1024")
+	errInvalidKafkaExportMaxQueuedSize = errors.New("the distributor.kafka-export.max-queued-bytes must be greater than 0")
+	errInvalidKafkaExportSASLMechanism = errors.New("the distributor.kafka-export.sasl-mechanism must be empty, PLAIN, SCRAM-SHA-256 or SCRAM-SHA-512")
+	errInvalidKafkaExportSASLUsername  = errors.New("the distributor.kafka-export.sasl-username must be set when a SASL mechanism is configured")
+)
+
+// KafkaExportConfig configures the export of the accepted write requests to a Kafka topic.
+type KafkaExportConfig struct {
+	Addresses          flagext.StringSliceCSV `yaml:"addresses"`
+	Topic              string                 `yaml:"topic"`
+	ClientID           string                 `yaml:"client_id"`
+	WriteTimeout       time.Duration          `yaml:"write_timeout"`
+	QueueCapacity      int                    `yaml:"queue_capacity"`
+	Concurrency        int                    `yaml:"concurrency"`
+	MaxBufferedRecords int                    `yaml:"max_buffered_records"`
+	MaxRecordSizeBytes int                    `yaml:"max_record_size_bytes"`
+	MaxQueuedBytes     int                    `yaml:"max_queued_bytes"`
+
+	TLSEnabled bool             `yaml:"tls_enabled"`
+	TLS        tls.ClientConfig `yaml:",inline"`
+
+	SASLMechanism string         `yaml:"sasl_mechanism"`
+	SASLUsername  string         `yaml:"sasl_username"`
+	SASLPassword  flagext.Secret `yaml:"sasl_password"`
+}
+
+func (cfg *KafkaExportConfig) RegisterFlags(f *flag.FlagSet) {
+	f.Var(&cfg.Addresses, "distributor.kafka-export.addresses", "EXPERIMENTAL: Comma separated list of the Kafka seed brokers the accepted series of the tenants with -distributor.kafka-export-enabled are exported to, for downstream consumers. The series are exported asynchronously, after being validated and relabeled, and the export failures never fail the write requests. Each record is a protobuf encoded write request, keyed by the tenant ID. If empty, the export is disabled.")
+	f.StringVar(&cfg.Topic, "distributor.kafka-export.topic", "", "Kafka topic the series are exported to.")
+	f.StringVar(&cfg.ClientID, "distributor.kafka-export.client-id", "cortex-distributor", "Kafka client ID of the export.")
+	f.DurationVar(&cfg.WriteTimeout, "distributor.kafka-export.write-timeout", 10*time.Second, "Timeout of the delivery of an exported record, after which the record is counted as failed.")
+	f.IntVar(&cfg.QueueCapacity, "distributor.kafka-export.queue-capacity", 1000, "Maximum number of write requests queued to be exported. The write requests exported while the queue is full are dropped.")
+	f.IntVar(&cfg.MaxQueuedBytes, "distributor.kafka-export.max-queued-bytes", 256<<20, "Maximum size of the series of the write requests queued to be exported, which are retained until encoded into records. The write requests exported while the queued series exceed this size are dropped.")
+	f.IntVar(&cfg.Concurrency, "distributor.kafka-export.concurrency", 4, "Number of write requests encoded into records concurrently.")
+	f.IntVar(&cfg.MaxBufferedRecords, "distributor.kafka-export.max-buffered-records", 10000, "Maximum number of records buffered by the Kafka client waiting to be delivered. The encoding of the queued write requests is paused while the buffer is full.")
+	f.IntVar(&cfg.MaxRecordSizeBytes, "distributor.kafka-export.max-record-size-bytes", 1000000, "Maximum size of an exported record. The write requests larger than this are split into multiple records. It must not exceed the max message size of the Kafka topic.")
+	f.BoolVar(&cfg.TLSEnabled, "distributor.kafka-export.tls-enabled", false, "Enable TLS on the connections to the Kafka brokers.")
+	cfg.TLS.RegisterFlagsWithPrefix("distributor.kafka-export", f)
+	f.StringVar(&cfg.SASLMechanism, "distributor.kafka-export.sasl-mechanism", "", "SASL mechanism used to authenticate to the Kafka brokers. Supported values: PLAIN, SCRAM-SHA-256, SCRAM-SHA-512. If empty, SASL authentication is disabled.")
+	f.StringVar(&cfg.SASLUsername, "distributor.kafka-export.sasl-username", "", "SASL username used to authenticate to the Kafka brokers.")
+	f.Var(&cfg.SASLPassword, "distributor.kafka-export.sasl-password", "SASL password used to authenticate to the Kafka brokers.")
+}
+
+// IsEnabled returns whether the Kafka export is enabled.
+func (cfg *KafkaExportConfig) IsEnabled() bool {
+	return len(cfg.Addresses) > 0
+}
+
+func (cfg *KafkaExportConfig) Validate() error {
+	if !cfg.IsEnabled() {
+		return nil
+	}
+	if cfg.Topic == "" {
+		return errInvalidKafkaExportTopic
+	}
+	if cfg.QueueCapacity <= 0 {
+		return errInvalidKafkaExportQueueCapacity
+	}
+	if cfg.Concurrency <= 0 {
+		return errInvalidKafkaExportConcurrency
+	}
+	if cfg.MaxRecordSizeBytes <= kafkaRecordOverheadBytes {
+		return errInvalidKafkaExportMaxRecordSize
+	}
+	if cfg.MaxQueuedBytes <= 0 {
+		return errInvalidKafkaExportMaxQueuedSize
+	}
+	switch cfg.SASLMechanism {
+	case "":
+	case kafkaSASLMechanismPlain, kafkaSASLMechanismScramSHA256, kafkaSASLMechanismScramSHA512:
+		if cfg.SASLUsername == "" {
+			return errInvalidKafkaExportSASLUsername
+		}
+	default:
+		return errInvalidKafkaExportSASLMechanism
+	}
+	return nil
+}
+
+// saslMechanism returns the configured SASL mechanism, or nil if SASL authentication is disabled.
+func (cfg *KafkaExportConfig) saslMechanism() sasl.Mechanism {
+	switch cfg.SASLMechanism {
+	case kafkaSASLMechanismPlain:
+		return plain.Auth{User: cfg.SASLUsername, Pass: cfg.SASLPassword.Value}.AsMechanism()
+	case kafkaSASLMechanismScramSHA256:
+		return scram.Auth{User: cfg.SASLUsername, Pass: cfg.SASLPassword.Value}.AsSha256Mechanism()
+	case kafkaSASLMechanismScramSHA512:
+		return scram.Auth{User: cfg.SASLUsername, Pass: cfg.SASLPassword.Value}.AsSha512Mechanism()
+	default:
+		return nil
+	}
+}
+
+// kafkaProducer is the subset of the Kafka client used by the export.
+type kafkaProducer interface {
+	Produce(ctx context.Context, r *kgo.Record, promise func(*kgo.Record, error))
+	Flush(ctx context.Context) error
+	Close()
+}
+
+type kafkaExportRequest struct {
+	userID  string
+	series  []cortexpb.PreallocTimeseries
+	size    int64
+	source  cortexpb.SourceEnum
+	release func()
+}
+
+// kafkaExporter exports the accepted series of the enabled tenants to a Kafka topic. The write requests
+// are queued and encoded by a pool of workers, so that the export doesn't add latency to the write path:
+// the requests are dropped when the queue is full or its series exceed the max queued bytes, and the
+// records delivery failures are only tracked and logged.
+type kafkaExporter struct {
+	services.Service
+
+	cfg      KafkaExportConfig
+	limits   *validation.Overrides
+	logger   log.Logger
+	producer kafkaProducer
+	queue    chan kafkaExportRequest
+
+	// Size of the series of the queued write requests, retained until encoded into records.
+	queuedBytes atomic.Int64
+
+	droppedRequests *prometheus.CounterVec
+	exportedSeries  *prometheus.CounterVec
+	records         *prometheus.CounterVec
+}
+
+func newKafkaExporter(cfg KafkaExportConfig, limits *validation.Overrides, reg prometheus.Registerer, logger log.Logger) (*kafkaExporter, error) {
+	opts := []kgo.Opt{
+		kgo.SeedBrokers(cfg.Addresses...),
+		kgo.ClientID(cfg.ClientID),
+		kgo.DefaultProduceTopic(cfg.Topic),
+		kgo.RecordDeliveryTimeout(cfg.WriteTimeout),
+		kgo.MaxBufferedRecords(cfg.MaxBufferedRecords),
+		kgo.ProducerBatchMaxBytes(int32(cfg.MaxRecordSizeBytes)),
+	}
+	if cfg.TLSEnabled {
+		tlsConfig, err := cfg.TLS.GetTLSConfig()
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, kgo.DialTLSConfig(tlsConfig))
+	}
+	if mechanism := cfg.saslMechanism(); mechanism != nil {
+		opts = append(opts, kgo.SASL(mechanism))
+	}
+
+	client, err := kgo.NewClient(opts...)
+	if err != nil {
+		return nil, err
+	}
+	return newKafkaExporterWithProducer(cfg, limits, client, reg, logger), nil
+}
+
+func newKafkaExporterWithProducer(cfg KafkaExportConfig, limits *validation.Overrides, producer kafkaProducer, reg prometheus.Registerer, logger log.Logger) *kafkaExporter {
+	e := &kafkaExporter{
+		cfg:      cfg,
+		limits:   limits,
+		logger:   logger,
+		producer: producer,
+		queue:    make(chan kafkaExportRequest, cfg.QueueCapacity),
+		droppedRequests: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Namespace: "cortex",
+			Name:      "distributor_kafka_export_dropped_requests_total",
+			Help:      "The total number of write requests not exported to Kafka because the export queue was full.",
+		}, []string{"user"}),
+		exportedSeries: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Namespace: "cortex",
+			Name:      "distributor_kafka_export_series_total",
+			Help:      "The total number of series encoded into records exported to Kafka.",
+		}, []string{"user"}),
+		records: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Namespace: "cortex",
+			Name:      "distributor_kafka_export_records_total",
+			Help:      "The total number of records exported to Kafka, by outcome.",
+		}, []string{"user", "outcome"}),
+	}
+
+	e.Service = services.NewBasicService(nil, e.running, nil)
+	return e
+}
+
+func (e *kafkaExporter) running(ctx context.Context) error {
+	wg := sync.WaitGroup{}
+	for range e.cfg.Concurrency {
+		wg.Go(func() {
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case req := <-e.queue:
+					e.export(ctx, req)
+				}
+			}
+		})
+	}
+	wg.Wait()
+
+	// Release the requests still queued, so that they can be reused.
+	for done := false; !done; {
+		select {
+		case req := <-e.queue:
+			e.queuedBytes.Sub(req.size)
+			req.release()
+		default:
+			done = true
+		}
+	}
+
+	// Give the buffered records a chance to be delivered before closing the client.
+	flushCtx, cancel := context.WithTimeout(context.Background(), e.cfg.WriteTimeout)
+	defer cancel()
+	if err := e.producer.Flush(flushCtx); err != nil {
+		level.Warn(e.logger).Log("msg", "failed to flush the records exported to Kafka", "err", err)
+	}
+	e.producer.Close()
+	return nil
+}
+
+// exportSeries queues the series of the user to be exported, if the export is enabled for the user.
+// The series are encoded by the workers, so the write request can't be reused until then: the returned
+// function must be called instead of release once the write path is done with the request, and release
+// is called once both the write path and the export are done with it. The retained series are bounded
+// by the max queued bytes.
+func (e *kafkaExporter) exportSeries(userID string, series []cortexpb.PreallocTimeseries, source cortexpb.SourceEnum, release func()) func() {
+	if !e.limits.KafkaExportEnabled(userID) || len(series) == 0 {
+		return release
+	}
+
+	size := int64(0)
+	for _, ts := range series {
+		size += int64(ts.Size())
+	}
+	if e.queuedBytes.Add(size) > int64(e.cfg.MaxQueuedBytes) {
+		e.queuedBytes.Sub(size)
+		e.droppedRequests.WithLabelValues(userID).Inc()
+		return release
+	}
+
+	refs := atomic.NewInt32(2)
+	done := func() {
+		if refs.Dec() == 0 {
+			release()
+		}
+	}
+
+	select {
+	case e.queue <- kafkaExportRequest{userID: userID, series: series, size: size, source: source, release: done}:
+		return done
+	default:
+		e.queuedBytes.Sub(size)
+		e.droppedRequests.WithLabelValues(userID).Inc()
+		return release
+	}
+}
+
+// export encodes the queued request into records, releases it and produces the records.
+func (e *kafkaExporter) export(ctx context.Context, req kafkaExportRequest) {
+	values, err := encodeKafkaExportRecords(req.series, req.source, e.cfg.MaxRecordSizeBytes-kafkaRecordOverheadBytes)
+	e.queuedBytes.Sub(req.size)
+	req.release()
+	if err != nil {
+		e.records.WithLabelValues(req.userID, kafkaExportOutcomeFailure).Inc()
+		level.Warn(util_log.WithUserID(req.userID, e.logger)).Log("msg", "failed to marshal the write request exported to Kafka", "err", err)
+		return
+	}
+	e.exportedSeries.WithLabelValues(req.userID).Add(float64(len(req.series)))
+
+	key := []byte(req.userID)
+	for _, value := range values {
+		// Produce only blocks while the client buffer is full.
+		e.producer.Produce(ctx, &kgo.Record{Key: key, Value: value}, func(_ *kgo.Record, err error) {
+			if err != nil {
+				e.records.WithLabelValues(req.userID, kafkaExportOutcomeFailure).Inc()
+				level.Warn(util_log.WithUserID(req.userID, e.logger)).Log("msg", "failed to export the write request to Kafka", "err", err)
+				return
+			}
+			e.records.WithLabelValues(req.userID, kafkaExportOutcomeSuccess).Inc()
+		})
+	}
+}
+
+func (e *kafkaExporter) cleanupUser(userID string) {
+	e.droppedRequests.DeleteLabelValues(userID)
+	e.exportedSeries.DeleteLabelValues(userID)
+	if err := util.DeleteMatchingLabels(e.records, map[string]string{"user": userID}); err != nil {
+		level.Warn(e.logger).Log("msg", "failed to remove cortex_distributor_kafka_export_records_total metric for user", "user", userID, "err", err)
+	}
+}
+
+// encodeKafkaExportRecords returns the series encoded into write requests of at most maxSize bytes. A
+// series larger than maxSize is encoded into a write request of its own.
+func encodeKafkaExportRecords(series []cortexpb.PreallocTimeseries, source cortexpb.SourceEnum, maxSize int) ([][]byte, error) {
+	var (
+		values    [][]byte
+		batch     = cortexpb.WriteRequest{Source: source}
+		batchSize int
+	)
+
+	flush := func() error {
+		if len(batch.Timeseries) == 0 {
+			return nil
+		}
+		data, err := batch.Marshal()
+		if err != nil {
+			return err
+		}
+		values = append(values, data)
+		batch.Timeseries = nil
+		batchSize = 0
+		return nil
+	}
+
+	for _, ts := range series {
+		// The encoded series is prefixed by its field tag and length, of at most 6 bytes.
+		size := ts.Size() + 6
+		if len(batch.Timeseries) > 0 && batchSize+size > maxSize {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+		}
+		batch.Timeseries = append(batch.Timeseries, ts)
+		batchSize += size
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
@@ -0,0 +1,287 @@
+package distributor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"go.uber.org/atomic"
+
+	"github.com/cortexproject/cortex/pkg/cortexpb"
+	"github.com/cortexproject/cortex/pkg/util/flagext"
+	"github.com/cortexproject/cortex/pkg/util/services"
+	"github.com/cortexproject/cortex/pkg/util/test"
+	"github.com/cortexproject/cortex/pkg/util/validation"
+)
+
+func TestEncodeKafkaExportRecords(t *testing.T) {
+	series := make([]cortexpb.PreallocTimeseries, 0, 100)
+	for i := range 100 {
+		series = append(series, cortexpb.PreallocTimeseries{TimeSeries: &cortexpb.TimeSeries{
+			Labels:  cortexpb.FromLabelsToLabelAdapters(labels.FromStrings("__name__", "foo", "i", fmt.Sprint(i))),
+			Samples: []cortexpb.Sample{{TimestampMs: 1000, Value: float64(i)}},
+		}})
+	}
+
+	for _, maxSize := range []int{1, 100, 1000, 1000000} {
+		t.Run(fmt.Sprint(maxSize), func(t *testing.T) {
+			values, err := encodeKafkaExportRecords(series, cortexpb.RULE, maxSize)
+			require.NoError(t, err)
+
+			var decoded []cortexpb.PreallocTimeseries
+			for _, value := range values {
+				// A record only exceeds the max size when it has a single series.
+				req := cortexpb.WriteRequest{}
+				require.NoError(t, req.Unmarshal(value))
+				if len(value) > maxSize {
+					assert.Len(t, req.Timeseries, 1)
+				}
+				assert.Equal(t, cortexpb.RULE, req.Source)
+				decoded = append(decoded, req.Timeseries...)
+			}
+			require.Len(t, decoded, len(series))
+			for i := range series {
+				assert.Equal(t, series[i].Labels, decoded[i].Labels)
+				assert.Equal(t, series[i].Samples, decoded[i].Samples)
+			}
+		})
+	}
+}
+
+func TestKafkaExporter(t *testing.T) {
+	cfg := KafkaExportConfig{}
+	flagext.DefaultValues(&cfg)
+	cfg.Addresses = []string{"localhost:9092"}
+	cfg.Topic = "series"
+	require.NoError(t, cfg.Validate())
+
+	limits := validation.Limits{}
+	flagext.DefaultValues(&limits)
+	user2Limits := limits
+	user2Limits.KafkaExportEnabled = false
+	overrides := validation.NewOverrides(limits, mockTenantLimits{"user-2": &user2Limits})
+
+	producer := &mockKafkaProducer{}
+	reg := prometheus.NewPedanticRegistry()
+	e := newKafkaExporterWithProducer(cfg, overrides, producer, reg, log.NewNopLogger())
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), e))
+
+	series := []cortexpb.PreallocTimeseries{{TimeSeries: &cortexpb.TimeSeries{
+		Labels:  cortexpb.FromLabelsToLabelAdapters(labels.FromStrings("__name__", "foo")),
+		Samples: []cortexpb.Sample{{TimestampMs: 1000, Value: 1}},
+	}}}
+
+	released := atomic.NewInt32(0)
+	release := func() { released.Inc() }
+	for _, userID := range []string{"user-1", "user-2", "user-3"} {
+		// The request is released once both the write path and the export are done with it.
+		e.exportSeries(userID, series, cortexpb.API, release)()
+	}
+	test.Poll(t, time.Second, int32(3), func() any {
+		return released.Load()
+	})
+	test.Poll(t, time.Second, 2, func() any {
+		return len(producer.getRecords())
+	})
+
+	// The records are keyed by tenant, and user-2 isn't exported.
+	keys := []string{}
+	for _, r := range producer.getRecords() {
+		keys = append(keys, string(r.Key))
+		req := cortexpb.WriteRequest{}
+		require.NoError(t, req.Unmarshal(r.Value))
+		assert.Equal(t, series[0].Labels, req.Timeseries[0].Labels)
+	}
+	assert.ElementsMatch(t, []string{"user-1", "user-3"}, keys)
+
+	// The delivery failures are counted.
+	producer.setErr(errors.New("broker unavailable"))
+	e.exportSeries("user-1", series, cortexpb.API, func() {})()
+	test.Poll(t, time.Second, 1.0, func() any {
+		return testutil.ToFloat64(e.records.WithLabelValues("user-1", kafkaExportOutcomeFailure))
+	})
+
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP cortex_distributor_kafka_export_records_total The total number of records exported to Kafka, by outcome.
+		# TYPE cortex_distributor_kafka_export_records_total counter
+		cortex_distributor_kafka_export_records_total{outcome="failure",user="user-1"} 1
+		cortex_distributor_kafka_export_records_total{outcome="success",user="user-1"} 1
+		cortex_distributor_kafka_export_records_total{outcome="success",user="user-3"} 1
+		# HELP cortex_distributor_kafka_export_series_total The total number of series encoded into records exported to Kafka.
+		# TYPE cortex_distributor_kafka_export_series_total counter
+		cortex_distributor_kafka_export_series_total{user="user-1"} 2
+		cortex_distributor_kafka_export_series_total{user="user-3"} 1
+	`), "cortex_distributor_kafka_export_records_total", "cortex_distributor_kafka_export_series_total"))
+
+	// The client is flushed and closed when the exporter stops.
+	require.NoError(t, services.StopAndAwaitTerminated(context.Background(), e))
+	assert.True(t, producer.closed.Load())
+}
+
+func TestKafkaExporter_ShouldDropRequestsWhenQueueIsFull(t *testing.T) {
+	cfg := KafkaExportConfig{}
+	flagext.DefaultValues(&cfg)
+	cfg.QueueCapacity = 1
+
+	limits := validation.Limits{}
+	flagext.DefaultValues(&limits)
+
+	// The exporter isn't started, so the queued requests are never exported.
+	e := newKafkaExporterWithProducer(cfg, validation.NewOverrides(limits, nil), &mockKafkaProducer{}, nil, log.NewNopLogger())
+	series := []cortexpb.PreallocTimeseries{{TimeSeries: &cortexpb.TimeSeries{
+		Labels:  cortexpb.FromLabelsToLabelAdapters(labels.FromStrings("__name__", "foo")),
+		Samples: []cortexpb.Sample{{TimestampMs: 1000, Value: 1}},
+	}}}
+	released := 0
+	release := func() { released++ }
+
+	// The queued request is not released until exported.
+	e.exportSeries("user-1", series, cortexpb.API, release)()
+	assert.Equal(t, 0, released)
+
+	// The dropped request is released by the write path.
+	e.exportSeries("user-1", series, cortexpb.API, release)()
+	assert.Equal(t, 1, released)
+
+	assert.Len(t, e.queue, 1)
+	assert.Equal(t, 1.0, testutil.ToFloat64(e.droppedRequests.WithLabelValues("user-1")))
+
+	(<-e.queue).release()
+	assert.Equal(t, 2, released)
+}
+
+func TestKafkaExporter_ShouldDropRequestsWhenQueuedBytesExceedTheMax(t *testing.T) {
+	series := []cortexpb.PreallocTimeseries{{TimeSeries: &cortexpb.TimeSeries{
+		Labels:  cortexpb.FromLabelsToLabelAdapters(labels.FromStrings("__name__", "foo")),
+		Samples: []cortexpb.Sample{{TimestampMs: 1000, Value: 1}},
+	}}}
+
+	cfg := KafkaExportConfig{}
+	flagext.DefaultValues(&cfg)
+	cfg.MaxQueuedBytes = series[0].Size() * 3 / 2
+
+	limits := validation.Limits{}
+	flagext.DefaultValues(&limits)
+
+	// The exporter isn't started, so the queued requests are only exported when dequeued below.
+	producer := &mockKafkaProducer{}
+	e := newKafkaExporterWithProducer(cfg, validation.NewOverrides(limits, nil), producer, nil, log.NewNopLogger())
+	released := 0
+	release := func() { released++ }
+
+	// The second request would exceed the max queued bytes, so it's dropped and released by the write path.
+	e.exportSeries("user-1", series, cortexpb.API, release)()
+	e.exportSeries("user-1", series, cortexpb.API, release)()
+	assert.Equal(t, 1, released)
+	assert.Len(t, e.queue, 1)
+	assert.Equal(t, int64(series[0].Size()), e.queuedBytes.Load())
+	assert.Equal(t, 1.0, testutil.ToFloat64(e.droppedRequests.WithLabelValues("user-1")))
+
+	// Once the queued request is encoded, its series are released and don't count anymore.
+	e.export(context.Background(), <-e.queue)
+	assert.Equal(t, 2, released)
+	assert.Equal(t, int64(0), e.queuedBytes.Load())
+	assert.Len(t, producer.getRecords(), 1)
+
+	e.exportSeries("user-1", series, cortexpb.API, release)()
+	assert.Len(t, e.queue, 1)
+}
+
+func TestKafkaExportConfig_Validate(t *testing.T) {
+	tests := map[string]struct {
+		setup    func(cfg *KafkaExportConfig)
+		expected error
+	}{
+		"should pass with the default config": {
+			setup: func(*KafkaExportConfig) {},
+		},
+		"should fail with a non positive max queued bytes": {
+			setup:    func(cfg *KafkaExportConfig) { cfg.MaxQueuedBytes = 0 },
+			expected: errInvalidKafkaExportMaxQueuedSize,
+		},
+		"should pass with a supported SASL mechanism and a username": {
+			setup: func(cfg *KafkaExportConfig) {
+				cfg.SASLMechanism = kafkaSASLMechanismScramSHA512
+				cfg.SASLUsername = "user"
+			},
+		},
+		"should fail with a SASL mechanism but no username": {
+			setup:    func(cfg *KafkaExportConfig) { cfg.SASLMechanism = kafkaSASLMechanismPlain },
+			expected: errInvalidKafkaExportSASLUsername,
+		},
+		"should fail with an unsupported SASL mechanism": {
+			setup: func(cfg *KafkaExportConfig) {
+				cfg.SASLMechanism = "GSSAPI"
+				cfg.SASLUsername = "user"
+			},
+			expected: errInvalidKafkaExportSASLMechanism,
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			cfg := KafkaExportConfig{}
+			flagext.DefaultValues(&cfg)
+			cfg.Addresses = []string{"localhost:9092"}
+			cfg.Topic = "series"
+			testData.setup(&cfg)
+			assert.Equal(t, testData.expected, cfg.Validate())
+		})
+	}
+}
+
+func TestKafkaExportConfig_SASLMechanism(t *testing.T) {
+	cfg := KafkaExportConfig{SASLUsername: "user"}
+	assert.Nil(t, cfg.saslMechanism())
+
+	for _, mechanism := range []string{kafkaSASLMechanismPlain, kafkaSASLMechanismScramSHA256, kafkaSASLMechanismScramSHA512} {
+		cfg.SASLMechanism = mechanism
+		require.NotNil(t, cfg.saslMechanism())
+		assert.Equal(t, mechanism, cfg.saslMechanism().Name())
+	}
+}
+
+type mockKafkaProducer struct {
+	mtx     sync.Mutex
+	records []*kgo.Record
+	err     error
+	closed  atomic.Bool
+}
+
+func (p *mockKafkaProducer) Produce(_ context.Context, r *kgo.Record, promise func(*kgo.Record, error)) {
+	p.mtx.Lock()
+	err := p.err
+	if err == nil {
+		p.records = append(p.records, r)
+	}
+	p.mtx.Unlock()
+
+	promise(r, err)
+}
+
+func (p *mockKafkaProducer) Flush(context.Context) error { return nil }
+
+func (p *mockKafkaProducer) Close() { p.closed.Store(true) }
+
+func (p *mockKafkaProducer) setErr(err error) {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	p.err = err
+}
+
+func (p *mockKafkaProducer) getRecords() []*kgo.Record {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	return append([]*kgo.Record(nil), p.records...)
+}
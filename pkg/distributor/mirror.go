@@ -0,0 +1,259 @@
+package distributor
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/golang/snappy"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	config_util "github.com/prometheus/common/config"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/storage/remote"
+
+	"github.com/cortexproject/cortex/pkg/cortexpb"
+	"github.com/cortexproject/cortex/pkg/util"
+	"github.com/cortexproject/cortex/pkg/util/flagext"
+	util_log "github.com/cortexproject/cortex/pkg/util/log"
+	"github.com/cortexproject/cortex/pkg/util/services"
+	"github.com/cortexproject/cortex/pkg/util/tls"
+	"github.com/cortexproject/cortex/pkg/util/validation"
+)
+
+const (
+	mirrorOutcomeSuccess = "success"
+	mirrorOutcomeFailure = "failure"
+	mirrorOutcomeDropped = "dropped"
+)
+
+var (
+	errInvalidMirrorQueueCapacity = errors.New("the distributor.mirror.queue-capacity must be greater than 0")
+	errInvalidMirrorConcurrency   = errors.New("the distributor.mirror.concurrency must be greater than 0")
+)
+
+// MirrorConfig configures the mirroring of the write requests to a secondary remote write endpoint.
+type MirrorConfig struct {
+	URL           flagext.URLValue `yaml:"url"`
+	Timeout       time.Duration    `yaml:"timeout"`
+	QueueCapacity int              `yaml:"queue_capacity"`
+	Concurrency   int              `yaml:"concurrency"`
+	TLS           tls.ClientConfig `yaml:",inline"`
+	BasicAuth     util.BasicAuth   `yaml:",inline"`
+}
+
+func (cfg *MirrorConfig) RegisterFlags(f *flag.FlagSet) {
+	f.Var(&cfg.URL, "distributor.mirror.url", "EXPERIMENTAL: URL of the Prometheus remote write endpoint the series of the tenants with a -distributor.mirror-writes-ratio greater than 0 are mirrored to, for instance to validate a new cluster under real load. The series are mirrored asynchronously, after being validated, and the mirroring failures never fail the write requests. The tenant ID is sent in the X-Scope-OrgID header. If empty, the mirroring is disabled.")
+	f.DurationVar(&cfg.Timeout, "distributor.mirror.timeout", 10*time.Second, "Timeout of the mirrored write requests.")
+	f.IntVar(&cfg.QueueCapacity, "distributor.mirror.queue-capacity", 1000, "Maximum number of mirrored write requests queued to be sent. The queued requests only retain a copy of the series selected to be mirrored. The write requests mirrored while the queue is full are dropped.")
+	f.IntVar(&cfg.Concurrency, "distributor.mirror.concurrency", 4, "Number of mirrored write requests sent concurrently.")
+	cfg.TLS.RegisterFlagsWithPrefix("distributor.mirror", f)
+	cfg.BasicAuth.RegisterFlagsWithPrefix("distributor.mirror.", f)
+}
+
+// IsEnabled returns whether the mirroring is enabled.
+func (cfg *MirrorConfig) IsEnabled() bool {
+	return cfg.URL.URL != nil
+}
+
+func (cfg *MirrorConfig) Validate() error {
+	if !cfg.IsEnabled() {
+		return nil
+	}
+	if cfg.QueueCapacity <= 0 {
+		return errInvalidMirrorQueueCapacity
+	}
+	if cfg.Concurrency <= 0 {
+		return errInvalidMirrorConcurrency
+	}
+	return nil
+}
+
+func (cfg *MirrorConfig) clientConfig(userID string) *remote.ClientConfig {
+	clientCfg := &remote.ClientConfig{
+		URL:     &config_util.URL{URL: cfg.URL.URL},
+		Timeout: model.Duration(cfg.Timeout),
+		HTTPClientConfig: config_util.HTTPClientConfig{
+			TLSConfig: config_util.TLSConfig{
+				CAFile:             cfg.TLS.CAPath,
+				CertFile:           cfg.TLS.CertPath,
+				KeyFile:            cfg.TLS.KeyPath,
+				InsecureSkipVerify: cfg.TLS.InsecureSkipVerify,
+				ServerName:         cfg.TLS.ServerName,
+			},
+		},
+		Headers: map[string]string{"X-Scope-OrgID": userID},
+	}
+
+	if cfg.BasicAuth.IsEnabled() {
+		clientCfg.HTTPClientConfig.BasicAuth = &config_util.BasicAuth{
+			Username: cfg.BasicAuth.Username,
+			Password: config_util.Secret(cfg.BasicAuth.Password.Value),
+		}
+	} else if cfg.URL.User != nil {
+		clientCfg.HTTPClientConfig.BasicAuth = &config_util.BasicAuth{
+			Username: cfg.URL.User.Username(),
+		}
+		if password, isSet := cfg.URL.User.Password(); isSet {
+			clientCfg.HTTPClientConfig.BasicAuth.Password = config_util.Secret(password)
+		}
+	}
+
+	return clientCfg
+}
+
+type mirrorRequest struct {
+	userID string
+	data   []byte
+}
+
+// writeMirror mirrors a per-tenant ratio of the written series to a secondary remote write endpoint.
+// The series selected by the ratio are copied into a write request queued to be compressed and sent by
+// a pool of workers, so that the mirroring neither retains the write requests of the write path nor adds
+// the latency of the endpoint to it: the requests are dropped when the queue is full, and the failures
+// are only tracked and logged.
+type writeMirror struct {
+	services.Service
+
+	cfg    MirrorConfig
+	limits *validation.Overrides
+	logger log.Logger
+	queue  chan mirrorRequest
+
+	clientsMu sync.Mutex
+	clients   map[string]remote.WriteClient
+
+	mirroredSeries *prometheus.CounterVec
+	requests       *prometheus.CounterVec
+}
+
+func newWriteMirror(cfg MirrorConfig, limits *validation.Overrides, reg prometheus.Registerer, logger log.Logger) *writeMirror {
+	m := &writeMirror{
+		cfg:     cfg,
+		limits:  limits,
+		logger:  logger,
+		queue:   make(chan mirrorRequest, cfg.QueueCapacity),
+		clients: map[string]remote.WriteClient{},
+		mirroredSeries: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Namespace: "cortex",
+			Name:      "distributor_mirror_series_total",
+			Help:      "The total number of series selected to be mirrored to the secondary remote write endpoint.",
+		}, []string{"user"}),
+		requests: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Namespace: "cortex",
+			Name:      "distributor_mirror_requests_total",
+			Help:      "The total number of write requests mirrored to the secondary remote write endpoint, by outcome.",
+		}, []string{"user", "outcome"}),
+	}
+
+	m.Service = services.NewBasicService(nil, m.running, nil)
+	return m
+}
+
+func (m *writeMirror) running(ctx context.Context) error {
+	wg := sync.WaitGroup{}
+	for range m.cfg.Concurrency {
+		wg.Go(func() {
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case req := <-m.queue:
+					m.send(ctx, req.userID, snappy.Encode(nil, req.data))
+				}
+			}
+		})
+	}
+	wg.Wait()
+	return nil
+}
+
+// mirror queues the series of the user selected by its mirror writes ratio to be mirrored, if any. The
+// selected series are copied, so the write request can be reused as soon as the write path is done with it.
+func (m *writeMirror) mirror(userID string, series []cortexpb.PreallocTimeseries, source cortexpb.SourceEnum) {
+	ratio := m.limits.MirrorWritesRatio(userID)
+	if ratio <= 0 {
+		return
+	}
+
+	// Don't copy the series if the request would be dropped anyway.
+	if len(m.queue) == cap(m.queue) {
+		m.requests.WithLabelValues(userID, mirrorOutcomeDropped).Inc()
+		return
+	}
+
+	writeReq := cortexpb.WriteRequest{Source: source}
+	for _, ts := range series {
+		if shouldMirrorSeries(ts.Labels, ratio) {
+			writeReq.Timeseries = append(writeReq.Timeseries, ts)
+		}
+	}
+	if len(writeReq.Timeseries) == 0 {
+		return
+	}
+
+	data, err := writeReq.Marshal()
+	if err != nil {
+		m.requests.WithLabelValues(userID, mirrorOutcomeFailure).Inc()
+		level.Warn(util_log.WithUserID(userID, m.logger)).Log("msg", "failed to marshal the mirrored write request", "err", err)
+		return
+	}
+
+	select {
+	case m.queue <- mirrorRequest{userID: userID, data: data}:
+		m.mirroredSeries.WithLabelValues(userID).Add(float64(len(writeReq.Timeseries)))
+	default:
+		m.requests.WithLabelValues(userID, mirrorOutcomeDropped).Inc()
+	}
+}
+
+func (m *writeMirror) send(ctx context.Context, userID string, data []byte) {
+	client, err := m.client(userID)
+	if err == nil {
+		_, err = client.Store(ctx, data, 0)
+	}
+	if err != nil {
+		m.requests.WithLabelValues(userID, mirrorOutcomeFailure).Inc()
+		level.Warn(util_log.WithUserID(userID, m.logger)).Log("msg", "failed to mirror the write request", "err", err)
+		return
+	}
+	m.requests.WithLabelValues(userID, mirrorOutcomeSuccess).Inc()
+}
+
+// client returns the remote write client of the user, sending the user tenant ID.
+func (m *writeMirror) client(userID string) (remote.WriteClient, error) {
+	m.clientsMu.Lock()
+	defer m.clientsMu.Unlock()
+
+	if client, ok := m.clients[userID]; ok {
+		return client, nil
+	}
+	client, err := remote.NewWriteClient("distributor-mirror-"+userID, m.cfg.clientConfig(userID))
+	if err != nil {
+		return nil, err
+	}
+	m.clients[userID] = client
+	return client, nil
+}
+
+func (m *writeMirror) cleanupUser(userID string) {
+	m.clientsMu.Lock()
+	delete(m.clients, userID)
+	m.clientsMu.Unlock()
+
+	m.mirroredSeries.DeleteLabelValues(userID)
+	if err := util.DeleteMatchingLabels(m.requests, map[string]string{"user": userID}); err != nil {
+		level.Warn(m.logger).Log("msg", "failed to remove cortex_distributor_mirror_requests_total metric for user", "user", userID, "err", err)
+	}
+}
+
+// shouldMirrorSeries returns whether the series is selected by the mirror ratio. The selection is based on
+// the hash of the series labels, so that a series is consistently mirrored or not.
+func shouldMirrorSeries(lbls []cortexpb.LabelAdapter, ratio float64) bool {
+	return float64(cortexpb.FromLabelAdaptersToLabels(lbls).Hash()) < ratio*math.MaxUint64
+}
@@ -0,0 +1,201 @@
+package distributor
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/golang/snappy"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cortexproject/cortex/pkg/cortexpb"
+	"github.com/cortexproject/cortex/pkg/util/flagext"
+	"github.com/cortexproject/cortex/pkg/util/services"
+	"github.com/cortexproject/cortex/pkg/util/test"
+	"github.com/cortexproject/cortex/pkg/util/validation"
+)
+
+func TestShouldMirrorSeries(t *testing.T) {
+	series := make([][]cortexpb.LabelAdapter, 0, 1000)
+	for i := range 1000 {
+		series = append(series, cortexpb.FromLabelsToLabelAdapters(labels.FromStrings("__name__", "foo", "i", fmt.Sprint(i))))
+	}
+
+	count := func(ratio float64) int {
+		n := 0
+		for _, lbls := range series {
+			if shouldMirrorSeries(lbls, ratio) {
+				n++
+			}
+		}
+		return n
+	}
+
+	assert.Equal(t, 0, count(0))
+	assert.Equal(t, len(series), count(1))
+	assert.InDelta(t, 250, count(0.25), 50)
+
+	// The selection is deterministic, and the series selected by a ratio are also selected by a greater one.
+	for _, lbls := range series {
+		assert.Equal(t, shouldMirrorSeries(lbls, 0.25), shouldMirrorSeries(lbls, 0.25))
+		if shouldMirrorSeries(lbls, 0.25) {
+			assert.True(t, shouldMirrorSeries(lbls, 0.5))
+		}
+	}
+}
+
+func TestWriteMirror(t *testing.T) {
+	var (
+		mtx      sync.Mutex
+		received = map[string][]cortexpb.PreallocTimeseries{}
+		fail     bool
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mtx.Lock()
+		defer mtx.Unlock()
+		if fail {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		compressed, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		data, err := snappy.Decode(nil, compressed)
+		require.NoError(t, err)
+		req := cortexpb.WriteRequest{}
+		require.NoError(t, req.Unmarshal(data))
+
+		userID := r.Header.Get("X-Scope-OrgID")
+		received[userID] = append(received[userID], req.Timeseries...)
+	}))
+	t.Cleanup(server.Close)
+
+	cfg := MirrorConfig{}
+	flagext.DefaultValues(&cfg)
+	serverURL, err := url.Parse(server.URL)
+	require.NoError(t, err)
+	cfg.URL.URL = serverURL
+	require.NoError(t, cfg.Validate())
+
+	limits := validation.Limits{}
+	flagext.DefaultValues(&limits)
+	user1Limits, user2Limits := limits, limits
+	user1Limits.MirrorWritesRatio = 1
+	user2Limits.MirrorWritesRatio = 0.5
+	overrides := validation.NewOverrides(limits, mockTenantLimits{"user-1": &user1Limits, "user-2": &user2Limits})
+
+	reg := prometheus.NewPedanticRegistry()
+	m := newWriteMirror(cfg, overrides, reg, log.NewNopLogger())
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), m))
+	t.Cleanup(func() {
+		require.NoError(t, services.StopAndAwaitTerminated(context.Background(), m))
+	})
+
+	series := make([]cortexpb.PreallocTimeseries, 0, 100)
+	for i := range 100 {
+		series = append(series, cortexpb.PreallocTimeseries{TimeSeries: &cortexpb.TimeSeries{
+			Labels:     cortexpb.FromLabelsToLabelAdapters(labels.FromStrings("__name__", "foo", "i", fmt.Sprint(i))),
+			Samples:    []cortexpb.Sample{{TimestampMs: 1000, Value: float64(i)}},
+			Exemplars:  []cortexpb.Exemplar{},
+			Histograms: []cortexpb.WrappedHistogram{},
+		}})
+	}
+
+	for _, userID := range []string{"user-1", "user-2", "user-3"} {
+		m.mirror(userID, series, cortexpb.API)
+	}
+
+	test.Poll(t, time.Second, 2, func() any {
+		mtx.Lock()
+		defer mtx.Unlock()
+		return len(received)
+	})
+
+	mtx.Lock()
+	// All the series of user-1 are mirrored, user-3 isn't mirrored.
+	assert.Equal(t, series, received["user-1"])
+	assert.NotContains(t, received, "user-3")
+
+	// Only the series of user-2 selected by the ratio are mirrored.
+	expected := []cortexpb.PreallocTimeseries(nil)
+	for _, ts := range series {
+		if shouldMirrorSeries(ts.Labels, 0.5) {
+			expected = append(expected, ts)
+		}
+	}
+	assert.Equal(t, expected, received["user-2"])
+	fail = true
+	mtx.Unlock()
+
+	// The mirroring failures are counted.
+	m.mirror("user-1", series, cortexpb.API)
+	test.Poll(t, time.Second, 1.0, func() any {
+		return testutil.ToFloat64(m.requests.WithLabelValues("user-1", mirrorOutcomeFailure))
+	})
+
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(fmt.Sprintf(`
+		# HELP cortex_distributor_mirror_requests_total The total number of write requests mirrored to the secondary remote write endpoint, by outcome.
+		# TYPE cortex_distributor_mirror_requests_total counter
+		cortex_distributor_mirror_requests_total{outcome="failure",user="user-1"} 1
+		cortex_distributor_mirror_requests_total{outcome="success",user="user-1"} 1
+		cortex_distributor_mirror_requests_total{outcome="success",user="user-2"} 1
+		# HELP cortex_distributor_mirror_series_total The total number of series selected to be mirrored to the secondary remote write endpoint.
+		# TYPE cortex_distributor_mirror_series_total counter
+		cortex_distributor_mirror_series_total{user="user-1"} 200
+		cortex_distributor_mirror_series_total{user="user-2"} %d
+	`, len(expected))), "cortex_distributor_mirror_requests_total", "cortex_distributor_mirror_series_total"))
+}
+
+func TestWriteMirror_ShouldDropRequestsWhenQueueIsFull(t *testing.T) {
+	cfg := MirrorConfig{}
+	flagext.DefaultValues(&cfg)
+	cfg.QueueCapacity = 1
+	cfg.URL.URL = &url.URL{Scheme: "http", Host: "localhost"}
+
+	limits := validation.Limits{}
+	flagext.DefaultValues(&limits)
+	limits.MirrorWritesRatio = 1
+
+	// The mirror isn't started, so the queued requests are never sent.
+	m := newWriteMirror(cfg, validation.NewOverrides(limits, nil), nil, log.NewNopLogger())
+	series := []cortexpb.PreallocTimeseries{{TimeSeries: &cortexpb.TimeSeries{
+		Labels:  cortexpb.FromLabelsToLabelAdapters(labels.FromStrings("__name__", "foo")),
+		Samples: []cortexpb.Sample{{TimestampMs: 1000, Value: 1}},
+	}}}
+	m.mirror("user-1", series, cortexpb.API)
+	m.mirror("user-1", series, cortexpb.API)
+
+	assert.Len(t, m.queue, 1)
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.requests.WithLabelValues("user-1", mirrorOutcomeDropped)))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.mirroredSeries.WithLabelValues("user-1")))
+
+	// The queued request is a copy of the mirrored series, so the write request can be reused meanwhile.
+	series[0].Samples[0].Value = 2
+	req := cortexpb.WriteRequest{}
+	require.NoError(t, req.Unmarshal((<-m.queue).data))
+	require.Len(t, req.Timeseries, 1)
+	assert.Equal(t, []cortexpb.Sample{{TimestampMs: 1000, Value: 1}}, req.Timeseries[0].Samples)
+}
+
+// mockTenantLimits exposes per-tenant limits based on a provided map.
+type mockTenantLimits map[string]*validation.Limits
+
+func (l mockTenantLimits) ByUserID(userID string) *validation.Limits {
+	return l[userID]
+}
+
+func (l mockTenantLimits) AllByUserID() map[string]*validation.Limits {
+	return l
+}
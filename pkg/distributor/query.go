@@ -194,8 +194,10 @@ func mergeExemplarQueryResponses(results []any) *ingester_client.ExemplarQueryRe
 	var keys []string
 	exemplarResults := make(map[string]cortexpb.TimeSeries)
 	buf := make([]byte, 0, 1024)
+	truncated := false
 	for _, result := range results {
 		r := result.(*ingester_client.ExemplarQueryResponse)
+		truncated = truncated || r.Truncated
 		for _, ts := range r.Timeseries {
 			lbls := string(cortexpb.FromLabelAdaptersToLabels(ts.Labels).Bytes(buf))
 			e, ok := exemplarResults[lbls]
@@ -218,7 +220,7 @@ func mergeExemplarQueryResponses(results []any) *ingester_client.ExemplarQueryRe
 		result[i] = exemplarResults[k]
 	}
 
-	return &ingester_client.ExemplarQueryResponse{Timeseries: result}
+	return &ingester_client.ExemplarQueryResponse{Timeseries: result, Truncated: truncated}
 }
 
 // queryIngesterStream queries the ingesters using the new streaming API.
@@ -87,3 +87,20 @@ func TestMergeExemplars(t *testing.T) {
 		})
 	}
 }
+
+func TestMergeExemplars_ShouldPropagateTruncation(t *testing.T) {
+	t.Parallel()
+	series := []cortexpb.TimeSeries{{Labels: []cortexpb.LabelAdapter{{Name: "label1", Value: "foo1"}}}}
+
+	e := mergeExemplarQueryResponses([]any{
+		&ingester_client.ExemplarQueryResponse{Timeseries: series},
+		&ingester_client.ExemplarQueryResponse{Timeseries: series},
+	})
+	require.False(t, e.Truncated)
+
+	e = mergeExemplarQueryResponses([]any{
+		&ingester_client.ExemplarQueryResponse{Timeseries: series},
+		&ingester_client.ExemplarQueryResponse{Timeseries: series, Truncated: true},
+	})
+	require.True(t, e.Truncated)
+}
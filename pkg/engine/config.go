@@ -13,11 +13,12 @@ var supportedOptimizers = []string{"default", "all", "propagate-matchers", "sort
 
 // ThanosEngineConfig contains the configuration to create engine.
 type ThanosEngineConfig struct {
-	Enabled             bool                    `yaml:"enabled"`
-	EnableXFunctions    bool                    `yaml:"enable_x_functions"`
-	Optimizers          string                  `yaml:"optimizers"`
-	DecodingConcurrency int                     `yaml:"decoding_concurrency"`
-	LogicalOptimizers   []logicalplan.Optimizer `yaml:"-"`
+	Enabled                    bool                    `yaml:"enabled"`
+	EnableXFunctions           bool                    `yaml:"enable_x_functions"`
+	Optimizers                 string                  `yaml:"optimizers"`
+	DecodingConcurrency        int                     `yaml:"decoding_concurrency"`
+	FallbackToPrometheusEngine bool                    `yaml:"fallback_to_prometheus_engine"`
+	LogicalOptimizers          []logicalplan.Optimizer `yaml:"-"`
 }
 
 func (cfg *ThanosEngineConfig) RegisterFlagsWithPrefix(prefix string, f *flag.FlagSet) {
@@ -25,6 +26,7 @@ func (cfg *ThanosEngineConfig) RegisterFlagsWithPrefix(prefix string, f *flag.Fl
 	f.BoolVar(&cfg.EnableXFunctions, prefix+"enable-x-functions", false, "Enable xincrease, xdelta, xrate etc from Thanos engine.")
 	f.StringVar(&cfg.Optimizers, prefix+"optimizers", "default", "Logical plan optimizers. Multiple optimizers can be provided as a comma-separated list. Supported values: "+strings.Join(supportedOptimizers, ", "))
 	f.IntVar(&cfg.DecodingConcurrency, prefix+"decoding-concurrency", 0, "Maximum number of goroutines that can be used to decode samples. 0 defaults to GOMAXPROCS / 2.")
+	f.BoolVar(&cfg.FallbackToPrometheusEngine, prefix+"fallback-to-prometheus-engine", true, "Experimental. Evaluate the queries not supported by the Thanos promql engine with the Prometheus promql engine, instead of failing them.")
 }
 
 func (cfg *ThanosEngineConfig) Validate() error {
@@ -11,6 +11,9 @@ import (
 	"github.com/prometheus/prometheus/storage"
 	thanosengine "github.com/thanos-io/promql-engine/engine"
 	"github.com/thanos-io/promql-engine/logicalplan"
+
+	"github.com/cortexproject/cortex/pkg/querier/stats"
+	"github.com/cortexproject/cortex/pkg/util/users"
 )
 
 type engineKeyType struct{}
@@ -50,31 +53,48 @@ type QueryEngine interface {
 	MakeRangeQueryFromPlan(ctx context.Context, q storage.Queryable, opts promql.QueryOpts, root logicalplan.Node, start time.Time, end time.Time, interval time.Duration, qs string) (promql.Query, error)
 }
 
+// Limits is the per-tenant configuration of the engine.
+type Limits interface {
+	// QueryEngine returns the engine evaluating the queries of the user, or an empty string to use
+	// the engine selected by the configuration.
+	QueryEngine(userID string) string
+}
+
 type Engine struct {
 	prometheusEngine *promql.Engine
 	thanosEngine     *thanosengine.Engine
 
+	// Whether the Thanos engine is used by default, and whether the queries not supported by
+	// the Thanos engine fall back to the Prometheus engine.
+	thanosEngineEnabled bool
+	fallbackEnabled     bool
+	limits              Limits
+
 	fallbackQueriesTotal     prometheus.Counter
 	engineSwitchQueriesTotal *prometheus.CounterVec
+	queriesTotal             *prometheus.CounterVec
 }
 
-func New(opts promql.EngineOpts, thanosEngineCfg ThanosEngineConfig, reg prometheus.Registerer) *Engine {
+// New returns an Engine evaluating the queries with the Thanos engine if enabled by the config or
+// by the per-tenant limits, and with the Prometheus engine otherwise. The limits may be nil.
+func New(opts promql.EngineOpts, thanosEngineCfg ThanosEngineConfig, limits Limits, reg prometheus.Registerer) *Engine {
 	prometheusEngine := promql.NewEngine(opts)
 
-	var thanosEngine *thanosengine.Engine
-	if thanosEngineCfg.Enabled {
-		thanosEngine = thanosengine.New(thanosengine.Opts{
-			EngineOpts:          opts,
-			LogicalOptimizers:   thanosEngineCfg.LogicalOptimizers,
-			EnableAnalysis:      true,
-			EnableXFunctions:    thanosEngineCfg.EnableXFunctions,
-			DecodingConcurrency: thanosEngineCfg.DecodingConcurrency,
-		})
-	}
+	// The Thanos engine is always created, since it can be enabled per tenant.
+	thanosEngine := thanosengine.New(thanosengine.Opts{
+		EngineOpts:          opts,
+		LogicalOptimizers:   thanosEngineCfg.LogicalOptimizers,
+		EnableAnalysis:      true,
+		EnableXFunctions:    thanosEngineCfg.EnableXFunctions,
+		DecodingConcurrency: thanosEngineCfg.DecodingConcurrency,
+	})
 
 	return &Engine{
-		prometheusEngine: prometheusEngine,
-		thanosEngine:     thanosEngine,
+		prometheusEngine:    prometheusEngine,
+		thanosEngine:        thanosEngine,
+		thanosEngineEnabled: thanosEngineCfg.Enabled,
+		fallbackEnabled:     thanosEngineCfg.FallbackToPrometheusEngine,
+		limits:              limits,
 		fallbackQueriesTotal: promauto.With(reg).NewCounter(prometheus.CounterOpts{
 			Name: "cortex_thanos_engine_fallback_queries_total",
 			Help: "Total number of fallback queries due to not implementation in thanos engine",
@@ -83,103 +103,116 @@ func New(opts promql.EngineOpts, thanosEngineCfg ThanosEngineConfig, reg prometh
 			Name: "cortex_engine_switch_queries_total",
 			Help: "Total number of queries where engine_type is set explicitly",
 		}, []string{"engine_type"}),
+		queriesTotal: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_engine_queries_total",
+			Help: "Total number of queries by engine evaluating them.",
+		}, []string{"engine_type"}),
 	}
 }
 
 func (qf *Engine) NewInstantQuery(ctx context.Context, q storage.Queryable, opts promql.QueryOpts, qs string, ts time.Time) (promql.Query, error) {
-	if engineType := GetEngineType(ctx); engineType == Prometheus {
-		qf.engineSwitchQueriesTotal.WithLabelValues(string(Prometheus)).Inc()
-		goto prom
-	} else if engineType == Thanos {
-		qf.engineSwitchQueriesTotal.WithLabelValues(string(Thanos)).Inc()
-	}
-
-	if qf.thanosEngine != nil {
-		res, err := qf.thanosEngine.MakeInstantQuery(ctx, q, fromPromQLOpts(opts), qs, ts)
-		if err != nil {
-			if thanosengine.IsUnimplemented(err) {
-				// fallback to use prometheus engine
-				qf.fallbackQueriesTotal.Inc()
-				goto prom
-			}
-			return nil, err
-		}
-		return res, nil
-	}
+	useThanos := qf.countEngineSwitch(ctx) != Prometheus && qf.thanosEngineEnabledFor(ctx)
 
-prom:
-	return qf.prometheusEngine.NewInstantQuery(ctx, q, opts, qs, ts)
+	return qf.newQuery(ctx, useThanos, func() (promql.Query, error) {
+		return qf.thanosEngine.MakeInstantQuery(ctx, q, fromPromQLOpts(opts), qs, ts)
+	}, func() (promql.Query, error) {
+		return qf.prometheusEngine.NewInstantQuery(ctx, q, opts, qs, ts)
+	})
 }
 
 func (qf *Engine) NewRangeQuery(ctx context.Context, q storage.Queryable, opts promql.QueryOpts, qs string, start, end time.Time, interval time.Duration) (promql.Query, error) {
-	if engineType := GetEngineType(ctx); engineType == Prometheus {
-		qf.engineSwitchQueriesTotal.WithLabelValues(string(Prometheus)).Inc()
-		goto prom
-	} else if engineType == Thanos {
-		qf.engineSwitchQueriesTotal.WithLabelValues(string(Thanos)).Inc()
-	}
-	if qf.thanosEngine != nil {
-		res, err := qf.thanosEngine.MakeRangeQuery(ctx, q, fromPromQLOpts(opts), qs, start, end, interval)
-		if err != nil {
-			if thanosengine.IsUnimplemented(err) {
-				// fallback to use prometheus engine
-				qf.fallbackQueriesTotal.Inc()
-				goto prom
-			}
-			return nil, err
-		}
-		return res, nil
-	}
+	useThanos := qf.countEngineSwitch(ctx) != Prometheus && qf.thanosEngineEnabledFor(ctx)
 
-prom:
-	return qf.prometheusEngine.NewRangeQuery(ctx, q, opts, qs, start, end, interval)
+	return qf.newQuery(ctx, useThanos, func() (promql.Query, error) {
+		return qf.thanosEngine.MakeRangeQuery(ctx, q, fromPromQLOpts(opts), qs, start, end, interval)
+	}, func() (promql.Query, error) {
+		return qf.prometheusEngine.NewRangeQuery(ctx, q, opts, qs, start, end, interval)
+	})
 }
 
 func (qf *Engine) MakeInstantQueryFromPlan(ctx context.Context, q storage.Queryable, opts promql.QueryOpts, root logicalplan.Node, ts time.Time, qs string) (promql.Query, error) {
-	if engineType := GetEngineType(ctx); engineType == Prometheus {
-		qf.engineSwitchQueriesTotal.WithLabelValues(string(Prometheus)).Inc()
-	} else if engineType == Thanos {
-		qf.engineSwitchQueriesTotal.WithLabelValues(string(Thanos)).Inc()
-	}
+	qf.countEngineSwitch(ctx)
+
+	return qf.newQuery(ctx, qf.thanosEngineEnabledFor(ctx), func() (promql.Query, error) {
+		return qf.thanosEngine.MakeInstantQueryFromPlan(ctx, q, fromPromQLOpts(opts), root, ts)
+	}, func() (promql.Query, error) {
+		return qf.prometheusEngine.NewInstantQuery(ctx, q, opts, qs, ts)
+	})
+}
 
-	if qf.thanosEngine != nil {
-		res, err := qf.thanosEngine.MakeInstantQueryFromPlan(ctx, q, fromPromQLOpts(opts), root, ts)
-		if err != nil {
-			if thanosengine.IsUnimplemented(err) {
-				// fallback to use prometheus engine
-				qf.fallbackQueriesTotal.Inc()
-				goto prom
-			}
+func (qf *Engine) MakeRangeQueryFromPlan(ctx context.Context, q storage.Queryable, opts promql.QueryOpts, root logicalplan.Node, start time.Time, end time.Time, interval time.Duration, qs string) (promql.Query, error) {
+	qf.countEngineSwitch(ctx)
+
+	return qf.newQuery(ctx, qf.thanosEngineEnabledFor(ctx), func() (promql.Query, error) {
+		return qf.thanosEngine.MakeRangeQueryFromPlan(ctx, q, fromPromQLOpts(opts), root, start, end, interval)
+	}, func() (promql.Query, error) {
+		return qf.prometheusEngine.NewRangeQuery(ctx, q, opts, qs, start, end, interval)
+	})
+}
+
+// newQuery returns the query made by the Thanos engine if useThanos is true, falling back to the
+// Prometheus engine if the query is not supported by the Thanos engine and the fallback is enabled.
+// The engine evaluating the query is reported in the query stats.
+func (qf *Engine) newQuery(ctx context.Context, useThanos bool, thanosQuery, prometheusQuery func() (promql.Query, error)) (promql.Query, error) {
+	if useThanos {
+		res, err := thanosQuery()
+		if err == nil {
+			qf.recordEngine(ctx, Thanos, false)
+			return res, nil
+		}
+		if !thanosengine.IsUnimplemented(err) || !qf.fallbackEnabled {
 			return nil, err
 		}
-		return res, nil
+		// fallback to use prometheus engine
+		qf.fallbackQueriesTotal.Inc()
+		qf.recordEngine(ctx, Prometheus, true)
+		return prometheusQuery()
 	}
 
-prom:
-	return qf.prometheusEngine.NewInstantQuery(ctx, q, opts, qs, ts)
+	qf.recordEngine(ctx, Prometheus, false)
+	return prometheusQuery()
 }
 
-func (qf *Engine) MakeRangeQueryFromPlan(ctx context.Context, q storage.Queryable, opts promql.QueryOpts, root logicalplan.Node, start time.Time, end time.Time, interval time.Duration, qs string) (promql.Query, error) {
-	if engineType := GetEngineType(ctx); engineType == Prometheus {
-		qf.engineSwitchQueriesTotal.WithLabelValues(string(Prometheus)).Inc()
-	} else if engineType == Thanos {
-		qf.engineSwitchQueriesTotal.WithLabelValues(string(Thanos)).Inc()
+// countEngineSwitch tracks the queries whose engine type is set explicitly, and returns it.
+func (qf *Engine) countEngineSwitch(ctx context.Context) Type {
+	engineType := GetEngineType(ctx)
+	if engineType == Prometheus || engineType == Thanos {
+		qf.engineSwitchQueriesTotal.WithLabelValues(string(engineType)).Inc()
 	}
-	if qf.thanosEngine != nil {
-		res, err := qf.thanosEngine.MakeRangeQueryFromPlan(ctx, q, fromPromQLOpts(opts), root, start, end, interval)
-		if err != nil {
-			if thanosengine.IsUnimplemented(err) {
-				// fallback to use prometheus engine
-				qf.fallbackQueriesTotal.Inc()
-				goto prom
-			}
-			return nil, err
-		}
-		return res, nil
+	return engineType
+}
+
+// thanosEngineEnabledFor returns whether the Thanos engine is enabled for the tenant of the query,
+// either by its limits or by the config.
+func (qf *Engine) thanosEngineEnabledFor(ctx context.Context) bool {
+	if qf.limits == nil {
+		return qf.thanosEngineEnabled
+	}
+
+	// The queries spanning multiple tenants use the engine selected by the config.
+	tenantIDs, err := users.TenantIDs(ctx)
+	if err != nil || len(tenantIDs) != 1 {
+		return qf.thanosEngineEnabled
 	}
 
-prom:
-	return qf.prometheusEngine.NewRangeQuery(ctx, q, opts, qs, start, end, interval)
+	switch Type(qf.limits.QueryEngine(tenantIDs[0])) {
+	case Prometheus:
+		return false
+	case Thanos:
+		return true
+	default:
+		return qf.thanosEngineEnabled
+	}
+}
+
+func (qf *Engine) recordEngine(ctx context.Context, engineType Type, fallback bool) {
+	qf.queriesTotal.WithLabelValues(string(engineType)).Inc()
+
+	queryStats := stats.FromContext(ctx)
+	queryStats.AddExtraFields("query_engine", string(engineType))
+	if fallback {
+		queryStats.AddExtraFields("query_engine_fallback", true)
+	}
 }
 
 func fromPromQLOpts(opts promql.QueryOpts) *thanosengine.QueryOpts {
@@ -14,12 +14,15 @@ import (
 	"github.com/prometheus/prometheus/promql"
 	"github.com/prometheus/prometheus/promql/parser"
 	"github.com/prometheus/prometheus/promql/promqltest"
+	thanosengine "github.com/thanos-io/promql-engine/engine"
 	"github.com/thanos-io/promql-engine/execution/parse"
 	"github.com/thanos-io/promql-engine/logicalplan"
 	"github.com/thanos-io/promql-engine/query"
 
 	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
 
+	"github.com/cortexproject/cortex/pkg/querier/stats"
 	utillog "github.com/cortexproject/cortex/pkg/util/log"
 )
 
@@ -42,7 +45,7 @@ func TestEngine_Fallback(t *testing.T) {
 		Logger: utillog.GoKitLogToSlog(log.NewNopLogger()),
 		Reg:    reg,
 	}
-	queryEngine := New(opts, ThanosEngineConfig{Enabled: true}, reg)
+	queryEngine := New(opts, ThanosEngineConfig{Enabled: true, FallbackToPrometheusEngine: true}, nil, reg)
 
 	// instant query, should go to fallback
 	_, _ = queryEngine.NewInstantQuery(ctx, queryable, nil, "unimplemented(foo)", now)
@@ -73,7 +76,7 @@ func TestEngine_Switch(t *testing.T) {
 		Logger: utillog.GoKitLogToSlog(log.NewNopLogger()),
 		Reg:    reg,
 	}
-	queryEngine := New(opts, ThanosEngineConfig{Enabled: true}, reg)
+	queryEngine := New(opts, ThanosEngineConfig{Enabled: true, FallbackToPrometheusEngine: true}, nil, reg)
 
 	// Query Prometheus engine
 	r := &http.Request{Header: http.Header{}}
@@ -114,7 +117,7 @@ func TestEngine_XFunctions(t *testing.T) {
 		Logger: utillog.GoKitLogToSlog(log.NewNopLogger()),
 		Reg:    reg,
 	}
-	queryEngine := New(opts, ThanosEngineConfig{Enabled: true, EnableXFunctions: true}, reg)
+	queryEngine := New(opts, ThanosEngineConfig{Enabled: true, EnableXFunctions: true}, nil, reg)
 
 	for name := range parse.XFunctions {
 		t.Run(name, func(t *testing.T) {
@@ -139,7 +142,7 @@ func TestEngine_With_Logical_Plan(t *testing.T) {
 		Logger: utillog.GoKitLogToSlog(log.NewNopLogger()),
 		Reg:    reg,
 	}
-	queryEngine := New(opts, ThanosEngineConfig{Enabled: true}, reg)
+	queryEngine := New(opts, ThanosEngineConfig{Enabled: true, FallbackToPrometheusEngine: true}, nil, reg)
 
 	range_lp := createTestLogicalPlan(t, start, now, step, "up")
 	instant_lp := createTestLogicalPlan(t, now, now, 0, "up")
@@ -193,6 +196,82 @@ func TestEngine_With_Logical_Plan(t *testing.T) {
 	`), "cortex_engine_switch_queries_total"))
 }
 
+func TestEngine_FallbackDisabled(t *testing.T) {
+	// add unimplemented function
+	parser.Functions["unimplemented"] = &parser.Function{
+		Name:       "unimplemented",
+		ArgTypes:   []parser.ValueType{parser.ValueTypeVector},
+		ReturnType: parser.ValueTypeVector,
+	}
+
+	reg := prometheus.NewRegistry()
+	queryable := promqltest.LoadedStorage(t, "")
+	opts := promql.EngineOpts{
+		Logger: utillog.GoKitLogToSlog(log.NewNopLogger()),
+		Reg:    reg,
+	}
+	queryEngine := New(opts, ThanosEngineConfig{Enabled: true}, nil, reg)
+
+	_, err := queryEngine.NewInstantQuery(context.Background(), queryable, nil, "unimplemented(foo)", time.Now())
+	require.True(t, thanosengine.IsUnimplemented(err))
+	require.NoError(t, testutil.GatherAndCompare(reg, bytes.NewBufferString(`
+		# HELP cortex_thanos_engine_fallback_queries_total Total number of fallback queries due to not implementation in thanos engine
+		# TYPE cortex_thanos_engine_fallback_queries_total counter
+		cortex_thanos_engine_fallback_queries_total 0
+	`), "cortex_thanos_engine_fallback_queries_total"))
+}
+
+func TestEngine_PerTenantEngine(t *testing.T) {
+	// add unimplemented function
+	parser.Functions["unimplemented"] = &parser.Function{
+		Name:       "unimplemented",
+		ArgTypes:   []parser.ValueType{parser.ValueTypeVector},
+		ReturnType: parser.ValueTypeVector,
+	}
+
+	queryable := promqltest.LoadedStorage(t, "")
+	limits := mockLimits{"user-prometheus": string(Prometheus), "user-thanos": string(Thanos)}
+
+	for _, thanosEnabled := range []bool{false, true} {
+		t.Run(fmt.Sprintf("thanos engine enabled: %v", thanosEnabled), func(t *testing.T) {
+			reg := prometheus.NewRegistry()
+			opts := promql.EngineOpts{
+				Logger: utillog.GoKitLogToSlog(log.NewNopLogger()),
+				Reg:    reg,
+			}
+			queryEngine := New(opts, ThanosEngineConfig{Enabled: thanosEnabled, FallbackToPrometheusEngine: true}, limits, reg)
+
+			expected := map[string]string{
+				"user-prometheus": string(Prometheus),
+				"user-thanos":     string(Thanos),
+				"user-default":    string(Prometheus),
+			}
+			if thanosEnabled {
+				expected["user-default"] = string(Thanos)
+			}
+
+			for userID, expectedEngine := range expected {
+				queryStats, ctx := stats.ContextWithEmptyStats(user.InjectOrgID(context.Background(), userID))
+				_, err := queryEngine.NewInstantQuery(ctx, queryable, nil, "foo", time.Now())
+				require.NoError(t, err)
+				require.Equal(t, map[string]string{"query_engine": expectedEngine}, queryStats.ExtraFields, userID)
+			}
+
+			// The fallback to the Prometheus engine is reported in the query stats.
+			queryStats, ctx := stats.ContextWithEmptyStats(user.InjectOrgID(context.Background(), "user-thanos"))
+			_, err := queryEngine.NewInstantQuery(ctx, queryable, nil, "unimplemented(foo)", time.Now())
+			require.NoError(t, err)
+			require.Equal(t, map[string]string{"query_engine": string(Prometheus), "query_engine_fallback": "true"}, queryStats.ExtraFields)
+		})
+	}
+}
+
+type mockLimits map[string]string
+
+func (m mockLimits) QueryEngine(userID string) string {
+	return m[userID]
+}
+
 func createTestLogicalPlan(t *testing.T, startTime time.Time, endTime time.Time, step time.Duration, q string) logicalplan.Plan {
 
 	qOpts := query.Options{
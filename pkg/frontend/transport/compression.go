@@ -0,0 +1,136 @@
+package transport
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zstd"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	gzipEncoding = "gzip"
+	zstdEncoding = "zstd"
+)
+
+var supportedResponseEncodings = []string{zstdEncoding, gzipEncoding}
+
+// responseCompressor compresses the query responses with the encoding negotiated with
+// the Accept-Encoding header of the request, among the configured ones.
+type responseCompressor struct {
+	encodings []string
+	minSize   int
+
+	zstdEncoder *zstd.Encoder
+
+	compressedResponses *prometheus.CounterVec
+	savedBytes          *prometheus.CounterVec
+}
+
+func newResponseCompressor(encodings []string, minSize int, compressedResponses, savedBytes *prometheus.CounterVec) (*responseCompressor, error) {
+	c := &responseCompressor{
+		encodings:           encodings,
+		minSize:             minSize,
+		compressedResponses: compressedResponses,
+		savedBytes:          savedBytes,
+	}
+
+	if slices.Contains(encodings, zstdEncoding) {
+		// The encoder is only used with EncodeAll, which is safe for concurrent use.
+		enc, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedDefault))
+		if err != nil {
+			return nil, err
+		}
+		c.zstdEncoder = enc
+	}
+	return c, nil
+}
+
+// compress returns the body compressed with the encoding negotiated with the request, and the
+// encoding used. The body is returned as is, with an empty encoding, when it's smaller than the
+// minimum size, no encoding can be negotiated or the compression doesn't reduce its size.
+func (c *responseCompressor) compress(r *http.Request, body []byte) ([]byte, string) {
+	if len(body) < c.minSize {
+		return body, ""
+	}
+
+	encoding := negotiateEncoding(r.Header.Get("Accept-Encoding"), c.encodings)
+	if encoding == "" {
+		return body, ""
+	}
+
+	var compressed []byte
+	switch encoding {
+	case zstdEncoding:
+		compressed = c.zstdEncoder.EncodeAll(body, make([]byte, 0, len(body)/2))
+	case gzipEncoding:
+		buf := bytes.NewBuffer(make([]byte, 0, len(body)/2))
+		w := gzip.NewWriter(buf)
+		if _, err := w.Write(body); err != nil {
+			return body, ""
+		}
+		if err := w.Close(); err != nil {
+			return body, ""
+		}
+		compressed = buf.Bytes()
+	}
+
+	if len(compressed) >= len(body) {
+		return body, ""
+	}
+
+	c.compressedResponses.WithLabelValues(encoding).Inc()
+	c.savedBytes.WithLabelValues(encoding).Add(float64(len(body) - len(compressed)))
+	return compressed, encoding
+}
+
+// negotiateEncoding returns the first of the encodings, in order of preference, accepted by the
+// Accept-Encoding header value, or an empty string if none is accepted.
+func negotiateEncoding(acceptEncoding string, encodings []string) string {
+	if acceptEncoding == "" {
+		return ""
+	}
+
+	accepted := map[string]bool{}
+	for part := range strings.SplitSeq(acceptEncoding, ",") {
+		name, params, _ := strings.Cut(part, ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+
+		ok := true
+		for param := range strings.SplitSeq(params, ";") {
+			key, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+			if strings.TrimSpace(key) == "q" {
+				q, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+				ok = err == nil && q > 0
+			}
+		}
+		accepted[name] = ok
+	}
+
+	for _, encoding := range encodings {
+		if ok, found := accepted[encoding]; found {
+			if ok {
+				return encoding
+			}
+			continue
+		}
+		if accepted["*"] {
+			return encoding
+		}
+	}
+	return ""
+}
+
+func validateResponseEncodings(encodings []string) error {
+	for _, encoding := range encodings {
+		if !slices.Contains(supportedResponseEncodings, encoding) {
+			return fmt.Errorf("unsupported response compression encoding %q, supported values are: %s", encoding, strings.Join(supportedResponseEncodings, ", "))
+		}
+	}
+	return nil
+}
@@ -10,6 +10,7 @@ import (
 	"maps"
 	"net/http"
 	"net/url"
+	"os"
 	"strconv"
 	"strings"
 	"sync"
@@ -30,6 +31,7 @@ import (
 	"github.com/cortexproject/cortex/pkg/querier/tripperware"
 	"github.com/cortexproject/cortex/pkg/util"
 	util_api "github.com/cortexproject/cortex/pkg/util/api"
+	"github.com/cortexproject/cortex/pkg/util/flagext"
 	"github.com/cortexproject/cortex/pkg/util/limiter"
 	util_log "github.com/cortexproject/cortex/pkg/util/log"
 	"github.com/cortexproject/cortex/pkg/util/requestmeta"
@@ -86,16 +88,27 @@ const (
 // Config for a Handler.
 type HandlerConfig struct {
 	LogQueriesLongerThan      time.Duration `yaml:"log_queries_longer_than"`
+	SlowQueryLogFile          string        `yaml:"slow_query_log_file"`
 	MaxBodySize               int64         `yaml:"max_body_size"`
 	QueryStatsEnabled         bool          `yaml:"query_stats_enabled"`
 	EnabledRulerQueryStatsLog bool          `yaml:"enabled_ruler_query_stats_log"`
+
+	ResponseCompressionEncodings flagext.StringSliceCSV `yaml:"response_compression_encodings"`
+	ResponseCompressionMinSize   int                    `yaml:"response_compression_min_size"`
 }
 
 func (cfg *HandlerConfig) RegisterFlags(f *flag.FlagSet) {
 	f.DurationVar(&cfg.LogQueriesLongerThan, "frontend.log-queries-longer-than", 0, "Log queries that are slower than the specified duration. Set to 0 to disable. Set to < 0 to enable on all queries.")
+	f.StringVar(&cfg.SlowQueryLogFile, "frontend.slow-query-log-file", "", "EXPERIMENTAL: Path of the file the queries slower than -frontend.log-queries-longer-than are logged to, one JSON object per line, instead of the server log. When set, the statistics of the queries (fetched series and chunks, number of shards, ...) are tracked and included in the slow query log, even if -frontend.query-stats-enabled is disabled. The file is reopened once it has been moved or removed, so it can be rotated.")
 	f.Int64Var(&cfg.MaxBodySize, "frontend.max-body-size", 10*1024*1024, "Max body size for downstream prometheus.")
 	f.BoolVar(&cfg.QueryStatsEnabled, "frontend.query-stats-enabled", false, "True to enable query statistics tracking. When enabled, a message with some statistics is logged for every query.")
 	f.BoolVar(&cfg.EnabledRulerQueryStatsLog, "frontend.enabled-ruler-query-stats", false, "If enabled, report the query stats log for queries coming from the ruler to evaluate rules. It only takes effect when '-ruler.frontend-address' is configured.")
+	f.Var(&cfg.ResponseCompressionEncodings, "frontend.response-compression-encodings", "[EXPERIMENTAL] Comma separated list of encodings the query responses can be compressed with, in order of preference. The encoding is negotiated with the Accept-Encoding header of the request. Supported values: zstd, gzip. Empty to disable.")
+	f.IntVar(&cfg.ResponseCompressionMinSize, "frontend.response-compression-min-size", 1024, "[EXPERIMENTAL] Minimum size in bytes of the query responses to compress with -frontend.response-compression-encodings.")
+}
+
+func (cfg *HandlerConfig) Validate() error {
+	return validateResponseEncodings(cfg.ResponseCompressionEncodings)
 }
 
 // Handler accepts queries and forwards them to RoundTripper. It can log slow queries,
@@ -104,7 +117,9 @@ type Handler struct {
 	cfg                 HandlerConfig
 	tenantFederationCfg tenantfederation.Config
 	log                 log.Logger
+	slowQueryLog        log.Logger
 	roundTripper        http.RoundTripper
+	compressor          *responseCompressor
 
 	// Metrics.
 	querySeconds        *prometheus.CounterVec
@@ -128,10 +143,39 @@ func NewHandler(cfg HandlerConfig, tenantFederationCfg tenantfederation.Config,
 		cfg:                 cfg,
 		tenantFederationCfg: tenantFederationCfg,
 		log:                 log,
+		slowQueryLog:        log,
 		roundTripper:        roundTripper,
 		reg:                 reg,
 	}
 
+	if cfg.SlowQueryLogFile != "" {
+		slowQueryLog, err := newSlowQueryFileLogger(cfg.SlowQueryLogFile)
+		if err != nil {
+			// Slow queries are still logged, to the server log.
+			level.Error(log).Log("msg", "failed to open the slow query log file", "file", cfg.SlowQueryLogFile, "err", err)
+		} else {
+			h.slowQueryLog = slowQueryLog
+		}
+	}
+
+	if len(cfg.ResponseCompressionEncodings) > 0 {
+		compressedResponses := promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_frontend_compressed_responses_total",
+			Help: "Total number of query responses compressed by the query-frontend.",
+		}, []string{"encoding"})
+		savedBytes := promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_frontend_response_compression_saved_bytes_total",
+			Help: "Total number of bytes saved compressing the query responses.",
+		}, []string{"encoding"})
+
+		compressor, err := newResponseCompressor(cfg.ResponseCompressionEncodings, cfg.ResponseCompressionMinSize, compressedResponses, savedBytes)
+		if err != nil {
+			level.Error(log).Log("msg", "failed to create the response compressor, the query responses won't be compressed", "err", err)
+		} else {
+			h.compressor = compressor
+		}
+	}
+
 	if cfg.QueryStatsEnabled {
 		h.querySeconds = promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
 			Name: "cortex_query_seconds_total",
@@ -268,7 +312,7 @@ func (f *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 	// Initialise the stats in the context and make sure it's propagated
 	// down the request chain.
-	if f.cfg.QueryStatsEnabled {
+	if f.cfg.QueryStatsEnabled || (f.cfg.SlowQueryLogFile != "" && f.cfg.LogQueriesLongerThan != 0) {
 		// Check if querier stats is enabled in the context.
 		stats = querier_stats.FromContext(r.Context())
 		if stats == nil {
@@ -345,7 +389,17 @@ func (f *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			}
 		}
 
-		f.reportQueryStats(r, source, userID, queryString, queryResponseTime, stats, err, statusCode, resp)
+		if err == nil && resp != nil && resp.ContentLength < 0 {
+			// The size of a streamed response is only known once it's written, so its stats are reported then.
+			body := &countingReadCloser{ReadCloser: resp.Body}
+			resp.Body = body
+			defer func() {
+				resp.ContentLength = body.count
+				f.reportQueryStats(r, source, userID, queryString, queryResponseTime, stats, nil, statusCode, resp)
+			}()
+		} else {
+			f.reportQueryStats(r, source, userID, queryString, queryResponseTime, stats, err, statusCode, resp)
+		}
 	}
 
 	hs := w.Header()
@@ -359,8 +413,17 @@ func (f *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// The response body may be encoded while being copied, so it's important to close it
+	// to release its resources in case the copy fails.
+	defer resp.Body.Close()
+
 	maps.Copy(hs, resp.Header)
 
+	if f.compressor != nil && resp.Header.Get("Content-Encoding") == "" {
+		f.writeCompressedResponse(logger, w, r, resp)
+		return
+	}
+
 	w.WriteHeader(resp.StatusCode)
 	// log copy response body error so that we will know even though success response code returned
 	bytesCopied, err := io.Copy(w, resp.Body)
@@ -369,6 +432,43 @@ func (f *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// writeCompressedResponse writes the response, compressing its body with the encoding negotiated
+// with the request. The results cache stores the uncompressed responses, so they're compressed here
+// on send.
+func (f *Handler) writeCompressedResponse(logger log.Logger, w http.ResponseWriter, r *http.Request, resp *http.Response) {
+	hs := w.Header()
+	hs.Add("Vary", "Accept-Encoding")
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		writeError(logger, w, err, hs)
+		return
+	}
+
+	body, encoding := f.compressor.compress(r, body)
+	if encoding != "" {
+		hs.Set("Content-Encoding", encoding)
+		hs.Set("Content-Length", strconv.Itoa(len(body)))
+	}
+
+	w.WriteHeader(resp.StatusCode)
+	if _, err := w.Write(body); err != nil && !errors.Is(err, syscall.EPIPE) {
+		level.Error(logger).Log("msg", "write response body error", "err", err)
+	}
+}
+
+// countingReadCloser counts the bytes read from the wrapped io.ReadCloser.
+type countingReadCloser struct {
+	io.ReadCloser
+	count int64
+}
+
+func (c *countingReadCloser) Read(p []byte) (int, error) {
+	n, err := c.ReadCloser.Read(p)
+	c.count += int64(n)
+	return n, err
+}
+
 func formatGrafanaStatsFields(r *http.Request) []any {
 	// NOTE(GiedriusS): see https://github.com/grafana/grafana/pull/60301 for more info.
 
@@ -475,9 +575,60 @@ func (f *Handler) reportSlowQuery(r *http.Request, queryString url.Values, query
 		logMessage = append(logMessage, "split_queries", n)
 	}
 
+	// The extra fields include the number of shards of the query, if sharded.
+	logMessage = append(logMessage, stats.LoadExtraFields()...)
 	logMessage = append(logMessage, formatQueryString(queryString)...)
 
-	level.Info(util_log.WithContext(r.Context(), f.log)).Log(logMessage...)
+	level.Info(util_log.WithContext(r.Context(), f.slowQueryLog)).Log(logMessage...)
+}
+
+// newSlowQueryFileLogger returns a logger appending the slow queries to the given file, as JSON.
+func newSlowQueryFileLogger(path string) (log.Logger, error) {
+	file := &rotatableFile{path: path}
+	if err := file.reopenIfRotated(); err != nil {
+		return nil, err
+	}
+
+	return log.With(log.NewJSONLogger(file), "ts", log.DefaultTimestampUTC), nil
+}
+
+// rotatableFile is an io.Writer appending to a file, which is reopened once it has been moved or
+// removed, eg. when it's rotated by logrotate.
+type rotatableFile struct {
+	path string
+
+	mtx  sync.Mutex
+	file *os.File
+}
+
+func (f *rotatableFile) Write(p []byte) (int, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+
+	if err := f.reopenIfRotated(); err != nil {
+		return 0, err
+	}
+	return f.file.Write(p)
+}
+
+// reopenIfRotated opens the file at the path, unless the file already open is still there.
+func (f *rotatableFile) reopenIfRotated() error {
+	if f.file != nil {
+		opened, err := f.file.Stat()
+		current, currentErr := os.Stat(f.path)
+		if err == nil && currentErr == nil && os.SameFile(opened, current) {
+			return nil
+		}
+		_ = f.file.Close()
+		f.file = nil
+	}
+
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o666)
+	if err != nil {
+		return err
+	}
+	f.file = file
+	return nil
 }
 
 func (f *Handler) reportQueryStats(r *http.Request, source, userID string, queryString url.Values, queryResponseTime time.Duration, stats *querier_stats.QueryStats, error error, statusCode int, resp *http.Response) {
@@ -497,6 +648,7 @@ func (f *Handler) reportQueryStats(r *http.Request, source, userID string, query
 	dataSelectMaxTime := stats.LoadDataSelectMaxTime()
 	dataSelectMinTime := stats.LoadDataSelectMinTime()
 	splitInterval := stats.LoadSplitInterval()
+	verticalShardSize := stats.LoadVerticalShardSize()
 
 	// Track stats.
 	f.querySeconds.WithLabelValues(source, userID).Add(wallTime.Seconds())
@@ -594,6 +746,9 @@ func (f *Handler) reportQueryStats(r *http.Request, source, userID string, query
 	if splitInterval > 0 {
 		logMessage = append(logMessage, "split_interval", splitInterval.String())
 	}
+	if verticalShardSize > 0 {
+		logMessage = append(logMessage, "vertical_shard_size", verticalShardSize)
+	}
 
 	if error != nil {
 		s, ok := status.FromError(error)
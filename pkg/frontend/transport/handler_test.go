@@ -8,11 +8,15 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/go-kit/log"
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zstd"
 	"github.com/pkg/errors"
 	v1 "github.com/prometheus/client_golang/api/prometheus/v1"
 	"github.com/prometheus/client_golang/prometheus"
@@ -670,6 +674,16 @@ func TestReportSlowQueryFormat(t *testing.T) {
 			},
 			expectedLog: `level=info msg="slow query detected" method=GET host=localhost:8080 path=/prometheus/api/v1/query source=api time_taken_ms=1000 query_wall_time_seconds=3 fetched_series_count=100`,
 		},
+		"should include the extra fields before the query string": {
+			source:      requestmeta.SourceAPI,
+			queryString: url.Values(map[string][]string{"query": {"up"}}),
+			queryStats: &querier_stats.QueryStats{
+				Stats: querier_stats.Stats{
+					ExtraFields: map[string]string{"shard_by.num_shards": "4"},
+				},
+			},
+			expectedLog: `level=info msg="slow query detected" method=GET host=localhost:8080 path=/prometheus/api/v1/query source=api time_taken_ms=1000 shard_by.num_shards=4 param_query=up`,
+		},
 	}
 
 	for testName, testData := range tests {
@@ -691,6 +705,184 @@ func TestReportSlowQueryFormat(t *testing.T) {
 	}
 }
 
+func TestHandler_SlowQueryLogFile(t *testing.T) {
+	slowQueryLogFile := filepath.Join(t.TempDir(), "slow-queries.log")
+
+	roundTripper := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		// The stats are tracked even if the query stats are disabled.
+		querier_stats.FromContext(req.Context()).AddFetchedSeries(10)
+		querier_stats.FromContext(req.Context()).AddFetchedChunks(20)
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(strings.NewReader("{}")),
+		}, nil
+	})
+
+	serverLog := bytes.NewBuffer(nil)
+	handler := NewHandler(HandlerConfig{LogQueriesLongerThan: -1, SlowQueryLogFile: slowQueryLogFile}, tenantfederation.Config{}, roundTripper, log.NewLogfmtLogger(serverLog), nil)
+
+	req := httptest.NewRequest(http.MethodGet, "http://localhost:8080/prometheus/api/v1/query_range?query=up&start=10&end=20&step=5", nil)
+	req = req.WithContext(user.InjectOrgID(context.Background(), "user-1"))
+	resp := httptest.NewRecorder()
+	handler.ServeHTTP(resp, req)
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	data, err := os.ReadFile(slowQueryLogFile)
+	require.NoError(t, err)
+
+	entry := map[string]any{}
+	require.NoError(t, json.Unmarshal(data, &entry))
+	assert.Equal(t, "slow query detected", entry["msg"])
+	assert.Equal(t, "user-1", entry["org_id"])
+	assert.Equal(t, "up", entry["param_query"])
+	assert.Equal(t, "10", entry["param_start"])
+	assert.Equal(t, "20", entry["param_end"])
+	assert.Equal(t, "5", entry["param_step"])
+	assert.Equal(t, float64(10), entry["fetched_series_count"])
+	assert.Equal(t, float64(20), entry["fetched_chunks_count"])
+	assert.NotContains(t, serverLog.String(), "slow query detected")
+}
+
+func TestNewSlowQueryFileLogger_ShouldReopenTheRotatedFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "slow-queries.log")
+	logger, err := newSlowQueryFileLogger(path)
+	require.NoError(t, err)
+
+	require.NoError(t, logger.Log("msg", "first"))
+	require.NoError(t, os.Rename(path, path+".1"))
+	require.NoError(t, logger.Log("msg", "second"))
+
+	rotated, err := os.ReadFile(path + ".1")
+	require.NoError(t, err)
+	assert.Contains(t, string(rotated), `"msg":"first"`)
+	assert.NotContains(t, string(rotated), `"msg":"second"`)
+
+	current, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(current), `"msg":"second"`)
+}
+
+func TestHandler_ShouldReportTheSizeOfStreamedResponses(t *testing.T) {
+	roundTripper := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode:    http.StatusOK,
+			Body:          io.NopCloser(strings.NewReader(`{"status":"success"}`)),
+			ContentLength: -1,
+		}, nil
+	})
+
+	serverLog := bytes.NewBuffer(nil)
+	handler := NewHandler(HandlerConfig{QueryStatsEnabled: true}, tenantfederation.Config{}, roundTripper, log.NewLogfmtLogger(serverLog), nil)
+
+	req := httptest.NewRequest(http.MethodGet, "http://localhost:8080/prometheus/api/v1/query_range?query=up&start=10&end=20&step=5", nil)
+	req = req.WithContext(user.InjectOrgID(context.Background(), "user-1"))
+	resp := httptest.NewRecorder()
+	handler.ServeHTTP(resp, req)
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, `{"status":"success"}`, resp.Body.String())
+	assert.Contains(t, serverLog.String(), "response_size=20 ")
+}
+
+func TestHandler_ResponseCompression(t *testing.T) {
+	body := strings.Repeat(`{"metric":{"__name__":"up"},"value":[1,"1"]},`, 100)
+
+	roundTripper := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+			Body:       io.NopCloser(strings.NewReader(body)),
+		}, nil
+	})
+
+	tests := map[string]struct {
+		acceptEncoding   string
+		minSize          int
+		expectedEncoding string
+	}{
+		"no Accept-Encoding header": {
+			minSize: 100,
+		},
+		"zstd preferred over gzip": {
+			acceptEncoding:   "gzip, zstd",
+			minSize:          100,
+			expectedEncoding: "zstd",
+		},
+		"gzip when zstd is not accepted": {
+			acceptEncoding:   "gzip, zstd;q=0",
+			minSize:          100,
+			expectedEncoding: "gzip",
+		},
+		"wildcard": {
+			acceptEncoding:   "*",
+			minSize:          100,
+			expectedEncoding: "zstd",
+		},
+		"unsupported encoding": {
+			acceptEncoding: "br",
+			minSize:        100,
+		},
+		"response smaller than the minimum size": {
+			acceptEncoding: "zstd",
+			minSize:        len(body) * 2,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			reg := prometheus.NewPedanticRegistry()
+			cfg := HandlerConfig{ResponseCompressionEncodings: []string{"zstd", "gzip"}, ResponseCompressionMinSize: tc.minSize}
+			handler := NewHandler(cfg, tenantfederation.Config{}, roundTripper, log.NewNopLogger(), reg)
+
+			req := httptest.NewRequest(http.MethodGet, "http://localhost:8080/prometheus/api/v1/query?query=up", nil)
+			req = req.WithContext(user.InjectOrgID(context.Background(), "user-1"))
+			if tc.acceptEncoding != "" {
+				req.Header.Set("Accept-Encoding", tc.acceptEncoding)
+			}
+			resp := httptest.NewRecorder()
+			handler.ServeHTTP(resp, req)
+			require.Equal(t, http.StatusOK, resp.Code)
+			assert.Equal(t, tc.expectedEncoding, resp.Header().Get("Content-Encoding"))
+			assert.Equal(t, "Accept-Encoding", resp.Header().Get("Vary"))
+			compressedSize := resp.Body.Len()
+
+			var decoded []byte
+			switch tc.expectedEncoding {
+			case "zstd":
+				dec, err := zstd.NewReader(resp.Body)
+				require.NoError(t, err)
+				defer dec.Close()
+				decoded, err = io.ReadAll(dec)
+				require.NoError(t, err)
+			case "gzip":
+				dec, err := gzip.NewReader(resp.Body)
+				require.NoError(t, err)
+				decoded, err = io.ReadAll(dec)
+				require.NoError(t, err)
+			default:
+				decoded = resp.Body.Bytes()
+			}
+			assert.Equal(t, body, string(decoded))
+
+			count, err := promtest.GatherAndCount(reg, "cortex_frontend_compressed_responses_total")
+			require.NoError(t, err)
+			if tc.expectedEncoding == "" {
+				assert.Equal(t, 0, count)
+				return
+			}
+			assert.Equal(t, float64(1), promtest.ToFloat64(handler.compressor.compressedResponses.WithLabelValues(tc.expectedEncoding)))
+			assert.Equal(t, float64(len(body)-compressedSize), promtest.ToFloat64(handler.compressor.savedBytes.WithLabelValues(tc.expectedEncoding)))
+		})
+	}
+}
+
+func TestHandlerConfig_Validate(t *testing.T) {
+	cfg := HandlerConfig{ResponseCompressionEncodings: []string{"gzip", "zstd"}}
+	require.NoError(t, cfg.Validate())
+
+	cfg.ResponseCompressionEncodings = append(cfg.ResponseCompressionEncodings, "br")
+	require.EqualError(t, cfg.Validate(), `unsupported response compression encoding "br", supported values are: zstd, gzip`)
+}
+
 func TestReportQueryStatsRejectionReason(t *testing.T) {
 	outputBuf := bytes.NewBuffer(nil)
 	logger := log.NewSyncLogger(log.NewLogfmtLogger(outputBuf))
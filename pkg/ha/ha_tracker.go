@@ -42,6 +42,10 @@ type HATrackerLimits interface {
 
 	// HATrackerFailoverTimeout returns the failover timeout for a user.
 	HATrackerFailoverTimeout(user string) time.Duration
+
+	// HATrackerFastFailoverTimeout returns the failover timeout for a user applied when a quorum
+	// of the replicas of a cluster is still sending samples. 0 to disable the fast failover.
+	HATrackerFastFailoverTimeout(user string) time.Duration
 }
 
 // ProtoReplicaDescFactory makes new InstanceDescs
@@ -113,8 +117,8 @@ func (d *ReplicaDesc) Merge(mergeable memberlist.Mergeable, _ bool) (memberlist.
 	}
 
 	if otherLatest < curLatest {
-		// If the current is more recent, ignore the incoming data.
-		return nil, nil
+		// If the current is more recent, ignore the incoming data except for the standby replicas.
+		return d.applyStandbyReplicas(other.StandbyReplicas), nil
 	}
 
 	// If timestamps are the same, we take deleted one.
@@ -127,7 +131,7 @@ func (d *ReplicaDesc) Merge(mergeable memberlist.Mergeable, _ bool) (memberlist.
 	}
 	if isCurDeleted && !isOtherIsDeleted {
 		// If the current has been deleted, ignore the incoming data.
-		return nil, nil
+		return d.applyStandbyReplicas(other.StandbyReplicas), nil
 	}
 
 	// If timestamps are exactly equal but replicas differ, use lexicographic ordering
@@ -137,18 +141,54 @@ func (d *ReplicaDesc) Merge(mergeable memberlist.Mergeable, _ bool) (memberlist.
 		}
 	}
 
-	// No change (same timestamp, same replica)
-	return nil, nil
+	// Same timestamp and same replica, only the standby replicas may have changed.
+	return d.applyStandbyReplicas(other.StandbyReplicas), nil
 }
 
 // apply performs an in-place update of the current descriptor and returns a cloned result.
 func (d *ReplicaDesc) apply(other *ReplicaDesc) *ReplicaDesc {
+	standbyReplicas := d.StandbyReplicas
+
 	d.Replica = other.Replica
 	d.ReceivedAt = other.ReceivedAt
 	d.DeletedAt = other.DeletedAt
+	d.StandbyReplicas = nil
+	d.mergeStandbyReplicas(other.StandbyReplicas)
+	d.mergeStandbyReplicas(standbyReplicas)
 	return proto.Clone(d).(*ReplicaDesc)
 }
 
+// applyStandbyReplicas performs an in-place merge of the input standby replicas into the current
+// descriptor and returns a cloned result, or nil if the current descriptor hasn't changed.
+func (d *ReplicaDesc) applyStandbyReplicas(standbyReplicas map[string]int64) memberlist.Mergeable {
+	if !d.mergeStandbyReplicas(standbyReplicas) {
+		return nil
+	}
+	return proto.Clone(d).(*ReplicaDesc)
+}
+
+// mergeStandbyReplicas merges the input standby replicas into the current descriptor, keeping the
+// most recent timestamp for each replica, and returns whether the current descriptor has changed.
+// The elected replica is never tracked as a standby one, and deleted descriptors have no standby replicas.
+func (d *ReplicaDesc) mergeStandbyReplicas(standbyReplicas map[string]int64) bool {
+	if d.DeletedAt > 0 {
+		return false
+	}
+
+	changed := false
+	for replica, receivedAt := range standbyReplicas {
+		if replica == d.Replica || receivedAt <= d.StandbyReplicas[replica] {
+			continue
+		}
+		if d.StandbyReplicas == nil {
+			d.StandbyReplicas = map[string]int64{}
+		}
+		d.StandbyReplicas[replica] = receivedAt
+		changed = true
+	}
+	return changed
+}
+
 // MergeContent describes content of this Mergeable.
 // For ReplicaDesc, we return the replica name.
 func (d *ReplicaDesc) MergeContent() []string {
@@ -224,6 +264,7 @@ type HATracker struct {
 	replicaGroups map[string]map[string]struct{} // Known replica groups with elected replicas per user. First key = user, second key = replica group name (e.g. cluster).
 
 	electedReplicaChanges         *prometheus.CounterVec
+	electedReplicaFastFailovers   *prometheus.CounterVec
 	electedReplicaTimestamp       *prometheus.GaugeVec
 	electedReplicaPropagationTime prometheus.Histogram
 	kvCASCalls                    *prometheus.CounterVec
@@ -259,6 +300,10 @@ func NewHATracker(cfg HATrackerConfig, limits HATrackerLimits, trackerStatusConf
 			Name: "ha_tracker_elected_replica_changes_total",
 			Help: "The total number of times the elected replica has changed for a user ID/cluster.",
 		}, []string{"user", "cluster"}),
+		electedReplicaFastFailovers: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "ha_tracker_elected_replica_fast_failovers_total",
+			Help: "The total number of times the HA tracker failed over to a new replica, before the failover timeout, because a quorum of replicas was still sending samples for a user ID/cluster.",
+		}, []string{"user", "cluster"}),
 		electedReplicaTimestamp: promauto.With(reg).NewGaugeVec(prometheus.GaugeOpts{
 			Name: "ha_tracker_elected_replica_timestamp_seconds",
 			Help: "The timestamp stored for the currently elected replica, from the KVStore.",
@@ -442,6 +487,7 @@ func (c *HATracker) loop(ctx context.Context) error {
 		if replica.DeletedAt > 0 {
 			delete(c.elected, key)
 			c.electedReplicaChanges.DeleteLabelValues(user, cluster)
+			c.electedReplicaFastFailovers.DeleteLabelValues(user, cluster)
 			c.electedReplicaTimestamp.DeleteLabelValues(user, cluster)
 
 			userClusters := c.replicaGroups[user]
@@ -466,7 +512,11 @@ func (c *HATracker) loop(ctx context.Context) error {
 		}
 		c.elected[key] = *replica
 		c.electedReplicaTimestamp.WithLabelValues(user, cluster).Set(float64(replica.ReceivedAt / 1000))
-		c.electedReplicaPropagationTime.Observe(time.Since(timestamp.Time(replica.ReceivedAt)).Seconds())
+
+		// Updates of the standby replicas only don't change the elected replica timestamp.
+		if !exists || replica.ReceivedAt != elected.ReceivedAt {
+			c.electedReplicaPropagationTime.Observe(time.Since(timestamp.Time(replica.ReceivedAt)).Seconds())
+		}
 		return true
 	})
 
@@ -591,10 +641,15 @@ func (c *HATracker) CheckReplica(ctx context.Context, userID, replicaGroup, repl
 	c.electedLock.RUnlock()
 
 	if ok && now.Sub(timestamp.Time(entry.ReceivedAt)) < c.cfg.UpdateTimeout+c.updateTimeoutJitter {
-		if entry.Replica != replica {
+		if entry.Replica == replica {
+			return nil
+		}
+
+		// When the fast failover is enabled we also keep track of the last time we received samples
+		// from the non-elected replicas, so we need to go through the KV store to update it once stale.
+		if c.fastFailoverTimeout(userID) <= 0 || now.Sub(timestamp.Time(entry.StandbyReplicas[replica])) < c.cfg.UpdateTimeout+c.updateTimeoutJitter {
 			return ReplicasNotMatchError{replica: replica, elected: entry.Replica}
 		}
-		return nil
 	}
 
 	if !ok {
@@ -606,7 +661,7 @@ func (c *HATracker) CheckReplica(ctx context.Context, userID, replicaGroup, repl
 		}
 	}
 
-	err := c.checkKVStore(ctx, key, replica, userID, now)
+	err := c.checkKVStore(ctx, key, replica, userID, replicaGroup, now)
 	c.kvCASCalls.WithLabelValues(userID, replicaGroup).Inc()
 	if err != nil {
 		// The callback within checkKVStore will return a ReplicasNotMatchError if the sample is being deduped,
@@ -618,8 +673,20 @@ func (c *HATracker) CheckReplica(ctx context.Context, userID, replicaGroup, repl
 	return err
 }
 
-func (c *HATracker) checkKVStore(ctx context.Context, key, replica, userID string, now time.Time) error {
-	return c.client.CAS(ctx, key, func(in any) (out any, retry bool, err error) {
+func (c *HATracker) checkKVStore(ctx context.Context, key, replica, userID, replicaGroup string, now time.Time) error {
+	var (
+		// Set when the sample should be rejected even if the CAS succeeded, because
+		// we've only updated the timestamp of a standby replica.
+		rejectErr    error
+		fastFailover bool
+	)
+
+	err := c.client.CAS(ctx, key, func(in any) (out any, retry bool, err error) {
+		rejectErr, fastFailover = nil, false
+		failoverTimeout := c.limits.HATrackerFailoverTimeout(userID)
+		fastFailoverTimeout := c.fastFailoverTimeout(userID)
+
+		var standbyReplicas map[string]int64
 		if desc, ok := in.(*ReplicaDesc); ok && desc.DeletedAt == 0 {
 			// We don't need to CAS and update the timestamp in the KV store if the timestamp we've received
 			// this sample at is less than updateTimeout amount of time since the timestamp in the KV store.
@@ -628,9 +695,36 @@ func (c *HATracker) checkKVStore(ctx context.Context, key, replica, userID strin
 			}
 
 			// We shouldn't failover to accepting a new replica if the timestamp we've received this sample at
-			// is less than failover timeout amount of time since the timestamp in the KV store.
-			if desc.Replica != replica && now.Sub(timestamp.Time(desc.ReceivedAt)) < c.limits.HATrackerFailoverTimeout(userID) {
-				return nil, false, ReplicasNotMatchError{replica: replica, elected: desc.Replica}
+			// is less than failover timeout amount of time since the timestamp in the KV store, unless the
+			// fast failover is enabled and a quorum of replicas agrees the elected one stopped sending samples.
+			if desc.Replica != replica && now.Sub(timestamp.Time(desc.ReceivedAt)) < failoverTimeout {
+				notMatchErr := ReplicasNotMatchError{replica: replica, elected: desc.Replica}
+				if fastFailoverTimeout <= 0 {
+					return nil, false, notMatchErr
+				}
+
+				if !hasFailoverQuorum(desc, replica, now, fastFailoverTimeout, failoverTimeout) {
+					if now.Sub(timestamp.Time(desc.StandbyReplicas[replica])) < c.cfg.UpdateTimeout+c.updateTimeoutJitter {
+						return nil, false, notMatchErr
+					}
+
+					// Keep track of the last time we received samples from this standby replica.
+					updated := proto.Clone(desc).(*ReplicaDesc)
+					updated.StandbyReplicas = activeStandbyReplicas(desc.StandbyReplicas, now, failoverTimeout)
+					updated.StandbyReplicas[replica] = timestamp.FromTime(now)
+					rejectErr = notMatchErr
+					return updated, true, nil
+				}
+
+				fastFailover = true
+			}
+
+			if fastFailoverTimeout > 0 {
+				standbyReplicas = activeStandbyReplicas(desc.StandbyReplicas, now, failoverTimeout)
+				if desc.Replica != replica {
+					standbyReplicas[desc.Replica] = desc.ReceivedAt
+				}
+				delete(standbyReplicas, replica)
 			}
 		}
 
@@ -638,11 +732,70 @@ func (c *HATracker) checkKVStore(ctx context.Context, key, replica, userID strin
 		// from this replica. Invalid could mean that the timestamp in the KV store was
 		// out of date based on the update and failover timeouts when compared to now.
 		return &ReplicaDesc{
-			Replica:    replica,
-			ReceivedAt: timestamp.FromTime(now),
-			DeletedAt:  0,
+			Replica:         replica,
+			ReceivedAt:      timestamp.FromTime(now),
+			DeletedAt:       0,
+			StandbyReplicas: standbyReplicas,
 		}, true, nil
 	})
+	if err != nil {
+		return err
+	}
+
+	if fastFailover {
+		c.electedReplicaFastFailovers.WithLabelValues(userID, replicaGroup).Inc()
+	}
+	return rejectErr
+}
+
+// fastFailoverTimeout returns the fast failover timeout for the input user, or 0 if it's disabled.
+func (c *HATracker) fastFailoverTimeout(userID string) time.Duration {
+	if c.limits == nil {
+		return 0
+	}
+	return c.limits.HATrackerFastFailoverTimeout(userID)
+}
+
+// hasFailoverQuorum returns whether replica can be elected in place of the currently elected one before the
+// failover timeout expires. It happens when the elected replica hasn't sent samples for the fast failover
+// timeout, while the majority of the known replicas of the cluster are still sending samples. A replica is
+// known if it has sent samples within the failover timeout, so a pair of replicas never has a quorum.
+func hasFailoverQuorum(desc *ReplicaDesc, replica string, now time.Time, fastFailoverTimeout, failoverTimeout time.Duration) bool {
+	if now.Sub(timestamp.Time(desc.ReceivedAt)) < fastFailoverTimeout {
+		return false
+	}
+
+	// The elected replica and the one which has just sent samples are always known.
+	known, sending := 2, 1
+	for standby, receivedAt := range desc.StandbyReplicas {
+		if standby == replica || standby == desc.Replica {
+			continue
+		}
+
+		age := now.Sub(timestamp.Time(receivedAt))
+		if age >= failoverTimeout {
+			continue
+		}
+
+		known++
+		if age < fastFailoverTimeout {
+			sending++
+		}
+	}
+
+	return sending > known/2
+}
+
+// activeStandbyReplicas returns a copy of the input standby replicas, excluding the ones
+// which haven't sent samples within the failover timeout.
+func activeStandbyReplicas(standbyReplicas map[string]int64, now time.Time, failoverTimeout time.Duration) map[string]int64 {
+	active := make(map[string]int64, len(standbyReplicas)+1)
+	for replica, receivedAt := range standbyReplicas {
+		if now.Sub(timestamp.Time(receivedAt)) < failoverTimeout {
+			active[replica] = receivedAt
+		}
+	}
+	return active
 }
 
 func (c *HATracker) Cfg() HATrackerConfig {
@@ -690,6 +843,9 @@ func (c *HATracker) CleanupHATrackerMetricsForUser(userID string) {
 	if err := util.DeleteMatchingLabels(c.electedReplicaChanges, filter); err != nil {
 		level.Warn(c.logger).Log("msg", "failed to remove cortex_ha_tracker_elected_replica_changes_total metric for user", "user", userID, "err", err)
 	}
+	if err := util.DeleteMatchingLabels(c.electedReplicaFastFailovers, filter); err != nil {
+		level.Warn(c.logger).Log("msg", "failed to remove cortex_ha_tracker_elected_replica_fast_failovers_total metric for user", "user", userID, "err", err)
+	}
 	if err := util.DeleteMatchingLabels(c.electedReplicaTimestamp, filter); err != nil {
 		level.Warn(c.logger).Log("msg", "failed to remove cortex_ha_tracker_elected_replica_timestamp_seconds metric for user", "user", userID, "err", err)
 	}
@@ -709,9 +865,10 @@ func (c *HATracker) SnapshotElectedReplicas() map[string]ReplicaDesc {
 	electedCopy := make(map[string]ReplicaDesc)
 	for key, desc := range c.elected {
 		electedCopy[key] = ReplicaDesc{
-			Replica:    desc.Replica,
-			ReceivedAt: desc.ReceivedAt,
-			DeletedAt:  desc.DeletedAt,
+			Replica:         desc.Replica,
+			ReceivedAt:      desc.ReceivedAt,
+			DeletedAt:       desc.DeletedAt,
+			StandbyReplicas: maps.Clone(desc.StandbyReplicas),
 		}
 	}
 	return electedCopy
@@ -7,6 +7,7 @@ import (
 	fmt "fmt"
 	_ "github.com/gogo/protobuf/gogoproto"
 	proto "github.com/gogo/protobuf/proto"
+	github_com_gogo_protobuf_sortkeys "github.com/gogo/protobuf/sortkeys"
 	io "io"
 	math "math"
 	math_bits "math/bits"
@@ -34,6 +35,11 @@ type ReplicaDesc struct {
 	// already remove entry from memory. Actual deletion from KV store does *not* trigger
 	// "watch" notification with a key for all KV stores.
 	DeletedAt int64 `protobuf:"varint,3,opt,name=deleted_at,json=deletedAt,proto3" json:"deleted_at,omitempty"`
+	// Unix timestamp in milliseconds of the last sample received from each non-elected
+	// replica of the cluster, keyed by replica name. It's only populated when the HA tracker
+	// fast failover is enabled, and it's used to elect a new replica when a quorum of replicas
+	// is still sending samples while the elected one stopped.
+	StandbyReplicas map[string]int64 `protobuf:"bytes,4,rep,name=standby_replicas,json=standbyReplicas,proto3" json:"standby_replicas,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"varint,2,opt,name=value,proto3"`
 }
 
 func (m *ReplicaDesc) Reset()      { *m = ReplicaDesc{} }
@@ -89,27 +95,40 @@ func (m *ReplicaDesc) GetDeletedAt() int64 {
 	return 0
 }
 
+func (m *ReplicaDesc) GetStandbyReplicas() map[string]int64 {
+	if m != nil {
+		return m.StandbyReplicas
+	}
+	return nil
+}
+
 func init() {
 	proto.RegisterType((*ReplicaDesc)(nil), "ha.ReplicaDesc")
+	proto.RegisterMapType((map[string]int64)(nil), "ha.ReplicaDesc.StandbyReplicasEntry")
 }
 
 func init() { proto.RegisterFile("ha_tracker.proto", fileDescriptor_86f0e7bcf71d860b) }
 
 var fileDescriptor_86f0e7bcf71d860b = []byte{
-	// 197 bytes of a gzipped FileDescriptorProto
+	// 276 bytes of a gzipped FileDescriptorProto
 	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xe2, 0x12, 0xc8, 0x48, 0x8c, 0x2f,
 	0x29, 0x4a, 0x4c, 0xce, 0x4e, 0x2d, 0xd2, 0x2b, 0x28, 0xca, 0x2f, 0xc9, 0x17, 0x62, 0xca, 0x48,
-	0x94, 0x12, 0x49, 0xcf, 0x4f, 0xcf, 0x07, 0x73, 0xf5, 0x41, 0x2c, 0x88, 0x8c, 0x52, 0x3a, 0x17,
-	0x77, 0x50, 0x6a, 0x41, 0x4e, 0x66, 0x72, 0xa2, 0x4b, 0x6a, 0x71, 0xb2, 0x90, 0x04, 0x17, 0x7b,
-	0x11, 0x84, 0x2b, 0xc1, 0xa8, 0xc0, 0xa8, 0xc1, 0x19, 0x04, 0xe3, 0x0a, 0xc9, 0x73, 0x71, 0x17,
-	0xa5, 0x26, 0xa7, 0x66, 0x96, 0xa5, 0xa6, 0xc4, 0x27, 0x96, 0x48, 0x30, 0x29, 0x30, 0x6a, 0x30,
-	0x07, 0x71, 0xc1, 0x84, 0x1c, 0x4b, 0x84, 0x64, 0xb9, 0xb8, 0x52, 0x52, 0x73, 0x52, 0x4b, 0x20,
-	0xf2, 0xcc, 0x60, 0x79, 0x4e, 0xa8, 0x88, 0x63, 0x89, 0x93, 0xc9, 0x85, 0x87, 0x72, 0x0c, 0x37,
-	0x1e, 0xca, 0x31, 0x7c, 0x78, 0x28, 0xc7, 0xd8, 0xf0, 0x48, 0x8e, 0x71, 0xc5, 0x23, 0x39, 0xc6,
-	0x13, 0x8f, 0xe4, 0x18, 0x2f, 0x3c, 0x92, 0x63, 0x7c, 0xf0, 0x48, 0x8e, 0xf1, 0xc5, 0x23, 0x39,
-	0x86, 0x0f, 0x8f, 0xe4, 0x18, 0x27, 0x3c, 0x96, 0x63, 0xb8, 0xf0, 0x58, 0x8e, 0xe1, 0xc6, 0x63,
-	0x39, 0x86, 0x24, 0x36, 0xb0, 0x2b, 0x8d, 0x01, 0x01, 0x00, 0x00, 0xff, 0xff, 0xb4, 0x1b, 0xc6,
-	0x42, 0xd3, 0x00, 0x00, 0x00,
+	0x94, 0x12, 0x49, 0xcf, 0x4f, 0xcf, 0x07, 0x73, 0xf5, 0x41, 0x2c, 0x88, 0x8c, 0xd2, 0x1f, 0x46,
+	0x2e, 0xee, 0xa0, 0xd4, 0x82, 0x9c, 0xcc, 0xe4, 0x44, 0x97, 0xd4, 0xe2, 0x64, 0x21, 0x09, 0x2e,
+	0xf6, 0x22, 0x08, 0x57, 0x82, 0x51, 0x81, 0x51, 0x83, 0x33, 0x08, 0xc6, 0x15, 0x92, 0xe7, 0xe2,
+	0x2e, 0x4a, 0x4d, 0x4e, 0xcd, 0x2c, 0x4b, 0x4d, 0x89, 0x4f, 0x2c, 0x91, 0x60, 0x52, 0x60, 0xd4,
+	0x60, 0x0e, 0xe2, 0x82, 0x09, 0x39, 0x96, 0x08, 0xc9, 0x72, 0x71, 0xa5, 0xa4, 0xe6, 0xa4, 0x96,
+	0x40, 0xe4, 0x99, 0xc1, 0xf2, 0x9c, 0x50, 0x11, 0xc7, 0x12, 0x21, 0x7f, 0x2e, 0x81, 0xe2, 0x92,
+	0xc4, 0xbc, 0x94, 0xa4, 0xca, 0x78, 0xa8, 0x91, 0xc5, 0x12, 0x2c, 0x0a, 0xcc, 0x1a, 0xdc, 0x46,
+	0x2a, 0x7a, 0x19, 0x89, 0x7a, 0x48, 0x8e, 0xd0, 0x0b, 0x86, 0xa8, 0x83, 0x0a, 0x15, 0xbb, 0xe6,
+	0x95, 0x14, 0x55, 0x06, 0xf1, 0x17, 0xa3, 0x8a, 0x4a, 0x39, 0x71, 0x89, 0x60, 0x53, 0x28, 0x24,
+	0xc0, 0xc5, 0x9c, 0x9d, 0x5a, 0x09, 0x75, 0x3e, 0x88, 0x29, 0x24, 0xc2, 0xc5, 0x5a, 0x96, 0x98,
+	0x53, 0x9a, 0x0a, 0x75, 0x34, 0x84, 0x63, 0xc5, 0x64, 0xc1, 0xe8, 0x64, 0x72, 0xe1, 0xa1, 0x1c,
+	0xc3, 0x8d, 0x87, 0x72, 0x0c, 0x1f, 0x1e, 0xca, 0x31, 0x36, 0x3c, 0x92, 0x63, 0x5c, 0xf1, 0x48,
+	0x8e, 0xf1, 0xc4, 0x23, 0x39, 0xc6, 0x0b, 0x8f, 0xe4, 0x18, 0x1f, 0x3c, 0x92, 0x63, 0x7c, 0xf1,
+	0x48, 0x8e, 0xe1, 0xc3, 0x23, 0x39, 0xc6, 0x09, 0x8f, 0xe5, 0x18, 0x2e, 0x3c, 0x96, 0x63, 0xb8,
+	0xf1, 0x58, 0x8e, 0x21, 0x89, 0x0d, 0x1c, 0x76, 0xc6, 0x80, 0x01, 0x00, 0xce, 0x2b, 0xd5, 0xdf,
+	0x69, 0x01, 0x00, 0x00,
 }
 
 func (this *ReplicaDesc) Equal(that interface{}) bool {
@@ -140,17 +159,38 @@ func (this *ReplicaDesc) Equal(that interface{}) bool {
 	if this.DeletedAt != that1.DeletedAt {
 		return false
 	}
+	if len(this.StandbyReplicas) != len(that1.StandbyReplicas) {
+		return false
+	}
+	for i := range this.StandbyReplicas {
+		if this.StandbyReplicas[i] != that1.StandbyReplicas[i] {
+			return false
+		}
+	}
 	return true
 }
 func (this *ReplicaDesc) GoString() string {
 	if this == nil {
 		return "nil"
 	}
-	s := make([]string, 0, 7)
+	s := make([]string, 0, 8)
 	s = append(s, "&ha.ReplicaDesc{")
 	s = append(s, "Replica: "+fmt.Sprintf("%#v", this.Replica)+",\n")
 	s = append(s, "ReceivedAt: "+fmt.Sprintf("%#v", this.ReceivedAt)+",\n")
 	s = append(s, "DeletedAt: "+fmt.Sprintf("%#v", this.DeletedAt)+",\n")
+	keysForStandbyReplicas := make([]string, 0, len(this.StandbyReplicas))
+	for k, _ := range this.StandbyReplicas {
+		keysForStandbyReplicas = append(keysForStandbyReplicas, k)
+	}
+	github_com_gogo_protobuf_sortkeys.Strings(keysForStandbyReplicas)
+	mapStringForStandbyReplicas := "map[string]int64{"
+	for _, k := range keysForStandbyReplicas {
+		mapStringForStandbyReplicas += fmt.Sprintf("%#v: %#v,", k, this.StandbyReplicas[k])
+	}
+	mapStringForStandbyReplicas += "}"
+	if this.StandbyReplicas != nil {
+		s = append(s, "StandbyReplicas: "+mapStringForStandbyReplicas+",\n")
+	}
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
@@ -182,6 +222,23 @@ func (m *ReplicaDesc) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	if len(m.StandbyReplicas) > 0 {
+		for k := range m.StandbyReplicas {
+			v := m.StandbyReplicas[k]
+			baseI := i
+			i = encodeVarintHaTracker(dAtA, i, uint64(v))
+			i--
+			dAtA[i] = 0x10
+			i -= len(k)
+			copy(dAtA[i:], k)
+			i = encodeVarintHaTracker(dAtA, i, uint64(len(k)))
+			i--
+			dAtA[i] = 0xa
+			i = encodeVarintHaTracker(dAtA, i, uint64(baseI-i))
+			i--
+			dAtA[i] = 0x22
+		}
+	}
 	if m.DeletedAt != 0 {
 		i = encodeVarintHaTracker(dAtA, i, uint64(m.DeletedAt))
 		i--
@@ -229,6 +286,14 @@ func (m *ReplicaDesc) Size() (n int) {
 	if m.DeletedAt != 0 {
 		n += 1 + sovHaTracker(uint64(m.DeletedAt))
 	}
+	if len(m.StandbyReplicas) > 0 {
+		for k, v := range m.StandbyReplicas {
+			_ = k
+			_ = v
+			mapEntrySize := 1 + len(k) + sovHaTracker(uint64(len(k))) + 1 + sovHaTracker(uint64(v))
+			n += mapEntrySize + 1 + sovHaTracker(uint64(mapEntrySize))
+		}
+	}
 	return n
 }
 
@@ -242,10 +307,21 @@ func (this *ReplicaDesc) String() string {
 	if this == nil {
 		return "nil"
 	}
+	keysForStandbyReplicas := make([]string, 0, len(this.StandbyReplicas))
+	for k, _ := range this.StandbyReplicas {
+		keysForStandbyReplicas = append(keysForStandbyReplicas, k)
+	}
+	github_com_gogo_protobuf_sortkeys.Strings(keysForStandbyReplicas)
+	mapStringForStandbyReplicas := "map[string]int64{"
+	for _, k := range keysForStandbyReplicas {
+		mapStringForStandbyReplicas += fmt.Sprintf("%v: %v,", k, this.StandbyReplicas[k])
+	}
+	mapStringForStandbyReplicas += "}"
 	s := strings.Join([]string{`&ReplicaDesc{`,
 		`Replica:` + fmt.Sprintf("%v", this.Replica) + `,`,
 		`ReceivedAt:` + fmt.Sprintf("%v", this.ReceivedAt) + `,`,
 		`DeletedAt:` + fmt.Sprintf("%v", this.DeletedAt) + `,`,
+		`StandbyReplicas:` + mapStringForStandbyReplicas + `,`,
 		`}`,
 	}, "")
 	return s
@@ -357,6 +433,119 @@ func (m *ReplicaDesc) Unmarshal(dAtA []byte) error {
 					break
 				}
 			}
+		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field StandbyReplicas", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowHaTracker
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthHaTracker
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthHaTracker
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.StandbyReplicas == nil {
+				m.StandbyReplicas = make(map[string]int64)
+			}
+			var mapkey string
+			var mapvalue int64
+			for iNdEx < postIndex {
+				entryPreIndex := iNdEx
+				var wire uint64
+				for shift := uint(0); ; shift += 7 {
+					if shift >= 64 {
+						return ErrIntOverflowHaTracker
+					}
+					if iNdEx >= l {
+						return io.ErrUnexpectedEOF
+					}
+					b := dAtA[iNdEx]
+					iNdEx++
+					wire |= uint64(b&0x7F) << shift
+					if b < 0x80 {
+						break
+					}
+				}
+				fieldNum := int32(wire >> 3)
+				if fieldNum == 1 {
+					var stringLenmapkey uint64
+					for shift := uint(0); ; shift += 7 {
+						if shift >= 64 {
+							return ErrIntOverflowHaTracker
+						}
+						if iNdEx >= l {
+							return io.ErrUnexpectedEOF
+						}
+						b := dAtA[iNdEx]
+						iNdEx++
+						stringLenmapkey |= uint64(b&0x7F) << shift
+						if b < 0x80 {
+							break
+						}
+					}
+					intStringLenmapkey := int(stringLenmapkey)
+					if intStringLenmapkey < 0 {
+						return ErrInvalidLengthHaTracker
+					}
+					postStringIndexmapkey := iNdEx + intStringLenmapkey
+					if postStringIndexmapkey < 0 {
+						return ErrInvalidLengthHaTracker
+					}
+					if postStringIndexmapkey > l {
+						return io.ErrUnexpectedEOF
+					}
+					mapkey = string(dAtA[iNdEx:postStringIndexmapkey])
+					iNdEx = postStringIndexmapkey
+				} else if fieldNum == 2 {
+					for shift := uint(0); ; shift += 7 {
+						if shift >= 64 {
+							return ErrIntOverflowHaTracker
+						}
+						if iNdEx >= l {
+							return io.ErrUnexpectedEOF
+						}
+						b := dAtA[iNdEx]
+						iNdEx++
+						mapvalue |= int64(b&0x7F) << shift
+						if b < 0x80 {
+							break
+						}
+					}
+				} else {
+					iNdEx = entryPreIndex
+					skippy, err := skipHaTracker(dAtA[iNdEx:])
+					if err != nil {
+						return err
+					}
+					if skippy < 0 {
+						return ErrInvalidLengthHaTracker
+					}
+					if (iNdEx + skippy) > postIndex {
+						return io.ErrUnexpectedEOF
+					}
+					iNdEx += skippy
+				}
+			}
+			m.StandbyReplicas[mapkey] = mapvalue
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipHaTracker(dAtA[iNdEx:])
@@ -332,6 +332,73 @@ func TestCheckReplicaOverwriteTimeout(t *testing.T) {
 	assert.Error(t, err)
 }
 
+func TestCheckReplicaFastFailover(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		cluster               string
+		replicas              []string
+		expectedFastFailovers float64
+	}{
+		"should failover before the failover timeout when a quorum of replicas is sending samples": {
+			cluster:               "cluster-with-three-replicas",
+			replicas:              []string{"replica1", "replica2", "replica3"},
+			expectedFastFailovers: 1,
+		},
+		"should wait for the failover timeout with a pair of replicas": {
+			cluster:               "cluster-with-two-replicas",
+			replicas:              []string{"replica1", "replica2"},
+			expectedFastFailovers: 0,
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			t.Parallel()
+
+			c, err := NewHATracker(HATrackerConfig{
+				EnableHATracker:        true,
+				KVStore:                kv.Config{Store: "inmemory"},
+				UpdateTimeout:          time.Second,
+				UpdateTimeoutJitterMax: 0,
+			}, trackerLimits{maxReplicaGroups: 100, failoverTimeout: 10 * time.Second, fastFailoverTimeout: 3 * time.Second}, haTrackerStatusConfig, nil, "test-ha-tracker", log.NewNopLogger())
+			require.NoError(t, err)
+			require.NoError(t, services.StartAndAwaitRunning(context.Background(), c))
+			defer services.StopAndAwaitTerminated(context.Background(), c) //nolint:errcheck
+
+			ctx := context.Background()
+			start := time.Now()
+			elected, standbys := testData.replicas[0], testData.replicas[1:]
+
+			// All replicas are sending samples, only the elected one is accepted.
+			for _, offset := range []time.Duration{0, 2 * time.Second} {
+				require.NoError(t, c.CheckReplica(ctx, "user", testData.cluster, elected, start.Add(offset)))
+				for _, replica := range standbys {
+					require.ErrorIs(t, c.CheckReplica(ctx, "user", testData.cluster, replica, start.Add(offset)), ReplicasNotMatchError{})
+				}
+			}
+
+			// The elected replica stops sending samples, while the last standby replica keeps sending them.
+			last := standbys[len(standbys)-1]
+			require.ErrorIs(t, c.CheckReplica(ctx, "user", testData.cluster, last, start.Add(4*time.Second)), ReplicasNotMatchError{})
+
+			// The fast failover timeout has expired since the last sample of the elected replica.
+			err = c.CheckReplica(ctx, "user", testData.cluster, standbys[0], start.Add(5500*time.Millisecond))
+			if testData.expectedFastFailovers > 0 {
+				require.NoError(t, err)
+				require.ErrorIs(t, c.CheckReplica(ctx, "user", testData.cluster, elected, start.Add(5500*time.Millisecond)), ReplicasNotMatchError{})
+			} else {
+				require.ErrorIs(t, err, ReplicasNotMatchError{})
+
+				// The failover timeout has expired since the last sample of the elected replica.
+				require.NoError(t, c.CheckReplica(ctx, "user", testData.cluster, standbys[0], start.Add(12*time.Second)))
+			}
+
+			assert.Equal(t, testData.expectedFastFailovers, testutil.ToFloat64(c.electedReplicaFastFailovers.WithLabelValues("user", testData.cluster)))
+		})
+	}
+}
+
 func TestCheckReplicaMultiCluster(t *testing.T) {
 	t.Parallel()
 	replica1 := "replica1"
@@ -739,8 +806,9 @@ func TestReplicasNotMatchError(t *testing.T) {
 }
 
 type trackerLimits struct {
-	maxReplicaGroups int
-	failoverTimeout  time.Duration
+	maxReplicaGroups    int
+	failoverTimeout     time.Duration
+	fastFailoverTimeout time.Duration
 }
 
 func (l trackerLimits) MaxHAReplicaGroups(_ string) int {
@@ -751,6 +819,10 @@ func (l trackerLimits) HATrackerFailoverTimeout(_ string) time.Duration {
 	return l.failoverTimeout
 }
 
+func (l trackerLimits) HATrackerFastFailoverTimeout(_ string) time.Duration {
+	return l.fastFailoverTimeout
+}
+
 func TestHATracker_MetricsCleanup(t *testing.T) {
 	t.Parallel()
 	reg := prometheus.NewPedanticRegistry()
@@ -759,6 +831,7 @@ func TestHATracker_MetricsCleanup(t *testing.T) {
 
 	metrics := []string{
 		"cortex_ha_tracker_elected_replica_changes_total",
+		"cortex_ha_tracker_elected_replica_fast_failovers_total",
 		"cortex_ha_tracker_elected_replica_timestamp_seconds",
 		"cortex_ha_tracker_kv_store_cas_total",
 		"cortex_ha_tracker_user_replica_group_count",
@@ -767,6 +840,8 @@ func TestHATracker_MetricsCleanup(t *testing.T) {
 	tr.electedReplicaChanges.WithLabelValues("userA", "replicaGroup1").Add(5)
 	tr.electedReplicaChanges.WithLabelValues("userA", "replicaGroup2").Add(8)
 	tr.electedReplicaChanges.WithLabelValues("userB", "replicaGroup").Add(10)
+	tr.electedReplicaFastFailovers.WithLabelValues("userA", "replicaGroup1").Add(2)
+	tr.electedReplicaFastFailovers.WithLabelValues("userB", "replicaGroup").Add(3)
 	tr.electedReplicaTimestamp.WithLabelValues("userA", "replicaGroup1").Add(5)
 	tr.electedReplicaTimestamp.WithLabelValues("userA", "replicaGroup2").Add(8)
 	tr.electedReplicaTimestamp.WithLabelValues("userB", "replicaGroup").Add(10)
@@ -782,6 +857,11 @@ func TestHATracker_MetricsCleanup(t *testing.T) {
 		cortex_ha_tracker_elected_replica_changes_total{cluster="replicaGroup1",user="userA"} 5
 		cortex_ha_tracker_elected_replica_changes_total{cluster="replicaGroup2",user="userA"} 8
 
+		# HELP cortex_ha_tracker_elected_replica_fast_failovers_total The total number of times the HA tracker failed over to a new replica, before the failover timeout, because a quorum of replicas was still sending samples for a user ID/cluster.
+		# TYPE cortex_ha_tracker_elected_replica_fast_failovers_total counter
+		cortex_ha_tracker_elected_replica_fast_failovers_total{cluster="replicaGroup",user="userB"} 3
+		cortex_ha_tracker_elected_replica_fast_failovers_total{cluster="replicaGroup1",user="userA"} 2
+
 		# HELP cortex_ha_tracker_elected_replica_timestamp_seconds The timestamp stored for the currently elected replica, from the KVStore.
 		# TYPE cortex_ha_tracker_elected_replica_timestamp_seconds gauge
 		cortex_ha_tracker_elected_replica_timestamp_seconds{cluster="replicaGroup",user="userB"} 10
@@ -806,6 +886,10 @@ func TestHATracker_MetricsCleanup(t *testing.T) {
 		# TYPE cortex_ha_tracker_elected_replica_changes_total counter
 		cortex_ha_tracker_elected_replica_changes_total{cluster="replicaGroup",user="userB"} 10
 
+		# HELP cortex_ha_tracker_elected_replica_fast_failovers_total The total number of times the HA tracker failed over to a new replica, before the failover timeout, because a quorum of replicas was still sending samples for a user ID/cluster.
+		# TYPE cortex_ha_tracker_elected_replica_fast_failovers_total counter
+		cortex_ha_tracker_elected_replica_fast_failovers_total{cluster="replicaGroup",user="userB"} 3
+
 		# HELP cortex_ha_tracker_elected_replica_timestamp_seconds The timestamp stored for the currently elected replica, from the KVStore.
 		# TYPE cortex_ha_tracker_elected_replica_timestamp_seconds gauge
 		cortex_ha_tracker_elected_replica_timestamp_seconds{cluster="replicaGroup",user="userB"} 10
@@ -1264,6 +1348,46 @@ func TestReplicaDesc_Merge(t *testing.T) {
 	}
 }
 
+func TestReplicaDesc_Merge_StandbyReplicas(t *testing.T) {
+	now := timestamp.FromTime(time.Now())
+
+	current := &ReplicaDesc{
+		Replica:         "replica1",
+		ReceivedAt:      now,
+		StandbyReplicas: map[string]int64{"replica2": now - 1000, "replica3": now},
+	}
+
+	// Same elected replica, but more recent standby replicas.
+	change, err := current.Merge(&ReplicaDesc{
+		Replica:         "replica1",
+		ReceivedAt:      now,
+		StandbyReplicas: map[string]int64{"replica2": now, "replica3": now - 1000},
+	}, false)
+	require.NoError(t, err)
+	require.NotNil(t, change)
+	assert.Equal(t, map[string]int64{"replica2": now, "replica3": now}, current.StandbyReplicas)
+
+	// Nothing new.
+	change, err = current.Merge(&ReplicaDesc{
+		Replica:         "replica1",
+		ReceivedAt:      now,
+		StandbyReplicas: map[string]int64{"replica2": now},
+	}, false)
+	require.NoError(t, err)
+	require.Nil(t, change)
+
+	// A new replica has been elected, so it's not a standby replica anymore.
+	change, err = current.Merge(&ReplicaDesc{
+		Replica:         "replica2",
+		ReceivedAt:      now + 1000,
+		StandbyReplicas: map[string]int64{"replica1": now},
+	}, false)
+	require.NoError(t, err)
+	require.NotNil(t, change)
+	assert.Equal(t, "replica2", current.Replica)
+	assert.Equal(t, map[string]int64{"replica1": now, "replica3": now}, current.StandbyReplicas)
+}
+
 func TestReplicaDesc_Merge_Commutativity(t *testing.T) {
 	tests := []struct {
 		name  string
@@ -5,6 +5,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/prometheus/model/labels"
 	"go.uber.org/atomic"
 )
@@ -35,6 +36,7 @@ type activeSeriesStripe struct {
 type activeSeriesEntry struct {
 	lbs               labels.Labels
 	nanos             *atomic.Int64 // Unix timestamp in nanoseconds. Needs to be a pointer because we don't store pointers to entries in the stripe.
+	createdNanos      int64         // Unix timestamp in nanoseconds of when the series became active.
 	isNativeHistogram bool
 }
 
@@ -79,6 +81,14 @@ func (c *ActiveSeries) Active() int {
 	return total
 }
 
+// ObserveSeriesAge observes, for each active series, for how long it has been active as of now.
+func (c *ActiveSeries) ObserveSeriesAge(now time.Time, o prometheus.Observer) {
+	nowNanos := now.UnixNano()
+	for s := range numActiveSeriesStripes {
+		c.stripes[s].observeSeriesAge(nowNanos, o)
+	}
+}
+
 func (c *ActiveSeries) ActiveNativeHistogram() int {
 	total := 0
 	for s := range numActiveSeriesStripes {
@@ -145,6 +155,7 @@ func (s *activeSeriesStripe) findOrCreateEntryForSeries(fingerprint uint64, seri
 	e := activeSeriesEntry{
 		lbs:               labelsCopy(series),
 		nanos:             atomic.NewInt64(nowNanos),
+		createdNanos:      nowNanos,
 		isNativeHistogram: nativeHistogram,
 	}
 
@@ -235,6 +246,17 @@ func (s *activeSeriesStripe) purge(keepUntil time.Time) {
 	s.activeNativeHistogram = activeNativeHistogram
 }
 
+func (s *activeSeriesStripe) observeSeriesAge(nowNanos int64, o prometheus.Observer) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, entries := range s.refs {
+		for _, e := range entries {
+			o.Observe(time.Duration(max(nowNanos-e.createdNanos, 0)).Seconds())
+		}
+	}
+}
+
 func (s *activeSeriesStripe) getActive() int {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
@@ -69,6 +69,27 @@ func TestActiveSeries_Purge(t *testing.T) {
 	}
 }
 
+func TestActiveSeries_ObserveSeriesAge(t *testing.T) {
+	ls1 := labels.FromStrings("a", "1")
+	ls2 := labels.FromStrings("a", "2")
+	now := time.Now()
+
+	c := NewActiveSeries()
+	c.UpdateSeries(ls1, ls1.Hash(), now.Add(-time.Hour), false, copyFn)
+	c.UpdateSeries(ls2, ls2.Hash(), now.Add(-time.Minute), false, copyFn)
+
+	// Updating the series doesn't change when it became active.
+	c.UpdateSeries(ls1, ls1.Hash(), now, false, copyFn)
+
+	var ages []float64
+	c.ObserveSeriesAge(now, observerFunc(func(v float64) { ages = append(ages, v) }))
+	assert.ElementsMatch(t, []float64{3600, 60}, ages)
+}
+
+type observerFunc func(float64)
+
+func (f observerFunc) Observe(v float64) { f(v) }
+
 func TestActiveSeries_PurgeOpt(t *testing.T) {
 	metric := labels.NewBuilder(labels.FromStrings("__name__", "logs"))
 	ls1 := metric.Set("_", "ypfajYg2lsv").Labels()
@@ -2,6 +2,7 @@ package client
 
 import (
 	"fmt"
+	"sort"
 
 	"github.com/prometheus/common/model"
 	"github.com/prometheus/prometheus/model/labels"
@@ -232,6 +233,50 @@ func FromLabelNamesRequest(cache storecache.MatchersCache, req *LabelNamesReques
 	return req.StartTimestampMs, req.EndTimestampMs, int(req.Limit), matchers, nil
 }
 
+// ToCardinalityRequest builds a CardinalityRequest proto
+func ToCardinalityRequest(limit int, matchers []*labels.Matcher) (*CardinalityRequest, error) {
+	ms, err := toLabelMatchers(matchers)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CardinalityRequest{
+		Limit:    int32(limit),
+		Matchers: ms,
+	}, nil
+}
+
+// FromCardinalityRequest unpacks a CardinalityRequest proto
+func FromCardinalityRequest(cache storecache.MatchersCache, req *CardinalityRequest) (int, []*labels.Matcher, error) {
+	matchers, err := FromLabelMatchers(cache, req.Matchers)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return int(req.Limit), matchers, nil
+}
+
+// TopCardinalityStats returns the limit stats with the highest value, sorted by value
+// in descending order and then by name.
+func TopCardinalityStats(values map[string]uint64, limit int) []CardinalityStat {
+	stats := make([]CardinalityStat, 0, len(values))
+	for name, value := range values {
+		stats = append(stats, CardinalityStat{Name: name, Value: value})
+	}
+
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Value != stats[j].Value {
+			return stats[i].Value > stats[j].Value
+		}
+		return stats[i].Name < stats[j].Name
+	})
+
+	if len(stats) > limit {
+		stats = stats[:limit]
+	}
+	return stats
+}
+
 func toLabelMatchers(matchers []*labels.Matcher) ([]*LabelMatcher, error) {
 	result := make([]*LabelMatcher, 0, len(matchers))
 	for _, matcher := range matchers {
@@ -67,6 +67,11 @@ func (m *IngesterServerMock) AllUserStats(ctx context.Context, r *UserStatsReque
 	return args.Get(0).(*UsersStatsResponse), args.Error(1)
 }
 
+func (m *IngesterServerMock) Cardinality(ctx context.Context, r *CardinalityRequest) (*CardinalityResponse, error) {
+	args := m.Called(ctx, r)
+	return args.Get(0).(*CardinalityResponse), args.Error(1)
+}
+
 func (m *IngesterServerMock) MetricsForLabelMatchers(ctx context.Context, r *MetricsForLabelMatchersRequest) (*MetricsForLabelMatchersResponse, error) {
 	args := m.Called(ctx, r)
 	return args.Get(0).(*MetricsForLabelMatchersResponse), args.Error(1)
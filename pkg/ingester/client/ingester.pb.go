@@ -357,6 +357,9 @@ func (m *QueryStreamResponse) GetChunkseries() []TimeSeriesChunk {
 
 type ExemplarQueryResponse struct {
 	Timeseries []cortexpb.TimeSeries `protobuf:"bytes,1,rep,name=timeseries,proto3" json:"timeseries"`
+	// Whether the exemplars have been truncated because they exceeded the maximum
+	// number of exemplars returned per query.
+	Truncated bool `protobuf:"varint,2,opt,name=truncated,proto3" json:"truncated,omitempty"`
 }
 
 func (m *ExemplarQueryResponse) Reset()      { *m = ExemplarQueryResponse{} }
@@ -398,6 +401,13 @@ func (m *ExemplarQueryResponse) GetTimeseries() []cortexpb.TimeSeries {
 	return nil
 }
 
+func (m *ExemplarQueryResponse) GetTruncated() bool {
+	if m != nil {
+		return m.Truncated
+	}
+	return false
+}
+
 type LabelValuesRequest struct {
 	LabelName        string         `protobuf:"bytes,1,opt,name=label_name,json=labelName,proto3" json:"label_name,omitempty"`
 	StartTimestampMs int64          `protobuf:"varint,2,opt,name=start_timestamp_ms,json=startTimestampMs,proto3" json:"start_timestamp_ms,omitempty"`
@@ -924,6 +934,203 @@ func (m *UsersStatsResponse) GetStats() []*UserIDStatsResponse {
 	return nil
 }
 
+type CardinalityRequest struct {
+	// Max number of stats returned of each kind.
+	Limit int32 `protobuf:"varint,1,opt,name=limit,proto3" json:"limit,omitempty"`
+	// Matchers of the series to analyze. All the series are analyzed if empty.
+	Matchers []*LabelMatcher `protobuf:"bytes,2,rep,name=matchers,proto3" json:"matchers,omitempty"`
+}
+
+func (m *CardinalityRequest) Reset()      { *m = CardinalityRequest{} }
+func (*CardinalityRequest) ProtoMessage() {}
+func (*CardinalityRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_60f6df4f3586b478, []int{17}
+}
+func (m *CardinalityRequest) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *CardinalityRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_CardinalityRequest.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *CardinalityRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CardinalityRequest.Merge(m, src)
+}
+func (m *CardinalityRequest) XXX_Size() int {
+	return m.Size()
+}
+func (m *CardinalityRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_CardinalityRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CardinalityRequest proto.InternalMessageInfo
+
+func (m *CardinalityRequest) GetLimit() int32 {
+	if m != nil {
+		return m.Limit
+	}
+	return 0
+}
+
+func (m *CardinalityRequest) GetMatchers() []*LabelMatcher {
+	if m != nil {
+		return m.Matchers
+	}
+	return nil
+}
+
+type CardinalityResponse struct {
+	NumSeries                   uint64            `protobuf:"varint,1,opt,name=num_series,json=numSeries,proto3" json:"num_series,omitempty"`
+	SeriesCountByMetricName     []CardinalityStat `protobuf:"bytes,2,rep,name=series_count_by_metric_name,json=seriesCountByMetricName,proto3" json:"series_count_by_metric_name"`
+	LabelValueCountByLabelName  []CardinalityStat `protobuf:"bytes,3,rep,name=label_value_count_by_label_name,json=labelValueCountByLabelName,proto3" json:"label_value_count_by_label_name"`
+	SeriesCountByLabelValuePair []CardinalityStat `protobuf:"bytes,4,rep,name=series_count_by_label_value_pair,json=seriesCountByLabelValuePair,proto3" json:"series_count_by_label_value_pair"`
+	// Number of chunks of the analyzed series. Only counted when matchers are set.
+	NumChunks uint64 `protobuf:"varint,5,opt,name=num_chunks,json=numChunks,proto3" json:"num_chunks,omitempty"`
+	// Time range of the in-memory samples.
+	MinTimestampMs int64 `protobuf:"varint,6,opt,name=min_timestamp_ms,json=minTimestampMs,proto3" json:"min_timestamp_ms,omitempty"`
+	MaxTimestampMs int64 `protobuf:"varint,7,opt,name=max_timestamp_ms,json=maxTimestampMs,proto3" json:"max_timestamp_ms,omitempty"`
+}
+
+func (m *CardinalityResponse) Reset()      { *m = CardinalityResponse{} }
+func (*CardinalityResponse) ProtoMessage() {}
+func (*CardinalityResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_60f6df4f3586b478, []int{18}
+}
+func (m *CardinalityResponse) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *CardinalityResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_CardinalityResponse.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *CardinalityResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CardinalityResponse.Merge(m, src)
+}
+func (m *CardinalityResponse) XXX_Size() int {
+	return m.Size()
+}
+func (m *CardinalityResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_CardinalityResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CardinalityResponse proto.InternalMessageInfo
+
+func (m *CardinalityResponse) GetNumSeries() uint64 {
+	if m != nil {
+		return m.NumSeries
+	}
+	return 0
+}
+
+func (m *CardinalityResponse) GetSeriesCountByMetricName() []CardinalityStat {
+	if m != nil {
+		return m.SeriesCountByMetricName
+	}
+	return nil
+}
+
+func (m *CardinalityResponse) GetLabelValueCountByLabelName() []CardinalityStat {
+	if m != nil {
+		return m.LabelValueCountByLabelName
+	}
+	return nil
+}
+
+func (m *CardinalityResponse) GetSeriesCountByLabelValuePair() []CardinalityStat {
+	if m != nil {
+		return m.SeriesCountByLabelValuePair
+	}
+	return nil
+}
+
+func (m *CardinalityResponse) GetNumChunks() uint64 {
+	if m != nil {
+		return m.NumChunks
+	}
+	return 0
+}
+
+func (m *CardinalityResponse) GetMinTimestampMs() int64 {
+	if m != nil {
+		return m.MinTimestampMs
+	}
+	return 0
+}
+
+func (m *CardinalityResponse) GetMaxTimestampMs() int64 {
+	if m != nil {
+		return m.MaxTimestampMs
+	}
+	return 0
+}
+
+type CardinalityStat struct {
+	Name  string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Value uint64 `protobuf:"varint,2,opt,name=value,proto3" json:"value,omitempty"`
+}
+
+func (m *CardinalityStat) Reset()      { *m = CardinalityStat{} }
+func (*CardinalityStat) ProtoMessage() {}
+func (*CardinalityStat) Descriptor() ([]byte, []int) {
+	return fileDescriptor_60f6df4f3586b478, []int{19}
+}
+func (m *CardinalityStat) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *CardinalityStat) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_CardinalityStat.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *CardinalityStat) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CardinalityStat.Merge(m, src)
+}
+func (m *CardinalityStat) XXX_Size() int {
+	return m.Size()
+}
+func (m *CardinalityStat) XXX_DiscardUnknown() {
+	xxx_messageInfo_CardinalityStat.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CardinalityStat proto.InternalMessageInfo
+
+func (m *CardinalityStat) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *CardinalityStat) GetValue() uint64 {
+	if m != nil {
+		return m.Value
+	}
+	return 0
+}
+
 type MetricsForLabelMatchersRequest struct {
 	StartTimestampMs int64            `protobuf:"varint,1,opt,name=start_timestamp_ms,json=startTimestampMs,proto3" json:"start_timestamp_ms,omitempty"`
 	EndTimestampMs   int64            `protobuf:"varint,2,opt,name=end_timestamp_ms,json=endTimestampMs,proto3" json:"end_timestamp_ms,omitempty"`
@@ -934,7 +1141,7 @@ type MetricsForLabelMatchersRequest struct {
 func (m *MetricsForLabelMatchersRequest) Reset()      { *m = MetricsForLabelMatchersRequest{} }
 func (*MetricsForLabelMatchersRequest) ProtoMessage() {}
 func (*MetricsForLabelMatchersRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_60f6df4f3586b478, []int{17}
+	return fileDescriptor_60f6df4f3586b478, []int{20}
 }
 func (m *MetricsForLabelMatchersRequest) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -998,7 +1205,7 @@ type MetricsForLabelMatchersResponse struct {
 func (m *MetricsForLabelMatchersResponse) Reset()      { *m = MetricsForLabelMatchersResponse{} }
 func (*MetricsForLabelMatchersResponse) ProtoMessage() {}
 func (*MetricsForLabelMatchersResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_60f6df4f3586b478, []int{18}
+	return fileDescriptor_60f6df4f3586b478, []int{21}
 }
 func (m *MetricsForLabelMatchersResponse) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1041,7 +1248,7 @@ type MetricsForLabelMatchersStreamResponse struct {
 func (m *MetricsForLabelMatchersStreamResponse) Reset()      { *m = MetricsForLabelMatchersStreamResponse{} }
 func (*MetricsForLabelMatchersStreamResponse) ProtoMessage() {}
 func (*MetricsForLabelMatchersStreamResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_60f6df4f3586b478, []int{19}
+	return fileDescriptor_60f6df4f3586b478, []int{22}
 }
 func (m *MetricsForLabelMatchersStreamResponse) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1086,7 +1293,7 @@ type MetricsMetadataRequest struct {
 func (m *MetricsMetadataRequest) Reset()      { *m = MetricsMetadataRequest{} }
 func (*MetricsMetadataRequest) ProtoMessage() {}
 func (*MetricsMetadataRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_60f6df4f3586b478, []int{20}
+	return fileDescriptor_60f6df4f3586b478, []int{23}
 }
 func (m *MetricsMetadataRequest) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1143,7 +1350,7 @@ type MetricsMetadataResponse struct {
 func (m *MetricsMetadataResponse) Reset()      { *m = MetricsMetadataResponse{} }
 func (*MetricsMetadataResponse) ProtoMessage() {}
 func (*MetricsMetadataResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_60f6df4f3586b478, []int{21}
+	return fileDescriptor_60f6df4f3586b478, []int{24}
 }
 func (m *MetricsMetadataResponse) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1189,7 +1396,7 @@ type TimeSeriesChunk struct {
 func (m *TimeSeriesChunk) Reset()      { *m = TimeSeriesChunk{} }
 func (*TimeSeriesChunk) ProtoMessage() {}
 func (*TimeSeriesChunk) Descriptor() ([]byte, []int) {
-	return fileDescriptor_60f6df4f3586b478, []int{22}
+	return fileDescriptor_60f6df4f3586b478, []int{25}
 }
 func (m *TimeSeriesChunk) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1249,7 +1456,7 @@ type Chunk struct {
 func (m *Chunk) Reset()      { *m = Chunk{} }
 func (*Chunk) ProtoMessage() {}
 func (*Chunk) Descriptor() ([]byte, []int) {
-	return fileDescriptor_60f6df4f3586b478, []int{23}
+	return fileDescriptor_60f6df4f3586b478, []int{26}
 }
 func (m *Chunk) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1313,7 +1520,7 @@ type LabelMatchers struct {
 func (m *LabelMatchers) Reset()      { *m = LabelMatchers{} }
 func (*LabelMatchers) ProtoMessage() {}
 func (*LabelMatchers) Descriptor() ([]byte, []int) {
-	return fileDescriptor_60f6df4f3586b478, []int{24}
+	return fileDescriptor_60f6df4f3586b478, []int{27}
 }
 func (m *LabelMatchers) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1358,7 +1565,7 @@ type LabelMatcher struct {
 func (m *LabelMatcher) Reset()      { *m = LabelMatcher{} }
 func (*LabelMatcher) ProtoMessage() {}
 func (*LabelMatcher) Descriptor() ([]byte, []int) {
-	return fileDescriptor_60f6df4f3586b478, []int{25}
+	return fileDescriptor_60f6df4f3586b478, []int{28}
 }
 func (m *LabelMatcher) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1418,7 +1625,7 @@ type TimeSeriesFile struct {
 func (m *TimeSeriesFile) Reset()      { *m = TimeSeriesFile{} }
 func (*TimeSeriesFile) ProtoMessage() {}
 func (*TimeSeriesFile) Descriptor() ([]byte, []int) {
-	return fileDescriptor_60f6df4f3586b478, []int{26}
+	return fileDescriptor_60f6df4f3586b478, []int{29}
 }
 func (m *TimeSeriesFile) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1494,6 +1701,9 @@ func init() {
 	proto.RegisterType((*UserStatsResponse)(nil), "cortex.UserStatsResponse")
 	proto.RegisterType((*UserIDStatsResponse)(nil), "cortex.UserIDStatsResponse")
 	proto.RegisterType((*UsersStatsResponse)(nil), "cortex.UsersStatsResponse")
+	proto.RegisterType((*CardinalityRequest)(nil), "cortex.CardinalityRequest")
+	proto.RegisterType((*CardinalityResponse)(nil), "cortex.CardinalityResponse")
+	proto.RegisterType((*CardinalityStat)(nil), "cortex.CardinalityStat")
 	proto.RegisterType((*MetricsForLabelMatchersRequest)(nil), "cortex.MetricsForLabelMatchersRequest")
 	proto.RegisterType((*MetricsForLabelMatchersResponse)(nil), "cortex.MetricsForLabelMatchersResponse")
 	proto.RegisterType((*MetricsForLabelMatchersStreamResponse)(nil), "cortex.MetricsForLabelMatchersStreamResponse")
@@ -1509,97 +1719,110 @@ func init() {
 func init() { proto.RegisterFile("ingester.proto", fileDescriptor_60f6df4f3586b478) }
 
 var fileDescriptor_60f6df4f3586b478 = []byte{
-	// 1439 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xbc, 0x58, 0x4b, 0x73, 0x13, 0x47,
-	0x10, 0xd6, 0xea, 0x65, 0xa9, 0xf5, 0x40, 0x1e, 0x1b, 0x2c, 0x44, 0x58, 0xc1, 0x52, 0x24, 0xaa,
-	0x24, 0xc8, 0xe0, 0x24, 0x55, 0x90, 0x07, 0x94, 0x05, 0x06, 0x0c, 0x18, 0xc3, 0xda, 0x40, 0x2a,
-	0x95, 0xd4, 0xd6, 0x5a, 0x1a, 0xcb, 0x1b, 0x76, 0xb5, 0xcb, 0xce, 0x88, 0x02, 0x4e, 0x49, 0xe5,
-	0x07, 0x24, 0x87, 0xfc, 0x81, 0xdc, 0x72, 0x4d, 0x55, 0x7e, 0x04, 0x47, 0x1f, 0x72, 0xa0, 0x38,
-	0xb8, 0x82, 0xb8, 0x24, 0x37, 0xf2, 0x0f, 0x52, 0x3b, 0x33, 0xfb, 0xb4, 0xfc, 0x20, 0x05, 0xb9,
-	0x69, 0xbb, 0xbf, 0xe9, 0xe9, 0xfe, 0xe6, 0x9b, 0xe9, 0xb6, 0xa1, 0x6a, 0x0c, 0xfa, 0x98, 0x50,
-	0xec, 0xb6, 0x1d, 0xd7, 0xa6, 0x36, 0xca, 0x77, 0x6d, 0x97, 0xe2, 0x47, 0x8d, 0xe9, 0xbe, 0xdd,
-	0xb7, 0x99, 0x69, 0xd6, 0xfb, 0xc5, 0xbd, 0x8d, 0x73, 0x7d, 0x83, 0x6e, 0x0c, 0xd7, 0xda, 0x5d,
-	0xdb, 0x9a, 0xe5, 0x40, 0xc7, 0xb5, 0xbf, 0xc5, 0x5d, 0x2a, 0xbe, 0x66, 0x9d, 0xfb, 0x7d, 0xdf,
-	0xb1, 0x26, 0x7e, 0xf0, 0xa5, 0xca, 0x17, 0x50, 0x52, 0xb1, 0xde, 0x53, 0xf1, 0x83, 0x21, 0x26,
-	0x14, 0xb5, 0x61, 0xe2, 0xc1, 0x10, 0xbb, 0x06, 0x26, 0x75, 0xe9, 0x58, 0xa6, 0x55, 0x9a, 0x9b,
-	0x6e, 0x0b, 0xf8, 0xed, 0x21, 0x76, 0x1f, 0x0b, 0x98, 0xea, 0x83, 0x94, 0x0b, 0x50, 0xe6, 0xcb,
-	0x89, 0x63, 0x0f, 0x08, 0x46, 0xb3, 0x30, 0xe1, 0x62, 0x32, 0x34, 0xa9, 0xbf, 0xfe, 0x60, 0x62,
-	0x3d, 0xc7, 0xa9, 0x3e, 0x4a, 0xb9, 0x0e, 0x95, 0x98, 0x07, 0x7d, 0x0a, 0x40, 0x0d, 0x0b, 0x93,
-	0x71, 0x49, 0x38, 0x6b, 0xed, 0x55, 0xc3, 0xc2, 0x2b, 0xcc, 0xd7, 0xc9, 0x3e, 0xdd, 0x6a, 0xa6,
-	0xd4, 0x08, 0x5a, 0xf9, 0x39, 0x0d, 0xe5, 0x68, 0x9e, 0xe8, 0x43, 0x40, 0x84, 0xea, 0x2e, 0xd5,
-	0x18, 0x88, 0xea, 0x96, 0xa3, 0x59, 0x5e, 0x50, 0xa9, 0x95, 0x51, 0x6b, 0xcc, 0xb3, 0xea, 0x3b,
-	0x96, 0x08, 0x6a, 0x41, 0x0d, 0x0f, 0x7a, 0x71, 0x6c, 0x9a, 0x61, 0xab, 0x78, 0xd0, 0x8b, 0x22,
-	0x4f, 0x43, 0xc1, 0xd2, 0x69, 0x77, 0x03, 0xbb, 0xa4, 0x9e, 0x89, 0xf3, 0x74, 0x43, 0x5f, 0xc3,
-	0xe6, 0x12, 0x77, 0xaa, 0x01, 0x0a, 0x3d, 0x81, 0x8c, 0x8a, 0xd7, 0xeb, 0x7f, 0x4f, 0x1c, 0x93,
-	0x5a, 0xa5, 0xb9, 0x23, 0x61, 0x41, 0x4b, 0x98, 0x10, 0xbd, 0x8f, 0xef, 0x19, 0x74, 0xa3, 0x33,
-	0x5c, 0x57, 0xf1, 0x7a, 0xe7, 0x9a, 0x57, 0xd7, 0xe6, 0x56, 0x53, 0x7a, 0xbe, 0xd5, 0x3c, 0xff,
-	0x3a, 0x27, 0xbb, 0x3d, 0x96, 0xea, 0x6d, 0xaa, 0xfc, 0x22, 0xc1, 0xf4, 0xc2, 0x23, 0x6c, 0x39,
-	0xa6, 0xee, 0xfe, 0x2f, 0xf4, 0x9c, 0xd9, 0x46, 0xcf, 0xc1, 0x71, 0xf4, 0x90, 0x90, 0x1f, 0xe5,
-	0x6b, 0x98, 0x62, 0xa9, 0xad, 0x50, 0x17, 0xeb, 0x56, 0xa0, 0x86, 0x0b, 0x50, 0xea, 0x6e, 0x0c,
-	0x07, 0xf7, 0x63, 0x72, 0x98, 0xf1, 0x83, 0x85, 0x62, 0xb8, 0xe8, 0x81, 0x84, 0x22, 0xa2, 0x2b,
-	0xae, 0x65, 0x0b, 0xe9, 0x5a, 0x46, 0x59, 0x81, 0x83, 0x09, 0x02, 0xde, 0x80, 0xda, 0xfe, 0x90,
-	0x00, 0xb1, 0x72, 0xee, 0xea, 0xe6, 0x10, 0x13, 0x9f, 0xd4, 0xa3, 0x00, 0xa6, 0x67, 0xd5, 0x06,
-	0xba, 0x85, 0x19, 0x99, 0x45, 0xb5, 0xc8, 0x2c, 0x37, 0x75, 0x0b, 0xef, 0xc0, 0x79, 0xfa, 0x35,
-	0x38, 0xcf, 0xec, 0xc9, 0x79, 0x96, 0x89, 0x6c, 0x2f, 0xce, 0xd1, 0x34, 0xe4, 0x4c, 0xc3, 0x32,
-	0x68, 0x3d, 0xc7, 0x22, 0xf2, 0x0f, 0xe5, 0x2c, 0x4c, 0xc5, 0xaa, 0x12, 0x4c, 0x1d, 0x87, 0x32,
-	0x2f, 0xeb, 0x21, 0xb3, 0x33, 0xae, 0x8a, 0x6a, 0xc9, 0x0c, 0xa1, 0xca, 0x79, 0x38, 0x1c, 0x59,
-	0x99, 0x38, 0xc9, 0x7d, 0xac, 0xff, 0x5d, 0x82, 0xc9, 0x1b, 0x3e, 0x51, 0xe4, 0x6d, 0x8b, 0x34,
-	0xa8, 0x3e, 0x13, 0xa9, 0xfe, 0x3f, 0xd0, 0xa8, 0x7c, 0x22, 0x64, 0x20, 0xb2, 0x16, 0xf5, 0x36,
-	0xa1, 0x14, 0xca, 0xc0, 0x2f, 0x17, 0x02, 0x1d, 0x10, 0xe5, 0x33, 0xa8, 0x87, 0xcb, 0x12, 0x64,
-	0xed, 0xb9, 0x18, 0x41, 0xed, 0x0e, 0xc1, 0xee, 0x0a, 0xd5, 0xa9, 0x4f, 0x94, 0xf2, 0x7d, 0x1a,
-	0x26, 0x23, 0x46, 0x11, 0xea, 0xa4, 0xdf, 0x4b, 0x0c, 0x7b, 0xa0, 0xb9, 0x3a, 0xe5, 0x92, 0x94,
-	0xd4, 0x4a, 0x60, 0x55, 0x75, 0x8a, 0x3d, 0xd5, 0x0e, 0x86, 0x96, 0x26, 0x2e, 0x82, 0xc7, 0x58,
-	0x56, 0x2d, 0x0e, 0x86, 0x16, 0x57, 0xbf, 0x77, 0x08, 0xba, 0x63, 0x68, 0x89, 0x48, 0x19, 0x16,
-	0xa9, 0xa6, 0x3b, 0xc6, 0x62, 0x2c, 0x58, 0x1b, 0xa6, 0xdc, 0xa1, 0x89, 0x93, 0xf0, 0x2c, 0x83,
-	0x4f, 0x7a, 0xae, 0x38, 0xfe, 0x04, 0x54, 0xf4, 0x2e, 0x35, 0x1e, 0x62, 0x7f, 0xff, 0x1c, 0xdb,
-	0xbf, 0xcc, 0x8d, 0x22, 0x85, 0x13, 0x50, 0x31, 0x6d, 0xbd, 0x87, 0x7b, 0xda, 0x9a, 0x69, 0x77,
-	0xef, 0x93, 0x7a, 0x9e, 0x83, 0xb8, 0xb1, 0xc3, 0x6c, 0xca, 0x37, 0x30, 0xe5, 0x51, 0xb0, 0x78,
-	0x29, 0x4e, 0xc2, 0x0c, 0x4c, 0x0c, 0x09, 0x76, 0x35, 0xa3, 0x27, 0x2e, 0x64, 0xde, 0xfb, 0x5c,
-	0xec, 0xa1, 0x53, 0x90, 0xed, 0xe9, 0x54, 0x67, 0x05, 0x97, 0xe6, 0x0e, 0xfb, 0x47, 0xbd, 0x8d,
-	0x46, 0x95, 0xc1, 0x94, 0x2b, 0x80, 0x3c, 0x17, 0x89, 0x47, 0x3f, 0x03, 0x39, 0xe2, 0x19, 0xc4,
-	0xfb, 0x71, 0x24, 0x1a, 0x25, 0x91, 0x89, 0xca, 0x91, 0xca, 0x53, 0x09, 0xe4, 0x25, 0x4c, 0x5d,
-	0xa3, 0x4b, 0x2e, 0xdb, 0x6e, 0x5c, 0x59, 0x6f, 0x59, 0xf7, 0x67, 0xa1, 0xec, 0x4b, 0x57, 0x23,
-	0x98, 0xee, 0xfe, 0x40, 0x97, 0x7c, 0xe8, 0x0a, 0xa6, 0xe1, 0x8d, 0xc9, 0x46, 0xdf, 0x8b, 0xeb,
-	0xd0, 0xdc, 0xb1, 0x12, 0x41, 0x50, 0x0b, 0xf2, 0x16, 0x83, 0x08, 0x86, 0x6a, 0xd1, 0xf6, 0xe7,
-	0xd9, 0x55, 0xe1, 0x57, 0x6e, 0xc3, 0xc9, 0x1d, 0x82, 0x25, 0x6e, 0xc8, 0xfe, 0x43, 0x3a, 0x70,
-	0x48, 0x84, 0x5c, 0xc2, 0x54, 0xf7, 0x8e, 0xd1, 0x67, 0x38, 0xa8, 0x47, 0x8a, 0xbe, 0x00, 0x2d,
-	0xa8, 0xb1, 0x1f, 0x9a, 0x83, 0x5d, 0x4d, 0xec, 0x21, 0x98, 0x64, 0xf6, 0x5b, 0xd8, 0xe5, 0xf1,
-	0xd0, 0xa1, 0x20, 0x87, 0x0c, 0x17, 0x95, 0xd8, 0x71, 0x19, 0x66, 0xb6, 0xed, 0x28, 0xd2, 0xfe,
-	0x18, 0x0a, 0x96, 0xb0, 0x89, 0xc4, 0xeb, 0xc9, 0xc4, 0x83, 0x35, 0x01, 0x52, 0xf9, 0x47, 0x82,
-	0x03, 0x89, 0x5e, 0xe7, 0xa5, 0xb9, 0xee, 0xda, 0x96, 0xe6, 0x0f, 0x8a, 0xa1, 0xb6, 0xab, 0x9e,
-	0x7d, 0x51, 0x98, 0x17, 0x7b, 0x51, 0xf1, 0xa7, 0x63, 0xe2, 0x1f, 0x40, 0x9e, 0x3d, 0x29, 0x7e,
-	0x93, 0x9e, 0x0a, 0x53, 0x61, 0xd4, 0xdf, 0xd2, 0x0d, 0xb7, 0x33, 0xef, 0xf5, 0xbd, 0xe7, 0x5b,
-	0xcd, 0xd7, 0x9a, 0x31, 0xf9, 0xfa, 0xf9, 0x9e, 0xee, 0x50, 0xec, 0xaa, 0x62, 0x17, 0xf4, 0x01,
-	0xe4, 0x79, 0x6b, 0xae, 0x67, 0xd9, 0x7e, 0x15, 0x5f, 0x73, 0xd1, 0xee, 0x2d, 0x20, 0xca, 0x8f,
-	0x12, 0xe4, 0x78, 0xa5, 0x6f, 0xeb, 0x22, 0x34, 0xa0, 0x80, 0x07, 0x5d, 0xbb, 0x67, 0x0c, 0xfa,
-	0xec, 0x00, 0x73, 0x6a, 0xf0, 0x8d, 0x90, 0x78, 0x17, 0x3c, 0xa5, 0x97, 0xc5, 0xe5, 0x9f, 0x87,
-	0x4a, 0x4c, 0x91, 0xb1, 0x29, 0x50, 0xda, 0xcf, 0x14, 0xa8, 0x68, 0x50, 0x8e, 0x7a, 0xd0, 0x49,
-	0xc8, 0xd2, 0xc7, 0x0e, 0x7f, 0x92, 0xab, 0x73, 0x93, 0xfe, 0x6a, 0xe6, 0x5e, 0x7d, 0xec, 0x60,
-	0x95, 0xb9, 0xbd, 0x6c, 0xd8, 0x30, 0xc1, 0x8f, 0x8f, 0xfd, 0xf6, 0xc4, 0xcb, 0x3a, 0xa9, 0xd0,
-	0x1e, 0xff, 0x50, 0x7e, 0x90, 0xa0, 0x1a, 0x2a, 0xe5, 0xb2, 0x61, 0xe2, 0x37, 0x21, 0x94, 0x06,
-	0x14, 0xd6, 0x0d, 0x13, 0xb3, 0x1c, 0xf8, 0x76, 0xc1, 0xf7, 0x38, 0xa6, 0xde, 0xbf, 0x06, 0xc5,
-	0xa0, 0x04, 0x54, 0x84, 0xdc, 0xc2, 0xed, 0x3b, 0xf3, 0x37, 0x6a, 0x29, 0x54, 0x81, 0xe2, 0xcd,
-	0xe5, 0x55, 0x8d, 0x7f, 0x4a, 0xe8, 0x00, 0x94, 0xd4, 0x85, 0x2b, 0x0b, 0x5f, 0x6a, 0x4b, 0xf3,
-	0xab, 0x17, 0xaf, 0xd6, 0xd2, 0x08, 0x41, 0x95, 0x1b, 0x6e, 0x2e, 0x0b, 0x5b, 0x66, 0xee, 0xb7,
-	0x02, 0x14, 0xfc, 0x1c, 0xd1, 0x39, 0xc8, 0xde, 0x1a, 0x92, 0x0d, 0x74, 0x28, 0x54, 0xea, 0x3d,
-	0xd7, 0xa0, 0x58, 0xdc, 0xe8, 0xc6, 0xcc, 0x36, 0x3b, 0xbf, 0x77, 0x4a, 0x0a, 0x2d, 0x02, 0x78,
-	0x4b, 0xf9, 0x33, 0x82, 0xde, 0x09, 0x81, 0xdc, 0xb2, 0xcf, 0x30, 0x2d, 0xe9, 0xb4, 0x84, 0x2e,
-	0x41, 0x29, 0x32, 0xab, 0xa2, 0xb1, 0x7f, 0x22, 0x35, 0x8e, 0xc4, 0xac, 0xf1, 0xd7, 0x4b, 0x49,
-	0x9d, 0x96, 0xd0, 0x32, 0x54, 0x99, 0xcb, 0x1f, 0x4c, 0x49, 0x90, 0x54, 0x7b, 0xdc, 0xb0, 0xde,
-	0x38, 0xba, 0x83, 0x37, 0xa8, 0xf0, 0x2a, 0x94, 0x22, 0xe3, 0x17, 0x6a, 0xc4, 0xb4, 0x18, 0x9b,
-	0x51, 0xc3, 0xe4, 0xc6, 0x4c, 0x7a, 0x4a, 0x0a, 0xdd, 0x15, 0x73, 0x58, 0x74, 0x90, 0xdb, 0x35,
-	0xde, 0xf1, 0x31, 0xbe, 0x31, 0x25, 0x2f, 0x00, 0x84, 0x23, 0x0f, 0x3a, 0x1c, 0x5b, 0x14, 0x9d,
-	0xf9, 0x1a, 0x8d, 0x71, 0xae, 0x20, 0xbd, 0x15, 0xa8, 0x25, 0x27, 0xa7, 0xdd, 0x82, 0x1d, 0xdb,
-	0xee, 0x1a, 0x93, 0x5b, 0x07, 0x8a, 0x41, 0xd7, 0x47, 0xf5, 0x31, 0x83, 0x00, 0x0f, 0xb6, 0xf3,
-	0x88, 0xa0, 0xa4, 0xd0, 0x65, 0x28, 0xcf, 0x9b, 0xe6, 0x7e, 0xc2, 0x34, 0xa2, 0x1e, 0x92, 0x8c,
-	0x63, 0x06, 0x0d, 0x24, 0xd9, 0x05, 0xd1, 0xbb, 0xc1, 0x1b, 0xb1, 0xeb, 0xf4, 0xd0, 0x78, 0x6f,
-	0x4f, 0x5c, 0xb0, 0xdb, 0x13, 0x38, 0xba, 0x6b, 0xcf, 0xdd, 0xf7, 0x9e, 0xa7, 0xf6, 0xc0, 0x8d,
-	0x61, 0x7d, 0x15, 0x0e, 0x24, 0x5a, 0x25, 0x92, 0x13, 0x51, 0x12, 0x5d, 0xbb, 0xd1, 0xdc, 0xd1,
-	0xef, 0xc7, 0xed, 0x7c, 0xbe, 0xf9, 0x42, 0x4e, 0x3d, 0x7b, 0x21, 0xa7, 0x5e, 0xbd, 0x90, 0xa5,
-	0xef, 0x46, 0xb2, 0xf4, 0xeb, 0x48, 0x96, 0x9e, 0x8e, 0x64, 0x69, 0x73, 0x24, 0x4b, 0x7f, 0x8e,
-	0x64, 0xe9, 0xaf, 0x91, 0x9c, 0x7a, 0x35, 0x92, 0xa5, 0x9f, 0x5e, 0xca, 0xa9, 0xcd, 0x97, 0x72,
-	0xea, 0xd9, 0x4b, 0x39, 0xf5, 0x55, 0xbe, 0x6b, 0x1a, 0x78, 0x40, 0xd7, 0xf2, 0xec, 0x3f, 0x23,
-	0x1f, 0xfd, 0x1b, 0x00, 0x00, 0xff, 0xff, 0x3c, 0x76, 0xeb, 0xb3, 0x84, 0x11, 0x00, 0x00,
+	// 1639 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xbc, 0x58, 0xcd, 0x72, 0xd3, 0xd6,
+	0x17, 0xb7, 0xfc, 0x15, 0xfb, 0xd8, 0x49, 0x9c, 0x9b, 0x40, 0x8c, 0x02, 0x76, 0x10, 0xc3, 0xff,
+	0x9f, 0x69, 0x4b, 0x02, 0x69, 0x3b, 0x03, 0xa5, 0x85, 0x89, 0x43, 0x80, 0x00, 0x21, 0xa0, 0x04,
+	0xe8, 0xb4, 0x74, 0x34, 0xb2, 0x7d, 0x93, 0xa8, 0x48, 0xb2, 0x90, 0xae, 0x98, 0x84, 0x55, 0x3b,
+	0x7d, 0x80, 0x76, 0xd1, 0x17, 0xe8, 0xae, 0x0f, 0xd0, 0x87, 0x60, 0xd5, 0xc9, 0xa2, 0x0b, 0x86,
+	0x45, 0xa6, 0x84, 0x4d, 0xbb, 0xa3, 0xeb, 0x6e, 0x3a, 0xba, 0xf7, 0xea, 0x33, 0x4a, 0x62, 0xda,
+	0xd2, 0x9d, 0x75, 0xce, 0xef, 0x9c, 0x7b, 0xce, 0xb9, 0xe7, 0xeb, 0x1a, 0x86, 0x34, 0x73, 0x1d,
+	0x3b, 0x04, 0xdb, 0xd3, 0x96, 0xdd, 0x23, 0x3d, 0x54, 0xec, 0xf4, 0x6c, 0x82, 0x37, 0xc5, 0xb1,
+	0xf5, 0xde, 0x7a, 0x8f, 0x92, 0x66, 0xbc, 0x5f, 0x8c, 0x2b, 0x5e, 0x58, 0xd7, 0xc8, 0x86, 0xdb,
+	0x9e, 0xee, 0xf4, 0x8c, 0x19, 0x06, 0xb4, 0xec, 0xde, 0x97, 0xb8, 0x43, 0xf8, 0xd7, 0x8c, 0xf5,
+	0x68, 0xdd, 0x67, 0xb4, 0xf9, 0x0f, 0x26, 0x2a, 0x7d, 0x02, 0x15, 0x19, 0xab, 0x5d, 0x19, 0x3f,
+	0x76, 0xb1, 0x43, 0xd0, 0x34, 0x0c, 0x3c, 0x76, 0xb1, 0xad, 0x61, 0xa7, 0x2e, 0x4c, 0xe6, 0xa6,
+	0x2a, 0xb3, 0x63, 0xd3, 0x1c, 0x7e, 0xd7, 0xc5, 0xf6, 0x16, 0x87, 0xc9, 0x3e, 0x48, 0xba, 0x0c,
+	0x55, 0x26, 0xee, 0x58, 0x3d, 0xd3, 0xc1, 0x68, 0x06, 0x06, 0x6c, 0xec, 0xb8, 0x3a, 0xf1, 0xe5,
+	0x8f, 0x24, 0xe4, 0x19, 0x4e, 0xf6, 0x51, 0xd2, 0x4d, 0x18, 0x8c, 0x71, 0xd0, 0x47, 0x00, 0x44,
+	0x33, 0xb0, 0x93, 0x66, 0x84, 0xd5, 0x9e, 0x5e, 0xd5, 0x0c, 0xbc, 0x42, 0x79, 0xad, 0xfc, 0xb3,
+	0x9d, 0x66, 0x46, 0x8e, 0xa0, 0xa5, 0xef, 0xb3, 0x50, 0x8d, 0xda, 0x89, 0xde, 0x03, 0xe4, 0x10,
+	0xd5, 0x26, 0x0a, 0x05, 0x11, 0xd5, 0xb0, 0x14, 0xc3, 0x53, 0x2a, 0x4c, 0xe5, 0xe4, 0x1a, 0xe5,
+	0xac, 0xfa, 0x8c, 0x25, 0x07, 0x4d, 0x41, 0x0d, 0x9b, 0xdd, 0x38, 0x36, 0x4b, 0xb1, 0x43, 0xd8,
+	0xec, 0x46, 0x91, 0x67, 0xa1, 0x64, 0xa8, 0xa4, 0xb3, 0x81, 0x6d, 0xa7, 0x9e, 0x8b, 0xc7, 0xe9,
+	0x96, 0xda, 0xc6, 0xfa, 0x12, 0x63, 0xca, 0x01, 0x0a, 0x3d, 0x85, 0x9c, 0x8c, 0xd7, 0xea, 0xbf,
+	0x0f, 0x4c, 0x0a, 0x53, 0x95, 0xd9, 0x89, 0xd0, 0xa1, 0x25, 0xec, 0x38, 0xea, 0x3a, 0x7e, 0xa0,
+	0x91, 0x8d, 0x96, 0xbb, 0x26, 0xe3, 0xb5, 0xd6, 0x0d, 0xcf, 0xaf, 0xed, 0x9d, 0xa6, 0xf0, 0x62,
+	0xa7, 0x79, 0xe9, 0x4d, 0x6e, 0x76, 0xaf, 0x2e, 0xd9, 0x3b, 0x54, 0xfa, 0x41, 0x80, 0xb1, 0x85,
+	0x4d, 0x6c, 0x58, 0xba, 0x6a, 0xff, 0x27, 0xe1, 0x39, 0xb7, 0x27, 0x3c, 0x47, 0xd2, 0xc2, 0xe3,
+	0x84, 0xf1, 0x91, 0x1e, 0xc2, 0x28, 0x35, 0x6d, 0x85, 0xd8, 0x58, 0x35, 0x82, 0x6c, 0xb8, 0x0c,
+	0x95, 0xce, 0x86, 0x6b, 0x3e, 0x8a, 0xa5, 0xc3, 0xb8, 0xaf, 0x2c, 0x4c, 0x86, 0x79, 0x0f, 0xc4,
+	0x33, 0x22, 0x2a, 0x71, 0x23, 0x5f, 0xca, 0xd6, 0x72, 0xd2, 0x63, 0x38, 0x92, 0x08, 0xc0, 0x3f,
+	0xcf, 0x36, 0x74, 0x1c, 0xca, 0xc4, 0x76, 0xcd, 0x8e, 0x4a, 0x70, 0x97, 0x06, 0xa2, 0x24, 0x87,
+	0x04, 0xe9, 0x17, 0x01, 0x10, 0x75, 0xf6, 0xbe, 0xaa, 0xbb, 0xd8, 0xf1, 0x43, 0x7e, 0x02, 0x40,
+	0xf7, 0xa8, 0x8a, 0xa9, 0x1a, 0x98, 0x86, 0xba, 0x2c, 0x97, 0x29, 0xe5, 0xb6, 0x6a, 0xe0, 0x7d,
+	0x6e, 0x24, 0xfb, 0x06, 0x37, 0x92, 0x3b, 0xf4, 0x46, 0xf2, 0x93, 0x42, 0x1f, 0x37, 0x82, 0xc6,
+	0xa0, 0xa0, 0x6b, 0x86, 0x46, 0xea, 0x05, 0xaa, 0x91, 0x7d, 0x48, 0xe7, 0x61, 0x34, 0xe6, 0x15,
+	0x8f, 0xe3, 0x49, 0xa8, 0x32, 0xb7, 0x9e, 0x50, 0x3a, 0x8d, 0x64, 0x59, 0xae, 0xe8, 0x21, 0x54,
+	0xba, 0x04, 0xc7, 0x22, 0x92, 0x89, 0x7b, 0xee, 0x43, 0xfe, 0x27, 0x01, 0x46, 0x6e, 0xf9, 0x81,
+	0x72, 0xde, 0x76, 0x0a, 0x07, 0xde, 0xe7, 0x22, 0xde, 0xff, 0x8d, 0x30, 0x4a, 0x1f, 0x02, 0x8a,
+	0x5a, 0xcd, 0xfd, 0x6d, 0x42, 0x25, 0x4c, 0x03, 0xdf, 0x5d, 0x08, 0xf2, 0xc0, 0x91, 0x2e, 0x42,
+	0x3d, 0x14, 0x4b, 0x04, 0xeb, 0x50, 0x61, 0x04, 0xb5, 0x7b, 0x0e, 0xb6, 0x57, 0x88, 0x4a, 0xfc,
+	0x40, 0x49, 0x5f, 0x67, 0x61, 0x24, 0x42, 0xe4, 0xaa, 0x4e, 0xfb, 0x93, 0x46, 0xeb, 0x99, 0x8a,
+	0xad, 0x12, 0x96, 0x92, 0x82, 0x3c, 0x18, 0x50, 0x65, 0x95, 0x60, 0x2f, 0x6b, 0x4d, 0xd7, 0x50,
+	0x78, 0x99, 0x78, 0x11, 0xcb, 0xcb, 0x65, 0xd3, 0x35, 0x58, 0x6d, 0x78, 0x97, 0xa0, 0x5a, 0x9a,
+	0x92, 0xd0, 0x94, 0xa3, 0x9a, 0x6a, 0xaa, 0xa5, 0x2d, 0xc6, 0x94, 0x4d, 0xc3, 0xa8, 0xed, 0xea,
+	0x38, 0x09, 0xcf, 0x53, 0xf8, 0x88, 0xc7, 0x8a, 0xe3, 0x4f, 0xc1, 0xa0, 0xda, 0x21, 0xda, 0x13,
+	0xec, 0x9f, 0x5f, 0xa0, 0xe7, 0x57, 0x19, 0x91, 0x9b, 0x70, 0x0a, 0x06, 0xf5, 0x9e, 0xda, 0xc5,
+	0x5d, 0xa5, 0xad, 0xf7, 0x3a, 0x8f, 0x9c, 0x7a, 0x91, 0x81, 0x18, 0xb1, 0x45, 0x69, 0xd2, 0x17,
+	0x30, 0xea, 0x85, 0x60, 0xf1, 0x4a, 0x3c, 0x08, 0xe3, 0x30, 0xe0, 0x3a, 0xd8, 0x56, 0xb4, 0x2e,
+	0x2f, 0xc8, 0xa2, 0xf7, 0xb9, 0xd8, 0x45, 0x67, 0x20, 0xdf, 0x55, 0x89, 0x4a, 0x1d, 0xae, 0xcc,
+	0x1e, 0xf3, 0xaf, 0x7a, 0x4f, 0x18, 0x65, 0x0a, 0x93, 0xae, 0x01, 0xf2, 0x58, 0x4e, 0x5c, 0xfb,
+	0x39, 0x28, 0x38, 0x1e, 0x81, 0x77, 0x97, 0x89, 0xa8, 0x96, 0x84, 0x25, 0x32, 0x43, 0x4a, 0x0f,
+	0x01, 0xcd, 0xab, 0x76, 0x57, 0x33, 0x55, 0x5d, 0x23, 0x41, 0xb7, 0x0e, 0x52, 0xd2, 0x33, 0xb2,
+	0xe0, 0xa7, 0x64, 0x74, 0x14, 0x65, 0xfb, 0x19, 0x45, 0xd2, 0xcf, 0x39, 0x18, 0x8d, 0xa9, 0xe7,
+	0x86, 0xc6, 0x2f, 0x59, 0x48, 0x5e, 0xf2, 0xe7, 0x30, 0xc1, 0x58, 0x4a, 0xa7, 0xe7, 0x9a, 0x44,
+	0x69, 0x6f, 0x29, 0x06, 0x26, 0xb6, 0xd6, 0x61, 0xad, 0x2c, 0x1b, 0x6f, 0xcd, 0x91, 0x03, 0x3c,
+	0x17, 0x79, 0xfb, 0x1c, 0x67, 0x1a, 0xe6, 0x3d, 0x05, 0xad, 0xad, 0x25, 0x2a, 0x4e, 0xfb, 0x5e,
+	0x1b, 0x9a, 0x91, 0xfa, 0x0f, 0x4f, 0x88, 0xf4, 0xca, 0x5c, 0x3f, 0x07, 0x88, 0x61, 0xc7, 0xe0,
+	0x87, 0x04, 0x55, 0x84, 0xba, 0x30, 0x99, 0x74, 0x20, 0x7a, 0xa6, 0xa5, 0x6a, 0x76, 0x3d, 0xdf,
+	0xcf, 0x21, 0x13, 0x31, 0x2f, 0xc2, 0xa6, 0x76, 0x47, 0xd5, 0x6c, 0x3f, 0x8a, 0x6c, 0x06, 0xd5,
+	0x0b, 0x41, 0x14, 0xe9, 0x84, 0xa2, 0x1d, 0xc8, 0xd0, 0xcc, 0x78, 0x07, 0x2a, 0xb2, 0x0e, 0x64,
+	0x68, 0x66, 0xa2, 0x57, 0x19, 0xea, 0x66, 0x1c, 0x39, 0xc0, 0x91, 0xea, 0x66, 0x04, 0x29, 0x5d,
+	0x84, 0xe1, 0x84, 0xa1, 0x08, 0x41, 0x3e, 0x32, 0x60, 0xe8, 0x6f, 0x2f, 0x7f, 0xa8, 0xa7, 0xbc,
+	0x7e, 0xd9, 0x87, 0xf4, 0x4c, 0x80, 0x06, 0xbb, 0x08, 0xe7, 0x6a, 0xcf, 0x8e, 0x77, 0xb1, 0xb7,
+	0xdc, 0x63, 0xcf, 0x43, 0xd5, 0x4f, 0x4a, 0xc5, 0xc1, 0xe4, 0xe0, 0x55, 0xa1, 0xe2, 0x43, 0x57,
+	0x70, 0xa4, 0x14, 0xf2, 0xd1, 0xd9, 0x74, 0x13, 0x9a, 0xfb, 0x7a, 0xc2, 0x73, 0x7c, 0x0a, 0x8a,
+	0x2c, 0x69, 0x79, 0x35, 0xd6, 0xa2, 0x8b, 0x98, 0x47, 0x97, 0x39, 0x5f, 0xba, 0x0b, 0xa7, 0xf7,
+	0x51, 0x96, 0xe8, 0xc6, 0xfd, 0xab, 0xb4, 0xe0, 0x28, 0x57, 0xb9, 0x84, 0x89, 0xea, 0xb5, 0x8c,
+	0xd4, 0xd2, 0x0e, 0xa6, 0xcd, 0x14, 0xd4, 0xe8, 0x0f, 0xc5, 0xc2, 0x36, 0xaf, 0x35, 0x3f, 0x92,
+	0x94, 0x7e, 0x07, 0xdb, 0x4c, 0x1f, 0x3a, 0x1a, 0xd8, 0x90, 0x63, 0x0d, 0x8c, 0x9f, 0xb8, 0x0c,
+	0xe3, 0x7b, 0x4e, 0xe4, 0x66, 0x7f, 0x00, 0x25, 0x83, 0xd3, 0xb8, 0xe1, 0xf5, 0xa4, 0xe1, 0x81,
+	0x4c, 0x80, 0x94, 0xfe, 0x10, 0x60, 0x38, 0xb1, 0x75, 0x79, 0x66, 0xae, 0xd9, 0x3d, 0x43, 0xf1,
+	0x9f, 0x2c, 0x61, 0x1f, 0x1d, 0xf2, 0xe8, 0x8b, 0x9c, 0xbc, 0xd8, 0x8d, 0x36, 0xda, 0x6c, 0xac,
+	0xd1, 0x9a, 0x50, 0xa4, 0xa5, 0xe8, 0xaf, 0x8b, 0xa3, 0xa1, 0x29, 0x34, 0xf4, 0x5e, 0x65, 0xb5,
+	0xe6, 0xbc, 0xe2, 0x7b, 0xb1, 0xd3, 0x7c, 0xa3, 0xd7, 0x0e, 0x93, 0x9f, 0xeb, 0xaa, 0x16, 0xc1,
+	0xb6, 0xcc, 0x4f, 0x41, 0xef, 0x42, 0x91, 0x17, 0x28, 0x2b, 0xf8, 0xc1, 0xa0, 0xe0, 0x23, 0x7b,
+	0x24, 0x87, 0x48, 0xdf, 0x0a, 0x50, 0x60, 0x9e, 0xbe, 0xad, 0x42, 0x10, 0xa1, 0x84, 0xcd, 0x4e,
+	0xaf, 0xab, 0x99, 0xeb, 0xf4, 0x02, 0x0b, 0x72, 0xf0, 0xed, 0x55, 0x32, 0xbd, 0x23, 0x2f, 0xd3,
+	0xab, 0x7c, 0xd0, 0xcc, 0xc1, 0x60, 0x2c, 0x23, 0x63, 0x43, 0x40, 0xe8, 0x6b, 0x08, 0x28, 0x50,
+	0x8d, 0x72, 0xd0, 0x69, 0xc8, 0x93, 0x2d, 0x8b, 0x35, 0x8c, 0xa1, 0xd9, 0x11, 0x5f, 0x9a, 0xb2,
+	0x57, 0xb7, 0x2c, 0x2c, 0x53, 0x76, 0xd0, 0x57, 0xb2, 0x69, 0x7d, 0x85, 0xe5, 0x1e, 0xfb, 0x90,
+	0xbe, 0x11, 0x60, 0x28, 0xcc, 0x94, 0xab, 0x9a, 0x8e, 0xff, 0x8d, 0x44, 0x11, 0xa1, 0xb4, 0xa6,
+	0xe9, 0x98, 0x0f, 0x04, 0x8f, 0x13, 0x7c, 0xa7, 0x45, 0xea, 0x9d, 0x1b, 0x50, 0x0e, 0x5c, 0x40,
+	0x65, 0x28, 0x2c, 0xdc, 0xbd, 0x37, 0x77, 0xab, 0x96, 0x41, 0x83, 0x50, 0xbe, 0xbd, 0xbc, 0xaa,
+	0xb0, 0x4f, 0x01, 0x0d, 0x43, 0x45, 0x5e, 0xb8, 0xb6, 0xf0, 0xa9, 0xb2, 0x34, 0xb7, 0x3a, 0x7f,
+	0xbd, 0x96, 0x45, 0x08, 0x86, 0x18, 0xe1, 0xf6, 0x32, 0xa7, 0xe5, 0x66, 0xff, 0x2c, 0x41, 0xc9,
+	0xb7, 0x11, 0x5d, 0x80, 0xfc, 0x1d, 0xd7, 0xd9, 0x40, 0x47, 0xc3, 0x4c, 0x7d, 0x60, 0x6b, 0x04,
+	0xf3, 0x8a, 0x16, 0xc7, 0xf7, 0xd0, 0x59, 0xdd, 0x49, 0x19, 0xb4, 0x08, 0xe0, 0x89, 0xb2, 0x36,
+	0x82, 0x8e, 0x87, 0x40, 0x46, 0xe9, 0x53, 0xcd, 0x94, 0x70, 0x56, 0x40, 0x57, 0xa0, 0x12, 0x79,
+	0x35, 0xa1, 0xd4, 0xc7, 0xba, 0x38, 0x11, 0xa3, 0xc6, 0xbb, 0x97, 0x94, 0x39, 0x2b, 0xa0, 0x65,
+	0x18, 0xa2, 0x2c, 0xff, 0x89, 0xe4, 0x04, 0x46, 0x4d, 0xa7, 0x3d, 0x1b, 0xc5, 0x13, 0xfb, 0x70,
+	0x03, 0x0f, 0xaf, 0x43, 0x25, 0xb2, 0xea, 0x23, 0x31, 0x96, 0x8b, 0xb1, 0xf7, 0x90, 0x38, 0x91,
+	0xca, 0x0b, 0x34, 0xdd, 0x87, 0x91, 0x08, 0x83, 0xbb, 0x79, 0x90, 0xbe, 0x93, 0x29, 0xbc, 0x14,
+	0x97, 0x17, 0x00, 0xc2, 0xf5, 0x1a, 0x1d, 0x8b, 0x09, 0x45, 0xdf, 0x17, 0xa2, 0x98, 0xc6, 0x0a,
+	0xcc, 0x5b, 0x81, 0x5a, 0x72, 0x4b, 0x3f, 0x48, 0xd9, 0xe4, 0x5e, 0x56, 0x8a, 0x6d, 0x2d, 0x28,
+	0x07, 0x1b, 0x26, 0xaa, 0xa7, 0x2c, 0x9d, 0x4c, 0xd9, 0xfe, 0xeb, 0xa8, 0x94, 0x41, 0x57, 0xa1,
+	0x3a, 0xa7, 0xeb, 0xfd, 0xa8, 0x11, 0xa3, 0x1c, 0x27, 0xa9, 0xe7, 0x3a, 0x54, 0x22, 0xab, 0x45,
+	0x18, 0xf9, 0xbd, 0xeb, 0xa9, 0x38, 0x91, 0xca, 0x0b, 0x34, 0xe9, 0x30, 0xbe, 0xcf, 0x3c, 0x45,
+	0xff, 0x0b, 0xba, 0xcd, 0x81, 0x7b, 0x88, 0xf8, 0xff, 0x43, 0x71, 0xc1, 0x69, 0x4f, 0xe1, 0xc4,
+	0x81, 0xd3, 0xbb, 0xef, 0x33, 0xcf, 0x1c, 0x82, 0x4b, 0xb9, 0xbf, 0x55, 0x18, 0x4e, 0x0c, 0x5d,
+	0xd4, 0x48, 0x68, 0x49, 0xcc, 0x7f, 0xb1, 0xb9, 0x2f, 0xdf, 0xd7, 0xdb, 0xfa, 0x78, 0xfb, 0x65,
+	0x23, 0xf3, 0xfc, 0x65, 0x23, 0xf3, 0xfa, 0x65, 0x43, 0xf8, 0x6a, 0xb7, 0x21, 0xfc, 0xb8, 0xdb,
+	0x10, 0x9e, 0xed, 0x36, 0x84, 0xed, 0xdd, 0x86, 0xf0, 0xeb, 0x6e, 0x43, 0xf8, 0x6d, 0xb7, 0x91,
+	0x79, 0xbd, 0xdb, 0x10, 0xbe, 0x7b, 0xd5, 0xc8, 0x6c, 0xbf, 0x6a, 0x64, 0x9e, 0xbf, 0x6a, 0x64,
+	0x3e, 0x2b, 0x76, 0x74, 0x0d, 0x9b, 0xa4, 0x5d, 0xa4, 0xff, 0xf6, 0xbd, 0xff, 0xd7, 0x00, 0x48,
+	0x34, 0x10, 0x36, 0x58, 0x14, 0x00, 0x00,
 }
 
 func (x MatchType) String() string {
@@ -1825,6 +2048,9 @@ func (this *ExemplarQueryResponse) Equal(that interface{}) bool {
 			return false
 		}
 	}
+	if this.Truncated != that1.Truncated {
+		return false
+	}
 	return true
 }
 func (this *LabelValuesRequest) Equal(that interface{}) bool {
@@ -2128,6 +2354,122 @@ func (this *UsersStatsResponse) Equal(that interface{}) bool {
 	}
 	return true
 }
+func (this *CardinalityRequest) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*CardinalityRequest)
+	if !ok {
+		that2, ok := that.(CardinalityRequest)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if this.Limit != that1.Limit {
+		return false
+	}
+	if len(this.Matchers) != len(that1.Matchers) {
+		return false
+	}
+	for i := range this.Matchers {
+		if !this.Matchers[i].Equal(that1.Matchers[i]) {
+			return false
+		}
+	}
+	return true
+}
+func (this *CardinalityResponse) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*CardinalityResponse)
+	if !ok {
+		that2, ok := that.(CardinalityResponse)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if this.NumSeries != that1.NumSeries {
+		return false
+	}
+	if len(this.SeriesCountByMetricName) != len(that1.SeriesCountByMetricName) {
+		return false
+	}
+	for i := range this.SeriesCountByMetricName {
+		if !this.SeriesCountByMetricName[i].Equal(&that1.SeriesCountByMetricName[i]) {
+			return false
+		}
+	}
+	if len(this.LabelValueCountByLabelName) != len(that1.LabelValueCountByLabelName) {
+		return false
+	}
+	for i := range this.LabelValueCountByLabelName {
+		if !this.LabelValueCountByLabelName[i].Equal(&that1.LabelValueCountByLabelName[i]) {
+			return false
+		}
+	}
+	if len(this.SeriesCountByLabelValuePair) != len(that1.SeriesCountByLabelValuePair) {
+		return false
+	}
+	for i := range this.SeriesCountByLabelValuePair {
+		if !this.SeriesCountByLabelValuePair[i].Equal(&that1.SeriesCountByLabelValuePair[i]) {
+			return false
+		}
+	}
+	if this.NumChunks != that1.NumChunks {
+		return false
+	}
+	if this.MinTimestampMs != that1.MinTimestampMs {
+		return false
+	}
+	if this.MaxTimestampMs != that1.MaxTimestampMs {
+		return false
+	}
+	return true
+}
+func (this *CardinalityStat) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*CardinalityStat)
+	if !ok {
+		that2, ok := that.(CardinalityStat)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if this.Name != that1.Name {
+		return false
+	}
+	if this.Value != that1.Value {
+		return false
+	}
+	return true
+}
 func (this *MetricsForLabelMatchersRequest) Equal(that interface{}) bool {
 	if that == nil {
 		return this == nil
@@ -2540,7 +2882,7 @@ func (this *ExemplarQueryResponse) GoString() string {
 	if this == nil {
 		return "nil"
 	}
-	s := make([]string, 0, 5)
+	s := make([]string, 0, 6)
 	s = append(s, "&client.ExemplarQueryResponse{")
 	if this.Timeseries != nil {
 		vs := make([]*cortexpb.TimeSeries, len(this.Timeseries))
@@ -2549,6 +2891,7 @@ func (this *ExemplarQueryResponse) GoString() string {
 		}
 		s = append(s, "Timeseries: "+fmt.Sprintf("%#v", vs)+",\n")
 	}
+	s = append(s, "Truncated: "+fmt.Sprintf("%#v", this.Truncated)+",\n")
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
@@ -2672,19 +3015,77 @@ func (this *UsersStatsResponse) GoString() string {
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
-func (this *MetricsForLabelMatchersRequest) GoString() string {
+func (this *CardinalityRequest) GoString() string {
 	if this == nil {
 		return "nil"
 	}
-	s := make([]string, 0, 8)
-	s = append(s, "&client.MetricsForLabelMatchersRequest{")
-	s = append(s, "StartTimestampMs: "+fmt.Sprintf("%#v", this.StartTimestampMs)+",\n")
-	s = append(s, "EndTimestampMs: "+fmt.Sprintf("%#v", this.EndTimestampMs)+",\n")
-	if this.MatchersSet != nil {
-		s = append(s, "MatchersSet: "+fmt.Sprintf("%#v", this.MatchersSet)+",\n")
-	}
+	s := make([]string, 0, 6)
+	s = append(s, "&client.CardinalityRequest{")
 	s = append(s, "Limit: "+fmt.Sprintf("%#v", this.Limit)+",\n")
-	s = append(s, "}")
+	if this.Matchers != nil {
+		s = append(s, "Matchers: "+fmt.Sprintf("%#v", this.Matchers)+",\n")
+	}
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func (this *CardinalityResponse) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 11)
+	s = append(s, "&client.CardinalityResponse{")
+	s = append(s, "NumSeries: "+fmt.Sprintf("%#v", this.NumSeries)+",\n")
+	if this.SeriesCountByMetricName != nil {
+		vs := make([]*CardinalityStat, len(this.SeriesCountByMetricName))
+		for i := range vs {
+			vs[i] = &this.SeriesCountByMetricName[i]
+		}
+		s = append(s, "SeriesCountByMetricName: "+fmt.Sprintf("%#v", vs)+",\n")
+	}
+	if this.LabelValueCountByLabelName != nil {
+		vs := make([]*CardinalityStat, len(this.LabelValueCountByLabelName))
+		for i := range vs {
+			vs[i] = &this.LabelValueCountByLabelName[i]
+		}
+		s = append(s, "LabelValueCountByLabelName: "+fmt.Sprintf("%#v", vs)+",\n")
+	}
+	if this.SeriesCountByLabelValuePair != nil {
+		vs := make([]*CardinalityStat, len(this.SeriesCountByLabelValuePair))
+		for i := range vs {
+			vs[i] = &this.SeriesCountByLabelValuePair[i]
+		}
+		s = append(s, "SeriesCountByLabelValuePair: "+fmt.Sprintf("%#v", vs)+",\n")
+	}
+	s = append(s, "NumChunks: "+fmt.Sprintf("%#v", this.NumChunks)+",\n")
+	s = append(s, "MinTimestampMs: "+fmt.Sprintf("%#v", this.MinTimestampMs)+",\n")
+	s = append(s, "MaxTimestampMs: "+fmt.Sprintf("%#v", this.MaxTimestampMs)+",\n")
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func (this *CardinalityStat) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 6)
+	s = append(s, "&client.CardinalityStat{")
+	s = append(s, "Name: "+fmt.Sprintf("%#v", this.Name)+",\n")
+	s = append(s, "Value: "+fmt.Sprintf("%#v", this.Value)+",\n")
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func (this *MetricsForLabelMatchersRequest) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 8)
+	s = append(s, "&client.MetricsForLabelMatchersRequest{")
+	s = append(s, "StartTimestampMs: "+fmt.Sprintf("%#v", this.StartTimestampMs)+",\n")
+	s = append(s, "EndTimestampMs: "+fmt.Sprintf("%#v", this.EndTimestampMs)+",\n")
+	if this.MatchersSet != nil {
+		s = append(s, "MatchersSet: "+fmt.Sprintf("%#v", this.MatchersSet)+",\n")
+	}
+	s = append(s, "Limit: "+fmt.Sprintf("%#v", this.Limit)+",\n")
+	s = append(s, "}")
 	return strings.Join(s, "")
 }
 func (this *MetricsForLabelMatchersResponse) GoString() string {
@@ -2835,6 +3236,7 @@ type IngesterClient interface {
 	LabelNamesStream(ctx context.Context, in *LabelNamesRequest, opts ...grpc.CallOption) (Ingester_LabelNamesStreamClient, error)
 	UserStats(ctx context.Context, in *UserStatsRequest, opts ...grpc.CallOption) (*UserStatsResponse, error)
 	AllUserStats(ctx context.Context, in *UserStatsRequest, opts ...grpc.CallOption) (*UsersStatsResponse, error)
+	Cardinality(ctx context.Context, in *CardinalityRequest, opts ...grpc.CallOption) (*CardinalityResponse, error)
 	MetricsForLabelMatchers(ctx context.Context, in *MetricsForLabelMatchersRequest, opts ...grpc.CallOption) (*MetricsForLabelMatchersResponse, error)
 	MetricsForLabelMatchersStream(ctx context.Context, in *MetricsForLabelMatchersRequest, opts ...grpc.CallOption) (Ingester_MetricsForLabelMatchersStreamClient, error)
 	MetricsMetadata(ctx context.Context, in *MetricsMetadataRequest, opts ...grpc.CallOption) (*MetricsMetadataResponse, error)
@@ -3029,6 +3431,15 @@ func (c *ingesterClient) AllUserStats(ctx context.Context, in *UserStatsRequest,
 	return out, nil
 }
 
+func (c *ingesterClient) Cardinality(ctx context.Context, in *CardinalityRequest, opts ...grpc.CallOption) (*CardinalityResponse, error) {
+	out := new(CardinalityResponse)
+	err := c.cc.Invoke(ctx, "/cortex.Ingester/Cardinality", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *ingesterClient) MetricsForLabelMatchers(ctx context.Context, in *MetricsForLabelMatchersRequest, opts ...grpc.CallOption) (*MetricsForLabelMatchersResponse, error) {
 	out := new(MetricsForLabelMatchersResponse)
 	err := c.cc.Invoke(ctx, "/cortex.Ingester/MetricsForLabelMatchers", in, out, opts...)
@@ -3091,6 +3502,7 @@ type IngesterServer interface {
 	LabelNamesStream(*LabelNamesRequest, Ingester_LabelNamesStreamServer) error
 	UserStats(context.Context, *UserStatsRequest) (*UserStatsResponse, error)
 	AllUserStats(context.Context, *UserStatsRequest) (*UsersStatsResponse, error)
+	Cardinality(context.Context, *CardinalityRequest) (*CardinalityResponse, error)
 	MetricsForLabelMatchers(context.Context, *MetricsForLabelMatchersRequest) (*MetricsForLabelMatchersResponse, error)
 	MetricsForLabelMatchersStream(*MetricsForLabelMatchersRequest, Ingester_MetricsForLabelMatchersStreamServer) error
 	MetricsMetadata(context.Context, *MetricsMetadataRequest) (*MetricsMetadataResponse, error)
@@ -3130,6 +3542,9 @@ func (*UnimplementedIngesterServer) UserStats(ctx context.Context, req *UserStat
 func (*UnimplementedIngesterServer) AllUserStats(ctx context.Context, req *UserStatsRequest) (*UsersStatsResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method AllUserStats not implemented")
 }
+func (*UnimplementedIngesterServer) Cardinality(ctx context.Context, req *CardinalityRequest) (*CardinalityResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Cardinality not implemented")
+}
 func (*UnimplementedIngesterServer) MetricsForLabelMatchers(ctx context.Context, req *MetricsForLabelMatchersRequest) (*MetricsForLabelMatchersResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method MetricsForLabelMatchers not implemented")
 }
@@ -3341,6 +3756,24 @@ func _Ingester_AllUserStats_Handler(srv interface{}, ctx context.Context, dec fu
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Ingester_Cardinality_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CardinalityRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IngesterServer).Cardinality(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/cortex.Ingester/Cardinality",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IngesterServer).Cardinality(ctx, req.(*CardinalityRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _Ingester_MetricsForLabelMatchers_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(MetricsForLabelMatchersRequest)
 	if err := dec(in); err != nil {
@@ -3426,6 +3859,10 @@ var _Ingester_serviceDesc = grpc.ServiceDesc{
 			MethodName: "AllUserStats",
 			Handler:    _Ingester_AllUserStats_Handler,
 		},
+		{
+			MethodName: "Cardinality",
+			Handler:    _Ingester_Cardinality_Handler,
+		},
 		{
 			MethodName: "MetricsForLabelMatchers",
 			Handler:    _Ingester_MetricsForLabelMatchers_Handler,
@@ -3740,6 +4177,16 @@ func (m *ExemplarQueryResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	if m.Truncated {
+		i--
+		if m.Truncated {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i--
+		dAtA[i] = 0x10
+	}
 	if len(m.Timeseries) > 0 {
 		for iNdEx := len(m.Timeseries) - 1; iNdEx >= 0; iNdEx-- {
 			{
@@ -4150,6 +4597,168 @@ func (m *UsersStatsResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	return len(dAtA) - i, nil
 }
 
+func (m *CardinalityRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *CardinalityRequest) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *CardinalityRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.Matchers) > 0 {
+		for iNdEx := len(m.Matchers) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Matchers[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintIngester(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x12
+		}
+	}
+	if m.Limit != 0 {
+		i = encodeVarintIngester(dAtA, i, uint64(m.Limit))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *CardinalityResponse) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *CardinalityResponse) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *CardinalityResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.MaxTimestampMs != 0 {
+		i = encodeVarintIngester(dAtA, i, uint64(m.MaxTimestampMs))
+		i--
+		dAtA[i] = 0x38
+	}
+	if m.MinTimestampMs != 0 {
+		i = encodeVarintIngester(dAtA, i, uint64(m.MinTimestampMs))
+		i--
+		dAtA[i] = 0x30
+	}
+	if m.NumChunks != 0 {
+		i = encodeVarintIngester(dAtA, i, uint64(m.NumChunks))
+		i--
+		dAtA[i] = 0x28
+	}
+	if len(m.SeriesCountByLabelValuePair) > 0 {
+		for iNdEx := len(m.SeriesCountByLabelValuePair) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.SeriesCountByLabelValuePair[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintIngester(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x22
+		}
+	}
+	if len(m.LabelValueCountByLabelName) > 0 {
+		for iNdEx := len(m.LabelValueCountByLabelName) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.LabelValueCountByLabelName[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintIngester(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x1a
+		}
+	}
+	if len(m.SeriesCountByMetricName) > 0 {
+		for iNdEx := len(m.SeriesCountByMetricName) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.SeriesCountByMetricName[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintIngester(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x12
+		}
+	}
+	if m.NumSeries != 0 {
+		i = encodeVarintIngester(dAtA, i, uint64(m.NumSeries))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *CardinalityStat) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *CardinalityStat) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *CardinalityStat) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.Value != 0 {
+		i = encodeVarintIngester(dAtA, i, uint64(m.Value))
+		i--
+		dAtA[i] = 0x10
+	}
+	if len(m.Name) > 0 {
+		i -= len(m.Name)
+		copy(dAtA[i:], m.Name)
+		i = encodeVarintIngester(dAtA, i, uint64(len(m.Name)))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
 func (m *MetricsForLabelMatchersRequest) Marshal() (dAtA []byte, err error) {
 	size := m.Size()
 	dAtA = make([]byte, size)
@@ -4720,6 +5329,9 @@ func (m *ExemplarQueryResponse) Size() (n int) {
 			n += 1 + l + sovIngester(uint64(l))
 		}
 	}
+	if m.Truncated {
+		n += 2
+	}
 	return n
 }
 
@@ -4899,86 +5511,159 @@ func (m *UsersStatsResponse) Size() (n int) {
 	return n
 }
 
-func (m *MetricsForLabelMatchersRequest) Size() (n int) {
+func (m *CardinalityRequest) Size() (n int) {
 	if m == nil {
 		return 0
 	}
 	var l int
 	_ = l
-	if m.StartTimestampMs != 0 {
-		n += 1 + sovIngester(uint64(m.StartTimestampMs))
-	}
-	if m.EndTimestampMs != 0 {
-		n += 1 + sovIngester(uint64(m.EndTimestampMs))
+	if m.Limit != 0 {
+		n += 1 + sovIngester(uint64(m.Limit))
 	}
-	if len(m.MatchersSet) > 0 {
-		for _, e := range m.MatchersSet {
+	if len(m.Matchers) > 0 {
+		for _, e := range m.Matchers {
 			l = e.Size()
 			n += 1 + l + sovIngester(uint64(l))
 		}
 	}
-	if m.Limit != 0 {
-		n += 1 + sovIngester(uint64(m.Limit))
-	}
 	return n
 }
 
-func (m *MetricsForLabelMatchersResponse) Size() (n int) {
+func (m *CardinalityResponse) Size() (n int) {
 	if m == nil {
 		return 0
 	}
 	var l int
 	_ = l
-	if len(m.Metric) > 0 {
-		for _, e := range m.Metric {
+	if m.NumSeries != 0 {
+		n += 1 + sovIngester(uint64(m.NumSeries))
+	}
+	if len(m.SeriesCountByMetricName) > 0 {
+		for _, e := range m.SeriesCountByMetricName {
 			l = e.Size()
 			n += 1 + l + sovIngester(uint64(l))
 		}
 	}
-	return n
-}
-
-func (m *MetricsForLabelMatchersStreamResponse) Size() (n int) {
-	if m == nil {
-		return 0
+	if len(m.LabelValueCountByLabelName) > 0 {
+		for _, e := range m.LabelValueCountByLabelName {
+			l = e.Size()
+			n += 1 + l + sovIngester(uint64(l))
+		}
 	}
-	var l int
-	_ = l
-	if len(m.Metric) > 0 {
-		for _, e := range m.Metric {
+	if len(m.SeriesCountByLabelValuePair) > 0 {
+		for _, e := range m.SeriesCountByLabelValuePair {
 			l = e.Size()
 			n += 1 + l + sovIngester(uint64(l))
 		}
 	}
+	if m.NumChunks != 0 {
+		n += 1 + sovIngester(uint64(m.NumChunks))
+	}
+	if m.MinTimestampMs != 0 {
+		n += 1 + sovIngester(uint64(m.MinTimestampMs))
+	}
+	if m.MaxTimestampMs != 0 {
+		n += 1 + sovIngester(uint64(m.MaxTimestampMs))
+	}
 	return n
 }
 
-func (m *MetricsMetadataRequest) Size() (n int) {
+func (m *CardinalityStat) Size() (n int) {
 	if m == nil {
 		return 0
 	}
 	var l int
 	_ = l
-	if m.Limit != 0 {
-		n += 1 + sovIngester(uint64(m.Limit))
-	}
-	if m.LimitPerMetric != 0 {
-		n += 1 + sovIngester(uint64(m.LimitPerMetric))
-	}
-	l = len(m.Metric)
+	l = len(m.Name)
 	if l > 0 {
 		n += 1 + l + sovIngester(uint64(l))
 	}
+	if m.Value != 0 {
+		n += 1 + sovIngester(uint64(m.Value))
+	}
 	return n
 }
 
-func (m *MetricsMetadataResponse) Size() (n int) {
+func (m *MetricsForLabelMatchersRequest) Size() (n int) {
 	if m == nil {
 		return 0
 	}
 	var l int
 	_ = l
-	if len(m.Metadata) > 0 {
+	if m.StartTimestampMs != 0 {
+		n += 1 + sovIngester(uint64(m.StartTimestampMs))
+	}
+	if m.EndTimestampMs != 0 {
+		n += 1 + sovIngester(uint64(m.EndTimestampMs))
+	}
+	if len(m.MatchersSet) > 0 {
+		for _, e := range m.MatchersSet {
+			l = e.Size()
+			n += 1 + l + sovIngester(uint64(l))
+		}
+	}
+	if m.Limit != 0 {
+		n += 1 + sovIngester(uint64(m.Limit))
+	}
+	return n
+}
+
+func (m *MetricsForLabelMatchersResponse) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.Metric) > 0 {
+		for _, e := range m.Metric {
+			l = e.Size()
+			n += 1 + l + sovIngester(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *MetricsForLabelMatchersStreamResponse) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.Metric) > 0 {
+		for _, e := range m.Metric {
+			l = e.Size()
+			n += 1 + l + sovIngester(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *MetricsMetadataRequest) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Limit != 0 {
+		n += 1 + sovIngester(uint64(m.Limit))
+	}
+	if m.LimitPerMetric != 0 {
+		n += 1 + sovIngester(uint64(m.LimitPerMetric))
+	}
+	l = len(m.Metric)
+	if l > 0 {
+		n += 1 + l + sovIngester(uint64(l))
+	}
+	return n
+}
+
+func (m *MetricsMetadataResponse) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.Metadata) > 0 {
 		for _, e := range m.Metadata {
 			l = e.Size()
 			n += 1 + l + sovIngester(uint64(l))
@@ -5210,6 +5895,7 @@ func (this *ExemplarQueryResponse) String() string {
 	repeatedStringForTimeseries += "}"
 	s := strings.Join([]string{`&ExemplarQueryResponse{`,
 		`Timeseries:` + repeatedStringForTimeseries + `,`,
+		`Truncated:` + fmt.Sprintf("%v", this.Truncated) + `,`,
 		`}`,
 	}, "")
 	return s
@@ -5331,6 +6017,64 @@ func (this *UsersStatsResponse) String() string {
 	}, "")
 	return s
 }
+func (this *CardinalityRequest) String() string {
+	if this == nil {
+		return "nil"
+	}
+	repeatedStringForMatchers := "[]*LabelMatcher{"
+	for _, f := range this.Matchers {
+		repeatedStringForMatchers += strings.Replace(f.String(), "LabelMatcher", "LabelMatcher", 1) + ","
+	}
+	repeatedStringForMatchers += "}"
+	s := strings.Join([]string{`&CardinalityRequest{`,
+		`Limit:` + fmt.Sprintf("%v", this.Limit) + `,`,
+		`Matchers:` + repeatedStringForMatchers + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *CardinalityResponse) String() string {
+	if this == nil {
+		return "nil"
+	}
+	repeatedStringForSeriesCountByMetricName := "[]CardinalityStat{"
+	for _, f := range this.SeriesCountByMetricName {
+		repeatedStringForSeriesCountByMetricName += strings.Replace(strings.Replace(f.String(), "CardinalityStat", "CardinalityStat", 1), `&`, ``, 1) + ","
+	}
+	repeatedStringForSeriesCountByMetricName += "}"
+	repeatedStringForLabelValueCountByLabelName := "[]CardinalityStat{"
+	for _, f := range this.LabelValueCountByLabelName {
+		repeatedStringForLabelValueCountByLabelName += strings.Replace(strings.Replace(f.String(), "CardinalityStat", "CardinalityStat", 1), `&`, ``, 1) + ","
+	}
+	repeatedStringForLabelValueCountByLabelName += "}"
+	repeatedStringForSeriesCountByLabelValuePair := "[]CardinalityStat{"
+	for _, f := range this.SeriesCountByLabelValuePair {
+		repeatedStringForSeriesCountByLabelValuePair += strings.Replace(strings.Replace(f.String(), "CardinalityStat", "CardinalityStat", 1), `&`, ``, 1) + ","
+	}
+	repeatedStringForSeriesCountByLabelValuePair += "}"
+	s := strings.Join([]string{`&CardinalityResponse{`,
+		`NumSeries:` + fmt.Sprintf("%v", this.NumSeries) + `,`,
+		`SeriesCountByMetricName:` + repeatedStringForSeriesCountByMetricName + `,`,
+		`LabelValueCountByLabelName:` + repeatedStringForLabelValueCountByLabelName + `,`,
+		`SeriesCountByLabelValuePair:` + repeatedStringForSeriesCountByLabelValuePair + `,`,
+		`NumChunks:` + fmt.Sprintf("%v", this.NumChunks) + `,`,
+		`MinTimestampMs:` + fmt.Sprintf("%v", this.MinTimestampMs) + `,`,
+		`MaxTimestampMs:` + fmt.Sprintf("%v", this.MaxTimestampMs) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *CardinalityStat) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&CardinalityStat{`,
+		`Name:` + fmt.Sprintf("%v", this.Name) + `,`,
+		`Value:` + fmt.Sprintf("%v", this.Value) + `,`,
+		`}`,
+	}, "")
+	return s
+}
 func (this *MetricsForLabelMatchersRequest) String() string {
 	if this == nil {
 		return "nil"
@@ -6179,6 +6923,26 @@ func (m *ExemplarQueryResponse) Unmarshal(dAtA []byte) error {
 				return err
 			}
 			iNdEx = postIndex
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Truncated", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIngester
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.Truncated = bool(v != 0)
 		default:
 			iNdEx = preIndex
 			skippy, err := skipIngester(dAtA[iNdEx:])
@@ -7184,6 +7948,447 @@ func (m *UserIDStatsResponse) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
+func (m *CardinalityRequest) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowIngester
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: CardinalityRequest: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: CardinalityRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Limit", wireType)
+			}
+			m.Limit = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIngester
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Limit |= int32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Matchers", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIngester
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthIngester
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthIngester
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Matchers = append(m.Matchers, &LabelMatcher{})
+			if err := m.Matchers[len(m.Matchers)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipIngester(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthIngester
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthIngester
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *CardinalityResponse) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowIngester
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: CardinalityResponse: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: CardinalityResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field NumSeries", wireType)
+			}
+			m.NumSeries = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIngester
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.NumSeries |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field SeriesCountByMetricName", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIngester
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthIngester
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthIngester
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.SeriesCountByMetricName = append(m.SeriesCountByMetricName, CardinalityStat{})
+			if err := m.SeriesCountByMetricName[len(m.SeriesCountByMetricName)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field LabelValueCountByLabelName", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIngester
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthIngester
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthIngester
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.LabelValueCountByLabelName = append(m.LabelValueCountByLabelName, CardinalityStat{})
+			if err := m.LabelValueCountByLabelName[len(m.LabelValueCountByLabelName)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field SeriesCountByLabelValuePair", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIngester
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthIngester
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthIngester
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.SeriesCountByLabelValuePair = append(m.SeriesCountByLabelValuePair, CardinalityStat{})
+			if err := m.SeriesCountByLabelValuePair[len(m.SeriesCountByLabelValuePair)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 5:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field NumChunks", wireType)
+			}
+			m.NumChunks = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIngester
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.NumChunks |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 6:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field MinTimestampMs", wireType)
+			}
+			m.MinTimestampMs = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIngester
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.MinTimestampMs |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 7:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field MaxTimestampMs", wireType)
+			}
+			m.MaxTimestampMs = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIngester
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.MaxTimestampMs |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipIngester(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthIngester
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthIngester
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *CardinalityStat) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowIngester
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: CardinalityStat: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: CardinalityStat: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Name", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIngester
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthIngester
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthIngester
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Name = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Value", wireType)
+			}
+			m.Value = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIngester
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Value |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipIngester(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthIngester
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthIngester
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
 func (m *UsersStatsResponse) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
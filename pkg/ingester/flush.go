@@ -1,15 +1,108 @@
 package ingester
 
 import (
+	"context"
 	"net/http"
+	"time"
+
+	"github.com/go-kit/log/level"
+	"github.com/oklog/ulid/v2"
+	"github.com/pkg/errors"
+
+	"github.com/cortexproject/cortex/pkg/storage/tsdb/bucketindex"
 )
 
+// How frequently the bucket index is checked while waiting before leaving the ring.
+var waitBeforeLeavingCheckInterval = 15 * time.Second
+
 // Flush triggers a flush of all the chunks and closes the flush queues.
 // Called from the Lifecycler as part of the ingester shutdown.
 func (i *Ingester) Flush() {
 	i.lifecyclerFlush()
 }
 
+// WaitBeforeLeaving waits until all the blocks shipped by the ingester, and still retained locally,
+// are in the tenants' bucket index, so that queriers and store-gateways can discover them.
+// Called from the Lifecycler as part of the ingester shutdown, before leaving the ring. The
+// ingester keeps serving the read requests while waiting.
+func (i *Ingester) WaitBeforeLeaving(ctx context.Context) error {
+	if !i.cfg.BlocksStorageConfig.TSDB.IsBlocksShippingEnabled() || !i.cfg.BlocksStorageConfig.BucketStore.BucketIndex.Enabled {
+		return nil
+	}
+
+	i.waitingBeforeLeaving.Store(true)
+	defer i.waitingBeforeLeaving.Store(false)
+
+	ticker := time.NewTicker(waitBeforeLeavingCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		missing := i.countShippedBlocksMissingFromBucketIndex(ctx)
+		if missing == 0 {
+			return nil
+		}
+
+		level.Info(i.logger).Log("msg", "waiting for shipped blocks to be in the bucket index", "missing_blocks", missing)
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return errors.Wrapf(ctx.Err(), "%d shipped blocks are not in the bucket index yet", missing)
+		}
+	}
+}
+
+// countShippedBlocksMissingFromBucketIndex returns the number of blocks shipped by the ingester and still retained
+// locally which can't be found in the bucket index. Blocks marked for deletion are considered as found, because
+// they've been compacted into another block.
+func (i *Ingester) countShippedBlocksMissingFromBucketIndex(ctx context.Context) int {
+	missing := 0
+
+	for _, userID := range i.getTSDBUsers() {
+		userDB, err := i.getTSDB(userID)
+		if err != nil || userDB == nil {
+			continue
+		}
+
+		shippedBlocks := userDB.getCachedShippedBlocks()
+
+		var retainedShippedBlocks []ulid.ULID
+		for _, b := range userDB.Blocks() {
+			if _, ok := shippedBlocks[b.Meta().ULID]; ok {
+				retainedShippedBlocks = append(retainedShippedBlocks, b.Meta().ULID)
+			}
+		}
+		if len(retainedShippedBlocks) == 0 {
+			continue
+		}
+
+		idx, err := bucketindex.ReadIndex(ctx, i.TSDBState.bucket, userID, i.limits, i.logger)
+		if err != nil {
+			if !errors.Is(err, bucketindex.ErrIndexNotFound) {
+				level.Warn(i.logger).Log("msg", "failed to read bucket index", "user", userID, "err", err)
+			}
+			missing += len(retainedShippedBlocks)
+			continue
+		}
+
+		indexed := make(map[ulid.ULID]struct{}, len(idx.Blocks)+len(idx.BlockDeletionMarks))
+		for _, b := range idx.Blocks {
+			indexed[b.ID] = struct{}{}
+		}
+		for _, m := range idx.BlockDeletionMarks {
+			indexed[m.ID] = struct{}{}
+		}
+
+		for _, id := range retainedShippedBlocks {
+			if _, ok := indexed[id]; !ok {
+				missing++
+			}
+		}
+	}
+
+	return missing
+}
+
 // FlushHandler triggers a flush of all in memory chunks.  Mainly used for
 // local testing.
 func (i *Ingester) FlushHandler(w http.ResponseWriter, r *http.Request) {
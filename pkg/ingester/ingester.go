@@ -35,6 +35,7 @@ import (
 	"github.com/prometheus/prometheus/tsdb"
 	"github.com/prometheus/prometheus/tsdb/chunkenc"
 	"github.com/prometheus/prometheus/tsdb/chunks"
+	"github.com/prometheus/prometheus/tsdb/index"
 	"github.com/prometheus/prometheus/util/compression"
 	"github.com/prometheus/prometheus/util/zeropool"
 	"github.com/thanos-io/objstore"
@@ -86,6 +87,21 @@ const (
 	// Jitter applied to the idle timeout to prevent compaction in all ingesters concurrently.
 	compactionIdleTimeoutJitter = 0.25
 
+	// Reasons triggering the head compaction.
+	compactionReasonForced          = "forced"
+	compactionReasonIdle            = "idle"
+	compactionReasonSeriesThreshold = "series_threshold"
+	compactionReasonEvictIdle       = "evict_idle"
+	compactionReasonHeadRetention   = "head_retention"
+	compactionReasonRegular         = "regular"
+
+	// Min ratio of the in-memory series which must be idle to request their eviction, with the evict-idle
+	// max series policy.
+	minIdleSeriesRatioForEviction = 0.1
+
+	// Max number of tenants whose series threshold compaction can be pending in the compaction loop.
+	seriesThresholdCompactTriggerSize = 100
+
 	instanceIngestionRateTickInterval = time.Second
 
 	// Number of timeseries to return in each batch of a QueryStream.
@@ -103,6 +119,9 @@ const (
 	maxInflightRequestResetPeriod = 1 * time.Minute
 
 	labelSetMetricsTickInterval = 30 * time.Second
+
+	// Period at which the WAL replay progress of the TSDBs being opened is updated.
+	walReplayProgressUpdateInterval = time.Second
 )
 
 var (
@@ -123,12 +142,16 @@ type Config struct {
 	// Config for metadata purging.
 	MetadataRetainPeriod time.Duration `yaml:"metadata_retain_period"`
 
+	// How to resolve the distinct metadata received for the same metric.
+	MetadataConflictResolution string `yaml:"metadata_conflict_resolution"`
+
 	RateUpdatePeriod            time.Duration `yaml:"rate_update_period"`
 	UserTSDBConfigsUpdatePeriod time.Duration `yaml:"user_tsdb_configs_update_period"`
 
 	ActiveSeriesMetricsEnabled      bool          `yaml:"active_series_metrics_enabled"`
 	ActiveSeriesMetricsUpdatePeriod time.Duration `yaml:"active_series_metrics_update_period"`
 	ActiveSeriesMetricsIdleTimeout  time.Duration `yaml:"active_series_metrics_idle_timeout"`
+	ActiveSeriesAgeMetricsEnabled   bool          `yaml:"active_series_age_metrics_enabled"`
 
 	ActiveQueriedSeriesMetricsEnabled        bool                     `yaml:"active_queried_series_metrics_enabled"`
 	ActiveQueriedSeriesMetricsUpdatePeriod   time.Duration            `yaml:"active_queried_series_metrics_update_period"`
@@ -185,6 +208,11 @@ type Config struct {
 	// for unoptimized regex matchers, and enforce per-tenant limits if configured.
 	EnableRegexMatcherLimits bool `yaml:"enable_regex_matcher_limits"`
 
+	// The series of a push request having samples older than ColdAppendSampleAge are appended
+	// and committed separately, after the other series, with a bounded concurrency.
+	ColdAppendSampleAge      time.Duration `yaml:"cold_append_sample_age"`
+	ColdAppendMaxConcurrency int           `yaml:"cold_append_max_concurrency"`
+
 	QueryProtection configs.QueryProtection `yaml:"query_protection"`
 }
 
@@ -193,12 +221,14 @@ func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
 	cfg.LifecyclerConfig.RegisterFlags(f)
 
 	f.DurationVar(&cfg.MetadataRetainPeriod, "ingester.metadata-retain-period", 10*time.Minute, "Period at which metadata we have not seen will remain in memory before being deleted.")
+	f.StringVar(&cfg.MetadataConflictResolution, "ingester.metadata-conflict-resolution", metadataConflictResolutionAll, fmt.Sprintf("How to resolve the distinct metadata received for the same metric, eg. from different targets. Supported values are: %s. With %s, all the distinct metadata are kept and returned. With %s, only the metadata received last is kept. With %s, all the distinct metadata are kept, and only the one received the most times is returned.", strings.Join(metadataConflictResolutions, ", "), metadataConflictResolutionAll, metadataConflictResolutionMostRecent, metadataConflictResolutionMostCommon))
 
 	f.DurationVar(&cfg.RateUpdatePeriod, "ingester.rate-update-period", 15*time.Second, "Period with which to update the per-user ingestion rates.")
 	f.DurationVar(&cfg.UserTSDBConfigsUpdatePeriod, "ingester.user-tsdb-configs-update-period", 15*time.Second, "Period with which to update the per-user tsdb config.")
 	f.BoolVar(&cfg.ActiveSeriesMetricsEnabled, "ingester.active-series-metrics-enabled", true, "Enable tracking of active series and export them as metrics.")
 	f.DurationVar(&cfg.ActiveSeriesMetricsUpdatePeriod, "ingester.active-series-metrics-update-period", 1*time.Minute, "How often to update active series metrics.")
 	f.DurationVar(&cfg.ActiveSeriesMetricsIdleTimeout, "ingester.active-series-metrics-idle-timeout", 10*time.Minute, "After what time a series is considered to be inactive.")
+	f.BoolVar(&cfg.ActiveSeriesAgeMetricsEnabled, "ingester.active-series-age-metrics-enabled", false, "Enable exporting, per user, the number of active series by how long they have been active, to tell tenants with stable cardinality apart from tenants churning series. The age of a series is counted from when it was first seen active by this ingester, so it restarts from zero when the ingester restarts. The metric is updated at every active series metrics update, at a cost proportional to the number of active series. Requires active series metrics to be enabled.")
 
 	f.BoolVar(&cfg.ActiveQueriedSeriesMetricsEnabled, "ingester.active-queried-series-metrics-enabled", false, "Enable tracking of active queried series using probabilistic data structure and export them as metrics.")
 	f.DurationVar(&cfg.ActiveQueriedSeriesMetricsUpdatePeriod, "ingester.active-queried-series-metrics-update-period", 1*time.Minute, "How often to update active queried series metrics.")
@@ -223,6 +253,8 @@ func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
 	f.BoolVar(&cfg.SkipMetadataLimits, "ingester.skip-metadata-limits", true, "If enabled, the metadata API returns all metadata regardless of the limits.")
 	f.BoolVar(&cfg.EnableMatcherOptimization, "ingester.enable-matcher-optimization", false, "Enable optimization of label matchers when query chunks. When enabled, matchers with low selectivity such as =~.+ are applied lazily during series scanning instead of being used for postings matching.")
 	f.BoolVar(&cfg.EnableRegexMatcherLimits, "ingester.enable-regex-matcher-limits", false, "Enable regex matcher limits and metrics collection for unoptimized regex queries. When enabled, the ingester will track pattern length, label cardinality, and total value length for unoptimized regex matchers.")
+	f.DurationVar(&cfg.ColdAppendSampleAge, "ingester.cold-append-sample-age", 0, "[Experimental] The series of a push request having samples older than this age are appended to TSDB through a separate cold path, committed after the other series of the request, so that late-arriving and backfilled samples don't add latency to the append of fresh samples. 0 to disable.")
+	f.IntVar(&cfg.ColdAppendMaxConcurrency, "ingester.cold-append-max-concurrency", 4, "[Experimental] The maximum number of push requests having samples to append through the cold append path processed at the same time. The other requests having such samples wait for their turn before being processed, without counting toward the max inflight push requests nor holding the TSDB of the tenant meanwhile. Used only if -ingester.cold-append-sample-age is set.")
 	cfg.DefaultLimits.RegisterFlagsWithPrefix(f, "ingester.")
 	cfg.QueryProtection.RegisterFlagsWithPrefix(f, "ingester.")
 }
@@ -240,6 +272,18 @@ func (cfg *Config) Validate(monitoredResources flagext.StringSliceCSV) error {
 		return err
 	}
 
+	if !slices.Contains(metadataConflictResolutions, cfg.MetadataConflictResolution) {
+		return fmt.Errorf("unsupported metadata conflict resolution: %q", cfg.MetadataConflictResolution)
+	}
+
+	if cfg.ColdAppendSampleAge > 0 && cfg.ColdAppendMaxConcurrency < 1 {
+		return fmt.Errorf("the cold append max concurrency must be at least 1 when the cold append path is enabled")
+	}
+
+	if cfg.ActiveSeriesAgeMetricsEnabled && !cfg.ActiveSeriesMetricsEnabled {
+		return fmt.Errorf("active series age metrics require active series metrics to be enabled")
+	}
+
 	// Validate active queried series metrics windows
 	if cfg.ActiveQueriedSeriesMetricsEnabled {
 		if len(cfg.ActiveQueriedSeriesMetricsWindows) == 0 {
@@ -316,6 +360,9 @@ type Ingester struct {
 	stoppedMtx sync.RWMutex // protects stopped
 	stopped    bool         // protected by stoppedMtx
 
+	// Whether the ingester waits before leaving the ring on shutdown, while it keeps serving the reads.
+	waitingBeforeLeaving atomic.Bool
+
 	// For storing metadata ingested.
 	usersMetadataMtx sync.RWMutex
 	usersMetadata    map[string]*userMetricsMetadata
@@ -335,6 +382,9 @@ type Ingester struct {
 	expandedPostingsCacheFactory *cortex_tsdb.ExpandedPostingsCacheFactory
 
 	activeQueriedSeriesService *ActiveQueriedSeriesService
+
+	// Bounds the concurrency of the cold append path. Nil if the cold append path is disabled.
+	coldAppendSlots chan struct{}
 }
 
 // Shipper interface is used to have an easy way to mock it in tests.
@@ -394,6 +444,23 @@ type userTSDB struct {
 	// Used to detect idle TSDBs.
 	lastUpdate atomic.Int64
 
+	// Whether a head compaction has been requested because of the number of in-memory series,
+	// and it's not been run yet.
+	seriesThresholdCompactionPending atomic.Bool
+
+	// Whether a head compaction has been requested to evict the idle series from the head, because
+	// the max series per user limit has been reached with the evict-idle policy.
+	idleSeriesEvictionRequested atomic.Bool
+	idleSeriesEvicted           prometheus.Counter
+
+	// Unix timestamp (in milliseconds) of the last head compaction triggered by the number of in-memory series.
+	lastSeriesCompaction atomic.Int64
+
+	// Out-of-order time window (in milliseconds) currently applied to the TSDB. Used to
+	// apply changes of the per-tenant limit at push time. Updates are serialized by applyConfigMtx.
+	oooTimeWindow  atomic.Int64
+	applyConfigMtx sync.Mutex
+
 	// Thanos shipper used to ship blocks to the storage.
 	shipper                 Shipper
 	shipperMetadataFilePath string
@@ -463,6 +530,126 @@ func (u *userTSDB) StartTime() (int64, error) {
 	return u.db.StartTime()
 }
 
+// identicalSampleChecker finds out whether the samples of a push request rejected as out-of-order
+// or too old are identical to an ingested sample. The readers are opened once per push request: the
+// samples in the range of the Head block are looked up by series reference, and only the older ones
+// go through a querier.
+type identicalSampleChecker struct {
+	db *userTSDB
+
+	headIndex  tsdb.IndexReader
+	headChunks tsdb.ChunkReader
+	querier    storage.Querier
+
+	builder labels.ScratchBuilder
+	chks    []chunks.Meta
+}
+
+func newIdenticalSampleChecker(db *userTSDB) *identicalSampleChecker {
+	return &identicalSampleChecker{db: db}
+}
+
+// hasIdenticalSample returns whether the TSDB contains a float sample of the series with the same
+// timestamp and value. The out-of-order Head block is only checked through the querier, so the
+// in-order Head block is only looked up for the samples rejected as out-of-order.
+func (c *identicalSampleChecker) hasIdenticalSample(ctx context.Context, ref storage.SeriesRef, lset labels.Labels, t int64, v float64, appendErr error) (bool, error) {
+	if ref != 0 && t >= c.db.Head().MinTime() && errors.Is(appendErr, storage.ErrOutOfOrderSample) {
+		return c.hasIdenticalHeadSample(ref, t, v)
+	}
+	return c.hasIdenticalQueriedSample(ctx, lset, t, v)
+}
+
+func (c *identicalSampleChecker) hasIdenticalHeadSample(ref storage.SeriesRef, t int64, v float64) (bool, error) {
+	if c.headIndex == nil {
+		h := c.db.Head()
+		ir, err := h.Index()
+		if err != nil {
+			return false, err
+		}
+		cr, err := h.Chunks()
+		if err != nil {
+			_ = ir.Close()
+			return false, err
+		}
+		c.headIndex, c.headChunks = ir, cr
+	}
+
+	if err := c.headIndex.Series(ref, &c.builder, &c.chks); err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	for _, meta := range c.chks {
+		if t < meta.MinTime || t > meta.MaxTime {
+			continue
+		}
+		chk, iterable, err := c.headChunks.ChunkOrIterable(meta)
+		if err != nil {
+			return false, err
+		}
+		var it chunkenc.Iterator
+		if chk != nil {
+			it = chk.Iterator(nil)
+		} else {
+			it = iterable.Iterator(nil)
+		}
+		if identical, err := isIdenticalSample(it, t, v); identical || err != nil {
+			return identical, err
+		}
+	}
+	return false, nil
+}
+
+func (c *identicalSampleChecker) hasIdenticalQueriedSample(ctx context.Context, lset labels.Labels, t int64, v float64) (bool, error) {
+	if c.querier == nil {
+		q, err := c.db.Querier(math.MinInt64, math.MaxInt64)
+		if err != nil {
+			return false, err
+		}
+		c.querier = q
+	}
+
+	matchers := make([]*labels.Matcher, 0, lset.Len())
+	lset.Range(func(l labels.Label) {
+		matchers = append(matchers, labels.MustNewMatcher(labels.MatchEqual, l.Name, l.Value))
+	})
+
+	hints := &storage.SelectHints{Start: t, End: t}
+	ss := c.querier.Select(ctx, false, hints, matchers...)
+	for ss.Next() {
+		series := ss.At()
+		if !labels.Equal(series.Labels(), lset) {
+			continue
+		}
+		if identical, err := isIdenticalSample(series.Iterator(nil), t, v); identical || err != nil {
+			return identical, err
+		}
+	}
+	return false, ss.Err()
+}
+
+// close releases the readers opened by the checker.
+func (c *identicalSampleChecker) close() {
+	if c.headIndex != nil {
+		_ = c.headIndex.Close()
+		_ = c.headChunks.Close()
+	}
+	if c.querier != nil {
+		_ = c.querier.Close()
+	}
+}
+
+// isIdenticalSample returns whether the iterator has a float sample with the given timestamp and value.
+func isIdenticalSample(it chunkenc.Iterator, t int64, v float64) (bool, error) {
+	if it.Seek(t) != chunkenc.ValFloat {
+		return false, it.Err()
+	}
+	ts, value := it.At()
+	return ts == t && math.Float64bits(value) == math.Float64bits(v), nil
+}
+
 func (u *userTSDB) casState(from, to tsdbState) bool {
 	u.stateMtx.Lock()
 	defer u.stateMtx.Unlock()
@@ -476,6 +663,13 @@ func (u *userTSDB) casState(from, to tsdbState) bool {
 
 // compactHead compacts the Head block at specified block durations avoiding a single huge block.
 func (u *userTSDB) compactHead(ctx context.Context, blockDuration int64) error {
+	return u.compactHeadBefore(ctx, blockDuration, math.MaxInt64)
+}
+
+// compactHeadBefore compacts the samples of the Head block older than the given time at specified block
+// durations, truncating the Head block accordingly. The out-of-order Head block is only compacted along
+// with the whole Head block.
+func (u *userTSDB) compactHeadBefore(ctx context.Context, blockDuration int64, before int64) error {
 	if !u.casState(active, forceCompacting) {
 		return errors.New("TSDB head cannot be compacted because it is not in active state (possibly being closed or blocks shipping in progress)")
 	}
@@ -489,7 +683,7 @@ func (u *userTSDB) compactHead(ctx context.Context, blockDuration int64) error {
 
 	h := u.Head()
 
-	minTime, maxTime := h.MinTime(), h.MaxTime()
+	minTime, maxTime := h.MinTime(), min(h.MaxTime(), before-1)
 
 	for (minTime/blockDuration)*blockDuration != (maxTime/blockDuration)*blockDuration {
 		// Data in Head spans across multiple block ranges, so we break it into blocks here.
@@ -500,15 +694,40 @@ func (u *userTSDB) compactHead(ctx context.Context, blockDuration int64) error {
 		}
 
 		// Get current min/max times after compaction.
-		minTime, maxTime = h.MinTime(), h.MaxTime()
+		minTime, maxTime = h.MinTime(), min(h.MaxTime(), before-1)
 	}
 
 	if err := u.db.CompactHead(tsdb.NewRangeHead(h, minTime, maxTime)); err != nil {
 		return err
 	}
+	if before != math.MaxInt64 {
+		return nil
+	}
 	return u.db.CompactOOOHead(ctx)
 }
 
+// truncateHeadBefore compacts the samples of the Head block older than the given time at specified block
+// durations, truncating the Head block accordingly. Unlike compactHeadBefore, the pushes are not rejected
+// meanwhile: like the TSDB head compaction, the given time must not be more recent than the min valid time
+// of the appends, that is half the block duration before the Head block max time, so that no sample can
+// be appended within the compacted range once the in-flight appends overlapping it are done.
+func (u *userTSDB) truncateHeadBefore(blockDuration int64, before int64) error {
+	h := u.Head()
+	before = min(before, h.MaxTime()-blockDuration/2)
+
+	for h.NumSeries() > 0 && h.MinTime() < before {
+		// Block max time is exclusive, so we do a -1 here.
+		minTime := h.MinTime()
+		rh := tsdb.NewRangeHeadWithIsolationDisabled(h, minTime, min(((minTime/blockDuration)+1)*blockDuration, before)-1)
+		h.WaitForAppendersOverlapping(rh.MaxTime())
+
+		if err := u.db.CompactHead(rh); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // PreCreation implements SeriesLifecycleCallback interface.
 func (u *userTSDB) PreCreation(metric labels.Labels) error {
 	if u.limiter == nil {
@@ -525,6 +744,15 @@ func (u *userTSDB) PreCreation(metric labels.Labels) error {
 
 	// Total series limit.
 	if err := u.limiter.AssertMaxSeriesPerUser(u.userID, int(u.Head().NumSeries())); err != nil {
+		// With the evict-idle policy, a head compaction is requested to evict the series not appended
+		// within the active series idle timeout, once their samples are compacted into a block. The
+		// new series are rejected until then. The compaction is only requested if enough series are
+		// idle, so that it's worth it.
+		if u.limiter.MaxSeriesPolicy(u.userID) == validation.MaxSeriesPolicyEvictIdle {
+			if numSeries := int(u.Head().NumSeries()); float64(numSeries-u.activeSeries.Active()) >= float64(numSeries)*minIdleSeriesRatioForEviction {
+				u.idleSeriesEvictionRequested.Store(true)
+			}
+		}
 		return err
 	}
 
@@ -581,7 +809,7 @@ func (u *userTSDB) PostDeletion(metrics map[chunks.HeadSeriesRef]labels.Labels)
 			// This should never happen because it has already been checked in PreCreation().
 			continue
 		}
-		u.seriesInMetric.decreaseSeriesForMetric(metricName)
+		u.seriesInMetric.decreaseSeriesForMetric(u.userID, metricName)
 		u.labelSetCounter.decreaseSeriesLabelSet(u, metric)
 		u.trackerCounter.decrease(metric)
 		if u.postingCache != nil {
@@ -719,6 +947,9 @@ type TSDBState struct {
 	forceCompactTrigger chan requestWithUsersAndCallback
 	shipTrigger         chan requestWithUsersAndCallback
 
+	// Tenants whose head has reached the series threshold triggering the compaction.
+	seriesThresholdCompactTrigger chan string
+
 	// Timeout chosen for idle compactions.
 	compactionIdleTimeout time.Duration
 
@@ -727,10 +958,12 @@ type TSDBState struct {
 
 	// Head compactions metrics.
 	compactionsTriggered   prometheus.Counter
+	compactionsByReason    *prometheus.CounterVec
 	compactionsFailed      prometheus.Counter
 	walReplayTime          prometheus.Histogram
 	appenderAddDuration    prometheus.Histogram
 	appenderCommitDuration prometheus.Histogram
+	appendPathDuration     *prometheus.HistogramVec
 	idleTsdbChecks         *prometheus.CounterVec
 }
 
@@ -756,6 +989,15 @@ func newTSDBState(bucketClient objstore.Bucket, registerer prometheus.Registerer
 	idleTsdbChecks.WithLabelValues(string(tsdbTenantMarkedForDeletion))
 	idleTsdbChecks.WithLabelValues(string(tsdbIdleClosed))
 
+	compactionsByReason := promauto.With(registerer).NewCounterVec(prometheus.CounterOpts{
+		Name: "cortex_ingester_tsdb_compactions_triggered_by_reason_total",
+		Help: "Total number of triggered compactions, by the reason triggering them.",
+	}, []string{"reason"})
+
+	for _, reason := range []string{compactionReasonForced, compactionReasonIdle, compactionReasonSeriesThreshold, compactionReasonEvictIdle, compactionReasonHeadRetention, compactionReasonRegular} {
+		compactionsByReason.WithLabelValues(reason)
+	}
+
 	return TSDBState{
 		dbs:                 make(map[string]*userTSDB),
 		bucket:              bucketClient,
@@ -763,10 +1005,13 @@ func newTSDBState(bucketClient objstore.Bucket, registerer prometheus.Registerer
 		forceCompactTrigger: make(chan requestWithUsersAndCallback),
 		shipTrigger:         make(chan requestWithUsersAndCallback),
 
+		seriesThresholdCompactTrigger: make(chan string, seriesThresholdCompactTriggerSize),
+
 		compactionsTriggered: promauto.With(registerer).NewCounter(prometheus.CounterOpts{
 			Name: "cortex_ingester_tsdb_compactions_triggered_total",
 			Help: "Total number of triggered compactions.",
 		}),
+		compactionsByReason: compactionsByReason,
 
 		compactionsFailed: promauto.With(registerer).NewCounter(prometheus.CounterOpts{
 			Name: "cortex_ingester_tsdb_compactions_failed_total",
@@ -796,6 +1041,14 @@ func newTSDBState(bucketClient objstore.Bucket, registerer prometheus.Registerer
 			NativeHistogramMaxBucketNumber:  100,
 			NativeHistogramMinResetDuration: time.Hour,
 		}),
+		appendPathDuration: promauto.With(registerer).NewHistogramVec(prometheus.HistogramOpts{
+			Name:                            "cortex_ingester_tsdb_append_path_duration_seconds",
+			Help:                            "The total time it takes for a push request to append and commit samples to TSDB, by append path. The cold path includes the time spent waiting for its turn.",
+			Buckets:                         []float64{.001, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10},
+			NativeHistogramBucketFactor:     1.1,
+			NativeHistogramMaxBucketNumber:  100,
+			NativeHistogramMinResetDuration: time.Hour,
+		}, []string{"path"}),
 
 		idleTsdbChecks: idleTsdbChecks,
 	}
@@ -824,6 +1077,10 @@ func New(cfg Config, limits *validation.Overrides, registerer prometheus.Registe
 		matchersCache:                storecache.NoopMatchersCache,
 	}
 
+	if cfg.ColdAppendSampleAge > 0 {
+		i.coldAppendSlots = make(chan struct{}, cfg.ColdAppendMaxConcurrency)
+	}
+
 	if cfg.ActiveQueriedSeriesMetricsEnabled || cfg.HeadQueriedSeriesMetricsEnabled {
 		i.activeQueriedSeriesService = NewActiveQueriedSeriesService(logger, registerer)
 	}
@@ -840,6 +1097,7 @@ func New(cfg Config, limits *validation.Overrides, registerer prometheus.Registe
 	i.metrics = newIngesterMetrics(registerer,
 		false,
 		cfg.ActiveSeriesMetricsEnabled,
+		cfg.ActiveSeriesAgeMetricsEnabled,
 		cfg.ActiveQueriedSeriesMetricsEnabled,
 		cfg.HeadQueriedSeriesMetricsEnabled,
 		i.getInstanceLimits,
@@ -941,6 +1199,7 @@ func NewForFlusher(cfg Config, limits *validation.Overrides, registerer promethe
 		false,
 		false,
 		false,
+		false,
 		i.getInstanceLimits,
 		nil,
 		&i.maxInflightPushRequests,
@@ -1140,22 +1399,88 @@ func (i *Ingester) updateUserTSDBConfigs() {
 			continue
 		}
 
-		cfg := &config.Config{
-			StorageConfig: config.StorageConfig{
-				ExemplarsConfig: &config.ExemplarsConfig{
-					MaxExemplars: i.getMaxExemplars(userID),
-				},
-				TSDBConfig: &config.TSDBConfig{
-					OutOfOrderTimeWindow: time.Duration(i.limits.OutOfOrderTimeWindow(userID)).Milliseconds(),
-				},
-			},
+		if err := i.applyUserTSDBConfig(userID, userDB); err != nil {
+			level.Error(logutil.WithUserID(userID, i.logger)).Log("msg", "failed to update user tsdb configuration.")
 		}
+	}
+}
 
-		// This method currently updates the MaxExemplars and OutOfOrderTimeWindow.
-		err = userDB.db.ApplyConfig(cfg)
-		if err != nil {
-			level.Error(logutil.WithUserID(userID, i.logger)).Log("msg", "failed to update user tsdb configuration.")
+// applyUserTSDBConfig applies the current per-tenant limits to the user TSDB.
+// This method currently updates the MaxExemplars and OutOfOrderTimeWindow.
+func (i *Ingester) applyUserTSDBConfig(userID string, userDB *userTSDB) error {
+	userDB.applyConfigMtx.Lock()
+	defer userDB.applyConfigMtx.Unlock()
+
+	oooTimeWindow := time.Duration(i.limits.OutOfOrderTimeWindow(userID)).Milliseconds()
+	cfg := &config.Config{
+		StorageConfig: config.StorageConfig{
+			ExemplarsConfig: &config.ExemplarsConfig{
+				MaxExemplars: i.getMaxExemplars(userID),
+			},
+			TSDBConfig: &config.TSDBConfig{
+				OutOfOrderTimeWindow: oooTimeWindow,
+			},
+		},
+	}
+
+	if err := userDB.db.ApplyConfig(cfg); err != nil {
+		return err
+	}
+
+	userDB.oooTimeWindow.Store(oooTimeWindow)
+	return nil
+}
+
+// hasColdSamples returns whether the request has a sample older than minT, so that some of its series
+// must be appended through the cold append path.
+func hasColdSamples(req *cortexpb.WriteRequest, minT int64) bool {
+	for _, ts := range req.Timeseries {
+		for _, s := range ts.Samples {
+			if s.TimestampMs < minT {
+				return true
+			}
 		}
+		for _, h := range ts.Histograms {
+			if h.TimestampMs < minT {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isColdSeries returns whether the series must be appended through the cold append path, because
+// it has a sample older than minT or another entry of the same series in the request has. The
+// series appended through the cold path are tracked in coldSeries.
+func isColdSeries(ts cortexpb.PreallocTimeseries, hash uint64, minT int64, coldSeries map[uint64]struct{}) bool {
+	if _, ok := coldSeries[hash]; ok {
+		return true
+	}
+
+	cold := false
+	for _, s := range ts.Samples {
+		cold = cold || s.TimestampMs < minT
+	}
+	for _, h := range ts.Histograms {
+		cold = cold || h.TimestampMs < minT
+	}
+
+	if cold {
+		coldSeries[hash] = struct{}{}
+	}
+	return cold
+}
+
+// updateUserTSDBOutOfOrderTimeWindow applies the per-tenant out-of-order time window to the
+// user TSDB if it has changed since it was last applied, so that changes of the limit take
+// effect for the samples appended by the next push without waiting for the periodic update.
+func (i *Ingester) updateUserTSDBOutOfOrderTimeWindow(userID string, userDB *userTSDB) {
+	if time.Duration(i.limits.OutOfOrderTimeWindow(userID)).Milliseconds() == userDB.oooTimeWindow.Load() {
+		return
+	}
+
+	if err := i.applyUserTSDBConfig(userID, userDB); err != nil {
+		level.Error(logutil.WithUserID(userID, i.logger)).Log("msg", "failed to update user tsdb out-of-order time window", "err", err)
 	}
 }
 
@@ -1184,6 +1509,9 @@ func (i *Ingester) updateActiveSeries(ctx context.Context) {
 		userDB.activeSeries.Purge(purgeTime)
 		i.metrics.activeSeriesPerUser.WithLabelValues(userID).Set(float64(userDB.activeSeries.Active()))
 		i.metrics.activeNHSeriesPerUser.WithLabelValues(userID).Set(float64(userDB.activeSeries.ActiveNativeHistogram()))
+		if i.cfg.ActiveSeriesAgeMetricsEnabled {
+			i.metrics.activeSeriesAgePerUser.update(userID, userDB.activeSeries, time.Now())
+		}
 		if err := userDB.labelSetCounter.UpdateMetric(ctx, userDB, i.metrics); err != nil {
 			level.Warn(i.logger).Log("msg", "failed to update per labelSet metrics", "user", userID, "err", err)
 		}
@@ -1309,6 +1637,16 @@ func (i *Ingester) checkRunning() error {
 	return status.Error(codes.Unavailable, s.String())
 }
 
+// checkReadable checks that the ingester can serve the read requests. Besides the Running state, the
+// ingester keeps serving them while it waits before leaving the ring on shutdown, because it still
+// holds its replicas of the series and its TSDBs are not closed yet.
+func (i *Ingester) checkReadable() error {
+	if i.State() == services.Stopping && i.waitingBeforeLeaving.Load() {
+		return nil
+	}
+	return i.checkRunning()
+}
+
 // GetRef() is an extra method added to TSDB to let Cortex check before calling Add()
 type extendedAppender interface {
 	storage.Appender
@@ -1343,6 +1681,22 @@ func (i *Ingester) Push(ctx context.Context, req *cortexpb.WriteRequest) (*corte
 		return nil, err
 	}
 
+	// The requests with series to append through the cold append path wait for their turn before being
+	// counted as in-flight and before taking the append lock of the tenant, so that they neither reject
+	// nor delay the fresh push requests and the forced head compactions meanwhile.
+	var coldMinT int64
+	if i.coldAppendSlots != nil {
+		coldMinT = time.Now().Add(-i.cfg.ColdAppendSampleAge).UnixMilli()
+		if hasColdSamples(req, coldMinT) {
+			select {
+			case i.coldAppendSlots <- struct{}{}:
+				defer func() { <-i.coldAppendSlots }()
+			case <-ctx.Done():
+				return nil, wrapWithUser(ctx.Err(), userID)
+			}
+		}
+	}
+
 	// We will report *this* request in the error too.
 	inflight := i.inflightPushRequests.Inc()
 	i.maxInflightPushRequests.Track(inflight)
@@ -1388,6 +1742,8 @@ func (i *Ingester) Push(ctx context.Context, req *cortexpb.WriteRequest) (*corte
 	}
 	defer db.releaseAppendLock()
 
+	i.updateUserTSDBOutOfOrderTimeWindow(userID, db)
+
 	// Given metadata is a best-effort approach, and we don't halt on errors
 	// process it before samples. Otherwise, we risk returning an error before ingestion.
 	ingestedMetadata := i.pushMetadata(ctx, userID, req.GetMetadata())
@@ -1410,10 +1766,13 @@ func (i *Ingester) Push(ctx context.Context, req *cortexpb.WriteRequest) (*corte
 		sampleOutOfOrderCount                  = 0
 		sampleTooOldCount                      = 0
 		newValueForTimestampCount              = 0
+		identicalDuplicateSamplesCount         = 0
+		identicalDuplicateCheckFailuresCount   = 0
 		perUserSeriesLimitCount                = 0
 		perUserNativeHistogramSeriesLimitCount = 0
 		perLabelSetSeriesLimitCount            = 0
 		perMetricSeriesLimitCount              = 0
+		perMetricSeriesQuarantineCount         = 0
 		discardedNativeHistogramCount          = 0
 
 		updateFirstPartial = func(errFn func() error) {
@@ -1468,6 +1827,13 @@ func (i *Ingester) Push(ctx context.Context, req *cortexpb.WriteRequest) (*corte
 					return makeMetricLimitError(perMetricSeriesLimit, copiedLabels, i.limiter.FormatError(userID, cause, copiedLabels))
 				})
 
+			case errors.Is(cause, errMetricQuarantined):
+				perMetricSeriesQuarantineCount++
+				i.validateMetrics.DiscardedSeriesTracker.Track(perMetricSeriesQuarantine, userID, copiedLabels.Hash())
+				updateFirstPartial(func() error {
+					return makeMetricLimitError(perMetricSeriesQuarantine, copiedLabels, i.limiter.FormatError(userID, cause, copiedLabels))
+				})
+
 			case errors.As(cause, &errMaxSeriesPerLabelSetLimitExceeded{}):
 				perLabelSetSeriesLimitCount++
 				i.validateMetrics.DiscardedSeriesTracker.Track(perLabelsetSeriesLimit, userID, copiedLabels.Hash())
@@ -1491,32 +1857,57 @@ func (i *Ingester) Push(ctx context.Context, req *cortexpb.WriteRequest) (*corte
 	)
 
 	// Walk the samples, appending them to the users database
-	app := db.Appender(ctx).(extendedAppender)
+	hotApp := db.Appender(ctx).(extendedAppender)
 
-	// Ensure the appender is always released so that we don't leak TSDB head
+	// The series with samples older than the cold append age are appended to a separate appender,
+	// committed after the hot one. A series is always appended to a single appender, so that its
+	// samples are committed in the order they're received.
+	var (
+		coldApp    extendedAppender
+		coldSeries map[uint64]struct{}
+	)
+	if i.coldAppendSlots != nil {
+		coldApp = db.Appender(ctx).(extendedAppender)
+		coldSeries = map[uint64]struct{}{}
+	}
+
+	// Ensure the appenders are always released so that we don't leak TSDB head
 	// series references, mmap'd chunks and pending state on early returns.
 	// `committed` is flipped to true immediately before app.Commit() because
 	// Prometheus closes the appender even on Commit failure (it self-rolls
 	// back internally on WAL error), so the deferred Rollback must not run
 	// afterwards.
-	committed := false
+	committed, coldCommitted := false, false
 	defer func() {
-		if committed {
-			return
+		if !committed {
+			if rollbackErr := hotApp.Rollback(); rollbackErr != nil {
+				level.Warn(logutil.WithContext(ctx, i.logger)).Log("msg", "failed to rollback appender on early return", "user", userID, "err", rollbackErr)
+			}
 		}
-		if rollbackErr := app.Rollback(); rollbackErr != nil {
-			level.Warn(logutil.WithContext(ctx, i.logger)).Log("msg", "failed to rollback appender on early return", "user", userID, "err", rollbackErr)
+		if coldApp != nil && !coldCommitted {
+			if rollbackErr := coldApp.Rollback(); rollbackErr != nil {
+				level.Warn(logutil.WithContext(ctx, i.logger)).Log("msg", "failed to rollback cold appender on early return", "user", userID, "err", rollbackErr)
+			}
 		}
 	}()
 
 	// Even when OOO is enabled globally, we want to reject OOO samples in some cases.
 	// prometheus implementation: https://github.com/prometheus/prometheus/pull/14710
 	if req.DiscardOutOfOrder {
-		app.SetOptions(&storage.AppendOptions{DiscardOutOfOrder: true})
+		hotApp.SetOptions(&storage.AppendOptions{DiscardOutOfOrder: true})
+		if coldApp != nil {
+			coldApp.SetOptions(&storage.AppendOptions{DiscardOutOfOrder: true})
+		}
 	}
 
 	var newSeries []labels.Labels
 
+	var identicalChecker *identicalSampleChecker
+	if i.limits.AcceptIdenticalDuplicateSamples(userID) {
+		identicalChecker = newIdenticalSampleChecker(db)
+		defer identicalChecker.close()
+	}
+
 	for _, ts := range req.Timeseries {
 		// The labels must be sorted (in our case, it's guaranteed a write request
 		// has sorted labels once hit the ingester).
@@ -1528,6 +1919,11 @@ func (i *Ingester) Push(ctx context.Context, req *cortexpb.WriteRequest) (*corte
 			return nil, wrapWithUser(errors.Errorf("out-of-order label set found when push: %s", tsLabels), userID)
 		}
 		tsLabelsHash := tsLabels.Hash()
+
+		app := hotApp
+		if coldApp != nil && isColdSeries(ts, tsLabelsHash, coldMinT, coldSeries) {
+			app = coldApp
+		}
 		ref, copiedLabels := app.GetRef(tsLabels, tsLabelsHash)
 
 		// To find out if any sample was added to this series, we keep old value.
@@ -1572,6 +1968,19 @@ func (i *Ingester) Push(ctx context.Context, req *cortexpb.WriteRequest) (*corte
 				}
 			}
 
+			// A retried write request may contain samples which were already ingested, and are
+			// now rejected as out-of-order or too old. Accept them if they are exact duplicates.
+			if identicalChecker != nil && (errors.Is(err, storage.ErrOutOfOrderSample) || errors.Is(err, storage.ErrTooOldSample)) {
+				identical, checkErr := identicalChecker.hasIdenticalSample(ctx, ref, copiedLabels, s.TimestampMs, s.Value, err)
+				if checkErr != nil {
+					identicalDuplicateCheckFailuresCount++
+					level.Warn(logutil.WithContext(ctx, i.logger)).Log("msg", "failed to check whether the rejected sample is an identical duplicate", "user", userID, "err", checkErr)
+				} else if identical {
+					identicalDuplicateSamplesCount++
+					continue
+				}
+			}
+
 			failedSamplesCount++
 
 			if rollback := handleAppendFailure(err, s.TimestampMs, ts.Labels, copiedLabels, matchedLabelSetLimits); !rollback {
@@ -1705,10 +2114,22 @@ func (i *Ingester) Push(ctx context.Context, req *cortexpb.WriteRequest) (*corte
 	// both success and failure of Commit (it self-rolls-back on WAL error), so
 	// the deferred Rollback must not fire afterwards.
 	committed = true
-	if err := app.Commit(); err != nil {
+	if err := hotApp.Commit(); err != nil {
 		return nil, wrapWithUser(err, userID)
 	}
 
+	if coldApp != nil {
+		i.TSDBState.appendPathDuration.WithLabelValues("hot").Observe(time.Since(startAppend).Seconds())
+
+		if len(coldSeries) > 0 {
+			coldCommitted = true
+			if err := coldApp.Commit(); err != nil {
+				return nil, wrapWithUser(err, userID)
+			}
+			i.TSDBState.appendPathDuration.WithLabelValues("cold").Observe(time.Since(startAppend).Seconds())
+		}
+	}
+
 	// This is a workaround of https://github.com/prometheus/prometheus/pull/15579
 	// Calling expire here may result in the series names being expired multiple times,
 	// as there may be multiple Push operations concurrently for the same new timeseries.
@@ -1736,6 +2157,12 @@ func (i *Ingester) Push(ctx context.Context, req *cortexpb.WriteRequest) (*corte
 	i.metrics.ingestedExemplars.Add(float64(succeededExemplarsCount))
 	i.metrics.ingestedExemplarsFail.Add(float64(failedExemplarsCount))
 
+	if identicalDuplicateSamplesCount > 0 {
+		i.metrics.identicalDuplicatesTotal.WithLabelValues(userID).Add(float64(identicalDuplicateSamplesCount))
+	}
+	if identicalDuplicateCheckFailuresCount > 0 {
+		i.metrics.identicalDuplicateCheckFailuresTotal.WithLabelValues(userID).Add(float64(identicalDuplicateCheckFailuresCount))
+	}
 	if sampleOutOfBoundsCount > 0 {
 		i.validateMetrics.DiscardedSamples.WithLabelValues(sampleOutOfBounds, userID).Add(float64(sampleOutOfBoundsCount))
 	}
@@ -1757,6 +2184,9 @@ func (i *Ingester) Push(ctx context.Context, req *cortexpb.WriteRequest) (*corte
 	if perMetricSeriesLimitCount > 0 {
 		i.validateMetrics.DiscardedSamples.WithLabelValues(perMetricSeriesLimit, userID).Add(float64(perMetricSeriesLimitCount))
 	}
+	if perMetricSeriesQuarantineCount > 0 {
+		i.validateMetrics.DiscardedSamples.WithLabelValues(perMetricSeriesQuarantine, userID).Add(float64(perMetricSeriesQuarantineCount))
+	}
 	if perLabelSetSeriesLimitCount > 0 {
 		i.validateMetrics.DiscardedSamples.WithLabelValues(perLabelsetSeriesLimit, userID).Add(float64(perLabelSetSeriesLimitCount))
 	}
@@ -1785,6 +2215,8 @@ func (i *Ingester) Push(ctx context.Context, req *cortexpb.WriteRequest) (*corte
 		db.ingestedAPISamples.Add(int64(succeededSamplesCount + succeededHistogramsCount))
 	}
 
+	i.triggerSeriesThresholdCompaction(userID, db)
+
 	if firstPartialErr != nil {
 		code := http.StatusBadRequest
 		var ve *validationError
@@ -1915,7 +2347,7 @@ func (u *userTSDB) releaseAppendLock() {
 // QueryExemplars implements service.IngesterServer
 func (i *Ingester) QueryExemplars(ctx context.Context, req *client.ExemplarQueryRequest) (resp *client.ExemplarQueryResponse, err error) {
 	defer recoverIngester(i.logger, &err)
-	if err = i.checkRunning(); err != nil {
+	if err = i.checkReadable(); err != nil {
 		return nil, err
 	}
 
@@ -1968,16 +2400,31 @@ func (i *Ingester) queryExemplars(ctx context.Context, userID string, req *clien
 	}
 
 	numExemplars := 0
+	maxExemplars := i.limits.MaxExemplarsPerQuery(userID)
 
 	result := &client.ExemplarQueryResponse{}
 	for _, es := range res {
-		ts := cortexpb.TimeSeries{
-			Labels:    cortexpb.FromLabelsToLabelAdapters(es.SeriesLabels),
-			Exemplars: cortexpb.FromExemplarsToExemplarProtos(es.Exemplars),
+		exemplars := es.Exemplars
+		if maxExemplars > 0 && numExemplars+len(exemplars) > maxExemplars {
+			// Series are sorted, so the same series are consistently truncated. Within the
+			// last series returned, only the most recent exemplars are kept.
+			exemplars = exemplars[len(exemplars)-(maxExemplars-numExemplars):]
+			result.Truncated = true
 		}
 
-		numExemplars += len(ts.Exemplars)
-		result.Timeseries = append(result.Timeseries, ts)
+		if len(exemplars) > 0 {
+			ts := cortexpb.TimeSeries{
+				Labels:    cortexpb.FromLabelsToLabelAdapters(es.SeriesLabels),
+				Exemplars: cortexpb.FromExemplarsToExemplarProtos(exemplars),
+			}
+
+			numExemplars += len(ts.Exemplars)
+			result.Timeseries = append(result.Timeseries, ts)
+		}
+
+		if result.Truncated {
+			break
+		}
 	}
 
 	i.metrics.queriedExemplars.Observe(float64(numExemplars))
@@ -2044,7 +2491,7 @@ func (i *Ingester) LabelValuesStream(req *client.LabelValuesRequest, stream clie
 // the cleanup function should be called in order to close the querier
 func (i *Ingester) labelsValuesCommon(ctx context.Context, req *client.LabelValuesRequest) (*client.LabelValuesResponse, func(), error) {
 	cleanup := func() {}
-	if err := i.checkRunning(); err != nil {
+	if err := i.checkReadable(); err != nil {
 		return nil, cleanup, err
 	}
 
@@ -2160,7 +2607,7 @@ func (i *Ingester) LabelNamesStream(req *client.LabelNamesRequest, stream client
 // the cleanup function should be called in order to close the querier
 func (i *Ingester) labelNamesCommon(ctx context.Context, req *client.LabelNamesRequest) (*client.LabelNamesResponse, func(), error) {
 	cleanup := func() {}
-	if err := i.checkRunning(); err != nil {
+	if err := i.checkReadable(); err != nil {
 		return nil, cleanup, err
 	}
 
@@ -2291,7 +2738,7 @@ func (i *Ingester) MetricsForLabelMatchersStream(req *client.MetricsForLabelMatc
 // the cleanup function should be called in order to close the querier
 func (i *Ingester) metricsForLabelMatchersCommon(ctx context.Context, req *client.MetricsForLabelMatchersRequest, acc func(labels.Labels) error) (func(), error) {
 	cleanup := func() {}
-	if err := i.checkRunning(); err != nil {
+	if err := i.checkReadable(); err != nil {
 		return cleanup, err
 	}
 
@@ -2416,7 +2863,7 @@ func (i *Ingester) CheckReady(ctx context.Context) error {
 
 // UserStats returns ingestion statistics for the current user.
 func (i *Ingester) UserStats(ctx context.Context, req *client.UserStatsRequest) (*client.UserStatsResponse, error) {
-	if err := i.checkRunning(); err != nil {
+	if err := i.checkReadable(); err != nil {
 		return nil, err
 	}
 
@@ -2468,7 +2915,7 @@ func (i *Ingester) AllUserStatsHandler(w http.ResponseWriter, r *http.Request) {
 
 // AllUserStats returns ingestion statistics for all users known to this ingester.
 func (i *Ingester) AllUserStats(_ context.Context, _ *client.UserStatsRequest) (*client.UsersStatsResponse, error) {
-	if err := i.checkRunning(); err != nil {
+	if err := i.checkReadable(); err != nil {
 		return nil, err
 	}
 
@@ -2493,6 +2940,138 @@ func (i *Ingester) AllUserStats(_ context.Context, _ *client.UserStatsRequest) (
 	return response, nil
 }
 
+// Cardinality returns the top limit metric names, label names and label name/value pairs of the user's
+// series in the TSDB head matching the request matchers.
+func (i *Ingester) Cardinality(ctx context.Context, req *client.CardinalityRequest) (*client.CardinalityResponse, error) {
+	if err := i.checkReadable(); err != nil {
+		return nil, err
+	}
+
+	userID, err := users.TenantID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := i.getTSDB(userID)
+	if err != nil || db == nil {
+		return &client.CardinalityResponse{}, nil
+	}
+
+	if err := db.acquireReadLock(); err != nil {
+		return &client.CardinalityResponse{}, nil
+	}
+	defer db.releaseReadLock()
+
+	limit, matchers, err := client.FromCardinalityRequest(i.matchersCache, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp *client.CardinalityResponse
+	if len(matchers) == 0 {
+		// The stats of all the series are computed from the sizes of the head postings, without reading the series.
+		stats := db.Head().Stats(labels.MetricName, limit)
+		resp = &client.CardinalityResponse{
+			NumSeries:                   stats.NumSeries,
+			SeriesCountByMetricName:     cardinalityStatsFromPostingsStats(stats.IndexPostingStats.CardinalityMetricsStats),
+			LabelValueCountByLabelName:  cardinalityStatsFromPostingsStats(stats.IndexPostingStats.CardinalityLabelStats),
+			SeriesCountByLabelValuePair: cardinalityStatsFromPostingsStats(stats.IndexPostingStats.LabelValuePairsStats),
+		}
+	} else if resp, err = headCardinality(ctx, db.Head(), limit, matchers); err != nil {
+		return nil, err
+	}
+
+	// The head time range is unset while the head is empty.
+	if minT, maxT := db.Head().MinTime(), db.Head().MaxTime(); minT <= maxT {
+		resp.MinTimestampMs, resp.MaxTimestampMs = minT, maxT
+	}
+	return resp, nil
+}
+
+// headCardinality returns the top limit metric names, label names and label name/value pairs of the
+// head series matching the matchers, and the number of chunks of these series.
+func headCardinality(ctx context.Context, head *tsdb.Head, limit int, matchers []*labels.Matcher) (*client.CardinalityResponse, error) {
+	ir, err := head.Index()
+	if err != nil {
+		return nil, err
+	}
+	defer ir.Close()
+
+	postings, err := tsdb.PostingsForMatchers(ctx, ir, matchers...)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		numSeries              uint64
+		numChunks              uint64
+		builder                labels.ScratchBuilder
+		chks                   []chunks.Meta
+		seriesByMetricName     = map[string]uint64{}
+		seriesByLabelValuePair = map[string]uint64{}
+		labelValuesByLabelName = map[string]map[string]struct{}{}
+	)
+	for postings.Next() {
+		// Interrupt if the context has been canceled.
+		if numSeries%util.CheckContextEveryNIterations == 0 && ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		if err := ir.Series(postings.At(), &builder, &chks); err != nil {
+			// The series may have been garbage collected since the postings have been read.
+			if errors.Is(err, storage.ErrNotFound) {
+				continue
+			}
+			return nil, err
+		}
+
+		numSeries++
+		numChunks += uint64(len(chks))
+
+		// The stats aren't computed if none is returned.
+		if limit <= 0 {
+			continue
+		}
+		builder.Labels().Range(func(l labels.Label) {
+			if l.Name == labels.MetricName {
+				seriesByMetricName[l.Value]++
+			}
+			seriesByLabelValuePair[l.Name+"="+l.Value]++
+
+			values, ok := labelValuesByLabelName[l.Name]
+			if !ok {
+				values = map[string]struct{}{}
+				labelValuesByLabelName[l.Name] = values
+			}
+			values[l.Value] = struct{}{}
+		})
+	}
+	if err := postings.Err(); err != nil {
+		return nil, err
+	}
+
+	labelValueCountByLabelName := make(map[string]uint64, len(labelValuesByLabelName))
+	for name, values := range labelValuesByLabelName {
+		labelValueCountByLabelName[name] = uint64(len(values))
+	}
+
+	return &client.CardinalityResponse{
+		NumSeries:                   numSeries,
+		SeriesCountByMetricName:     client.TopCardinalityStats(seriesByMetricName, limit),
+		LabelValueCountByLabelName:  client.TopCardinalityStats(labelValueCountByLabelName, limit),
+		SeriesCountByLabelValuePair: client.TopCardinalityStats(seriesByLabelValuePair, limit),
+		NumChunks:                   numChunks,
+	}, nil
+}
+
+func cardinalityStatsFromPostingsStats(stats []index.Stat) []client.CardinalityStat {
+	result := make([]client.CardinalityStat, 0, len(stats))
+	for _, s := range stats {
+		result = append(result, client.CardinalityStat{Name: s.Name, Value: s.Count})
+	}
+	return result
+}
+
 func createUserStats(db *userTSDB, activeSeriesMetricsEnabled bool) UserStats {
 	apiRate := db.ingestedAPISamples.Rate()
 	ruleRate := db.ingestedRuleSamples.Rate()
@@ -2520,7 +3099,7 @@ func (i *Ingester) QueryStream(req *client.QueryRequest, stream client.Ingester_
 	defer recoverIngester(i.logger, &err)
 	defer req.Free()
 
-	if err = i.checkRunning(); err != nil {
+	if err = i.checkReadable(); err != nil {
 		return err
 	}
 
@@ -3012,7 +3591,7 @@ func (i *Ingester) createTSDB(userID string) (*userTSDB, error) {
 		activeSeries:        NewActiveSeries(),
 		activeQueriedSeries: activeQueriedSeries,
 		headQueriedSeries:   headQueriedSeries,
-		seriesInMetric:      newMetricCounter(i.limiter, i.cfg.getIgnoreSeriesLimitForMetricNamesMap()),
+		seriesInMetric:      newMetricCounter(i.limiter, i.cfg.getIgnoreSeriesLimitForMetricNamesMap(), i.metrics.quarantinedMetrics),
 		labelSetCounter:     newLabelSetCounter(i.limiter),
 		trackerCounter:      newTrackerCounter(),
 		ingestedAPISamples:  util_math.NewEWMARate(0.2, i.cfg.RateUpdatePeriod),
@@ -3025,6 +3604,7 @@ func (i *Ingester) createTSDB(userID string) (*userTSDB, error) {
 
 		blockRetentionPeriod: i.cfg.BlocksStorageConfig.TSDB.Retention.Milliseconds(),
 		postingCache:         postingCache,
+		idleSeriesEvicted:    i.metrics.idleSeriesEvictedTotal.WithLabelValues(userID),
 	}
 
 	enableExemplars := false
@@ -3039,7 +3619,9 @@ func (i *Ingester) createTSDB(userID string) (*userTSDB, error) {
 		walCompressType = i.cfg.BlocksStorageConfig.TSDB.WALCompressionType
 	}
 
-	// Create a new user database
+	// Create a new user database, tracking the progress of the WAL replay.
+	dbStats := tsdb.NewDBStats()
+	stopWALReplayProgress := i.trackWALReplayProgress(userID, dbStats.Head.WALReplayStatus)
 	db, err := tsdb.Open(udir, logutil.GoKitLogToSlog(userLogger), tsdbPromReg, &tsdb.Options{
 		RetentionDuration:              i.cfg.BlocksStorageConfig.TSDB.Retention.Milliseconds(),
 		MinBlockDuration:               blockRanges[0],
@@ -3060,10 +3642,13 @@ func (i *Ingester) createTSDB(userID string) (*userTSDB, error) {
 		OutOfOrderCapMax:               i.cfg.BlocksStorageConfig.TSDB.OutOfOrderCapMax,
 		EnableOverlappingCompaction:    false, // Always let compactors handle overlapped blocks, e.g. OOO blocks.
 		BlockChunkQuerierFunc:          i.blockChunkQuerierFunc(userID),
-	}, nil)
+	}, dbStats)
+	stopWALReplayProgress()
 	if err != nil {
+		i.metrics.walReplayProgress.DeleteLabelValues(userID)
 		return nil, errors.Wrapf(err, "failed to open TSDB: %s", udir)
 	}
+	i.metrics.walReplayProgress.WithLabelValues(userID).Set(1)
 	db.DisableCompactions() // we will compact on our own schedule
 
 	// Run compaction before using this TSDB. If there is data in head that needs to be put into blocks,
@@ -3079,6 +3664,7 @@ func (i *Ingester) createTSDB(userID string) (*userTSDB, error) {
 	}
 
 	userDB.db = db
+	userDB.oooTimeWindow.Store(time.Duration(oooTimeWindow).Milliseconds())
 	// We set the limiter here because we don't want to limit
 	// series during WAL replay.
 	userDB.limiter = i.limiter
@@ -3159,6 +3745,45 @@ func (i *Ingester) closeAllTSDB() {
 	wg.Wait()
 }
 
+// trackWALReplayProgress periodically updates the WAL replay progress of the user from the given status,
+// until the returned function is called.
+func (i *Ingester) trackWALReplayProgress(userID string, status *tsdb.WALReplayStatus) (stop func()) {
+	progress := i.metrics.walReplayProgress.WithLabelValues(userID)
+	progress.Set(0)
+
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+
+		ticker := time.NewTicker(walReplayProgressUpdateInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				replay := status.GetWALReplayStatus()
+				progress.Set(walReplayProgressRatio(replay.Min, replay.Max, replay.Current))
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
+
+// walReplayProgressRatio returns the ratio of the WAL segments replayed so far, between 0 and 1.
+func walReplayProgressRatio(minSegment, maxSegment, currentSegment int) float64 {
+	if maxSegment <= minSegment {
+		return 0
+	}
+	return float64(currentSegment-minSegment) / float64(maxSegment-minSegment)
+}
+
 // openExistingTSDB walks the user tsdb dir, and opens a tsdb for each user. This may start a WAL replay, so we limit the number of
 // concurrently opening TSDB.
 func (i *Ingester) openExistingTSDB(ctx context.Context) error {
@@ -3424,6 +4049,12 @@ func (i *Ingester) compactionLoop(ctx context.Context) error {
 			i.compactBlocks(ctx, true, req.users)
 			close(req.callback) // Notify back.
 
+		case userID := <-i.TSDBState.seriesThresholdCompactTrigger:
+			i.compactBlocks(ctx, false, users.NewAllowedTenants([]string{userID}, nil))
+			if userDB, err := i.getTSDB(userID); err == nil && userDB != nil {
+				userDB.seriesThresholdCompactionPending.Store(false)
+			}
+
 		case <-ctx.Done():
 			return nil
 		}
@@ -3458,24 +4089,64 @@ func (i *Ingester) compactBlocks(ctx context.Context, force bool, allowed *users
 			return nil
 		}
 
-		i.TSDBState.compactionsTriggered.Inc()
+		truncateBefore, reachedHeadRetention := i.headRetentionTruncationTime(userID, h)
+		seriesCompactionBefore, seriesCompactionAllowed := i.seriesCompactionBefore(userDB, time.Now())
 
 		reason := ""
 		switch {
 		case force:
-			reason = "forced"
+			reason = compactionReasonForced
+		case i.TSDBState.compactionIdleTimeout > 0 && userDB.isIdle(time.Now(), i.TSDBState.compactionIdleTimeout):
+			reason = compactionReasonIdle
+		case i.reachedSeriesThreshold(h) && seriesCompactionAllowed:
+			reason = compactionReasonSeriesThreshold
+		case userDB.idleSeriesEvictionRequested.Load() && seriesCompactionAllowed:
+			reason = compactionReasonEvictIdle
+		case reachedHeadRetention:
+			reason = compactionReasonHeadRetention
+		default:
+			reason = compactionReasonRegular
+		}
+
+		i.TSDBState.compactionsTriggered.Inc()
+		i.TSDBState.compactionsByReason.WithLabelValues(reason).Inc()
+
+		switch reason {
+		case compactionReasonForced:
 			err = userDB.compactHead(ctx, i.cfg.BlocksStorageConfig.TSDB.BlockRanges[0].Milliseconds())
 
-		case i.TSDBState.compactionIdleTimeout > 0 && userDB.isIdle(time.Now(), i.TSDBState.compactionIdleTimeout):
-			reason = "idle"
+		case compactionReasonIdle:
 			level.Info(logutil.WithContext(ctx, i.logger)).Log("msg", "TSDB is idle, forcing compaction", "user", userID)
 			err = userDB.compactHead(ctx, i.cfg.BlocksStorageConfig.TSDB.BlockRanges[0].Milliseconds())
 
+		case compactionReasonSeriesThreshold:
+			level.Info(logutil.WithContext(ctx, i.logger)).Log("msg", "TSDB head reached the series threshold, forcing compaction", "user", userID, "series", h.NumSeries(), "before", seriesCompactionBefore)
+			userDB.lastSeriesCompaction.Store(time.Now().UnixMilli())
+			err = userDB.compactHeadBefore(ctx, i.cfg.BlocksStorageConfig.TSDB.BlockRanges[0].Milliseconds(), seriesCompactionBefore)
+
+		case compactionReasonEvictIdle:
+			// The head is truncated once its older block ranges are compacted, removing the series with no
+			// sample left in the head. The new series are rejected by the limit meanwhile, so the series
+			// removed from the head are the evicted ones.
+			level.Info(logutil.WithContext(ctx, i.logger)).Log("msg", "TSDB head reached the max series per user limit, forcing compaction to evict the idle series", "user", userID, "series", h.NumSeries(), "before", seriesCompactionBefore)
+			userDB.lastSeriesCompaction.Store(time.Now().UnixMilli())
+			numSeries := h.NumSeries()
+			err = userDB.compactHeadBefore(ctx, i.cfg.BlocksStorageConfig.TSDB.BlockRanges[0].Milliseconds(), seriesCompactionBefore)
+			if evicted := h.NumSeries(); err == nil && evicted < numSeries {
+				userDB.idleSeriesEvicted.Add(float64(numSeries - evicted))
+			}
+
+		case compactionReasonHeadRetention:
+			level.Info(logutil.WithContext(ctx, i.logger)).Log("msg", "TSDB head exceeds the head retention period, compacting its oldest samples", "user", userID, "retention", i.limits.HeadRetentionPeriod(userID), "before", truncateBefore)
+			err = userDB.truncateHeadBefore(i.cfg.BlocksStorageConfig.TSDB.BlockRanges[0].Milliseconds(), truncateBefore)
+
 		default:
-			reason = "regular"
 			err = userDB.Compact(ctx)
 		}
 
+		// The eviction is requested again by the next series rejected because of the limit.
+		userDB.idleSeriesEvictionRequested.Store(false)
+
 		if err != nil {
 			i.TSDBState.compactionsFailed.Inc()
 			level.Warn(logutil.WithContext(ctx, i.logger)).Log("msg", "TSDB blocks compaction for user has failed", "user", userID, "err", err, "compactReason", reason)
@@ -3483,10 +4154,89 @@ func (i *Ingester) compactBlocks(ctx context.Context, force bool, allowed *users
 			level.Debug(logutil.WithContext(ctx, i.logger)).Log("msg", "TSDB blocks compaction completed successfully", "user", userID, "compactReason", reason)
 		}
 
+		// The head min time allows to verify the head retention period is applied.
+		if h.NumSeries() > 0 {
+			i.metrics.headMinTimestamp.WithLabelValues(userID).Set(float64(h.MinTime()) / 1000)
+		} else {
+			i.metrics.headMinTimestamp.DeleteLabelValues(userID)
+		}
+
 		return nil
 	})
 }
 
+// headRetentionTruncationTime returns the time before which the samples of the given head are compacted
+// because of the head retention period of the user, and whether the head has to be truncated. Like the
+// TSDB head compaction, the head is only truncated once it spans 1.5 times the period, so that each
+// compaction creates blocks of at least half the period. The period is at least half the smallest block
+// range, because the samples can still be appended within it. A period greater or equal to the smallest
+// block range has no effect, because the TSDB head compaction already truncates the head before.
+func (i *Ingester) headRetentionTruncationTime(userID string, h *tsdb.Head) (int64, bool) {
+	period := time.Duration(i.limits.HeadRetentionPeriod(userID)).Milliseconds()
+	blockRange := i.cfg.BlocksStorageConfig.TSDB.BlockRanges[0].Milliseconds()
+	if period <= 0 || period >= blockRange {
+		return 0, false
+	}
+
+	period = max(period, blockRange/2)
+	if h.MaxTime()-h.MinTime() <= period/2*3 {
+		return 0, false
+	}
+	return h.MaxTime() - period, true
+}
+
+// reachedSeriesThreshold returns whether the number of in-memory series of the given head reached
+// the threshold triggering the head compaction.
+func (i *Ingester) reachedSeriesThreshold(h *tsdb.Head) bool {
+	threshold := i.cfg.BlocksStorageConfig.TSDB.HeadCompactionSeriesThreshold
+	return threshold > 0 && h.NumSeries() >= uint64(threshold)
+}
+
+// seriesCompactionBefore returns the time before which the samples of the head of the given TSDB are
+// compacted by a head compaction triggered by its number of in-memory series, and whether such a
+// compaction is allowed at the given time. Only the block ranges older than the one of the most recent
+// sample are compacted, so that the pushes of the series still appended are neither rejected as out of
+// bounds nor turned into tiny blocks, and the compactions are at least the min interval apart.
+func (i *Ingester) seriesCompactionBefore(db *userTSDB, now time.Time) (int64, bool) {
+	h := db.Head()
+	if h.NumSeries() == 0 {
+		return 0, false
+	}
+
+	blockRange := i.cfg.BlocksStorageConfig.TSDB.BlockRanges[0].Milliseconds()
+	before := (h.MaxTime() / blockRange) * blockRange
+	if h.MinTime() >= before {
+		return 0, false
+	}
+
+	if last := db.lastSeriesCompaction.Load(); last > 0 && now.Sub(time.UnixMilli(last)) < i.cfg.BlocksStorageConfig.TSDB.SeriesCompactionMinInterval {
+		return 0, false
+	}
+	return before, true
+}
+
+// triggerSeriesThresholdCompaction requests the head compaction of the tenant's TSDB to the compaction
+// loop if its head reached the series threshold, or if the eviction of its idle series has been
+// requested, unless such a compaction is not allowed yet or has already been requested.
+func (i *Ingester) triggerSeriesThresholdCompaction(userID string, db *userTSDB) {
+	if !i.reachedSeriesThreshold(db.Head()) && !db.idleSeriesEvictionRequested.Load() {
+		return
+	}
+	if _, allowed := i.seriesCompactionBefore(db, time.Now()); !allowed {
+		return
+	}
+	if !db.seriesThresholdCompactionPending.CompareAndSwap(false, true) {
+		return
+	}
+
+	select {
+	case i.TSDBState.seriesThresholdCompactTrigger <- userID:
+	default:
+		// The compaction loop is lagging behind. The next push will try again.
+		db.seriesThresholdCompactionPending.Store(false)
+	}
+}
+
 func (i *Ingester) closeAndDeleteIdleUserTSDBs(ctx context.Context) error {
 	for _, userID := range i.getTSDBUsers() {
 		if ctx.Err() != nil {
@@ -3656,7 +4406,7 @@ func (i *Ingester) getOrCreateUserMetadata(userID string) *userMetricsMetadata {
 	// Ensure it was not created between switching locks.
 	userMetadata, ok := i.usersMetadata[userID]
 	if !ok {
-		userMetadata = newMetadataMap(i.limiter, i.metrics, i.validateMetrics, userID, i.cfg.SkipMetadataLimits)
+		userMetadata = newMetadataMap(i.limiter, i.metrics, i.validateMetrics, userID, i.cfg.SkipMetadataLimits, i.cfg.MetadataConflictResolution)
 		i.usersMetadata[userID] = userMetadata
 	}
 	return userMetadata
@@ -871,6 +871,104 @@ func TestIngesterUserLimitExceeded(t *testing.T) {
 
 }
 
+func TestIngesterUserLimitExceeded_EvictIdlePolicy(t *testing.T) {
+	limits := defaultLimitsTestConfig()
+	limits.MaxLocalSeriesPerUser = 2
+	limits.MaxSeriesPolicy = validation.MaxSeriesPolicyEvictIdle
+
+	userID := "1"
+	labels1 := labels.FromStrings(labels.MetricName, "testmetric", "foo", "bar")
+	labels2 := labels.FromStrings(labels.MetricName, "testmetric", "foo", "biz")
+	labels3 := labels.FromStrings(labels.MetricName, "testmetric", "foo", "baz")
+	labels4 := labels.FromStrings(labels.MetricName, "testmetric", "foo", "qux")
+	now := time.Now().UnixMilli()
+	// The old samples are in the previous block range, but not old enough for the regular head compaction.
+	oldSample := cortexpb.Sample{TimestampMs: now - (150 * time.Minute).Milliseconds(), Value: 1}
+	sample1 := cortexpb.Sample{TimestampMs: now, Value: 2}
+	sample2 := cortexpb.Sample{TimestampMs: now + 1, Value: 3}
+
+	reg := prometheus.NewRegistry()
+	ing, err := prepareIngesterWithBlocksStorageAndLimits(t, defaultIngesterTestConfig(t), limits, nil, "", reg)
+	require.NoError(t, err)
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), ing))
+	defer services.StopAndAwaitTerminated(context.Background(), ing) //nolint:errcheck
+	test.Poll(t, time.Second, ring.ACTIVE, func() any {
+		return ing.lifecycler.GetState()
+	})
+
+	ctx := user.InjectOrgID(context.Background(), userID)
+	push := func(lbls labels.Labels, sample cortexpb.Sample) error {
+		_, err := ing.Push(ctx, cortexpb.ToWriteRequest([]labels.Labels{lbls}, []cortexpb.Sample{sample}, nil, nil, cortexpb.API))
+		return err
+	}
+	require.NoError(t, push(labels1, oldSample))
+	require.NoError(t, push(labels2, oldSample))
+	require.NoError(t, push(labels2, sample1))
+
+	db, err := ing.getTSDB(userID)
+	require.NoError(t, err)
+
+	// The series are active, so the new series is rejected and nothing is evicted.
+	err = push(labels3, sample1)
+	httpResp, ok := httpgrpc.HTTPResponseFromError(err)
+	require.True(t, ok, "returned error is not an httpgrpc response")
+	assert.Equal(t, http.StatusBadRequest, int(httpResp.Code))
+	assert.False(t, db.idleSeriesEvictionRequested.Load())
+
+	// Once the series are idle, the new series is still rejected, but the older block ranges of the head
+	// are compacted, evicting the series with no sample in the current block range.
+	db.activeSeries.Purge(time.Now().Add(time.Hour))
+	require.Error(t, push(labels3, sample1))
+	test.Poll(t, 5*time.Second, uint64(1), func() any {
+		return db.Head().NumSeries()
+	})
+	assert.Equal(t, 1, len(db.Blocks()))
+
+	// The new series is admitted, and the head never exceeds the limit.
+	require.NoError(t, push(labels3, sample2))
+	require.Error(t, push(labels4, sample2))
+	assert.Equal(t, uint64(2), db.Head().NumSeries())
+
+	// In the steady state, the head has nothing left to compact before the current block range, so the
+	// eviction is not triggered again even if the series are idle.
+	db.activeSeries.Purge(time.Now().Add(time.Hour))
+	require.Error(t, push(labels4, sample2))
+	for range 3 {
+		ing.compactBlocks(context.Background(), false, nil)
+	}
+	assert.Equal(t, uint64(2), db.Head().NumSeries())
+	assert.Equal(t, 1, len(db.Blocks()))
+	assert.Equal(t, float64(1), testutil.ToFloat64(ing.TSDBState.compactionsByReason.WithLabelValues(compactionReasonEvictIdle)))
+
+	// The samples of the evicted series are not lost.
+	res, _, err := runTestQuery(ctx, t, ing, labels.MatchEqual, model.MetricNameLabel, "testmetric")
+	require.NoError(t, err)
+	assert.Equal(t, model.Matrix{
+		{
+			Metric: cortexpb.FromLabelAdaptersToMetric(cortexpb.FromLabelsToLabelAdapters(labels1)),
+			Values: []model.SamplePair{{Timestamp: model.Time(oldSample.TimestampMs), Value: model.SampleValue(oldSample.Value)}},
+		},
+		{
+			Metric: cortexpb.FromLabelAdaptersToMetric(cortexpb.FromLabelsToLabelAdapters(labels3)),
+			Values: []model.SamplePair{{Timestamp: model.Time(sample2.TimestampMs), Value: model.SampleValue(sample2.Value)}},
+		},
+		{
+			Metric: cortexpb.FromLabelAdaptersToMetric(cortexpb.FromLabelsToLabelAdapters(labels2)),
+			Values: []model.SamplePair{
+				{Timestamp: model.Time(oldSample.TimestampMs), Value: model.SampleValue(oldSample.Value)},
+				{Timestamp: model.Time(sample1.TimestampMs), Value: model.SampleValue(sample1.Value)},
+			},
+		},
+	}, res)
+
+	// Only the series removed from the head are counted as evicted.
+	require.NoError(t, testutil.GatherAndCompare(reg, bytes.NewBufferString(`
+		# HELP cortex_ingester_idle_series_evicted_total The total number of idle series evicted from the TSDB head by the head compactions triggered by the max series per user limit, when the max series policy is evict-idle.
+		# TYPE cortex_ingester_idle_series_evicted_total counter
+		cortex_ingester_idle_series_evicted_total{user="1"} 1
+	`), "cortex_ingester_idle_series_evicted_total"))
+}
+
 func TestIngesterUserLimitExceededForNativeHistogram(t *testing.T) {
 	limits := defaultLimitsTestConfig()
 	limits.EnableNativeHistograms = true
@@ -4716,6 +4814,10 @@ func TestIngester_OpenExistingTSDBOnStartup(t *testing.T) {
 				db2, err := i.getTSDB("user2")
 				require.Nil(t, db2)
 				require.ErrorIs(t, err, errNoUserDb)
+
+				// The WAL replay has completed for the opened TSDBs.
+				require.Equal(t, float64(1), testutil.ToFloat64(i.metrics.walReplayProgress.WithLabelValues("user0")))
+				require.Equal(t, float64(1), testutil.ToFloat64(i.metrics.walReplayProgress.WithLabelValues("user1")))
 			},
 		},
 		"should load all TSDBs on concurrency < number of TSDBs": {
@@ -4947,6 +5049,35 @@ func TestIngester_dontShipBlocksWhenTenantDeletionMarkerIsPresent(t *testing.T)
 	require.Equal(t, tsdbTenantMarkedForDeletion, i.closeAndDeleteUserTSDBIfIdle(userID))
 }
 
+func TestWALReplayProgressRatio(t *testing.T) {
+	tests := map[string]struct {
+		minSegment, maxSegment, currentSegment int
+		expected                               float64
+	}{
+		"replay not started": {
+			expected: 0,
+		},
+		"replay of the first segment": {
+			minSegment: 2, maxSegment: 6, currentSegment: 2,
+			expected: 0,
+		},
+		"replay in progress": {
+			minSegment: 2, maxSegment: 6, currentSegment: 5,
+			expected: 0.75,
+		},
+		"replay of the last segment": {
+			minSegment: 2, maxSegment: 6, currentSegment: 6,
+			expected: 1,
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			assert.Equal(t, testData.expected, walReplayProgressRatio(testData.minSegment, testData.maxSegment, testData.currentSegment))
+		})
+	}
+}
+
 func TestIngester_seriesCountIsCorrectAfterClosingTSDBForDeletedTenant(t *testing.T) {
 	cfg := defaultIngesterTestConfig(t)
 	cfg.LifecyclerConfig.JoinAfter = 0
@@ -5551,6 +5682,114 @@ func TestIngester_ForFlush(t *testing.T) {
 	require.NoError(t, services.StopAndAwaitTerminated(context.Background(), i))
 }
 
+func TestIngester_WaitBeforeLeaving(t *testing.T) {
+	originalInterval := waitBeforeLeavingCheckInterval
+	waitBeforeLeavingCheckInterval = 10 * time.Millisecond
+	t.Cleanup(func() { waitBeforeLeavingCheckInterval = originalInterval })
+
+	cfg := defaultIngesterTestConfig(t)
+	cfg.LifecyclerConfig.JoinAfter = 0
+	cfg.BlocksStorageConfig.TSDB.ShipInterval = 10 * time.Minute // Long enough to not be reached during the test.
+	cfg.BlocksStorageConfig.BucketStore.BucketIndex.Enabled = true
+
+	i, err := prepareIngesterWithBlocksStorage(t, cfg, prometheus.NewRegistry())
+	require.NoError(t, err)
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), i))
+	defer services.StopAndAwaitTerminated(context.Background(), i) //nolint:errcheck
+
+	test.Poll(t, 1*time.Second, ring.ACTIVE, func() any {
+		return i.lifecycler.GetState()
+	})
+
+	// Nothing shipped yet, so there's nothing to wait for.
+	require.NoError(t, i.WaitBeforeLeaving(context.Background()))
+
+	// Push some data, then compact and ship it.
+	pushSingleSampleWithMetadata(t, i)
+	i.Flush()
+
+	blocks := getTSDB(t, i, userID).Blocks()
+	require.Len(t, blocks, 1)
+
+	// The shipped block is not in the bucket index yet.
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	err = i.WaitBeforeLeaving(ctx)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Contains(t, err.Error(), "1 shipped blocks are not in the bucket index yet")
+
+	// Once the bucket index is updated the wait is over.
+	idx := &bucketindex.Index{
+		Version:   bucketindex.IndexVersion1,
+		Blocks:    bucketindex.Blocks{{ID: blocks[0].Meta().ULID, MinTime: blocks[0].Meta().MinTime, MaxTime: blocks[0].Meta().MaxTime}},
+		UpdatedAt: time.Now().Unix(),
+	}
+	require.NoError(t, bucketindex.WriteIndex(context.Background(), i.TSDBState.bucket, userID, nil, idx))
+	require.NoError(t, i.WaitBeforeLeaving(context.Background()))
+}
+
+func TestIngester_WaitBeforeLeaving_ShouldServeReadsWhileWaiting(t *testing.T) {
+	originalInterval := waitBeforeLeavingCheckInterval
+	waitBeforeLeavingCheckInterval = 10 * time.Millisecond
+	t.Cleanup(func() { waitBeforeLeavingCheckInterval = originalInterval })
+
+	cfg := defaultIngesterTestConfig(t)
+	cfg.LifecyclerConfig.JoinAfter = 0
+	cfg.LifecyclerConfig.WaitBeforeLeavingTimeout = time.Minute
+	cfg.BlocksStorageConfig.TSDB.ShipInterval = 10 * time.Minute // Long enough to not be reached during the test.
+	cfg.BlocksStorageConfig.TSDB.FlushBlocksOnShutdown = true
+	cfg.BlocksStorageConfig.BucketStore.BucketIndex.Enabled = true
+
+	i, err := prepareIngesterWithBlocksStorage(t, cfg, prometheus.NewRegistry())
+	require.NoError(t, err)
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), i))
+
+	test.Poll(t, 1*time.Second, ring.ACTIVE, func() any {
+		return i.lifecycler.GetState()
+	})
+
+	pushSingleSampleWithMetadata(t, i)
+
+	// On shutdown, the head is compacted and shipped, then the ingester waits for the block
+	// to be in the bucket index.
+	i.StopAsync()
+	test.Poll(t, 5*time.Second, true, func() any {
+		return i.waitingBeforeLeaving.Load()
+	})
+	require.Equal(t, services.Stopping, i.State())
+
+	ctx := user.InjectOrgID(context.Background(), userID)
+	res, err := i.LabelNames(ctx, &client.LabelNamesRequest{StartTimestampMs: math.MinInt64, EndTimestampMs: math.MaxInt64})
+	require.NoError(t, err)
+	assert.Equal(t, []string{labels.MetricName}, res.LabelNames)
+
+	s := &mockQueryStreamServer{ctx: ctx}
+	require.NoError(t, i.QueryStream(&client.QueryRequest{
+		StartTimestampMs: math.MinInt64,
+		EndTimestampMs:   math.MaxInt64,
+		Matchers:         []*client.LabelMatcher{{Type: client.EQUAL, Name: labels.MetricName, Value: "test"}},
+	}, s))
+	assert.Len(t, s.series, 1)
+
+	// The writes are rejected while waiting.
+	_, err = i.Push(ctx, cortexpb.ToWriteRequest([]labels.Labels{labels.FromStrings(labels.MetricName, "test")}, []cortexpb.Sample{{Value: 1, TimestampMs: 9}}, nil, nil, cortexpb.API))
+	require.Error(t, err)
+
+	// Once the bucket index is updated the wait is over, and the reads are rejected.
+	blocks := getTSDB(t, i, userID).Blocks()
+	require.Len(t, blocks, 1)
+	idx := &bucketindex.Index{
+		Version:   bucketindex.IndexVersion1,
+		Blocks:    bucketindex.Blocks{{ID: blocks[0].Meta().ULID, MinTime: blocks[0].Meta().MinTime, MaxTime: blocks[0].Meta().MaxTime}},
+		UpdatedAt: time.Now().Unix(),
+	}
+	require.NoError(t, bucketindex.WriteIndex(context.Background(), i.TSDBState.bucket, userID, nil, idx))
+	require.NoError(t, i.AwaitTerminated(context.Background()))
+
+	_, err = i.LabelNames(ctx, &client.LabelNamesRequest{StartTimestampMs: math.MinInt64, EndTimestampMs: math.MaxInt64})
+	require.Error(t, err)
+}
+
 func mockUserShipper(t *testing.T, i *Ingester) *shipperMock {
 	m := &shipperMock{}
 	userDB, err := i.getOrCreateTSDB(userID, false)
@@ -5607,6 +5846,73 @@ func Test_Ingester_UserStats(t *testing.T) {
 	assert.Equal(t, uint64(3), res.NumSeries)
 }
 
+func Test_Ingester_Cardinality(t *testing.T) {
+	series := []labels.Labels{
+		labels.FromStrings("__name__", "test_1", "route", "a", "status", "200"),
+		labels.FromStrings("__name__", "test_1", "route", "a", "status", "500"),
+		labels.FromStrings("__name__", "test_1", "route", "b", "status", "404"),
+		labels.FromStrings("__name__", "test_2", "route", "b", "status", "503"),
+	}
+
+	// Create ingester
+	i, err := prepareIngesterWithBlocksStorage(t, defaultIngesterTestConfig(t), prometheus.NewRegistry())
+	require.NoError(t, err)
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), i))
+	defer services.StopAndAwaitTerminated(context.Background(), i) //nolint:errcheck
+
+	// Wait until it's ACTIVE
+	test.Poll(t, 1*time.Second, ring.ACTIVE, func() any {
+		return i.lifecycler.GetState()
+	})
+
+	ctx := user.InjectOrgID(context.Background(), "test")
+
+	// Should return empty stats if the TSDB doesn't exist.
+	res, err := i.Cardinality(ctx, &client.CardinalityRequest{Limit: 1})
+	require.NoError(t, err)
+	assert.Equal(t, &client.CardinalityResponse{}, res)
+
+	// Push series
+	for _, lbls := range series {
+		req, _ := mockWriteRequest(t, lbls, 1, 100000)
+		_, err := i.Push(ctx, req)
+		require.NoError(t, err)
+	}
+
+	t.Run("all series", func(t *testing.T) {
+		req, err := client.ToCardinalityRequest(1, nil)
+		require.NoError(t, err)
+
+		res, err := i.Cardinality(ctx, req)
+		require.NoError(t, err)
+		assert.Equal(t, &client.CardinalityResponse{
+			NumSeries:                   4,
+			SeriesCountByMetricName:     []client.CardinalityStat{{Name: "test_1", Value: 3}},
+			LabelValueCountByLabelName:  []client.CardinalityStat{{Name: "status", Value: 4}},
+			SeriesCountByLabelValuePair: []client.CardinalityStat{{Name: "__name__=test_1", Value: 3}},
+			MinTimestampMs:              100000,
+			MaxTimestampMs:              100000,
+		}, res)
+	})
+
+	t.Run("series matching the matchers", func(t *testing.T) {
+		req, err := client.ToCardinalityRequest(2, []*labels.Matcher{labels.MustNewMatcher(labels.MatchEqual, "__name__", "test_1")})
+		require.NoError(t, err)
+
+		res, err := i.Cardinality(ctx, req)
+		require.NoError(t, err)
+		assert.Equal(t, &client.CardinalityResponse{
+			NumSeries:                   3,
+			SeriesCountByMetricName:     []client.CardinalityStat{{Name: "test_1", Value: 3}},
+			LabelValueCountByLabelName:  []client.CardinalityStat{{Name: "status", Value: 3}, {Name: "route", Value: 2}},
+			SeriesCountByLabelValuePair: []client.CardinalityStat{{Name: "__name__=test_1", Value: 3}, {Name: "route=a", Value: 2}},
+			NumChunks:                   3,
+			MinTimestampMs:              100000,
+			MaxTimestampMs:              100000,
+		}, res)
+	})
+}
+
 func Test_Ingester_AllUserStats(t *testing.T) {
 	series := []struct {
 		user      string
@@ -5950,6 +6256,216 @@ func TestIngesterCompactAndCloseIdleTSDB(t *testing.T) {
     `), metricsToCheck...))
 }
 
+func TestIngesterCompactHeadOnSeriesThreshold(t *testing.T) {
+	cfg := defaultIngesterTestConfig(t)
+	cfg.LifecyclerConfig.JoinAfter = 0
+	cfg.BlocksStorageConfig.TSDB.HeadCompactionInterval = 1 * time.Hour // Long enough to not be reached during the test.
+	cfg.BlocksStorageConfig.TSDB.HeadCompactionIdleTimeout = 0
+	cfg.BlocksStorageConfig.TSDB.HeadCompactionSeriesThreshold = 2 // Testing this.
+
+	r := prometheus.NewRegistry()
+
+	// Create ingester
+	i, err := prepareIngesterWithBlocksStorage(t, cfg, r)
+	require.NoError(t, err)
+
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), i))
+	t.Cleanup(func() {
+		_ = services.StopAndAwaitTerminated(context.Background(), i)
+	})
+
+	// Wait until it's ACTIVE
+	test.Poll(t, 1*time.Second, ring.ACTIVE, func() any {
+		return i.lifecycler.GetState()
+	})
+
+	ctx := user.InjectOrgID(context.Background(), userID)
+	now := time.Now()
+	push := func(metricName string, ts time.Time) {
+		req, _ := mockWriteRequest(t, labels.FromStrings("__name__", metricName), 0, util.TimeToMillis(ts))
+		_, err := i.Push(ctx, req)
+		require.NoError(t, err)
+	}
+	numSeries := func() any {
+		db, err := i.getTSDB(userID)
+		if err != nil || db == nil {
+			return err
+		}
+		return db.Head().NumSeries()
+	}
+
+	// The threshold is not reached yet. The sample is in the previous block range, but not old enough
+	// for the regular head compaction.
+	push("test_1", now.Add(-150*time.Minute))
+	i.compactBlocks(context.Background(), false, nil)
+	verifyCompactedHead(t, i, false)
+
+	// Reaching the threshold triggers the compaction of the older block ranges, without waiting for the
+	// compaction interval. The series with no sample left in the head are removed.
+	push("test_2", now)
+	test.Poll(t, 5*time.Second, uint64(1), numSeries)
+
+	// In the steady state, the head stays above the threshold but has nothing left to compact before the
+	// current block range, so the compaction is not triggered again, and the pushes keep working.
+	push("test_3", now)
+	for range 3 {
+		i.compactBlocks(context.Background(), false, nil)
+	}
+	push("test_4", now)
+	assert.Equal(t, uint64(3), numSeries())
+
+	require.NoError(t, testutil.GatherAndCompare(r, strings.NewReader(`
+		# HELP cortex_ingester_tsdb_compactions_triggered_by_reason_total Total number of triggered compactions, by the reason triggering them.
+		# TYPE cortex_ingester_tsdb_compactions_triggered_by_reason_total counter
+		cortex_ingester_tsdb_compactions_triggered_by_reason_total{reason="evict_idle"} 0
+		cortex_ingester_tsdb_compactions_triggered_by_reason_total{reason="forced"} 0
+		cortex_ingester_tsdb_compactions_triggered_by_reason_total{reason="head_retention"} 0
+		cortex_ingester_tsdb_compactions_triggered_by_reason_total{reason="idle"} 0
+		cortex_ingester_tsdb_compactions_triggered_by_reason_total{reason="regular"} 4
+		cortex_ingester_tsdb_compactions_triggered_by_reason_total{reason="series_threshold"} 1
+	`), "cortex_ingester_tsdb_compactions_triggered_by_reason_total"))
+}
+
+func TestIngesterCompactHeadOnHeadRetention(t *testing.T) {
+	cfg := defaultIngesterTestConfig(t)
+	cfg.LifecyclerConfig.JoinAfter = 0
+	cfg.BlocksStorageConfig.TSDB.HeadCompactionInterval = 1 * time.Hour // Long enough to not be reached during the test.
+	cfg.BlocksStorageConfig.TSDB.HeadCompactionIdleTimeout = 0
+
+	limits := defaultLimitsTestConfig()
+	limits.HeadRetentionPeriod = model.Duration(time.Hour) // Testing this.
+
+	r := prometheus.NewRegistry()
+
+	// Create ingester
+	i, err := prepareIngesterWithBlocksStorageAndLimits(t, cfg, limits, nil, "", r)
+	require.NoError(t, err)
+
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), i))
+	t.Cleanup(func() {
+		_ = services.StopAndAwaitTerminated(context.Background(), i)
+	})
+
+	// Wait until it's ACTIVE
+	test.Poll(t, 1*time.Second, ring.ACTIVE, func() any {
+		return i.lifecycler.GetState()
+	})
+
+	ctx := user.InjectOrgID(context.Background(), userID)
+	push := func(ts time.Time) {
+		req, _ := mockWriteRequest(t, labels.FromStrings("__name__", "test"), 0, util.TimeToMillis(ts))
+		_, err := i.Push(ctx, req)
+		require.NoError(t, err)
+	}
+
+	// The head spans less than 1.5 times the retention period, so it's not truncated.
+	now := time.Now()
+	push(now.Add(-2 * time.Hour))
+	push(now.Add(-90 * time.Minute))
+	i.compactBlocks(context.Background(), false, nil)
+
+	db, err := i.getTSDB(userID)
+	require.NoError(t, err)
+	require.Empty(t, db.Blocks())
+
+	// Once the head spans more than 1.5 times the retention period, its samples older than the period are compacted.
+	push(now)
+	i.compactBlocks(context.Background(), false, nil)
+
+	require.NotEmpty(t, db.Blocks())
+	for _, b := range db.Blocks() {
+		assert.Less(t, b.MaxTime(), util.TimeToMillis(now.Add(-time.Hour))+1)
+	}
+	assert.GreaterOrEqual(t, db.Head().MinTime(), util.TimeToMillis(now.Add(-time.Hour)))
+	assert.Equal(t, uint64(1), db.Head().NumSeries())
+
+	require.NoError(t, testutil.GatherAndCompare(r, strings.NewReader(fmt.Sprintf(`
+		# HELP cortex_ingester_tsdb_compactions_triggered_by_reason_total Total number of triggered compactions, by the reason triggering them.
+		# TYPE cortex_ingester_tsdb_compactions_triggered_by_reason_total counter
+		cortex_ingester_tsdb_compactions_triggered_by_reason_total{reason="evict_idle"} 0
+		cortex_ingester_tsdb_compactions_triggered_by_reason_total{reason="forced"} 0
+		cortex_ingester_tsdb_compactions_triggered_by_reason_total{reason="head_retention"} 1
+		cortex_ingester_tsdb_compactions_triggered_by_reason_total{reason="idle"} 0
+		cortex_ingester_tsdb_compactions_triggered_by_reason_total{reason="regular"} 1
+		cortex_ingester_tsdb_compactions_triggered_by_reason_total{reason="series_threshold"} 0
+
+		# HELP cortex_ingester_tsdb_head_min_timestamp_seconds Unix timestamp of the oldest sample in the TSDB head of the user, updated at each head compaction interval.
+		# TYPE cortex_ingester_tsdb_head_min_timestamp_seconds gauge
+		cortex_ingester_tsdb_head_min_timestamp_seconds{user="1"} %g
+	`, float64(db.Head().MinTime())/1000)), "cortex_ingester_tsdb_compactions_triggered_by_reason_total", "cortex_ingester_tsdb_head_min_timestamp_seconds"))
+
+	// Pushing another sample still works.
+	push(now.Add(time.Second))
+}
+
+func TestIngesterCompactHeadOnHeadRetention_ShouldApplyPeriodWithinBlockRange(t *testing.T) {
+	now := time.Now()
+
+	tests := map[string]struct {
+		period         time.Duration
+		expectedBefore time.Time
+		expectedBlocks bool
+	}{
+		"period lower than half the block range is applied as half the block range": {
+			period:         10 * time.Minute,
+			expectedBefore: now.Add(-time.Hour),
+			expectedBlocks: true,
+		},
+		"period equal to the block range has no effect": {
+			period: 2 * time.Hour,
+		},
+		"period greater than the block range has no effect": {
+			period: 6 * time.Hour,
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			cfg := defaultIngesterTestConfig(t)
+			cfg.LifecyclerConfig.JoinAfter = 0
+			cfg.BlocksStorageConfig.TSDB.BlockRanges = []time.Duration{2 * time.Hour}
+			cfg.BlocksStorageConfig.TSDB.HeadCompactionInterval = 1 * time.Hour // Long enough to not be reached during the test.
+			cfg.BlocksStorageConfig.TSDB.HeadCompactionIdleTimeout = 0
+
+			limits := defaultLimitsTestConfig()
+			limits.HeadRetentionPeriod = model.Duration(testData.period)
+
+			i, err := prepareIngesterWithBlocksStorageAndLimits(t, cfg, limits, nil, "", prometheus.NewRegistry())
+			require.NoError(t, err)
+			require.NoError(t, services.StartAndAwaitRunning(context.Background(), i))
+			t.Cleanup(func() {
+				_ = services.StopAndAwaitTerminated(context.Background(), i)
+			})
+			test.Poll(t, 1*time.Second, ring.ACTIVE, func() any {
+				return i.lifecycler.GetState()
+			})
+
+			ctx := user.InjectOrgID(context.Background(), userID)
+			for _, ts := range []time.Time{now.Add(-2 * time.Hour), now.Add(-30 * time.Minute), now} {
+				req, _ := mockWriteRequest(t, labels.FromStrings("__name__", "test"), 0, util.TimeToMillis(ts))
+				_, err := i.Push(ctx, req)
+				require.NoError(t, err)
+			}
+			i.compactBlocks(context.Background(), false, nil)
+
+			db, err := i.getTSDB(userID)
+			require.NoError(t, err)
+			if !testData.expectedBlocks {
+				require.Empty(t, db.Blocks())
+				assert.Equal(t, util.TimeToMillis(now.Add(-2*time.Hour)), db.Head().MinTime())
+				return
+			}
+
+			require.NotEmpty(t, db.Blocks())
+			for _, b := range db.Blocks() {
+				assert.LessOrEqual(t, b.MaxTime(), util.TimeToMillis(testData.expectedBefore))
+			}
+			assert.GreaterOrEqual(t, db.Head().MinTime(), util.TimeToMillis(testData.expectedBefore))
+			assert.Equal(t, uint64(1), db.Head().NumSeries())
+		})
+	}
+}
+
 func verifyCompactedHead(t *testing.T, i *Ingester, expected bool) {
 	db, err := i.getTSDB(userID)
 	require.NoError(t, err)
@@ -7158,6 +7674,100 @@ func Test_Ingester_QueryExemplar_MaxInflightQueryRequest(t *testing.T) {
 	require.Equal(t, err, errTooManyInflightQueryRequests)
 }
 
+func TestIngester_QueryExemplars_ShouldApplyMatchersAndLimit(t *testing.T) {
+	const userID = "test"
+
+	cfg := defaultIngesterTestConfig(t)
+	limits := defaultLimitsTestConfig()
+	limits.MaxExemplars = 100
+	tenantLimits := newMockTenantLimits(map[string]*validation.Limits{userID: &limits})
+
+	i, err := prepareIngesterWithBlocksStorageAndLimits(t, cfg, limits, tenantLimits, t.TempDir(), prometheus.NewRegistry())
+	require.NoError(t, err)
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), i))
+	defer services.StopAndAwaitTerminated(context.Background(), i) //nolint:errcheck
+
+	// Wait until it's ACTIVE
+	test.Poll(t, 1*time.Second, ring.ACTIVE, func() any {
+		return i.lifecycler.GetState()
+	})
+
+	// Push multiple series sharing the same metric name but with different labels, each with two exemplars.
+	ctx := user.InjectOrgID(context.Background(), userID)
+	for _, route := range []string{"a", "b", "c"} {
+		req := &cortexpb.WriteRequest{
+			Timeseries: []cortexpb.PreallocTimeseries{{
+				TimeSeries: &cortexpb.TimeSeries{
+					Labels:  cortexpb.FromLabelsToLabelAdapters(labels.FromStrings(labels.MetricName, "http_requests_total", "route", route)),
+					Samples: []cortexpb.Sample{{Value: 1, TimestampMs: 1000}},
+					Exemplars: []cortexpb.Exemplar{
+						{Labels: []cortexpb.LabelAdapter{{Name: "traceID", Value: route + "-1"}}, TimestampMs: 1000, Value: 1},
+						{Labels: []cortexpb.LabelAdapter{{Name: "traceID", Value: route + "-2"}}, TimestampMs: 2000, Value: 2},
+					},
+				},
+			}},
+		}
+		_, err := i.Push(ctx, req)
+		require.NoError(t, err)
+	}
+
+	queryExemplars := func(t *testing.T, matchers ...[]*client.LabelMatcher) *client.ExemplarQueryResponse {
+		req := &client.ExemplarQueryRequest{StartTimestampMs: math.MinInt64, EndTimestampMs: math.MaxInt64}
+		for _, m := range matchers {
+			req.Matchers = append(req.Matchers, &client.LabelMatchers{Matchers: m})
+		}
+		res, err := i.QueryExemplars(ctx, req)
+		require.NoError(t, err)
+		return res
+	}
+
+	traceIDs := func(res *client.ExemplarQueryResponse) map[string][]string {
+		out := map[string][]string{}
+		for _, ts := range res.Timeseries {
+			route := cortexpb.FromLabelAdaptersToLabels(ts.Labels).Get("route")
+			for _, e := range ts.Exemplars {
+				out[route] = append(out[route], cortexpb.FromLabelAdaptersToLabels(e.Labels).Get("traceID"))
+			}
+		}
+		return out
+	}
+
+	nameMatcher := &client.LabelMatcher{Type: client.EQUAL, Name: labels.MetricName, Value: "http_requests_total"}
+
+	t.Run("should only return exemplars of the series matching the matchers", func(t *testing.T) {
+		res := queryExemplars(t, []*client.LabelMatcher{nameMatcher, {Type: client.EQUAL, Name: "route", Value: "b"}})
+		assert.Equal(t, map[string][]string{"b": {"b-1", "b-2"}}, traceIDs(res))
+		assert.False(t, res.Truncated)
+
+		res = queryExemplars(t, []*client.LabelMatcher{nameMatcher, {Type: client.REGEX_MATCH, Name: "route", Value: "a|c"}})
+		assert.Equal(t, map[string][]string{"a": {"a-1", "a-2"}, "c": {"c-1", "c-2"}}, traceIDs(res))
+		assert.False(t, res.Truncated)
+	})
+
+	t.Run("should return the union of the series matching any of the matcher sets", func(t *testing.T) {
+		res := queryExemplars(t,
+			[]*client.LabelMatcher{nameMatcher, {Type: client.EQUAL, Name: "route", Value: "a"}},
+			[]*client.LabelMatcher{nameMatcher, {Type: client.EQUAL, Name: "route", Value: "c"}},
+		)
+		assert.Equal(t, map[string][]string{"a": {"a-1", "a-2"}, "c": {"c-1", "c-2"}}, traceIDs(res))
+		assert.False(t, res.Truncated)
+	})
+
+	t.Run("should truncate exemplars exceeding the per-tenant limit", func(t *testing.T) {
+		limits.MaxExemplarsPerQuery = 3
+		defer func() { limits.MaxExemplarsPerQuery = 0 }()
+
+		res := queryExemplars(t, []*client.LabelMatcher{nameMatcher})
+		assert.Equal(t, map[string][]string{"a": {"a-1", "a-2"}, "b": {"b-2"}}, traceIDs(res))
+		assert.True(t, res.Truncated)
+
+		// The limit is not hit when the matchers select fewer exemplars.
+		res = queryExemplars(t, []*client.LabelMatcher{nameMatcher, {Type: client.EQUAL, Name: "route", Value: "c"}})
+		assert.Equal(t, map[string][]string{"c": {"c-1", "c-2"}}, traceIDs(res))
+		assert.False(t, res.Truncated)
+	})
+}
+
 func generateSamplesForLabel(l labels.Labels, count int, sampleIntervalInMs int) *cortexpb.WriteRequest {
 	var lbls = make([]labels.Labels, 0, count)
 	var samples = make([]cortexpb.Sample, 0, count)
@@ -8172,3 +8782,314 @@ func TestIngester_DiscardOutOfOrderFlagIntegration(t *testing.T) {
 	require.NoError(t, iter.Err())
 	require.Equal(t, 1, sampleCount, "Should have exactly one sample stored")
 }
+
+func TestIngester_OutOfOrderTimeWindowAppliedAtPushTime(t *testing.T) {
+	const userID = "test-user"
+
+	registry := prometheus.NewRegistry()
+	cfg := defaultIngesterTestConfig(t)
+	cfg.LifecyclerConfig.JoinAfter = 0
+	// Ensure the periodic update of the TSDB configs doesn't run during the test.
+	cfg.UserTSDBConfigsUpdatePeriod = time.Hour
+
+	limits := defaultLimitsTestConfig()
+	tenantLimits := newMockTenantLimits(map[string]*validation.Limits{userID: &limits})
+
+	i, err := prepareIngesterWithBlocksStorageAndLimits(t, cfg, limits, tenantLimits, "", registry)
+	require.NoError(t, err)
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), i))
+	defer services.StopAndAwaitTerminated(context.Background(), i) //nolint:errcheck
+
+	// Wait until it's ACTIVE
+	test.Poll(t, time.Second, ring.ACTIVE, func() any {
+		return i.lifecycler.GetState()
+	})
+
+	ctx := user.InjectOrgID(context.Background(), userID)
+	metricLabels := labels.FromStrings(labels.MetricName, "test_metric")
+	now := time.Now()
+
+	push := func(ts time.Time) error {
+		_, err := i.Push(ctx, cortexpb.ToWriteRequest(
+			[]labels.Labels{metricLabels},
+			[]cortexpb.Sample{{Value: 1, TimestampMs: ts.UnixMilli()}},
+			nil, nil, cortexpb.API))
+		return err
+	}
+
+	require.NoError(t, push(now))
+
+	// Out-of-order samples are rejected while the out-of-order time window is disabled.
+	require.Error(t, push(now.Add(-time.Minute)))
+
+	// Enable the out-of-order time window for the tenant.
+	oooLimits := limits
+	oooLimits.OutOfOrderTimeWindow = model.Duration(5 * time.Minute)
+	tenantLimits.setLimits(userID, &oooLimits)
+
+	require.NoError(t, push(now.Add(-time.Minute)))
+	require.Error(t, push(now.Add(-10*time.Minute)))
+
+	require.NoError(t, testutil.GatherAndCompare(registry, bytes.NewBufferString(`
+		# HELP cortex_discarded_samples_total The total number of samples that were discarded.
+		# TYPE cortex_discarded_samples_total counter
+		cortex_discarded_samples_total{reason="sample-out-of-order",user="test-user"} 1
+		cortex_discarded_samples_total{reason="sample-too-old",user="test-user"} 1
+		# HELP cortex_ingester_tsdb_head_out_of_order_samples_appended_total Total number of appended out of order samples.
+		# TYPE cortex_ingester_tsdb_head_out_of_order_samples_appended_total counter
+		cortex_ingester_tsdb_head_out_of_order_samples_appended_total{type="float",user="test-user"} 1
+		cortex_ingester_tsdb_head_out_of_order_samples_appended_total{type="histogram",user="test-user"} 0
+	`), "cortex_discarded_samples_total", "cortex_ingester_tsdb_head_out_of_order_samples_appended_total"))
+}
+
+func TestIngester_Push_ShouldAcceptIdenticalDuplicateSamplesIfEnabled(t *testing.T) {
+	const userID = "test-user"
+
+	registry := prometheus.NewRegistry()
+	cfg := defaultIngesterTestConfig(t)
+	cfg.LifecyclerConfig.JoinAfter = 0
+
+	limits := defaultLimitsTestConfig()
+	tenantLimits := newMockTenantLimits(map[string]*validation.Limits{userID: &limits})
+
+	i, err := prepareIngesterWithBlocksStorageAndLimits(t, cfg, limits, tenantLimits, "", registry)
+	require.NoError(t, err)
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), i))
+	defer services.StopAndAwaitTerminated(context.Background(), i) //nolint:errcheck
+
+	// Wait until it's ACTIVE
+	test.Poll(t, time.Second, ring.ACTIVE, func() any {
+		return i.lifecycler.GetState()
+	})
+
+	ctx := user.InjectOrgID(context.Background(), userID)
+	metricLabels := labels.FromStrings(labels.MetricName, "test_metric")
+	now := time.Now()
+
+	push := func(samples ...cortexpb.Sample) error {
+		lbls := make([]labels.Labels, 0, len(samples))
+		for range samples {
+			lbls = append(lbls, metricLabels)
+		}
+		_, err := i.Push(ctx, cortexpb.ToWriteRequest(lbls, samples, nil, nil, cortexpb.API))
+		return err
+	}
+
+	first := cortexpb.Sample{Value: 1, TimestampMs: now.Add(-2 * time.Minute).UnixMilli()}
+	second := cortexpb.Sample{Value: 2, TimestampMs: now.Add(-time.Minute).UnixMilli()}
+	latest := cortexpb.Sample{Value: 3, TimestampMs: now.UnixMilli()}
+	require.NoError(t, push(first, second, latest))
+
+	// Identical duplicates of older samples are rejected while the option is disabled.
+	require.Error(t, push(first))
+
+	enabledLimits := limits
+	enabledLimits.AcceptIdenticalDuplicateSamples = true
+	tenantLimits.setLimits(userID, &enabledLimits)
+
+	// Retrying the whole write request succeeds.
+	require.NoError(t, push(first, second, latest))
+
+	// A different value for an ingested timestamp is still rejected.
+	require.Error(t, push(cortexpb.Sample{Value: 10, TimestampMs: first.TimestampMs}))
+
+	// A sample for a timestamp never ingested is still rejected.
+	require.Error(t, push(cortexpb.Sample{Value: 1, TimestampMs: now.Add(-90 * time.Second).UnixMilli()}))
+
+	require.NoError(t, testutil.GatherAndCompare(registry, bytes.NewBufferString(`
+		# HELP cortex_discarded_samples_total The total number of samples that were discarded.
+		# TYPE cortex_discarded_samples_total counter
+		cortex_discarded_samples_total{reason="sample-out-of-order",user="test-user"} 3
+		# HELP cortex_ingester_identical_duplicate_samples_total The total number of out-of-order or too old samples accepted because they are exact duplicates of an ingested sample, when accepting identical duplicate samples is enabled.
+		# TYPE cortex_ingester_identical_duplicate_samples_total counter
+		cortex_ingester_identical_duplicate_samples_total{user="test-user"} 2
+	`), "cortex_discarded_samples_total", "cortex_ingester_identical_duplicate_samples_total"))
+}
+
+func TestIngester_Push_ShouldAcceptIdenticalDuplicateSamplesTooOldForTheOutOfOrderTimeWindow(t *testing.T) {
+	const userID = "test-user"
+
+	registry := prometheus.NewRegistry()
+	cfg := defaultIngesterTestConfig(t)
+	cfg.LifecyclerConfig.JoinAfter = 0
+
+	limits := defaultLimitsTestConfig()
+	limits.AcceptIdenticalDuplicateSamples = true
+	limits.OutOfOrderTimeWindow = model.Duration(time.Minute)
+
+	i, err := prepareIngesterWithBlocksStorageAndLimits(t, cfg, limits, nil, "", registry)
+	require.NoError(t, err)
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), i))
+	defer services.StopAndAwaitTerminated(context.Background(), i) //nolint:errcheck
+
+	// Wait until it's ACTIVE
+	test.Poll(t, time.Second, ring.ACTIVE, func() any {
+		return i.lifecycler.GetState()
+	})
+
+	ctx := user.InjectOrgID(context.Background(), userID)
+	metricLabels := labels.FromStrings(labels.MetricName, "test_metric")
+	now := time.Now()
+
+	push := func(samples ...cortexpb.Sample) error {
+		lbls := make([]labels.Labels, 0, len(samples))
+		for range samples {
+			lbls = append(lbls, metricLabels)
+		}
+		_, err := i.Push(ctx, cortexpb.ToWriteRequest(lbls, samples, nil, nil, cortexpb.API))
+		return err
+	}
+
+	first := cortexpb.Sample{Value: 1, TimestampMs: now.Add(-5 * time.Minute).UnixMilli()}
+	latest := cortexpb.Sample{Value: 2, TimestampMs: now.UnixMilli()}
+	require.NoError(t, push(first, latest))
+
+	// The retried sample is older than the out-of-order time window, so it's rejected as too old
+	// and looked up through a querier.
+	require.NoError(t, push(first))
+	require.Error(t, push(cortexpb.Sample{Value: 10, TimestampMs: first.TimestampMs}))
+
+	require.NoError(t, testutil.GatherAndCompare(registry, bytes.NewBufferString(`
+		# HELP cortex_discarded_samples_total The total number of samples that were discarded.
+		# TYPE cortex_discarded_samples_total counter
+		cortex_discarded_samples_total{reason="sample-too-old",user="test-user"} 1
+		# HELP cortex_ingester_identical_duplicate_samples_total The total number of out-of-order or too old samples accepted because they are exact duplicates of an ingested sample, when accepting identical duplicate samples is enabled.
+		# TYPE cortex_ingester_identical_duplicate_samples_total counter
+		cortex_ingester_identical_duplicate_samples_total{user="test-user"} 1
+	`), "cortex_discarded_samples_total", "cortex_ingester_identical_duplicate_samples_total", "cortex_ingester_identical_duplicate_samples_check_failures_total"))
+}
+
+func TestIngester_Push_ShouldAppendOldSamplesThroughTheColdPath(t *testing.T) {
+	const userID = "test-user"
+
+	registry := prometheus.NewRegistry()
+	cfg := defaultIngesterTestConfig(t)
+	cfg.LifecyclerConfig.JoinAfter = 0
+	cfg.ColdAppendSampleAge = 30 * time.Minute
+	cfg.ColdAppendMaxConcurrency = 1
+
+	i, err := prepareIngesterWithBlocksStorage(t, cfg, registry)
+	require.NoError(t, err)
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), i))
+	defer services.StopAndAwaitTerminated(context.Background(), i) //nolint:errcheck
+
+	// Wait until it's ACTIVE
+	test.Poll(t, time.Second, ring.ACTIVE, func() any {
+		return i.lifecycler.GetState()
+	})
+
+	ctx := user.InjectOrgID(context.Background(), userID)
+	now := time.Now()
+	backfilled := labels.FromStrings(labels.MetricName, "backfilled")
+	fresh := labels.FromStrings(labels.MetricName, "fresh")
+
+	// The second entry of the backfilled series has a fresh sample, but it must be appended through
+	// the cold path too, otherwise it would be committed first and the older samples rejected.
+	req := cortexpb.ToWriteRequest(
+		[]labels.Labels{backfilled, backfilled, fresh, backfilled},
+		[]cortexpb.Sample{
+			{Value: 1, TimestampMs: now.Add(-50 * time.Minute).UnixMilli()},
+			{Value: 2, TimestampMs: now.Add(-40 * time.Minute).UnixMilli()},
+			{Value: 3, TimestampMs: now.UnixMilli()},
+			{Value: 4, TimestampMs: now.UnixMilli()},
+		}, nil, nil, cortexpb.API)
+	_, err = i.Push(ctx, req)
+	require.NoError(t, err)
+
+	db, err := i.getTSDB(userID)
+	require.NoError(t, err)
+	q, err := db.Querier(math.MinInt64, math.MaxInt64)
+	require.NoError(t, err)
+	defer q.Close()
+
+	ss := q.Select(ctx, true, nil, labels.MustNewMatcher(labels.MatchRegexp, labels.MetricName, ".+"))
+	samples := map[string][]float64{}
+	for ss.Next() {
+		it := ss.At().Iterator(nil)
+		for it.Next() == chunkenc.ValFloat {
+			_, v := it.At()
+			samples[ss.At().Labels().Get(labels.MetricName)] = append(samples[ss.At().Labels().Get(labels.MetricName)], v)
+		}
+	}
+	require.NoError(t, ss.Err())
+	assert.Equal(t, map[string][]float64{"backfilled": {1, 2, 4}, "fresh": {3}}, samples)
+
+	assert.Equal(t, 2, testutil.CollectAndCount(i.TSDBState.appendPathDuration))
+}
+
+func TestIngester_Push_ShouldNotRejectFreshSamplesWhileColdSamplesWaitForTheirTurn(t *testing.T) {
+	const userID = "test-user"
+
+	cfg := defaultIngesterTestConfig(t)
+	cfg.LifecyclerConfig.JoinAfter = 0
+	cfg.ColdAppendSampleAge = 30 * time.Minute
+	cfg.ColdAppendMaxConcurrency = 1
+	cfg.DefaultLimits.MaxInflightPushRequests = 1
+
+	i, err := prepareIngesterWithBlocksStorage(t, cfg, prometheus.NewRegistry())
+	require.NoError(t, err)
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), i))
+	defer services.StopAndAwaitTerminated(context.Background(), i) //nolint:errcheck
+
+	// Wait until it's ACTIVE
+	test.Poll(t, time.Second, ring.ACTIVE, func() any {
+		return i.lifecycler.GetState()
+	})
+
+	ctx := user.InjectOrgID(context.Background(), userID)
+	now := time.Now()
+	push := func(metricName string, ts time.Time) error {
+		_, err := i.Push(ctx, cortexpb.ToWriteRequest(
+			[]labels.Labels{labels.FromStrings(labels.MetricName, metricName)},
+			[]cortexpb.Sample{{Value: 1, TimestampMs: ts.UnixMilli()}}, nil, nil, cortexpb.API))
+		return err
+	}
+
+	// Create the TSDB of the tenant, then take the only cold append slot.
+	require.NoError(t, push("fresh", now))
+	i.coldAppendSlots <- struct{}{}
+
+	coldDone := make(chan error, 1)
+	go func() {
+		coldDone <- push("backfilled", now.Add(-45*time.Minute))
+	}()
+
+	// Give the cold push the time to start waiting for its turn.
+	time.Sleep(100 * time.Millisecond)
+
+	// The cold push waits for its turn without being counted as in-flight, nor holding the append lock
+	// of the tenant, so the fresh pushes and the forced compactions aren't rejected nor delayed meanwhile.
+	for range 10 {
+		require.NoError(t, push("fresh", time.Now()))
+	}
+	db, err := i.getTSDB(userID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), i.inflightPushRequests.Load())
+
+	// The forced compactions wait for the in-flight pushes of the tenant.
+	pushesDone := make(chan struct{})
+	go func() {
+		db.pushesInFlight.Wait()
+		close(pushesDone)
+	}()
+	select {
+	case <-pushesDone:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the cold push waiting for its turn holds the append lock of the tenant")
+	}
+
+	select {
+	case err := <-coldDone:
+		t.Fatalf("the cold push has not waited for its turn: %v", err)
+	default:
+	}
+
+	// Once the slot is released, the cold push is processed.
+	<-i.coldAppendSlots
+	select {
+	case err := <-coldDone:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("the cold push has not been processed once the slot was released")
+	}
+}
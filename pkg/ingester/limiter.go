@@ -13,6 +13,7 @@ import (
 
 var (
 	errMaxSeriesPerMetricLimitExceeded              = errors.New("per-metric series limit exceeded")
+	errMetricQuarantined                            = errors.New("metric quarantined")
 	errMaxMetadataPerMetricLimitExceeded            = errors.New("per-metric metadata limit exceeded")
 	errMaxSeriesPerUserLimitExceeded                = errors.New("per-user series limit exceeded")
 	errMaxNativeHistogramSeriesPerUserLimitExceeded = errors.New("per-user native histogram series limit exceeded")
@@ -96,6 +97,11 @@ func (l *Limiter) AssertMaxSeriesPerUser(userID string, series int) error {
 	return errMaxSeriesPerUserLimitExceeded
 }
 
+// MaxSeriesPolicy returns the behavior of the ingester once the user reached the max series per user limit.
+func (l *Limiter) MaxSeriesPolicy(userID string) string {
+	return l.limits.MaxSeriesPolicy(userID)
+}
+
 // AssertMaxNativeHistogramSeriesPerUser limit has not been reached compared to the current
 // number of native histogram series in input and returns an error if so.
 func (l *Limiter) AssertMaxNativeHistogramSeriesPerUser(userID string, series int) error {
@@ -149,6 +155,8 @@ func (l *Limiter) FormatError(userID string, err error, lbls labels.Labels) erro
 		return l.formatMaxNativeHistogramsSeriesPerUserError(userID)
 	case errors.Is(err, errMaxSeriesPerMetricLimitExceeded):
 		return l.formatMaxSeriesPerMetricError(userID, lbls.Get(labels.MetricName))
+	case errors.Is(err, errMetricQuarantined):
+		return l.formatMetricQuarantinedError(userID, lbls.Get(labels.MetricName))
 	case errors.Is(err, errMaxMetadataPerUserLimitExceeded):
 		return l.formatMaxMetadataPerUserError(userID)
 	case errors.Is(err, errMaxMetadataPerMetricLimitExceeded):
@@ -189,6 +197,13 @@ func (l *Limiter) formatMaxSeriesPerMetricError(userID string, metric string) er
 		minNonZero(localLimit, globalLimit), metric, l.AdminLimitMessage, localLimit, globalLimit, actualLimit)
 }
 
+func (l *Limiter) formatMetricQuarantinedError(userID string, metric string) error {
+	threshold, lowWaterMark := l.metricQuarantineThresholds(userID)
+
+	return fmt.Errorf("metric %s is quarantined because it exceeded the per-metric quarantine threshold of %d series, new series for the metric are rejected until it has no more than %d series, %s",
+		metric, threshold, lowWaterMark, l.AdminLimitMessage)
+}
+
 func (l *Limiter) formatMaxMetadataPerUserError(userID string) error {
 	actualLimit := l.maxMetadataPerUser(userID)
 	localLimit := l.limits.MaxLocalMetricsWithMetadataPerUser(userID)
@@ -217,6 +232,17 @@ func (l *Limiter) limitsPerLabelSets(userID string, metric labels.Labels) []vali
 	return validation.LimitsPerLabelSetsForSeries(m, metric)
 }
 
+// metricQuarantineThresholds returns the number of series per metric above which a metric is quarantined,
+// and the number of series at or below which the quarantine is cleared. The threshold is 0 if disabled.
+func (l *Limiter) metricQuarantineThresholds(userID string) (threshold, lowWaterMark int) {
+	threshold = l.limits.MetricQuarantineSeriesThreshold(userID)
+	lowWaterMark = l.limits.MetricQuarantineSeriesLowWaterMark(userID)
+	if lowWaterMark <= 0 {
+		lowWaterMark = threshold - 1
+	}
+	return threshold, lowWaterMark
+}
+
 func (l *Limiter) maxSeriesPerMetric(userID string) int {
 	localLimit := l.limits.MaxLocalSeriesPerMetric(userID)
 	globalLimit := l.limits.MaxGlobalSeriesPerMetric(userID)
@@ -646,6 +646,8 @@ func TestLimiter_FormatError(t *testing.T) {
 		MaxGlobalSeriesPerMetric:              20,
 		MaxGlobalMetricsWithMetadataPerUser:   10,
 		MaxGlobalMetadataPerMetric:            3,
+		MetricQuarantineSeriesThreshold:       50,
+		MetricQuarantineSeriesLowWaterMark:    40,
 	}, nil)
 
 	limiter := NewLimiter(limits, ring, util.ShardingStrategyDefault, true, 3, false, "please contact administrator to raise it")
@@ -660,6 +662,9 @@ func TestLimiter_FormatError(t *testing.T) {
 	actual = limiter.FormatError("user-1", errMaxSeriesPerMetricLimitExceeded, lbls)
 	assert.EqualError(t, actual, "per-metric series limit of 20 exceeded for metric testMetric, please contact administrator to raise it (local limit: 0 global limit: 20 actual local limit: 20)")
 
+	actual = limiter.FormatError("user-1", errMetricQuarantined, lbls)
+	assert.EqualError(t, actual, "metric testMetric is quarantined because it exceeded the per-metric quarantine threshold of 50 series, new series for the metric are rejected until it has no more than 40 series, please contact administrator to raise it")
+
 	actual = limiter.FormatError("user-1", errMaxMetadataPerUserLimitExceeded, lbls)
 	assert.EqualError(t, actual, "per-user metric metadata limit of 10 exceeded, please contact administrator to raise it (local limit: 0 global limit: 10 actual local limit: 10)")
 
@@ -1,6 +1,8 @@
 package ingester
 
 import (
+	"slices"
+	"strconv"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
@@ -32,30 +34,35 @@ const (
 )
 
 type ingesterMetrics struct {
-	ingestedSamples          prometheus.Counter
-	ingestedHistograms       prometheus.Counter
-	ingestedExemplars        prometheus.Counter
-	ingestedMetadata         prometheus.Counter
-	ingestedSamplesFail      prometheus.Counter
-	ingestedHistogramsFail   prometheus.Counter
-	startTimestampFail       *prometheus.CounterVec
-	ingestedExemplarsFail    prometheus.Counter
-	ingestedMetadataFail     prometheus.Counter
-	ingestedHistogramBuckets *prometheus.HistogramVec
-	oooLabelsTotal           *prometheus.CounterVec
-	queries                  prometheus.Counter
-	queriedSamples           prometheus.Histogram
-	queriedExemplars         prometheus.Histogram
-	queriedSeries            prometheus.Histogram
-	queriedChunks            prometheus.Histogram
-	memSeries                prometheus.Gauge
-	memMetadata              prometheus.Gauge
-	memUsers                 prometheus.Gauge
-	memSeriesCreatedTotal    *prometheus.CounterVec
-	memMetadataCreatedTotal  *prometheus.CounterVec
-	memSeriesRemovedTotal    *prometheus.CounterVec
-	memMetadataRemovedTotal  *prometheus.CounterVec
-	pushErrorsTotal          *prometheus.CounterVec
+	ingestedSamples                      prometheus.Counter
+	ingestedHistograms                   prometheus.Counter
+	ingestedExemplars                    prometheus.Counter
+	ingestedMetadata                     prometheus.Counter
+	ingestedSamplesFail                  prometheus.Counter
+	ingestedHistogramsFail               prometheus.Counter
+	startTimestampFail                   *prometheus.CounterVec
+	ingestedExemplarsFail                prometheus.Counter
+	ingestedMetadataFail                 prometheus.Counter
+	ingestedHistogramBuckets             *prometheus.HistogramVec
+	walReplayProgress                    *prometheus.GaugeVec
+	oooLabelsTotal                       *prometheus.CounterVec
+	queries                              prometheus.Counter
+	queriedSamples                       prometheus.Histogram
+	queriedExemplars                     prometheus.Histogram
+	queriedSeries                        prometheus.Histogram
+	queriedChunks                        prometheus.Histogram
+	memSeries                            prometheus.Gauge
+	memMetadata                          prometheus.Gauge
+	memUsers                             prometheus.Gauge
+	memSeriesCreatedTotal                *prometheus.CounterVec
+	memMetadataCreatedTotal              *prometheus.CounterVec
+	memSeriesRemovedTotal                *prometheus.CounterVec
+	memMetadataRemovedTotal              *prometheus.CounterVec
+	pushErrorsTotal                      *prometheus.CounterVec
+	idleSeriesEvictedTotal               *prometheus.CounterVec
+	identicalDuplicatesTotal             *prometheus.CounterVec
+	identicalDuplicateCheckFailuresTotal *prometheus.CounterVec
+	headMinTimestamp                     *prometheus.GaugeVec
 
 	activeSeriesPerUser        *prometheus.GaugeVec
 	activeNHSeriesPerUser      *prometheus.GaugeVec
@@ -63,7 +70,9 @@ type ingesterMetrics struct {
 	headQueriedSeriesPerUser   *prometheus.GaugeVec
 	limitsPerLabelSet          *prometheus.GaugeVec
 	usagePerLabelSet           *prometheus.GaugeVec
+	quarantinedMetrics         *prometheus.GaugeVec
 	activeSeriesPerTracker     *prometheus.GaugeVec
+	activeSeriesAgePerUser     *activeSeriesAgeMetrics
 
 	// Global limit metrics
 	maxUsersGauge           prometheus.GaugeFunc
@@ -89,6 +98,7 @@ type ingesterMetrics struct {
 func newIngesterMetrics(r prometheus.Registerer,
 	createMetricsConflictingWithTSDB bool,
 	activeSeriesEnabled bool,
+	activeSeriesAgeEnabled bool,
 	activeQueriedSeriesEnabled bool,
 	headQueriedSeriesEnabled bool,
 	instanceLimitsFn func() *InstanceLimits,
@@ -193,6 +203,10 @@ func newIngesterMetrics(r prometheus.Registerer,
 			NativeHistogramMaxBucketNumber:  100,
 			NativeHistogramMinResetDuration: time.Hour,
 		}),
+		walReplayProgress: promauto.With(r).NewGaugeVec(prometheus.GaugeOpts{
+			Name: "cortex_ingester_tsdb_wal_replay_progress_ratio",
+			Help: "Progress of the replay of the TSDB WAL segments per user, between 0 and 1. The out-of-order WBL segments, if any, are replayed after the WAL ones and reported the same way. Set to 1 once the TSDB has been opened.",
+		}, []string{"user"}),
 		memSeries: promauto.With(r).NewGauge(prometheus.GaugeOpts{
 			Name: "cortex_ingester_memory_series",
 			Help: "The current number of series in memory.",
@@ -217,6 +231,22 @@ func newIngesterMetrics(r prometheus.Registerer,
 			Name: "cortex_ingester_push_errors_total",
 			Help: "The total number of push errors per user.",
 		}, []string{"user", "reason"}),
+		idleSeriesEvictedTotal: promauto.With(r).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_ingester_idle_series_evicted_total",
+			Help: "The total number of idle series evicted from the TSDB head by the head compactions triggered by the max series per user limit, when the max series policy is evict-idle.",
+		}, []string{"user"}),
+		identicalDuplicatesTotal: promauto.With(r).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_ingester_identical_duplicate_samples_total",
+			Help: "The total number of out-of-order or too old samples accepted because they are exact duplicates of an ingested sample, when accepting identical duplicate samples is enabled.",
+		}, []string{"user"}),
+		identicalDuplicateCheckFailuresTotal: promauto.With(r).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_ingester_identical_duplicate_samples_check_failures_total",
+			Help: "The total number of out-of-order or too old samples rejected because the check whether they are exact duplicates of an ingested sample failed, when accepting identical duplicate samples is enabled.",
+		}, []string{"user"}),
+		headMinTimestamp: promauto.With(r).NewGaugeVec(prometheus.GaugeOpts{
+			Name: "cortex_ingester_tsdb_head_min_timestamp_seconds",
+			Help: "Unix timestamp of the oldest sample in the TSDB head of the user, updated at each head compaction interval.",
+		}, []string{"user"}),
 
 		maxUsersGauge: promauto.With(r).NewGaugeFunc(prometheus.GaugeOpts{
 			Name:        instanceLimits,
@@ -302,6 +332,11 @@ func newIngesterMetrics(r prometheus.Registerer,
 			Help: "Current usage per user and labelset.",
 		}, []string{"user", "limit", "labelset"}),
 
+		quarantinedMetrics: promauto.With(r).NewGaugeVec(prometheus.GaugeOpts{
+			Name: "cortex_ingester_quarantined_metrics",
+			Help: "Metrics quarantined because they exceeded the per-metric quarantine series threshold. New series for these metrics are rejected.",
+		}, []string{"user", "metric_name"}),
+
 		// Not registered automatically, but only if activeSeriesEnabled is true.
 		activeSeriesPerUser: prometheus.NewGaugeVec(prometheus.GaugeOpts{
 			Name: "cortex_ingester_active_series",
@@ -320,6 +355,9 @@ func newIngesterMetrics(r prometheus.Registerer,
 			Help: "Number of currently active series matching a configured tracker pattern.",
 		}, []string{"user", "name"}),
 
+		// Not registered automatically, but only if activeSeriesAgeEnabled is true.
+		activeSeriesAgePerUser: newActiveSeriesAgeMetrics(),
+
 		// Not registered automatically, but only if activeQueriedSeriesEnabled is true.
 		activeQueriedSeriesPerUser: prometheus.NewGaugeVec(prometheus.GaugeOpts{
 			Name: "cortex_ingester_active_queried_series",
@@ -374,6 +412,10 @@ func newIngesterMetrics(r prometheus.Registerer,
 		r.MustRegister(m.activeSeriesPerTracker)
 	}
 
+	if activeSeriesEnabled && activeSeriesAgeEnabled && r != nil {
+		r.MustRegister(m.activeSeriesAgePerUser)
+	}
+
 	if activeQueriedSeriesEnabled && r != nil {
 		r.MustRegister(m.activeQueriedSeriesPerUser)
 	}
@@ -407,12 +449,19 @@ func (m *ingesterMetrics) deletePerUserMetrics(userID string) {
 	m.activeSeriesPerUser.DeleteLabelValues(userID)
 	m.activeNHSeriesPerUser.DeleteLabelValues(userID)
 	m.activeSeriesPerTracker.DeletePartialMatch(prometheus.Labels{"user": userID})
+	m.activeSeriesAgePerUser.deleteUser(userID)
 	m.activeQueriedSeriesPerUser.DeletePartialMatch(prometheus.Labels{"user": userID})
 	m.headQueriedSeriesPerUser.DeletePartialMatch(prometheus.Labels{"user": userID})
 	m.usagePerLabelSet.DeletePartialMatch(prometheus.Labels{"user": userID})
 	m.limitsPerLabelSet.DeletePartialMatch(prometheus.Labels{"user": userID})
+	m.quarantinedMetrics.DeletePartialMatch(prometheus.Labels{"user": userID})
 	m.pushErrorsTotal.DeletePartialMatch(prometheus.Labels{"user": userID})
+	m.idleSeriesEvictedTotal.DeleteLabelValues(userID)
+	m.identicalDuplicatesTotal.DeleteLabelValues(userID)
+	m.identicalDuplicateCheckFailuresTotal.DeleteLabelValues(userID)
+	m.headMinTimestamp.DeleteLabelValues(userID)
 	m.ingestedHistogramBuckets.DeleteLabelValues(userID)
+	m.walReplayProgress.DeleteLabelValues(userID)
 
 	if m.memSeriesCreatedTotal != nil {
 		m.memSeriesCreatedTotal.DeleteLabelValues(userID)
@@ -427,6 +476,46 @@ func (m *ingesterMetrics) deletePerUserMetrics(userID string) {
 	}
 }
 
+// activeSeriesAgeBuckets are the upper bounds, in seconds, of the age of the active series tracked by activeSeriesAgeMetrics.
+var activeSeriesAgeBuckets = []float64{300, 900, 3600, 3 * 3600, 6 * 3600, 12 * 3600, 24 * 3600, 3 * 24 * 3600, 7 * 24 * 3600}
+
+// activeSeriesAgeMetrics exposes, per user, the number of active series that have been active for at most
+// each of the activeSeriesAgeBuckets, like the cumulative buckets of a histogram. They're gauges, because
+// each update is a snapshot of the current active series.
+type activeSeriesAgeMetrics struct {
+	*prometheus.GaugeVec
+}
+
+func newActiveSeriesAgeMetrics() *activeSeriesAgeMetrics {
+	return &activeSeriesAgeMetrics{
+		GaugeVec: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "cortex_ingester_active_series_by_age",
+			Help: "Number of currently active series which have been active for at most the number of seconds of the le label, per user. The age of a series is counted from when the ingester first saw it active since it started.",
+		}, []string{"user", "le"}),
+	}
+}
+
+// update sets the gauges of the user to the age of its current active series.
+func (m *activeSeriesAgeMetrics) update(userID string, activeSeries *ActiveSeries, now time.Time) {
+	counts := make([]int, len(activeSeriesAgeBuckets)+1)
+	activeSeries.ObserveSeriesAge(now, prometheus.ObserverFunc(func(age float64) {
+		i, _ := slices.BinarySearch(activeSeriesAgeBuckets, age)
+		counts[i]++
+	}))
+
+	cumulative := 0
+	for i, upperBound := range activeSeriesAgeBuckets {
+		cumulative += counts[i]
+		m.WithLabelValues(userID, strconv.FormatFloat(upperBound, 'f', -1, 64)).Set(float64(cumulative))
+	}
+	cumulative += counts[len(activeSeriesAgeBuckets)]
+	m.WithLabelValues(userID, "+Inf").Set(float64(cumulative))
+}
+
+func (m *activeSeriesAgeMetrics) deleteUser(userID string) {
+	m.DeletePartialMatch(prometheus.Labels{"user": userID})
+}
+
 // TSDB metrics collector. Each tenant has its own registry, that TSDB code uses.
 type tsdbMetrics struct {
 	// Metrics aggregated from Thanos shipper.
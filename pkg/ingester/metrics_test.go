@@ -2,11 +2,14 @@ package ingester
 
 import (
 	"bytes"
+	"strconv"
 	"testing"
+	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/prometheus/model/labels"
 	"github.com/stretchr/testify/require"
 
 	util_math "github.com/cortexproject/cortex/pkg/util/math"
@@ -20,7 +23,7 @@ func TestRegexMatcherLimitsMetricsFeatureFlag(t *testing.T) {
 	// Test with feature flag disabled - metrics should be nil
 	t.Run("metrics are nil when feature flag is disabled", func(t *testing.T) {
 		reg := prometheus.NewRegistry()
-		m := newIngesterMetrics(reg, false, false, false, false,
+		m := newIngesterMetrics(reg, false, false, false, false, false,
 			func() *InstanceLimits { return &InstanceLimits{} },
 			ingestionRate, &inflightPushRequests, &maxInflightQueryRequests, false, false)
 
@@ -33,7 +36,7 @@ func TestRegexMatcherLimitsMetricsFeatureFlag(t *testing.T) {
 	// Test with feature flag enabled - metrics should be initialized
 	t.Run("metrics are initialized when feature flag is enabled", func(t *testing.T) {
 		reg := prometheus.NewRegistry()
-		m := newIngesterMetrics(reg, false, false, false, false,
+		m := newIngesterMetrics(reg, false, false, false, false, false,
 			func() *InstanceLimits { return &InstanceLimits{} },
 			ingestionRate, &inflightPushRequests, &maxInflightQueryRequests, false, true)
 
@@ -51,7 +54,7 @@ func TestUnoptimizedRegexRejectedMetric(t *testing.T) {
 
 	t.Run("rejected metric increments correctly", func(t *testing.T) {
 		reg := prometheus.NewRegistry()
-		m := newIngesterMetrics(reg, false, false, false, false,
+		m := newIngesterMetrics(reg, false, false, false, false, false,
 			func() *InstanceLimits { return &InstanceLimits{} },
 			ingestionRate, &inflightPushRequests, &maxInflightQueryRequests, false, true)
 
@@ -75,7 +78,7 @@ func TestUnoptimizedRegexRejectedMetric(t *testing.T) {
 
 	t.Run("metric cleanup works correctly", func(t *testing.T) {
 		reg := prometheus.NewRegistry()
-		m := newIngesterMetrics(reg, false, false, false, false,
+		m := newIngesterMetrics(reg, false, false, false, false, false,
 			func() *InstanceLimits { return &InstanceLimits{} },
 			ingestionRate, &inflightPushRequests, &maxInflightQueryRequests, false, true)
 
@@ -111,6 +114,7 @@ func TestIngesterMetrics(t *testing.T) {
 		true,
 		false,
 		false,
+		false,
 		func() *InstanceLimits {
 			return &InstanceLimits{
 				MaxIngestionRate:        12,
@@ -1266,3 +1270,64 @@ func populateTSDBMetrics(base float64) *prometheus.Registry {
 
 	return r
 }
+
+func TestActiveSeriesAgeMetrics(t *testing.T) {
+	reg := prometheus.NewPedanticRegistry()
+	m := newActiveSeriesAgeMetrics()
+	reg.MustRegister(m)
+
+	now := time.Now()
+	activeSeries := NewActiveSeries()
+	for i, age := range []time.Duration{time.Minute, 2 * time.Hour, 2 * 24 * time.Hour} {
+		ls := labels.FromStrings("a", strconv.Itoa(i))
+		activeSeries.UpdateSeries(ls, ls.Hash(), now.Add(-age), false, copyFn)
+	}
+
+	m.update("user1", activeSeries, now)
+	m.update("user2", NewActiveSeries(), now)
+
+	require.NoError(t, testutil.GatherAndCompare(reg, bytes.NewBufferString(`
+		# HELP cortex_ingester_active_series_by_age Number of currently active series which have been active for at most the number of seconds of the le label, per user. The age of a series is counted from when the ingester first saw it active since it started.
+		# TYPE cortex_ingester_active_series_by_age gauge
+		cortex_ingester_active_series_by_age{le="300",user="user1"} 1
+		cortex_ingester_active_series_by_age{le="900",user="user1"} 1
+		cortex_ingester_active_series_by_age{le="3600",user="user1"} 1
+		cortex_ingester_active_series_by_age{le="10800",user="user1"} 2
+		cortex_ingester_active_series_by_age{le="21600",user="user1"} 2
+		cortex_ingester_active_series_by_age{le="43200",user="user1"} 2
+		cortex_ingester_active_series_by_age{le="86400",user="user1"} 2
+		cortex_ingester_active_series_by_age{le="259200",user="user1"} 3
+		cortex_ingester_active_series_by_age{le="604800",user="user1"} 3
+		cortex_ingester_active_series_by_age{le="+Inf",user="user1"} 3
+		cortex_ingester_active_series_by_age{le="300",user="user2"} 0
+		cortex_ingester_active_series_by_age{le="900",user="user2"} 0
+		cortex_ingester_active_series_by_age{le="3600",user="user2"} 0
+		cortex_ingester_active_series_by_age{le="10800",user="user2"} 0
+		cortex_ingester_active_series_by_age{le="21600",user="user2"} 0
+		cortex_ingester_active_series_by_age{le="43200",user="user2"} 0
+		cortex_ingester_active_series_by_age{le="86400",user="user2"} 0
+		cortex_ingester_active_series_by_age{le="259200",user="user2"} 0
+		cortex_ingester_active_series_by_age{le="604800",user="user2"} 0
+		cortex_ingester_active_series_by_age{le="+Inf",user="user2"} 0
+	`), "cortex_ingester_active_series_by_age"))
+
+	// Once the series are purged, the gauges only count the remaining active series.
+	activeSeries.Purge(now.Add(-time.Hour))
+	m.update("user1", activeSeries, now)
+	m.deleteUser("user2")
+
+	require.NoError(t, testutil.GatherAndCompare(reg, bytes.NewBufferString(`
+		# HELP cortex_ingester_active_series_by_age Number of currently active series which have been active for at most the number of seconds of the le label, per user. The age of a series is counted from when the ingester first saw it active since it started.
+		# TYPE cortex_ingester_active_series_by_age gauge
+		cortex_ingester_active_series_by_age{le="300",user="user1"} 1
+		cortex_ingester_active_series_by_age{le="900",user="user1"} 1
+		cortex_ingester_active_series_by_age{le="3600",user="user1"} 1
+		cortex_ingester_active_series_by_age{le="10800",user="user1"} 1
+		cortex_ingester_active_series_by_age{le="21600",user="user1"} 1
+		cortex_ingester_active_series_by_age{le="43200",user="user1"} 1
+		cortex_ingester_active_series_by_age{le="86400",user="user1"} 1
+		cortex_ingester_active_series_by_age{le="259200",user="user1"} 1
+		cortex_ingester_active_series_by_age{le="604800",user="user1"} 1
+		cortex_ingester_active_series_by_age{le="+Inf",user="user1"} 1
+	`), "cortex_ingester_active_series_by_age"))
+}
@@ -14,8 +14,17 @@ import (
 const (
 	defaultLimit          = -1
 	defaultLimitPerMetric = -1
+
+	// metadataConflictResolutionAll keeps and returns all the distinct metadata of a metric.
+	metadataConflictResolutionAll = "all"
+	// metadataConflictResolutionMostRecent keeps only the metadata of a metric received last.
+	metadataConflictResolutionMostRecent = "most-recent"
+	// metadataConflictResolutionMostCommon keeps all the distinct metadata of a metric, and returns the one received the most times.
+	metadataConflictResolutionMostCommon = "most-common"
 )
 
+var metadataConflictResolutions = []string{metadataConflictResolutionAll, metadataConflictResolutionMostRecent, metadataConflictResolutionMostCommon}
+
 // userMetricsMetadata allows metric metadata of a tenant to be held by the ingester.
 // Metadata is kept as a set as it can come from multiple targets that Prometheus scrapes
 // with the same metric name.
@@ -25,12 +34,13 @@ type userMetricsMetadata struct {
 	validateMetrics    *validation.ValidateMetrics
 	userID             string
 	skipMetadataLimits bool
+	conflictResolution string
 
 	mtx              sync.RWMutex
 	metricToMetadata map[string]metricMetadataSet
 }
 
-func newMetadataMap(l *Limiter, m *ingesterMetrics, v *validation.ValidateMetrics, userID string, skipMetadataLimits bool) *userMetricsMetadata {
+func newMetadataMap(l *Limiter, m *ingesterMetrics, v *validation.ValidateMetrics, userID string, skipMetadataLimits bool, conflictResolution string) *userMetricsMetadata {
 	return &userMetricsMetadata{
 		metricToMetadata:   map[string]metricMetadataSet{},
 		limiter:            l,
@@ -38,6 +48,7 @@ func newMetadataMap(l *Limiter, m *ingesterMetrics, v *validation.ValidateMetric
 		validateMetrics:    v,
 		userID:             userID,
 		skipMetadataLimits: skipMetadataLimits,
+		conflictResolution: conflictResolution,
 	}
 }
 
@@ -59,19 +70,30 @@ func (mm *userMetricsMetadata) add(metric string, metadata *cortexpb.MetricMetad
 		mm.metricToMetadata[metric] = set
 	}
 
+	entry, seen := set[*metadata]
+
+	// The metadata received last replaces the ones previously received.
+	if !seen && mm.conflictResolution == metadataConflictResolutionMostRecent {
+		if deleted := set.purge(time.Time{}); deleted > 0 {
+			mm.metrics.memMetadata.Sub(float64(deleted))
+			mm.metrics.memMetadataRemovedTotal.WithLabelValues(mm.userID).Add(float64(deleted))
+		}
+	}
+
 	if err := mm.limiter.AssertMaxMetadataPerMetric(mm.userID, len(set)); err != nil {
 		mm.validateMetrics.DiscardedMetadata.WithLabelValues(mm.userID, perMetricMetadataLimit).Inc()
 		return makeMetricLimitError(perMetricMetadataLimit, labels.FromStrings(labels.MetricName, metric), mm.limiter.FormatError(mm.userID, err, labels.FromStrings(labels.MetricName, metric)))
 	}
 
 	// if we have seen this metadata before, it is a no-op and we don't need to change our metrics.
-	_, ok = set[*metadata]
-	if !ok {
+	if !seen {
 		mm.metrics.memMetadata.Inc()
 		mm.metrics.memMetadataCreatedTotal.WithLabelValues(mm.userID).Inc()
 	}
 
-	mm.metricToMetadata[metric][*metadata] = time.Now()
+	entry.lastSeen = time.Now()
+	entry.count++
+	set[*metadata] = entry
 	return nil
 }
 
@@ -115,7 +137,7 @@ func (mm *userMetricsMetadata) toClientMetadata(req *client.MetricsMetadataReque
 			return r
 		}
 
-		metadataSet.add(limitPerMetric, &r)
+		metadataSet.add(limitPerMetric, mm.conflictResolution, &r)
 		return r
 	}
 
@@ -124,13 +146,18 @@ func (mm *userMetricsMetadata) toClientMetadata(req *client.MetricsMetadataReque
 		if limit > 0 && metrics >= limit {
 			break
 		}
-		set.add(limitPerMetric, &r)
+		set.add(limitPerMetric, mm.conflictResolution, &r)
 		metrics++
 	}
 	return r
 }
 
-func (mns metricMetadataSet) add(limitPerMetric int64, r *[]*cortexpb.MetricMetadata) {
+func (mns metricMetadataSet) add(limitPerMetric int64, conflictResolution string, r *[]*cortexpb.MetricMetadata) {
+	if conflictResolution == metadataConflictResolutionMostCommon && limitPerMetric != 0 && len(mns) > 0 {
+		*r = append(*r, mns.mostCommon())
+		return
+	}
+
 	var metrics int64
 	for m := range mns {
 		if limitPerMetric > 0 && metrics >= limitPerMetric {
@@ -141,13 +168,34 @@ func (mns metricMetadataSet) add(limitPerMetric int64, r *[]*cortexpb.MetricMeta
 	}
 }
 
-type metricMetadataSet map[cortexpb.MetricMetadata]time.Time
+// mostCommon returns the metadata received the most times, or the most recently received one in case of a tie.
+func (mns metricMetadataSet) mostCommon() *cortexpb.MetricMetadata {
+	var (
+		result *cortexpb.MetricMetadata
+		best   metricMetadataEntry
+	)
+	for m, entry := range mns {
+		if result == nil || entry.count > best.count || (entry.count == best.count && entry.lastSeen.After(best.lastSeen)) {
+			result = &m
+			best = entry
+		}
+	}
+	return result
+}
+
+type metricMetadataEntry struct {
+	lastSeen time.Time
+	// Number of times the metadata has been received.
+	count uint64
+}
+
+type metricMetadataSet map[cortexpb.MetricMetadata]metricMetadataEntry
 
 // If deadline is zero time, all metrics are purged.
 func (mms metricMetadataSet) purge(deadline time.Time) int {
 	var deleted int
-	for metadata, t := range mms {
-		if deadline.IsZero() || deadline.After(t) {
+	for metadata, entry := range mms {
+		if deadline.IsZero() || deadline.After(entry.lastSeen) {
 			delete(mms, metadata)
 			deleted++
 		}
@@ -27,6 +27,7 @@ func Test_UserMetricsMetadata(t *testing.T) {
 		false,
 		false,
 		false,
+		false,
 		func() *InstanceLimits {
 			return &InstanceLimits{}
 		},
@@ -110,7 +111,7 @@ func Test_UserMetricsMetadata(t *testing.T) {
 
 	for _, test := range tests {
 		t.Run(test.description, func(t *testing.T) {
-			userMetricsMetadata := newMetadataMap(limiter, m, validation.NewValidateMetrics(reg), userId, test.skipMetadataLimits)
+			userMetricsMetadata := newMetadataMap(limiter, m, validation.NewValidateMetrics(reg), userId, test.skipMetadataLimits, metadataConflictResolutionAll)
 
 			addMetricMetadata := func(name string, i int) {
 				metadata := &cortexpb.MetricMetadata{
@@ -138,3 +139,59 @@ func Test_UserMetricsMetadata(t *testing.T) {
 		})
 	}
 }
+
+func Test_UserMetricsMetadata_ConflictResolution(t *testing.T) {
+	userId := "user-1"
+
+	reg := prometheus.NewPedanticRegistry()
+	ingestionRate := util_math.NewEWMARate(0.2, instanceIngestionRateTickInterval)
+	inflightPushRequests := util_math.MaxTracker{}
+	maxInflightQueryRequests := util_math.MaxTracker{}
+
+	m := newIngesterMetrics(reg, false, false, false, false, false, func() *InstanceLimits {
+		return &InstanceLimits{}
+	}, ingestionRate, &inflightPushRequests, &maxInflightQueryRequests, false, false)
+
+	overrides := validation.NewOverrides(validation.Limits{}, nil)
+	limiter := NewLimiter(overrides, nil, util.ShardingStrategyDefault, true, 1, false, "")
+
+	counter := &cortexpb.MetricMetadata{MetricFamilyName: "metric1", Type: cortexpb.COUNTER, Help: "a counter"}
+	gauge := &cortexpb.MetricMetadata{MetricFamilyName: "metric1", Type: cortexpb.GAUGE, Help: "a gauge"}
+
+	tests := map[string]struct {
+		conflictResolution string
+		expected           []*cortexpb.MetricMetadata
+		expectedInMemory   int
+	}{
+		"all": {
+			conflictResolution: metadataConflictResolutionAll,
+			expected:           []*cortexpb.MetricMetadata{counter, gauge},
+			expectedInMemory:   2,
+		},
+		"most-recent": {
+			conflictResolution: metadataConflictResolutionMostRecent,
+			expected:           []*cortexpb.MetricMetadata{gauge},
+			expectedInMemory:   1,
+		},
+		"most-common": {
+			conflictResolution: metadataConflictResolutionMostCommon,
+			expected:           []*cortexpb.MetricMetadata{counter},
+			expectedInMemory:   2,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			userMetricsMetadata := newMetadataMap(limiter, m, validation.NewValidateMetrics(reg), userId, true, test.conflictResolution)
+
+			// The counter metadata is received more times, but the gauge one is received last.
+			for _, metadata := range []*cortexpb.MetricMetadata{counter, counter, gauge} {
+				require.NoError(t, userMetricsMetadata.add("metric1", metadata))
+			}
+
+			r := userMetricsMetadata.toClientMetadata(&client.MetricsMetadataRequest{Limit: defaultLimit, LimitPerMetric: defaultLimitPerMetric})
+			require.ElementsMatch(t, test.expected, r)
+			require.Len(t, userMetricsMetadata.metricToMetadata["metric1"], test.expectedInMemory)
+		})
+	}
+}
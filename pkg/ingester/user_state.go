@@ -4,6 +4,7 @@ import (
 	"context"
 	"sync"
 
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/common/model"
 	"github.com/prometheus/prometheus/model/labels"
 	"github.com/prometheus/prometheus/tsdb"
@@ -19,6 +20,7 @@ const (
 	perUserSeriesLimit                = "per_user_series_limit"
 	perUserNativeHistogramSeriesLimit = "per_user_native_histogram_series_limit"
 	perMetricSeriesLimit              = "per_metric_series_limit"
+	perMetricSeriesQuarantine         = "per_metric_series_quarantine"
 	perLabelsetSeriesLimit            = "per_labelset_series_limit"
 )
 
@@ -27,36 +29,47 @@ const numMetricCounterShards = 128
 type metricCounterShard struct {
 	mtx sync.Mutex
 	m   map[string]int
+
+	// Metrics whose new series are rejected until their number of series drops to the quarantine low-water mark.
+	quarantined map[string]struct{}
 }
 
 type metricCounter struct {
 	limiter *Limiter
 	shards  []metricCounterShard
 
-	ignoredMetrics map[string]struct{}
+	ignoredMetrics     map[string]struct{}
+	quarantinedMetrics *prometheus.GaugeVec
 }
 
-func newMetricCounter(limiter *Limiter, ignoredMetricsForSeriesCount map[string]struct{}) *metricCounter {
+func newMetricCounter(limiter *Limiter, ignoredMetricsForSeriesCount map[string]struct{}, quarantinedMetrics *prometheus.GaugeVec) *metricCounter {
 	shards := make([]metricCounterShard, 0, numMetricCounterShards)
 	for range numMetricCounterShards {
 		shards = append(shards, metricCounterShard{
-			m: map[string]int{},
+			m:           map[string]int{},
+			quarantined: map[string]struct{}{},
 		})
 	}
 	return &metricCounter{
 		limiter: limiter,
 		shards:  shards,
 
-		ignoredMetrics: ignoredMetricsForSeriesCount,
+		ignoredMetrics:     ignoredMetricsForSeriesCount,
+		quarantinedMetrics: quarantinedMetrics,
 	}
 }
 
-func (m *metricCounter) decreaseSeriesForMetric(metricName string) {
+func (m *metricCounter) decreaseSeriesForMetric(userID, metricName string) {
 	shard := m.getShard(metricName)
 	shard.mtx.Lock()
 	defer shard.mtx.Unlock()
 
 	shard.m[metricName]--
+	if _, ok := shard.quarantined[metricName]; ok {
+		if threshold, lowWaterMark := m.limiter.metricQuarantineThresholds(userID); threshold <= 0 || shard.m[metricName] <= lowWaterMark {
+			m.releaseQuarantine(userID, metricName, shard)
+		}
+	}
 	if shard.m[metricName] == 0 {
 		delete(shard.m, metricName)
 	}
@@ -76,9 +89,28 @@ func (m *metricCounter) canAddSeriesFor(userID, metric string) error {
 	shard.mtx.Lock()
 	defer shard.mtx.Unlock()
 
+	threshold, lowWaterMark := m.limiter.metricQuarantineThresholds(userID)
+	if _, ok := shard.quarantined[metric]; ok {
+		if threshold > 0 && shard.m[metric] > lowWaterMark {
+			return errMetricQuarantined
+		}
+		// The quarantine has been disabled or its thresholds changed meanwhile.
+		m.releaseQuarantine(userID, metric, shard)
+	} else if threshold > 0 && shard.m[metric] >= threshold {
+		shard.quarantined[metric] = struct{}{}
+		m.quarantinedMetrics.WithLabelValues(userID, metric).Set(1)
+		return errMetricQuarantined
+	}
+
 	return m.limiter.AssertMaxSeriesPerMetric(userID, shard.m[metric])
 }
 
+// releaseQuarantine clears the quarantine of the metric. The shard lock must be held.
+func (m *metricCounter) releaseQuarantine(userID, metric string, shard *metricCounterShard) {
+	delete(shard.quarantined, metric)
+	m.quarantinedMetrics.DeleteLabelValues(userID, metric)
+}
+
 func (m *metricCounter) increaseSeriesForMetric(metric string) {
 	shard := m.getShard(metric)
 	shard.mtx.Lock()
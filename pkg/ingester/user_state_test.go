@@ -5,6 +5,8 @@ import (
 	"errors"
 	"testing"
 
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/prometheus/prometheus/model/labels"
 	"github.com/prometheus/prometheus/storage"
 	"github.com/prometheus/prometheus/tsdb"
@@ -84,7 +86,7 @@ func TestMetricCounter(t *testing.T) {
 			// We're testing code that's not dependent on sharding strategy, replication factor, etc. To simplify the test,
 			// we use local limit only.
 			limiter := NewLimiter(overrides, nil, util.ShardingStrategyDefault, true, 3, false, "")
-			mc := newMetricCounter(limiter, ignored)
+			mc := newMetricCounter(limiter, ignored, newQuarantinedMetricsGauge())
 
 			for i := 0; i < tc.series; i++ {
 				err := mc.canAddSeriesFor("user", metric)
@@ -99,6 +101,39 @@ func TestMetricCounter(t *testing.T) {
 	}
 }
 
+func TestMetricCounter_Quarantine(t *testing.T) {
+	const metric = "metric"
+
+	limits := validation.Limits{MetricQuarantineSeriesThreshold: 5, MetricQuarantineSeriesLowWaterMark: 2}
+	overrides := validation.NewOverrides(limits, nil)
+	limiter := NewLimiter(overrides, nil, util.ShardingStrategyDefault, true, 3, false, "")
+	quarantinedMetrics := newQuarantinedMetricsGauge()
+	mc := newMetricCounter(limiter, nil, quarantinedMetrics)
+
+	for range 5 {
+		require.NoError(t, mc.canAddSeriesFor("user", metric))
+		mc.increaseSeriesForMetric(metric)
+	}
+
+	// The metric is quarantined once the threshold is exceeded, while the other metrics are not.
+	assert.Equal(t, errMetricQuarantined, mc.canAddSeriesFor("user", metric))
+	assert.Equal(t, float64(1), testutil.ToFloat64(quarantinedMetrics.WithLabelValues("user", metric)))
+	require.NoError(t, mc.canAddSeriesFor("user", "another_metric"))
+
+	// The quarantine holds until the number of series drops to the low-water mark.
+	mc.decreaseSeriesForMetric("user", metric)
+	mc.decreaseSeriesForMetric("user", metric)
+	assert.Equal(t, errMetricQuarantined, mc.canAddSeriesFor("user", metric))
+
+	mc.decreaseSeriesForMetric("user", metric)
+	require.NoError(t, mc.canAddSeriesFor("user", metric))
+	assert.Equal(t, 0, testutil.CollectAndCount(quarantinedMetrics))
+}
+
+func newQuarantinedMetricsGauge() *prometheus.GaugeVec {
+	return prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "cortex_ingester_quarantined_metrics"}, []string{"user", "metric_name"})
+}
+
 func TestGetCardinalityForLimitsPerLabelSet(t *testing.T) {
 	ctx := context.Background()
 	testErr := errors.New("err")
@@ -2,7 +2,10 @@ package querier
 
 import (
 	"context"
+	"fmt"
+	"strings"
 
+	"github.com/oklog/ulid/v2"
 	"github.com/prometheus/prometheus/model/labels"
 	"github.com/thanos-io/thanos/pkg/store/storepb"
 
@@ -12,9 +15,13 @@ import (
 type contextKey int
 
 var (
-	blockCtxKey contextKey = 0
+	blockCtxKey       contextKey = 0
+	queryBlocksCtxKey contextKey = 1
 )
 
+// QueryBlocksHeader is the header restricting a query to a comma-separated list of block ULIDs.
+const QueryBlocksHeader = "X-Cortex-Query-Blocks"
+
 func InjectBlocksIntoContext(ctx context.Context, blocks ...*bucketindex.Block) context.Context {
 	return context.WithValue(ctx, blockCtxKey, blocks)
 }
@@ -27,6 +34,40 @@ func ExtractBlocksFromContext(ctx context.Context) ([]*bucketindex.Block, bool)
 	return nil, false
 }
 
+// AddQueryBlocksToContext parses the comma-separated list of block ULIDs of the QueryBlocksHeader
+// and, if any, stores them in the context to restrict the query to these blocks.
+func AddQueryBlocksToContext(ctx context.Context, header string) (context.Context, error) {
+	if strings.TrimSpace(header) == "" {
+		return ctx, nil
+	}
+
+	ids := map[ulid.ULID]struct{}{}
+	for _, s := range strings.Split(header, ",") {
+		id, err := ulid.Parse(strings.TrimSpace(s))
+		if err != nil {
+			return ctx, fmt.Errorf("invalid block ID %q in the %s header: %w", s, QueryBlocksHeader, err)
+		}
+		ids[id] = struct{}{}
+	}
+	return context.WithValue(ctx, queryBlocksCtxKey, ids), nil
+}
+
+func extractQueryBlocksFromContext(ctx context.Context) (map[ulid.ULID]struct{}, bool) {
+	ids, ok := ctx.Value(queryBlocksCtxKey).(map[ulid.ULID]struct{})
+	return ids, ok
+}
+
+// filterQueryBlocks returns the blocks among the given IDs.
+func filterQueryBlocks(blocks bucketindex.Blocks, ids map[ulid.ULID]struct{}) bucketindex.Blocks {
+	filtered := make(bucketindex.Blocks, 0, len(ids))
+	for _, b := range blocks {
+		if _, ok := ids[b.ID]; ok {
+			filtered = append(filtered, b)
+		}
+	}
+	return filtered
+}
+
 func convertMatchersToLabelMatcher(matchers []*labels.Matcher) []storepb.LabelMatcher {
 	var converted []storepb.LabelMatcher
 	for _, m := range matchers {
@@ -14,7 +14,7 @@ import (
 
 type BlocksConsistencyChecker struct {
 	uploadGracePeriod   time.Duration
-	deletionGracePeriod time.Duration
+	deletionGracePeriod func(userID string) time.Duration
 	logger              log.Logger
 
 	checksTotal  prometheus.Counter
@@ -22,6 +22,12 @@ type BlocksConsistencyChecker struct {
 }
 
 func NewBlocksConsistencyChecker(uploadGracePeriod, deletionGracePeriod time.Duration, logger log.Logger, reg prometheus.Registerer) *BlocksConsistencyChecker {
+	return NewTenantBlocksConsistencyChecker(uploadGracePeriod, func(string) time.Duration { return deletionGracePeriod }, logger, reg)
+}
+
+// NewTenantBlocksConsistencyChecker creates a BlocksConsistencyChecker resolving the deletion
+// grace period for each tenant through the input function.
+func NewTenantBlocksConsistencyChecker(uploadGracePeriod time.Duration, deletionGracePeriod func(userID string) time.Duration, logger log.Logger, reg prometheus.Registerer) *BlocksConsistencyChecker {
 	return &BlocksConsistencyChecker{
 		uploadGracePeriod:   uploadGracePeriod,
 		deletionGracePeriod: deletionGracePeriod,
@@ -37,7 +43,7 @@ func NewBlocksConsistencyChecker(uploadGracePeriod, deletionGracePeriod time.Dur
 	}
 }
 
-func (c *BlocksConsistencyChecker) Check(knownBlocks bucketindex.Blocks, knownDeletionMarks map[ulid.ULID]*bucketindex.BlockDeletionMark, queriedBlocks []ulid.ULID) (missingBlocks []ulid.ULID) {
+func (c *BlocksConsistencyChecker) Check(userID string, knownBlocks bucketindex.Blocks, knownDeletionMarks map[ulid.ULID]*bucketindex.BlockDeletionMark, queriedBlocks []ulid.ULID) (missingBlocks []ulid.ULID) {
 	c.checksTotal.Inc()
 
 	// Reverse the map of queried blocks, so that we can easily look for missing ones.
@@ -46,6 +52,8 @@ func (c *BlocksConsistencyChecker) Check(knownBlocks bucketindex.Blocks, knownDe
 		actualBlocks[blockID] = struct{}{}
 	}
 
+	deletionGracePeriod := c.deletionGracePeriod(userID)
+
 	// Look for any missing block.
 	for _, block := range knownBlocks {
 		// Some recently uploaded blocks, already discovered by the querier, may not have been discovered
@@ -67,7 +75,7 @@ func (c *BlocksConsistencyChecker) Check(knownBlocks bucketindex.Blocks, knownDe
 		if mark := knownDeletionMarks[block.ID]; mark != nil {
 			deletionTime := time.Unix(mark.DeletionTime, 0)
 
-			if c.deletionGracePeriod > 0 && time.Since(deletionTime) > c.deletionGracePeriod {
+			if deletionGracePeriod > 0 && time.Since(deletionTime) > deletionGracePeriod {
 				level.Debug(c.logger).Log("msg", "block skipped from consistency check because it is marked for deletion", "block", block.ID.String(), "deletionTime", deletionTime.String())
 				continue
 			}
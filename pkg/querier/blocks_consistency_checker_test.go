@@ -109,7 +109,7 @@ func TestBlocksConsistencyChecker_Check(t *testing.T) {
 			reg := prometheus.NewPedanticRegistry()
 			c := NewBlocksConsistencyChecker(uploadGracePeriod, deletionGracePeriod, log.NewNopLogger(), reg)
 
-			missingBlocks := c.Check(testData.knownBlocks, testData.knownDeletionMarks, testData.queriedBlocks)
+			missingBlocks := c.Check("user-1", testData.knownBlocks, testData.knownDeletionMarks, testData.queriedBlocks)
 			assert.Equal(t, testData.expectedMissingBlocks, missingBlocks)
 			assert.Equal(t, float64(1), testutil.ToFloat64(c.checksTotal))
 
@@ -121,3 +121,23 @@ func TestBlocksConsistencyChecker_Check(t *testing.T) {
 		})
 	}
 }
+
+func TestBlocksConsistencyChecker_PerTenantDeletionGracePeriod(t *testing.T) {
+	now := time.Now()
+	block1 := ulid.MustNew(uint64(util.TimeToMillis(now.Add(-time.Hour))), nil)
+	knownBlocks := bucketindex.Blocks{&bucketindex.Block{ID: block1, UploadedAt: now.Add(-time.Hour).Unix()}}
+	knownDeletionMarks := map[ulid.ULID]*bucketindex.BlockDeletionMark{
+		block1: {DeletionTime: now.Add(-30 * time.Minute).Unix()},
+	}
+
+	c := NewTenantBlocksConsistencyChecker(time.Minute, func(userID string) time.Duration {
+		if userID == "user-1" {
+			return 10 * time.Minute
+		}
+		return time.Hour
+	}, log.NewNopLogger(), nil)
+
+	// The block marked for deletion is skipped only for the tenant whose grace period has expired.
+	assert.Empty(t, c.Check("user-1", knownBlocks, knownDeletionMarks, nil))
+	assert.Equal(t, []ulid.ULID{block1}, c.Check("user-2", knownBlocks, knownDeletionMarks, nil))
+}
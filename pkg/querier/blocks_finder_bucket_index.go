@@ -15,6 +15,7 @@ import (
 
 	"github.com/cortexproject/cortex/pkg/storage/bucket"
 	"github.com/cortexproject/cortex/pkg/storage/tsdb/bucketindex"
+	"github.com/cortexproject/cortex/pkg/storegateway"
 	"github.com/cortexproject/cortex/pkg/util/services"
 )
 
@@ -28,6 +29,18 @@ type BucketIndexBlocksFinderConfig struct {
 	MaxStalePeriod           time.Duration
 	IgnoreDeletionMarksDelay time.Duration
 	IgnoreBlocksWithin       time.Duration
+
+	// IgnoreBlocksBelowCompactionLevel excludes the blocks with a known compaction level lower than
+	// this value, like the store-gateways do. 0 to disable.
+	IgnoreBlocksBelowCompactionLevel int
+
+	// IgnoreOutOfOrderBlocks excludes the blocks compacted from out-of-order samples, like the
+	// store-gateways do.
+	IgnoreOutOfOrderBlocks bool
+
+	// TenantIgnoreDeletionMarksDelay, if set, resolves the per-tenant deletion marks delay.
+	// A value of 0 falls back to IgnoreDeletionMarksDelay.
+	TenantIgnoreDeletionMarksDelay storegateway.DeletionDelayFunc
 }
 
 // BucketIndexBlocksFinder implements BlocksFinder interface and find blocks in the bucket
@@ -93,9 +106,19 @@ func (f *BucketIndexBlocksFinder) GetBlocks(ctx context.Context, userID string,
 			continue
 		}
 
+		// Exclude the blocks not synced by the store-gateways. This is the same logic as the store-gateway
+		// MinCompactionLevelFilter and IgnoreOutOfOrderBlocksFilter.
+		if f.cfg.IgnoreBlocksBelowCompactionLevel > 0 && block.CompactionLevel > 0 && block.CompactionLevel < f.cfg.IgnoreBlocksBelowCompactionLevel {
+			continue
+		}
+		if f.cfg.IgnoreOutOfOrderBlocks && block.OutOfOrder {
+			continue
+		}
+
 		matchingBlocks[block.ID] = block
 	}
 
+	ignoreDeletionMarksDelay := tenantIgnoreDeletionMarksDelay(userID, f.cfg.IgnoreDeletionMarksDelay, f.cfg.TenantIgnoreDeletionMarksDelay)
 	for _, mark := range idx.BlockDeletionMarks {
 		// Filter deletion marks by matching blocks only.
 		if _, ok := matchingBlocks[mark.ID]; !ok {
@@ -103,7 +126,7 @@ func (f *BucketIndexBlocksFinder) GetBlocks(ctx context.Context, userID string,
 		}
 
 		// Exclude blocks marked for deletion. This is the same logic as Thanos IgnoreDeletionMarkFilter.
-		if time.Since(time.Unix(mark.DeletionTime, 0)).Seconds() > f.cfg.IgnoreDeletionMarksDelay.Seconds() {
+		if time.Since(time.Unix(mark.DeletionTime, 0)).Seconds() > ignoreDeletionMarksDelay.Seconds() {
 			delete(matchingBlocks, mark.ID)
 			continue
 		}
@@ -119,3 +142,14 @@ func (f *BucketIndexBlocksFinder) GetBlocks(ctx context.Context, userID string,
 
 	return blocks, matchingDeletionMarks, nil
 }
+
+// tenantIgnoreDeletionMarksDelay returns the deletion marks delay of the tenant, honoring the
+// per-tenant override resolved by tenantDelay, if set.
+func tenantIgnoreDeletionMarksDelay(userID string, defaultDelay time.Duration, tenantDelay storegateway.DeletionDelayFunc) time.Duration {
+	if tenantDelay != nil {
+		if delay := tenantDelay(userID); delay > 0 {
+			return delay
+		}
+	}
+	return defaultDelay
+}
@@ -274,6 +274,126 @@ func TestBucketIndexBlocksFinder_GetBlocks_BucketIndexIsTooOldWithCustomerKeyErr
 	}
 }
 
+func TestBucketIndexBlocksFinder_GetBlocks_PerTenantIgnoreDeletionMarksDelay(t *testing.T) {
+	ctx := context.Background()
+	bkt, _ := cortex_testutil.PrepareFilesystemBucket(t)
+
+	// The block has been marked for deletion 30 minutes ago for both the tenants.
+	block1 := &bucketindex.Block{ID: ulid.MustNew(1, nil), MinTime: 10, MaxTime: 15}
+	mark1 := &bucketindex.BlockDeletionMark{ID: block1.ID, DeletionTime: time.Now().Add(-30 * time.Minute).Unix()}
+	for _, userID := range []string{"user-1", "user-2"} {
+		require.NoError(t, bucketindex.WriteIndex(ctx, bkt, userID, nil, &bucketindex.Index{
+			Version:            bucketindex.IndexVersion1,
+			Blocks:             bucketindex.Blocks{block1},
+			BlockDeletionMarks: bucketindex.BlockDeletionMarks{mark1},
+			UpdatedAt:          time.Now().Unix(),
+		}))
+	}
+
+	finder := NewBucketIndexBlocksFinder(BucketIndexBlocksFinderConfig{
+		IndexLoader: bucketindex.LoaderConfig{
+			CheckInterval:         time.Minute,
+			UpdateOnStaleInterval: time.Minute,
+			UpdateOnErrorInterval: time.Minute,
+			IdleTimeout:           time.Minute,
+		},
+		MaxStalePeriod:           time.Hour,
+		IgnoreDeletionMarksDelay: time.Hour,
+		TenantIgnoreDeletionMarksDelay: func(userID string) time.Duration {
+			if userID == "user-1" {
+				return 10 * time.Minute
+			}
+			return 0
+		},
+	}, bkt, nil, log.NewNopLogger(), nil)
+	require.NoError(t, services.StartAndAwaitRunning(ctx, finder))
+	t.Cleanup(func() {
+		require.NoError(t, services.StopAndAwaitTerminated(ctx, finder))
+	})
+
+	// The block is excluded for the tenant whose deletion marks delay has expired.
+	blocks, marks, err := finder.GetBlocks(ctx, "user-1", 0, 100, nil)
+	require.NoError(t, err)
+	assert.Empty(t, blocks)
+	assert.Empty(t, marks)
+
+	// The other tenant falls back to the default delay.
+	blocks, marks, err = finder.GetBlocks(ctx, "user-2", 0, 100, nil)
+	require.NoError(t, err)
+	assert.Equal(t, bucketindex.Blocks{block1}, blocks)
+	assert.Equal(t, map[ulid.ULID]*bucketindex.BlockDeletionMark{block1.ID: mark1}, marks)
+}
+
+func TestBucketIndexBlocksFinder_GetBlocks_IgnoreBlocksBelowCompactionLevel(t *testing.T) {
+	const userID = "user-1"
+
+	ctx := context.Background()
+	bkt, _ := cortex_testutil.PrepareFilesystemBucket(t)
+
+	// The block 3 has been indexed before the compaction level was tracked.
+	block1 := &bucketindex.Block{ID: ulid.MustNew(1, nil), MinTime: 10, MaxTime: 15, CompactionLevel: 1}
+	block2 := &bucketindex.Block{ID: ulid.MustNew(2, nil), MinTime: 10, MaxTime: 15, CompactionLevel: 2}
+	block3 := &bucketindex.Block{ID: ulid.MustNew(3, nil), MinTime: 10, MaxTime: 15}
+	require.NoError(t, bucketindex.WriteIndex(ctx, bkt, userID, nil, &bucketindex.Index{
+		Version:   bucketindex.IndexVersion1,
+		Blocks:    bucketindex.Blocks{block1, block2, block3},
+		UpdatedAt: time.Now().Unix(),
+	}))
+
+	finder := NewBucketIndexBlocksFinder(BucketIndexBlocksFinderConfig{
+		IndexLoader: bucketindex.LoaderConfig{
+			CheckInterval:         time.Minute,
+			UpdateOnStaleInterval: time.Minute,
+			UpdateOnErrorInterval: time.Minute,
+			IdleTimeout:           time.Minute,
+		},
+		MaxStalePeriod:                   time.Hour,
+		IgnoreBlocksBelowCompactionLevel: 2,
+	}, bkt, nil, log.NewNopLogger(), nil)
+	require.NoError(t, services.StartAndAwaitRunning(ctx, finder))
+	t.Cleanup(func() {
+		require.NoError(t, services.StopAndAwaitTerminated(ctx, finder))
+	})
+
+	blocks, _, err := finder.GetBlocks(ctx, userID, 0, 100, nil)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, bucketindex.Blocks{block2, block3}, blocks)
+}
+
+func TestBucketIndexBlocksFinder_GetBlocks_IgnoreOutOfOrderBlocks(t *testing.T) {
+	const userID = "user-1"
+
+	ctx := context.Background()
+	bkt, _ := cortex_testutil.PrepareFilesystemBucket(t)
+
+	block1 := &bucketindex.Block{ID: ulid.MustNew(1, nil), MinTime: 10, MaxTime: 15}
+	block2 := &bucketindex.Block{ID: ulid.MustNew(2, nil), MinTime: 10, MaxTime: 15, OutOfOrder: true}
+	require.NoError(t, bucketindex.WriteIndex(ctx, bkt, userID, nil, &bucketindex.Index{
+		Version:   bucketindex.IndexVersion1,
+		Blocks:    bucketindex.Blocks{block1, block2},
+		UpdatedAt: time.Now().Unix(),
+	}))
+
+	finder := NewBucketIndexBlocksFinder(BucketIndexBlocksFinderConfig{
+		IndexLoader: bucketindex.LoaderConfig{
+			CheckInterval:         time.Minute,
+			UpdateOnStaleInterval: time.Minute,
+			UpdateOnErrorInterval: time.Minute,
+			IdleTimeout:           time.Minute,
+		},
+		MaxStalePeriod:         time.Hour,
+		IgnoreOutOfOrderBlocks: true,
+	}, bkt, nil, log.NewNopLogger(), nil)
+	require.NoError(t, services.StartAndAwaitRunning(ctx, finder))
+	t.Cleanup(func() {
+		require.NoError(t, services.StopAndAwaitTerminated(ctx, finder))
+	})
+
+	blocks, _, err := finder.GetBlocks(ctx, userID, 0, 100, nil)
+	require.NoError(t, err)
+	assert.Equal(t, bucketindex.Blocks{block1}, blocks)
+}
+
 func prepareBucketIndexBlocksFinder(t testing.TB, bkt objstore.Bucket) *BucketIndexBlocksFinder {
 	ctx := context.Background()
 	cfg := BucketIndexBlocksFinderConfig{
@@ -49,6 +49,22 @@ type BucketScanBlocksFinderConfig struct {
 	IgnoreDeletionMarksDelay time.Duration
 	IgnoreBlocksWithin       time.Duration
 
+	// IgnoreBlocksBelowCompactionLevel excludes the blocks with a compaction level lower than
+	// this value, like the store-gateways do. 0 to disable.
+	IgnoreBlocksBelowCompactionLevel int
+
+	// IgnoreOutOfOrderBlocks excludes the blocks compacted from out-of-order samples, like the
+	// store-gateways do.
+	IgnoreOutOfOrderBlocks bool
+
+	// IgnoreIncompleteBlocks excludes the blocks whose files are missing or partially uploaded,
+	// like the store-gateways do.
+	IgnoreIncompleteBlocks bool
+
+	// TenantIgnoreDeletionMarksDelay, if set, resolves the per-tenant deletion marks delay.
+	// A value of 0 falls back to IgnoreDeletionMarksDelay.
+	TenantIgnoreDeletionMarksDelay storegateway.DeletionDelayFunc
+
 	BlockDiscoveryStrategy string
 }
 
@@ -359,7 +375,7 @@ func (d *BucketScanBlocksFinder) scanUserBlocks(ctx context.Context, userID stri
 	return res, marks, nil
 }
 
-func (d *BucketScanBlocksFinder) getOrCreateMetaFetcher(userID string) (block.MetadataFetcher, objstore.Bucket, *block.IgnoreDeletionMarkFilter, error) {
+func (d *BucketScanBlocksFinder) getOrCreateMetaFetcher(userID string) (block.MetadataFetcher, objstore.Bucket, *storegateway.IgnoreDeletionMarkFilter, error) {
 	d.fetchersMx.Lock()
 	defer d.fetchersMx.Unlock()
 
@@ -381,7 +397,7 @@ func (d *BucketScanBlocksFinder) getOrCreateMetaFetcher(userID string) (block.Me
 	return fetcher, userBucket, deletionMarkFilter, nil
 }
 
-func (d *BucketScanBlocksFinder) createMetaFetcher(userID string) (block.MetadataFetcher, objstore.Bucket, *block.IgnoreDeletionMarkFilter, error) {
+func (d *BucketScanBlocksFinder) createMetaFetcher(userID string) (block.MetadataFetcher, objstore.Bucket, *storegateway.IgnoreDeletionMarkFilter, error) {
 	userLogger := util_log.WithUserID(userID, d.logger)
 	userBucket := bucket.NewUserBucketClient(userID, d.bucketClient, d.cfgProvider)
 	userReg := prometheus.NewRegistry()
@@ -392,12 +408,25 @@ func (d *BucketScanBlocksFinder) createMetaFetcher(userID string) (block.Metadat
 	// - Deduplicate filter: omitted because it could cause troubles with the consistency check if
 	//   we "hide" source blocks because recently compacted by the compactor before the store-gateway instances
 	//   discover and load the compacted ones.
-	deletionMarkFilter := block.NewIgnoreDeletionMarkFilter(userLogger, userBucket, d.cfg.IgnoreDeletionMarksDelay, d.cfg.MetasConcurrency)
+	deletionMarkFilter := storegateway.NewTenantIgnoreDeletionMarkFilter(userLogger, storegateway.NewPerBlockDeletionMarksReader(userLogger, userBucket, d.cfg.MetasConcurrency), userID, func(userID string) time.Duration {
+		return tenantIgnoreDeletionMarksDelay(userID, d.cfg.IgnoreDeletionMarksDelay, d.cfg.TenantIgnoreDeletionMarksDelay)
+	})
 	filters := []block.MetadataFilter{deletionMarkFilter}
 
 	// Here we filter out the blocks that are too new to query.
 	if d.cfg.IgnoreBlocksWithin > 0 {
-		filters = append(filters, storegateway.NewIgnoreNonQueryableBlocksFilter(d.logger, d.cfg.IgnoreBlocksWithin))
+		filters = append(filters, storegateway.NewIgnoreNonQueryableBlocksFilter(d.logger, d.cfg.IgnoreBlocksWithin, nil))
+	}
+
+	// Filter out the blocks not synced by the store-gateways, which would otherwise fail the consistency check.
+	if d.cfg.IgnoreBlocksBelowCompactionLevel > 0 {
+		filters = append(filters, storegateway.NewMinCompactionLevelFilter(userLogger, d.cfg.IgnoreBlocksBelowCompactionLevel))
+	}
+	if d.cfg.IgnoreOutOfOrderBlocks {
+		filters = append(filters, storegateway.NewIgnoreOutOfOrderBlocksFilter(userLogger))
+	}
+	if d.cfg.IgnoreIncompleteBlocks {
+		filters = append(filters, storegateway.NewIgnoreIncompleteBlocksFilter(userLogger, userBucket, d.cfg.MetasConcurrency))
 	}
 
 	var (
@@ -519,6 +548,6 @@ func logPartialBlocks(userID string, partials map[ulid.ULID]error, logger log.Lo
 
 type userFetcher struct {
 	metadataFetcher    block.MetadataFetcher
-	deletionMarkFilter *block.IgnoreDeletionMarkFilter
+	deletionMarkFilter *storegateway.IgnoreDeletionMarkFilter
 	userBucket         objstore.Bucket
 }
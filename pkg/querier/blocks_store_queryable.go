@@ -37,6 +37,7 @@ import (
 	grpc_metadata "google.golang.org/grpc/metadata"
 
 	"github.com/cortexproject/cortex/pkg/cortexpb"
+	"github.com/cortexproject/cortex/pkg/querier/partialdata"
 	"github.com/cortexproject/cortex/pkg/querier/series"
 	"github.com/cortexproject/cortex/pkg/querier/stats"
 	"github.com/cortexproject/cortex/pkg/querysharding"
@@ -106,11 +107,14 @@ type BlocksStoreLimits interface {
 	MaxChunksPerQueryFromStore(userID string) int
 	StoreGatewayTenantShardSize(userID string) float64
 	QueryStoreAfter(userID string) time.Duration
+	IgnoreDeletionMarksDelay(userID string) time.Duration
 }
 
 type blocksStoreQueryableMetrics struct {
-	storesHit prometheus.Histogram
-	refetches prometheus.Histogram
+	storesHit          prometheus.Histogram
+	refetches          prometheus.Histogram
+	partialDataQueries prometheus.Counter
+	requestTimeouts    prometheus.Counter
 }
 
 func newBlocksStoreQueryableMetrics(reg prometheus.Registerer) *blocksStoreQueryableMetrics {
@@ -133,6 +137,16 @@ func newBlocksStoreQueryableMetrics(reg prometheus.Registerer) *blocksStoreQuery
 			NativeHistogramMaxBucketNumber:  100,
 			NativeHistogramMinResetDuration: time.Hour,
 		}),
+		partialDataQueries: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Namespace: "cortex",
+			Name:      "querier_storegateway_partial_data_queries_total",
+			Help:      "Number of queries returning partial data because some blocks could not be queried from store-gateway instances.",
+		}),
+		requestTimeouts: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Namespace: "cortex",
+			Name:      "querier_storegateway_request_timeouts_total",
+			Help:      "Total number of requests to store-gateway instances which exceeded the store-gateway query timeout.",
+		}),
 	}
 }
 
@@ -150,6 +164,7 @@ type BlocksStoreQueryable struct {
 
 	storeGatewayQueryStatsEnabled           bool
 	storeGatewayConsistencyCheckMaxAttempts int
+	storeGatewayQueryTimeout                time.Duration
 	storeGatewaySeriesBatchSize             int64
 
 	// Subservices manager.
@@ -182,6 +197,7 @@ func NewBlocksStoreQueryable(
 		limits:                                  limits,
 		storeGatewayQueryStatsEnabled:           config.StoreGatewayQueryStatsEnabled,
 		storeGatewayConsistencyCheckMaxAttempts: config.StoreGatewayConsistencyCheckMaxAttempts,
+		storeGatewayQueryTimeout:                config.StoreGatewayQueryTimeout,
 		storeGatewaySeriesBatchSize:             config.StoreGatewaySeriesBatchSize,
 	}
 
@@ -193,7 +209,7 @@ func NewBlocksStoreQueryable(
 func NewBlocksStoreQueryableFromConfig(querierCfg Config, gatewayCfg storegateway.Config, storageCfg cortex_tsdb.BlocksStorageConfig, limits BlocksStoreLimits, logger log.Logger, reg prometheus.Registerer) (*BlocksStoreQueryable, error) {
 	var stores BlocksStoreSet
 
-	bucketClient, err := createCachingBucketClient(context.Background(), storageCfg, gatewayCfg.HedgedRequest.GetHedgedRoundTripper(), "querier", logger, reg)
+	bucketClient, err := createCachingBucketClient(context.Background(), storageCfg, gatewayCfg.BucketFederation, gatewayCfg.HedgedRequest.GetHedgedRoundTripper("querier", reg), "querier", logger, reg)
 	if err != nil {
 		return nil, err
 	}
@@ -207,9 +223,12 @@ func NewBlocksStoreQueryableFromConfig(querierCfg Config, gatewayCfg storegatewa
 				UpdateOnErrorInterval: storageCfg.BucketStore.BucketIndex.UpdateOnErrorInterval,
 				IdleTimeout:           storageCfg.BucketStore.BucketIndex.IdleTimeout,
 			},
-			MaxStalePeriod:           storageCfg.BucketStore.BucketIndex.MaxStalePeriod,
-			IgnoreDeletionMarksDelay: storageCfg.BucketStore.IgnoreDeletionMarksDelay,
-			IgnoreBlocksWithin:       storageCfg.BucketStore.IgnoreBlocksWithin,
+			MaxStalePeriod:                   storageCfg.BucketStore.BucketIndex.MaxStalePeriod,
+			IgnoreDeletionMarksDelay:         storageCfg.BucketStore.IgnoreDeletionMarksDelay,
+			IgnoreBlocksWithin:               storageCfg.BucketStore.IgnoreBlocksWithin,
+			IgnoreBlocksBelowCompactionLevel: storageCfg.BucketStore.IgnoreBlocksBelowCompactionLevel,
+			IgnoreOutOfOrderBlocks:           storageCfg.BucketStore.IgnoreOutOfOrderBlocks,
+			TenantIgnoreDeletionMarksDelay:   limits.IgnoreDeletionMarksDelay,
 		}, bucketClient, limits, logger, reg)
 	} else {
 		usersScanner, err := users.NewScanner(storageCfg.UsersScanner, bucketClient, logger, extprom.WrapRegistererWith(prometheus.Labels{"component": "querier"}, reg))
@@ -217,13 +236,17 @@ func NewBlocksStoreQueryableFromConfig(querierCfg Config, gatewayCfg storegatewa
 			return nil, errors.Wrap(err, "failed to create users scanner for bucket scan blocks finder")
 		}
 		finder = NewBucketScanBlocksFinder(BucketScanBlocksFinderConfig{
-			ScanInterval:             storageCfg.BucketStore.SyncInterval,
-			TenantsConcurrency:       storageCfg.BucketStore.TenantSyncConcurrency,
-			MetasConcurrency:         storageCfg.BucketStore.MetaSyncConcurrency,
-			CacheDir:                 storageCfg.BucketStore.SyncDir,
-			IgnoreDeletionMarksDelay: storageCfg.BucketStore.IgnoreDeletionMarksDelay,
-			IgnoreBlocksWithin:       storageCfg.BucketStore.IgnoreBlocksWithin,
-			BlockDiscoveryStrategy:   storageCfg.BucketStore.BlockDiscoveryStrategy,
+			ScanInterval:                     storageCfg.BucketStore.SyncInterval,
+			TenantsConcurrency:               storageCfg.BucketStore.TenantSyncConcurrency,
+			MetasConcurrency:                 storageCfg.BucketStore.MetaSyncConcurrency,
+			CacheDir:                         storageCfg.BucketStore.SyncDir,
+			IgnoreDeletionMarksDelay:         storageCfg.BucketStore.IgnoreDeletionMarksDelay,
+			IgnoreBlocksWithin:               storageCfg.BucketStore.IgnoreBlocksWithin,
+			IgnoreBlocksBelowCompactionLevel: storageCfg.BucketStore.IgnoreBlocksBelowCompactionLevel,
+			IgnoreOutOfOrderBlocks:           storageCfg.BucketStore.IgnoreOutOfOrderBlocks,
+			IgnoreIncompleteBlocks:           storageCfg.BucketStore.IgnoreIncompleteBlocks,
+			BlockDiscoveryStrategy:           storageCfg.BucketStore.BlockDiscoveryStrategy,
+			TenantIgnoreDeletionMarksDelay:   limits.IgnoreDeletionMarksDelay,
 		}, usersScanner, bucketClient, limits, logger, reg)
 	}
 
@@ -256,14 +279,16 @@ func NewBlocksStoreQueryableFromConfig(querierCfg Config, gatewayCfg storegatewa
 		stores = newBlocksStoreBalancedSet(querierCfg.GetStoreGatewayAddresses(), querierCfg.StoreGatewayClient, logger, reg)
 	}
 
-	consistency := NewBlocksConsistencyChecker(
+	consistency := NewTenantBlocksConsistencyChecker(
 		// Exclude blocks which have been recently uploaded, in order to give enough time to store-gateways
 		// to discover and load them (3 times the sync interval).
 		storageCfg.BucketStore.ConsistencyDelay+(3*storageCfg.BucketStore.SyncInterval),
 		// To avoid any false positive in the consistency check, we do exclude blocks which have been
 		// recently marked for deletion, until the "ignore delay / 2". This means the consistency checker
 		// exclude such blocks about 50% of the time before querier and store-gateway stops querying them.
-		storageCfg.BucketStore.IgnoreDeletionMarksDelay/2,
+		func(userID string) time.Duration {
+			return tenantIgnoreDeletionMarksDelay(userID, storageCfg.BucketStore.IgnoreDeletionMarksDelay, limits.IgnoreDeletionMarksDelay) / 2
+		},
 		logger,
 		reg,
 	)
@@ -313,6 +338,7 @@ func (q *BlocksStoreQueryable) Querier(mint, maxt int64) (storage.Querier, error
 		logger:                                  q.logger,
 		storeGatewayQueryStatsEnabled:           q.storeGatewayQueryStatsEnabled,
 		storeGatewayConsistencyCheckMaxAttempts: q.storeGatewayConsistencyCheckMaxAttempts,
+		storeGatewayQueryTimeout:                q.storeGatewayQueryTimeout,
 		storeGatewaySeriesBatchSize:             q.storeGatewaySeriesBatchSize,
 		nowFn:                                   time.Now,
 	}, nil
@@ -334,6 +360,10 @@ type blocksStoreQuerier struct {
 	// The maximum number of times we attempt fetching missing blocks from different Store Gateways.
 	storeGatewayConsistencyCheckMaxAttempts int
 
+	// The timeout of a single request to a Store Gateway. A request exceeding it is
+	// retried on other Store Gateways by the consistency check.
+	storeGatewayQueryTimeout time.Duration
+
 	// The maximum number of series to be batched in a single gRPC response message from Store Gateways.
 	storeGatewaySeriesBatchSize int64
 
@@ -383,7 +413,10 @@ func (q *blocksStoreQuerier) LabelNames(ctx context.Context, hints *storage.Labe
 	}
 
 	if err := q.queryWithConsistencyCheck(spanCtx, spanLog, minT, maxT, matchers, userID, queryFunc); err != nil {
-		return nil, nil, err
+		if !partialdata.IsPartialDataError(err) {
+			return nil, nil, err
+		}
+		resWarnings.Add(err)
 	}
 
 	return strutil.MergeSlices(int(limit), resNameSets...), resWarnings, nil
@@ -426,7 +459,10 @@ func (q *blocksStoreQuerier) LabelValues(ctx context.Context, name string, hints
 	}
 
 	if err := q.queryWithConsistencyCheck(spanCtx, spanLog, minT, maxT, matchers, userID, queryFunc); err != nil {
-		return nil, nil, err
+		if !partialdata.IsPartialDataError(err) {
+			return nil, nil, err
+		}
+		resWarnings.Add(err)
 	}
 
 	return strutil.MergeSlices(int(limit), resValueSets...), resWarnings, nil
@@ -482,7 +518,10 @@ func (q *blocksStoreQuerier) selectSorted(ctx context.Context, sp *storage.Selec
 	}
 
 	if err := q.queryWithConsistencyCheck(spanCtx, spanLog, minT, maxT, matchers, userID, queryFunc); err != nil {
-		return storage.ErrSeriesSet(err)
+		if !partialdata.IsPartialDataError(err) {
+			return storage.ErrSeriesSet(err)
+		}
+		resWarnings.Add(err)
 	}
 
 	if len(resSeriesSets) == 0 {
@@ -497,11 +536,13 @@ func (q *blocksStoreQuerier) selectSorted(ctx context.Context, sp *storage.Selec
 func (q *blocksStoreQuerier) queryWithConsistencyCheck(ctx context.Context, logger log.Logger, minT, maxT int64, matchers []*labels.Matcher,
 	userID string, queryFunc func(clients map[BlocksStoreClient][]ulid.ULID, minT, maxT int64) ([]ulid.ULID, error, error)) error {
 	queryStoreAfter := q.limits.QueryStoreAfter(userID)
+	queryBlocks, hasQueryBlocks := extractQueryBlocksFromContext(ctx)
 	// If queryStoreAfter is enabled, we do manipulate the query maxt to query samples up until
 	// now - queryStoreAfter, because the most recent time range is covered by ingesters. This
 	// optimization is particularly important for the blocks storage because can be used to skip
-	// querying most recent not-compacted-yet blocks from the storage.
-	if queryStoreAfter > 0 {
+	// querying most recent not-compacted-yet blocks from the storage. The ingesters are not
+	// queried if the query is restricted to specific blocks, so the time range is kept.
+	if queryStoreAfter > 0 && !hasQueryBlocks {
 		now := q.nowFn()
 		origMaxT := maxT
 		maxT = min(maxT, util.TimeToMillis(now.Add(-queryStoreAfter)))
@@ -528,6 +569,10 @@ func (q *blocksStoreQuerier) queryWithConsistencyCheck(ctx context.Context, logg
 		return err
 	}
 
+	if hasQueryBlocks {
+		knownBlocks = filterQueryBlocks(knownBlocks, queryBlocks)
+	}
+
 	if len(knownBlocks) == 0 {
 		q.metrics.storesHit.Observe(0)
 		level.Debug(logger).Log("msg", "no blocks found")
@@ -561,6 +606,12 @@ func (q *blocksStoreQuerier) queryWithConsistencyCheck(ctx context.Context, logg
 				break
 			}
 
+			// If partial data is allowed, none of the blocks is queried instead of failing the query.
+			if partialdata.IsEnabledFromContext(ctx) {
+				level.Warn(logger).Log("msg", "unable to get store-gateway clients to fetch blocks", "err", err)
+				break
+			}
+
 			return err
 		}
 		level.Debug(logger).Log("msg", "found store-gateway instances to query", "num instances", len(clients), "attempt", attempt)
@@ -585,7 +636,7 @@ func (q *blocksStoreQuerier) queryWithConsistencyCheck(ctx context.Context, logg
 		}
 
 		// Ensure all expected blocks have been queried (during all tries done so far).
-		missingBlocks := q.consistency.Check(knownBlocks, knownDeletionMarks, resQueriedBlocks)
+		missingBlocks := q.consistency.Check(userID, knownBlocks, knownDeletionMarks, resQueriedBlocks)
 		if len(missingBlocks) == 0 {
 			q.metrics.storesHit.Observe(float64(len(touchedStores)))
 			q.metrics.refetches.Observe(float64(attempt - 1))
@@ -599,6 +650,20 @@ func (q *blocksStoreQuerier) queryWithConsistencyCheck(ctx context.Context, logg
 		remainingBlocks = missingBlocks
 	}
 
+	// If partial data is allowed, the query is evaluated with the blocks queried so far, and the
+	// missing blocks are reported as a warning.
+	if partialdata.IsEnabledFromContext(ctx) {
+		q.metrics.partialDataQueries.Inc()
+		stats.FromContext(ctx).AddExtraFields(
+			"store_gateway.partial_data", true,
+			"store_gateway.missing_blocks", len(remainingBlocks),
+		)
+
+		err = fmt.Errorf("%w: some blocks could not be queried from store-gateways: %s", partialdata.ErrPartialData, strings.Join(convertULIDsToString(remainingBlocks), " "))
+		level.Warn(util_log.WithContext(ctx, logger)).Log("msg", "failed consistency check, returning partial data", "err", err)
+		return err
+	}
+
 	// After we exhausted retries, if retryable error is not nil return the retryable error.
 	// It can be helpful to know whether we need to retry more or not.
 	if retryableError != nil {
@@ -649,6 +714,9 @@ func (q *blocksStoreQuerier) fetchSeriesFromStores(
 		// Change variables scope since it will be used in a goroutine.
 
 		g.Go(func() error {
+			callCtx, cancel := q.storeGatewayCallContext(gCtx)
+			defer cancel()
+
 			// See: https://github.com/prometheus/prometheus/pull/8050
 			// TODO(goutham): we should ideally be passing the hints down to the storage layer
 			// and let the TSDB return us data with no chunks as in prometheus#8050.
@@ -665,9 +733,9 @@ func (q *blocksStoreQuerier) fetchSeriesFromStores(
 			}
 
 			begin := time.Now()
-			stream, err := c.Series(gCtx, req)
+			stream, err := c.Series(callCtx, req)
 			if err != nil {
-				if isRetryableError(err) {
+				if q.isRetryableStoreGatewayError(callCtx, gCtx, err) {
 					level.Warn(spanLog).Log("err", errors.Wrapf(err, "failed to fetch series from %s due to retryable error", c.RemoteAddress()))
 					merrMtx.Lock()
 					merr.Add(err)
@@ -681,13 +749,16 @@ func (q *blocksStoreQuerier) fetchSeriesFromStores(
 			myWarnings := annotations.Annotations(nil)
 			myQueriedBlocks := []ulid.ULID(nil)
 
-			processSeries := func(s *storepb.Series) error {
-				// Detach series data from the gRPC unmarshal buffer so that it can be freed.
-				sCopy := *s
-				sCopy.Labels = append([]labelpb.ZLabel(nil), s.Labels...)
-				detachSeriesFromBuffer(&sCopy)
-				mySeries = append(mySeries, &sCopy)
+			addFetchedStats := func(series []*storepb.Series) {
+				numSamples, chunksCount := countSamplesAndChunks(series...)
+				reqStats.AddFetchedSeries(uint64(len(series)))
+				reqStats.AddFetchedChunks(chunksCount)
+				reqStats.AddFetchedSamples(numSamples)
+				reqStats.AddFetchedChunkBytes(uint64(countChunkBytes(series...)))
+				reqStats.AddFetchedDataBytes(uint64(countDataBytes(series...)))
+			}
 
+			checkSeriesLimits := func(s *storepb.Series) error {
 				// Add series fingerprint to query limiter; will return error if we are over the limit
 				limitErr := queryLimiter.AddSeries(cortexpb.FromLabelsToLabelAdapters(s.PromLabels()))
 				if limitErr != nil {
@@ -716,6 +787,23 @@ func (q *blocksStoreQuerier) fetchSeriesFromStores(
 				return nil
 			}
 
+			processSeries := func(s *storepb.Series) error {
+				// Check the limits before retaining the series, so that a query is aborted as soon as
+				// the limit is reached. The series fetched so far are tracked in the query stats anyway.
+				if err := checkSeriesLimits(s); err != nil {
+					addFetchedStats(append(mySeries, s))
+					return err
+				}
+
+				// Detach series data from the gRPC unmarshal buffer so that it can be freed.
+				sCopy := *s
+				sCopy.Labels = append([]labelpb.ZLabel(nil), s.Labels...)
+				detachSeriesFromBuffer(&sCopy)
+				mySeries = append(mySeries, &sCopy)
+
+				return nil
+			}
+
 			for {
 				// Ensure the context hasn't been canceled in the meanwhile (eg. an error occurred
 				// in another goroutine).
@@ -728,7 +816,7 @@ func (q *blocksStoreQuerier) fetchSeriesFromStores(
 					break
 				}
 
-				if isRetryableError(err) {
+				if q.isRetryableStoreGatewayError(callCtx, gCtx, err) {
 					level.Warn(spanLog).Log("err", errors.Wrapf(err, "failed to receive series from %s due to retryable error", c.RemoteAddress()))
 					merrMtx.Lock()
 					merr.Add(err)
@@ -791,16 +879,7 @@ func (q *blocksStoreQuerier) fetchSeriesFromStores(
 				}
 			}
 
-			numSeries := len(mySeries)
-			numSamples, chunksCount := countSamplesAndChunks(mySeries...)
-			chunkBytes := countChunkBytes(mySeries...)
-			dataBytes := countDataBytes(mySeries...)
-
-			reqStats.AddFetchedSeries(uint64(numSeries))
-			reqStats.AddFetchedChunks(chunksCount)
-			reqStats.AddFetchedSamples(numSamples)
-			reqStats.AddFetchedChunkBytes(uint64(chunkBytes))
-			reqStats.AddFetchedDataBytes(uint64(dataBytes))
+			addFetchedStats(mySeries)
 			reqStats.AddStoreGatewayTouchedPostings(uint64(seriesQueryStats.PostingsTouched))
 			reqStats.AddStoreGatewayTouchedPostingBytes(uint64(seriesQueryStats.PostingsTouchedSizeSum))
 
@@ -815,8 +894,8 @@ func (q *blocksStoreQuerier) fetchSeriesFromStores(
 			if q.storeGatewayQueryStatsEnabled && seriesQueryStats.BlocksQueried > 0 {
 				level.Info(spanLog).Log("msg", "store gateway series request stats",
 					"instance", c.RemoteAddress(),
-					"queryable_chunk_bytes_fetched", chunkBytes,
-					"queryable_data_bytes_fetched", dataBytes,
+					"queryable_chunk_bytes_fetched", countChunkBytes(mySeries...),
+					"queryable_data_bytes_fetched", countDataBytes(mySeries...),
 					"blocks_queried", seriesQueryStats.BlocksQueried,
 					"series_merged_count", seriesQueryStats.MergedSeriesCount,
 					"chunks_merged_count", seriesQueryStats.MergedChunksCount,
@@ -890,14 +969,17 @@ func (q *blocksStoreQuerier) fetchLabelNamesFromStore(
 		// Change variables scope since it will be used in a goroutine.
 
 		g.Go(func() error {
+			callCtx, cancel := q.storeGatewayCallContext(gCtx)
+			defer cancel()
+
 			req, err := createLabelNamesRequest(minT, maxT, limit, blockIDs, matchers)
 			if err != nil {
 				return errors.Wrapf(err, "failed to create label names request")
 			}
 
-			namesResp, err := c.LabelNames(gCtx, req)
+			namesResp, err := c.LabelNames(callCtx, req)
 			if err != nil {
-				if isRetryableError(err) {
+				if q.isRetryableStoreGatewayError(callCtx, gCtx, err) {
 					level.Warn(spanLog).Log("err", errors.Wrapf(err, "failed to fetch label names from %s due to retryable error", c.RemoteAddress()))
 					merrMtx.Lock()
 					merr.Add(err)
@@ -995,14 +1077,17 @@ func (q *blocksStoreQuerier) fetchLabelValuesFromStore(
 		// Change variables scope since it will be used in a goroutine.
 
 		g.Go(func() error {
+			callCtx, cancel := q.storeGatewayCallContext(gCtx)
+			defer cancel()
+
 			req, err := createLabelValuesRequest(minT, maxT, limit, name, blockIDs, matchers...)
 			if err != nil {
 				return errors.Wrapf(err, "failed to create label values request")
 			}
 
-			valuesResp, err := c.LabelValues(gCtx, req)
+			valuesResp, err := c.LabelValues(callCtx, req)
 			if err != nil {
-				if isRetryableError(err) {
+				if q.isRetryableStoreGatewayError(callCtx, gCtx, err) {
 					level.Warn(spanLog).Log("err", errors.Wrapf(err, "failed to fetch label values from %s due to retryable error", c.RemoteAddress()))
 					merrMtx.Lock()
 					merr.Add(err)
@@ -1247,6 +1332,32 @@ func countSamplesAndChunks(series ...*storepb.Series) (samplesCount, chunksCount
 	return
 }
 
+// storeGatewayCallContext returns the context of a single request to a store-gateway,
+// bounded by the store-gateway query timeout if configured.
+func (q *blocksStoreQuerier) storeGatewayCallContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if q.storeGatewayQueryTimeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, q.storeGatewayQueryTimeout)
+}
+
+// isRetryableStoreGatewayError returns whether the error of a request to a store-gateway is retryable.
+// A request exceeding the store-gateway query timeout while the query is still running is retryable,
+// so that its blocks are fetched from other store-gateways.
+func (q *blocksStoreQuerier) isRetryableStoreGatewayError(callCtx, ctx context.Context, err error) bool {
+	if isCallTimeout(callCtx, ctx) {
+		q.metrics.requestTimeouts.Inc()
+		return true
+	}
+	return isRetryableError(err)
+}
+
+// isCallTimeout returns whether the context of a single call has exceeded its deadline
+// while the parent context is still alive.
+func isCallTimeout(callCtx, ctx context.Context) bool {
+	return errors.Is(callCtx.Err(), context.DeadlineExceeded) && ctx.Err() == nil
+}
+
 // only retry connection issues
 func isRetryableError(err error) bool {
 	switch status.Code(err) {
@@ -40,6 +40,8 @@ import (
 
 	"github.com/cortexproject/cortex/pkg/chunk/encoding"
 	"github.com/cortexproject/cortex/pkg/cortexpb"
+	"github.com/cortexproject/cortex/pkg/querier/partialdata"
+	"github.com/cortexproject/cortex/pkg/querier/stats"
 	"github.com/cortexproject/cortex/pkg/storage/tsdb/bucketindex"
 	"github.com/cortexproject/cortex/pkg/storegateway"
 	"github.com/cortexproject/cortex/pkg/storegateway/storegatewaypb"
@@ -1744,7 +1746,7 @@ func TestBlocksStoreQuerier_Select(t *testing.T) {
 
 			// Assert on metrics (optional, only for test cases defining it).
 			if testData.expectedMetrics != "" {
-				assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(testData.expectedMetrics)))
+				assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(testData.expectedMetrics), "cortex_querier_storegateway_instances_hit_per_query", "cortex_querier_storegateway_refetches_per_query"))
 			}
 		})
 	}
@@ -1793,6 +1795,275 @@ func TestOverrideBlockDiscovery(t *testing.T) {
 	require.NoError(t, ss.Err())
 }
 
+func TestBlocksStoreQuerier_ShouldOnlyQueryTheBlocksOfTheQueryBlocksHeader(t *testing.T) {
+	block1 := ulid.MustNew(1, nil)
+	block2 := ulid.MustNew(2, nil)
+	block3 := ulid.MustNew(3, nil)
+	now := time.Now()
+	minT := int64(10)
+	maxT := util.TimeToMillis(now)
+
+	stores := &blocksStoreSetMock{mockedResponses: []any{
+		map[BlocksStoreClient][]ulid.ULID{
+			&storeGatewayClientMock{remoteAddr: "1.1.1.1", mockedSeriesResponses: []*storepb.SeriesResponse{
+				mockHintsResponse(block1, block3),
+			}}: {block1, block3},
+		},
+	},
+	}
+	finder := &blocksFinderMock{}
+	// The max time isn't manipulated by the query store after, since the ingesters aren't queried.
+	finder.On("GetBlocks", mock.Anything, "user-1", minT, maxT, mock.Anything).Return(bucketindex.Blocks{
+		&bucketindex.Block{ID: block1},
+		&bucketindex.Block{ID: block2},
+		&bucketindex.Block{ID: block3},
+	}, map[ulid.ULID]*bucketindex.BlockDeletionMark(nil), nil)
+
+	q := &blocksStoreQuerier{
+		minT:        minT,
+		maxT:        maxT,
+		finder:      finder,
+		stores:      stores,
+		consistency: NewBlocksConsistencyChecker(0, 0, log.NewNopLogger(), nil),
+		logger:      log.NewNopLogger(),
+		metrics:     newBlocksStoreQueryableMetrics(prometheus.NewPedanticRegistry()),
+		limits:      &blocksStoreLimitsMock{queryStoreAfter: time.Hour},
+		nowFn:       func() time.Time { return now },
+
+		storeGatewayConsistencyCheckMaxAttempts: 3,
+	}
+
+	matchers := []*labels.Matcher{
+		labels.MustNewMatcher(labels.MatchEqual, labels.MetricName, "name"),
+	}
+	ctx := user.InjectOrgID(context.Background(), "user-1")
+	ctx, err := AddQueryBlocksToContext(ctx, fmt.Sprintf("%s, %s", block3, block1))
+	require.NoError(t, err)
+
+	ss := q.Select(ctx, true, nil, matchers...)
+	require.NoError(t, ss.Err())
+	assert.Equal(t, []ulid.ULID{block1, block3}, stores.queriedBlocks)
+}
+
+func TestAddQueryBlocksToContext(t *testing.T) {
+	ctx, err := AddQueryBlocksToContext(context.Background(), "")
+	require.NoError(t, err)
+	_, ok := extractQueryBlocksFromContext(ctx)
+	assert.False(t, ok)
+
+	_, err = AddQueryBlocksToContext(context.Background(), "not-a-ulid")
+	require.ErrorContains(t, err, `invalid block ID "not-a-ulid" in the X-Cortex-Query-Blocks header`)
+}
+
+func TestBlocksStoreQuerier_ShouldReturnPartialDataIfEnabled(t *testing.T) {
+	const (
+		minT = int64(10)
+		maxT = int64(20)
+	)
+
+	var (
+		block1 = ulid.MustNew(1, nil)
+		block2 = ulid.MustNew(2, nil)
+		series = labels.FromStrings(labels.MetricName, "test_metric")
+	)
+
+	newQuerier := func(reg prometheus.Registerer) *blocksStoreQuerier {
+		stores := &blocksStoreSetMock{mockedResponses: []any{
+			// First attempt returns a client whose response does not include all expected blocks.
+			map[BlocksStoreClient][]ulid.ULID{
+				&storeGatewayClientMock{
+					remoteAddr: "1.1.1.1",
+					mockedSeriesResponses: []*storepb.SeriesResponse{
+						mockSeriesResponse(series, []cortexpb.Sample{{Value: 1, TimestampMs: minT}}, nil, nil),
+						mockHintsResponse(block1),
+					},
+					mockedLabelNamesResponse: &storepb.LabelNamesResponse{
+						Names: []string{labels.MetricName},
+						Hints: mockNamesHints(block1),
+					},
+				}: {block1},
+			},
+			// Second attempt returns an error because there are no other store-gateways left.
+			errors.New("no store-gateway remaining after exclude"),
+		}}
+		finder := &blocksFinderMock{}
+		finder.On("GetBlocks", mock.Anything, "user-1", minT, maxT, mock.Anything).Return(bucketindex.Blocks{
+			&bucketindex.Block{ID: block1},
+			&bucketindex.Block{ID: block2},
+		}, map[ulid.ULID]*bucketindex.BlockDeletionMark(nil), nil)
+
+		return &blocksStoreQuerier{
+			minT:        minT,
+			maxT:        maxT,
+			finder:      finder,
+			stores:      stores,
+			consistency: NewBlocksConsistencyChecker(0, 0, log.NewNopLogger(), nil),
+			logger:      log.NewNopLogger(),
+			metrics:     newBlocksStoreQueryableMetrics(reg),
+			limits:      &blocksStoreLimitsMock{},
+
+			storeGatewayConsistencyCheckMaxAttempts: 3,
+		}
+	}
+
+	expectedWarning := fmt.Sprintf("query result may contain partial data: some blocks could not be queried from store-gateways: %s", block2.String())
+	matchers := []*labels.Matcher{
+		labels.MustNewMatcher(labels.MatchEqual, labels.MetricName, "test_metric"),
+	}
+
+	t.Run("select", func(t *testing.T) {
+		reg := prometheus.NewPedanticRegistry()
+		queryStats, ctx := stats.ContextWithEmptyStats(user.InjectOrgID(context.Background(), "user-1"))
+		ctx = limiter.AddQueryLimiterToContext(partialdata.ContextWithEnabled(ctx), limiter.NewQueryLimiter(0, 0, 0, 0))
+
+		set := newQuerier(reg).Select(ctx, true, nil, matchers...)
+		require.True(t, set.Next())
+		assert.Equal(t, series, set.At().Labels())
+		assert.False(t, set.Next())
+		require.NoError(t, set.Err())
+
+		warnings := set.Warnings().AsErrors()
+		require.Len(t, warnings, 1)
+		assert.True(t, partialdata.IsPartialDataError(warnings[0]))
+		assert.EqualError(t, warnings[0], expectedWarning)
+
+		assert.ElementsMatch(t, []any{"store_gateway.partial_data", "true", "store_gateway.missing_blocks", "1"}, queryStats.LoadExtraFields())
+		assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+			# HELP cortex_querier_storegateway_partial_data_queries_total Number of queries returning partial data because some blocks could not be queried from store-gateway instances.
+			# TYPE cortex_querier_storegateway_partial_data_queries_total counter
+			cortex_querier_storegateway_partial_data_queries_total 1
+		`), "cortex_querier_storegateway_partial_data_queries_total"))
+	})
+
+	t.Run("label names", func(t *testing.T) {
+		ctx := partialdata.ContextWithEnabled(user.InjectOrgID(context.Background(), "user-1"))
+
+		names, warnings, err := newQuerier(prometheus.NewPedanticRegistry()).LabelNames(ctx, nil, matchers...)
+		require.NoError(t, err)
+		assert.Equal(t, []string{labels.MetricName}, names)
+		require.Len(t, warnings, 1)
+		assert.EqualError(t, warnings.AsErrors()[0], expectedWarning)
+	})
+
+	t.Run("partial data disabled", func(t *testing.T) {
+		ctx := limiter.AddQueryLimiterToContext(user.InjectOrgID(context.Background(), "user-1"), limiter.NewQueryLimiter(0, 0, 0, 0))
+
+		set := newQuerier(prometheus.NewPedanticRegistry()).Select(ctx, true, nil, matchers...)
+		assert.False(t, set.Next())
+		assert.EqualError(t, set.Err(), fmt.Sprintf("consistency check failed because some blocks were not queried: %s", block2.String()))
+	})
+}
+
+func TestBlocksStoreQuerier_ShouldRetryStoreGatewayRequestsExceedingTimeout(t *testing.T) {
+	const (
+		minT = int64(10)
+		maxT = int64(20)
+	)
+
+	block1 := ulid.MustNew(1, nil)
+	reg := prometheus.NewPedanticRegistry()
+	stores := &blocksStoreSetMock{mockedResponses: []any{
+		// First attempt hits a store-gateway slower than the store-gateway query timeout.
+		map[BlocksStoreClient][]ulid.ULID{
+			&slowStoreGatewayClientMock{storeGatewayClientMock{remoteAddr: "1.1.1.1"}}: {block1},
+		},
+		// Second attempt hits another store-gateway holding the same block.
+		map[BlocksStoreClient][]ulid.ULID{
+			&storeGatewayClientMock{
+				remoteAddr: "2.2.2.2",
+				mockedLabelNamesResponse: &storepb.LabelNamesResponse{
+					Names: []string{labels.MetricName},
+					Hints: mockNamesHints(block1),
+				},
+			}: {block1},
+		},
+	}}
+	finder := &blocksFinderMock{}
+	finder.On("GetBlocks", mock.Anything, "user-1", minT, maxT, mock.Anything).Return(bucketindex.Blocks{
+		&bucketindex.Block{ID: block1},
+	}, map[ulid.ULID]*bucketindex.BlockDeletionMark(nil), nil)
+
+	q := &blocksStoreQuerier{
+		minT:        minT,
+		maxT:        maxT,
+		finder:      finder,
+		stores:      stores,
+		consistency: NewBlocksConsistencyChecker(0, 0, log.NewNopLogger(), nil),
+		logger:      log.NewNopLogger(),
+		metrics:     newBlocksStoreQueryableMetrics(reg),
+		limits:      &blocksStoreLimitsMock{},
+
+		storeGatewayConsistencyCheckMaxAttempts: 3,
+		storeGatewayQueryTimeout:                10 * time.Millisecond,
+	}
+
+	names, _, err := q.LabelNames(user.InjectOrgID(context.Background(), "user-1"), nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{labels.MetricName}, names)
+	assert.Equal(t, []ulid.ULID{block1, block1}, stores.queriedBlocks)
+
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP cortex_querier_storegateway_request_timeouts_total Total number of requests to store-gateway instances which exceeded the store-gateway query timeout.
+		# TYPE cortex_querier_storegateway_request_timeouts_total counter
+		cortex_querier_storegateway_request_timeouts_total 1
+	`), "cortex_querier_storegateway_request_timeouts_total"))
+}
+
+func TestBlocksStoreQuerier_ShouldTrackFetchedStatsWhenChunkBytesLimitIsHit(t *testing.T) {
+	t.Parallel()
+
+	const (
+		minT = int64(10)
+		maxT = int64(20)
+	)
+
+	block1 := ulid.MustNew(1, nil)
+	series1 := mockSeriesResponse(labels.FromStrings(labels.MetricName, "test_metric", "series", "1"), []cortexpb.Sample{{Value: 1, TimestampMs: minT}}, nil, nil)
+	series2 := mockSeriesResponse(labels.FromStrings(labels.MetricName, "test_metric", "series", "2"), []cortexpb.Sample{{Value: 2, TimestampMs: minT}}, nil, nil)
+
+	// The limit allows to fetch the first series only.
+	chunkBytesLimit := countChunkBytes(series1.GetSeries())
+
+	stores := &blocksStoreSetMock{mockedResponses: []any{
+		map[BlocksStoreClient][]ulid.ULID{
+			&storeGatewayClientMock{remoteAddr: "1.1.1.1", mockedSeriesResponses: []*storepb.SeriesResponse{
+				series1,
+				series2,
+				mockHintsResponse(block1),
+			}}: {block1},
+		},
+	}}
+	finder := &blocksFinderMock{}
+	finder.On("GetBlocks", mock.Anything, "user-1", minT, maxT, mock.Anything).Return(bucketindex.Blocks{
+		&bucketindex.Block{ID: block1},
+	}, map[ulid.ULID]*bucketindex.BlockDeletionMark(nil), nil)
+
+	q := &blocksStoreQuerier{
+		minT:        minT,
+		maxT:        maxT,
+		finder:      finder,
+		stores:      stores,
+		consistency: NewBlocksConsistencyChecker(0, 0, log.NewNopLogger(), nil),
+		logger:      log.NewNopLogger(),
+		metrics:     newBlocksStoreQueryableMetrics(prometheus.NewPedanticRegistry()),
+		limits:      &blocksStoreLimitsMock{},
+
+		storeGatewayConsistencyCheckMaxAttempts: 3,
+	}
+
+	queryStats, ctx := stats.ContextWithEmptyStats(user.InjectOrgID(context.Background(), "user-1"))
+	ctx = limiter.AddQueryLimiterToContext(ctx, limiter.NewQueryLimiter(0, chunkBytesLimit, 0, 0))
+
+	set := q.Select(ctx, true, nil, labels.MustNewMatcher(labels.MatchEqual, labels.MetricName, "test_metric"))
+	assert.False(t, set.Next())
+	assert.EqualError(t, set.Err(), validation.LimitError(fmt.Sprintf(limiter.ErrMaxChunkBytesHit, chunkBytesLimit)).Error())
+
+	// The series fetched until the limit has been hit, including the one hitting it, are tracked.
+	assert.Equal(t, uint64(2), queryStats.LoadFetchedSeries())
+	assert.Equal(t, uint64(2), queryStats.LoadFetchedChunks())
+	assert.Equal(t, uint64(countChunkBytes(series1.GetSeries(), series2.GetSeries())), queryStats.LoadFetchedChunkBytes())
+}
+
 func TestBlocksStoreQuerier_Labels(t *testing.T) {
 	t.Parallel()
 
@@ -2345,7 +2616,7 @@ func TestBlocksStoreQuerier_Labels(t *testing.T) {
 
 					// Assert on metrics (optional, only for test cases defining it).
 					if testData.expectedMetrics != "" {
-						assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(testData.expectedMetrics)))
+						assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(testData.expectedMetrics), "cortex_querier_storegateway_instances_hit_per_query", "cortex_querier_storegateway_refetches_per_query"))
 					}
 				}
 
@@ -2366,7 +2637,7 @@ func TestBlocksStoreQuerier_Labels(t *testing.T) {
 
 					// Assert on metrics (optional, only for test cases defining it).
 					if testData.expectedMetrics != "" {
-						assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(testData.expectedMetrics)))
+						assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(testData.expectedMetrics), "cortex_querier_storegateway_instances_hit_per_query", "cortex_querier_storegateway_refetches_per_query"))
 					}
 				}
 			}
@@ -2693,6 +2964,16 @@ func (m *storeGatewayClientMock) RemoteAddress() string {
 	return m.remoteAddr
 }
 
+// slowStoreGatewayClientMock is a store-gateway client whose requests never complete before the context is done.
+type slowStoreGatewayClientMock struct {
+	storeGatewayClientMock
+}
+
+func (m *slowStoreGatewayClientMock) LabelNames(ctx context.Context, _ *storepb.LabelNamesRequest, _ ...grpc.CallOption) (*storepb.LabelNamesResponse, error) {
+	<-ctx.Done()
+	return nil, status.FromContextError(ctx.Err()).Err()
+}
+
 type storeGatewaySeriesClientMock struct {
 	grpc.ClientStream
 
@@ -2732,6 +3013,10 @@ func (m *blocksStoreLimitsMock) QueryStoreAfter(_ string) time.Duration {
 	return m.queryStoreAfter
 }
 
+func (m *blocksStoreLimitsMock) IgnoreDeletionMarksDelay(_ string) time.Duration {
+	return 0
+}
+
 func (m *blocksStoreLimitsMock) S3SSEType(_ string) string {
 	return ""
 }
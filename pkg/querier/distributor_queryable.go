@@ -2,10 +2,14 @@ package querier
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"sort"
 	"time"
 
 	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
 	"github.com/prometheus/common/model"
 	"github.com/prometheus/prometheus/model/exemplar"
 	"github.com/prometheus/prometheus/model/labels"
@@ -18,6 +22,7 @@ import (
 	"github.com/cortexproject/cortex/pkg/ingester/client"
 	"github.com/cortexproject/cortex/pkg/querier/partialdata"
 	"github.com/cortexproject/cortex/pkg/querier/series"
+	"github.com/cortexproject/cortex/pkg/querier/stats"
 	"github.com/cortexproject/cortex/pkg/util"
 	"github.com/cortexproject/cortex/pkg/util/backoff"
 	"github.com/cortexproject/cortex/pkg/util/chunkcompat"
@@ -29,6 +34,8 @@ import (
 const retryMinBackoff = time.Millisecond
 const retryMaxBackoff = 5 * time.Millisecond
 
+var errIngesterQueryTimeout = errors.New("ingester query timed out")
+
 // Distributor is the read interface to the distributor, made an interface here
 // to reduce package coupling.
 type Distributor interface {
@@ -43,7 +50,27 @@ type Distributor interface {
 	MetricsMetadata(ctx context.Context, req *client.MetricsMetadataRequest) ([]scrape.MetricMetadata, error)
 }
 
-func newDistributorQueryable(distributor Distributor, streamingMetdata bool, labelNamesWithMatchers bool, iteratorFn chunkIteratorFunc, isPartialDataEnabled partialdata.IsCfgEnabledFunc, ingesterQueryMaxAttempts int, limits *validation.Overrides, nowFn func() time.Time) QueryableWithFilter {
+type distributorQueryableMetrics struct {
+	retries  prometheus.Counter
+	timeouts prometheus.Counter
+}
+
+func newDistributorQueryableMetrics(reg prometheus.Registerer) *distributorQueryableMetrics {
+	return &distributorQueryableMetrics{
+		retries: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Namespace: "cortex",
+			Name:      "querier_ingester_query_retries_total",
+			Help:      "Total number of queries to ingesters retried after a retryable error or a timeout.",
+		}),
+		timeouts: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Namespace: "cortex",
+			Name:      "querier_ingester_query_timeouts_total",
+			Help:      "Total number of queries to ingesters which exceeded the ingester query timeout.",
+		}),
+	}
+}
+
+func newDistributorQueryable(distributor Distributor, streamingMetdata bool, labelNamesWithMatchers bool, iteratorFn chunkIteratorFunc, isPartialDataEnabled partialdata.IsCfgEnabledFunc, ingesterQueryMaxAttempts int, ingesterQueryTimeout time.Duration, limits *validation.Overrides, nowFn func() time.Time, reg prometheus.Registerer) QueryableWithFilter {
 	if nowFn == nil {
 		nowFn = time.Now
 	}
@@ -54,8 +81,10 @@ func newDistributorQueryable(distributor Distributor, streamingMetdata bool, lab
 		iteratorFn:               iteratorFn,
 		isPartialDataEnabled:     isPartialDataEnabled,
 		ingesterQueryMaxAttempts: ingesterQueryMaxAttempts,
+		ingesterQueryTimeout:     ingesterQueryTimeout,
 		limits:                   limits,
 		nowFn:                    nowFn,
+		metrics:                  newDistributorQueryableMetrics(reg),
 	}
 }
 
@@ -66,8 +95,10 @@ type distributorQueryable struct {
 	iteratorFn               chunkIteratorFunc
 	isPartialDataEnabled     partialdata.IsCfgEnabledFunc
 	ingesterQueryMaxAttempts int
+	ingesterQueryTimeout     time.Duration
 	limits                   *validation.Overrides
 	nowFn                    func() time.Time
+	metrics                  *distributorQueryableMetrics
 }
 
 func (d distributorQueryable) Querier(mint, maxt int64) (storage.Querier, error) {
@@ -80,8 +111,10 @@ func (d distributorQueryable) Querier(mint, maxt int64) (storage.Querier, error)
 		chunkIterFn:              d.iteratorFn,
 		isPartialDataEnabled:     d.isPartialDataEnabled,
 		ingesterQueryMaxAttempts: d.ingesterQueryMaxAttempts,
+		ingesterQueryTimeout:     d.ingesterQueryTimeout,
 		limits:                   d.limits,
 		nowFn:                    d.nowFn,
+		metrics:                  d.metrics,
 	}, nil
 }
 func (d distributorQueryable) UseQueryable(now time.Time, userID string, _, queryMaxT int64) bool {
@@ -98,8 +131,10 @@ type distributorQuerier struct {
 	chunkIterFn              chunkIteratorFunc
 	isPartialDataEnabled     partialdata.IsCfgEnabledFunc
 	ingesterQueryMaxAttempts int
+	ingesterQueryTimeout     time.Duration
 	limits                   *validation.Overrides
 	nowFn                    func() time.Time
+	metrics                  *distributorQueryableMetrics
 }
 
 // Select implements storage.Querier interface.
@@ -148,9 +183,13 @@ func (q *distributorQuerier) Select(ctx context.Context, sortSeries bool, sp *st
 		)
 
 		if q.streamingMetadata {
-			ms, err = q.distributor.MetricsForLabelMatchersStream(ctx, model.Time(minT), model.Time(maxT), sp, partialDataEnabled, matchers...)
+			ms, err = callIngesters(ctx, q, func(ctx context.Context) ([]labels.Labels, error) {
+				return q.distributor.MetricsForLabelMatchersStream(ctx, model.Time(minT), model.Time(maxT), sp, partialDataEnabled, matchers...)
+			})
 		} else {
-			ms, err = q.distributor.MetricsForLabelMatchers(ctx, model.Time(minT), model.Time(maxT), sp, partialDataEnabled, matchers...)
+			ms, err = callIngesters(ctx, q, func(ctx context.Context) ([]labels.Labels, error) {
+				return q.distributor.MetricsForLabelMatchers(ctx, model.Time(minT), model.Time(maxT), sp, partialDataEnabled, matchers...)
+			})
 		}
 
 		if err != nil && !partialdata.IsPartialDataError(err) {
@@ -171,7 +210,7 @@ func (q *distributorQuerier) Select(ctx context.Context, sortSeries bool, sp *st
 }
 
 func (q *distributorQuerier) streamingSelect(ctx context.Context, sortSeries, partialDataEnabled bool, minT, maxT int64, matchers []*labels.Matcher) storage.SeriesSet {
-	results, err := q.queryWithRetry(ctx, func() (*client.QueryStreamResponse, error) {
+	results, err := q.queryWithRetry(ctx, func(ctx context.Context) (*client.QueryStreamResponse, error) {
 		return q.distributor.QueryStream(ctx, model.Time(minT), model.Time(maxT), partialDataEnabled, matchers...)
 	})
 
@@ -215,9 +254,9 @@ func (q *distributorQuerier) streamingSelect(ctx context.Context, sortSeries, pa
 	return seriesSet
 }
 
-func (q *distributorQuerier) queryWithRetry(ctx context.Context, queryFunc func() (*client.QueryStreamResponse, error)) (*client.QueryStreamResponse, error) {
+func (q *distributorQuerier) queryWithRetry(ctx context.Context, queryFunc func(context.Context) (*client.QueryStreamResponse, error)) (*client.QueryStreamResponse, error) {
 	if q.ingesterQueryMaxAttempts <= 1 {
-		return queryFunc()
+		return callIngesters(ctx, q, queryFunc)
 	}
 
 	var result *client.QueryStreamResponse
@@ -230,7 +269,10 @@ func (q *distributorQuerier) queryWithRetry(ctx context.Context, queryFunc func(
 	})
 
 	for retries.Ongoing() {
-		result, err = queryFunc()
+		if retries.NumRetries() > 0 {
+			q.metrics.retries.Inc()
+		}
+		result, err = callIngesters(ctx, q, queryFunc)
 
 		if err == nil || !q.isRetryableError(err) {
 			return result, err
@@ -258,11 +300,11 @@ func (q *distributorQuerier) LabelValues(ctx context.Context, name string, hints
 	partialDataEnabled := q.partialDataEnabled(ctx)
 
 	if q.streamingMetadata {
-		lvs, err = q.labelsWithRetry(ctx, func() ([]string, error) {
+		lvs, err = q.labelsWithRetry(ctx, func(ctx context.Context) ([]string, error) {
 			return q.distributor.LabelValuesForLabelNameStream(ctx, model.Time(q.mint), model.Time(q.maxt), model.LabelName(name), hints, partialDataEnabled, matchers...)
 		})
 	} else {
-		lvs, err = q.labelsWithRetry(ctx, func() ([]string, error) {
+		lvs, err = q.labelsWithRetry(ctx, func(ctx context.Context) ([]string, error) {
 			return q.distributor.LabelValuesForLabelName(ctx, model.Time(q.mint), model.Time(q.maxt), model.LabelName(name), hints, partialDataEnabled, matchers...)
 		})
 	}
@@ -291,11 +333,11 @@ func (q *distributorQuerier) LabelNames(ctx context.Context, hints *storage.Labe
 	)
 
 	if q.streamingMetadata {
-		ln, err = q.labelsWithRetry(ctx, func() ([]string, error) {
+		ln, err = q.labelsWithRetry(ctx, func(ctx context.Context) ([]string, error) {
 			return q.distributor.LabelNamesStream(ctx, model.Time(q.mint), model.Time(q.maxt), hints, partialDataEnabled, matchers...)
 		})
 	} else {
-		ln, err = q.labelsWithRetry(ctx, func() ([]string, error) {
+		ln, err = q.labelsWithRetry(ctx, func(ctx context.Context) ([]string, error) {
 			return q.distributor.LabelNames(ctx, model.Time(q.mint), model.Time(q.maxt), hints, partialDataEnabled, matchers...)
 		})
 	}
@@ -308,9 +350,9 @@ func (q *distributorQuerier) LabelNames(ctx context.Context, hints *storage.Labe
 	return ln, nil, err
 }
 
-func (q *distributorQuerier) labelsWithRetry(ctx context.Context, labelsFunc func() ([]string, error)) ([]string, error) {
+func (q *distributorQuerier) labelsWithRetry(ctx context.Context, labelsFunc func(context.Context) ([]string, error)) ([]string, error) {
 	if q.ingesterQueryMaxAttempts <= 1 {
-		return labelsFunc()
+		return callIngesters(ctx, q, labelsFunc)
 	}
 
 	var result []string
@@ -323,7 +365,10 @@ func (q *distributorQuerier) labelsWithRetry(ctx context.Context, labelsFunc fun
 	})
 
 	for retries.Ongoing() {
-		result, err = labelsFunc()
+		if retries.NumRetries() > 0 {
+			q.metrics.retries.Inc()
+		}
+		result, err = callIngesters(ctx, q, labelsFunc)
 
 		if err == nil || !q.isRetryableError(err) {
 			return result, err
@@ -353,9 +398,13 @@ func (q *distributorQuerier) labelNamesWithMatchers(ctx context.Context, hints *
 	)
 
 	if q.streamingMetadata {
-		ms, err = q.distributor.MetricsForLabelMatchersStream(ctx, model.Time(q.mint), model.Time(q.maxt), labelHintsToSelectHints(hints), partialDataEnabled, matchers...)
+		ms, err = callIngesters(ctx, q, func(ctx context.Context) ([]labels.Labels, error) {
+			return q.distributor.MetricsForLabelMatchersStream(ctx, model.Time(q.mint), model.Time(q.maxt), labelHintsToSelectHints(hints), partialDataEnabled, matchers...)
+		})
 	} else {
-		ms, err = q.distributor.MetricsForLabelMatchers(ctx, model.Time(q.mint), model.Time(q.maxt), labelHintsToSelectHints(hints), partialDataEnabled, matchers...)
+		ms, err = callIngesters(ctx, q, func(ctx context.Context) ([]labels.Labels, error) {
+			return q.distributor.MetricsForLabelMatchers(ctx, model.Time(q.mint), model.Time(q.maxt), labelHintsToSelectHints(hints), partialDataEnabled, matchers...)
+		})
 	}
 
 	if err != nil && !partialdata.IsPartialDataError(err) {
@@ -397,7 +446,26 @@ func (q *distributorQuerier) partialDataEnabled(ctx context.Context) bool {
 }
 
 func (q *distributorQuerier) isRetryableError(err error) bool {
-	return partialdata.IsPartialDataError(err)
+	return partialdata.IsPartialDataError(err) || errors.Is(err, errIngesterQueryTimeout)
+}
+
+// callIngesters runs a single call to the ingesters, bounded by the ingester query timeout if
+// configured. If the call exceeds the ingester query timeout while the query context is still
+// alive, the returned error wraps errIngesterQueryTimeout so that the call can be retried.
+func callIngesters[T any](ctx context.Context, q *distributorQuerier, call func(context.Context) (T, error)) (T, error) {
+	if q.ingesterQueryTimeout <= 0 {
+		return call(ctx)
+	}
+
+	callCtx, cancel := context.WithTimeout(ctx, q.ingesterQueryTimeout)
+	defer cancel()
+
+	result, err := call(callCtx)
+	if err != nil && isCallTimeout(callCtx, ctx) {
+		q.metrics.timeouts.Inc()
+		err = fmt.Errorf("%w: %w", errIngesterQueryTimeout, err)
+	}
+	return result, err
 }
 
 type distributorExemplarQueryable struct {
@@ -430,6 +498,10 @@ func (q *distributorExemplarQuerier) Select(start, end int64, matchers ...[]*lab
 		return nil, err
 	}
 
+	if allResults.Truncated {
+		stats.FromContext(q.ctx).AddExtraFields("exemplars_truncated", true)
+	}
+
 	var e exemplar.QueryResult
 	ret := make([]exemplar.QueryResult, len(allResults.Timeseries))
 	for i, ts := range allResults.Timeseries {
@@ -3,9 +3,12 @@ package querier
 import (
 	"context"
 	"fmt"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/prometheus/common/model"
 	"github.com/prometheus/prometheus/model/labels"
 	"github.com/prometheus/prometheus/storage"
@@ -21,6 +24,7 @@ import (
 	"github.com/cortexproject/cortex/pkg/ingester/client"
 	"github.com/cortexproject/cortex/pkg/querier/batch"
 	"github.com/cortexproject/cortex/pkg/querier/partialdata"
+	"github.com/cortexproject/cortex/pkg/querier/stats"
 	"github.com/cortexproject/cortex/pkg/util"
 	"github.com/cortexproject/cortex/pkg/util/chunkcompat"
 	"github.com/cortexproject/cortex/pkg/util/validation"
@@ -96,7 +100,7 @@ func TestDistributorQuerier_SelectShouldHonorQueryIngestersWithin(t *testing.T)
 				limits.QueryIngestersWithin = model.Duration(testData.queryIngestersWithin)
 				overrides := validation.NewOverrides(limits, nil)
 
-				queryable := newDistributorQueryable(distributor, streamingMetadataEnabled, true, nil, nil, 1, overrides, nil)
+				queryable := newDistributorQueryable(distributor, streamingMetadataEnabled, true, nil, nil, 1, 0, overrides, nil, nil)
 				querier, err := queryable.Querier(testData.queryMinT, testData.queryMaxT)
 				require.NoError(t, err)
 
@@ -136,7 +140,7 @@ func TestDistributorQueryableFilter(t *testing.T) {
 	limits.QueryIngestersWithin = model.Duration(1 * time.Hour)
 	overrides := validation.NewOverrides(limits, nil)
 
-	dq := newDistributorQueryable(d, false, true, nil, nil, 1, overrides, nil)
+	dq := newDistributorQueryable(d, false, true, nil, nil, 1, 0, overrides, nil, nil)
 
 	now := time.Now()
 
@@ -192,7 +196,7 @@ func TestIngesterStreaming(t *testing.T) {
 
 			queryable := newDistributorQueryable(d, true, true, batch.NewChunkMergeIterator, func(string) bool {
 				return partialDataEnabled
-			}, 1, overrides, nil)
+			}, 1, 0, overrides, nil, nil)
 			querier, err := queryable.Querier(mint, maxt)
 			require.NoError(t, err)
 
@@ -363,7 +367,7 @@ func TestDistributorQuerier_Retry(t *testing.T) {
 
 			queryable := newDistributorQueryable(d, true, true, batch.NewChunkMergeIterator, func(string) bool {
 				return true
-			}, ingesterQueryMaxAttempts, overrides, nil)
+			}, ingesterQueryMaxAttempts, 0, overrides, nil, nil)
 			querier, err := queryable.Querier(mint, maxt)
 			require.NoError(t, err)
 
@@ -421,7 +425,7 @@ func TestDistributorQuerier_Select_CancelledContext_NoRetry(t *testing.T) {
 	overrides := validation.NewOverrides(limits, nil)
 	queryable := newDistributorQueryable(d, true, true, batch.NewChunkMergeIterator, func(string) bool {
 		return true
-	}, ingesterQueryMaxAttempts, overrides, nil)
+	}, ingesterQueryMaxAttempts, 0, overrides, nil, nil)
 	querier, err := queryable.Querier(mint, maxt)
 	require.NoError(t, err)
 
@@ -455,7 +459,7 @@ func TestDistributorQuerier_Select_CancelledContext(t *testing.T) {
 	overrides := validation.NewOverrides(limits, nil)
 	queryable := newDistributorQueryable(d, true, true, batch.NewChunkMergeIterator, func(string) bool {
 		return true
-	}, ingesterQueryMaxAttempts, overrides, nil)
+	}, ingesterQueryMaxAttempts, 0, overrides, nil, nil)
 	querier, err := queryable.Querier(mint, maxt)
 	require.NoError(t, err)
 
@@ -480,7 +484,7 @@ func TestDistributorQuerier_Labels_CancelledContext(t *testing.T) {
 	overrides := validation.NewOverrides(limits, nil)
 	queryable := newDistributorQueryable(d, true, true, batch.NewChunkMergeIterator, func(string) bool {
 		return true
-	}, ingesterQueryMaxAttempts, overrides, nil)
+	}, ingesterQueryMaxAttempts, 0, overrides, nil, nil)
 	querier, err := queryable.Querier(mint, maxt)
 	require.NoError(t, err)
 
@@ -495,6 +499,48 @@ func TestDistributorQuerier_Labels_CancelledContext(t *testing.T) {
 	})
 }
 
+func TestDistributorQuerier_IngesterQueryTimeout(t *testing.T) {
+	t.Parallel()
+
+	ctx := user.InjectOrgID(context.Background(), "0")
+	waitForTimeout := func(args mock.Arguments) {
+		<-args.Get(0).(context.Context).Done()
+	}
+
+	for _, maxAttempts := range []int{1, 2} {
+		t.Run(fmt.Sprintf("max attempts %d", maxAttempts), func(t *testing.T) {
+			t.Parallel()
+
+			d := &MockDistributor{}
+			d.On("QueryStream", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Run(waitForTimeout).Return(&client.QueryStreamResponse{}, context.DeadlineExceeded).Once()
+			d.On("QueryStream", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(&client.QueryStreamResponse{}, nil).Once()
+
+			reg := prometheus.NewPedanticRegistry()
+			overrides := validation.NewOverrides(DefaultLimitsConfig(), nil)
+			queryable := newDistributorQueryable(d, true, true, batch.NewChunkMergeIterator, nil, maxAttempts, 10*time.Millisecond, overrides, nil, reg)
+			querier, err := queryable.Querier(mint, maxt)
+			require.NoError(t, err)
+
+			seriesSet := querier.Select(ctx, true, &storage.SelectHints{Start: mint, End: maxt})
+			if maxAttempts == 1 {
+				require.ErrorIs(t, seriesSet.Err(), context.DeadlineExceeded)
+			} else {
+				// The query timed out on the first attempt is retried.
+				require.NoError(t, seriesSet.Err())
+			}
+
+			assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(fmt.Sprintf(`
+				# HELP cortex_querier_ingester_query_retries_total Total number of queries to ingesters retried after a retryable error or a timeout.
+				# TYPE cortex_querier_ingester_query_retries_total counter
+				cortex_querier_ingester_query_retries_total %d
+				# HELP cortex_querier_ingester_query_timeouts_total Total number of queries to ingesters which exceeded the ingester query timeout.
+				# TYPE cortex_querier_ingester_query_timeouts_total counter
+				cortex_querier_ingester_query_timeouts_total 1
+			`, maxAttempts-1))))
+		})
+	}
+}
+
 func TestDistributorQuerier_LabelNames(t *testing.T) {
 	t.Parallel()
 
@@ -537,7 +583,7 @@ func TestDistributorQuerier_LabelNames(t *testing.T) {
 
 					queryable := newDistributorQueryable(d, streamingEnabled, labelNamesWithMatchers, nil, func(string) bool {
 						return partialDataEnabled
-					}, 1, overrides, nil)
+					}, 1, 0, overrides, nil, nil)
 					querier, err := queryable.Querier(mint, maxt)
 					require.NoError(t, err)
 
@@ -625,7 +671,7 @@ func TestDistributorQuerier_QueryIngestersWithinBoundary(t *testing.T) {
 			limits.QueryIngestersWithin = model.Duration(lookback)
 			overrides := validation.NewOverrides(limits, nil)
 
-			queryable := newDistributorQueryable(distributor, false, true, nil, nil, 1, overrides, func() time.Time { return now })
+			queryable := newDistributorQueryable(distributor, false, true, nil, nil, 1, 0, overrides, func() time.Time { return now }, nil)
 			querier, err := queryable.Querier(testData.queryMinT, testData.queryMaxT)
 			require.NoError(t, err)
 
@@ -642,3 +688,33 @@ func TestDistributorQuerier_QueryIngestersWithinBoundary(t *testing.T) {
 		})
 	}
 }
+
+func TestDistributorExemplarQuerier_ShouldRecordTruncationInQueryStats(t *testing.T) {
+	for _, truncated := range []bool{false, true} {
+		t.Run(fmt.Sprintf("truncated=%v", truncated), func(t *testing.T) {
+			d := &MockDistributor{}
+			d.On("QueryExemplars", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(&client.ExemplarQueryResponse{
+				Timeseries: []cortexpb.TimeSeries{{
+					Labels:    []cortexpb.LabelAdapter{{Name: "__name__", Value: "foo"}},
+					Exemplars: []cortexpb.Exemplar{{Labels: []cortexpb.LabelAdapter{{Name: "traceID", Value: "123"}}, TimestampMs: 1, Value: 1}},
+				}},
+				Truncated: truncated,
+			}, nil)
+
+			queryStats, ctx := stats.ContextWithEmptyStats(context.Background())
+			querier, err := newDistributorExemplarQueryable(d).ExemplarQuerier(ctx)
+			require.NoError(t, err)
+
+			res, err := querier.Select(mint, maxt, []*labels.Matcher{labels.MustNewMatcher(labels.MatchEqual, "__name__", "foo")})
+			require.NoError(t, err)
+			require.Len(t, res, 1)
+			require.Len(t, res[0].Exemplars, 1)
+
+			if truncated {
+				assert.Equal(t, map[string]string{"exemplars_truncated": "true"}, queryStats.ExtraFields)
+			} else {
+				assert.Empty(t, queryStats.ExtraFields)
+			}
+		})
+	}
+}
@@ -32,6 +32,7 @@ import (
 	cortex_parquet "github.com/cortexproject/cortex/pkg/storage/parquet"
 	cortex_tsdb "github.com/cortexproject/cortex/pkg/storage/tsdb"
 	"github.com/cortexproject/cortex/pkg/storage/tsdb/bucketindex"
+	"github.com/cortexproject/cortex/pkg/storegateway"
 	"github.com/cortexproject/cortex/pkg/util"
 	"github.com/cortexproject/cortex/pkg/util/limiter"
 	"github.com/cortexproject/cortex/pkg/util/multierror"
@@ -127,7 +128,7 @@ func NewParquetQueryable(
 	logger log.Logger,
 	reg prometheus.Registerer,
 ) (storage.Queryable, error) {
-	bucketClient, err := createCachingBucketClient(context.Background(), storageCfg, nil, "parquet-querier", logger, reg)
+	bucketClient, err := createCachingBucketClient(context.Background(), storageCfg, storegateway.BucketFederationConfig{}, nil, "parquet-querier", logger, reg)
 	if err != nil {
 		return nil, err
 	}
@@ -594,7 +595,7 @@ func (q *parquetQuerierWithFallback) adjustMaxT(ctx context.Context, maxt int64)
 		return maxt
 	}
 	queryStoreAfter := q.limits.QueryStoreAfter(userID)
-	if queryStoreAfter > 0 {
+	if _, ok := extractQueryBlocksFromContext(ctx); queryStoreAfter > 0 && !ok {
 		now := time.Now()
 		maxt = min(maxt, util.TimeToMillis(now.Add(-queryStoreAfter)))
 	}
@@ -624,6 +625,9 @@ func (q *parquetQuerierWithFallback) getBlocks(ctx context.Context, minT, maxT i
 	if err != nil {
 		return nil, nil, err
 	}
+	if ids, ok := extractQueryBlocksFromContext(ctx); ok {
+		blocks = filterQueryBlocks(blocks, ids)
+	}
 
 	useParquet := getBlockStoreType(ctx, q.defaultBlockStoreType) == parquetBlockStore
 	parquetBlocks := make([]*bucketindex.Block, 0, len(blocks))
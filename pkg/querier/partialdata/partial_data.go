@@ -1,13 +1,29 @@
 package partialdata
 
 import (
+	"context"
 	"errors"
 )
 
 type IsCfgEnabledFunc func(userID string) bool
 
+type contextKey int
+
+const enabledCtxKey contextKey = 0
+
 var ErrPartialData = errors.New("query result may contain partial data")
 
 func IsPartialDataError(err error) bool {
 	return errors.Is(err, ErrPartialData)
 }
+
+// ContextWithEnabled returns a context marking that the query may be evaluated with partial data.
+func ContextWithEnabled(ctx context.Context) context.Context {
+	return context.WithValue(ctx, enabledCtxKey, true)
+}
+
+// IsEnabledFromContext returns whether the query may be evaluated with partial data.
+func IsEnabledFromContext(ctx context.Context) bool {
+	enabled, _ := ctx.Value(enabledCtxKey).(bool)
+	return enabled
+}
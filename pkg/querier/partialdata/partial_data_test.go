@@ -1,6 +1,7 @@
 package partialdata
 
 import (
+	"context"
 	"fmt"
 	"testing"
 
@@ -11,3 +12,8 @@ func TestPartialData_ReturnPartialData(t *testing.T) {
 	assert.False(t, IsPartialDataError(fmt.Errorf("")))
 	assert.True(t, IsPartialDataError(ErrPartialData))
 }
+
+func TestPartialData_EnabledFromContext(t *testing.T) {
+	assert.False(t, IsEnabledFromContext(context.Background()))
+	assert.True(t, IsEnabledFromContext(ContextWithEnabled(context.Background())))
+}
@@ -27,6 +27,7 @@ import (
 	"github.com/cortexproject/cortex/pkg/querier/batch"
 	"github.com/cortexproject/cortex/pkg/querier/lazyquery"
 	"github.com/cortexproject/cortex/pkg/querier/partialdata"
+	"github.com/cortexproject/cortex/pkg/querier/series"
 	querier_stats "github.com/cortexproject/cortex/pkg/querier/stats"
 	"github.com/cortexproject/cortex/pkg/util"
 	"github.com/cortexproject/cortex/pkg/util/flagext"
@@ -81,12 +82,18 @@ type Config struct {
 	// The maximum number of times we attempt fetching missing blocks from different Store Gateways.
 	StoreGatewayConsistencyCheckMaxAttempts int `yaml:"store_gateway_consistency_check_max_attempts"`
 
+	// The timeout of a single request to a Store Gateway.
+	StoreGatewayQueryTimeout time.Duration `yaml:"store_gateway_query_timeout"`
+
 	// The maximum number of series to be batched in a single gRPC response message from Store Gateways.
 	StoreGatewaySeriesBatchSize int64 `yaml:"store_gateway_series_batch_size"`
 
 	// The maximum number of times we attempt fetching data from Ingesters.
 	IngesterQueryMaxAttempts int `yaml:"ingester_query_max_attempts"`
 
+	// The timeout of a single query to Ingesters.
+	IngesterQueryTimeout time.Duration `yaml:"ingester_query_timeout"`
+
 	ThanosEngine engine.ThanosEngineConfig `yaml:"thanos_engine"`
 
 	// Ignore max query length check at Querier.
@@ -103,6 +110,9 @@ type Config struct {
 
 	HonorProjectionHints bool `yaml:"honor_projection_hints"`
 
+	// Allow restricting queries to specific blocks, for debugging.
+	DebugQueryBlocksEnabled bool `yaml:"debug_query_blocks_enabled"`
+
 	// Timeout classification flags for converting 5XX to 4XX on expensive queries.
 	TimeoutClassificationEnabled       bool          `yaml:"timeout_classification_enabled"`
 	TimeoutClassificationDeadline      time.Duration `yaml:"timeout_classification_deadline"`
@@ -115,9 +125,12 @@ type Config struct {
 var (
 	errEmptyTimeRange                           = errors.New("empty time range")
 	errUnsupportedResponseCompression           = errors.New("unsupported response compression. Supported compression 'gzip', 'snappy', 'zstd' and '' (disable compression)")
+	errQueryBlocksDisabled                      = errors.New("querying specific blocks is disabled, set -querier.debug-query-blocks-enabled to enable it")
 	errInvalidConsistencyCheckAttempts          = errors.New("store gateway consistency check max attempts should be greater or equal than 1")
 	errInvalidSeriesBatchSize                   = errors.New("store gateway series batch size should be greater or equal than 0")
 	errInvalidIngesterQueryMaxAttempts          = errors.New("ingester query max attempts should be greater or equal than 1")
+	errInvalidStoreGatewayQueryTimeout          = errors.New("store gateway query timeout should be greater or equal than 0")
+	errInvalidIngesterQueryTimeout              = errors.New("ingester query timeout should be greater or equal than 0")
 	errInvalidParquetQueryableDefaultBlockStore = errors.New("unsupported parquet queryable default block store. Supported options are tsdb and parquet")
 
 	errTimeoutClassificationDeadlineNotPositive          = errors.New("timeout_classification_deadline must be positive when timeout classification is enabled")
@@ -153,8 +166,10 @@ func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
 	f.StringVar(&cfg.StoreGatewayAddresses, "querier.store-gateway-addresses", "", "Comma separated list of store-gateway addresses in DNS Service Discovery format. This option should be set when using the blocks storage and the store-gateway sharding is disabled (when enabled, the store-gateway instances form a ring and addresses are picked from the ring).")
 	f.BoolVar(&cfg.StoreGatewayQueryStatsEnabled, "querier.store-gateway-query-stats-enabled", true, "If enabled, store gateway query stats will be logged using `info` log level.")
 	f.IntVar(&cfg.StoreGatewayConsistencyCheckMaxAttempts, "querier.store-gateway-consistency-check-max-attempts", maxFetchSeriesAttempts, "The maximum number of times we attempt fetching missing blocks from different store-gateways. If no more store-gateways are left (ie. due to lower replication factor) than we'll end the retries earlier")
+	f.DurationVar(&cfg.StoreGatewayQueryTimeout, "querier.store-gateway-query-timeout", 0, "The timeout of a single request to a store-gateway. A request exceeding it is retried on other store-gateways holding the same blocks, within the -querier.store-gateway-consistency-check-max-attempts. 0 to disable, the request is then only bounded by the query timeout.")
 	f.Int64Var(&cfg.StoreGatewaySeriesBatchSize, "querier.store-gateway-series-batch-size", 1, "[Experimental] The maximum number of series to be batched in a single gRPC response message from Store Gateways. A value of 0 or 1 disables batching.")
 	f.IntVar(&cfg.IngesterQueryMaxAttempts, "querier.ingester-query-max-attempts", 1, "The maximum number of times we attempt fetching data from ingesters for retryable errors (ex. partial data returned).")
+	f.DurationVar(&cfg.IngesterQueryTimeout, "querier.ingester-query-timeout", 0, "The timeout of a single query to ingesters. A query exceeding it is retried within the -querier.ingester-query-max-attempts. 0 to disable, the query is then only bounded by the query timeout.")
 	f.DurationVar(&cfg.LookbackDelta, "querier.lookback-delta", 5*time.Minute, "Time since the last sample after which a time series is considered stale and ignored by expression evaluations.")
 	f.Int64Var(&cfg.MaxSubQuerySteps, "querier.max-subquery-steps", 0, "Max number of steps allowed for every subquery expression in query. Number of steps is calculated using subquery range / step. A value > 0 enables it.")
 	f.BoolVar(&cfg.IgnoreMaxQueryLength, "querier.ignore-max-query-length", false, "If enabled, ignore max query length check at Querier select method. Users can choose to ignore it since the validation can be done before Querier evaluation like at Query Frontend or Ruler.")
@@ -163,6 +178,7 @@ func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
 	cfg.ParquetShardCache.RegisterFlagsWithPrefix("querier.", f)
 	f.StringVar(&cfg.ParquetQueryableDefaultBlockStore, "querier.parquet-queryable-default-block-store", string(parquetBlockStore), "[Experimental] Parquet queryable's default block store to query. Valid options are tsdb and parquet. If it is set to tsdb, parquet queryable always fallback to store gateway.")
 	f.BoolVar(&cfg.HonorProjectionHints, "querier.honor-projection-hints", false, "[Experimental] If true, querier will honor projection hints and only materialize requested labels. Today, projection is only effective when Parquet Queryable is enabled. Projection is only applied when not querying mixed block types (parquet and non-parquet) and not querying ingesters.")
+	f.BoolVar(&cfg.DebugQueryBlocksEnabled, "querier.debug-query-blocks-enabled", false, "[Experimental] If true, the queries with the X-Cortex-Query-Blocks header, set to a comma-separated list of block ULIDs, only query these blocks of the tenant from the store-gateways, and skip the ingesters. This is a debugging aid: enable it only if the header can be set by administrators only. The query-frontend forwards the header only if it's in -frontend.forward-headers-list, and doesn't cache the results of the queries with the header.")
 	f.BoolVar(&cfg.DistributedExecEnabled, "querier.distributed-exec-enabled", false, "Experimental: Enables distributed execution of queries by passing logical query plan fragments to downstream components.")
 	f.BoolVar(&cfg.ParquetQueryableFallbackDisabled, "querier.parquet-queryable-fallback-disabled", false, "[Experimental] Disable Parquet queryable to fallback queries to Store Gateway if the block is not available as Parquet files but available in TSDB. Setting this to true will disable the fallback and users can remove Store Gateway. But need to make sure Parquet files are created before it is queryable.")
 	f.BoolVar(&cfg.TimeoutClassificationEnabled, "querier.timeout-classification-enabled", false, "If true, classify query timeouts as 4XX (user error) or 5XX (system error) based on phase timing.")
@@ -190,6 +206,14 @@ func (cfg *Config) Validate(monitoredResources flagext.StringSliceCSV) error {
 		return errInvalidIngesterQueryMaxAttempts
 	}
 
+	if cfg.StoreGatewayQueryTimeout < 0 {
+		return errInvalidStoreGatewayQueryTimeout
+	}
+
+	if cfg.IngesterQueryTimeout < 0 {
+		return errInvalidIngesterQueryTimeout
+	}
+
 	if cfg.EnableParquetQueryable {
 		if !slices.Contains(validBlockStoreTypes, blockStoreType(cfg.ParquetQueryableDefaultBlockStore)) {
 			return errInvalidParquetQueryableDefaultBlockStore
@@ -275,13 +299,14 @@ func New(cfg Config, limits *validation.Overrides, distributor Distributor, stor
 		)
 	}
 
-	distributorQueryable := newDistributorQueryable(distributor, cfg.IngesterMetadataStreaming, cfg.IngesterLabelNamesWithMatchers, iteratorFunc, isPartialDataEnabled, cfg.IngesterQueryMaxAttempts, limits, nil)
+	distributorQueryable := newDistributorQueryable(distributor, cfg.IngesterMetadataStreaming, cfg.IngesterLabelNamesWithMatchers, iteratorFunc, isPartialDataEnabled, cfg.IngesterQueryMaxAttempts, cfg.IngesterQueryTimeout, limits, nil, reg)
 
 	ns := make([]QueryableWithFilter, len(stores))
 	for ix, s := range stores {
 		ns[ix] = storeQueryable{
-			QueryableWithFilter: s,
-			limits:              limits,
+			QueryableWithFilter:  s,
+			limits:               limits,
+			isPartialDataEnabled: isPartialDataEnabled,
 		}
 	}
 	queryable := NewQueryable(distributorQueryable, ns, cfg, limits, resourceBasedLimiter, logger, reg)
@@ -317,7 +342,7 @@ func New(cfg Config, limits *validation.Overrides, distributor Distributor, stor
 			return cfg.DefaultEvaluationInterval.Milliseconds()
 		},
 	}
-	queryEngine := engine.New(opts, cfg.ThanosEngine, reg)
+	queryEngine := engine.New(opts, cfg.ThanosEngine, limits, reg)
 
 	// Wrap the engine with eviction support if the registry was created.
 	var eng engine.QueryEngine = queryEngine
@@ -391,6 +416,7 @@ func NewQueryable(distributor QueryableWithFilter, stores []QueryableWithFilter,
 			maxQueryIntoFuture:      cfg.MaxQueryIntoFuture,
 			ignoreMaxQueryLength:    cfg.IgnoreMaxQueryLength,
 			honorProjectionHints:    cfg.HonorProjectionHints,
+			debugQueryBlocksEnabled: cfg.DebugQueryBlocksEnabled,
 			distributor:             distributor,
 			stores:                  stores,
 			limiterHolder:           &limiterHolder{},
@@ -409,6 +435,7 @@ type querier struct {
 	limits                  *validation.Overrides
 	maxQueryIntoFuture      time.Duration
 	honorProjectionHints    bool
+	debugQueryBlocksEnabled bool
 	distributor             QueryableWithFilter
 	stores                  []QueryableWithFilter
 	limiterHolder           *limiterHolder
@@ -437,6 +464,12 @@ func (q querier) setupFromCtx(ctx context.Context) (context.Context, *querier_st
 		return ctx, stats, userID, 0, 0, nil, nil, err
 	}
 
+	// A query restricted to specific blocks only queries them from the stores, regardless of the time range.
+	_, queryBlocks := extractQueryBlocksFromContext(ctx)
+	if queryBlocks && !q.debugQueryBlocksEnabled {
+		return ctx, stats, userID, 0, 0, nil, nil, errQueryBlocksDisabled
+	}
+
 	dqr, err := q.distributor.Querier(mint, maxt)
 	if err != nil {
 		return ctx, stats, userID, 0, 0, nil, nil, err
@@ -444,12 +477,12 @@ func (q querier) setupFromCtx(ctx context.Context) (context.Context, *querier_st
 	metadataQuerier := dqr
 
 	queriers := make([]storage.Querier, 0)
-	if q.distributor.UseQueryable(q.now, userID, mint, maxt) {
+	if !queryBlocks && q.distributor.UseQueryable(q.now, userID, mint, maxt) {
 		queriers = append(queriers, dqr)
 	}
 
 	for _, s := range q.stores {
-		if !s.UseQueryable(q.now, userID, mint, maxt) {
+		if !queryBlocks && !s.UseQueryable(q.now, userID, mint, maxt) {
 			continue
 		}
 
@@ -548,25 +581,32 @@ func (q querier) Select(ctx context.Context, sortSeries bool, sp *storage.Select
 		return queriers[0].Select(ctx, sortSeries, sp, matchers...)
 	}
 
-	sets := make(chan storage.SeriesSet, len(queriers))
-	for _, querier := range queriers {
-		go func(querier storage.Querier) {
+	type prioritySet struct {
+		set      storage.SeriesSet
+		priority int
+	}
+
+	sets := make(chan prioritySet, len(queriers))
+	for i, querier := range queriers {
+		go func(querier storage.Querier, priority int) {
 			// We should always select sorted here as we will need to merge the series
-			sets <- querier.Select(ctx, true, sp, matchers...)
-		}(querier)
+			sets <- prioritySet{set: querier.Select(ctx, true, sp, matchers...), priority: priority}
+		}(querier, i)
 	}
 
-	var result []storage.SeriesSet
+	result := make([]storage.SeriesSet, len(queriers))
 	for range queriers {
 		select {
-		case set := <-sets:
-			result = append(result, set)
+		case s := <-sets:
+			result[s.priority] = series.NewPrioritySeriesSet(s.set, s.priority)
 		case <-ctx.Done():
 			return storage.ErrSeriesSet(ctx.Err())
 		}
 	}
 
-	return storage.NewMergeSeriesSet(result, 0, storage.ChainedSeriesMerge)
+	// Samples with the same timestamp returned by multiple queriers are deduplicated deterministically,
+	// keeping the one from the first querier, so ingesters are preferred over the long-term storage.
+	return storage.NewMergeSeriesSet(result, 0, series.PrioritySeriesMerge)
 }
 
 // LabelValues implements storage.Querier.
@@ -738,7 +778,16 @@ func (querier) Close() error {
 
 type storeQueryable struct {
 	QueryableWithFilter
-	limits *validation.Overrides
+	limits               *validation.Overrides
+	isPartialDataEnabled partialdata.IsCfgEnabledFunc
+}
+
+func (s storeQueryable) Querier(mint, maxt int64) (storage.Querier, error) {
+	q, err := s.QueryableWithFilter.Querier(mint, maxt)
+	if err != nil || s.isPartialDataEnabled == nil {
+		return q, err
+	}
+	return storeQuerier{Querier: q, isPartialDataEnabled: s.isPartialDataEnabled}, nil
 }
 
 func (s storeQueryable) UseQueryable(now time.Time, userID string, queryMinT, queryMaxT int64) bool {
@@ -754,6 +803,33 @@ func (s storeQueryable) UseQueryable(now time.Time, userID string, queryMinT, qu
 	return s.QueryableWithFilter.UseQueryable(now, userID, queryMinT, queryMaxT)
 }
 
+// storeQuerier marks the context of the queries run against the store when
+// they may be evaluated with partial data.
+type storeQuerier struct {
+	storage.Querier
+	isPartialDataEnabled partialdata.IsCfgEnabledFunc
+}
+
+func (q storeQuerier) Select(ctx context.Context, sortSeries bool, sp *storage.SelectHints, matchers ...*labels.Matcher) storage.SeriesSet {
+	return q.Querier.Select(q.withPartialData(ctx), sortSeries, sp, matchers...)
+}
+
+func (q storeQuerier) LabelValues(ctx context.Context, name string, hints *storage.LabelHints, matchers ...*labels.Matcher) ([]string, annotations.Annotations, error) {
+	return q.Querier.LabelValues(q.withPartialData(ctx), name, hints, matchers...)
+}
+
+func (q storeQuerier) LabelNames(ctx context.Context, hints *storage.LabelHints, matchers ...*labels.Matcher) ([]string, annotations.Annotations, error) {
+	return q.Querier.LabelNames(q.withPartialData(ctx), hints, matchers...)
+}
+
+func (q storeQuerier) withPartialData(ctx context.Context) context.Context {
+	userID, err := users.TenantID(ctx)
+	if err != nil || !q.isPartialDataEnabled(userID) {
+		return ctx
+	}
+	return partialdata.ContextWithEnabled(ctx)
+}
+
 type alwaysTrueFilterQueryable struct {
 	storage.Queryable
 }
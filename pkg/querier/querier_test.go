@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/go-kit/log"
+	"github.com/oklog/ulid/v2"
 	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
 	promutil "github.com/prometheus/client_golang/prometheus/testutil"
@@ -37,6 +38,7 @@ import (
 	"github.com/cortexproject/cortex/pkg/ingester/client"
 	cortexparser "github.com/cortexproject/cortex/pkg/parser"
 	"github.com/cortexproject/cortex/pkg/querier/batch"
+	"github.com/cortexproject/cortex/pkg/querier/partialdata"
 	"github.com/cortexproject/cortex/pkg/querier/series"
 	"github.com/cortexproject/cortex/pkg/util"
 	"github.com/cortexproject/cortex/pkg/util/chunkcompat"
@@ -304,7 +306,7 @@ func TestShouldSortSeriesIfQueryingMultipleQueryables(t *testing.T) {
 		limits := DefaultLimitsConfig()
 		testOverrides := validation.NewOverrides(limits, nil)
 
-		distributorQueryable := newDistributorQueryable(distributor, cfg.IngesterMetadataStreaming, cfg.IngesterLabelNamesWithMatchers, batch.NewChunkMergeIterator, nil, 1, testOverrides, nil)
+		distributorQueryable := newDistributorQueryable(distributor, cfg.IngesterMetadataStreaming, cfg.IngesterLabelNamesWithMatchers, batch.NewChunkMergeIterator, nil, 1, 0, testOverrides, nil, nil)
 
 		tCases := []struct {
 			name                 string
@@ -374,6 +376,55 @@ func TestShouldSortSeriesIfQueryingMultipleQueryables(t *testing.T) {
 	}
 }
 
+func TestQuerier_ShouldDeduplicateOverlappingSamplesPreferringIngesters(t *testing.T) {
+	t.Parallel()
+	ctx := user.InjectOrgID(context.Background(), "0")
+	var cfg Config
+	flagext.DefaultValues(&cfg)
+	overrides := validation.NewOverrides(DefaultLimitsConfig(), nil)
+
+	now := model.Now()
+	lbls := labels.FromStrings(model.MetricNameLabel, "foo")
+	mockQueryable := func(samples []model.SamplePair) QueryableWithFilter {
+		return UseAlwaysQueryable(storage.QueryableFunc(func(_, _ int64) (storage.Querier, error) {
+			return &storage.MockQuerier{SelectMockFunction: func(_ bool, _ *storage.SelectHints, _ ...*labels.Matcher) storage.SeriesSet {
+				return series.NewConcreteSeriesSet(true, []storage.Series{series.NewConcreteSeries(lbls, samples)})
+			}}, nil
+		}))
+	}
+
+	// Ingesters and store-gateways return a different value for the same timestamp.
+	distributorQueryable := mockQueryable([]model.SamplePair{{Timestamp: now.Add(-time.Minute), Value: 1}, {Timestamp: now, Value: 2}})
+	storeQueryable := mockQueryable([]model.SamplePair{{Timestamp: now.Add(-2 * time.Minute), Value: 10}, {Timestamp: now.Add(-time.Minute), Value: 20}})
+
+	queryable := NewQueryable(distributorQueryable, []QueryableWithFilter{storeQueryable}, cfg, overrides, nil, log.NewNopLogger(), nil)
+
+	// The result is the same whatever the order the queriers return their series.
+	for i := 0; i < 20; i++ {
+		q, err := queryable.Querier(int64(now.Add(-time.Hour)), int64(now))
+		require.NoError(t, err)
+
+		set := q.Select(ctx, true, nil)
+		require.True(t, set.Next())
+		it := set.At().Iterator(nil)
+
+		var samples []model.SamplePair
+		for it.Next() != chunkenc.ValNone {
+			ts, v := it.At()
+			samples = append(samples, model.SamplePair{Timestamp: model.Time(ts), Value: model.SampleValue(v)})
+		}
+		require.NoError(t, it.Err())
+		require.False(t, set.Next())
+		require.NoError(t, set.Err())
+
+		assert.Equal(t, []model.SamplePair{
+			{Timestamp: now.Add(-2 * time.Minute), Value: 10},
+			{Timestamp: now.Add(-time.Minute), Value: 1},
+			{Timestamp: now, Value: 2},
+		}, samples)
+	}
+}
+
 type tenantLimit struct {
 	MaxFetchedSeriesPerQuery int
 }
@@ -453,7 +504,7 @@ func TestLimits(t *testing.T) {
 		limits := DefaultLimitsConfig()
 		testOverrides := validation.NewOverrides(limits, nil)
 
-		distributorQueryableStreaming := newDistributorQueryable(distributor, cfg.IngesterMetadataStreaming, cfg.IngesterLabelNamesWithMatchers, batch.NewChunkMergeIterator, nil, 1, testOverrides, nil)
+		distributorQueryableStreaming := newDistributorQueryable(distributor, cfg.IngesterMetadataStreaming, cfg.IngesterLabelNamesWithMatchers, batch.NewChunkMergeIterator, nil, 1, 0, testOverrides, nil, nil)
 
 		tCases := []struct {
 			name                 string
@@ -836,13 +887,13 @@ func TestQuerier_ValidateQueryTimeRange_MaxQueryLength(t *testing.T) {
 			query:          "rate(foo[31d])",
 			queryStartTime: time.Now().Add(-time.Hour),
 			queryEndTime:   time.Now(),
-			expected:       errors.New("expanding series: the query time range exceeds the limit (query length: 744h59m59.999s, limit: 720h0m0s)"),
+			expected:       errors.New("expanding series: the query time range exceeds the limit (query length: 744h59m59.999s, max_query_length: 720h0m0s)"),
 		},
 		"should forbid query on large time range over the limit and short rate time window": {
 			query:          "rate(foo[1m])",
 			queryStartTime: time.Now().Add(-maxQueryLength).Add(-time.Hour),
 			queryEndTime:   time.Now(),
-			expected:       errors.New("expanding series: the query time range exceeds the limit (query length: 721h0m59.999s, limit: 720h0m0s)"),
+			expected:       errors.New("expanding series: the query time range exceeds the limit (query length: 721h0m59.999s, max_query_length: 720h0m0s)"),
 		},
 		"max query length check ignored, invalid query is still allowed": {
 			query:                "rate(foo[1m])",
@@ -926,7 +977,7 @@ func TestQuerier_ValidateQueryTimeRange_MaxQueryLength_Series(t *testing.T) {
 	require.NoError(t, err)
 	ss := q.Select(ctx, false, &storage.SelectHints{Func: "series", Start: minT, End: maxT})
 	require.False(t, ss.Next())
-	require.True(t, strings.Contains(ss.Err().Error(), "the query time range exceeds the limit (query length: 721h0m0s, limit: 720h0m0s)"))
+	require.True(t, strings.Contains(ss.Err().Error(), "the query time range exceeds the limit (query length: 721h0m0s, max_query_length: 720h0m0s)"))
 }
 
 func TestQuerier_ValidateQueryTimeRange_MaxQueryLength_Labels(t *testing.T) {
@@ -946,13 +997,13 @@ func TestQuerier_ValidateQueryTimeRange_MaxQueryLength_Labels(t *testing.T) {
 		"time range longer than maxQueryLength": {
 			startTime:            time.Now().Add(-maxQueryLength).Add(-time.Hour),
 			endTime:              time.Now(),
-			expected:             validation.LimitError("expanding series: the query time range exceeds the limit (query length: 721h0m0s, limit: 720h0m0s)"),
+			expected:             validation.LimitError("expanding series: the query time range exceeds the limit (query length: 721h0m0s, max_query_length: 720h0m0s)"),
 			ignoreMaxQueryLength: false,
 		},
 		"time range longer than maxQueryLength and ignoreMaxQueryLength is true": {
 			startTime:            time.Now().Add(-maxQueryLength).Add(-time.Hour),
 			endTime:              time.Now(),
-			expected:             validation.LimitError("expanding series: the query time range exceeds the limit (query length: 721h0m0s, limit: 720h0m0s)"),
+			expected:             validation.LimitError("expanding series: the query time range exceeds the limit (query length: 721h0m0s, max_query_length: 720h0m0s)"),
 			ignoreMaxQueryLength: true,
 		},
 	}
@@ -1643,7 +1694,7 @@ func TestStoreQueryable(t *testing.T) {
 	limits.QueryStoreAfter = model.Duration(time.Hour)
 	overrides := validation.NewOverrides(limits, nil)
 
-	sq := storeQueryable{m, overrides}
+	sq := storeQueryable{QueryableWithFilter: m, limits: overrides}
 
 	require.False(t, sq.UseQueryable(now, "test", util.TimeToMillis(now.Add(-5*time.Minute)), util.TimeToMillis(now)))
 	require.False(t, m.useQueryableCalled)
@@ -1655,6 +1706,37 @@ func TestStoreQueryable(t *testing.T) {
 	require.True(t, m.useQueryableCalled) // storeQueryable wraps QueryableWithFilter, so it must call its UseQueryable method.
 }
 
+func TestStoreQueryable_ShouldMarkPartialDataInContext(t *testing.T) {
+	t.Parallel()
+
+	var partialDataEnabled bool
+	sq := storeQueryable{
+		QueryableWithFilter: UseAlwaysQueryable(storage.QueryableFunc(func(_, _ int64) (storage.Querier, error) {
+			return &partialDataCheckingQuerier{Querier: storage.NoopQuerier(), enabled: &partialDataEnabled}, nil
+		})),
+		isPartialDataEnabled: func(userID string) bool { return userID == "user-1" },
+	}
+
+	q, err := sq.Querier(0, 10)
+	require.NoError(t, err)
+
+	q.Select(user.InjectOrgID(context.Background(), "user-1"), true, nil)
+	require.True(t, partialDataEnabled)
+
+	q.Select(user.InjectOrgID(context.Background(), "user-2"), true, nil)
+	require.False(t, partialDataEnabled)
+}
+
+type partialDataCheckingQuerier struct {
+	storage.Querier
+	enabled *bool
+}
+
+func (q *partialDataCheckingQuerier) Select(ctx context.Context, sortSeries bool, hints *storage.SelectHints, matchers ...*labels.Matcher) storage.SeriesSet {
+	*q.enabled = partialdata.IsEnabledFromContext(ctx)
+	return storage.EmptySeriesSet()
+}
+
 func TestConfig_Validate(t *testing.T) {
 	t.Parallel()
 	tests := map[string]struct {
@@ -1892,11 +1974,11 @@ func TestQuerier_ProjectionHints(t *testing.T) {
 			var distributorQueryable QueryableWithFilter
 			if testData.queryIngesters {
 				// Ingesters will be queried
-				distributorQueryable = newDistributorQueryable(distributor, cfg.IngesterMetadataStreaming, cfg.IngesterLabelNamesWithMatchers, batch.NewChunkMergeIterator, nil, 1, testOverrides, nil)
+				distributorQueryable = newDistributorQueryable(distributor, cfg.IngesterMetadataStreaming, cfg.IngesterLabelNamesWithMatchers, batch.NewChunkMergeIterator, nil, 1, 0, testOverrides, nil, nil)
 			} else {
 				// Ingesters will not be queried (time range is too old)
 				distributorQueryable = UseBeforeTimestampQueryable(
-					newDistributorQueryable(distributor, cfg.IngesterMetadataStreaming, cfg.IngesterLabelNamesWithMatchers, batch.NewChunkMergeIterator, nil, 1, testOverrides, nil),
+					newDistributorQueryable(distributor, cfg.IngesterMetadataStreaming, cfg.IngesterLabelNamesWithMatchers, batch.NewChunkMergeIterator, nil, 1, 0, testOverrides, nil, nil),
 					start.Add(-1*time.Hour),
 				)
 			}
@@ -1939,6 +2021,55 @@ func TestQuerier_ProjectionHints(t *testing.T) {
 	}
 }
 
+func TestQuerier_ShouldOnlyQueryTheStoresIfTheQueryIsRestrictedToBlocks(t *testing.T) {
+	start := time.Now().Add(-2 * time.Hour)
+	end := time.Now()
+	matcher := labels.MustNewMatcher(labels.MatchEqual, labels.MetricName, "test")
+
+	ctx := user.InjectOrgID(context.Background(), "0")
+	ctx, err := AddQueryBlocksToContext(ctx, ulid.MustNew(1, nil).String())
+	require.NoError(t, err)
+
+	for _, enabled := range []bool{false, true} {
+		t.Run(fmt.Sprintf("enabled=%t", enabled), func(t *testing.T) {
+			var cfg Config
+			flagext.DefaultValues(&cfg)
+			cfg.ActiveQueryTrackerDir = ""
+			cfg.DebugQueryBlocksEnabled = enabled
+
+			overrides := validation.NewOverrides(DefaultLimitsConfig(), nil)
+
+			distributor := &MockDistributor{}
+			distributor.On("QueryStream", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(&client.QueryStreamResponse{}, nil)
+			distributorQueryable := &wrappedSampleAndChunkQueryable{
+				QueryableWithFilter: newDistributorQueryable(distributor, cfg.IngesterMetadataStreaming, cfg.IngesterLabelNamesWithMatchers, batch.NewChunkMergeIterator, nil, 1, 0, overrides, nil, nil),
+			}
+
+			// The store isn't queried for the time range, unless the query is restricted to blocks.
+			storeQueryable := &wrappedSampleAndChunkQueryable{
+				QueryableWithFilter: UseBeforeTimestampQueryable(NewMockStoreQueryable(&emptyChunkStore{}), start.Add(-time.Hour)),
+			}
+
+			queryable := NewQueryable(distributorQueryable, []QueryableWithFilter{storeQueryable}, cfg, overrides, nil, log.NewNopLogger(), nil)
+			q, err := queryable.Querier(util.TimeToMillis(start), util.TimeToMillis(end))
+			require.NoError(t, err)
+
+			set := q.Select(ctx, false, &storage.SelectHints{Start: util.TimeToMillis(start), End: util.TimeToMillis(end)}, matcher)
+			require.False(t, set.Next())
+			if !enabled {
+				require.ErrorIs(t, set.Err(), errQueryBlocksDisabled)
+				return
+			}
+			require.NoError(t, set.Err())
+
+			require.Len(t, distributorQueryable.queriers, 1)
+			assert.Empty(t, distributorQueryable.queriers[0].selectCallsArgs)
+			require.Len(t, storeQueryable.queriers, 1)
+			assert.Len(t, storeQueryable.queriers[0].selectCallsArgs, 1)
+		})
+	}
+}
+
 func TestQuerier_ResourceBasedLimiter(t *testing.T) {
 	cfg := Config{}
 	flagext.DefaultValues(&cfg)
@@ -1961,7 +2092,7 @@ func TestQuerier_ResourceBasedLimiter(t *testing.T) {
 	require.NoError(t, err)
 
 	chunkStore := &errDistributor{}
-	distributorQueryable := newDistributorQueryable(chunkStore, cfg.IngesterMetadataStreaming, cfg.IngesterLabelNamesWithMatchers, batch.NewChunkMergeIterator, nil, 1, overrides, nil)
+	distributorQueryable := newDistributorQueryable(chunkStore, cfg.IngesterMetadataStreaming, cfg.IngesterLabelNamesWithMatchers, batch.NewChunkMergeIterator, nil, 1, 0, overrides, nil, nil)
 
 	reg := prometheus.NewPedanticRegistry()
 	queryable := NewQueryable(distributorQueryable, nil, cfg, overrides, resourceBasedLimiter, log.NewNopLogger(), reg)
@@ -2007,7 +2138,7 @@ func TestQuerier_ResourceBasedLimiter_Nil(t *testing.T) {
 	distributor.On("LabelValuesForLabelNameStream", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return([]string{}, nil)
 	distributor.On("LabelNamesStream", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return([]string{}, nil)
 
-	distributorQueryable := newDistributorQueryable(distributor, cfg.IngesterMetadataStreaming, cfg.IngesterLabelNamesWithMatchers, batch.NewChunkMergeIterator, nil, 1, overrides, nil)
+	distributorQueryable := newDistributorQueryable(distributor, cfg.IngesterMetadataStreaming, cfg.IngesterLabelNamesWithMatchers, batch.NewChunkMergeIterator, nil, 1, 0, overrides, nil, nil)
 
 	// nil resourceBasedLimiter should not block queries.
 	queryable := NewQueryable(distributorQueryable, nil, cfg, overrides, nil, log.NewNopLogger(), nil)
@@ -0,0 +1,154 @@
+package querier
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql/parser"
+	"github.com/weaveworks/common/httpgrpc"
+
+	"github.com/cortexproject/cortex/pkg/ingester/client"
+	cortexparser "github.com/cortexproject/cortex/pkg/parser"
+	"github.com/cortexproject/cortex/pkg/util"
+)
+
+// HeadCardinalityQuerier returns the cardinality stats of the ingesters TSDB head.
+type HeadCardinalityQuerier interface {
+	HeadCardinality(ctx context.Context, limit int, matchers ...*labels.Matcher) (*client.CardinalityResponse, error)
+}
+
+// QueryCost is the estimated cost of a query.
+type QueryCost struct {
+	// EstimatedSeries is the number of series touched by the query selectors.
+	EstimatedSeries int `json:"estimatedSeries"`
+	// EstimatedChunks is the number of chunks fetched for the series touched by the query selectors.
+	EstimatedChunks int `json:"estimatedChunks"`
+	// EstimatedShards is the number of queries the query-frontend splits and shards the query into.
+	// It's set by the query-frontend.
+	EstimatedShards   int            `json:"estimatedShards,omitempty"`
+	SplitInterval     string         `json:"splitInterval,omitempty"`
+	VerticalShardSize int            `json:"verticalShardSize,omitempty"`
+	Selectors         []SelectorCost `json:"selectors"`
+}
+
+// SelectorCost is the estimated cost of a query selector.
+type SelectorCost struct {
+	Selector        string `json:"selector"`
+	Start           int64  `json:"start"`
+	End             int64  `json:"end"`
+	EstimatedSeries int    `json:"estimatedSeries"`
+	EstimatedChunks int    `json:"estimatedChunks"`
+}
+
+type queryCostResult struct {
+	Status string    `json:"status"`
+	Data   QueryCost `json:"data"`
+}
+
+// QueryCostEstimator estimates the series and chunks touched by a query without executing it,
+// from the cardinality stats of the ingesters TSDB head. The estimates are approximated: the
+// series are the in-memory series, so the series which haven't been written since the last head
+// compaction aren't accounted for, and the chunks are extrapolated from the in-memory chunks.
+type QueryCostEstimator struct {
+	querier       HeadCardinalityQuerier
+	lookbackDelta time.Duration
+}
+
+// NewQueryCostEstimator makes a new QueryCostEstimator.
+func NewQueryCostEstimator(querier HeadCardinalityQuerier, lookbackDelta time.Duration) *QueryCostEstimator {
+	return &QueryCostEstimator{
+		querier:       querier,
+		lookbackDelta: lookbackDelta,
+	}
+}
+
+// Estimate estimates the cost of each selector of the query evaluated between start and end.
+func (e *QueryCostEstimator) Estimate(ctx context.Context, expr parser.Expr, start, end int64) (QueryCost, error) {
+	cost := QueryCost{Selectors: []SelectorCost{}}
+
+	var (
+		evalRange time.Duration
+		err       error
+	)
+	parser.Inspect(expr, func(node parser.Node, path []parser.Node) error {
+		switch n := node.(type) {
+		case *parser.VectorSelector:
+			s := SelectorCost{Selector: (&parser.VectorSelector{Name: n.Name, LabelMatchers: n.LabelMatchers}).String()}
+			s.Start, s.End = util.GetTimeRangesForSelector(start, end, e.lookbackDelta, n, path, evalRange)
+			evalRange = 0
+
+			if s.EstimatedSeries, s.EstimatedChunks, err = e.EstimateSelector(ctx, s.Start, s.End, n.LabelMatchers...); err != nil {
+				return err
+			}
+			cost.EstimatedSeries += s.EstimatedSeries
+			cost.EstimatedChunks += s.EstimatedChunks
+			cost.Selectors = append(cost.Selectors, s)
+		case *parser.MatrixSelector:
+			evalRange = n.Range
+		}
+		return nil
+	})
+	return cost, err
+}
+
+// EstimateSelector estimates the number of series matching the matchers and the number of their
+// chunks between start and end. The chunks are extrapolated from the number of in-memory chunks
+// of the series over the head time range, and each series has at least a chunk.
+func (e *QueryCostEstimator) EstimateSelector(ctx context.Context, start, end int64, matchers ...*labels.Matcher) (series, chunks int, err error) {
+	stats, err := e.querier.HeadCardinality(ctx, 0, matchers...)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	series, chunks = int(stats.NumSeries), int(stats.NumSeries)
+	if headRange := stats.MaxTimestampMs - stats.MinTimestampMs; headRange > 0 {
+		chunks = max(chunks, int(math.Ceil(float64(stats.NumChunks)*float64(end-start)/float64(headRange))))
+	}
+	return series, chunks, nil
+}
+
+// QueryCostHandler returns the estimated series and chunks touched by the query in input, without
+// executing it. The query is evaluated at the time parameter, or between the start and end parameters
+// if set.
+func QueryCostHandler(e *QueryCostEstimator) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		expr, err := cortexparser.ParseExpr(r.FormValue("query"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		var start, end int64
+		if r.FormValue("start") == "" && r.FormValue("end") == "" {
+			if start, err = util.ParseTimeParam(r, "time", time.Now().Unix()); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			end = start
+		} else {
+			if start, err = util.ParseTime(r.FormValue("start")); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			if end, err = util.ParseTime(r.FormValue("end")); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+
+		cost, err := e.Estimate(r.Context(), expr, start, end)
+		if err != nil {
+			if resp, ok := httpgrpc.HTTPResponseFromError(err); ok {
+				http.Error(w, string(resp.Body), int(resp.Code))
+				return
+			}
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		util.WriteJSONResponse(w, queryCostResult{Status: statusSuccess, Data: cost})
+	})
+}
@@ -0,0 +1,114 @@
+package querier
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cortexproject/cortex/pkg/ingester/client"
+)
+
+type mockHeadCardinalityQuerier struct {
+	resp *client.CardinalityResponse
+	err  error
+}
+
+func (m mockHeadCardinalityQuerier) HeadCardinality(context.Context, int, ...*labels.Matcher) (*client.CardinalityResponse, error) {
+	return m.resp, m.err
+}
+
+func TestQueryCostEstimator_EstimateSelector(t *testing.T) {
+	tests := map[string]struct {
+		stats          *client.CardinalityResponse
+		start, end     int64
+		expectedSeries int
+		expectedChunks int
+	}{
+		"no series": {
+			stats: &client.CardinalityResponse{},
+			end:   time.Hour.Milliseconds(),
+		},
+		"empty head time range": {
+			stats:          &client.CardinalityResponse{NumSeries: 10, NumChunks: 10},
+			end:            time.Hour.Milliseconds(),
+			expectedSeries: 10,
+			expectedChunks: 10,
+		},
+		"chunks extrapolated from the head chunks": {
+			stats:          &client.CardinalityResponse{NumSeries: 10, NumChunks: 20, MinTimestampMs: 0, MaxTimestampMs: 2 * time.Hour.Milliseconds()},
+			start:          0,
+			end:            24 * time.Hour.Milliseconds(),
+			expectedSeries: 10,
+			expectedChunks: 240,
+		},
+		"at least a chunk per series": {
+			stats:          &client.CardinalityResponse{NumSeries: 10, NumChunks: 20, MinTimestampMs: 0, MaxTimestampMs: 2 * time.Hour.Milliseconds()},
+			start:          0,
+			end:            5 * time.Minute.Milliseconds(),
+			expectedSeries: 10,
+			expectedChunks: 10,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			e := NewQueryCostEstimator(mockHeadCardinalityQuerier{resp: test.stats}, 5*time.Minute)
+
+			series, chunks, err := e.EstimateSelector(context.Background(), test.start, test.end, labels.MustNewMatcher(labels.MatchEqual, labels.MetricName, "up"))
+			require.NoError(t, err)
+			assert.Equal(t, test.expectedSeries, series)
+			assert.Equal(t, test.expectedChunks, chunks)
+		})
+	}
+}
+
+func TestQueryCostHandler(t *testing.T) {
+	stats := &client.CardinalityResponse{NumSeries: 2, NumChunks: 2, MaxTimestampMs: time.Hour.Milliseconds()}
+
+	request := func(t *testing.T, q mockHeadCardinalityQuerier, params url.Values) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		QueryCostHandler(NewQueryCostEstimator(q, 5*time.Minute)).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/query_cost?"+params.Encode(), nil))
+		return rec
+	}
+
+	t.Run("should estimate the cost of each query selector", func(t *testing.T) {
+		rec := request(t, mockHeadCardinalityQuerier{resp: stats}, url.Values{"query": []string{`up / rate(foo[1h] offset 1h)`}, "start": []string{"3600"}, "end": []string{"7200"}})
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var actual queryCostResult
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &actual))
+		assert.Equal(t, queryCostResult{
+			Status: statusSuccess,
+			Data: QueryCost{
+				EstimatedSeries: 4,
+				EstimatedChunks: 7,
+				Selectors: []SelectorCost{
+					{Selector: `up`, Start: 3300000, End: 7200000, EstimatedSeries: 2, EstimatedChunks: 3},
+					{Selector: `foo`, Start: -3600000, End: 3600000, EstimatedSeries: 2, EstimatedChunks: 4},
+				},
+			},
+		}, actual)
+	})
+
+	t.Run("should fail on invalid parameters", func(t *testing.T) {
+		rec := request(t, mockHeadCardinalityQuerier{resp: stats}, url.Values{"query": []string{`up{`}})
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+		rec = request(t, mockHeadCardinalityQuerier{resp: stats}, url.Values{"query": []string{`up`}, "start": []string{"x"}, "end": []string{"7200"}})
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("should fail if the stats can't be fetched", func(t *testing.T) {
+		rec := request(t, mockHeadCardinalityQuerier{err: errors.New("failed")}, url.Values{"query": []string{`up`}})
+		assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	})
+}
@@ -0,0 +1,185 @@
+package series
+
+import (
+	"slices"
+
+	"github.com/prometheus/prometheus/model/histogram"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/prometheus/prometheus/tsdb/chunkenc"
+)
+
+// NewPrioritySeriesSet wraps the series of a SeriesSet with the given priority,
+// which is used by PrioritySeriesMerge to pick a sample among overlapping ones.
+// Lower values have higher priority.
+func NewPrioritySeriesSet(set storage.SeriesSet, priority int) storage.SeriesSet {
+	return &prioritySeriesSet{SeriesSet: set, priority: priority}
+}
+
+type prioritySeriesSet struct {
+	storage.SeriesSet
+	priority int
+}
+
+func (s *prioritySeriesSet) At() storage.Series {
+	return &prioritySeries{Series: s.SeriesSet.At(), priority: s.priority}
+}
+
+type prioritySeries struct {
+	storage.Series
+	priority int
+}
+
+// PrioritySeriesMerge is a storage.VerticalSeriesMergeFunc merging series like storage.ChainedSeriesMerge,
+// except that when multiple series have a sample for the same timestamp, the sample of the series with the
+// highest priority is deterministically kept, instead of a random one.
+func PrioritySeriesMerge(series ...storage.Series) storage.Series {
+	if len(series) == 0 {
+		return nil
+	}
+
+	series = slices.Clone(series)
+	slices.SortStableFunc(series, func(a, b storage.Series) int {
+		return seriesPriority(a) - seriesPriority(b)
+	})
+
+	return &storage.SeriesEntry{
+		Lset: series[0].Labels(),
+		SampleIteratorFn: func(_ chunkenc.Iterator) chunkenc.Iterator {
+			iterators := make([]chunkenc.Iterator, 0, len(series))
+			for _, s := range series {
+				iterators = append(iterators, s.Iterator(nil))
+			}
+			return newPrioritySampleIterator(iterators)
+		},
+	}
+}
+
+func seriesPriority(s storage.Series) int {
+	if ps, ok := s.(*prioritySeries); ok {
+		return ps.priority
+	}
+	return 0
+}
+
+// timestamp is a sample timestamp in milliseconds. The Seek method of chunkenc.Iterator takes it
+// as argument, instead of an int64, so that it isn't mistaken for io.Seeker.
+type timestamp = int64
+
+// prioritySampleIterator iterates over the samples of multiple iterators in timestamp order. When multiple
+// iterators have a sample for the same timestamp, the sample of the first iterator is kept and the others
+// are dropped.
+type prioritySampleIterator struct {
+	iterators []chunkenc.Iterator
+	// Value type of the current sample of each iterator, ValNone once it's exhausted.
+	types []chunkenc.ValueType
+
+	started     bool
+	curr        int
+	consecutive bool
+}
+
+func newPrioritySampleIterator(iterators []chunkenc.Iterator) *prioritySampleIterator {
+	return &prioritySampleIterator{
+		iterators: iterators,
+		types:     make([]chunkenc.ValueType, len(iterators)),
+		curr:      -1,
+	}
+}
+
+func (p *prioritySampleIterator) Next() chunkenc.ValueType {
+	if !p.started {
+		p.started = true
+		for i, it := range p.iterators {
+			p.types[i] = it.Next()
+		}
+		return p.selectNext()
+	}
+	if p.curr < 0 {
+		return chunkenc.ValNone
+	}
+
+	// Move past the current timestamp all the iterators positioned on it.
+	currT := p.iterators[p.curr].AtT()
+	for i, it := range p.iterators {
+		if p.types[i] != chunkenc.ValNone && it.AtT() == currT {
+			p.types[i] = it.Next()
+		}
+	}
+	return p.selectNext()
+}
+
+func (p *prioritySampleIterator) Seek(t timestamp) chunkenc.ValueType {
+	if p.started && p.curr < 0 {
+		return chunkenc.ValNone
+	}
+	if p.started && p.iterators[p.curr].AtT() >= t {
+		return p.types[p.curr]
+	}
+
+	for i, it := range p.iterators {
+		if !p.started || (p.types[i] != chunkenc.ValNone && it.AtT() < t) {
+			p.types[i] = it.Seek(t)
+		}
+	}
+	p.started = true
+	return p.selectNext()
+}
+
+// selectNext picks the iterator with the lowest timestamp, preferring the first one in case of ties.
+func (p *prioritySampleIterator) selectNext() chunkenc.ValueType {
+	prev := p.curr
+	p.curr = -1
+	for i, it := range p.iterators {
+		if p.types[i] == chunkenc.ValNone {
+			if it.Err() != nil {
+				return chunkenc.ValNone
+			}
+			continue
+		}
+		if p.curr < 0 || it.AtT() < p.iterators[p.curr].AtT() {
+			p.curr = i
+		}
+	}
+	if p.curr < 0 {
+		return chunkenc.ValNone
+	}
+	p.consecutive = p.curr == prev
+	return p.types[p.curr]
+}
+
+func (p *prioritySampleIterator) At() (int64, float64) {
+	return p.iterators[p.curr].At()
+}
+
+func (p *prioritySampleIterator) AtHistogram(h *histogram.Histogram) (int64, *histogram.Histogram) {
+	t, h := p.iterators[p.curr].AtHistogram(h)
+	// If the current sample comes from a different iterator than the previous one,
+	// we cannot be sure anymore about counter resets for counter histograms.
+	if !p.consecutive && h.CounterResetHint != histogram.GaugeType {
+		h.CounterResetHint = histogram.UnknownCounterReset
+	}
+	return t, h
+}
+
+func (p *prioritySampleIterator) AtFloatHistogram(fh *histogram.FloatHistogram) (int64, *histogram.FloatHistogram) {
+	t, fh := p.iterators[p.curr].AtFloatHistogram(fh)
+	// If the current sample comes from a different iterator than the previous one,
+	// we cannot be sure anymore about counter resets for counter histograms.
+	if !p.consecutive && fh.CounterResetHint != histogram.GaugeType {
+		fh.CounterResetHint = histogram.UnknownCounterReset
+	}
+	return t, fh
+}
+
+func (p *prioritySampleIterator) AtT() int64 {
+	return p.iterators[p.curr].AtT()
+}
+
+func (p *prioritySampleIterator) Err() error {
+	for _, it := range p.iterators {
+		if err := it.Err(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package series
+
+import (
+	"testing"
+
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/prometheus/prometheus/tsdb/chunkenc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrioritySeriesMerge(t *testing.T) {
+	lbls := labels.FromStrings("__name__", "foo")
+
+	high := NewConcreteSeries(lbls, []model.SamplePair{{Timestamp: 1, Value: 1}, {Timestamp: 2, Value: 2}, {Timestamp: 4, Value: 4}})
+	low := NewConcreteSeries(lbls, []model.SamplePair{{Timestamp: 2, Value: 20}, {Timestamp: 3, Value: 30}, {Timestamp: 4, Value: 40}, {Timestamp: 5, Value: 50}})
+
+	expected := []model.SamplePair{{Timestamp: 1, Value: 1}, {Timestamp: 2, Value: 2}, {Timestamp: 3, Value: 30}, {Timestamp: 4, Value: 4}, {Timestamp: 5, Value: 50}}
+
+	// The result doesn't depend on the order the series are given.
+	for _, input := range [][]storage.Series{
+		{&prioritySeries{Series: high, priority: 0}, &prioritySeries{Series: low, priority: 1}},
+		{&prioritySeries{Series: low, priority: 1}, &prioritySeries{Series: high, priority: 0}},
+	} {
+		merged := PrioritySeriesMerge(input...)
+		assert.Equal(t, lbls, merged.Labels())
+		assert.Equal(t, expected, iterateSamples(t, merged.Iterator(nil)))
+	}
+}
+
+func TestPrioritySampleIterator_Seek(t *testing.T) {
+	lbls := labels.FromStrings("__name__", "foo")
+
+	high := NewConcreteSeries(lbls, []model.SamplePair{{Timestamp: 1, Value: 1}, {Timestamp: 4, Value: 4}})
+	low := NewConcreteSeries(lbls, []model.SamplePair{{Timestamp: 2, Value: 20}, {Timestamp: 4, Value: 40}, {Timestamp: 6, Value: 60}})
+
+	it := newPrioritySampleIterator([]chunkenc.Iterator{high.Iterator(nil), low.Iterator(nil)})
+
+	require.Equal(t, chunkenc.ValFloat, it.Seek(3))
+	ts, v := it.At()
+	assert.Equal(t, int64(4), ts)
+	assert.Equal(t, float64(4), v)
+
+	// Seeking to a timestamp before the current sample doesn't move the iterator.
+	require.Equal(t, chunkenc.ValFloat, it.Seek(2))
+	assert.Equal(t, int64(4), it.AtT())
+
+	require.Equal(t, chunkenc.ValFloat, it.Next())
+	ts, v = it.At()
+	assert.Equal(t, int64(6), ts)
+	assert.Equal(t, float64(60), v)
+
+	require.Equal(t, chunkenc.ValNone, it.Next())
+	require.Equal(t, chunkenc.ValNone, it.Seek(10))
+	require.NoError(t, it.Err())
+}
+
+func iterateSamples(t *testing.T, it chunkenc.Iterator) []model.SamplePair {
+	var samples []model.SamplePair
+	for it.Next() != chunkenc.ValNone {
+		ts, v := it.At()
+		samples = append(samples, model.SamplePair{Timestamp: model.Time(ts), Value: model.SampleValue(v)})
+	}
+	require.NoError(t, it.Err())
+	return samples
+}
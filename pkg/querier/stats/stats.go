@@ -29,6 +29,7 @@ type QueryStats struct {
 	DataSelectMaxTime   int64
 	DataSelectMinTime   int64
 	SplitInterval       time.Duration
+	VerticalShardSize   int64
 	m                   sync.Mutex
 
 	// Phase tracking fields for timeout classification.
@@ -320,6 +321,22 @@ func (s *QueryStats) LoadSplitInterval() time.Duration {
 	return s.SplitInterval
 }
 
+func (s *QueryStats) SetVerticalShardSize(verticalShardSize int64) {
+	if s == nil {
+		return
+	}
+
+	atomic.StoreInt64(&s.VerticalShardSize, verticalShardSize)
+}
+
+func (s *QueryStats) LoadVerticalShardSize() int64 {
+	if s == nil {
+		return 0
+	}
+
+	return atomic.LoadInt64(&s.VerticalShardSize)
+}
+
 // SetQueryStart records when the query began execution.
 func (s *QueryStats) SetQueryStart(t time.Time) {
 	if s == nil {
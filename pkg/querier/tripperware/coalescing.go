@@ -0,0 +1,131 @@
+package tripperware
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/cortexproject/cortex/pkg/util/users"
+)
+
+const (
+	coalescingOutcomeHit      = "hit"
+	coalescingOutcomeFallback = "fallback"
+)
+
+// coalescedCall is an in-flight query whose result is shared with the identical queries.
+type coalescedCall struct {
+	done chan struct{}
+	resp Response
+	err  error
+}
+
+// CoalescingMiddleware coalesces the concurrent identical queries: the first query is executed,
+// while the identical ones received before it completes wait for its result. A query waits at most
+// followerTimeout for the result, then it's executed independently, like when the first query fails.
+type CoalescingMiddleware struct {
+	followerTimeout time.Duration
+
+	mtx      sync.Mutex
+	inflight map[string]*coalescedCall
+
+	coalescedQueries *prometheus.CounterVec
+}
+
+// NewCoalescingMiddleware makes a new CoalescingMiddleware.
+func NewCoalescingMiddleware(followerTimeout time.Duration, registerer prometheus.Registerer) *CoalescingMiddleware {
+	return &CoalescingMiddleware{
+		followerTimeout: followerTimeout,
+		inflight:        map[string]*coalescedCall{},
+		coalescedQueries: promauto.With(registerer).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_query_frontend_coalesced_queries_total",
+			Help: "Total number of queries received while an identical query was in-flight, by outcome. A hit is served by the result of the in-flight query, a fallback is executed independently.",
+		}, []string{"outcome"}),
+	}
+}
+
+// Wrap implements Middleware.
+func (c *CoalescingMiddleware) Wrap(next Handler) Handler {
+	return HandlerFunc(func(ctx context.Context, req Request) (Response, error) {
+		tenantIDs, err := users.TenantIDs(ctx)
+		if err != nil {
+			return next.Do(ctx, req)
+		}
+		key, ok := coalescingKey(users.JoinTenantIDs(tenantIDs), req)
+		if !ok {
+			return next.Do(ctx, req)
+		}
+
+		c.mtx.Lock()
+		call, ok := c.inflight[key]
+		if !ok {
+			call = &coalescedCall{done: make(chan struct{})}
+			c.inflight[key] = call
+		}
+		c.mtx.Unlock()
+
+		if !ok {
+			// The call is completed even if the query panics, so that the followers don't wait for it.
+			defer func() {
+				c.mtx.Lock()
+				delete(c.inflight, key)
+				c.mtx.Unlock()
+				close(call.done)
+			}()
+
+			resp, err := next.Do(ctx, req)
+			// The followers get a copy of the response, because the returned one is modified while being encoded.
+			if err == nil && resp != nil {
+				call.resp = proto.Clone(resp).(Response)
+			}
+			call.err = err
+			return resp, err
+		}
+
+		timer := time.NewTimer(c.followerTimeout)
+		defer timer.Stop()
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-timer.C:
+		case <-call.done:
+			if call.err == nil && call.resp != nil {
+				c.coalescedQueries.WithLabelValues(coalescingOutcomeHit).Inc()
+				// The response is cloned because it's shared among the followers.
+				return proto.Clone(call.resp).(Response), nil
+			}
+		}
+
+		c.coalescedQueries.WithLabelValues(coalescingOutcomeFallback).Inc()
+		return next.Do(ctx, req)
+	})
+}
+
+// coalescingKey returns the key identifying the identical queries of a tenant, built from every
+// request parameter and forwarded header, and whether the request can be coalesced.
+func coalescingKey(userID string, req Request) (string, bool) {
+	promReq, ok := req.(*PrometheusRequest)
+	if !ok {
+		return "", false
+	}
+
+	b := strings.Builder{}
+	fmt.Fprintf(&b, "%s\x00%s\x00%s\x00%d\x00%d\x00%d\x00%d\x00%s\x00%s\x00%t",
+		userID, promReq.Path, promReq.Query, promReq.Time, promReq.Start, promReq.End, promReq.Step,
+		promReq.Timeout, promReq.Stats, promReq.CachingOptions.Disabled)
+
+	names := slices.Sorted(maps.Keys(promReq.Headers))
+	for _, name := range names {
+		fmt.Fprintf(&b, "\x00%s=%s", name, strings.Join(promReq.Headers[name], ","))
+	}
+	return b.String(), true
+}
@@ -0,0 +1,201 @@
+package tripperware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+	"go.uber.org/atomic"
+
+	"github.com/cortexproject/cortex/pkg/util/test"
+)
+
+func TestCoalescingMiddleware(t *testing.T) {
+	req := &PrometheusRequest{Query: "up", Start: 1000, End: 2000, Step: 10}
+	resp := &PrometheusResponse{Status: StatusSuccess, Data: PrometheusData{ResultType: "matrix"}}
+
+	for name, tc := range map[string]struct {
+		followerTimeout time.Duration
+		leaderErr       error
+		releaseLeader   bool
+		expectedCalls   int64
+		expectedOutcome string
+	}{
+		"followers are served by the result of the in-flight query": {
+			followerTimeout: time.Minute,
+			releaseLeader:   true,
+			expectedCalls:   1,
+			expectedOutcome: coalescingOutcomeHit,
+		},
+		"followers are executed independently if the in-flight query fails": {
+			followerTimeout: time.Minute,
+			leaderErr:       errors.New("failed"),
+			releaseLeader:   true,
+			expectedCalls:   4,
+			expectedOutcome: coalescingOutcomeFallback,
+		},
+		"followers are executed independently once their timeout elapsed": {
+			followerTimeout: 100 * time.Millisecond,
+			expectedCalls:   4,
+			expectedOutcome: coalescingOutcomeFallback,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			var (
+				calls         atomic.Int64
+				leaderStarted = make(chan struct{})
+				releaseLeader = make(chan struct{})
+			)
+			handler := HandlerFunc(func(ctx context.Context, _ Request) (Response, error) {
+				if calls.Inc() == 1 {
+					close(leaderStarted)
+					<-releaseLeader
+					return resp, tc.leaderErr
+				}
+				return resp, nil
+			})
+
+			m := NewCoalescingMiddleware(tc.followerTimeout, prometheus.NewPedanticRegistry())
+			h := m.Wrap(handler)
+			ctx := user.InjectOrgID(context.Background(), "user-1")
+
+			leaderDone := make(chan struct{})
+			go func() {
+				defer close(leaderDone)
+				_, _ = h.Do(ctx, req)
+			}()
+			<-leaderStarted
+
+			wg := sync.WaitGroup{}
+			for range 3 {
+				wg.Go(func() {
+					res, err := h.Do(ctx, req)
+					require.NoError(t, err)
+					assert.Equal(t, resp, res)
+				})
+			}
+
+			// Wait until the followers are waiting for the in-flight query.
+			time.Sleep(50 * time.Millisecond)
+			if tc.releaseLeader {
+				close(releaseLeader)
+				wg.Wait()
+			} else {
+				wg.Wait()
+				close(releaseLeader)
+			}
+			<-leaderDone
+
+			assert.Equal(t, tc.expectedCalls, calls.Load())
+			assert.Equal(t, 3.0, testutil.ToFloat64(m.coalescedQueries.WithLabelValues(tc.expectedOutcome)))
+		})
+	}
+}
+
+func TestCoalescingMiddleware_ShouldCompleteTheCallIfTheQueryPanics(t *testing.T) {
+	req := &PrometheusRequest{Query: "up", Start: 1000, End: 2000, Step: 10}
+	m := NewCoalescingMiddleware(time.Minute, nil)
+	h := m.Wrap(HandlerFunc(func(context.Context, Request) (Response, error) {
+		panic("query failed")
+	}))
+	ctx := user.InjectOrgID(context.Background(), "user-1")
+
+	require.Panics(t, func() {
+		_, _ = h.Do(ctx, req)
+	})
+	assert.Empty(t, m.inflight)
+}
+
+func TestCoalescingMiddleware_ShouldNotShareTheResponseOfTheInFlightQuery(t *testing.T) {
+	req := &PrometheusRequest{Query: "up", Start: 1000, End: 2000, Step: 10}
+	releaseLeader := make(chan struct{})
+	var calls atomic.Int64
+	m := NewCoalescingMiddleware(time.Minute, nil)
+	h := m.Wrap(HandlerFunc(func(context.Context, Request) (Response, error) {
+		calls.Inc()
+		<-releaseLeader
+		return &PrometheusResponse{Status: StatusSuccess, Data: PrometheusData{
+			ResultType: "matrix",
+			Result:     PrometheusQueryResult{Result: &PrometheusQueryResult_Matrix{Matrix: &Matrix{}}},
+		}}, nil
+	}))
+	ctx := user.InjectOrgID(context.Background(), "user-1")
+
+	// Each response is modified while it's encoded, like the codec does, concurrently with the others.
+	encode := func(res Response) {
+		promRes := res.(*PrometheusResponse)
+		promRes.Headers = append(promRes.Headers, &PrometheusResponseHeader{Name: "X-Test", Values: []string{"a"}})
+		_, err := json.Marshal(promRes)
+		require.NoError(t, err)
+	}
+
+	wg := sync.WaitGroup{}
+	wg.Go(func() {
+		res, err := h.Do(ctx, req)
+		require.NoError(t, err)
+		encode(res)
+	})
+	test.Poll(t, time.Second, int64(1), func() any { return calls.Load() })
+
+	for range 3 {
+		wg.Go(func() {
+			res, err := h.Do(ctx, req)
+			require.NoError(t, err)
+			encode(res)
+			assert.Len(t, res.(*PrometheusResponse).Headers, 1)
+		})
+	}
+
+	// Wait until the followers are waiting for the in-flight query.
+	time.Sleep(50 * time.Millisecond)
+	close(releaseLeader)
+	wg.Wait()
+	assert.Equal(t, int64(1), calls.Load())
+}
+
+func TestCoalescingKey(t *testing.T) {
+	req := &PrometheusRequest{
+		Path:    "/api/v1/query_range",
+		Query:   "up",
+		Start:   1000,
+		End:     2000,
+		Step:    10,
+		Headers: http.Header{"X-Forwarded": []string{"a"}},
+	}
+	key := func(userID string, r Request) string {
+		k, ok := coalescingKey(userID, r)
+		require.True(t, ok)
+		return k
+	}
+	expected := key("user-1", req)
+
+	same := *req
+	assert.Equal(t, expected, key("user-1", &same))
+
+	assert.NotEqual(t, expected, key("user-2", req))
+	assert.NotEqual(t, expected, key("user-1", req.WithQuery("down")))
+	assert.NotEqual(t, expected, key("user-1", req.WithStartEnd(1000, 3000)))
+	assert.NotEqual(t, expected, key("user-1", req.WithStats("all")))
+
+	for _, modify := range []func(r *PrometheusRequest){
+		func(r *PrometheusRequest) { r.Path = "/api/v1/query" },
+		func(r *PrometheusRequest) { r.Time = 1500 },
+		func(r *PrometheusRequest) { r.Step = 20 },
+		func(r *PrometheusRequest) { r.Timeout = time.Minute },
+		func(r *PrometheusRequest) { r.CachingOptions.Disabled = true },
+		func(r *PrometheusRequest) { r.Headers = http.Header{"X-Forwarded": []string{"b"}} },
+		func(r *PrometheusRequest) { r.Headers = http.Header{"X-Other": []string{"a"}} },
+	} {
+		modified := *req
+		modify(&modified)
+		assert.NotEqual(t, expected, key("user-1", &modified))
+	}
+}
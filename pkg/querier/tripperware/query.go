@@ -587,6 +587,75 @@ func (s *PrometheusData) MarshalJSON() ([]byte, error) {
 	}
 }
 
+// matrixResponseFlushThreshold is the number of encoded bytes buffered before being flushed to
+// the writer by EncodeMatrixResponse.
+const matrixResponseFlushThreshold = 64 * 1024
+
+// EncodeMatrixResponse writes the JSON encoding of the input matrix response to w, flushing
+// the sample streams as they're encoded, so that the whole encoded response is never held in
+// memory. The output is the same as the one of json.Marshal().
+func EncodeMatrixResponse(w io.Writer, resp *PrometheusResponse) error {
+	stream := json.BorrowStream(w)
+	defer json.ReturnStream(stream)
+
+	stream.WriteObjectStart()
+	stream.WriteObjectField("status")
+	stream.WriteString(resp.Status)
+	stream.WriteMore()
+	stream.WriteObjectField("data")
+	stream.WriteObjectStart()
+	stream.WriteObjectField("resultType")
+	stream.WriteString(resp.Data.ResultType)
+	stream.WriteMore()
+	stream.WriteObjectField("result")
+	stream.WriteArrayStart()
+	sampleStreams := resp.Data.Result.GetMatrix().GetSampleStreams()
+	for i := range sampleStreams {
+		if i > 0 {
+			stream.WriteMore()
+		}
+		stream.WriteVal(&sampleStreams[i])
+		if stream.Buffered() >= matrixResponseFlushThreshold {
+			if err := stream.Flush(); err != nil {
+				return err
+			}
+		}
+	}
+	stream.WriteArrayEnd()
+	if resp.Data.Stats != nil {
+		stream.WriteMore()
+		stream.WriteObjectField("stats")
+		stream.WriteVal(resp.Data.Stats)
+	}
+	stream.WriteObjectEnd()
+	if resp.ErrorType != "" {
+		stream.WriteMore()
+		stream.WriteObjectField("errorType")
+		stream.WriteString(resp.ErrorType)
+	}
+	if resp.Error != "" {
+		stream.WriteMore()
+		stream.WriteObjectField("error")
+		stream.WriteString(resp.Error)
+	}
+	if len(resp.Warnings) > 0 {
+		stream.WriteMore()
+		stream.WriteObjectField("warnings")
+		stream.WriteVal(resp.Warnings)
+	}
+	if len(resp.Infos) > 0 {
+		stream.WriteMore()
+		stream.WriteObjectField("infos")
+		stream.WriteVal(resp.Infos)
+	}
+	stream.WriteObjectEnd()
+
+	if stream.Error != nil {
+		return stream.Error
+	}
+	return stream.Flush()
+}
+
 // Adapted from https://github.com/prometheus/client_golang/blob/4b158abea9470f75b6f07460cdc2189b91914562/api/prometheus/v1/api.go#L84.
 func UnmarshalSampleHistogramPairJSON(ptr unsafe.Pointer, iter *jsoniter.Iterator) {
 	p := (*SampleHistogramPair)(ptr)
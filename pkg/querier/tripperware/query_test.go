@@ -1,6 +1,8 @@
 package tripperware
 
 import (
+	"bytes"
+	"errors"
 	"math"
 	"strconv"
 	"testing"
@@ -193,3 +195,54 @@ func generateData(timeseries, datapoints int) (floatMatrix, histogramMatrix []*S
 	}
 	return
 }
+
+func TestEncodeMatrixResponse(t *testing.T) {
+	sampleStreams := make([]SampleStream, 0, 1000)
+	for i := range 1000 {
+		sampleStreams = append(sampleStreams, SampleStream{
+			Labels:  []cortexpb.LabelAdapter{{Name: "__name__", Value: "foo"}, {Name: "series", Value: strconv.Itoa(i)}},
+			Samples: []cortexpb.Sample{{TimestampMs: 1000, Value: float64(i)}, {TimestampMs: 2000, Value: float64(i)}},
+		})
+	}
+
+	resp := &PrometheusResponse{
+		Status: StatusSuccess,
+		Data: PrometheusData{
+			ResultType: model.ValMatrix.String(),
+			Result:     PrometheusQueryResult{Result: &PrometheusQueryResult_Matrix{Matrix: &Matrix{SampleStreams: sampleStreams}}},
+			Stats:      &PrometheusResponseStats{Samples: &PrometheusResponseSamplesStats{TotalQueryableSamples: 2000, PeakSamples: 1000}},
+		},
+		Warnings: []string{"warning"},
+		Infos:    []string{"info"},
+	}
+
+	expected, err := json.Marshal(resp)
+	require.NoError(t, err)
+
+	// The response is bigger than the flush threshold, so it's written in multiple chunks.
+	w := &countingWriter{}
+	require.NoError(t, EncodeMatrixResponse(w, resp))
+	require.Equal(t, string(expected), w.buf.String())
+	require.Greater(t, w.writes, 1)
+
+	// Errors writing the response are returned.
+	require.ErrorIs(t, EncodeMatrixResponse(&failingWriter{}, resp), errWriteFailed)
+}
+
+var errWriteFailed = errors.New("write failed")
+
+type countingWriter struct {
+	buf    bytes.Buffer
+	writes int
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.writes++
+	return w.buf.Write(p)
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write(_ []byte) (int, error) {
+	return 0, errWriteFailed
+}
@@ -179,7 +179,7 @@ func TestLimitsMiddleware_MaxQueryLength(t *testing.T) {
 			maxQueryLength: thirtyDays,
 			reqStartTime:   now.Add(-4 * thirtyDays),
 			reqEndTime:     now.Add(-2 * thirtyDays),
-			expectedErr:    "the query time range exceeds the limit",
+			expectedErr:    "the query time range exceeds the limit (query length: 1440h0m0s, max_query_length: 720h0m0s)",
 		},
 		"shouldn't exceed time range when having multiple selects with offset": {
 			query:          `rate(up[5m]) + rate(up[5m] offset 40d) + rate(up[5m] offset 80d)`,
@@ -0,0 +1,202 @@
+package queryrange
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/thanos-io/thanos/pkg/querysharding"
+	"github.com/weaveworks/common/httpgrpc"
+
+	"github.com/cortexproject/cortex/pkg/api/queryapi"
+	cortexparser "github.com/cortexproject/cortex/pkg/parser"
+	"github.com/cortexproject/cortex/pkg/querier"
+	"github.com/cortexproject/cortex/pkg/querier/tripperware"
+	"github.com/cortexproject/cortex/pkg/util"
+)
+
+const queryCostPathSuffix = "/query_cost"
+
+type queryCostResponse struct {
+	Status string            `json:"status"`
+	Data   querier.QueryCost `json:"data"`
+}
+
+// NewQueryCostTripperware returns a Tripperware serving the query cost estimates of the
+// query_cost API, and passing through all other requests. The query isn't executed: the
+// series and chunks touched by its selectors are estimated by the querier, and the shards are
+// estimated with the same split by interval and vertical sharding as the query range requests.
+func NewQueryCostTripperware(cfg Config, limits tripperware.Limits, queryAnalyzer querysharding.Analyzer, lookbackDelta time.Duration) tripperware.Tripperware {
+	intervalFn := staticIntervalFn(cfg)
+	if cfg.DynamicQuerySplitsConfig.MaxShardsPerQuery > 0 || cfg.DynamicQuerySplitsConfig.MaxFetchedDataDurationPerQuery > 0 {
+		intervalFn = dynamicIntervalFn(cfg, limits, queryAnalyzer, lookbackDelta)
+	}
+
+	return func(next http.RoundTripper) http.RoundTripper {
+		e := queryCostEstimator{
+			next:          next,
+			cfg:           cfg,
+			limits:        limits,
+			queryAnalyzer: queryAnalyzer,
+			intervalFn:    intervalFn,
+		}
+
+		return tripperware.RoundTripFunc(func(r *http.Request) (*http.Response, error) {
+			if !strings.HasSuffix(r.URL.Path, queryCostPathSuffix) {
+				return next.RoundTrip(r)
+			}
+			return e.roundTrip(r)
+		})
+	}
+}
+
+type queryCostEstimator struct {
+	next          http.RoundTripper
+	cfg           Config
+	limits        tripperware.Limits
+	queryAnalyzer querysharding.Analyzer
+	intervalFn    IntervalFn
+}
+
+func (e queryCostEstimator) roundTrip(r *http.Request) (*http.Response, error) {
+	req, err := parseQueryCostRequest(r)
+	if err != nil {
+		return nil, httpgrpc.Errorf(http.StatusBadRequest, "%s", err.Error())
+	}
+
+	if _, err := cortexparser.ParseExpr(req.Query); err != nil {
+		return nil, httpgrpc.Errorf(http.StatusBadRequest, "%s", err.Error())
+	}
+
+	shards, splitInterval, verticalShardSize, err := e.estimateShards(r.Context(), req)
+	if err != nil {
+		return nil, err
+	}
+
+	cost, err := e.estimateSelectors(r, req)
+	if err != nil {
+		return nil, err
+	}
+	cost.EstimatedShards, cost.SplitInterval, cost.VerticalShardSize = shards, splitInterval, verticalShardSize
+
+	body, err := json.Marshal(queryCostResponse{Status: StatusSuccess, Data: cost})
+	if err != nil {
+		return nil, httpgrpc.Errorf(http.StatusInternalServerError, "%s", err.Error())
+	}
+	return &http.Response{
+		StatusCode:    http.StatusOK,
+		Header:        http.Header{"Content-Type": []string{"application/json"}},
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+	}, nil
+}
+
+// parseQueryCostRequest parses a range query request if the start and end parameters
+// are set, or an instant query request otherwise.
+func parseQueryCostRequest(r *http.Request) (*tripperware.PrometheusRequest, error) {
+	req := &tripperware.PrometheusRequest{Query: r.FormValue("query")}
+	if req.Query == "" {
+		return nil, fmt.Errorf("missing query parameter")
+	}
+
+	if r.FormValue("start") == "" && r.FormValue("end") == "" {
+		ts, err := util.ParseTimeParam(r, "time", time.Now().Unix())
+		if err != nil {
+			return nil, queryapi.DecorateWithParamName(err, "time")
+		}
+		req.Start, req.End = ts, ts
+		return req, nil
+	}
+
+	var err error
+	if req.Start, err = util.ParseTime(r.FormValue("start")); err != nil {
+		return nil, queryapi.DecorateWithParamName(err, "start")
+	}
+	if req.End, err = util.ParseTime(r.FormValue("end")); err != nil {
+		return nil, queryapi.DecorateWithParamName(err, "end")
+	}
+	if req.End < req.Start {
+		return nil, queryapi.ErrEndBeforeStart
+	}
+	if req.Step, err = util.ParseDurationMs(r.FormValue("step")); err != nil {
+		return nil, queryapi.DecorateWithParamName(err, "step")
+	}
+	if req.Step <= 0 {
+		return nil, queryapi.ErrNegativeStep
+	}
+	return req, nil
+}
+
+// estimateShards estimates the number of queries the query is split and sharded into.
+func (e queryCostEstimator) estimateShards(ctx context.Context, req *tripperware.PrometheusRequest) (shards int, splitInterval string, verticalShardSize int, err error) {
+	verticalShardSize, _, err = getMaxVerticalShardSize(ctx, req, e.limits, e.queryAnalyzer)
+	if err != nil {
+		return 0, "", 0, httpgrpc.Errorf(http.StatusBadRequest, "%s", err.Error())
+	}
+
+	// Instant queries aren't split by interval.
+	if req.Start == req.End || e.cfg.SplitQueriesByInterval == 0 {
+		return verticalShardSize, "", verticalShardSize, nil
+	}
+
+	ctx, interval, err := e.intervalFn(ctx, req)
+	if err != nil {
+		return 0, "", 0, httpgrpc.Errorf(http.StatusBadRequest, "%s", err.Error())
+	}
+	if dynamicVerticalShardSize, ok := tripperware.VerticalShardSizeFromContext(ctx); ok {
+		verticalShardSize = dynamicVerticalShardSize
+	}
+
+	splits, err := splitQuery(req, interval)
+	if err != nil {
+		return 0, "", 0, httpgrpc.Errorf(http.StatusBadRequest, "%s", err.Error())
+	}
+	return len(splits) * verticalShardSize, interval.String(), verticalShardSize, nil
+}
+
+// estimateSelectors forwards the request to the querier, which estimates the series and chunks
+// touched by the query selectors from the ingesters TSDB head stats, without executing the query.
+func (e queryCostEstimator) estimateSelectors(r *http.Request, req *tripperware.PrometheusRequest) (querier.QueryCost, error) {
+	params := url.Values{
+		"query": []string{req.Query},
+		"start": []string{tripperware.EncodeTime(req.Start)},
+		"end":   []string{tripperware.EncodeTime(req.End)},
+	}
+	u := &url.URL{
+		Path:     r.URL.Path,
+		RawQuery: params.Encode(),
+	}
+
+	costReq, err := http.NewRequestWithContext(r.Context(), http.MethodGet, u.String(), nil)
+	if err != nil {
+		return querier.QueryCost{}, httpgrpc.Errorf(http.StatusInternalServerError, "%s", err.Error())
+	}
+	costReq.Header = r.Header.Clone()
+	costReq.Header.Del("Content-Type")
+	costReq.Header.Del("Content-Length")
+
+	resp, err := e.next.RoundTrip(costReq)
+	if err != nil {
+		return querier.QueryCost{}, err
+	}
+	defer func() {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode/100 != 2 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return querier.QueryCost{}, httpgrpc.Errorf(resp.StatusCode, "failed to estimate the query selectors cost: %s", body)
+	}
+
+	var result queryCostResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return querier.QueryCost{}, httpgrpc.Errorf(http.StatusInternalServerError, "failed to decode the query selectors cost: %s", err.Error())
+	}
+	return result.Data, nil
+}
@@ -0,0 +1,143 @@
+package queryrange
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/thanos/pkg/querysharding"
+	"github.com/weaveworks/common/user"
+
+	"github.com/cortexproject/cortex/pkg/ingester/client"
+	"github.com/cortexproject/cortex/pkg/querier"
+	"github.com/cortexproject/cortex/pkg/querier/tripperware"
+)
+
+// mockHeadCardinalityQuerier returns the head stats by metric name matcher.
+type mockHeadCardinalityQuerier map[string]*client.CardinalityResponse
+
+func (m mockHeadCardinalityQuerier) HeadCardinality(_ context.Context, _ int, matchers ...*labels.Matcher) (*client.CardinalityResponse, error) {
+	for _, matcher := range matchers {
+		if resp, ok := m[matcher.String()]; ok && matcher.Name == labels.MetricName {
+			return resp, nil
+		}
+	}
+	return nil, fmt.Errorf("unexpected matchers %v", matchers)
+}
+
+func TestQueryCostTripperware(t *testing.T) {
+	estimator := querier.NewQueryCostEstimator(mockHeadCardinalityQuerier{
+		`__name__="up"`:       {NumSeries: 3, NumChunks: 3, MaxTimestampMs: time.Hour.Milliseconds()},
+		`__name__="foo"`:      {NumSeries: 2, NumChunks: 2, MaxTimestampMs: time.Hour.Milliseconds()},
+		`__name__=~"node_.+"`: {},
+	}, lookbackDelta)
+
+	var nextRequests []string
+	next := tripperware.RoundTripFunc(func(r *http.Request) (*http.Response, error) {
+		nextRequests = append(nextRequests, r.URL.Path)
+
+		rec := httptest.NewRecorder()
+		if strings.HasSuffix(r.URL.Path, "/query_cost") {
+			querier.QueryCostHandler(estimator).ServeHTTP(rec, r)
+		}
+		return rec.Result(), nil
+	})
+
+	tests := map[string]struct {
+		cfg      Config
+		limits   tripperware.Limits
+		path     string
+		expected querier.QueryCost
+	}{
+		"instant query": {
+			limits: mockLimits{},
+			path:   "/api/v1/query_cost?query=up&time=7200",
+			expected: querier.QueryCost{
+				EstimatedSeries:   3,
+				EstimatedChunks:   3,
+				EstimatedShards:   1,
+				VerticalShardSize: 1,
+				Selectors: []querier.SelectorCost{
+					{Selector: `up`, Start: 6900000, End: 7200000, EstimatedSeries: 3, EstimatedChunks: 3},
+				},
+			},
+		},
+		"range query split by interval and vertically sharded": {
+			cfg:    Config{SplitQueriesByInterval: time.Hour},
+			limits: mockLimits{queryVerticalShardSize: 2},
+			path:   "/api/v1/query_cost?query=" + url.QueryEscape(`sum by (job) (rate(up[5m])) / on(job) sum by (job) (foo{job="bar"} offset 1h)`) + "&start=0&end=10800&step=60",
+			expected: querier.QueryCost{
+				EstimatedSeries:   5,
+				EstimatedChunks:   17,
+				EstimatedShards:   6,
+				SplitInterval:     "1h0m0s",
+				VerticalShardSize: 2,
+				Selectors: []querier.SelectorCost{
+					{Selector: `up`, Start: -300000, End: 10800000, EstimatedSeries: 3, EstimatedChunks: 10},
+					{Selector: `foo{job="bar"}`, Start: -3900000, End: 7200000, EstimatedSeries: 2, EstimatedChunks: 7},
+				},
+			},
+		},
+		"range query not shardable": {
+			cfg:    Config{SplitQueriesByInterval: time.Hour},
+			limits: mockLimits{queryVerticalShardSize: 2},
+			path:   "/api/v1/query_cost?query=" + url.QueryEscape(`count({__name__=~"node_.+"})`) + "&start=0&end=3600&step=60",
+			expected: querier.QueryCost{
+				EstimatedShards:   1,
+				SplitInterval:     "1h0m0s",
+				VerticalShardSize: 1,
+				Selectors: []querier.SelectorCost{
+					{Selector: `{__name__=~"node_.+"}`, Start: -300000, End: 3600000},
+				},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			nextRequests = nil
+			rt := NewQueryCostTripperware(test.cfg, test.limits, querysharding.NewQueryAnalyzer(), lookbackDelta)(next)
+
+			req := httptest.NewRequest(http.MethodGet, test.path, nil)
+			req = req.WithContext(user.InjectOrgID(context.Background(), "user-1"))
+
+			resp, err := rt.RoundTrip(req)
+			require.NoError(t, err)
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+
+			var actual queryCostResponse
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&actual))
+			assert.Equal(t, StatusSuccess, actual.Status)
+			assert.Equal(t, test.expected, actual.Data)
+
+			// Only the querier query cost API is called, the query isn't executed.
+			assert.Equal(t, []string{"/api/v1/query_cost"}, nextRequests)
+		})
+	}
+
+	t.Run("should pass through the other requests", func(t *testing.T) {
+		nextRequests = nil
+		rt := NewQueryCostTripperware(Config{}, mockLimits{}, querysharding.NewQueryAnalyzer(), lookbackDelta)(next)
+
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/query?query=up", nil)
+		_, err := rt.RoundTrip(req.WithContext(user.InjectOrgID(context.Background(), "user-1")))
+		require.NoError(t, err)
+		assert.Equal(t, []string{"/api/v1/query"}, nextRequests)
+	})
+
+	t.Run("should fail on an invalid query", func(t *testing.T) {
+		rt := NewQueryCostTripperware(Config{}, mockLimits{}, querysharding.NewQueryAnalyzer(), lookbackDelta)(next)
+
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/query_cost?query=up{", nil)
+		_, err := rt.RoundTrip(req.WithContext(user.InjectOrgID(context.Background(), "user-1")))
+		require.Error(t, err)
+	})
+}
@@ -18,6 +18,7 @@ import (
 	"github.com/weaveworks/common/httpgrpc"
 
 	"github.com/cortexproject/cortex/pkg/api/queryapi"
+	"github.com/cortexproject/cortex/pkg/querier"
 	"github.com/cortexproject/cortex/pkg/querier/stats"
 	"github.com/cortexproject/cortex/pkg/querier/tripperware"
 	"github.com/cortexproject/cortex/pkg/util"
@@ -59,9 +60,12 @@ type prometheusCodec struct {
 	sharded          bool
 	compression      tripperware.Compression
 	defaultCodecType tripperware.CodecType
+
+	// Whether matrix responses are encoded while being written to the client.
+	streamMatrixResponses bool
 }
 
-func NewPrometheusCodec(sharded bool, compressionStr string, defaultCodecTypeStr string) *prometheusCodec { //nolint:revive
+func NewPrometheusCodec(sharded bool, compressionStr string, defaultCodecTypeStr string, streamMatrixResponses bool) *prometheusCodec { //nolint:revive
 	compression := tripperware.NonCompression // default
 	switch compressionStr {
 	case string(tripperware.GzipCompression):
@@ -80,9 +84,10 @@ func NewPrometheusCodec(sharded bool, compressionStr string, defaultCodecTypeStr
 	}
 
 	return &prometheusCodec{
-		sharded:          sharded,
-		compression:      compression,
-		defaultCodecType: defaultCodecType,
+		sharded:               sharded,
+		compression:           compression,
+		defaultCodecType:      defaultCodecType,
+		streamMatrixResponses: streamMatrixResponses,
 	}
 }
 
@@ -155,6 +160,11 @@ func (c prometheusCodec) DecodeRequest(_ context.Context, r *http.Request, forwa
 		}
 	}
 
+	// The results of the queries restricted to some blocks are partial, so they're not cached.
+	if r.Header.Get(querier.QueryBlocksHeader) != "" {
+		result.CachingOptions.Disabled = true
+	}
+
 	return &result, nil
 }
 
@@ -280,7 +290,7 @@ func (c prometheusCodec) DecodeResponse(ctx context.Context, r *http.Response, _
 	return &resp, nil
 }
 
-func (prometheusCodec) EncodeResponse(ctx context.Context, _ *http.Request, res tripperware.Response) (*http.Response, error) {
+func (c prometheusCodec) EncodeResponse(ctx context.Context, _ *http.Request, res tripperware.Response) (*http.Response, error) {
 	sp, _ := opentracing.StartSpanFromContext(ctx, "APIResponse.ToHTTPResponse")
 	defer sp.Finish()
 
@@ -295,6 +305,10 @@ func (prometheusCodec) EncodeResponse(ctx context.Context, _ *http.Request, res
 
 		queryStats := stats.FromContext(ctx)
 		tripperware.SetQueryResponseStats(a, queryStats)
+
+		if c.streamMatrixResponses && a.Data.ResultType == model.ValMatrix.String() {
+			return streamMatrixResponse(a), nil
+		}
 	}
 
 	b, err := json.Marshal(a)
@@ -315,6 +329,25 @@ func (prometheusCodec) EncodeResponse(ctx context.Context, _ *http.Request, res
 	return &resp, nil
 }
 
+// streamMatrixResponse returns an http response whose body is the JSON encoding of the input matrix
+// response, encoded while the body is read. The response size is unknown upfront.
+func streamMatrixResponse(a *tripperware.PrometheusResponse) *http.Response {
+	pr, pw := io.Pipe()
+	go func() {
+		// The encoding stops as soon as the reader is closed.
+		_ = pw.CloseWithError(tripperware.EncodeMatrixResponse(pw, a))
+	}()
+
+	return &http.Response{
+		Header: http.Header{
+			"Content-Type": []string{tripperware.ApplicationJson},
+		},
+		Body:          pr,
+		StatusCode:    http.StatusOK,
+		ContentLength: -1,
+	}
+}
+
 func encodeDurationMs(d int64) string {
 	return strconv.FormatFloat(float64(d)/float64(time.Second/time.Millisecond), 'f', -1, 64)
 }
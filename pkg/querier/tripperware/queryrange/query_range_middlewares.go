@@ -48,6 +48,11 @@ type Config struct {
 	// List of headers which query_range middleware chain would forward to downstream querier.
 	ForwardHeaders flagext.StringSlice `yaml:"forward_headers_list"`
 
+	StreamMatrixResponses bool `yaml:"stream_matrix_responses"`
+
+	QueryCoalescingEnabled         bool          `yaml:"query_coalescing_enabled"`
+	QueryCoalescingFollowerTimeout time.Duration `yaml:"query_coalescing_follower_timeout"`
+
 	// Populated based on the query configuration
 	VerticalShardSize int `yaml:"-"`
 }
@@ -59,6 +64,9 @@ func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
 	f.BoolVar(&cfg.AlignQueriesWithStep, "querier.align-querier-with-step", false, "Mutate incoming queries to align their start and end with their step.")
 	f.BoolVar(&cfg.CacheResults, "querier.cache-results", false, "Cache query results.")
 	f.Var(&cfg.ForwardHeaders, "frontend.forward-headers-list", "List of headers forwarded by the query Frontend to downstream querier.")
+	f.BoolVar(&cfg.StreamMatrixResponses, "frontend.stream-matrix-responses", false, "EXPERIMENTAL: Encode range query responses while writing them to the client, instead of encoding the whole JSON response in memory first. This reduces the query-frontend memory usage for responses with many series. The responses compressed with -frontend.response-compression-encodings are still encoded in memory first.")
+	f.BoolVar(&cfg.QueryCoalescingEnabled, "frontend.query-coalescing-enabled", false, "EXPERIMENTAL: Coalesce the concurrent identical queries of a tenant, with the same request parameters and forwarded headers: the first query is executed, and the identical ones received while it's in-flight are served by its result.")
+	f.DurationVar(&cfg.QueryCoalescingFollowerTimeout, "frontend.query-coalescing-follower-timeout", 10*time.Second, "Maximum time a coalesced query waits for the result of the identical in-flight query. Once elapsed, or if the in-flight query fails, the query is executed independently.")
 	cfg.ResultsCacheConfig.RegisterFlags(f)
 	cfg.DynamicQuerySplitsConfig.RegisterFlags(f)
 }
@@ -85,6 +93,8 @@ type DynamicQuerySplitsConfig struct {
 	MaxShardsPerQuery              int           `yaml:"max_shards_per_query"`
 	MaxFetchedDataDurationPerQuery time.Duration `yaml:"max_fetched_data_duration_per_query"`
 	EnableDynamicVerticalSharding  bool          `yaml:"enable_dynamic_vertical_sharding"`
+	MinVerticalShardSize           int           `yaml:"min_vertical_shard_size"`
+	QueryCostPerVerticalShard      time.Duration `yaml:"query_cost_per_vertical_shard"`
 }
 
 // RegisterFlags registers flags foy dynamic query splits
@@ -92,6 +102,8 @@ func (cfg *DynamicQuerySplitsConfig) RegisterFlags(f *flag.FlagSet) {
 	f.IntVar(&cfg.MaxShardsPerQuery, "querier.max-shards-per-query", 0, "[EXPERIMENTAL] Maximum number of shards for a query, 0 disables it. Dynamically uses a multiple of split interval to maintain a total number of shards below the set value. If vertical sharding is enabled for a query, the combined total number of interval splits and vertical shards is kept below this value.")
 	f.DurationVar(&cfg.MaxFetchedDataDurationPerQuery, "querier.max-fetched-data-duration-per-query", 0, "[EXPERIMENTAL] Max total duration of data fetched from storage by all query shards, 0 disables it. Dynamically uses a multiple of split interval to maintain a total fetched duration of data lower than the value set. It takes into account additional duration fetched by matrix selectors and subqueries.")
 	f.BoolVar(&cfg.EnableDynamicVerticalSharding, "querier.enable-dynamic-vertical-sharding", false, "[EXPERIMENTAL] Dynamically adjust vertical shard size to maximize the total combined number of query shards and splits.")
+	f.IntVar(&cfg.MinVerticalShardSize, "querier.min-vertical-shard-size", 1, "[EXPERIMENTAL] Minimum vertical shard size picked by the dynamic vertical sharding for shardable queries. The vertical shard size is picked between this value and the query vertical shard size of the tenant, which is used as maximum. Queries which can't be sharded are not sharded regardless of this value.")
+	f.DurationVar(&cfg.QueryCostPerVerticalShard, "querier.query-cost-per-vertical-shard", 0, "[EXPERIMENTAL] Estimated query cost handled by each vertical shard picked by the dynamic vertical sharding, 0 disables it. The query cost is estimated from the parsed query as the duration of data fetched by its selectors, accounting for their ranges, multiplied by the number of aggregations fanning in the selected series. Cheap queries use fewer vertical shards, down to the minimum vertical shard size, while expensive ones use more, up to the query vertical shard size of the tenant.")
 }
 
 // Middlewares returns list of middlewares that should be applied for range query.
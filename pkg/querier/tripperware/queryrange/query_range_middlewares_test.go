@@ -22,8 +22,9 @@ import (
 )
 
 var (
-	PrometheusCodec        = NewPrometheusCodec(false, "", "protobuf")
-	ShardedPrometheusCodec = NewPrometheusCodec(false, "", "protobuf")
+	PrometheusCodec        = NewPrometheusCodec(false, "", "protobuf", false)
+	ShardedPrometheusCodec = NewPrometheusCodec(false, "", "protobuf", false)
+	StreamingPromCodec     = NewPrometheusCodec(false, "", "protobuf", true)
 )
 
 func TestRoundTrip(t *testing.T) {
@@ -22,6 +22,7 @@ import (
 
 	"github.com/cortexproject/cortex/pkg/api/queryapi"
 	"github.com/cortexproject/cortex/pkg/cortexpb"
+	"github.com/cortexproject/cortex/pkg/querier"
 	"github.com/cortexproject/cortex/pkg/querier/tripperware"
 )
 
@@ -96,6 +97,26 @@ func TestRequest(t *testing.T) {
 	}
 }
 
+func TestRequest_ShouldDisableCachingOfTheQueriesRestrictedToBlocks(t *testing.T) {
+	t.Parallel()
+	ctx := user.InjectOrgID(context.Background(), "1")
+
+	for header, expectedDisabled := range map[string]bool{
+		"":                           false,
+		"01HV6Y4Z9N3K8F1S2T5G7M0QWE": true,
+	} {
+		r, err := http.NewRequest("GET", queryAll, http.NoBody)
+		require.NoError(t, err)
+		if header != "" {
+			r.Header.Set(querier.QueryBlocksHeader, header)
+		}
+
+		req, err := PrometheusCodec.DecodeRequest(ctx, r.Clone(ctx), nil)
+		require.NoError(t, err)
+		assert.Equal(t, expectedDisabled, req.(*tripperware.PrometheusRequest).CachingOptions.Disabled)
+	}
+}
+
 func TestResponse(t *testing.T) {
 	t.Parallel()
 	r := *parsedResponse
@@ -309,6 +330,14 @@ func TestResponse(t *testing.T) {
 			resp2, err := PrometheusCodec.EncodeResponse(context.Background(), nil, resp)
 			require.NoError(t, err)
 			assert.Equal(t, response, resp2)
+
+			// Encoding the response while writing it gives the same body.
+			streamed, err := StreamingPromCodec.EncodeResponse(context.Background(), nil, resp)
+			require.NoError(t, err)
+			assert.Equal(t, int64(-1), streamed.ContentLength)
+			streamedBody, err := io.ReadAll(streamed.Body)
+			require.NoError(t, err)
+			assert.Equal(t, tc.jsonBody, string(streamedBody))
 			cancelCtx()
 		})
 	}
@@ -433,6 +462,14 @@ func TestResponseWithStats(t *testing.T) {
 			resp2, err := PrometheusCodec.EncodeResponse(context.Background(), nil, resp)
 			require.NoError(t, err)
 			assert.Equal(t, response, resp2)
+
+			// Encoding the response while writing it gives the same body.
+			streamed, err := StreamingPromCodec.EncodeResponse(context.Background(), nil, resp)
+			require.NoError(t, err)
+			assert.Equal(t, int64(-1), streamed.ContentLength)
+			streamedBody, err := io.ReadAll(streamed.Body)
+			require.NoError(t, err)
+			assert.Equal(t, tc.jsonBody, string(streamedBody))
 		})
 	}
 }
@@ -159,7 +159,18 @@ func (t splitter) GenerateCacheKey(ctx context.Context, userID string, r tripper
 	}
 
 	currentInterval := r.GetStart() / int64(interval/time.Millisecond)
-	return fmt.Sprintf("%s:%s:%d:%d", userID, r.GetQuery(), r.GetStep(), currentInterval)
+	key := fmt.Sprintf("%s:%s:%d:%d", userID, r.GetQuery(), r.GetStep(), currentInterval)
+
+	// Requests not aligned to their step return samples at different timestamps than the
+	// aligned ones, so their extents can't be merged. Requests with the same offset from the
+	// step (e.g. a dashboard shifting its time range by a number of steps on refresh) still
+	// share the same key, to reuse the cached extents and only query the missing parts.
+	if step := r.GetStep(); step > 0 {
+		if offset := r.GetStart() % step; offset != 0 {
+			key = fmt.Sprintf("%s:%d", key, offset)
+		}
+	}
+	return key
 }
 
 // ShouldCacheFn checks whether the current request should go to cache
@@ -326,7 +337,9 @@ func (s resultsCache) shouldCacheResponse(ctx context.Context, req tripperware.R
 		return false
 	}
 	if res, ok := r.(*tripperware.PrometheusResponse); ok {
-		return !slices.Contains(res.Warnings, partialdata.ErrPartialData.Error())
+		return !slices.ContainsFunc(res.Warnings, func(warning string) bool {
+			return strings.HasPrefix(warning, partialdata.ErrPartialData.Error())
+		})
 	}
 
 	return true
@@ -572,6 +572,20 @@ func TestShouldCache(t *testing.T) {
 			}),
 			expected: false,
 		},
+		{
+			name:    "contains partial data warning with details",
+			request: &tripperware.PrometheusRequest{Query: "metric"},
+			input: tripperware.Response(&tripperware.PrometheusResponse{
+				Headers: []*tripperware.PrometheusResponseHeader{
+					{
+						Name:   "meaninglessheader",
+						Values: []string{},
+					},
+				},
+				Warnings: []string{partialdata.ErrPartialData.Error() + ": some blocks could not be queried from store-gateways"},
+			}),
+			expected: false,
+		},
 		{
 			name:    "contains other warning",
 			request: &tripperware.PrometheusRequest{Query: "metric"},
@@ -1479,6 +1493,8 @@ func TestSplitter_generateCacheKey(t *testing.T) {
 		{"<1d", &tripperware.PrometheusRequest{Start: toMs(22 * time.Hour), Step: 10, Query: "foo{}"}, 24 * time.Hour, "fake:foo{}:10:0"},
 		{"4d", &tripperware.PrometheusRequest{Start: toMs(4 * 24 * time.Hour), Step: 10, Query: "foo{}"}, 24 * time.Hour, "fake:foo{}:10:4"},
 		{"3d5h", &tripperware.PrometheusRequest{Start: toMs(77 * time.Hour), Step: 10, Query: "foo{}"}, 24 * time.Hour, "fake:foo{}:10:3"},
+		{"unaligned to step", &tripperware.PrometheusRequest{Start: toMs(10*time.Minute) + 3, Step: 10, Query: "foo{}"}, 30 * time.Minute, "fake:foo{}:10:0:3"},
+		{"unaligned to step with the same offset", &tripperware.PrometheusRequest{Start: toMs(20*time.Minute) + 13, Step: 10, Query: "foo{}"}, 30 * time.Minute, "fake:foo{}:10:0:3"},
 	}
 	for _, tt := range tests {
 		t.Run(fmt.Sprintf("%s - %s", tt.name, tt.interval), func(t *testing.T) {
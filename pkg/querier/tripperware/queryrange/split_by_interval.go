@@ -92,7 +92,7 @@ func splitQuery(r tripperware.Request, interval time.Duration) ([]tripperware.Re
 
 	// Replace @ modifier function to their respective constant values in the query.
 	// This way subqueries will be evaluated at the same time as the parent query.
-	query, err := evaluateAtModifierFunction(r.GetQuery(), r.GetStart(), r.GetEnd())
+	query, err := tripperware.EvaluateAtModifierFunction(r.GetQuery(), r.GetStart(), r.GetEnd())
 	if err != nil {
 		return nil, err
 	}
@@ -108,38 +108,6 @@ func splitQuery(r tripperware.Request, interval time.Duration) ([]tripperware.Re
 	return reqs, nil
 }
 
-// evaluateAtModifierFunction parse the query and evaluates the `start()` and `end()` at modifier functions into actual constant timestamps.
-// For example given the start of the query is 10.00, `http_requests_total[1h] @ start()` query will be replaced with `http_requests_total[1h] @ 10.00`
-// If the modifier is already a constant, it will be returned as is.
-func evaluateAtModifierFunction(query string, start, end int64) (string, error) {
-	expr, err := cortexparser.ParseExpr(query)
-	if err != nil {
-		return "", httpgrpc.Errorf(http.StatusBadRequest, "%s", err)
-	}
-	parser.Inspect(expr, func(n parser.Node, _ []parser.Node) error {
-		if selector, ok := n.(*parser.VectorSelector); ok {
-			switch selector.StartOrEnd {
-			case parser.START:
-				selector.Timestamp = &start
-			case parser.END:
-				selector.Timestamp = &end
-			}
-			selector.StartOrEnd = 0
-		}
-		if selector, ok := n.(*parser.SubqueryExpr); ok {
-			switch selector.StartOrEnd {
-			case parser.START:
-				selector.Timestamp = &start
-			case parser.END:
-				selector.Timestamp = &end
-			}
-			selector.StartOrEnd = 0
-		}
-		return nil
-	})
-	return expr.String(), err
-}
-
 // Round up to the step before the next interval boundary.
 func nextIntervalBoundary(t, step int64, interval time.Duration) int64 {
 	msPerInterval := int64(interval / time.Millisecond)
@@ -179,15 +147,20 @@ func dynamicIntervalFn(cfg Config, limits tripperware.Limits, queryAnalyzer quer
 		}
 
 		minVerticalShardSize := maxVerticalShardSize
+		maxCandidateVerticalShardSize := maxVerticalShardSize
 		if dynamicSplitCfg.EnableDynamicVerticalSharding {
-			minVerticalShardSize = 1
+			minVerticalShardSize = max(1, min(dynamicSplitCfg.MinVerticalShardSize, maxVerticalShardSize))
+			if dynamicSplitCfg.QueryCostPerVerticalShard > 0 {
+				queryCost := estimateQueryCost(queryExpr, r.GetStart(), r.GetEnd(), baseInterval, lookbackDelta)
+				maxCandidateVerticalShardSize = getVerticalShardSizeFromQueryCost(queryCost, dynamicSplitCfg.QueryCostPerVerticalShard, minVerticalShardSize, maxVerticalShardSize)
+			}
 		}
 
 		interval := baseInterval
 		verticalShardSize := maxVerticalShardSize
 		totalShards := 0
 		// Find the combination of horizontal splits and vertical shards that will result in the largest total number of shards
-		for candidateVerticalShardSize := minVerticalShardSize; candidateVerticalShardSize <= maxVerticalShardSize; candidateVerticalShardSize++ {
+		for candidateVerticalShardSize := minVerticalShardSize; candidateVerticalShardSize <= maxCandidateVerticalShardSize; candidateVerticalShardSize++ {
 			maxSplitsFromMaxShards := getMaxSplitsFromMaxQueryShards(dynamicSplitCfg.MaxShardsPerQuery, candidateVerticalShardSize)
 			maxSplitsFromDurationFetched := getMaxSplitsFromDurationFetched(dynamicSplitCfg.MaxFetchedDataDurationPerQuery, candidateVerticalShardSize, queryExpr, r.GetStart(), r.GetEnd(), r.GetStep(), baseInterval, lookbackDelta)
 
@@ -341,6 +314,28 @@ func analyzeDurationFetchedByQueryExpr(expr parser.Expr, queryStart int64, query
 	return time.Duration(durationFetchedByRangeCount) * baseInterval, time.Duration(durationFetchedBySelectorsCount) * baseInterval
 }
 
+// estimateQueryCost estimates the cost of the query as the duration of data fetched from storage by
+// its selectors, accounting for the query range and the selectors ranges, multiplied by the number
+// of aggregations fanning in the selected series.
+func estimateQueryCost(expr parser.Expr, queryStart int64, queryEnd int64, baseInterval time.Duration, lookbackDelta time.Duration) time.Duration {
+	durationFetchedByRange, durationFetchedBySelectors := analyzeDurationFetchedByQueryExpr(expr, queryStart, queryEnd, baseInterval, lookbackDelta)
+
+	aggregations := 0
+	parser.Inspect(expr, func(node parser.Node, _ []parser.Node) error {
+		if _, ok := node.(*parser.AggregateExpr); ok {
+			aggregations++
+		}
+		return nil
+	})
+	return (durationFetchedByRange + durationFetchedBySelectors) * time.Duration(1+aggregations)
+}
+
+// Return the vertical shard size handling the query cost, between the min and max vertical shard size
+func getVerticalShardSizeFromQueryCost(queryCost time.Duration, queryCostPerVerticalShard time.Duration, minVerticalShardSize int, maxVerticalShardSize int) int {
+	verticalShardSize := int(ceilDiv(int64(queryCost), int64(queryCostPerVerticalShard)))
+	return max(minVerticalShardSize, min(verticalShardSize, maxVerticalShardSize))
+}
+
 func getExpectedTotalShards(queryStart int64, queryEnd int64, interval time.Duration, verticalShardSize int) int {
 	queryRange := time.Duration((queryEnd - queryStart) * int64(time.Millisecond))
 	expectedSplits := int(ceilDiv(int64(queryRange), int64(interval)))
@@ -421,7 +421,7 @@ func Test_evaluateAtModifier(t *testing.T) {
 		},
 	} {
 		t.Run(tt.in, func(t *testing.T) {
-			out, err := evaluateAtModifierFunction(tt.in, start, end)
+			out, err := tripperware.EvaluateAtModifierFunction(tt.in, start, end)
 			if tt.expectedErrorCode != 0 {
 				require.Error(t, err)
 				httpResp, ok := httpgrpc.HTTPResponseFromError(err)
@@ -644,8 +644,10 @@ func Test_dynamicIntervalFn_verticalSharding(t *testing.T) {
 		baseSplitInterval         time.Duration
 		req                       tripperware.Request
 		maxVerticalShardSize      int
+		minVerticalShardSize      int
 		maxShardsPerQuery         int
 		maxFetchedDataDuration    time.Duration
+		queryCostPerVerticalShard time.Duration
 		expectedInterval          time.Duration
 		expectedVerticalShardSize int
 		expectedError             bool
@@ -664,6 +666,51 @@ func Test_dynamicIntervalFn_verticalSharding(t *testing.T) {
 			maxVerticalShardSize:      3,
 			expectedVerticalShardSize: 3,
 		},
+		{
+			baseSplitInterval: day,
+			name:              "23 hour range with 30 max shards and 10 days query cost per vertical shard, expect split by 1 day and 1 vertical shard",
+			req: &tripperware.PrometheusRequest{
+				Start: 0,
+				End:   23*3600*seconds - 1,
+				Step:  60 * seconds,
+				Query: "sum(up) by (cluster)",
+			},
+			maxShardsPerQuery:         30,
+			queryCostPerVerticalShard: 10 * day,
+			expectedInterval:          day,
+			maxVerticalShardSize:      3,
+			expectedVerticalShardSize: 1,
+		},
+		{
+			baseSplitInterval: day,
+			name:              "23 hour range with 30 max shards and 2 days query cost per vertical shard, expect split by 1 day and 2 vertical shards",
+			req: &tripperware.PrometheusRequest{
+				Start: 0,
+				End:   23*3600*seconds - 1,
+				Step:  60 * seconds,
+				Query: "sum(up) by (cluster)",
+			},
+			maxShardsPerQuery:         30,
+			queryCostPerVerticalShard: 2 * day,
+			expectedInterval:          day,
+			maxVerticalShardSize:      3,
+			expectedVerticalShardSize: 2,
+		},
+		{
+			baseSplitInterval: day,
+			name:              "23 hour range with 30 max shards and 1 day query cost per vertical shard, expect split by 1 day and 3 vertical shards",
+			req: &tripperware.PrometheusRequest{
+				Start: 0,
+				End:   23*3600*seconds - 1,
+				Step:  60 * seconds,
+				Query: "sum(up) by (cluster)",
+			},
+			maxShardsPerQuery:         30,
+			queryCostPerVerticalShard: day,
+			expectedInterval:          day,
+			maxVerticalShardSize:      3,
+			expectedVerticalShardSize: 3,
+		},
 		{
 			baseSplitInterval: day,
 			name:              "30 day range with 30 max shards, expect split by 1 days and 1 vertical shards",
@@ -734,6 +781,21 @@ func Test_dynamicIntervalFn_verticalSharding(t *testing.T) {
 			maxVerticalShardSize:      3,
 			expectedVerticalShardSize: 1,
 		},
+		{
+			baseSplitInterval: day,
+			name:              "30 day range with 20d matrix selector, 200 days max duration fetched and 2 min vertical shards, expect split by 10 days and 2 vertical shards",
+			req: &tripperware.PrometheusRequest{
+				Start: 30 * 24 * 3600 * seconds,
+				End:   60*24*3600*seconds - 1,
+				Step:  5 * 60 * seconds,
+				Query: "sum(rate(up[20d])) by (cluster)",
+			},
+			maxFetchedDataDuration:    200 * day,
+			expectedInterval:          10 * day,
+			maxVerticalShardSize:      3,
+			minVerticalShardSize:      2,
+			expectedVerticalShardSize: 2,
+		},
 		{
 			baseSplitInterval: day,
 			name:              "100 day range with 5 day subquery and 100 days max duration fetched, expect no splitting (100 day interval)",
@@ -756,6 +818,8 @@ func Test_dynamicIntervalFn_verticalSharding(t *testing.T) {
 					MaxShardsPerQuery:              tc.maxShardsPerQuery,
 					MaxFetchedDataDurationPerQuery: tc.maxFetchedDataDuration,
 					EnableDynamicVerticalSharding:  true,
+					MinVerticalShardSize:           tc.minVerticalShardSize,
+					QueryCostPerVerticalShard:      tc.queryCostPerVerticalShard,
 				},
 			}
 			ctx := user.InjectOrgID(context.Background(), "1")
@@ -774,6 +838,38 @@ func Test_dynamicIntervalFn_verticalSharding(t *testing.T) {
 	}
 }
 
+func Test_estimateQueryCost(t *testing.T) {
+	for _, tc := range []struct {
+		query        string
+		expectedCost time.Duration
+	}{
+		{
+			// 1 day fetched by the query range and 1 day by the lookback delta.
+			query:        "up",
+			expectedCost: 2 * day,
+		},
+		{
+			query:        "sum(up) by (cluster)",
+			expectedCost: 4 * day,
+		},
+		{
+			query:        "max(sum(up) by (cluster, pod)) by (cluster)",
+			expectedCost: 6 * day,
+		},
+		{
+			// 1 day fetched by the query range of each selector, 2 days by the matrix selector range and 1 day by the lookback delta.
+			query:        "sum(rate(up[2d])) / sum(up)",
+			expectedCost: 15 * day,
+		},
+	} {
+		t.Run(tc.query, func(t *testing.T) {
+			expr, err := cortexparser.ParseExpr(tc.query)
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedCost, estimateQueryCost(expr, 0, 23*3600*seconds-1, day, lookbackDelta))
+		})
+	}
+}
+
 func Test_getIntervalFromMaxSplits(t *testing.T) {
 	for _, tc := range []struct {
 		name              string
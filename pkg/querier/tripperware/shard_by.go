@@ -74,6 +74,7 @@ func (s shardBy) Do(ctx context.Context, r Request) (Response, error) {
 	if !analysis.IsShardable() {
 		return s.next.Do(ctx, r)
 	}
+	stats.SetVerticalShardSize(int64(verticalShardSize))
 
 	reqs := s.shardQuery(logger, verticalShardSize, r, analysis)
 
@@ -91,9 +92,19 @@ func (s shardBy) Do(ctx context.Context, r Request) (Response, error) {
 }
 
 func (s shardBy) shardQuery(l log.Logger, verticalShardSize int, r Request, analysis querysharding.QueryAnalysis) []Request {
+	// Replace the start() and end() @ modifier functions with their absolute timestamps, so that
+	// all the shards evaluate the @ modifier against the same timestamp of the original query,
+	// regardless of how the sharded requests are handled downstream.
+	start, end := atModifierRange(r)
+	query, err := EvaluateAtModifierFunction(r.GetQuery(), start, end)
+	if err != nil {
+		level.Warn(l).Log("msg", "error evaluating @ modifier functions", "q", r.GetQuery(), "err", err)
+		return []Request{r}
+	}
+
 	reqs := make([]Request, verticalShardSize)
 	for i := range verticalShardSize {
-		q, err := cquerysharding.InjectShardingInfo(r.GetQuery(), &storepb.ShardInfo{
+		q, err := cquerysharding.InjectShardingInfo(query, &storepb.ShardInfo{
 			TotalShards: int64(verticalShardSize),
 			ShardIndex:  int64(i),
 			By:          analysis.ShardBy(),
@@ -110,6 +121,15 @@ func (s shardBy) shardQuery(l log.Logger, verticalShardSize int, r Request, anal
 	return reqs
 }
 
+// atModifierRange returns the timestamps the start() and end() @ modifier functions of the
+// request query evaluate to. Both evaluate to the evaluation time of instant queries.
+func atModifierRange(r Request) (int64, int64) {
+	if pr, ok := r.(*PrometheusRequest); ok && pr.GetStep() == 0 {
+		return pr.Time, pr.Time
+	}
+	return r.GetStart(), r.GetEnd()
+}
+
 type verticalShardsKey struct{}
 
 func VerticalShardSizeFromContext(ctx context.Context) (int, bool) {
@@ -0,0 +1,205 @@
+package tripperware
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/prometheus/prometheus/promql/promqltest"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/stretchr/testify/require"
+	thanosquerysharding "github.com/thanos-io/thanos/pkg/querysharding"
+	"github.com/weaveworks/common/user"
+
+	"github.com/cortexproject/cortex/pkg/querier/series"
+	querier_stats "github.com/cortexproject/cortex/pkg/querier/stats"
+	cquerysharding "github.com/cortexproject/cortex/pkg/querysharding"
+)
+
+func TestShardBy_AtModifierAndOffset(t *testing.T) {
+	t.Parallel()
+
+	storage := promqltest.LoadedStorage(t, `
+		load 1m
+			http_requests_total{pod="a", code="200"} 0+10x120
+			http_requests_total{pod="b", code="200"} 0+20x120
+			http_requests_total{pod="c", code="500"} 0+5x120
+			http_requests_total{pod="d", code="400"} 0+1x120
+			http_requests_total{pod="e", code="200"} 0+7x60 0+3x60
+	`)
+	t.Cleanup(func() { _ = storage.Close() })
+
+	engine := promql.NewEngine(promql.EngineOpts{
+		MaxSamples:           1e6,
+		Timeout:              time.Minute,
+		EnableAtModifier:     true,
+		EnableNegativeOffset: true,
+	})
+	queryable := shardingQueryable{Queryable: storage}
+	sharder := shardBy{analyzer: thanosquerysharding.NewQueryAnalyzer()}
+
+	start := time.Unix(0, 0).Add(30 * time.Minute)
+	end := time.Unix(0, 0).Add(90 * time.Minute)
+	step := time.Minute
+
+	for _, query := range []string{
+		`sum by (pod) (rate(http_requests_total[5m] @ start()))`,
+		`sum by (pod) (rate(http_requests_total[5m] @ end()))`,
+		`sum by (pod) (http_requests_total @ 3000)`,
+		`sum by (pod) (rate(http_requests_total[5m] offset 10m))`,
+		`sum by (pod) (rate(http_requests_total[5m] @ end() offset 30m))`,
+		`sum by (pod) (rate(http_requests_total[5m] @ 3000 offset -10m))`,
+		`http_requests_total{code="200"} @ start() / on (pod) http_requests_total offset 15m`,
+		`max_over_time(sum by (pod) (rate(http_requests_total[5m]))[30m:1m] @ end() offset 5m)`,
+	} {
+		t.Run(query, func(t *testing.T) {
+			analysis, err := sharder.analyzer.Analyze(query)
+			require.NoError(t, err)
+			require.True(t, analysis.IsShardable())
+
+			t.Run("range query", func(t *testing.T) {
+				req := &PrometheusRequest{Query: query, Start: start.UnixMilli(), End: end.UnixMilli(), Step: step.Milliseconds()}
+				reqs := sharder.shardQuery(log.NewNopLogger(), 3, req, analysis)
+				require.Len(t, reqs, 3)
+
+				eval := func(q string) promql.Matrix {
+					qry, err := engine.NewRangeQuery(context.Background(), queryable, nil, q, start, end, step)
+					require.NoError(t, err)
+					res := qry.Exec(context.Background())
+					require.NoError(t, res.Err)
+					m, err := res.Matrix()
+					require.NoError(t, err)
+					return m
+				}
+
+				expected := eval(query)
+				require.NotEmpty(t, expected)
+
+				var actual promql.Matrix
+				nonEmptyShards := 0
+				for _, r := range reqs {
+					require.NotContains(t, r.GetQuery(), "start()")
+					require.NotContains(t, r.GetQuery(), "end()")
+					m := eval(r.GetQuery())
+					if len(m) > 0 {
+						nonEmptyShards++
+					}
+					actual = append(actual, m...)
+				}
+				require.Greater(t, nonEmptyShards, 1)
+				sort.Sort(actual)
+				sort.Sort(expected)
+				require.Equal(t, expected, actual)
+			})
+
+			t.Run("instant query", func(t *testing.T) {
+				req := &PrometheusRequest{Query: query, Time: end.UnixMilli()}
+				reqs := sharder.shardQuery(log.NewNopLogger(), 3, req, analysis)
+				require.Len(t, reqs, 3)
+
+				eval := func(q string) promql.Vector {
+					qry, err := engine.NewInstantQuery(context.Background(), queryable, nil, q, end)
+					require.NoError(t, err)
+					res := qry.Exec(context.Background())
+					require.NoError(t, res.Err)
+					v, err := res.Vector()
+					require.NoError(t, err)
+					return v
+				}
+
+				expected := eval(query)
+				require.NotEmpty(t, expected)
+
+				var actual promql.Vector
+				for _, r := range reqs {
+					require.NotContains(t, r.GetQuery(), "start()")
+					require.NotContains(t, r.GetQuery(), "end()")
+					actual = append(actual, eval(r.GetQuery())...)
+				}
+				sortVector(actual)
+				sortVector(expected)
+				require.Equal(t, expected, actual)
+			})
+		})
+	}
+}
+
+func TestShardBy_ShouldRecordTheVerticalShardSizeInTheQueryStats(t *testing.T) {
+	t.Parallel()
+
+	for query, expectedVerticalShardSize := range map[string]int64{
+		`sum by (pod) (http_requests_total)`: 2,
+		// Not shardable.
+		`sort(http_requests_total)`: 0,
+	} {
+		t.Run(query, func(t *testing.T) {
+			next := HandlerFunc(func(context.Context, Request) (Response, error) {
+				return &PrometheusResponse{Status: StatusSuccess}, nil
+			})
+			merger := mergerFunc(func(context.Context, Request, ...Response) (Response, error) {
+				return &PrometheusResponse{Status: StatusSuccess}, nil
+			})
+			h := ShardByMiddleware(log.NewNopLogger(), mockLimits{shardSize: 3}, merger, thanosquerysharding.NewQueryAnalyzer()).Wrap(next)
+
+			stats, ctx := querier_stats.ContextWithEmptyStats(user.InjectOrgID(context.Background(), "user-1"))
+			ctx = InjectVerticalShardSizeToContext(ctx, 2)
+			_, err := h.Do(ctx, &PrometheusRequest{Query: query, Start: 0, End: 60000, Step: 15000})
+			require.NoError(t, err)
+			require.Equal(t, expectedVerticalShardSize, stats.LoadVerticalShardSize())
+		})
+	}
+}
+
+type mergerFunc func(context.Context, Request, ...Response) (Response, error)
+
+func (f mergerFunc) MergeResponse(ctx context.Context, req Request, resps ...Response) (Response, error) {
+	return f(ctx, req, resps...)
+}
+
+func sortVector(v promql.Vector) {
+	sort.Slice(v, func(i, j int) bool {
+		return labels.Compare(v[i].Metric, v[j].Metric) < 0
+	})
+}
+
+// shardingQueryable is a storage.Queryable only returning the series of the shard
+// selected by the sharding matcher injected in the query, if any.
+type shardingQueryable struct {
+	storage.Queryable
+}
+
+func (q shardingQueryable) Querier(mint, maxt int64) (storage.Querier, error) {
+	querier, err := q.Queryable.Querier(mint, maxt)
+	if err != nil {
+		return nil, err
+	}
+	return shardingQuerier{Querier: querier}, nil
+}
+
+type shardingQuerier struct {
+	storage.Querier
+}
+
+func (q shardingQuerier) Select(ctx context.Context, sortSeries bool, hints *storage.SelectHints, matchers ...*labels.Matcher) storage.SeriesSet {
+	matchers, shardMatcher, err := cquerysharding.ExtractShardingMatchers(matchers)
+	if err != nil {
+		return storage.ErrSeriesSet(err)
+	}
+	defer shardMatcher.Close()
+
+	set := q.Querier.Select(ctx, sortSeries, hints, matchers...)
+	var result []storage.Series
+	for set.Next() {
+		if shardMatcher.MatchesLabels(set.At().Labels()) {
+			result = append(result, set.At())
+		}
+	}
+	if err := set.Err(); err != nil {
+		return storage.ErrSeriesSet(err)
+	}
+	return series.NewConcreteSeriesSet(sortSeries, result)
+}
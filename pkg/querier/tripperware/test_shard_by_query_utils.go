@@ -186,6 +186,36 @@ sum by (container) (
 			expression:     `sum by (pod) (label_replace(metric, "dst_label", "$1", "src_label", "re"))`,
 			shardingLabels: []string{"pod"},
 		},
+		{
+			name:           "aggregation with @ start()",
+			expression:     `sum by (pod) (rate(http_requests_total[5m] @ start()))`,
+			shardingLabels: []string{"pod"},
+		},
+		{
+			name:           "aggregation with @ end()",
+			expression:     `sum by (pod) (rate(http_requests_total[5m] @ end()))`,
+			shardingLabels: []string{"pod"},
+		},
+		{
+			name:           "aggregation with numeric @",
+			expression:     `sum by (pod) (http_requests_total @ 1700000000)`,
+			shardingLabels: []string{"pod"},
+		},
+		{
+			name:           "aggregation with offset",
+			expression:     `sum by (pod) (rate(http_requests_total[5m] offset 1w))`,
+			shardingLabels: []string{"pod"},
+		},
+		{
+			name:           "aggregation with @ and offset",
+			expression:     `sum by (pod) (rate(http_requests_total[5m] @ end() offset 1d))`,
+			shardingLabels: []string{"pod"},
+		},
+		{
+			name:           "binary expression with vector matching, @ and offset",
+			expression:     `http_requests_total{code="400"} @ start() / on (pod) http_requests_total offset 1h`,
+			shardingLabels: []string{"pod"},
+		},
 	}
 
 	// Shardable by labels instant queries with matrix response
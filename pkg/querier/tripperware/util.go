@@ -4,8 +4,11 @@ import (
 	"context"
 	"net/http"
 
+	"github.com/prometheus/prometheus/promql/parser"
 	"github.com/weaveworks/common/httpgrpc"
 
+	cortexparser "github.com/cortexproject/cortex/pkg/parser"
+
 	"github.com/cortexproject/cortex/pkg/util/users"
 
 	"github.com/cortexproject/cortex/pkg/querier/stats"
@@ -85,3 +88,35 @@ func SetQueryResponseStats(a *PrometheusResponse, queryStats *stats.QueryStats)
 		}
 	}
 }
+
+// EvaluateAtModifierFunction parses the query and evaluates the `start()` and `end()` at modifier functions into actual constant timestamps.
+// For example given the start of the query is 10.00, `http_requests_total[1h] @ start()` query will be replaced with `http_requests_total[1h] @ 10.00`
+// If the modifier is already a constant, it will be returned as is.
+func EvaluateAtModifierFunction(query string, start, end int64) (string, error) {
+	expr, err := cortexparser.ParseExpr(query)
+	if err != nil {
+		return "", httpgrpc.Errorf(http.StatusBadRequest, "%s", err)
+	}
+	parser.Inspect(expr, func(n parser.Node, _ []parser.Node) error {
+		if selector, ok := n.(*parser.VectorSelector); ok {
+			switch selector.StartOrEnd {
+			case parser.START:
+				selector.Timestamp = &start
+			case parser.END:
+				selector.Timestamp = &end
+			}
+			selector.StartOrEnd = 0
+		}
+		if selector, ok := n.(*parser.SubqueryExpr); ok {
+			switch selector.StartOrEnd {
+			case parser.START:
+				selector.Timestamp = &start
+			case parser.END:
+				selector.Timestamp = &end
+			}
+			selector.StartOrEnd = 0
+		}
+		return nil
+	})
+	return expr.String(), err
+}
@@ -1,5 +1,7 @@
 package ring
 
+import "context"
+
 // FlushTransferer controls the shutdown of an instance in the ring.
 // Methods on this interface are called when lifecycler is stopping.
 // At that point, it no longer runs the "actor loop", but it keeps updating heartbeat in the ring.
@@ -8,6 +10,16 @@ type FlushTransferer interface {
 	Flush()
 }
 
+// LeaveWaiter can optionally be implemented by a FlushTransferer to delay the removal of the
+// instance from the ring until the data it holds is queryable from somewhere else.
+// It's called when the lifecycler is stopping, after Flush() and before unregistering,
+// only if a timeout to wait before leaving has been configured.
+type LeaveWaiter interface {
+	// WaitBeforeLeaving blocks until it's safe to remove the instance from the ring,
+	// or the input context is canceled.
+	WaitBeforeLeaving(ctx context.Context) error
+}
+
 // NoopFlushTransferer is a FlushTransferer which does nothing and can
 // be used in cases we don't need one
 type NoopFlushTransferer struct{}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"html/template"
+	"io"
 	"net/http"
 	"sort"
 	"strconv"
@@ -107,9 +108,22 @@ func (kvs *KVInitService) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 		viewKeyParam        = "viewKey"
 		viewMsgParam        = "viewMsg"
 		deleteMessagesParam = "deleteMessages"
+		exportStateParam    = "exportState"
+		importStateParam    = "importState"
 	)
 
+	// The state is imported from the request body, so it's checked before parsing the form.
+	if req.Method == http.MethodPost && req.URL.Query().Get(importStateParam) == "true" {
+		importState(w, kv, req)
+		return
+	}
+
 	if err := req.ParseForm(); err == nil {
+		if len(req.Form[exportStateParam]) > 0 && req.Form[exportStateParam][0] == "true" {
+			exportState(w, kv)
+			return
+		}
+
 		if req.Form[downloadKeyParam] != nil {
 			downloadKey(w, kv, kv.storeCopy(), req.Form[downloadKeyParam][0]) // Use first value, ignore the rest.
 			return
@@ -276,6 +290,42 @@ func downloadKey(w http.ResponseWriter, kv *KV, store map[string]valueDesc, key
 	_, _ = w.Write(encoded)
 }
 
+func exportState(w http.ResponseWriter, kv *KV) {
+	encoded, err := kv.ExportState()
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to export state: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Add("content-type", "application/octet-stream")
+	// Set content-length so that client knows whether it has received full response or not.
+	w.Header().Add("content-length", strconv.Itoa(len(encoded)))
+	w.Header().Add("content-disposition", fmt.Sprintf("attachment; filename=memberlist-state-%d", time.Now().Unix()))
+	w.WriteHeader(200)
+
+	// Ignore errors, we cannot do anything about them.
+	_, _ = w.Write(encoded)
+}
+
+func importState(w http.ResponseWriter, kv *KV, req *http.Request) {
+	// Allow one extra byte, so that a state exceeding the limit is rejected by ImportState.
+	data, err := io.ReadAll(io.LimitReader(req.Body, maxImportedStateSize+1))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to read state: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	updated, err := kv.ImportState(data)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to import state: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Add("content-type", "text/plain")
+	w.WriteHeader(200)
+	_, _ = fmt.Fprintf(w, "Imported state, updated keys: %d\n", updated)
+}
+
 type pageData struct {
 	Now              time.Time
 	Memberlist       *memberlist.Memberlist
@@ -332,6 +382,8 @@ const pageContent = `
 			</tbody>
 		</table>
 
+		<p><a href="?exportState=true">Export State</a> (can be imported with a POST request to <code>?importState=true</code>, or on startup with <code>-memberlist.seed-state-file</code>)</p>
+
 		<p>Note that value "version" is node-specific. It starts with 0 (on restart), and increases on each received update. Size is in bytes.</p>
 
 		<h2>Memberlist Cluster Members</h2>
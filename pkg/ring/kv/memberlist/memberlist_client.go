@@ -10,6 +10,8 @@ import (
 	"fmt"
 	"math"
 	mathrand "math/rand"
+	"net"
+	"os"
 	"strings"
 	"sync"
 	"time"
@@ -163,6 +165,11 @@ type KVConfig struct {
 	AbortIfJoinFails bool                `yaml:"abort_if_cluster_join_fails"`
 	RejoinInterval   time.Duration       `yaml:"rejoin_interval"`
 
+	// File containing a KV store state, merged into the local state on startup.
+	SeedStateFile string `yaml:"seed_state_file"`
+	// Heartbeat timeout of the members of the imported state.
+	ImportStateHeartbeatTimeout time.Duration `yaml:"import_state_heartbeat_timeout"`
+
 	// Remove LEFT ingesters from ring after this timeout.
 	LeftIngestersTimeout time.Duration `yaml:"left_ingesters_timeout"`
 
@@ -200,6 +207,8 @@ func (cfg *KVConfig) RegisterFlagsWithPrefix(f *flag.FlagSet, prefix string) {
 	f.IntVar(&cfg.MaxJoinRetries, prefix+"memberlist.max-join-retries", 10, "Max number of retries to join other cluster members.")
 	f.BoolVar(&cfg.AbortIfJoinFails, prefix+"memberlist.abort-if-join-fails", true, "If this node fails to join memberlist cluster, abort.")
 	f.DurationVar(&cfg.RejoinInterval, prefix+"memberlist.rejoin-interval", 0, "If not 0, how often to rejoin the cluster. Occasional rejoin can help to fix the cluster split issue, and is harmless otherwise. For example when using only few components as a seed nodes (via -memberlist.join), then it's recommended to use rejoin. If -memberlist.join points to dynamic service that resolves to all gossiping nodes (eg. Kubernetes headless service), then rejoin is not needed.")
+	f.StringVar(&cfg.SeedStateFile, prefix+"memberlist.seed-state-file", "", "[EXPERIMENTAL] Path to a file containing the KV store state exported from the /memberlist admin endpoint. If set, the state is merged into the local KV store on startup, after joining the cluster, to seed the rings when all the members are restarted at once. The state received from the live members takes precedence over the stale one of the file.")
+	f.DurationVar(&cfg.ImportStateHeartbeatTimeout, prefix+"memberlist.import-state-heartbeat-timeout", time.Minute, "[EXPERIMENTAL] When importing a KV store state, from the seed state file or the /memberlist admin endpoint, the ring instances whose heartbeat was older than this timeout when the state was exported are dropped, as well as the instances which are not live members of the memberlist cluster. 0 to only drop the instances which are not live members.")
 	f.DurationVar(&cfg.LeftIngestersTimeout, prefix+"memberlist.left-ingesters-timeout", 5*time.Minute, "How long to keep LEFT ingesters in the ring.")
 	f.DurationVar(&cfg.TombstoneTimeout, prefix+"memberlist.tombstone-timeout", 5*time.Minute, "How long to keep deleted keys (tombstones) in the KV store")
 	f.DurationVar(&cfg.LeaveTimeout, prefix+"memberlist.leave-timeout", 5*time.Second, "Timeout for leaving memberlist cluster.")
@@ -482,11 +491,33 @@ func (m *KV) starting(ctx context.Context) error {
 			level.Warn(m.logger).Log("msg", "failed to join memberlist cluster on startup", "err", err)
 		}
 	}
+
+	// The seed state is imported once joined, so that it's reconciled with the live members.
+	if m.cfg.SeedStateFile != "" {
+		m.seedState(m.cfg.SeedStateFile)
+	}
 	return nil
 }
 
 var errFailedToJoinCluster = errors.New("failed to join memberlist cluster on startup")
 
+// seedState merges the KV store state read from the file into the local state. Failures are logged
+// but not returned, because seeding the state only shortcuts the convergence of the cluster.
+func (m *KV) seedState(file string) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		level.Warn(m.logger).Log("msg", "failed to read the memberlist seed state file", "file", file, "err", err)
+		return
+	}
+
+	updated, err := m.ImportState(data)
+	if err != nil {
+		level.Warn(m.logger).Log("msg", "failed to import the memberlist seed state", "file", file, "err", err)
+		return
+	}
+	level.Info(m.logger).Log("msg", "imported the memberlist seed state", "file", file, "updated_keys", updated)
+}
+
 func (m *KV) running(ctx context.Context) error {
 	// Join the cluster, if configured. We want this to happen in Running state, because started memberlist
 	// is good enough for usage from Client (which checks for Running state), even before it connects to the cluster.
@@ -1339,6 +1370,153 @@ func (m *KV) MergeRemoteState(data []byte, join bool) {
 	}
 }
 
+// ExportState returns the state of the KV store, encoded as a KeyValueStore, which can be later
+// merged into the state of another member with ImportState.
+func (m *KV) ExportState() ([]byte, error) {
+	state := KeyValueStore{}
+	for key, val := range m.storeCopy() {
+		if val.value == nil {
+			continue
+		}
+
+		codec := m.GetCodec(val.codecID)
+		if codec == nil {
+			return nil, fmt.Errorf("unknown codec %s for key %s", val.codecID, key)
+		}
+
+		encoded, err := codec.Encode(val.value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode key %s: %v", key, err)
+		}
+
+		pair := &KeyValuePair{
+			Key:     key,
+			Value:   encoded,
+			Codec:   val.codecID,
+			Deleted: val.deleted,
+		}
+		if !val.updatedAt.IsZero() {
+			pair.UpdatedAt = val.updatedAt.UnixMilli()
+		}
+		state.Pairs = append(state.Pairs, pair)
+	}
+
+	return state.Marshal()
+}
+
+// maxImportedStateSize is the max size of a state imported by ImportState.
+const maxImportedStateSize = 64 << 20
+
+// ImportState merges the state of the KV store exported by ExportState into the local state, and
+// returns the number of updated keys. All the key-value pairs are decoded and validated before merging
+// any of them, so that a state which is not consistent is rejected as a whole. The values implementing
+// ImportableMergeable are reconciled with the live members of the cluster, then all the values are merged
+// like the state received from the other members, so the newer updates of the live members take
+// precedence over the stale ones of the imported state.
+func (m *KV) ImportState(data []byte) (int, error) {
+	m.initWG.Wait()
+
+	if len(data) > maxImportedStateSize {
+		return 0, fmt.Errorf("state size %d bytes exceeds the limit of %d bytes", len(data), maxImportedStateSize)
+	}
+
+	state := KeyValueStore{}
+	if err := state.Unmarshal(data); err != nil {
+		return 0, fmt.Errorf("failed to parse state: %v", err)
+	}
+
+	type importedValue struct {
+		pair  *KeyValuePair
+		value Mergeable
+		codec codec.Codec
+	}
+
+	isLiveMember := m.liveMemberMatcher()
+
+	values := make([]importedValue, 0, len(state.Pairs))
+	keys := make(map[string]struct{}, len(state.Pairs))
+	for _, pair := range state.Pairs {
+		if pair.Key == "" {
+			return 0, errors.New("found key-value pair with empty key")
+		}
+		if _, ok := keys[pair.Key]; ok {
+			return 0, fmt.Errorf("found duplicated key %s", pair.Key)
+		}
+		keys[pair.Key] = struct{}{}
+
+		codec := m.GetCodec(pair.Codec)
+		if codec == nil {
+			return 0, fmt.Errorf("unknown codec %s for key %s", pair.Codec, pair.Key)
+		}
+
+		decoded, err := codec.Decode(pair.Value)
+		if err != nil {
+			return 0, fmt.Errorf("failed to decode key %s: %v", pair.Key, err)
+		}
+
+		value, ok := decoded.(Mergeable)
+		if !ok {
+			return 0, fmt.Errorf("expected Mergeable for key %s, got: %T", pair.Key, decoded)
+		}
+
+		if importable, ok := value.(ImportableMergeable); ok && !pair.Deleted {
+			if value, err = importable.ReconcileImport(isLiveMember, m.cfg.ImportStateHeartbeatTimeout); err != nil {
+				return 0, fmt.Errorf("invalid value for key %s: %v", pair.Key, err)
+			}
+		}
+
+		values = append(values, importedValue{pair: pair, value: value, codec: codec})
+	}
+
+	updated := 0
+	for _, v := range values {
+		var updatedAt time.Time
+		if v.pair.UpdatedAt != 0 {
+			updatedAt = time.UnixMilli(v.pair.UpdatedAt)
+		}
+
+		change, newver, err := m.mergeValueForKey(v.pair.Key, v.value, 0, v.codec, v.pair.Deleted, updatedAt)
+		if err != nil {
+			return updated, fmt.Errorf("failed to merge key %s: %v", v.pair.Key, err)
+		}
+
+		if newver > 0 {
+			updated++
+			m.notifyWatchers(v.pair.Key)
+			m.broadcastNewValue(v.pair.Key, change, newver, v.codec)
+		}
+	}
+
+	return updated, nil
+}
+
+// liveMemberMatcher returns a function telling whether a ring instance is a live member of the
+// cluster, either by its address or by its ID, which is the node name of its member without the
+// random suffix when the node name is defaulted to the hostname.
+func (m *KV) liveMemberMatcher() func(id, addr string) bool {
+	hosts := map[string]struct{}{}
+	names := map[string]struct{}{}
+	for _, node := range m.memberlist.Members() {
+		hosts[node.Addr.String()] = struct{}{}
+
+		name := node.Name
+		if idx := strings.LastIndex(name, "-"); m.cfg.RandomizeNodeName && idx >= 0 {
+			name = name[:idx]
+		}
+		names[name] = struct{}{}
+	}
+
+	return func(id, addr string) bool {
+		host, _, err := net.SplitHostPort(addr)
+		if err != nil {
+			host = addr
+		}
+		_, liveHost := hosts[host]
+		_, liveName := names[id]
+		return liveHost || liveName
+	}
+}
+
 func (m *KV) mergeBytesValueForKey(key string, incomingData []byte, codec codec.Codec, deleted bool, updatedAt time.Time) (Mergeable, uint, error) {
 	decodedValue, err := codec.Decode(incomingData)
 	if err != nil {
@@ -10,6 +10,8 @@ import (
 	"math"
 	"math/rand"
 	"net"
+	"os"
+	"path/filepath"
 	"reflect"
 	"sort"
 	"sync"
@@ -1974,3 +1976,166 @@ func BenchmarkCASTimerAllocation(b *testing.B) {
 		})
 	}
 }
+
+func TestExportAndImportState(t *testing.T) {
+	c := dataCodec{}
+
+	cfg := KVConfig{}
+	cfg.Codecs = append(cfg.Codecs, c)
+
+	mkv1 := NewKV(cfg, log.NewNopLogger(), &dnsProviderMock{}, prometheus.NewPedanticRegistry())
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), mkv1))
+	defer services.StopAndAwaitTerminated(context.Background(), mkv1) //nolint:errcheck
+
+	client1, err := NewClient(mkv1, c)
+	require.NoError(t, err)
+
+	now := time.Now()
+	cas(t, client1, key, func(*data) (*data, bool, error) {
+		return &data{Members: map[string]member{
+			"stale": {Timestamp: now.Unix() - 10, State: ACTIVE},
+			"live":  {Timestamp: now.Unix() - 10, State: JOINING},
+		}}, true, nil
+	})
+
+	state, err := mkv1.ExportState()
+	require.NoError(t, err)
+
+	mkv2 := NewKV(cfg, log.NewNopLogger(), &dnsProviderMock{}, prometheus.NewPedanticRegistry())
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), mkv2))
+	defer services.StopAndAwaitTerminated(context.Background(), mkv2) //nolint:errcheck
+
+	client2, err := NewClient(mkv2, c)
+	require.NoError(t, err)
+
+	// The live member has registered itself with a newer state before the import.
+	cas(t, client2, key, func(*data) (*data, bool, error) {
+		return &data{Members: map[string]member{
+			"live": {Timestamp: now.Unix(), State: ACTIVE},
+		}}, true, nil
+	})
+
+	updated, err := mkv2.ImportState(state)
+	require.NoError(t, err)
+	assert.Equal(t, 1, updated)
+
+	d := getData(t, client2, key)
+	require.NotNil(t, d)
+	assert.Equal(t, map[string]member{
+		"stale": {Timestamp: now.Unix() - 10, Tokens: []uint32{}, State: ACTIVE},
+		"live":  {Timestamp: now.Unix(), Tokens: []uint32{}, State: ACTIVE},
+	}, d.Members)
+
+	// Importing the same state again doesn't update anything.
+	updated, err = mkv2.ImportState(state)
+	require.NoError(t, err)
+	assert.Equal(t, 0, updated)
+}
+
+func TestImportStateShouldRejectAnInconsistentState(t *testing.T) {
+	c := dataCodec{}
+
+	cfg := KVConfig{}
+	cfg.Codecs = append(cfg.Codecs, c)
+
+	mkv := NewKV(cfg, log.NewNopLogger(), &dnsProviderMock{}, prometheus.NewPedanticRegistry())
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), mkv))
+	defer services.StopAndAwaitTerminated(context.Background(), mkv) //nolint:errcheck
+
+	value, err := c.Encode(&data{Members: map[string]member{"member": {Timestamp: time.Now().Unix(), State: ACTIVE}}})
+	require.NoError(t, err)
+	validPair := &KeyValuePair{Key: key, Value: value, Codec: c.CodecID()}
+
+	for name, tc := range map[string]struct {
+		pairs       []*KeyValuePair
+		expectedErr string
+	}{
+		"empty key": {
+			pairs:       []*KeyValuePair{validPair, {Value: value, Codec: c.CodecID()}},
+			expectedErr: "empty key",
+		},
+		"duplicated key": {
+			pairs:       []*KeyValuePair{validPair, validPair},
+			expectedErr: "duplicated key",
+		},
+		"unknown codec": {
+			pairs:       []*KeyValuePair{validPair, {Key: "other", Value: value, Codec: "unknown"}},
+			expectedErr: "unknown codec",
+		},
+		"invalid value": {
+			pairs:       []*KeyValuePair{validPair, {Key: "other", Value: []byte("invalid"), Codec: c.CodecID()}},
+			expectedErr: "failed to decode key other",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			state, err := (&KeyValueStore{Pairs: tc.pairs}).Marshal()
+			require.NoError(t, err)
+
+			_, err = mkv.ImportState(state)
+			require.ErrorContains(t, err, tc.expectedErr)
+
+			// The valid pairs are not imported either.
+			assert.Empty(t, mkv.List(""))
+		})
+	}
+
+	_, err = mkv.ImportState([]byte("invalid"))
+	require.ErrorContains(t, err, "failed to parse state")
+}
+
+func TestImportStateShouldRejectTooLargeState(t *testing.T) {
+	cfg := KVConfig{}
+	cfg.Codecs = append(cfg.Codecs, dataCodec{})
+
+	mkv := NewKV(cfg, log.NewNopLogger(), &dnsProviderMock{}, prometheus.NewPedanticRegistry())
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), mkv))
+	defer services.StopAndAwaitTerminated(context.Background(), mkv) //nolint:errcheck
+
+	_, err := mkv.ImportState(make([]byte, maxImportedStateSize+1))
+	require.ErrorContains(t, err, "exceeds the limit")
+}
+
+func TestKV_LiveMemberMatcher(t *testing.T) {
+	cfg := KVConfig{NodeName: "ingester-1", RandomizeNodeName: true}
+	cfg.Codecs = append(cfg.Codecs, dataCodec{})
+
+	mkv := NewKV(cfg, log.NewNopLogger(), &dnsProviderMock{}, prometheus.NewPedanticRegistry())
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), mkv))
+	defer services.StopAndAwaitTerminated(context.Background(), mkv) //nolint:errcheck
+
+	isLiveMember := mkv.liveMemberMatcher()
+	localAddr := mkv.memberlist.LocalNode().Addr.String()
+
+	// The instances are matched by their address or by their ID.
+	assert.True(t, isLiveMember("ingester-1", "10.0.0.1:9095"))
+	assert.True(t, isLiveMember("other", net.JoinHostPort(localAddr, "9095")))
+	assert.True(t, isLiveMember("other", localAddr))
+	assert.False(t, isLiveMember("ingester-2", "10.0.0.1:9095"))
+	assert.False(t, isLiveMember("ingester", "10.0.0.1:9095"))
+}
+
+func TestSeedStateFile(t *testing.T) {
+	c := dataCodec{}
+
+	value, err := c.Encode(&data{Members: map[string]member{"member": {Timestamp: time.Now().Unix(), State: ACTIVE}}})
+	require.NoError(t, err)
+	state, err := (&KeyValueStore{Pairs: []*KeyValuePair{{Key: key, Value: value, Codec: c.CodecID()}}}).Marshal()
+	require.NoError(t, err)
+
+	seedFile := filepath.Join(t.TempDir(), "state")
+	require.NoError(t, os.WriteFile(seedFile, state, 0644))
+
+	cfg := KVConfig{SeedStateFile: seedFile}
+	cfg.Codecs = append(cfg.Codecs, c)
+
+	mkv := NewKV(cfg, log.NewNopLogger(), &dnsProviderMock{}, prometheus.NewPedanticRegistry())
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), mkv))
+	defer services.StopAndAwaitTerminated(context.Background(), mkv) //nolint:errcheck
+
+	client, err := NewClient(mkv, c)
+	require.NoError(t, err)
+
+	d := getData(t, client, key)
+	require.NotNil(t, d)
+	assert.Contains(t, d.Members, "member")
+}
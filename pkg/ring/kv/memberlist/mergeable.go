@@ -52,3 +52,14 @@ type Mergeable interface {
 	// Returns the total number of tombstones present and the number of removed tombstones by this invocation.
 	RemoveTombstones(limit time.Time) (total, removed int)
 }
+
+// ImportableMergeable is implemented by the Mergeable values which are validated and reconciled with the
+// live members of the cluster when imported by KV.ImportState. The other values are imported as is.
+type ImportableMergeable interface {
+	Mergeable
+
+	// ReconcileImport validates the imported value, and returns it without the entries of the members
+	// whose heartbeat was older than the heartbeat timeout when the state was exported, or which are
+	// not live members of the cluster. The value must not be modified.
+	ReconcileImport(isLiveMember func(id, addr string) bool, heartbeatTimeout time.Duration) (Mergeable, error)
+}
@@ -56,6 +56,7 @@ type LifecyclerConfig struct {
 	Zone                     string        `yaml:"availability_zone"`
 	UnregisterOnShutdown     bool          `yaml:"unregister_on_shutdown"`
 	ReadinessCheckRingHealth bool          `yaml:"readiness_check_ring_health"`
+	WaitBeforeLeavingTimeout time.Duration `yaml:"wait_before_leaving_timeout"`
 
 	// For testing, you can override the address and ID of this ingester
 	Addr string `yaml:"address" doc:"hidden"`
@@ -103,6 +104,7 @@ func (cfg *LifecyclerConfig) RegisterFlagsWithPrefix(prefix string, f *flag.Flag
 	f.StringVar(&cfg.Zone, prefix+"availability-zone", "", "The availability zone where this instance is running.")
 	f.BoolVar(&cfg.UnregisterOnShutdown, prefix+"unregister-on-shutdown", true, "Unregister from the ring upon clean shutdown. It can be useful to disable for rolling restarts with consistent naming in conjunction with -distributor.extend-writes=false.")
 	f.BoolVar(&cfg.ReadinessCheckRingHealth, prefix+"readiness-check-ring-health", true, "When enabled the readiness probe succeeds only after all instances are ACTIVE and healthy in the ring, otherwise only the instance itself is checked. This option should be disabled if in your cluster multiple instances can be rolled out simultaneously, otherwise rolling updates may be slowed down.")
+	f.DurationVar(&cfg.WaitBeforeLeavingTimeout, prefix+"wait-before-leaving-timeout", 0, "EXPERIMENTAL: Maximum time to wait on shutdown, before unregistering from the ring, for the data held by the instance to be queryable from other sources (eg. blocks shipped by ingesters to be found in the bucket index). When the timeout expires the instance is unregistered anyway. This only applies when the instance unregisters on shutdown. 0 = disabled.")
 }
 
 func (cfg *LifecyclerConfig) Validate() error {
@@ -1137,11 +1139,34 @@ func (i *Lifecycler) processShutdown(ctx context.Context) {
 		i.lifecyclerMetrics.shutdownDuration.WithLabelValues("flush", "success").Observe(time.Since(flushStart).Seconds())
 	}
 
+	if waiter, ok := i.flushTransferer.(LeaveWaiter); ok && i.cfg.WaitBeforeLeavingTimeout > 0 && i.ShouldUnregisterOnShutdown() {
+		i.waitBeforeLeaving(ctx, waiter)
+	}
+
 	// Sleep so the shutdownDuration metric can be collected.
 	level.Info(i.logger).Log("msg", "lifecycler entering final sleep before shutdown", "final_sleep", i.cfg.FinalSleep)
 	time.Sleep(i.cfg.FinalSleep)
 }
 
+// waitBeforeLeaving waits until the LeaveWaiter reports it's safe to leave the ring. On timeout
+// or error, we fall back to leaving the ring anyway so that a stuck wait doesn't block the shutdown.
+func (i *Lifecycler) waitBeforeLeaving(ctx context.Context, waiter LeaveWaiter) {
+	level.Info(i.logger).Log("msg", "waiting before leaving the ring", "ring", i.RingName, "timeout", i.cfg.WaitBeforeLeavingTimeout)
+
+	ctx, cancel := context.WithTimeout(ctx, i.cfg.WaitBeforeLeavingTimeout)
+	defer cancel()
+
+	waitStart := time.Now()
+	if err := waiter.WaitBeforeLeaving(ctx); err != nil {
+		level.Warn(i.logger).Log("msg", "failed to wait before leaving the ring, leaving anyway", "ring", i.RingName, "err", err)
+		i.lifecyclerMetrics.shutdownDuration.WithLabelValues("wait_before_leaving", "fail").Observe(time.Since(waitStart).Seconds())
+		return
+	}
+
+	level.Info(i.logger).Log("msg", "finished waiting before leaving the ring", "ring", i.RingName)
+	i.lifecyclerMetrics.shutdownDuration.WithLabelValues("wait_before_leaving", "success").Observe(time.Since(waitStart).Seconds())
+}
+
 // unregister removes our entry from consul.
 func (i *Lifecycler) unregister(ctx context.Context) error {
 	level.Debug(i.logger).Log("msg", "unregistering instance from ring", "ring", i.RingName)
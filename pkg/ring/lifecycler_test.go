@@ -11,6 +11,7 @@ import (
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.uber.org/atomic"
 
 	"github.com/cortexproject/cortex/pkg/ring/kv/consul"
 	"github.com/cortexproject/cortex/pkg/util/flagext"
@@ -297,6 +298,106 @@ func TestLifecycler_NilFlushTransferer(t *testing.T) {
 	assert.Equal(t, 0, lifecycler.HealthyInstancesCount())
 }
 
+type leaveWaiterFlushTransferer struct {
+	waitFn func(ctx context.Context) error
+	calls  atomic.Int32
+}
+
+func (f *leaveWaiterFlushTransferer) Flush() {}
+func (f *leaveWaiterFlushTransferer) WaitBeforeLeaving(ctx context.Context) error {
+	f.calls.Inc()
+	return f.waitFn(ctx)
+}
+
+func TestLifecycler_WaitBeforeLeaving(t *testing.T) {
+	tests := map[string]struct {
+		timeout              time.Duration
+		unregisterOnShutdown bool
+		waitFn               func(ctx context.Context) error
+		expectedCalls        int32
+		expectedRegistered   bool
+	}{
+		"should not wait if the timeout is disabled": {
+			timeout:              0,
+			unregisterOnShutdown: true,
+			waitFn:               func(_ context.Context) error { return nil },
+			expectedCalls:        0,
+		},
+		"should not wait if the instance doesn't unregister on shutdown": {
+			timeout:              time.Minute,
+			unregisterOnShutdown: false,
+			waitFn:               func(_ context.Context) error { return nil },
+			expectedCalls:        0,
+			expectedRegistered:   true,
+		},
+		"should wait and then unregister": {
+			timeout:              time.Minute,
+			unregisterOnShutdown: true,
+			waitFn:               func(_ context.Context) error { return nil },
+			expectedCalls:        1,
+		},
+		"should unregister anyway when the wait times out": {
+			timeout:              100 * time.Millisecond,
+			unregisterOnShutdown: true,
+			waitFn: func(ctx context.Context) error {
+				<-ctx.Done()
+				return ctx.Err()
+			},
+			expectedCalls: 1,
+		},
+		"should unregister anyway when the wait fails": {
+			timeout:              time.Minute,
+			unregisterOnShutdown: true,
+			waitFn:               func(_ context.Context) error { return errors.New("failed") },
+			expectedCalls:        1,
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			ctx := context.Background()
+			ringStore, closer := consul.NewInMemoryClient(GetCodec(), log.NewNopLogger(), nil)
+			t.Cleanup(func() { assert.NoError(t, closer.Close()) })
+
+			var ringConfig Config
+			flagext.DefaultValues(&ringConfig)
+			ringConfig.KVStore.Mock = ringStore
+
+			lifecyclerConfig := testLifecyclerConfig(ringConfig, "ing1")
+			lifecyclerConfig.WaitBeforeLeavingTimeout = testData.timeout
+			lifecyclerConfig.UnregisterOnShutdown = testData.unregisterOnShutdown
+
+			flushTransferer := &leaveWaiterFlushTransferer{}
+			flushTransferer.waitFn = func(ctx context.Context) error {
+				// The instance is still in the ring, in the LEAVING state, while waiting.
+				d, err := ringStore.Get(ctx, ringKey)
+				require.NoError(t, err)
+				require.NotNil(t, d)
+				assert.Equal(t, LEAVING, d.(*Desc).Ingesters["ing1"].State)
+
+				return testData.waitFn(ctx)
+			}
+
+			lifecycler, err := NewLifecycler(lifecyclerConfig, flushTransferer, "ingester", ringKey, true, true, log.NewNopLogger(), nil)
+			require.NoError(t, err)
+			require.NoError(t, services.StartAndAwaitRunning(ctx, lifecycler))
+
+			test.Poll(t, time.Second, 1, func() any {
+				return lifecycler.HealthyInstancesCount()
+			})
+
+			require.NoError(t, services.StopAndAwaitTerminated(ctx, lifecycler))
+			assert.Equal(t, testData.expectedCalls, flushTransferer.calls.Load())
+
+			d, err := ringStore.Get(ctx, ringKey)
+			require.NoError(t, err)
+			require.NotNil(t, d)
+			_, registered := d.(*Desc).Ingesters["ing1"]
+			assert.Equal(t, testData.expectedRegistered, registered)
+		})
+	}
+}
+
 func TestLifecycler_TwoRingsWithDifferentKeysOnTheSameKVStore(t *testing.T) {
 	// Create a shared ring
 	ringStore, closer := consul.NewInMemoryClient(GetCodec(), log.NewNopLogger(), nil)
@@ -482,6 +482,48 @@ func (d *Desc) RemoveTombstones(limit time.Time) (total, removed int) {
 	return
 }
 
+// ReconcileImport implements memberlist.ImportableMergeable. It returns a copy of the ring without the
+// instances whose heartbeat was older than the heartbeat timeout compared to the most recent heartbeat
+// of the ring, or which are not live members. The ring is rejected if a token is owned by more than one
+// of the returned instances.
+func (d *Desc) ReconcileImport(isLiveMember func(id, addr string) bool, heartbeatTimeout time.Duration) (memberlist.Mergeable, error) {
+	out := NewDesc()
+	if d == nil {
+		return out, nil
+	}
+
+	lastHeartbeat := int64(0)
+	for _, ing := range d.Ingesters {
+		lastHeartbeat = max(lastHeartbeat, ing.Timestamp)
+	}
+
+	ids := make([]string, 0, len(d.Ingesters))
+	for id := range d.Ingesters {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	owners := map[uint32]string{}
+	for _, id := range ids {
+		ing := d.Ingesters[id]
+		if heartbeatTimeout > 0 && time.Unix(lastHeartbeat, 0).Sub(time.Unix(ing.Timestamp, 0)) > heartbeatTimeout {
+			continue
+		}
+		if !isLiveMember(id, ing.Addr) {
+			continue
+		}
+
+		for _, token := range ing.Tokens {
+			if owner, ok := owners[token]; ok {
+				return nil, fmt.Errorf("token %d is owned by both the instances %s and %s", token, owner, id)
+			}
+			owners[token] = id
+		}
+		out.Ingesters[id] = *proto.Clone(&ing).(*InstanceDesc)
+	}
+	return out, nil
+}
+
 // Clone returns a deep copy of the ring state.
 func (d *Desc) Clone() any {
 	return proto.Clone(d).(*Desc)
@@ -291,6 +291,33 @@ func TestDesc_Ready(t *testing.T) {
 	}
 }
 
+func TestDesc_ReconcileImport(t *testing.T) {
+	now := time.Now().Unix()
+	isLiveMember := func(id, _ string) bool { return id != "left" }
+
+	desc := &Desc{Ingesters: map[string]InstanceDesc{
+		"live":  {Addr: "10.0.0.1:9095", Timestamp: now, State: ACTIVE, Tokens: []uint32{1, 2}},
+		"late":  {Addr: "10.0.0.2:9095", Timestamp: now - 30, State: ACTIVE, Tokens: []uint32{3}},
+		"stale": {Addr: "10.0.0.3:9095", Timestamp: now - 120, State: ACTIVE, Tokens: []uint32{1}},
+		"left":  {Addr: "10.0.0.4:9095", Timestamp: now, State: ACTIVE, Tokens: []uint32{2}},
+	}}
+
+	reconciled, err := desc.ReconcileImport(isLiveMember, time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, &Desc{Ingesters: map[string]InstanceDesc{
+		"live": desc.Ingesters["live"],
+		"late": desc.Ingesters["late"],
+	}}, reconciled)
+
+	// The imported ring is not modified.
+	assert.Len(t, desc.Ingesters, 4)
+
+	// The stale instances are kept when the heartbeat timeout is disabled, and a ring with
+	// conflicting tokens is rejected.
+	_, err = desc.ReconcileImport(isLiveMember, 0)
+	require.EqualError(t, err, "token 1 is owned by both the instances live and stale")
+}
+
 func TestDesc_getTokensByZone(t *testing.T) {
 	tests := map[string]struct {
 		desc     *Desc
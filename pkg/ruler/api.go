@@ -66,10 +66,29 @@ type RuleGroup struct {
 	LastEvaluation time.Time `json:"lastEvaluation"`
 	EvaluationTime float64   `json:"evaluationTime"`
 	Limit          int64     `json:"limit"`
+	QueryOffset    float64   `json:"queryOffset,omitempty"`
 }
 
 type rule any
 
+// RuleGroupStatsDiscovery has the evaluation stats of all rule groups.
+type RuleGroupStatsDiscovery struct {
+	RuleGroups []*RuleGroupStats `json:"groups"`
+}
+
+// RuleGroupStats has the evaluation stats of a rule group.
+type RuleGroupStats struct {
+	Name           string    `json:"name"`
+	File           string    `json:"file"`
+	Interval       float64   `json:"interval"`
+	LastEvaluation time.Time `json:"lastEvaluation"`
+	EvaluationTime float64   `json:"evaluationTime"`
+	// Number of samples produced by the last evaluation of the group.
+	Samples int64 `json:"samples"`
+	// First error returned by the last evaluation of the group rules, if any.
+	LastError string `json:"lastError"`
+}
+
 type alertingRule struct {
 	// State can be "pending", "firing", "inactive".
 	State          string        `json:"state"`
@@ -209,6 +228,9 @@ func (a *API) PrometheusRules(w http.ResponseWriter, req *http.Request) {
 			EvaluationTime: g.GetEvaluationDuration().Seconds(),
 			Limit:          g.Group.Limit,
 		}
+		if g.Group.QueryOffset != nil {
+			grp.QueryOffset = g.Group.QueryOffset.Seconds()
+		}
 
 		for i, rl := range g.ActiveRules {
 			if g.ActiveRules[i].Rule.Alert != "" {
@@ -393,6 +415,78 @@ func (a *API) PrometheusAlerts(w http.ResponseWriter, req *http.Request) {
 	}
 }
 
+// RuleGroupsStats returns the last evaluation duration, number of produced samples and last error
+// of each rule group of the tenant.
+func (a *API) RuleGroupsStats(w http.ResponseWriter, req *http.Request) {
+	logger := util_log.WithContext(req.Context(), a.logger)
+	userID, err := users.TenantID(req.Context())
+	if err != nil || userID == "" {
+		level.Error(logger).Log("msg", "error extracting org id from context", "err", err)
+		util_api.RespondError(logger, w, v1.ErrBadData, "no valid org id found", http.StatusBadRequest)
+		return
+	}
+
+	if err := req.ParseForm(); err != nil {
+		level.Error(logger).Log("msg", "error parsing form/query params", "err", err)
+		util_api.RespondError(logger, w, v1.ErrBadData, "error parsing form/query params", http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	rulesRequest := RulesRequest{
+		RuleGroupNames: req.Form["rule_group[]"],
+		Files:          req.Form["file[]"],
+		ExcludeAlerts:  true,
+		MaxRuleGroups:  -1,
+	}
+	rulesResponse, err := a.ruler.GetRules(req.Context(), rulesRequest)
+
+	if err != nil {
+		util_api.RespondError(logger, w, v1.ErrServer, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	groups := make([]*RuleGroupStats, 0, len(rulesResponse.Groups))
+	for _, g := range rulesResponse.Groups {
+		grp := &RuleGroupStats{
+			Name:           g.Group.Name,
+			File:           g.Group.Namespace,
+			Interval:       g.Group.Interval.Seconds(),
+			LastEvaluation: g.GetEvaluationTimestamp(),
+			EvaluationTime: g.GetEvaluationDuration().Seconds(),
+			Samples:        g.GetLastEvaluationSamples(),
+		}
+		for _, rl := range g.ActiveRules {
+			if rl.GetLastError() != "" {
+				grp.LastError = rl.GetLastError()
+				break
+			}
+		}
+		groups = append(groups, grp)
+	}
+
+	sort.Slice(groups, func(i, j int) bool {
+		if groups[i].File == groups[j].File {
+			return groups[i].Name < groups[j].Name
+		}
+		return groups[i].File < groups[j].File
+	})
+
+	b, err := json.Marshal(&util_api.Response{
+		Status: "success",
+		Data:   &RuleGroupStatsDiscovery{RuleGroups: groups},
+	})
+	if err != nil {
+		level.Error(logger).Log("msg", "error marshaling json response", "err", err)
+		util_api.RespondError(logger, w, v1.ErrServer, "unable to marshal the requested data", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	if n, err := w.Write(b); err != nil {
+		level.Error(logger).Log("msg", "error writing response", "bytesWritten", n, "err", err)
+	}
+}
+
 var (
 	// ErrNoNamespace signals that no namespace was specified in the request
 	ErrNoNamespace = errors.New("a namespace must be provided in the request")
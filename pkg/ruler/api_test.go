@@ -95,6 +95,36 @@ func TestAPIResponseSerialization(t *testing.T) {
 				"groupNextToken": "abcdef"
 			}`, responseTime),
 		},
+		"Rules with query offset": {
+			rules: RuleDiscovery{
+				RuleGroups: []*RuleGroup{
+					{
+						Name:           "Test",
+						File:           "/rules/Test",
+						Rules:          make([]rule, 0),
+						Interval:       60,
+						LastEvaluation: lastEvalTime,
+						EvaluationTime: 10,
+						Limit:          0,
+						QueryOffset:    120,
+					},
+				},
+			},
+			expectedJSON: fmt.Sprintf(`{
+				"groups": [
+					{
+						"evaluationTime": 10,
+						"limit": 0,
+						"queryOffset": 120,
+						"name": "Test",
+						"file": "/rules/Test",
+						"interval": 60,
+						"rules": [],
+						"lastEvaluation": "%s"
+					}
+				]
+			}`, responseTime),
+		},
 	}
 
 	for name, tc := range testCases {
@@ -461,6 +491,41 @@ func TestRuler_alerts(t *testing.T) {
 	require.Equal(t, string(expectedResponse), string(body))
 }
 
+func TestRuler_RuleGroupsStats(t *testing.T) {
+	store := newMockRuleStore(mockRules, nil)
+	cfg := defaultRulerConfig(t)
+
+	r := newTestRuler(t, cfg, store, nil)
+	defer services.StopAndAwaitTerminated(context.Background(), r) //nolint:errcheck
+
+	a := NewAPI(r, r.store, log.NewNopLogger())
+
+	getStats := func(userID string) RuleGroupStatsDiscovery {
+		req := requestFor(t, http.MethodGet, "https://localhost:8080/api/prom/api/v1/rule_groups/stats", nil, userID)
+		w := httptest.NewRecorder()
+		a.RuleGroupsStats(w, req)
+
+		resp := w.Result()
+		body, _ := io.ReadAll(resp.Body)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		stats := RuleGroupStatsDiscovery{}
+		require.NoError(t, json.Unmarshal(body, &util_api.Response{Data: &stats}))
+		return stats
+	}
+
+	stats := getStats("user1")
+	require.Len(t, stats.RuleGroups, 1)
+	require.Equal(t, "group1", stats.RuleGroups[0].Name)
+	require.Equal(t, "namespace1", stats.RuleGroups[0].File)
+	require.Equal(t, float64(10), stats.RuleGroups[0].Interval)
+	require.Empty(t, stats.RuleGroups[0].LastError)
+
+	// A tenant only sees its own rule groups.
+	stats = getStats("user-without-rules")
+	require.Empty(t, stats.RuleGroups)
+}
+
 func TestRuler_Create(t *testing.T) {
 	store := newMockRuleStore(make(map[string]rulespb.RuleGroupList), nil)
 	cfg := defaultRulerConfig(t)
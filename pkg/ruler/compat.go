@@ -20,6 +20,7 @@ import (
 	"github.com/prometheus/prometheus/promql"
 	"github.com/prometheus/prometheus/rules"
 	"github.com/prometheus/prometheus/storage"
+	"github.com/prometheus/prometheus/storage/remote"
 	"github.com/prometheus/prometheus/util/strutil"
 	"github.com/weaveworks/common/httpgrpc"
 	"github.com/weaveworks/common/user"
@@ -169,6 +170,7 @@ func (t *PusherAppendable) Appender(ctx context.Context) storage.Appender {
 // RulesLimits defines limits used by Ruler.
 type RulesLimits interface {
 	MaxQueryLength(userID string) time.Duration
+	QueryLookbackDelta(userID string) time.Duration
 	RulerTenantShardSize(userID string) float64
 	RulerMaxRuleGroupsPerTenant(userID string) int
 	RulerMaxRulesPerRuleGroup(userID string) int
@@ -177,6 +179,7 @@ type RulesLimits interface {
 	RulerExternalLabels(userID string) labels.Labels
 	RulerExternalURL(userID string) string
 	RulerAlertGeneratorURLTemplate(userID string) string
+	RulerMaxConcurrentRuleGroupEvaluations(userID string) int
 }
 
 type QueryExecutor func(ctx context.Context, qs string, t time.Time) (promql.Vector, error)
@@ -190,15 +193,21 @@ func engineQueryFunc(engine promql.QueryEngine, frontendClient *frontendClient,
 	} else {
 		// query to engine
 		executor = func(ctx context.Context, qs string, t time.Time) (promql.Vector, error) {
-			return executeQuery(ctx, engine, q, qs, t)
+			return executeQuery(ctx, engine, q, qs, t, overrides.QueryLookbackDelta(userID))
 		}
 	}
 
 	return wrapWithMiddleware(executor, overrides, userID, lookbackDelta)
 }
 
-func executeQuery(ctx context.Context, engine promql.QueryEngine, q storage.Queryable, qs string, t time.Time) (promql.Vector, error) {
-	qry, err := engine.NewInstantQuery(ctx, q, nil, qs, t)
+// executeQuery runs the query with the engine. The lookback delta overrides the one of the engine, if set.
+func executeQuery(ctx context.Context, engine promql.QueryEngine, q storage.Queryable, qs string, t time.Time, lookbackDelta time.Duration) (promql.Vector, error) {
+	var opts promql.QueryOpts
+	if lookbackDelta > 0 {
+		opts = promql.NewPrometheusQueryOpts(false, lookbackDelta)
+	}
+
+	qry, err := engine.NewInstantQuery(ctx, q, opts, qs, t)
 	if err != nil {
 		return nil, err
 	}
@@ -232,8 +241,13 @@ func wrapWithMiddleware(next QueryExecutor, overrides RulesLimits, userID string
 			// If failed to parse expression, skip checking select range.
 			// Fail the query in the engine.
 			if err == nil {
+				queryLookbackDelta := lookbackDelta
+				if tenantLookbackDelta := overrides.QueryLookbackDelta(userID); tenantLookbackDelta > 0 {
+					queryLookbackDelta = tenantLookbackDelta
+				}
+
 				// Enforce query length across all selectors in the query.
-				length := promql_util.FindNonOverlapQueryLength(expr, 0, 0, lookbackDelta)
+				length := promql_util.FindNonOverlapQueryLength(expr, 0, 0, queryLookbackDelta)
 				if length > maxQueryLength {
 					return nil, validation.LimitError(fmt.Sprintf(validation.ErrQueryTooLong, length, maxQueryLength))
 				}
@@ -407,10 +421,26 @@ func DefaultTenantManagerFactory(cfg Config, p Pusher, q storage.Queryable, engi
 		// The cache is invalidated if the template string changes via runtime config.
 		tmplCache := &generatorURLTemplateCache{}
 
+		var appendable storage.Appendable = NewPusherAppendable(p, userID, overrides,
+			evalMetrics.TotalWritesVec.WithLabelValues(userID),
+			evalMetrics.FailedWritesVec.WithLabelValues(userID))
+		if cfg.RemoteWrite.IsEnabled() {
+			client, err := remote.NewWriteClient("ruler-"+userID, cfg.RemoteWrite.clientConfig(userID))
+			if err != nil {
+				return nil, fmt.Errorf("create remote write client: %w", err)
+			}
+			appendable = NewRemoteWriteAppendable(appendable, client, userID, logger,
+				evalMetrics.TotalRemoteWritesVec.WithLabelValues(userID),
+				evalMetrics.FailedRemoteWritesVec.WithLabelValues(userID))
+		}
+
+		var groupLoader rules.GroupLoader = rules.FileLoader{}
+		if cfg.EvaluateRulesInDependencyOrder {
+			groupLoader = dependencyOrderedGroupLoader{GroupLoader: groupLoader, logger: logger}
+		}
+
 		return rules.NewManager(&rules.ManagerOptions{
-			Appendable: NewPusherAppendable(p, userID, overrides,
-				evalMetrics.TotalWritesVec.WithLabelValues(userID),
-				evalMetrics.FailedWritesVec.WithLabelValues(userID)),
+			Appendable:  appendable,
 			Queryable:   q,
 			QueryFunc:   queryFunc,
 			Context:     prometheusContext,
@@ -439,6 +469,7 @@ func DefaultTenantManagerFactory(cfg Config, p Pusher, q storage.Queryable, engi
 				return overrides.RulerQueryOffset(userID)
 			},
 			RestoreNewRuleGroups: cfg.EnableSharding,
+			GroupLoader:          groupLoader,
 		}), nil
 	}
 }
@@ -18,6 +18,7 @@ import (
 	"github.com/prometheus/prometheus/model/value"
 	"github.com/prometheus/prometheus/promql"
 	"github.com/prometheus/prometheus/promql/parser"
+	"github.com/prometheus/prometheus/promql/promqltest"
 	"github.com/prometheus/prometheus/storage"
 	"github.com/prometheus/prometheus/tsdb/tsdbutil"
 	"github.com/stretchr/testify/require"
@@ -414,6 +415,42 @@ func TestRecordAndReportRuleQueryMetrics(t *testing.T) {
 	require.Equal(t, testutil.ToFloat64(metrics.RulerQueryChunkBytes.WithLabelValues("userID")), float64(10))
 	require.Equal(t, testutil.ToFloat64(metrics.RulerQueryDataBytes.WithLabelValues("userID")), float64(14))
 }
+
+func TestEngineQueryFunc_ShouldUseTheTenantLookbackDelta(t *testing.T) {
+	storage := promqltest.LoadedStorage(t, `
+		load 1m
+			up 1
+	`)
+	t.Cleanup(func() { _ = storage.Close() })
+
+	engine := promql.NewEngine(promql.EngineOpts{
+		MaxSamples:    1e6,
+		Timeout:       time.Minute,
+		LookbackDelta: 5 * time.Minute,
+	})
+
+	// The only sample is older than the lookback delta of the engine.
+	ts := time.Unix(0, 0).Add(10 * time.Minute)
+
+	for name, tc := range map[string]struct {
+		lookbackDelta  time.Duration
+		expectedResult int
+	}{
+		"without tenant lookback delta":                          {lookbackDelta: 0, expectedResult: 0},
+		"with tenant lookback delta":                             {lookbackDelta: 15 * time.Minute, expectedResult: 1},
+		"with tenant lookback delta shorter than the sample age": {lookbackDelta: time.Minute, expectedResult: 0},
+	} {
+		t.Run(name, func(t *testing.T) {
+			limits := &ruleLimits{queryLookbackDelta: tc.lookbackDelta}
+			qf := engineQueryFunc(engine, nil, storage, limits, "user-1", 5*time.Minute)
+
+			result, err := qf(context.Background(), "up", ts)
+			require.NoError(t, err)
+			require.Len(t, result, tc.expectedResult)
+		})
+	}
+}
+
 func TestPusherAppender_Commit_WithDiscardOutOfOrder(t *testing.T) {
 	pusher := &fakePusher{response: &cortexpb.WriteResponse{}}
 	counter := prometheus.NewCounter(prometheus.CounterOpts{Name: "test"})
@@ -0,0 +1,111 @@
+package ruler
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	promRules "github.com/prometheus/prometheus/rules"
+)
+
+// userGroupEvalLimiter limits the number of rule groups of each user evaluated concurrently.
+// The rule group evaluations exceeding the limit wait for a running one to complete.
+type userGroupEvalLimiter struct {
+	limits RulesLimits
+
+	mtx   sync.Mutex
+	users map[string]*groupEvalSlots
+
+	inflight    *prometheus.GaugeVec
+	waitSeconds *prometheus.CounterVec
+}
+
+type groupEvalSlots struct {
+	limit int
+	slots chan struct{}
+}
+
+func newUserGroupEvalLimiter(limits RulesLimits, reg prometheus.Registerer) *userGroupEvalLimiter {
+	return &userGroupEvalLimiter{
+		limits: limits,
+		users:  map[string]*groupEvalSlots{},
+		inflight: promauto.With(reg).NewGaugeVec(prometheus.GaugeOpts{
+			Name: "cortex_ruler_rule_group_evaluations_inflight",
+			Help: "Number of rule groups currently evaluated per user.",
+		}, []string{"user"}),
+		waitSeconds: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_ruler_rule_group_evaluation_wait_seconds_total",
+			Help: "Total time spent by rule group evaluations waiting for the max concurrent rule group evaluations per user limit.",
+		}, []string{"user"}),
+	}
+}
+
+// wrap returns a GroupEvalIterationFunc running next once the rule group evaluation is allowed by the user limit.
+func (l *userGroupEvalLimiter) wrap(userID string, next promRules.GroupEvalIterationFunc) promRules.GroupEvalIterationFunc {
+	return func(ctx context.Context, g *promRules.Group, evalTimestamp time.Time) {
+		release, ok := l.acquire(ctx, userID)
+		if !ok {
+			// The rules manager is stopping.
+			return
+		}
+		defer release()
+
+		next(ctx, g, evalTimestamp)
+	}
+}
+
+func (l *userGroupEvalLimiter) acquire(ctx context.Context, userID string) (release func(), ok bool) {
+	inflight := l.inflight.WithLabelValues(userID)
+
+	slots := l.getSlots(userID)
+	if slots == nil {
+		inflight.Inc()
+		return inflight.Dec, true
+	}
+
+	start := time.Now()
+	select {
+	case slots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, false
+	}
+	l.waitSeconds.WithLabelValues(userID).Add(time.Since(start).Seconds())
+
+	inflight.Inc()
+	return func() {
+		inflight.Dec()
+		<-slots
+	}, true
+}
+
+// getSlots returns the slots of the user rule group evaluations, or nil if they're not limited.
+// The slots are replaced when the limit changes, while the running evaluations release the old ones.
+func (l *userGroupEvalLimiter) getSlots(userID string) chan struct{} {
+	limit := l.limits.RulerMaxConcurrentRuleGroupEvaluations(userID)
+
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	if limit <= 0 {
+		delete(l.users, userID)
+		return nil
+	}
+
+	s, ok := l.users[userID]
+	if !ok || s.limit != limit {
+		s = &groupEvalSlots{limit: limit, slots: make(chan struct{}, limit)}
+		l.users[userID] = s
+	}
+	return s.slots
+}
+
+func (l *userGroupEvalLimiter) remove(userID string) {
+	l.mtx.Lock()
+	delete(l.users, userID)
+	l.mtx.Unlock()
+
+	l.inflight.DeleteLabelValues(userID)
+	l.waitSeconds.DeleteLabelValues(userID)
+}
@@ -0,0 +1,90 @@
+package ruler
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	promRules "github.com/prometheus/prometheus/rules"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/atomic"
+
+	"github.com/cortexproject/cortex/pkg/util/test"
+)
+
+func TestUserGroupEvalLimiter(t *testing.T) {
+	limits := &ruleLimits{maxConcurrentGroupEvals: 2}
+	limiter := newUserGroupEvalLimiter(limits, prometheus.NewPedanticRegistry())
+
+	var (
+		running    atomic.Int64
+		maxRunning atomic.Int64
+		evaluated  atomic.Int64
+		unblock    = make(chan struct{})
+	)
+	iterationFunc := limiter.wrap("user-1", func(_ context.Context, _ *promRules.Group, _ time.Time) {
+		current := running.Inc()
+		for {
+			maxCurrent := maxRunning.Load()
+			if current <= maxCurrent || maxRunning.CompareAndSwap(maxCurrent, current) {
+				break
+			}
+		}
+		<-unblock
+		running.Dec()
+		evaluated.Inc()
+	})
+
+	wg := sync.WaitGroup{}
+	for range 5 {
+		wg.Go(func() {
+			iterationFunc(context.Background(), nil, time.Now())
+		})
+	}
+
+	// The evaluations exceeding the limit wait for the running ones.
+	test.Poll(t, time.Second, int64(2), func() any {
+		return running.Load()
+	})
+	assert.Equal(t, float64(2), testutil.ToFloat64(limiter.inflight.WithLabelValues("user-1")))
+
+	close(unblock)
+	wg.Wait()
+
+	assert.Equal(t, int64(2), maxRunning.Load())
+	assert.Equal(t, int64(5), evaluated.Load())
+	assert.Equal(t, float64(0), testutil.ToFloat64(limiter.inflight.WithLabelValues("user-1")))
+	assert.Greater(t, testutil.ToFloat64(limiter.waitSeconds.WithLabelValues("user-1")), float64(0))
+
+	t.Run("should not evaluate the rule group once the context is canceled while waiting", func(t *testing.T) {
+		limits := &ruleLimits{maxConcurrentGroupEvals: 1}
+		limiter := newUserGroupEvalLimiter(limits, prometheus.NewPedanticRegistry())
+
+		release, ok := limiter.acquire(context.Background(), "user-1")
+		require.True(t, ok)
+		defer release()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		evaluated := false
+		limiter.wrap("user-1", func(_ context.Context, _ *promRules.Group, _ time.Time) {
+			evaluated = true
+		})(ctx, nil, time.Now())
+		assert.False(t, evaluated)
+	})
+
+	t.Run("should not limit the rule group evaluations if the limit is disabled", func(t *testing.T) {
+		limiter := newUserGroupEvalLimiter(&ruleLimits{}, prometheus.NewPedanticRegistry())
+
+		for range 3 {
+			_, ok := limiter.acquire(context.Background(), "user-1")
+			require.True(t, ok)
+		}
+		assert.Equal(t, float64(3), testutil.ToFloat64(limiter.inflight.WithLabelValues("user-1")))
+	})
+}
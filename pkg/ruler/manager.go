@@ -56,6 +56,9 @@ type DefaultMultiTenantManager struct {
 	// Per-user externalURL.
 	userExternalURL *userExternalURL
 
+	// Per-user rule group evaluations limiter.
+	userGroupEvalLimiter *userGroupEvalLimiter
+
 	// rules backup
 	rulesBackupManager *rulesBackupManager
 
@@ -105,6 +108,7 @@ func NewDefaultMultiTenantManager(cfg Config, limits RulesLimits, managerFactory
 		notifiers:                 map[string]*rulerNotifier{},
 		userExternalLabels:        newUserExternalLabels(cfg.ExternalLabels, limits),
 		userExternalURL:           newUserExternalURL(cfg.ExternalURL.String(), limits),
+		userGroupEvalLimiter:      newUserGroupEvalLimiter(limits, reg),
 		notifiersDiscoveryMetrics: notifiersDiscoveryMetrics,
 		mapper:                    newMapper(cfg.RulePath, logger),
 		userManagers:              map[string]RulesManager{},
@@ -171,6 +175,7 @@ func (r *DefaultMultiTenantManager) SyncRuleGroups(ctx context.Context, ruleGrou
 			r.mapper.cleanupUser(userID)
 			r.userExternalLabels.remove(userID)
 			r.userExternalURL.remove(userID)
+			r.userGroupEvalLimiter.remove(userID)
 			r.lastReloadSuccessful.DeleteLabelValues(userID)
 			r.lastReloadSuccessfulTimestamp.DeleteLabelValues(userID)
 			r.configUpdatesTotal.DeleteLabelValues(userID)
@@ -234,7 +239,7 @@ func (r *DefaultMultiTenantManager) syncRulesToManager(ctx context.Context, user
 		if (rulesUpdated || externalLabelsUpdated || externalURLUpdated) && existing {
 			r.updateRuleCache(user, manager.RuleGroups())
 		}
-		err = manager.Update(r.cfg.EvaluationInterval, files, externalLabels, externalURL, r.ruleGroupIterationFunc)
+		err = manager.Update(r.cfg.EvaluationInterval, files, externalLabels, externalURL, r.userGroupEvalLimiter.wrap(user, r.ruleGroupIterationFunc))
 		r.deleteRuleCache(user)
 		if err != nil {
 			r.lastReloadSuccessful.WithLabelValues(user).Set(0)
@@ -416,6 +421,10 @@ func (r *DefaultMultiTenantManager) GetRules(userID string) []*promRules.Group {
 	return groups
 }
 
+func (r *DefaultMultiTenantManager) GetLastEvaluationSamples(userID string) map[string]float64 {
+	return r.userManagerMetrics.GetLastEvaluationSamples(userID)
+}
+
 func (r *DefaultMultiTenantManager) GetBackupRules(userID string) rulespb.RuleGroupList {
 	if r.rulesBackupManager != nil {
 		return r.rulesBackupManager.getRuleGroups(userID)
@@ -504,5 +513,13 @@ func (m *DefaultMultiTenantManager) ValidateRuleGroup(g rulefmt.RuleGroup) []err
 		}
 	}
 
+	errs = append(errs, validateRuleGroupRemoteWriteLabels(g)...)
+
+	if m.cfg.EvaluateRulesInDependencyOrder {
+		if _, err := orderRulesByDependency(g.Rules); err != nil {
+			errs = append(errs, fmt.Errorf("invalid rules config: rule group '%s': %w", g.Name, err))
+		}
+	}
+
 	return errs
 }
@@ -169,6 +169,35 @@ func (m *ManagerMetrics) RemoveUserRegistry(user string) {
 	m.regs.RemoveUserRegistry(user, true)
 }
 
+// GetLastEvaluationSamples returns the number of samples produced by the last evaluation
+// of each rule group of the given user, keyed by the rule group key.
+func (m *ManagerMetrics) GetLastEvaluationSamples(user string) map[string]float64 {
+	reg := m.regs.GetRegistryForUser(user)
+	if reg == nil {
+		return nil
+	}
+
+	families, err := reg.Gather()
+	if err != nil {
+		return nil
+	}
+
+	samples := map[string]float64{}
+	for _, mf := range families {
+		if mf.GetName() != "prometheus_rule_group_last_evaluation_samples" {
+			continue
+		}
+		for _, metric := range mf.GetMetric() {
+			for _, l := range metric.GetLabel() {
+				if l.GetName() == "rule_group" {
+					samples[l.GetValue()] = metric.GetGauge().GetValue()
+				}
+			}
+		}
+	}
+	return samples
+}
+
 // Describe implements the Collector interface
 func (m *ManagerMetrics) Describe(out chan<- *prometheus.Desc) {
 	out <- m.EvalDuration
@@ -226,15 +255,17 @@ func (m *ManagerMetrics) Collect(out chan<- prometheus.Metric) {
 }
 
 type RuleEvalMetrics struct {
-	TotalWritesVec       *prometheus.CounterVec
-	FailedWritesVec      *prometheus.CounterVec
-	TotalQueriesVec      *prometheus.CounterVec
-	FailedQueriesVec     *prometheus.CounterVec
-	RulerQuerySeconds    *prometheus.CounterVec
-	RulerQuerySeries     *prometheus.CounterVec
-	RulerQuerySamples    *prometheus.CounterVec
-	RulerQueryChunkBytes *prometheus.CounterVec
-	RulerQueryDataBytes  *prometheus.CounterVec
+	TotalWritesVec        *prometheus.CounterVec
+	FailedWritesVec       *prometheus.CounterVec
+	TotalRemoteWritesVec  *prometheus.CounterVec
+	FailedRemoteWritesVec *prometheus.CounterVec
+	TotalQueriesVec       *prometheus.CounterVec
+	FailedQueriesVec      *prometheus.CounterVec
+	RulerQuerySeconds     *prometheus.CounterVec
+	RulerQuerySeries      *prometheus.CounterVec
+	RulerQuerySamples     *prometheus.CounterVec
+	RulerQueryChunkBytes  *prometheus.CounterVec
+	RulerQueryDataBytes   *prometheus.CounterVec
 }
 
 func NewRuleEvalMetrics(cfg Config, reg prometheus.Registerer) *RuleEvalMetrics {
@@ -247,6 +278,14 @@ func NewRuleEvalMetrics(cfg Config, reg prometheus.Registerer) *RuleEvalMetrics
 			Name: "cortex_ruler_write_requests_failed_total",
 			Help: "Number of failed write requests to ingesters.",
 		}, []string{"user"}),
+		TotalRemoteWritesVec: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_ruler_remote_write_requests_total",
+			Help: "Number of remote write requests of the series produced by the opted-in rules.",
+		}, []string{"user"}),
+		FailedRemoteWritesVec: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_ruler_remote_write_requests_failed_total",
+			Help: "Number of failed remote write requests of the series produced by the opted-in rules.",
+		}, []string{"user"}),
 		TotalQueriesVec: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
 			Name: "cortex_ruler_queries_total",
 			Help: "Number of queries executed by ruler.",
@@ -285,6 +324,8 @@ func NewRuleEvalMetrics(cfg Config, reg prometheus.Registerer) *RuleEvalMetrics
 func (m *RuleEvalMetrics) deletePerUserMetrics(userID string) {
 	m.TotalWritesVec.DeleteLabelValues(userID)
 	m.FailedWritesVec.DeleteLabelValues(userID)
+	m.TotalRemoteWritesVec.DeleteLabelValues(userID)
+	m.FailedRemoteWritesVec.DeleteLabelValues(userID)
 	m.TotalQueriesVec.DeleteLabelValues(userID)
 	m.FailedQueriesVec.DeleteLabelValues(userID)
 
@@ -560,6 +560,19 @@ func TestMetricsArePerUser(t *testing.T) {
 	}
 }
 
+func TestManagerMetrics_GetLastEvaluationSamples(t *testing.T) {
+	managerMetrics := NewManagerMetrics(false)
+	managerMetrics.AddUserRegistry("user1", populateManager(1))
+	managerMetrics.AddUserRegistry("user2", populateManager(10))
+
+	assert.Equal(t, map[string]float64{"group_one": 1000, "group_two": 1000}, managerMetrics.GetLastEvaluationSamples("user1"))
+	assert.Equal(t, map[string]float64{"group_one": 10000, "group_two": 10000}, managerMetrics.GetLastEvaluationSamples("user2"))
+	assert.Nil(t, managerMetrics.GetLastEvaluationSamples("user3"))
+
+	managerMetrics.RemoveUserRegistry("user1")
+	assert.Nil(t, managerMetrics.GetLastEvaluationSamples("user1"))
+}
+
 func TestRuleEvalMetricsDeletePerUserMetrics(t *testing.T) {
 	dir := t.TempDir()
 	reg := prometheus.NewPedanticRegistry()
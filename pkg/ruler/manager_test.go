@@ -2,12 +2,14 @@ package ruler
 
 import (
 	"context"
+	"path/filepath"
 	"sync"
 	"testing"
 	"time"
 
 	"github.com/go-kit/log"
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
 	"github.com/prometheus/prometheus/model/labels"
 	"github.com/prometheus/prometheus/model/rulefmt"
 	"github.com/prometheus/prometheus/notifier"
@@ -113,6 +115,60 @@ func TestSyncRuleGroups(t *testing.T) {
 	})
 }
 
+func TestSyncRuleGroups_ShouldOnlyReloadChangedRuleGroups(t *testing.T) {
+	cfg := defaultRulerConfig(t)
+	m := newManager(t, cfg)
+	defer m.Stop()
+
+	const user = "testUser"
+
+	newRuleGroup := func(namespace, name, expr string) *rulespb.RuleGroupDesc {
+		return &rulespb.RuleGroupDesc{
+			Name:      name,
+			Namespace: namespace,
+			Interval:  time.Minute,
+			User:      user,
+			Rules:     []*rulespb.RuleDesc{{Record: "test_rule", Expr: expr}},
+		}
+	}
+
+	ruleGroupsByKey := func() map[string]*promRules.Group {
+		groups := map[string]*promRules.Group{}
+		for _, g := range getManager(m, user).RuleGroups() {
+			groups[promRules.GroupKey(filepath.Base(g.File()), g.Name())] = g
+		}
+		return groups
+	}
+
+	m.SyncRuleGroups(context.Background(), map[string]rulespb.RuleGroupList{
+		user: {
+			newRuleGroup("ns1", "group1", "up"),
+			newRuleGroup("ns1", "group2", "up"),
+			newRuleGroup("ns2", "group1", "up"),
+		},
+	})
+	before := ruleGroupsByKey()
+	require.Len(t, before, 3)
+
+	// Change a rule within a single group, and add a new group.
+	m.SyncRuleGroups(context.Background(), map[string]rulespb.RuleGroupList{
+		user: {
+			newRuleGroup("ns1", "group1", "up"),
+			newRuleGroup("ns1", "group2", "sum(up)"),
+			newRuleGroup("ns2", "group1", "up"),
+			newRuleGroup("ns2", "group2", "up"),
+		},
+	})
+	after := ruleGroupsByKey()
+	require.Len(t, after, 4)
+
+	// Untouched groups keep running, while the changed one has been recreated.
+	require.Same(t, before["ns1;group1"], after["ns1;group1"])
+	require.NotSame(t, before["ns1;group2"], after["ns1;group2"])
+	require.Same(t, before["ns2;group1"], after["ns2;group1"])
+	require.Contains(t, after, "ns2;group2")
+}
+
 func TestSlowRuleGroupSyncDoesNotSlowdownListRules(t *testing.T) {
 	dir := t.TempDir()
 	const user = "testUser"
@@ -452,3 +508,22 @@ func TestValidateRuleGroup_RejectsEmptyGroupName(t *testing.T) {
 	require.NotEmpty(t, errs, "Expected validation errors for empty group name")
 	require.Contains(t, errs[0].Error(), "rule group name must not be empty", "Error should mention empty group name")
 }
+
+func TestValidateRuleGroup_RejectsDependencyCyclesIfEvaluatingRulesInDependencyOrder(t *testing.T) {
+	ruleGroupWithCycle := rulefmt.RuleGroup{
+		Name: "test_group",
+		Rules: []rulefmt.Rule{
+			{Record: "job:a", Expr: "sum(job:b)"},
+			{Record: "job:b", Expr: "sum(job:a)"},
+		},
+	}
+
+	// The cycle is accepted while the rules are evaluated in the declared order.
+	manager := &DefaultMultiTenantManager{cfg: Config{NameValidationScheme: model.UTF8Validation}}
+	require.Empty(t, manager.ValidateRuleGroup(ruleGroupWithCycle))
+
+	manager = &DefaultMultiTenantManager{cfg: Config{NameValidationScheme: model.UTF8Validation, EvaluateRulesInDependencyOrder: true}}
+	errs := manager.ValidateRuleGroup(ruleGroupWithCycle)
+	require.Len(t, errs, 1)
+	require.EqualError(t, errs[0], "invalid rules config: rule group 'test_group': the rules have a dependency cycle: job:a, job:b")
+}
@@ -0,0 +1,240 @@
+package ruler
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/golang/snappy"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	config_util "github.com/prometheus/common/config"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/histogram"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/rulefmt"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/prometheus/prometheus/storage/remote"
+
+	"github.com/cortexproject/cortex/pkg/cortexpb"
+	"github.com/cortexproject/cortex/pkg/util"
+	"github.com/cortexproject/cortex/pkg/util/flagext"
+	"github.com/cortexproject/cortex/pkg/util/tls"
+)
+
+const (
+	// RemoteWriteLabel is the label opting-in the series produced by a rule group (or a single rule)
+	// to the remote write. The label is removed from the series before they're written.
+	RemoteWriteLabel = "__remote_write__"
+
+	// RemoteWriteModeMirror remote writes the series in addition to writing them to the ingesters.
+	RemoteWriteModeMirror = "mirror"
+
+	// RemoteWriteModeOnly remote writes the series instead of writing them to the ingesters.
+	RemoteWriteModeOnly = "only"
+)
+
+// RemoteWriteConfig configures the remote write of the series produced by the opted-in rules.
+type RemoteWriteConfig struct {
+	URL       flagext.URLValue `yaml:"url"`
+	Timeout   time.Duration    `yaml:"timeout"`
+	TLS       tls.ClientConfig `yaml:",inline"`
+	BasicAuth util.BasicAuth   `yaml:",inline"`
+}
+
+func (cfg *RemoteWriteConfig) RegisterFlags(f *flag.FlagSet) {
+	f.Var(&cfg.URL, "ruler.remote-write.url", fmt.Sprintf("EXPERIMENTAL: URL of the Prometheus remote write endpoint the series produced by the rules with the %s label are written to. The label value must be %q to write the series both to the ingesters and the remote write endpoint, or %q to only write them to the remote write endpoint. The tenant ID is sent in the X-Scope-OrgID header. If empty, the remote write is disabled.", RemoteWriteLabel, RemoteWriteModeMirror, RemoteWriteModeOnly))
+	f.DurationVar(&cfg.Timeout, "ruler.remote-write.timeout", 10*time.Second, "Timeout of the remote write requests. The remote write is synchronous: the evaluation of the rule waits for the request to complete, up to the timeout, so a slow endpoint delays the following rules of the group and can make the group miss evaluations.")
+	cfg.TLS.RegisterFlagsWithPrefix("ruler.remote-write", f)
+	cfg.BasicAuth.RegisterFlagsWithPrefix("ruler.remote-write.", f)
+}
+
+// IsEnabled returns whether the remote write is enabled.
+func (cfg *RemoteWriteConfig) IsEnabled() bool {
+	return cfg.URL.URL != nil
+}
+
+func (cfg *RemoteWriteConfig) clientConfig(userID string) *remote.ClientConfig {
+	clientCfg := &remote.ClientConfig{
+		URL:     &config_util.URL{URL: cfg.URL.URL},
+		Timeout: model.Duration(cfg.Timeout),
+		HTTPClientConfig: config_util.HTTPClientConfig{
+			TLSConfig: config_util.TLSConfig{
+				CAFile:             cfg.TLS.CAPath,
+				CertFile:           cfg.TLS.CertPath,
+				KeyFile:            cfg.TLS.KeyPath,
+				InsecureSkipVerify: cfg.TLS.InsecureSkipVerify,
+				ServerName:         cfg.TLS.ServerName,
+			},
+		},
+		Headers: map[string]string{"X-Scope-OrgID": userID},
+	}
+
+	if cfg.BasicAuth.IsEnabled() {
+		clientCfg.HTTPClientConfig.BasicAuth = &config_util.BasicAuth{
+			Username: cfg.BasicAuth.Username,
+			Password: config_util.Secret(cfg.BasicAuth.Password.Value),
+		}
+	} else if cfg.URL.User != nil {
+		clientCfg.HTTPClientConfig.BasicAuth = &config_util.BasicAuth{
+			Username: cfg.URL.User.Username(),
+		}
+		if password, isSet := cfg.URL.User.Password(); isSet {
+			clientCfg.HTTPClientConfig.BasicAuth.Password = config_util.Secret(password)
+		}
+	}
+
+	return clientCfg
+}
+
+// validateRemoteWriteLabel validates the value of the RemoteWriteLabel in the given labels, if any.
+func validateRemoteWriteLabel(lbls map[string]string) error {
+	mode, ok := lbls[RemoteWriteLabel]
+	if !ok || mode == RemoteWriteModeMirror || mode == RemoteWriteModeOnly {
+		return nil
+	}
+	return fmt.Errorf("invalid value %q for label %s: supported values are %q and %q", mode, RemoteWriteLabel, RemoteWriteModeMirror, RemoteWriteModeOnly)
+}
+
+// validateRuleGroupRemoteWriteLabels validates the RemoteWriteLabel of the rule group and its rules.
+func validateRuleGroupRemoteWriteLabels(g rulefmt.RuleGroup) []error {
+	var errs []error
+	if err := validateRemoteWriteLabel(g.Labels); err != nil {
+		errs = append(errs, fmt.Errorf("invalid rules config: rule group '%s': %w", g.Name, err))
+	}
+	for i, r := range g.Rules {
+		if err := validateRemoteWriteLabel(r.Labels); err != nil {
+			errs = append(errs, fmt.Errorf("invalid rules config: rule group '%s', rule %d: %w", g.Name, i+1, err))
+		}
+	}
+	return errs
+}
+
+// RemoteWriteAppendable wraps a storage.Appendable to remote write the series opted-in via the
+// RemoteWriteLabel. The remote write is best effort: failures are tracked and logged, but don't
+// fail the rule evaluation. The series are remote written synchronously when the appender is
+// committed, so the remote write latency adds up to the rule evaluation latency.
+type RemoteWriteAppendable struct {
+	next   storage.Appendable
+	client remote.WriteClient
+	userID string
+	logger log.Logger
+
+	totalWrites  prometheus.Counter
+	failedWrites prometheus.Counter
+}
+
+func NewRemoteWriteAppendable(next storage.Appendable, client remote.WriteClient, userID string, logger log.Logger, totalWrites, failedWrites prometheus.Counter) *RemoteWriteAppendable {
+	return &RemoteWriteAppendable{
+		next:         next,
+		client:       client,
+		userID:       userID,
+		logger:       logger,
+		totalWrites:  totalWrites,
+		failedWrites: failedWrites,
+	}
+}
+
+// Appender returns a storage.Appender
+func (a *RemoteWriteAppendable) Appender(ctx context.Context) storage.Appender {
+	return &remoteWriteAppender{
+		Appender: a.next.Appender(ctx),
+		ctx:      ctx,
+		parent:   a,
+	}
+}
+
+type remoteWriteAppender struct {
+	storage.Appender
+
+	ctx    context.Context
+	parent *RemoteWriteAppendable
+
+	labels          []labels.Labels
+	samples         []cortexpb.Sample
+	histogramLabels []labels.Labels
+	histograms      []cortexpb.WrappedHistogram
+}
+
+func (a *remoteWriteAppender) Append(ref storage.SeriesRef, l labels.Labels, t int64, v float64) (storage.SeriesRef, error) {
+	mode := l.Get(RemoteWriteLabel)
+	if mode == "" {
+		return a.Appender.Append(ref, l, t, v)
+	}
+
+	l = labels.NewBuilder(l).Del(RemoteWriteLabel).Labels()
+	a.labels = append(a.labels, l)
+	a.samples = append(a.samples, cortexpb.Sample{TimestampMs: t, Value: v})
+
+	if mode == RemoteWriteModeOnly {
+		return 0, nil
+	}
+	return a.Appender.Append(ref, l, t, v)
+}
+
+func (a *remoteWriteAppender) AppendHistogram(ref storage.SeriesRef, l labels.Labels, t int64, h *histogram.Histogram, fh *histogram.FloatHistogram) (storage.SeriesRef, error) {
+	mode := l.Get(RemoteWriteLabel)
+	if mode == "" {
+		return a.Appender.AppendHistogram(ref, l, t, h, fh)
+	}
+	if h == nil && fh == nil {
+		return 0, errors.New("no histogram")
+	}
+
+	l = labels.NewBuilder(l).Del(RemoteWriteLabel).Labels()
+	a.histogramLabels = append(a.histogramLabels, l)
+	if h != nil {
+		a.histograms = append(a.histograms, cortexpb.WrappedHistogram{Histogram: cortexpb.HistogramToHistogramProto(t, h)})
+	} else {
+		a.histograms = append(a.histograms, cortexpb.WrappedHistogram{Histogram: cortexpb.FloatHistogramToHistogramProto(t, fh)})
+	}
+
+	if mode == RemoteWriteModeOnly {
+		return 0, nil
+	}
+	return a.Appender.AppendHistogram(ref, l, t, h, fh)
+}
+
+func (a *remoteWriteAppender) Commit() error {
+	err := a.Appender.Commit()
+
+	if len(a.samples) > 0 || len(a.histograms) > 0 {
+		a.parent.totalWrites.Inc()
+		if rwErr := a.remoteWrite(); rwErr != nil {
+			a.parent.failedWrites.Inc()
+			level.Warn(a.parent.logger).Log("msg", "failed to remote write rule evaluation results", "user", a.parent.userID, "err", rwErr)
+		}
+	}
+
+	a.reset()
+	return err
+}
+
+func (a *remoteWriteAppender) remoteWrite() error {
+	req := cortexpb.ToWriteRequest(a.labels, a.samples, nil, nil, cortexpb.RULE)
+	req.AddHistogramTimeSeries(a.histogramLabels, a.histograms)
+	defer cortexpb.ReuseSlice(req.Timeseries)
+
+	data, err := req.Marshal()
+	if err != nil {
+		return errors.Wrap(err, "marshal write request")
+	}
+
+	_, err = a.parent.client.Store(a.ctx, snappy.Encode(nil, data), 0)
+	return err
+}
+
+func (a *remoteWriteAppender) Rollback() error {
+	a.reset()
+	return a.Appender.Rollback()
+}
+
+func (a *remoteWriteAppender) reset() {
+	a.labels = nil
+	a.samples = nil
+	a.histogramLabels = nil
+	a.histograms = nil
+}
@@ -0,0 +1,134 @@
+package ruler
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/golang/snappy"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/rulefmt"
+	"github.com/prometheus/prometheus/storage/remote"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cortexproject/cortex/pkg/cortexpb"
+)
+
+func TestRemoteWriteAppendable(t *testing.T) {
+	tests := map[string]struct {
+		mode               string
+		remoteWriteStatus  int
+		expectedPushed     []labels.Labels
+		expectedRemote     []labels.Labels
+		expectedRWFailures float64
+	}{
+		"series not opted-in are only written to the ingesters": {
+			expectedPushed: []labels.Labels{labels.FromStrings("__name__", "foo")},
+		},
+		"series with the mirror mode are written to the ingesters and the remote write endpoint": {
+			mode:           RemoteWriteModeMirror,
+			expectedPushed: []labels.Labels{labels.FromStrings("__name__", "foo")},
+			expectedRemote: []labels.Labels{labels.FromStrings("__name__", "foo")},
+		},
+		"series with the only mode are only written to the remote write endpoint": {
+			mode:           RemoteWriteModeOnly,
+			expectedRemote: []labels.Labels{labels.FromStrings("__name__", "foo")},
+		},
+		"remote write failures don't fail the commit": {
+			mode:               RemoteWriteModeMirror,
+			remoteWriteStatus:  http.StatusInternalServerError,
+			expectedPushed:     []labels.Labels{labels.FromStrings("__name__", "foo")},
+			expectedRemote:     []labels.Labels{labels.FromStrings("__name__", "foo")},
+			expectedRWFailures: 1,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var (
+				received *cortexpb.WriteRequest
+				orgID    string
+			)
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				orgID = r.Header.Get("X-Scope-OrgID")
+				compressed, err := io.ReadAll(r.Body)
+				require.NoError(t, err)
+				data, err := snappy.Decode(nil, compressed)
+				require.NoError(t, err)
+				received = &cortexpb.WriteRequest{}
+				require.NoError(t, received.Unmarshal(data))
+
+				if tc.remoteWriteStatus != 0 {
+					w.WriteHeader(tc.remoteWriteStatus)
+				}
+			}))
+			defer server.Close()
+
+			serverURL, err := url.Parse(server.URL)
+			require.NoError(t, err)
+			cfg := RemoteWriteConfig{Timeout: time.Second}
+			cfg.URL.URL = serverURL
+
+			client, err := remote.NewWriteClient("test", cfg.clientConfig("user-1"))
+			require.NoError(t, err)
+
+			pusher := &fakePusher{}
+			failures := prometheus.NewCounter(prometheus.CounterOpts{})
+			appendable := NewRemoteWriteAppendable(
+				NewPusherAppendable(pusher, "user-1", nil, prometheus.NewCounter(prometheus.CounterOpts{}), prometheus.NewCounter(prometheus.CounterOpts{})),
+				client, "user-1", log.NewNopLogger(), prometheus.NewCounter(prometheus.CounterOpts{}), failures)
+
+			lbls := labels.FromStrings("__name__", "foo")
+			if tc.mode != "" {
+				lbls = labels.FromStrings("__name__", "foo", RemoteWriteLabel, tc.mode)
+			}
+
+			app := appendable.Appender(context.Background())
+			_, err = app.Append(0, lbls, 120_000, 1)
+			require.NoError(t, err)
+			require.NoError(t, app.Commit())
+
+			var pushed []labels.Labels
+			for _, ts := range pusher.request.Timeseries {
+				pushed = append(pushed, cortexpb.FromLabelAdaptersToLabels(ts.Labels))
+			}
+			assert.Equal(t, tc.expectedPushed, pushed)
+
+			if tc.expectedRemote == nil {
+				assert.Nil(t, received)
+			} else {
+				require.NotNil(t, received)
+				var remote []labels.Labels
+				for _, ts := range received.Timeseries {
+					remote = append(remote, cortexpb.FromLabelAdaptersToLabels(ts.Labels))
+					assert.Equal(t, []cortexpb.Sample{{TimestampMs: 120_000, Value: 1}}, ts.Samples)
+				}
+				assert.Equal(t, tc.expectedRemote, remote)
+				assert.Equal(t, "user-1", orgID)
+			}
+			assert.Equal(t, tc.expectedRWFailures, testutil.ToFloat64(failures))
+		})
+	}
+}
+
+func TestValidateRuleGroupRemoteWriteLabels(t *testing.T) {
+	assert.Empty(t, validateRuleGroupRemoteWriteLabels(rulefmt.RuleGroup{
+		Name:   "group",
+		Labels: map[string]string{RemoteWriteLabel: RemoteWriteModeMirror},
+		Rules:  []rulefmt.Rule{{Record: "foo", Expr: "up", Labels: map[string]string{RemoteWriteLabel: RemoteWriteModeOnly}}},
+	}))
+
+	assert.Len(t, validateRuleGroupRemoteWriteLabels(rulefmt.RuleGroup{
+		Name:   "group",
+		Labels: map[string]string{RemoteWriteLabel: "true"},
+		Rules:  []rulefmt.Rule{{Record: "foo", Expr: "up", Labels: map[string]string{RemoteWriteLabel: "yes"}}},
+	}), 2)
+}
@@ -0,0 +1,141 @@
+package ruler
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/rulefmt"
+	"github.com/prometheus/prometheus/promql/parser"
+	promRules "github.com/prometheus/prometheus/rules"
+
+	cortexparser "github.com/cortexproject/cortex/pkg/parser"
+)
+
+// dependencyOrderedGroupLoader is a GroupLoader which reorders the rules of each group, so that
+// the rules querying the output of a recording rule of the same group are evaluated after it,
+// and consume the value it recorded at the same evaluation timestamp.
+type dependencyOrderedGroupLoader struct {
+	promRules.GroupLoader
+
+	logger log.Logger
+}
+
+func (l dependencyOrderedGroupLoader) Load(identifier string, ignoreUnknownFields bool, nameValidationScheme model.ValidationScheme) (*rulefmt.RuleGroups, []error) {
+	rgs, errs := l.GroupLoader.Load(identifier, ignoreUnknownFields, nameValidationScheme)
+	if errs != nil {
+		return rgs, errs
+	}
+
+	for i, g := range rgs.Groups {
+		ordered, err := orderRulesByDependency(g.Rules)
+		if err != nil {
+			// The cycles are rejected when the rule groups are uploaded, so this happens only
+			// for the groups stored before the ordering was enabled.
+			level.Warn(l.logger).Log("msg", "evaluating the rule group in the declared order", "file", identifier, "group", g.Name, "err", err)
+			continue
+		}
+		rgs.Groups[i].Rules = ordered
+	}
+	return rgs, nil
+}
+
+// orderRulesByDependency returns the rules sorted so that each rule comes after the recording
+// rules whose output it queries. The declared order is kept between the rules which don't depend
+// on each other. An error is returned if the dependencies have a cycle.
+func orderRulesByDependency(rules []rulefmt.Rule) ([]rulefmt.Rule, error) {
+	if len(rules) <= 1 {
+		return rules, nil
+	}
+
+	recordedBy := map[string][]int{}
+	for i, r := range rules {
+		if r.Record != "" {
+			recordedBy[r.Record] = append(recordedBy[r.Record], i)
+		}
+	}
+
+	// dependents[i] lists the rules querying the output of the rule i.
+	dependents := make([][]int, len(rules))
+	pending := make([]int, len(rules))
+	for i, r := range rules {
+		for name := range queriedMetricNames(r.Expr) {
+			for _, j := range recordedBy[name] {
+				// A rule querying its own output depends on the previous evaluation.
+				if j == i {
+					continue
+				}
+				dependents[j] = append(dependents[j], i)
+				pending[i]++
+			}
+		}
+	}
+
+	ordered := make([]rulefmt.Rule, 0, len(rules))
+	done := make([]bool, len(rules))
+	for len(ordered) < len(rules) {
+		next := -1
+		for i := range rules {
+			if !done[i] && pending[i] == 0 {
+				next = i
+				break
+			}
+		}
+
+		if next < 0 {
+			var cycle []string
+			for i, r := range rules {
+				if !done[i] {
+					cycle = append(cycle, ruleName(r))
+				}
+			}
+			return nil, fmt.Errorf("the rules have a dependency cycle: %s", strings.Join(cycle, ", "))
+		}
+
+		done[next] = true
+		ordered = append(ordered, rules[next])
+		for _, d := range dependents[next] {
+			pending[d]--
+		}
+	}
+	return ordered, nil
+}
+
+// queriedMetricNames returns the metric names selected by the expression. The selectors without
+// a metric name equality matcher are ignored, since they can't be matched to a recording rule.
+func queriedMetricNames(expr string) map[string]struct{} {
+	names := map[string]struct{}{}
+
+	parsed, err := cortexparser.ParseExpr(expr)
+	if err != nil {
+		return names
+	}
+
+	parser.Inspect(parsed, func(node parser.Node, _ []parser.Node) error {
+		vs, ok := node.(*parser.VectorSelector)
+		if !ok {
+			return nil
+		}
+		if vs.Name != "" {
+			names[vs.Name] = struct{}{}
+			return nil
+		}
+		for _, m := range vs.LabelMatchers {
+			if m.Name == labels.MetricName && m.Type == labels.MatchEqual {
+				names[m.Value] = struct{}{}
+			}
+		}
+		return nil
+	})
+	return names
+}
+
+func ruleName(r rulefmt.Rule) string {
+	if r.Alert != "" {
+		return r.Alert
+	}
+	return r.Record
+}
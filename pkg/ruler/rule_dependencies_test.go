@@ -0,0 +1,121 @@
+package ruler
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/rulefmt"
+	promRules "github.com/prometheus/prometheus/rules"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrderRulesByDependency(t *testing.T) {
+	tests := map[string]struct {
+		rules         []rulefmt.Rule
+		expectedOrder []string
+		expectedErr   string
+	}{
+		"no rules": {
+			rules:         nil,
+			expectedOrder: []string{},
+		},
+		"independent rules keep the declared order": {
+			rules: []rulefmt.Rule{
+				{Record: "job:b", Expr: "sum(b)"},
+				{Record: "job:a", Expr: "sum(a)"},
+				{Alert: "AlertC", Expr: "c > 0"},
+			},
+			expectedOrder: []string{"job:b", "job:a", "AlertC"},
+		},
+		"rules are moved after the recording rules they query": {
+			rules: []rulefmt.Rule{
+				{Alert: "HighRate", Expr: "job:rate:sum > 10"},
+				{Record: "job:rate:sum", Expr: "sum by (job) (job:rate)"},
+				{Record: "unrelated", Expr: "sum(up)"},
+				{Record: "job:rate", Expr: "rate(requests_total[5m])"},
+			},
+			expectedOrder: []string{"unrelated", "job:rate", "job:rate:sum", "HighRate"},
+		},
+		"metric name matchers are dependencies": {
+			rules: []rulefmt.Rule{
+				{Record: "job:sum", Expr: `sum({__name__="job:rate"})`},
+				{Record: "job:rate", Expr: "rate(requests_total[5m])"},
+			},
+			expectedOrder: []string{"job:rate", "job:sum"},
+		},
+		"selectors without a metric name are not dependencies": {
+			rules: []rulefmt.Rule{
+				{Record: "job:count", Expr: `count({job="api"})`},
+				{Record: "job:rate", Expr: "rate(requests_total[5m])"},
+			},
+			expectedOrder: []string{"job:count", "job:rate"},
+		},
+		"rules querying their own output are not cycles": {
+			rules: []rulefmt.Rule{
+				{Record: "job:max", Expr: "max_over_time(job:max[1h]) or up"},
+			},
+			expectedOrder: []string{"job:max"},
+		},
+		"cycles are detected": {
+			rules: []rulefmt.Rule{
+				{Record: "job:independent", Expr: "sum(up)"},
+				{Record: "job:a", Expr: "sum(job:c)"},
+				{Record: "job:b", Expr: "sum(job:a)"},
+				{Record: "job:c", Expr: "sum(job:b)"},
+			},
+			expectedErr: "the rules have a dependency cycle: job:a, job:b, job:c",
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			ordered, err := orderRulesByDependency(testData.rules)
+			if testData.expectedErr != "" {
+				require.EqualError(t, err, testData.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+
+			names := make([]string, 0, len(ordered))
+			for _, r := range ordered {
+				names = append(names, ruleName(r))
+			}
+			assert.Equal(t, testData.expectedOrder, names)
+		})
+	}
+}
+
+func TestDependencyOrderedGroupLoader(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "rules.yaml")
+	require.NoError(t, os.WriteFile(file, []byte(`
+groups:
+  - name: ordered
+    rules:
+      - record: job:rate:sum
+        expr: sum(job:rate)
+      - record: job:rate
+        expr: rate(requests_total[5m])
+  - name: cycle
+    rules:
+      - record: job:a
+        expr: sum(job:b)
+      - record: job:b
+        expr: sum(job:a)
+`), 0o600))
+
+	loader := dependencyOrderedGroupLoader{GroupLoader: promRules.FileLoader{}, logger: log.NewNopLogger()}
+	rgs, errs := loader.Load(file, false, model.UTF8Validation)
+	require.Empty(t, errs)
+	require.Len(t, rgs.Groups, 2)
+
+	assert.Equal(t, "job:rate", rgs.Groups[0].Rules[0].Record)
+	assert.Equal(t, "job:rate:sum", rgs.Groups[0].Rules[1].Record)
+
+	// The group with a cycle keeps the declared order.
+	assert.Equal(t, "job:a", rgs.Groups[1].Rules[0].Record)
+	assert.Equal(t, "job:b", rgs.Groups[1].Rules[1].Record)
+}
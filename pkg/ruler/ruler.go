@@ -144,6 +144,8 @@ type Config struct {
 	NotificationTimeout time.Duration `yaml:"notification_timeout"`
 	// Client configs for interacting with the Alertmanager
 	Notifier NotifierConfig `yaml:"alertmanager_client"`
+	// Remote write of the series produced by the opted-in rules.
+	RemoteWrite RemoteWriteConfig `yaml:"remote_write"`
 
 	// Max time to tolerate outage for restoring "for" state of alert.
 	OutageTolerance time.Duration `yaml:"for_outage_tolerance"`
@@ -155,6 +157,8 @@ type Config struct {
 	ConcurrentEvalsEnabled bool  `yaml:"concurrent_evals_enabled"`
 	MaxConcurrentEvals     int64 `yaml:"max_concurrent_evals"`
 
+	EvaluateRulesInDependencyOrder bool `yaml:"evaluate_rules_in_dependency_order"`
+
 	// Enable sharding rule groups.
 	EnableSharding   bool          `yaml:"enable_sharding"`
 	ShardingStrategy string        `yaml:"sharding_strategy"`
@@ -225,6 +229,7 @@ func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
 	cfg.GRPCClientConfig.RegisterFlagsWithPrefix("ruler.frontendClient", "", f)
 	cfg.Ring.RegisterFlags(f)
 	cfg.Notifier.RegisterFlags(f)
+	cfg.RemoteWrite.RegisterFlags(f)
 	cfg.ThanosEngine.RegisterFlagsWithPrefix("ruler.", f)
 
 	// Deprecated Flags that will be maintained to avoid user disruption
@@ -264,6 +269,7 @@ func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
 	f.DurationVar(&cfg.ResendDelay, "ruler.resend-delay", time.Minute, `Minimum amount of time to wait before resending an alert to Alertmanager.`)
 	f.BoolVar(&cfg.ConcurrentEvalsEnabled, "ruler.concurrent-evals-enabled", false, `If enabled, rules from a single rule group can be evaluated concurrently if there is no dependency between each other. Max concurrency for each rule group is controlled via ruler.max-concurrent-evals flag.`)
 	f.Int64Var(&cfg.MaxConcurrentEvals, "ruler.max-concurrent-evals", 1, `Max concurrency for a single rule group to evaluate independent rules.`)
+	f.BoolVar(&cfg.EvaluateRulesInDependencyOrder, "ruler.evaluate-rules-in-dependency-order", false, "[EXPERIMENTAL] If enabled, the rules of a rule group are evaluated after the recording rules of the same group whose output they query, regardless of the declared order, so that they consume the value recorded at the same evaluation timestamp. The rule groups with a dependency cycle are rejected by the ruler API.")
 
 	f.Var(&cfg.EnabledTenants, "ruler.enabled-tenants", "Comma separated list of tenants whose rules this ruler can evaluate. If specified, only these tenants will be handled by ruler, otherwise this ruler can process rules from all tenants. Subject to sharding.")
 	f.Var(&cfg.DisabledTenants, "ruler.disabled-tenants", "Comma separated list of tenants whose rules this ruler cannot evaluate. If specified, a ruler that would normally pick the specified tenant(s) for processing will ignore them instead. Subject to sharding.")
@@ -291,6 +297,9 @@ type MultiTenantManager interface {
 	BackUpRuleGroups(ctx context.Context, ruleGroups map[string]rulespb.RuleGroupList)
 	// GetRules fetches rules for a particular tenant (userID).
 	GetRules(userID string) []*promRules.Group
+	// GetLastEvaluationSamples returns the number of samples produced by the last evaluation
+	// of each rule group of a particular tenant (userID), keyed by the rule group key.
+	GetLastEvaluationSamples(userID string) map[string]float64
 	// GetBackupRules fetches rules for a particular tenant (userID) that the ruler stores for backup purposes
 	GetBackupRules(userID string) rulespb.RuleGroupList
 	// Stop stops all Manager components.
@@ -1215,6 +1224,7 @@ func (r *Ruler) GetRules(ctx context.Context, rulesRequest RulesRequest) (*Rules
 
 func (r *Ruler) getLocalRules(userID string, rulesRequest RulesRequest, includeBackups bool) (RulesResponse, error) {
 	groups := r.manager.GetRules(userID)
+	samples := r.manager.GetLastEvaluationSamples(userID)
 
 	groupDescs := make([]*GroupStateDesc, 0, len(groups))
 	prefix := filepath.Join(r.cfg.RulePath, userID) + "/"
@@ -1271,8 +1281,9 @@ func (r *Ruler) getLocalRules(userID string, rulesRequest RulesRequest, includeB
 				QueryOffset: &queryOffset,
 			},
 
-			EvaluationTimestamp: group.GetLastEvaluation(),
-			EvaluationDuration:  group.GetEvaluationTime(),
+			EvaluationTimestamp:   group.GetLastEvaluation(),
+			EvaluationDuration:    group.GetEvaluationTime(),
+			LastEvaluationSamples: int64(samples[promRules.GroupKey(group.File(), group.Name())]),
 		}
 		for _, r := range group.Rules() {
 			if len(ruleNameSet) > 0 {
@@ -288,6 +288,8 @@ type GroupStateDesc struct {
 	ActiveRules         []*RuleStateDesc       `protobuf:"bytes,2,rep,name=active_rules,json=activeRules,proto3" json:"active_rules,omitempty"`
 	EvaluationTimestamp time.Time              `protobuf:"bytes,3,opt,name=evaluationTimestamp,proto3,stdtime" json:"evaluationTimestamp"`
 	EvaluationDuration  time.Duration          `protobuf:"bytes,4,opt,name=evaluationDuration,proto3,stdduration" json:"evaluationDuration"`
+	// Number of samples produced by the last evaluation of the group.
+	LastEvaluationSamples int64 `protobuf:"varint,5,opt,name=lastEvaluationSamples,proto3" json:"lastEvaluationSamples,omitempty"`
 }
 
 func (m *GroupStateDesc) Reset()      { *m = GroupStateDesc{} }
@@ -350,6 +352,13 @@ func (m *GroupStateDesc) GetEvaluationDuration() time.Duration {
 	return 0
 }
 
+func (m *GroupStateDesc) GetLastEvaluationSamples() int64 {
+	if m != nil {
+		return m.LastEvaluationSamples
+	}
+	return 0
+}
+
 // RuleStateDesc is a proto representation of a Prometheus Rule
 type RuleStateDesc struct {
 	Rule                *rulespb.RuleDesc `protobuf:"bytes,1,opt,name=rule,proto3" json:"rule,omitempty"`
@@ -556,62 +565,63 @@ func init() {
 func init() { proto.RegisterFile("ruler.proto", fileDescriptor_9ecbec0a4cfddea6) }
 
 var fileDescriptor_9ecbec0a4cfddea6 = []byte{
-	// 880 bytes of a gzipped FileDescriptorProto
+	// 894 bytes of a gzipped FileDescriptorProto
 	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xb4, 0x55, 0x4f, 0x6f, 0xe3, 0x44,
-	0x14, 0x8f, 0xd3, 0x26, 0x4d, 0x5e, 0xda, 0xae, 0x98, 0x66, 0x57, 0x26, 0x54, 0x6e, 0x15, 0x10,
-	0xaa, 0x90, 0xd6, 0x91, 0xca, 0x4a, 0x88, 0x03, 0x42, 0x29, 0xbb, 0xcb, 0xa5, 0x42, 0x2b, 0x77,
-	0xe1, 0x84, 0x14, 0x4d, 0x9c, 0x57, 0xc7, 0xd4, 0xb1, 0xcd, 0xcc, 0x38, 0x0a, 0x37, 0xee, 0x5c,
-	0xf6, 0xc8, 0x99, 0x13, 0x67, 0x3e, 0xc5, 0x1e, 0x2b, 0x4e, 0x2b, 0x84, 0x16, 0x9a, 0x5e, 0x38,
-	0xee, 0x47, 0x40, 0xf3, 0xc6, 0x6e, 0xe2, 0x12, 0xa4, 0x56, 0xab, 0x5e, 0x92, 0x79, 0x7f, 0x7e,
-	0xbf, 0x79, 0xff, 0xe6, 0x19, 0x5a, 0x22, 0x8b, 0x50, 0xb8, 0xa9, 0x48, 0x54, 0xc2, 0x6a, 0x24,
-	0x74, 0xda, 0x41, 0x12, 0x24, 0xa4, 0xe9, 0xe9, 0x93, 0x31, 0x76, 0x9c, 0x20, 0x49, 0x82, 0x08,
-	0x7b, 0x24, 0x0d, 0xb3, 0xd3, 0xde, 0x28, 0x13, 0x5c, 0x85, 0x49, 0x9c, 0xdb, 0xf7, 0xae, 0xdb,
-	0x55, 0x38, 0x41, 0xa9, 0xf8, 0x24, 0xcd, 0x1d, 0x3e, 0x0d, 0x42, 0x35, 0xce, 0x86, 0xae, 0x9f,
-	0x4c, 0x7a, 0x7e, 0x22, 0x14, 0xce, 0x52, 0x91, 0x7c, 0x87, 0xbe, 0xca, 0xa5, 0x5e, 0x7a, 0x16,
-	0x14, 0x86, 0x61, 0x7e, 0xc8, 0xa1, 0x9f, 0xdd, 0x04, 0x4a, 0xc1, 0xd3, 0xaf, 0x4c, 0x87, 0xe6,
-	0xdf, 0xc0, 0xbb, 0xbf, 0x55, 0x61, 0xd3, 0xd3, 0xb2, 0x87, 0xdf, 0x67, 0x28, 0x15, 0xdb, 0x85,
-	0xa6, 0xb6, 0x7f, 0xc5, 0x27, 0x28, 0x6d, 0x6b, 0x7f, 0xed, 0xa0, 0xe9, 0x2d, 0x14, 0xec, 0x43,
-	0xd8, 0xd6, 0xc2, 0x97, 0x22, 0xc9, 0x52, 0xe3, 0x52, 0x25, 0x97, 0x6b, 0x5a, 0xd6, 0x86, 0xda,
-	0x69, 0x18, 0xa1, 0xb4, 0xd7, 0xc8, 0x6c, 0x04, 0xc6, 0x60, 0x5d, 0xfd, 0x90, 0xa2, 0xbd, 0xbe,
-	0x6f, 0x1d, 0x34, 0x3d, 0x3a, 0x6b, 0x4f, 0xa9, 0xb8, 0x42, 0xbb, 0x46, 0x4a, 0x23, 0xb0, 0x07,
-	0x50, 0x1f, 0x23, 0x8f, 0xd4, 0xd8, 0xae, 0x93, 0x3a, 0x97, 0x58, 0x07, 0x1a, 0x13, 0xae, 0xfc,
-	0x31, 0x0a, 0x69, 0x6f, 0x10, 0xf5, 0x95, 0xcc, 0x3e, 0x80, 0x2d, 0x9c, 0xf9, 0x51, 0x36, 0xc2,
-	0x7e, 0x84, 0x42, 0x49, 0xbb, 0xb1, 0x6f, 0x1d, 0x34, 0xbc, 0xb2, 0x52, 0x7b, 0x4d, 0xf8, 0xcc,
-	0x2b, 0xc2, 0x95, 0x76, 0x73, 0xdf, 0x3a, 0xa8, 0x79, 0x65, 0xa5, 0xae, 0x42, 0x8c, 0x33, 0xf5,
-	0x3c, 0x39, 0xc3, 0xd8, 0x06, 0x0a, 0x61, 0xa1, 0xe8, 0x3e, 0x80, 0xf6, 0x71, 0x38, 0xc5, 0x18,
-	0xa5, 0xfc, 0x62, 0x8c, 0xfe, 0x59, 0x5e, 0xbb, 0xee, 0x43, 0xb8, 0x7f, 0x4d, 0x2f, 0xd3, 0x24,
-	0x96, 0x4b, 0x49, 0x5a, 0x74, 0x99, 0x11, 0xba, 0xdf, 0xc2, 0x56, 0x5e, 0xfa, 0xdc, 0xed, 0x21,
-	0xd4, 0x03, 0x13, 0x94, 0x2e, 0x7c, 0xeb, 0xf0, 0xbe, 0x6b, 0x46, 0x90, 0x82, 0x3a, 0xd1, 0x98,
-	0xc7, 0x28, 0x7d, 0x2f, 0x77, 0x2a, 0x07, 0x59, 0xbd, 0x1e, 0xe4, 0x2f, 0x55, 0xd8, 0x2e, 0x03,
-	0xd9, 0x47, 0x50, 0x23, 0x28, 0x85, 0xd1, 0x3a, 0x6c, 0xbb, 0x66, 0x12, 0xae, 0xf2, 0x26, 0x76,
-	0xe3, 0xc2, 0x3e, 0x81, 0x4d, 0xee, 0xab, 0x70, 0x8a, 0x03, 0x72, 0xa2, 0x3e, 0x17, 0x10, 0x41,
-	0x90, 0x45, 0x40, 0x2d, 0xe3, 0x49, 0xc9, 0xb0, 0x6f, 0x60, 0x07, 0xa7, 0x3c, 0xca, 0xe8, 0x01,
-	0x3c, 0x2f, 0x06, 0xdd, 0x5e, 0xa3, 0x2b, 0x3b, 0xae, 0x79, 0x0a, 0x6e, 0xf1, 0x14, 0xdc, 0x2b,
-	0x8f, 0xa3, 0xc6, 0xcb, 0xd7, 0x7b, 0x95, 0x17, 0x7f, 0xed, 0x59, 0xde, 0x2a, 0x02, 0x76, 0x02,
-	0x6c, 0xa1, 0x7e, 0x9c, 0x3f, 0x30, 0x1a, 0xa5, 0xd6, 0xe1, 0xbb, 0xff, 0xa1, 0x2d, 0x1c, 0x0c,
-	0xeb, 0xcf, 0x9a, 0x75, 0x05, 0xbc, 0xfb, 0x67, 0xd5, 0xf4, 0x60, 0x51, 0xa3, 0xf7, 0x61, 0x5d,
-	0xa7, 0x98, 0x97, 0xe8, 0xde, 0x52, 0x89, 0x28, 0x55, 0x32, 0x2e, 0xfa, 0x59, 0x5d, 0x3d, 0xb4,
-	0x6b, 0xa5, 0xa1, 0xdd, 0x85, 0x66, 0xc4, 0xa5, 0x7a, 0x22, 0x44, 0x22, 0xf2, 0xd9, 0x5f, 0x28,
-	0x74, 0xd3, 0xb9, 0x99, 0xd7, 0x5a, 0xa9, 0xe9, 0x34, 0xaf, 0x4b, 0x4d, 0x37, 0x4e, 0xff, 0x57,
-	0xde, 0xfa, 0xdd, 0x94, 0x77, 0xe3, 0xed, 0xca, 0xfb, 0x7b, 0x0d, 0xb6, 0xcb, 0x79, 0x94, 0x9f,
-	0xc2, 0x55, 0xe9, 0x62, 0xa8, 0x47, 0x7c, 0x88, 0x51, 0x31, 0x67, 0x3b, 0x6e, 0xb1, 0xed, 0xdc,
-	0x63, 0xad, 0x7f, 0xc6, 0x43, 0x71, 0xd4, 0xd7, 0x77, 0xfd, 0xf1, 0x7a, 0xef, 0x56, 0xdb, 0xd2,
-	0xe0, 0xfb, 0x23, 0x9e, 0x2a, 0x14, 0x5e, 0x7e, 0x0b, 0x9b, 0x41, 0x8b, 0xc7, 0x71, 0xa2, 0x28,
-	0x4c, 0xb3, 0xa5, 0xee, 0xee, 0xd2, 0xe5, 0xab, 0x74, 0xfe, 0xba, 0x4e, 0x66, 0x09, 0x5a, 0x9e,
-	0x11, 0x58, 0x1f, 0x9a, 0xf9, 0x6b, 0xe3, 0x8a, 0x36, 0xe1, 0x4d, 0x7b, 0xd9, 0x30, 0xb0, 0xbe,
-	0x62, 0x9f, 0x43, 0xe3, 0x34, 0x14, 0x38, 0xd2, 0x0c, 0xb7, 0x99, 0x86, 0x0d, 0x42, 0xf5, 0x15,
-	0x7b, 0x02, 0x2d, 0x81, 0x32, 0x89, 0xa6, 0x86, 0x63, 0xe3, 0x16, 0x1c, 0x50, 0x00, 0xfb, 0x8a,
-	0x3d, 0x85, 0x4d, 0x3d, 0xdc, 0x03, 0x89, 0xb1, 0xd2, 0x3c, 0x8d, 0xdb, 0xf0, 0x68, 0xe4, 0x09,
-	0xc6, 0xca, 0x84, 0x33, 0xe5, 0x51, 0x38, 0x1a, 0x64, 0xb1, 0x0a, 0x23, 0x5a, 0xd3, 0x37, 0xa6,
-	0x21, 0xe0, 0xd7, 0x1a, 0xc7, 0x9e, 0xc1, 0x3b, 0x67, 0x88, 0xe9, 0xe0, 0x34, 0x14, 0x61, 0x1c,
-	0x0c, 0x64, 0x18, 0xfb, 0x48, 0x1b, 0xfd, 0xa6, 0x64, 0xf7, 0x34, 0xfc, 0x29, 0xa1, 0x4f, 0x34,
-	0xf8, 0xf0, 0x27, 0x0b, 0x6a, 0x7a, 0x1f, 0x08, 0xf6, 0xc8, 0x1c, 0x24, 0xdb, 0x59, 0x5a, 0x8b,
-	0xc5, 0x97, 0xb4, 0xd3, 0x2e, 0x2b, 0xcd, 0x8e, 0xef, 0x56, 0xd8, 0x31, 0x6c, 0x95, 0xbe, 0x12,
-	0xec, 0xbd, 0xdc, 0x71, 0xd5, 0x37, 0xa5, 0xb3, 0xbb, 0xda, 0x58, 0xb0, 0x1d, 0x3d, 0x3a, 0xbf,
-	0x70, 0x2a, 0xaf, 0x2e, 0x9c, 0xca, 0x9b, 0x0b, 0xc7, 0xfa, 0x71, 0xee, 0x58, 0xbf, 0xce, 0x1d,
-	0xeb, 0xe5, 0xdc, 0xb1, 0xce, 0xe7, 0x8e, 0xf5, 0xf7, 0xdc, 0xb1, 0xfe, 0x99, 0x3b, 0x95, 0x37,
-	0x73, 0xc7, 0x7a, 0x71, 0xe9, 0x54, 0xce, 0x2f, 0x9d, 0xca, 0xab, 0x4b, 0xa7, 0x32, 0xac, 0x53,
-	0xca, 0x1f, 0xff, 0x1b, 0x00, 0x00, 0xff, 0xff, 0x29, 0x54, 0x5d, 0xa5, 0xe4, 0x08, 0x00, 0x00,
+	0x14, 0xb7, 0x93, 0x3a, 0x4d, 0x5e, 0xda, 0xae, 0x98, 0xa6, 0x2b, 0x13, 0x2a, 0xb7, 0x0a, 0x08,
+	0x55, 0x48, 0xeb, 0x48, 0xa5, 0x12, 0xe2, 0x80, 0x50, 0xca, 0xee, 0x72, 0xa9, 0xd0, 0xca, 0x59,
+	0x38, 0x21, 0x45, 0x13, 0x67, 0xea, 0x98, 0x3a, 0xb6, 0x99, 0x19, 0x47, 0xe1, 0xc6, 0x9d, 0xcb,
+	0x1e, 0xf9, 0x08, 0x9c, 0xf9, 0x14, 0x7b, 0xac, 0x38, 0x55, 0x08, 0x2d, 0x34, 0xbd, 0x70, 0xdc,
+	0x8f, 0x80, 0xe6, 0x8d, 0x9d, 0xc4, 0x25, 0x48, 0x44, 0xab, 0xbd, 0x24, 0x7e, 0x7f, 0x7e, 0xbf,
+	0x79, 0xf3, 0xe6, 0xf7, 0x66, 0xa0, 0xc9, 0xb3, 0x88, 0x71, 0x37, 0xe5, 0x89, 0x4c, 0x88, 0x85,
+	0x46, 0xbb, 0x15, 0x24, 0x41, 0x82, 0x9e, 0xae, 0xfa, 0xd2, 0xc1, 0xb6, 0x13, 0x24, 0x49, 0x10,
+	0xb1, 0x2e, 0x5a, 0xc3, 0xec, 0xb2, 0x3b, 0xca, 0x38, 0x95, 0x61, 0x12, 0xe7, 0xf1, 0xa3, 0xfb,
+	0x71, 0x19, 0x4e, 0x98, 0x90, 0x74, 0x92, 0xe6, 0x09, 0x9f, 0x06, 0xa1, 0x1c, 0x67, 0x43, 0xd7,
+	0x4f, 0x26, 0x5d, 0x3f, 0xe1, 0x92, 0xcd, 0x52, 0x9e, 0x7c, 0xc7, 0x7c, 0x99, 0x5b, 0xdd, 0xf4,
+	0x2a, 0x28, 0x02, 0xc3, 0xfc, 0x23, 0x87, 0x7e, 0xf6, 0x7f, 0xa0, 0x58, 0x3c, 0xfe, 0x8a, 0x74,
+	0xa8, 0xff, 0x35, 0xbc, 0xf3, 0x6b, 0x05, 0x76, 0x3c, 0x65, 0x7b, 0xec, 0xfb, 0x8c, 0x09, 0x49,
+	0x0e, 0xa1, 0xa1, 0xe2, 0x5f, 0xd1, 0x09, 0x13, 0xb6, 0x79, 0x5c, 0x3d, 0x69, 0x78, 0x4b, 0x07,
+	0xf9, 0x10, 0xf6, 0x94, 0xf1, 0x25, 0x4f, 0xb2, 0x54, 0xa7, 0x54, 0x30, 0xe5, 0x9e, 0x97, 0xb4,
+	0xc0, 0xba, 0x0c, 0x23, 0x26, 0xec, 0x2a, 0x86, 0xb5, 0x41, 0x08, 0x6c, 0xc9, 0x1f, 0x52, 0x66,
+	0x6f, 0x1d, 0x9b, 0x27, 0x0d, 0x0f, 0xbf, 0x55, 0xa6, 0x90, 0x54, 0x32, 0xdb, 0x42, 0xa7, 0x36,
+	0xc8, 0x43, 0xa8, 0x8d, 0x19, 0x8d, 0xe4, 0xd8, 0xae, 0xa1, 0x3b, 0xb7, 0x48, 0x1b, 0xea, 0x13,
+	0x2a, 0xfd, 0x31, 0xe3, 0xc2, 0xde, 0x46, 0xea, 0x85, 0x4d, 0x3e, 0x80, 0x5d, 0x36, 0xf3, 0xa3,
+	0x6c, 0xc4, 0x7a, 0x11, 0xe3, 0x52, 0xd8, 0xf5, 0x63, 0xf3, 0xa4, 0xee, 0x95, 0x9d, 0x2a, 0x6b,
+	0x42, 0x67, 0x5e, 0x51, 0xae, 0xb0, 0x1b, 0xc7, 0xe6, 0x89, 0xe5, 0x95, 0x9d, 0xaa, 0x0b, 0x31,
+	0x9b, 0xc9, 0xe7, 0xc9, 0x15, 0x8b, 0x6d, 0xc0, 0x12, 0x96, 0x8e, 0xce, 0x43, 0x68, 0x5d, 0x84,
+	0x53, 0x16, 0x33, 0x21, 0xbe, 0x18, 0x33, 0xff, 0x2a, 0xef, 0x5d, 0xe7, 0x11, 0x1c, 0xdc, 0xf3,
+	0x8b, 0x34, 0x89, 0xc5, 0xca, 0x26, 0x4d, 0x5c, 0x4c, 0x1b, 0x9d, 0x6f, 0x61, 0x37, 0x6f, 0x7d,
+	0x9e, 0xf6, 0x08, 0x6a, 0x81, 0x2e, 0x4a, 0x35, 0xbe, 0x79, 0x7a, 0xe0, 0x6a, 0x09, 0x62, 0x51,
+	0x7d, 0x85, 0x79, 0xcc, 0x84, 0xef, 0xd5, 0x82, 0x35, 0x45, 0x56, 0xee, 0x17, 0x79, 0x53, 0x81,
+	0xbd, 0x32, 0x90, 0x7c, 0x04, 0x16, 0x42, 0xb1, 0x8c, 0xe6, 0x69, 0xcb, 0xd5, 0x4a, 0x58, 0xec,
+	0x1b, 0xd9, 0x75, 0x0a, 0xf9, 0x04, 0x76, 0xa8, 0x2f, 0xc3, 0x29, 0x1b, 0x60, 0x12, 0x9e, 0x73,
+	0x01, 0xe1, 0x08, 0x59, 0x16, 0xd4, 0xd4, 0x99, 0xb8, 0x19, 0xf2, 0x0d, 0xec, 0xb3, 0x29, 0x8d,
+	0x32, 0x1c, 0x80, 0xe7, 0x85, 0xd0, 0xed, 0x2a, 0x2e, 0xd9, 0x76, 0xf5, 0x28, 0xb8, 0xc5, 0x28,
+	0xb8, 0x8b, 0x8c, 0xf3, 0xfa, 0xcb, 0x57, 0x47, 0xc6, 0x8b, 0x3f, 0x8f, 0x4c, 0x6f, 0x1d, 0x01,
+	0xe9, 0x03, 0x59, 0xba, 0x1f, 0xe7, 0x03, 0x86, 0x52, 0x6a, 0x9e, 0xbe, 0xfb, 0x2f, 0xda, 0x22,
+	0x41, 0xb3, 0xfe, 0xac, 0x58, 0xd7, 0xc0, 0xc9, 0x19, 0x1c, 0x44, 0x54, 0xc8, 0x27, 0x8b, 0x48,
+	0x9f, 0x4e, 0x52, 0xb5, 0x5d, 0xa5, 0xc6, 0xaa, 0xb7, 0x3e, 0xd8, 0xf9, 0xa3, 0x02, 0xbb, 0xa5,
+	0x0e, 0x90, 0xf7, 0x61, 0x4b, 0x35, 0x26, 0x6f, 0xec, 0x83, 0x95, 0xc6, 0x62, 0x83, 0x30, 0xb8,
+	0x54, 0x41, 0x65, 0xbd, 0xd4, 0xab, 0x25, 0xa9, 0x1f, 0x42, 0x03, 0x57, 0xe7, 0x3c, 0xe1, 0xf9,
+	0xc4, 0x2c, 0x1d, 0x4a, 0x2a, 0x54, 0xab, 0xdc, 0x2a, 0x49, 0x05, 0x55, 0xbe, 0x22, 0x15, 0x9d,
+	0xf4, 0x5f, 0x87, 0x52, 0x7b, 0x3b, 0x87, 0xb2, 0xfd, 0x46, 0x87, 0xd2, 0xf9, 0xcd, 0x82, 0xbd,
+	0xf2, 0x3e, 0xca, 0x03, 0xb4, 0x68, 0x5d, 0x0c, 0xb5, 0x88, 0x0e, 0x59, 0x54, 0xa8, 0x73, 0xdf,
+	0x2d, 0xee, 0x48, 0xf7, 0x42, 0xf9, 0x9f, 0xd1, 0x90, 0x9f, 0xf7, 0xd4, 0x5a, 0xbf, 0xbf, 0x3a,
+	0xda, 0xe8, 0x8e, 0xd5, 0xf8, 0xde, 0x88, 0xa6, 0x92, 0x71, 0x2f, 0x5f, 0x85, 0xcc, 0xa0, 0x49,
+	0xe3, 0x38, 0x91, 0x58, 0xa6, 0xbe, 0xdb, 0xde, 0xde, 0xa2, 0xab, 0x4b, 0xa9, 0xfd, 0xab, 0x3e,
+	0xe9, 0xab, 0xd3, 0xf4, 0xb4, 0x41, 0x7a, 0xd0, 0xc8, 0x67, 0x94, 0x4a, 0xdb, 0xda, 0xe0, 0x2c,
+	0xeb, 0x1a, 0xd6, 0x93, 0xe4, 0x73, 0xa8, 0x5f, 0x86, 0x9c, 0x8d, 0x14, 0xc3, 0x26, 0x6a, 0xd8,
+	0x46, 0x54, 0x4f, 0x92, 0x27, 0xd0, 0xe4, 0x4c, 0x24, 0xd1, 0x54, 0x73, 0x6c, 0x6f, 0xc0, 0x01,
+	0x05, 0xb0, 0x27, 0xc9, 0x53, 0xd8, 0x51, 0xe2, 0x1e, 0x08, 0x16, 0x4b, 0xc5, 0x53, 0xdf, 0x84,
+	0x47, 0x21, 0xfb, 0x2c, 0x96, 0xba, 0x9c, 0x29, 0x8d, 0xc2, 0xd1, 0x20, 0x8b, 0x65, 0x18, 0xd9,
+	0x8d, 0x4d, 0x68, 0x10, 0xf8, 0xb5, 0xc2, 0x91, 0x67, 0xf0, 0xce, 0x15, 0x63, 0xe9, 0xe0, 0x32,
+	0xe4, 0x61, 0x1c, 0x0c, 0x44, 0x18, 0xfb, 0xcc, 0x86, 0x0d, 0xc8, 0x1e, 0x28, 0xf8, 0x53, 0x44,
+	0xf7, 0x15, 0xf8, 0xf4, 0x27, 0x13, 0x2c, 0x75, 0x1f, 0x70, 0x72, 0xa6, 0x3f, 0x04, 0xd9, 0x5f,
+	0xb9, 0x4c, 0x8b, 0xf7, 0xb7, 0xdd, 0x2a, 0x3b, 0xf5, 0xcb, 0xd0, 0x31, 0xc8, 0x05, 0xec, 0x96,
+	0xde, 0x16, 0xf2, 0x5e, 0x9e, 0xb8, 0xee, 0x25, 0x6a, 0x1f, 0xae, 0x0f, 0x16, 0x6c, 0xe7, 0x67,
+	0xd7, 0xb7, 0x8e, 0x71, 0x73, 0xeb, 0x18, 0xaf, 0x6f, 0x1d, 0xf3, 0xc7, 0xb9, 0x63, 0xfe, 0x32,
+	0x77, 0xcc, 0x97, 0x73, 0xc7, 0xbc, 0x9e, 0x3b, 0xe6, 0x5f, 0x73, 0xc7, 0xfc, 0x7b, 0xee, 0x18,
+	0xaf, 0xe7, 0x8e, 0xf9, 0xe2, 0xce, 0x31, 0xae, 0xef, 0x1c, 0xe3, 0xe6, 0xce, 0x31, 0x86, 0x35,
+	0xdc, 0xf2, 0xc7, 0xff, 0x0c, 0x00, 0xae, 0xa0, 0x79, 0x25, 0x1a, 0x09, 0x00, 0x00,
 }
 
 func (this *RulesRequest) Equal(that interface{}) bool {
@@ -798,6 +808,9 @@ func (this *GroupStateDesc) Equal(that interface{}) bool {
 	if this.EvaluationDuration != that1.EvaluationDuration {
 		return false
 	}
+	if this.LastEvaluationSamples != that1.LastEvaluationSamples {
+		return false
+	}
 	return true
 }
 func (this *RuleStateDesc) Equal(that interface{}) bool {
@@ -963,7 +976,7 @@ func (this *GroupStateDesc) GoString() string {
 	if this == nil {
 		return "nil"
 	}
-	s := make([]string, 0, 8)
+	s := make([]string, 0, 9)
 	s = append(s, "&ruler.GroupStateDesc{")
 	if this.Group != nil {
 		s = append(s, "Group: "+fmt.Sprintf("%#v", this.Group)+",\n")
@@ -973,6 +986,7 @@ func (this *GroupStateDesc) GoString() string {
 	}
 	s = append(s, "EvaluationTimestamp: "+fmt.Sprintf("%#v", this.EvaluationTimestamp)+",\n")
 	s = append(s, "EvaluationDuration: "+fmt.Sprintf("%#v", this.EvaluationDuration)+",\n")
+	s = append(s, "LastEvaluationSamples: "+fmt.Sprintf("%#v", this.LastEvaluationSamples)+",\n")
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
@@ -1357,6 +1371,11 @@ func (m *GroupStateDesc) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	if m.LastEvaluationSamples != 0 {
+		i = encodeVarintRuler(dAtA, i, uint64(m.LastEvaluationSamples))
+		i--
+		dAtA[i] = 0x28
+	}
 	n1, err1 := github_com_gogo_protobuf_types.StdDurationMarshalTo(m.EvaluationDuration, dAtA[i-github_com_gogo_protobuf_types.SizeOfStdDuration(m.EvaluationDuration):])
 	if err1 != nil {
 		return 0, err1
@@ -1726,6 +1745,9 @@ func (m *GroupStateDesc) Size() (n int) {
 	n += 1 + l + sovRuler(uint64(l))
 	l = github_com_gogo_protobuf_types.SizeOfStdDuration(m.EvaluationDuration)
 	n += 1 + l + sovRuler(uint64(l))
+	if m.LastEvaluationSamples != 0 {
+		n += 1 + sovRuler(uint64(m.LastEvaluationSamples))
+	}
 	return n
 }
 
@@ -1878,6 +1900,7 @@ func (this *GroupStateDesc) String() string {
 		`ActiveRules:` + repeatedStringForActiveRules + `,`,
 		`EvaluationTimestamp:` + strings.Replace(strings.Replace(fmt.Sprintf("%v", this.EvaluationTimestamp), "Timestamp", "timestamppb.Timestamp", 1), `&`, ``, 1) + `,`,
 		`EvaluationDuration:` + strings.Replace(strings.Replace(fmt.Sprintf("%v", this.EvaluationDuration), "Duration", "durationpb.Duration", 1), `&`, ``, 1) + `,`,
+		`LastEvaluationSamples:` + fmt.Sprintf("%v", this.LastEvaluationSamples) + `,`,
 		`}`,
 	}, "")
 	return s
@@ -2687,6 +2710,25 @@ func (m *GroupStateDesc) Unmarshal(dAtA []byte) error {
 				return err
 			}
 			iNdEx = postIndex
+		case 5:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field LastEvaluationSamples", wireType)
+			}
+			m.LastEvaluationSamples = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRuler
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.LastEvaluationSamples |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
 		default:
 			iNdEx = preIndex
 			skippy, err := skipRuler(dAtA[iNdEx:])
@@ -96,10 +96,12 @@ type ruleLimits struct {
 	maxRuleGroups             int
 	disabledRuleGroups        validation.DisabledRuleGroups
 	maxQueryLength            time.Duration
+	queryLookbackDelta        time.Duration
 	queryOffset               time.Duration
 	externalLabels            labels.Labels
 	externalURL               string
 	alertGeneratorURLTemplate string
+	maxConcurrentGroupEvals   int
 }
 
 func (r *ruleLimits) setRulerExternalLabels(lset labels.Labels) {
@@ -138,6 +140,12 @@ func (r *ruleLimits) MaxQueryLength(_ string) time.Duration {
 	return r.maxQueryLength
 }
 
+func (r *ruleLimits) QueryLookbackDelta(_ string) time.Duration {
+	r.mtx.RLock()
+	defer r.mtx.RUnlock()
+	return r.queryLookbackDelta
+}
+
 func (r *ruleLimits) RulerQueryOffset(_ string) time.Duration {
 	r.mtx.RLock()
 	defer r.mtx.RUnlock()
@@ -162,6 +170,12 @@ func (r *ruleLimits) RulerAlertGeneratorURLTemplate(_ string) string {
 	return r.alertGeneratorURLTemplate
 }
 
+func (r *ruleLimits) RulerMaxConcurrentRuleGroupEvaluations(_ string) int {
+	r.mtx.RLock()
+	defer r.mtx.RUnlock()
+	return r.maxConcurrentGroupEvals
+}
+
 func newEmptyQueryable() storage.Queryable {
 	return storage.QueryableFunc(func(mint, maxt int64) (storage.Querier, error) {
 		return emptyQuerier{}, nil
@@ -3307,6 +3321,24 @@ func TestRuler_QueryOffset(t *testing.T) {
 	// test group query offset is set
 	gotOffset = rg.GetGroup().QueryOffset
 	require.Equal(t, time.Minute*2, *gotOffset)
+
+	// test the tenant query offset is applied when not defined at group level,
+	// while the group query offset takes precedence when defined
+	r.limits.(*ruleLimits).mtx.Lock()
+	r.limits.(*ruleLimits).queryOffset = 5 * time.Minute
+	r.limits.(*ruleLimits).mtx.Unlock()
+
+	ctx = user.InjectOrgID(context.Background(), "user1")
+	rls, err = r.Rules(ctx, &RulesRequest{MaxRuleGroups: -1})
+	require.NoError(t, err)
+	require.Len(t, rls.Groups, 1)
+	require.Equal(t, 5*time.Minute, *rls.Groups[0].GetGroup().QueryOffset)
+
+	ctx = user.InjectOrgID(context.Background(), "user2")
+	rls, err = r.Rules(ctx, &RulesRequest{MaxRuleGroups: -1})
+	require.NoError(t, err)
+	require.Len(t, rls.Groups, 1)
+	require.Equal(t, 2*time.Minute, *rls.Groups[0].GetGroup().QueryOffset)
 }
 
 func TestGetShardSizeForUser(t *testing.T) {
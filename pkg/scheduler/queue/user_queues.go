@@ -1,6 +1,7 @@
 package queue
 
 import (
+	"maps"
 	"math/rand"
 	"sort"
 	"sync"
@@ -75,6 +76,7 @@ type userQueue struct {
 	maxOutstanding  int
 	priorityList    []int64
 	priorityEnabled bool
+	priorityWeights map[int64]int64
 
 	// Seed for shuffle sharding of queriers. This seed is based on userID only and is therefore consistent
 	// between different frontends.
@@ -140,6 +142,7 @@ func (q *queues) getOrAddQueue(userID string, maxQueriers int) userRequestQueue
 	priorityEnabled := q.limits.QueryPriority(userID).Enabled
 	maxOutstanding := q.limits.MaxOutstandingPerTenant(userID)
 	priorityList := getPriorityList(q.limits.QueryPriority(userID), maxQueriers)
+	priorityWeights := getPriorityWeights(q.limits.QueryPriority(userID))
 
 	if uq == nil {
 		uq = &userQueue{
@@ -149,6 +152,7 @@ func (q *queues) getOrAddQueue(userID string, maxQueriers int) userRequestQueue
 
 		uq.queue = q.createUserRequestQueue(userID)
 		uq.maxOutstanding = q.limits.MaxOutstandingPerTenant(userID)
+		uq.priorityWeights = priorityWeights
 		q.userQueues[userID] = uq
 
 		// Add user to the list of users... find first free spot, and put it there.
@@ -165,7 +169,7 @@ func (q *queues) getOrAddQueue(userID string, maxQueriers int) userRequestQueue
 			uq.index = len(q.users)
 			q.users = append(q.users, userID)
 		}
-	} else if (uq.priorityEnabled != priorityEnabled) || (!priorityEnabled && uq.maxOutstanding != maxOutstanding) {
+	} else if (uq.priorityEnabled != priorityEnabled) || (!priorityEnabled && uq.maxOutstanding != maxOutstanding) || !maps.Equal(uq.priorityWeights, priorityWeights) {
 		tmpQueue := q.createUserRequestQueue(userID)
 
 		// flush to new queue
@@ -178,6 +182,7 @@ func (q *queues) getOrAddQueue(userID string, maxQueriers int) userRequestQueue
 		uq.queue = tmpQueue
 		uq.maxOutstanding = q.limits.MaxOutstandingPerTenant(userID)
 		uq.priorityEnabled = priorityEnabled
+		uq.priorityWeights = priorityWeights
 	}
 
 	if uq.maxQueriers != maxQueriers {
@@ -208,6 +213,10 @@ func (q *queues) getOrAddQueue(userID string, maxQueriers int) userRequestQueue
 }
 
 func (q *queues) createUserRequestQueue(userID string) userRequestQueue {
+	if weights := getPriorityWeights(q.limits.QueryPriority(userID)); weights != nil {
+		return NewWeightedPriorityRequestQueue(weights, userID, q.queueLength)
+	}
+
 	if q.limits.QueryPriority(userID).Enabled {
 		return NewPriorityRequestQueue(util.NewPriorityQueue(nil), userID, q.queueLength)
 	}
@@ -408,6 +417,27 @@ func getPriorityList(queryPriority validation.QueryPriority, totalQuerierCount i
 	return priorityList
 }
 
+// getPriorityWeights returns the weight of each priority, or nil if query priority is disabled or no priority
+// has a weight set, in which case queries are dequeued in strict priority order.
+func getPriorityWeights(queryPriority validation.QueryPriority) map[int64]int64 {
+	if !queryPriority.Enabled {
+		return nil
+	}
+
+	var weights map[int64]int64
+	for _, priority := range queryPriority.Priorities {
+		if priority.Weight <= 0 {
+			continue
+		}
+		if weights == nil {
+			weights = map[int64]int64{}
+		}
+		weights[priority.Priority] = priority.Weight
+	}
+
+	return weights
+}
+
 func hasPriorityListChanged(old, new []int64) bool {
 	if len(old) != len(new) {
 		return true
@@ -440,10 +440,18 @@ func TestGetOrAddQueueShouldUpdateProperties(t *testing.T) {
 	assert.Equal(t, 0, len(q.userQueues["userID"].reservedQueriers))
 	assert.ElementsMatch(t, []int64{}, q.userQueues["userID"].priorityList)
 
+	limits.QueryPriorityVal.Priorities[0].Weight = 3
+	q.limits = limits
+	queue = q.getOrAddQueue("userID", 3)
+	assert.IsType(t, &WeightedPriorityRequestQueue{}, queue)
+	assert.Equal(t, 1, queue.length())
+	assert.Equal(t, map[int64]int64{1: 3}, q.userQueues["userID"].priorityWeights)
+
 	limits.QueryPriorityVal.Enabled = false
 	q.limits = limits
 	queue = q.getOrAddQueue("userID", 3)
 	assert.IsType(t, &FIFORequestQueue{}, queue)
+	assert.Equal(t, 1, queue.length())
 
 	// check the queriers and reservedQueriers map are consistent
 	for range 100 {
@@ -688,3 +696,21 @@ func TestGetPriorityList(t *testing.T) {
 	queryPriority.Enabled = false
 	assert.Nil(t, getPriorityList(queryPriority, 10))
 }
+
+func TestGetPriorityWeights(t *testing.T) {
+	queryPriority := validation.QueryPriority{
+		Enabled: true,
+		Priorities: []validation.PriorityDef{
+			{Priority: 1},
+			{Priority: 2},
+		},
+	}
+
+	assert.Nil(t, getPriorityWeights(queryPriority))
+
+	queryPriority.Priorities[1].Weight = 4
+	assert.Equal(t, map[int64]int64{2: 4}, getPriorityWeights(queryPriority))
+
+	queryPriority.Enabled = false
+	assert.Nil(t, getPriorityWeights(queryPriority))
+}
@@ -1,6 +1,7 @@
 package queue
 
 import (
+	"slices"
 	"strconv"
 
 	"github.com/prometheus/client_golang/prometheus"
@@ -74,3 +75,97 @@ func (f *PriorityRequestQueue) dequeueRequest(minPriority int64, checkMinPriorit
 func (f *PriorityRequestQueue) length() int {
 	return f.queue.Length()
 }
+
+// WeightedPriorityRequestQueue dequeues requests with weighted fairness across priorities, using a smooth
+// weighted round-robin: while requests of different priorities are queued, each priority gets a share of
+// dequeues proportional to its weight, so a priority with a non-zero weight is never starved. Requests of
+// the same priority are dequeued in FIFO order. It's not safe for concurrent use, and relies on callers
+// holding the RequestQueue lock.
+type WeightedPriorityRequestQueue struct {
+	queues      map[int64][]Request
+	weights     map[int64]int64
+	current     map[int64]int64
+	len         int
+	userID      string
+	queueLength *prometheus.GaugeVec
+}
+
+func NewWeightedPriorityRequestQueue(weights map[int64]int64, userID string, queueLength *prometheus.GaugeVec) *WeightedPriorityRequestQueue {
+	return &WeightedPriorityRequestQueue{
+		queues:      map[int64][]Request{},
+		weights:     weights,
+		current:     map[int64]int64{},
+		userID:      userID,
+		queueLength: queueLength,
+	}
+}
+
+func (f *WeightedPriorityRequestQueue) enqueueRequest(r Request) {
+	f.queues[r.Priority()] = append(f.queues[r.Priority()], r)
+	f.len++
+	if f.queueLength != nil {
+		f.queueLength.WithLabelValues(f.userID, strconv.FormatInt(r.Priority(), 10), "weighted").Inc()
+	}
+}
+
+func (f *WeightedPriorityRequestQueue) dequeueRequest(minPriority int64, checkMinPriority bool) Request {
+	var (
+		selected    int64
+		found       bool
+		totalWeight int64
+	)
+
+	// Iterate priorities in a stable order, so that ties are consistently broken in favour of the higher priority.
+	priorities := make([]int64, 0, len(f.queues))
+	for priority := range f.queues {
+		priorities = append(priorities, priority)
+	}
+	slices.Sort(priorities)
+	slices.Reverse(priorities)
+
+	for _, priority := range priorities {
+		if checkMinPriority && priority < minPriority {
+			continue
+		}
+
+		weight := f.weight(priority)
+		totalWeight += weight
+		f.current[priority] += weight
+
+		if !found || f.current[priority] > f.current[selected] {
+			selected = priority
+			found = true
+		}
+	}
+
+	if !found {
+		return nil
+	}
+	f.current[selected] -= totalWeight
+
+	r := f.queues[selected][0]
+	f.queues[selected][0] = nil
+	f.queues[selected] = f.queues[selected][1:]
+	if len(f.queues[selected]) == 0 {
+		// Priorities with no queued requests don't accumulate credit, and start from scratch once requests come back.
+		delete(f.queues, selected)
+		delete(f.current, selected)
+	}
+	f.len--
+
+	if f.queueLength != nil {
+		f.queueLength.WithLabelValues(f.userID, strconv.FormatInt(r.Priority(), 10), "weighted").Dec()
+	}
+	return r
+}
+
+func (f *WeightedPriorityRequestQueue) weight(priority int64) int64 {
+	if weight := f.weights[priority]; weight > 0 {
+		return weight
+	}
+	return 1
+}
+
+func (f *WeightedPriorityRequestQueue) length() int {
+	return f.len
+}
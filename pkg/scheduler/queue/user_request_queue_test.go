@@ -1,6 +1,7 @@
 package queue
 
 import (
+	"fmt"
 	"strings"
 	"testing"
 
@@ -144,3 +145,58 @@ func TestPriorityRequestQueue(t *testing.T) {
 		cortex_query_scheduler_queue_length{priority="3",type="priority",user="userID"} 0
 	`), "cortex_query_scheduler_queue_length"))
 }
+
+func TestWeightedPriorityRequestQueue(t *testing.T) {
+	reg := prometheus.NewPedanticRegistry()
+	queueLength := promauto.With(reg).NewGaugeVec(prometheus.GaugeOpts{
+		Name: "cortex_query_scheduler_queue_length",
+		Help: "Number of queries in the queue.",
+	}, []string{"user", "priority", "type"})
+
+	// Priority 1 has no weight, and gets the default weight of 1.
+	queue := NewWeightedPriorityRequestQueue(map[int64]int64{2: 3}, "userID", queueLength)
+	for i := range 8 {
+		queue.enqueueRequest(MockRequest{id: fmt.Sprintf("low %d", i), priority: 1})
+		queue.enqueueRequest(MockRequest{id: fmt.Sprintf("high %d", i), priority: 2})
+	}
+
+	assert.NoError(t, promtest.GatherAndCompare(reg, strings.NewReader(`
+		# HELP cortex_query_scheduler_queue_length Number of queries in the queue.
+		# TYPE cortex_query_scheduler_queue_length gauge
+		cortex_query_scheduler_queue_length{priority="1",type="weighted",user="userID"} 8
+		cortex_query_scheduler_queue_length{priority="2",type="weighted",user="userID"} 8
+	`), "cortex_query_scheduler_queue_length"))
+	assert.Equal(t, 16, queue.length())
+
+	// While both priorities are queued, the low priority gets 1 out of every 4 dequeues,
+	// and requests of the same priority are dequeued in FIFO order.
+	var dequeued []string
+	for range 8 {
+		dequeued = append(dequeued, queue.dequeueRequest(0, false).(MockRequest).id)
+	}
+	assert.Equal(t, []string{"high 0", "high 1", "low 0", "high 2", "high 3", "high 4", "low 1", "high 5"}, dequeued)
+	assert.NoError(t, promtest.GatherAndCompare(reg, strings.NewReader(`
+		# HELP cortex_query_scheduler_queue_length Number of queries in the queue.
+		# TYPE cortex_query_scheduler_queue_length gauge
+		cortex_query_scheduler_queue_length{priority="1",type="weighted",user="userID"} 6
+		cortex_query_scheduler_queue_length{priority="2",type="weighted",user="userID"} 2
+	`), "cortex_query_scheduler_queue_length"))
+
+	// Reserved queriers only get requests of the minimum priority or higher.
+	assert.Equal(t, "high 6", queue.dequeueRequest(2, true).(MockRequest).id)
+	assert.Equal(t, "high 7", queue.dequeueRequest(2, true).(MockRequest).id)
+	assert.Nil(t, queue.dequeueRequest(2, true))
+
+	// Once the high priority is drained, the low priority gets all dequeues.
+	for i := 2; i < 8; i++ {
+		assert.Equal(t, fmt.Sprintf("low %d", i), queue.dequeueRequest(0, false).(MockRequest).id)
+	}
+	assert.Equal(t, 0, queue.length())
+	assert.Nil(t, queue.dequeueRequest(0, false))
+	assert.NoError(t, promtest.GatherAndCompare(reg, strings.NewReader(`
+		# HELP cortex_query_scheduler_queue_length Number of queries in the queue.
+		# TYPE cortex_query_scheduler_queue_length gauge
+		cortex_query_scheduler_queue_length{priority="1",type="weighted",user="userID"} 0
+		cortex_query_scheduler_queue_length{priority="2",type="weighted",user="userID"} 0
+	`), "cortex_query_scheduler_queue_length"))
+}
@@ -0,0 +1,318 @@
+package bucket
+
+import (
+	"context"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+	"github.com/thanos-io/objstore"
+)
+
+const (
+	// Name of the block meta file, whose existence tells the bucket owns the block.
+	federatedBlockMetaFilename = "meta.json"
+	// Name of the global markers directory of a tenant.
+	federatedMarkersDirname = "markers"
+
+	// How long the bucket owning a block is cached. The bucket owning a block can only change
+	// while the block is copied to a bucket preceding the current owner.
+	federatedBlockOwnerTTL = 5 * time.Minute
+)
+
+// FederatedBucketClient presents multiple buckets as a single one. It's meant to serve blocks
+// while migrating them between buckets: listings are the union of the listings of all buckets.
+// The bucket owning a block is the first bucket containing the block meta file, in the order buckets
+// are given, and all the block objects, including its markers, are read from it. This way, a block
+// existing in multiple buckets is served from the first (primary) one. The objects not belonging to a
+// block are read from the first bucket they exist in. Writes are only sent to the primary bucket.
+type FederatedBucketClient struct {
+	buckets []objstore.Bucket
+	owners  *blockOwners
+}
+
+// NewFederatedBucketClient returns a new FederatedBucketClient. The first bucket is the primary one.
+func NewFederatedBucketClient(primary objstore.Bucket, others ...objstore.Bucket) *FederatedBucketClient {
+	return &FederatedBucketClient{
+		buckets: append([]objstore.Bucket{primary}, others...),
+		owners:  &blockOwners{owners: map[string]blockOwner{}},
+	}
+}
+
+// Close implements io.Closer
+func (b *FederatedBucketClient) Close() error {
+	var firstErr error
+	for _, bkt := range b.buckets {
+		if err := bkt.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// Upload the contents of the reader as an object into the primary bucket.
+func (b *FederatedBucketClient) Upload(ctx context.Context, name string, r io.Reader, opts ...objstore.ObjectUploadOption) error {
+	return b.buckets[0].Upload(ctx, name, r, opts...)
+}
+
+// Delete removes the object with the given name from the primary bucket.
+func (b *FederatedBucketClient) Delete(ctx context.Context, name string) error {
+	return b.buckets[0].Delete(ctx, name)
+}
+
+// Name returns the name of the primary bucket.
+func (b *FederatedBucketClient) Name() string { return b.buckets[0].Name() }
+
+// Provider returns the provider of the primary bucket.
+func (b *FederatedBucketClient) Provider() objstore.ObjProvider {
+	return b.buckets[0].Provider()
+}
+
+// Iter calls f for each entry in the given directory of any bucket (not recursive.). Entries existing
+// in multiple buckets are passed to f only once.
+func (b *FederatedBucketClient) Iter(ctx context.Context, dir string, f func(string) error, options ...objstore.IterOption) error {
+	seen := map[string]struct{}{}
+	for idx, bkt := range b.buckets {
+		if !b.listsFrom(ctx, dir, idx) {
+			continue
+		}
+		err := bkt.Iter(ctx, dir, func(name string) error {
+			if _, ok := seen[name]; ok || !b.isOwnedBy(ctx, name, idx) {
+				return nil
+			}
+			seen[name] = struct{}{}
+			return f(name)
+		}, options...)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// IterWithAttributes calls f for each entry in the given directory of any bucket (not recursive.). Entries
+// existing in multiple buckets are passed to f only once, with the attributes from the first bucket listing them.
+func (b *FederatedBucketClient) IterWithAttributes(ctx context.Context, dir string, f func(attrs objstore.IterObjectAttributes) error, options ...objstore.IterOption) error {
+	seen := map[string]struct{}{}
+	for idx, bkt := range b.buckets {
+		if !b.listsFrom(ctx, dir, idx) {
+			continue
+		}
+		err := bkt.IterWithAttributes(ctx, dir, func(attrs objstore.IterObjectAttributes) error {
+			if _, ok := seen[attrs.Name]; ok || !b.isOwnedBy(ctx, attrs.Name, idx) {
+				return nil
+			}
+			seen[attrs.Name] = struct{}{}
+			return f(attrs)
+		}, options...)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SupportedIterOptions returns the iter options supported by all buckets.
+func (b *FederatedBucketClient) SupportedIterOptions() []objstore.IterOptionType {
+	supported := b.buckets[0].SupportedIterOptions()
+	for _, bkt := range b.buckets[1:] {
+		others := bkt.SupportedIterOptions()
+		supported = slices.DeleteFunc(slices.Clone(supported), func(opt objstore.IterOptionType) bool {
+			return !slices.Contains(others, opt)
+		})
+	}
+	return supported
+}
+
+// Get returns a reader for the given object name, from the bucket owning its block or the first bucket the object exists in.
+func (b *FederatedBucketClient) Get(ctx context.Context, name string) (io.ReadCloser, error) {
+	return firstFound(b.bucketsFor(ctx, name), func(bkt objstore.Bucket) (io.ReadCloser, error) {
+		return bkt.Get(ctx, name)
+	})
+}
+
+// GetRange returns a new range reader for the given object name and range, from the bucket owning its block or the first bucket the object exists in.
+func (b *FederatedBucketClient) GetRange(ctx context.Context, name string, off, length int64) (io.ReadCloser, error) {
+	return firstFound(b.bucketsFor(ctx, name), func(bkt objstore.Bucket) (io.ReadCloser, error) {
+		return bkt.GetRange(ctx, name, off, length)
+	})
+}
+
+// Exists checks if the given object exists in the bucket owning its block, or in any bucket.
+func (b *FederatedBucketClient) Exists(ctx context.Context, name string) (bool, error) {
+	for _, bkt := range b.bucketsFor(ctx, name) {
+		exists, err := bkt.Exists(ctx, name)
+		if err != nil || exists {
+			return exists, err
+		}
+	}
+	return false, nil
+}
+
+// IsObjNotFoundErr returns true if error means that object is not found. Relevant to Get operations.
+func (b *FederatedBucketClient) IsObjNotFoundErr(err error) bool {
+	return slices.ContainsFunc(b.buckets, func(bkt objstore.Bucket) bool {
+		return bkt.IsObjNotFoundErr(err)
+	})
+}
+
+// IsAccessDeniedErr returns true if access to object is denied.
+func (b *FederatedBucketClient) IsAccessDeniedErr(err error) bool {
+	return slices.ContainsFunc(b.buckets, func(bkt objstore.Bucket) bool {
+		return bkt.IsAccessDeniedErr(err)
+	})
+}
+
+// Attributes returns attributes of the specified object, from the bucket owning its block or the first bucket the object exists in.
+func (b *FederatedBucketClient) Attributes(ctx context.Context, name string) (objstore.ObjectAttributes, error) {
+	return firstFound(b.bucketsFor(ctx, name), func(bkt objstore.Bucket) (objstore.ObjectAttributes, error) {
+		return bkt.Attributes(ctx, name)
+	})
+}
+
+// ReaderWithExpectedErrs allows to specify a filter that marks certain errors as expected, so it will not increment
+// thanos_objstore_bucket_operation_failures_total metric.
+func (b *FederatedBucketClient) ReaderWithExpectedErrs(fn objstore.IsOpFailureExpectedFunc) objstore.BucketReader {
+	return b.WithExpectedErrs(fn)
+}
+
+// WithExpectedErrs allows to specify a filter that marks certain errors as expected, so it will not increment
+// thanos_objstore_bucket_operation_failures_total metric.
+func (b *FederatedBucketClient) WithExpectedErrs(fn objstore.IsOpFailureExpectedFunc) objstore.Bucket {
+	buckets := make([]objstore.Bucket, 0, len(b.buckets))
+	for _, bkt := range b.buckets {
+		if ib, ok := bkt.(objstore.InstrumentedBucket); ok {
+			bkt = ib.WithExpectedErrs(fn)
+		}
+		buckets = append(buckets, bkt)
+	}
+	return &FederatedBucketClient{buckets: buckets, owners: b.owners}
+}
+
+// bucketsFor returns the buckets the given object is read from: the bucket owning its block, if
+// the object belongs to a block, otherwise all buckets.
+func (b *FederatedBucketClient) bucketsFor(ctx context.Context, name string) []objstore.Bucket {
+	if dir, ok := blockDir(name); ok {
+		if idx, ok := b.blockOwner(ctx, dir); ok {
+			return b.buckets[idx : idx+1]
+		}
+	}
+	return b.buckets
+}
+
+// listsFrom returns whether the given directory is listed from the bucket at the given index:
+// the directory of a block is only listed from the bucket owning it.
+func (b *FederatedBucketClient) listsFrom(ctx context.Context, dir string, idx int) bool {
+	if blkDir, ok := blockDir(strings.TrimSuffix(dir, "/") + "/"); ok {
+		owner, found := b.blockOwner(ctx, blkDir)
+		return !found || owner == idx
+	}
+	return true
+}
+
+// isOwnedBy returns whether the given listed object can be served from the bucket at the given index.
+// The directories are not checked, because the listing of a tenant would check every block.
+func (b *FederatedBucketClient) isOwnedBy(ctx context.Context, name string, idx int) bool {
+	if strings.HasSuffix(name, "/") {
+		return true
+	}
+	dir, ok := blockDir(name)
+	if !ok {
+		return true
+	}
+	owner, found := b.blockOwner(ctx, dir)
+	return !found || owner == idx
+}
+
+// blockOwner returns the index of the first bucket containing the meta file of the block stored
+// in the given directory, and whether there's one.
+func (b *FederatedBucketClient) blockOwner(ctx context.Context, dir string) (int, bool) {
+	now := time.Now()
+	if idx, ok := b.owners.get(dir, now); ok {
+		return idx, true
+	}
+
+	for idx, bkt := range b.buckets {
+		exists, err := bkt.Exists(ctx, dir+federatedBlockMetaFilename)
+		if err != nil {
+			return 0, false
+		}
+		if exists {
+			b.owners.set(dir, idx, now)
+			return idx, true
+		}
+	}
+	return 0, false
+}
+
+// blockDir returns the directory of the block the given object belongs to, e.g. "user-1/<block ID>/",
+// and whether the object belongs to a block. The objects in the block directory and the block markers
+// in the tenant global markers directory belong to the block.
+func blockDir(name string) (string, bool) {
+	parts := strings.Split(name, "/")
+	for i, part := range parts {
+		if i < len(parts)-1 {
+			if _, err := ulid.Parse(part); err == nil {
+				return strings.Join(parts[:i+1], "/") + "/", true
+			}
+			continue
+		}
+
+		// The global markers are named "<block ID>-<marker name>".
+		if i > 0 && parts[i-1] == federatedMarkersDirname && len(part) > ulid.EncodedSize && part[ulid.EncodedSize] == '-' {
+			if _, err := ulid.Parse(part[:ulid.EncodedSize]); err == nil {
+				return strings.Join(append(slices.Clone(parts[:i-1]), part[:ulid.EncodedSize]), "/") + "/", true
+			}
+		}
+	}
+	return "", false
+}
+
+type blockOwner struct {
+	idx     int
+	expires time.Time
+}
+
+// blockOwners caches the bucket owning each block.
+type blockOwners struct {
+	mtx    sync.Mutex
+	owners map[string]blockOwner
+}
+
+func (o *blockOwners) get(dir string, now time.Time) (int, bool) {
+	o.mtx.Lock()
+	defer o.mtx.Unlock()
+
+	owner, ok := o.owners[dir]
+	if !ok || now.After(owner.expires) {
+		delete(o.owners, dir)
+		return 0, false
+	}
+	return owner.idx, true
+}
+
+func (o *blockOwners) set(dir string, idx int, now time.Time) {
+	o.mtx.Lock()
+	defer o.mtx.Unlock()
+
+	o.owners[dir] = blockOwner{idx: idx, expires: now.Add(federatedBlockOwnerTTL)}
+}
+
+// firstFound runs fn against each bucket in order, and returns the result of the first bucket
+// which doesn't fail with a not found error.
+func firstFound[T any](buckets []objstore.Bucket, fn func(objstore.Bucket) (T, error)) (T, error) {
+	var (
+		res T
+		err error
+	)
+	for _, bkt := range buckets {
+		res, err = fn(bkt)
+		if err == nil || !bkt.IsObjNotFoundErr(err) {
+			return res, err
+		}
+	}
+	return res, err
+}
@@ -0,0 +1,167 @@
+package bucket
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/oklog/ulid/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/objstore"
+)
+
+func TestFederatedBucketClient(t *testing.T) {
+	ctx := context.Background()
+
+	primary := objstore.NewInMemBucket()
+	secondary := objstore.NewInMemBucket()
+	require.NoError(t, primary.Upload(ctx, "user-1/block-1/meta.json", strings.NewReader("primary-1")))
+	require.NoError(t, primary.Upload(ctx, "user-1/block-2/meta.json", strings.NewReader("primary-2")))
+	require.NoError(t, secondary.Upload(ctx, "user-1/block-2/meta.json", strings.NewReader("secondary-2")))
+	require.NoError(t, secondary.Upload(ctx, "user-1/block-3/meta.json", strings.NewReader("secondary-3")))
+	require.NoError(t, secondary.Upload(ctx, "user-2/block-4/meta.json", strings.NewReader("secondary-4")))
+
+	client := NewFederatedBucketClient(primary, secondary)
+
+	t.Run("listings are the union of all buckets", func(t *testing.T) {
+		var users []string
+		require.NoError(t, client.Iter(ctx, "", func(name string) error {
+			users = append(users, name)
+			return nil
+		}))
+		assert.ElementsMatch(t, []string{"user-1/", "user-2/"}, users)
+
+		var blocks []string
+		require.NoError(t, client.IterWithAttributes(ctx, "user-1/", func(attrs objstore.IterObjectAttributes) error {
+			blocks = append(blocks, attrs.Name)
+			return nil
+		}))
+		assert.ElementsMatch(t, []string{"user-1/block-1/", "user-1/block-2/", "user-1/block-3/"}, blocks)
+	})
+
+	t.Run("objects are read from the first bucket they exist in", func(t *testing.T) {
+		for name, expected := range map[string]string{
+			"user-1/block-1/meta.json": "primary-1",
+			"user-1/block-2/meta.json": "primary-2",
+			"user-1/block-3/meta.json": "secondary-3",
+		} {
+			r, err := client.Get(ctx, name)
+			require.NoError(t, err)
+			content, err := io.ReadAll(r)
+			require.NoError(t, err)
+			require.NoError(t, r.Close())
+			assert.Equal(t, expected, string(content))
+
+			r, err = client.GetRange(ctx, name, 0, 3)
+			require.NoError(t, err)
+			content, err = io.ReadAll(r)
+			require.NoError(t, err)
+			require.NoError(t, r.Close())
+			assert.Equal(t, expected[:3], string(content))
+
+			attrs, err := client.Attributes(ctx, name)
+			require.NoError(t, err)
+			assert.Equal(t, int64(len(expected)), attrs.Size)
+
+			exists, err := client.Exists(ctx, name)
+			require.NoError(t, err)
+			assert.True(t, exists)
+		}
+	})
+
+	t.Run("missing objects are reported as not found", func(t *testing.T) {
+		_, err := client.Get(ctx, "user-1/block-5/meta.json")
+		assert.True(t, client.IsObjNotFoundErr(err))
+
+		_, err = client.WithExpectedErrs(client.IsObjNotFoundErr).Get(ctx, "user-1/block-5/meta.json")
+		assert.True(t, client.IsObjNotFoundErr(err))
+
+		exists, err := client.Exists(ctx, "user-1/block-5/meta.json")
+		require.NoError(t, err)
+		assert.False(t, exists)
+	})
+
+	t.Run("writes are sent to the primary bucket only", func(t *testing.T) {
+		require.NoError(t, client.Upload(ctx, "user-1/block-3/deletion-mark.json", bytes.NewReader([]byte("mark"))))
+
+		exists, err := primary.Exists(ctx, "user-1/block-3/deletion-mark.json")
+		require.NoError(t, err)
+		assert.True(t, exists)
+
+		exists, err = secondary.Exists(ctx, "user-1/block-3/deletion-mark.json")
+		require.NoError(t, err)
+		assert.False(t, exists)
+	})
+}
+
+func TestFederatedBucketClient_ShouldServeTheBlockObjectsFromTheBucketOwningTheBlock(t *testing.T) {
+	ctx := context.Background()
+	block1 := ulid.MustNew(1, nil).String()
+	block2 := ulid.MustNew(2, nil).String()
+
+	primary := objstore.NewInMemBucket()
+	secondary := objstore.NewInMemBucket()
+	// The block 1 is owned by the primary bucket, while being still partially in the secondary one.
+	require.NoError(t, primary.Upload(ctx, "user-1/"+block1+"/meta.json", strings.NewReader("primary-meta")))
+	require.NoError(t, primary.Upload(ctx, "user-1/"+block1+"/index", strings.NewReader("primary-index")))
+	require.NoError(t, secondary.Upload(ctx, "user-1/"+block1+"/meta.json", strings.NewReader("secondary-meta")))
+	require.NoError(t, secondary.Upload(ctx, "user-1/"+block1+"/chunks/000001", strings.NewReader("secondary-chunks")))
+	require.NoError(t, secondary.Upload(ctx, "user-1/"+block1+"/deletion-mark.json", strings.NewReader("secondary-mark")))
+	require.NoError(t, secondary.Upload(ctx, "user-1/markers/"+block1+"-no-compact-mark.json", strings.NewReader("secondary-mark")))
+	// The block 2 is only in the secondary bucket.
+	require.NoError(t, secondary.Upload(ctx, "user-1/"+block2+"/meta.json", strings.NewReader("secondary-meta")))
+	require.NoError(t, secondary.Upload(ctx, "user-1/"+block2+"/deletion-mark.json", strings.NewReader("secondary-mark")))
+	require.NoError(t, secondary.Upload(ctx, "user-1/markers/"+block2+"-deletion-mark.json", strings.NewReader("secondary-mark")))
+
+	client := NewFederatedBucketClient(primary, secondary)
+
+	t.Run("markers existing only in another bucket are not applied to the block", func(t *testing.T) {
+		for _, name := range []string{
+			"user-1/" + block1 + "/deletion-mark.json",
+			"user-1/markers/" + block1 + "-no-compact-mark.json",
+		} {
+			exists, err := client.Exists(ctx, name)
+			require.NoError(t, err)
+			assert.False(t, exists)
+
+			_, err = client.Get(ctx, name)
+			assert.True(t, client.IsObjNotFoundErr(err))
+		}
+
+		var markers []string
+		require.NoError(t, client.Iter(ctx, "user-1/markers/", func(name string) error {
+			markers = append(markers, name)
+			return nil
+		}))
+		assert.Equal(t, []string{"user-1/markers/" + block2 + "-deletion-mark.json"}, markers)
+	})
+
+	t.Run("block objects are read from the bucket owning the block only", func(t *testing.T) {
+		_, err := client.Get(ctx, "user-1/"+block1+"/chunks/000001")
+		assert.True(t, client.IsObjNotFoundErr(err))
+
+		var files []string
+		require.NoError(t, client.Iter(ctx, "user-1/"+block1, func(name string) error {
+			files = append(files, name)
+			return nil
+		}, objstore.WithRecursiveIter()))
+		assert.ElementsMatch(t, []string{"user-1/" + block1 + "/meta.json", "user-1/" + block1 + "/index"}, files)
+
+		for name, expected := range map[string]string{
+			"user-1/" + block1 + "/meta.json":                  "primary-meta",
+			"user-1/" + block2 + "/meta.json":                  "secondary-meta",
+			"user-1/" + block2 + "/deletion-mark.json":         "secondary-mark",
+			"user-1/markers/" + block2 + "-deletion-mark.json": "secondary-mark",
+		} {
+			r, err := client.Get(ctx, name)
+			require.NoError(t, err)
+			content, err := io.ReadAll(r)
+			require.NoError(t, err)
+			require.NoError(t, r.Close())
+			assert.Equal(t, expected, string(content))
+		}
+	})
+}
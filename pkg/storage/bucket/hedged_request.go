@@ -4,7 +4,11 @@ import (
 	"errors"
 	"flag"
 	"net/http"
+	"strings"
 
+	"github.com/cristalhq/hedgedhttp"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
 	"github.com/thanos-io/thanos/pkg/exthttp"
 )
 
@@ -16,22 +20,48 @@ type HedgedRequestConfig struct {
 	Enabled     bool    `yaml:"enabled"`
 	MaxRequests uint    `yaml:"max_requests"`
 	Quantile    float64 `yaml:"quantile"`
+	ChunksOnly  bool    `yaml:"chunks_only"`
 }
 
 func (cfg *HedgedRequestConfig) RegisterFlagsWithPrefix(f *flag.FlagSet, prefix string) {
 	f.BoolVar(&cfg.Enabled, prefix+"hedged-request.enabled", false, "If true, hedged requests are applied to object store calls. It can help with reducing tail latency.")
 	f.UintVar(&cfg.MaxRequests, prefix+"hedged-request.max-requests", 3, "Maximum number of hedged requests allowed for each initial request. A high number can reduce latency but increase internal calls.")
 	f.Float64Var(&cfg.Quantile, prefix+"hedged-request.quantile", 0.9, "It is used to calculate a latency threshold to trigger hedged requests. For example, additional requests are triggered when the initial request response time exceeds the 90th percentile.")
+	f.BoolVar(&cfg.ChunksOnly, prefix+"hedged-request.chunks-only", false, "If true, hedged requests are only applied to the chunks reads, and the latency threshold is calculated from the chunks reads only.")
 }
 
-func (cfg *HedgedRequestConfig) GetHedgedRoundTripper() func(rt http.RoundTripper) http.RoundTripper {
-	return exthttp.CreateHedgedTransportWithConfig(exthttp.CustomBucketConfig{
+// GetHedgedRoundTripper returns a function wrapping the object store client transport with
+// hedged requests, tracking the hedged requests with metrics labelled by the given component name.
+func (cfg *HedgedRequestConfig) GetHedgedRoundTripper(name string, reg prometheus.Registerer) func(rt http.RoundTripper) http.RoundTripper {
+	hedgedTransport := exthttp.CreateHedgedTransportWithConfig(exthttp.CustomBucketConfig{
 		HedgingConfig: exthttp.HedgingConfig{
 			Enabled:  cfg.Enabled,
 			UpTo:     cfg.MaxRequests,
 			Quantile: cfg.Quantile,
 		},
 	})
+	if !cfg.Enabled {
+		return hedgedTransport
+	}
+
+	reg = prometheus.WrapRegistererWith(prometheus.Labels{"component": name}, reg)
+	hedgedRequests := promauto.With(reg).NewCounter(prometheus.CounterOpts{
+		Name: "cortex_bucket_hedged_requests_total",
+		Help: "Total number of hedged requests sent to the object store.",
+	})
+	hedgedRequestWins := promauto.With(reg).NewCounter(prometheus.CounterOpts{
+		Name: "cortex_bucket_hedged_request_wins_total",
+		Help: "Total number of object store calls whose response was returned by a hedged request.",
+	})
+
+	return func(rt http.RoundTripper) http.RoundTripper {
+		return &hedgedRoundTripper{
+			next:              rt,
+			hedged:            hedgedTransport(&hedgedRequestCounter{next: rt, hedgedRequests: hedgedRequests}),
+			chunksOnly:        cfg.ChunksOnly,
+			hedgedRequestWins: hedgedRequestWins,
+		}
+	}
 }
 
 func (cfg *HedgedRequestConfig) Validate() error {
@@ -41,3 +71,42 @@ func (cfg *HedgedRequestConfig) Validate() error {
 
 	return nil
 }
+
+// hedgedRoundTripper sends the requests through the hedged transport, unless only the
+// chunks reads are hedged, and counts the responses returned by the hedged requests.
+type hedgedRoundTripper struct {
+	next              http.RoundTripper
+	hedged            http.RoundTripper
+	chunksOnly        bool
+	hedgedRequestWins prometheus.Counter
+}
+
+func (h *hedgedRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if h.chunksOnly && !isChunksRead(req) {
+		return h.next.RoundTrip(req)
+	}
+
+	resp, err := h.hedged.RoundTrip(req)
+	if err == nil && resp.Request != nil && hedgedhttp.IsHedgedRequest(resp.Request) {
+		h.hedgedRequestWins.Inc()
+	}
+	return resp, err
+}
+
+// hedgedRequestCounter counts the hedged requests sent by the hedged transport.
+type hedgedRequestCounter struct {
+	next           http.RoundTripper
+	hedgedRequests prometheus.Counter
+}
+
+func (h *hedgedRequestCounter) RoundTrip(req *http.Request) (*http.Response, error) {
+	if hedgedhttp.IsHedgedRequest(req) {
+		h.hedgedRequests.Inc()
+	}
+	return h.next.RoundTrip(req)
+}
+
+// isChunksRead returns whether the request reads a block chunks segment file.
+func isChunksRead(req *http.Request) bool {
+	return req.Method == http.MethodGet && strings.Contains(req.URL.Path, "/chunks/")
+}
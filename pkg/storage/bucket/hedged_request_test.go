@@ -1,9 +1,19 @@
 package bucket
 
 import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 
+	"github.com/cristalhq/hedgedhttp"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.uber.org/atomic"
 )
 
 func TestHedgedRequest_Validate(t *testing.T) {
@@ -34,3 +44,66 @@ func TestHedgedRequest_Validate(t *testing.T) {
 	}
 
 }
+
+func TestHedgedRequest_RoundTripper(t *testing.T) {
+	t.Parallel()
+
+	// The initial requests hang until they're canceled, while the hedged ones return immediately.
+	var requests atomic.Int64
+	transport := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		requests.Inc()
+		if !hedgedhttp.IsHedgedRequest(req) && strings.Contains(req.URL.Path, "/chunks/") {
+			<-req.Context().Done()
+			return nil, req.Context().Err()
+		}
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("")), Request: req}, nil
+	})
+
+	tests := map[string]struct {
+		chunksOnly             bool
+		path                   string
+		expectedHedgedRequests float64
+	}{
+		"should hedge the chunks reads": {
+			path:                   "/bucket/user-1/01H0000000000000000000000/chunks/000001",
+			expectedHedgedRequests: 1,
+		},
+		"should hedge the chunks reads if only the chunks reads are hedged": {
+			chunksOnly:             true,
+			path:                   "/bucket/user-1/01H0000000000000000000000/chunks/000001",
+			expectedHedgedRequests: 1,
+		},
+		"should not hedge the other reads if only the chunks reads are hedged": {
+			chunksOnly:             true,
+			path:                   "/bucket/user-1/01H0000000000000000000000/index",
+			expectedHedgedRequests: 0,
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			cfg := HedgedRequestConfig{Enabled: true, MaxRequests: 2, Quantile: 0.9, ChunksOnly: testData.chunksOnly}
+			reg := prometheus.NewPedanticRegistry()
+			rt := cfg.GetHedgedRoundTripper("test", reg)(transport)
+
+			resp, err := rt.RoundTrip(httptest.NewRequest(http.MethodGet, testData.path, nil))
+			require.NoError(t, err)
+			require.NoError(t, resp.Body.Close())
+
+			assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(fmt.Sprintf(`
+				# HELP cortex_bucket_hedged_request_wins_total Total number of object store calls whose response was returned by a hedged request.
+				# TYPE cortex_bucket_hedged_request_wins_total counter
+				cortex_bucket_hedged_request_wins_total{component="test"} %[1]v
+				# HELP cortex_bucket_hedged_requests_total Total number of hedged requests sent to the object store.
+				# TYPE cortex_bucket_hedged_requests_total counter
+				cortex_bucket_hedged_requests_total{component="test"} %[1]v
+			`, testData.expectedHedgedRequests))))
+		})
+	}
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
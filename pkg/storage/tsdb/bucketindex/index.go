@@ -21,6 +21,8 @@ const (
 	IndexFilename           = "bucket-index.json"
 	IndexCompressedFilename = IndexFilename + ".gz"
 	IndexVersion1           = 1
+	// IndexVersion2 adds the blocks statistics (number of series, chunks and size) to the index.
+	IndexVersion2 = 2
 
 	SegmentsFormatUnknown = ""
 
@@ -113,12 +115,26 @@ type Block struct {
 	SeriesMaxSize int64 `json:"series_max_size,omitempty"`
 	ChunkMaxSize  int64 `json:"chunk_max_size,omitempty"`
 
+	// Number of series and chunks in the block, and total size in bytes of the block files.
+	// They're zero for blocks indexed before IndexVersion2, or whose meta.json doesn't
+	// track them.
+	NumSeries uint64 `json:"num_series,omitempty"`
+	NumChunks uint64 `json:"num_chunks,omitempty"`
+	SizeBytes int64  `json:"size_bytes,omitempty"`
+
 	// UploadedAt is a unix timestamp (seconds precision) of when the block has been completed to be uploaded
 	// to the storage.
 	UploadedAt int64 `json:"uploaded_at"`
 
 	// Parquet metadata if exists. If doesn't exist it will be nil.
 	Parquet *parquet.ConverterMarkMeta `json:"parquet,omitempty"`
+
+	// OutOfOrder is true if the block has been compacted from out-of-order samples.
+	OutOfOrder bool `json:"out_of_order,omitempty"`
+
+	// CompactionLevel is the compaction level of the block. It's zero for blocks indexed
+	// before it was tracked.
+	CompactionLevel int `json:"compaction_level,omitempty"`
 }
 
 // Within returns whether the block contains samples within the provided range.
@@ -136,12 +152,19 @@ func (m *Block) GetUploadedAt() time.Time {
 // The returned meta doesn't include all original meta.json data but only a subset
 // of it.
 func (m *Block) ThanosMeta(userID string) *metadata.Meta {
-	return &metadata.Meta{
+	meta := &metadata.Meta{
 		BlockMeta: tsdb.BlockMeta{
 			ULID:    m.ID,
 			MinTime: m.MinTime,
 			MaxTime: m.MaxTime,
 			Version: metadata.TSDBVersion1,
+			Stats: tsdb.BlockStats{
+				NumSeries: m.NumSeries,
+				NumChunks: m.NumChunks,
+			},
+			Compaction: tsdb.BlockMetaCompaction{
+				Level: m.CompactionLevel,
+			},
 		},
 		Thanos: metadata.Thanos{
 			Version: metadata.ThanosVersion1,
@@ -155,6 +178,12 @@ func (m *Block) ThanosMeta(userID string) *metadata.Meta {
 			},
 		},
 	}
+
+	if m.OutOfOrder {
+		meta.Compaction.Hints = []string{tsdb.CompactionHintFromOutOfOrder}
+	}
+
+	return meta
 }
 
 func (m *Block) thanosMetaSegmentFiles() (files []string) {
@@ -178,14 +207,28 @@ func BlockFromThanosMeta(meta metadata.Meta) *Block {
 	segmentsFormat, segmentsNum := detectBlockSegmentsFormat(meta)
 
 	return &Block{
-		ID:             meta.ULID,
-		MinTime:        meta.MinTime,
-		MaxTime:        meta.MaxTime,
-		SegmentsFormat: segmentsFormat,
-		SegmentsNum:    segmentsNum,
-		SeriesMaxSize:  meta.Thanos.IndexStats.SeriesMaxSize,
-		ChunkMaxSize:   meta.Thanos.IndexStats.ChunkMaxSize,
+		ID:              meta.ULID,
+		MinTime:         meta.MinTime,
+		MaxTime:         meta.MaxTime,
+		SegmentsFormat:  segmentsFormat,
+		SegmentsNum:     segmentsNum,
+		SeriesMaxSize:   meta.Thanos.IndexStats.SeriesMaxSize,
+		ChunkMaxSize:    meta.Thanos.IndexStats.ChunkMaxSize,
+		NumSeries:       meta.Stats.NumSeries,
+		NumChunks:       meta.Stats.NumChunks,
+		SizeBytes:       blockSizeBytes(meta),
+		OutOfOrder:      meta.Compaction.FromOutOfOrder(),
+		CompactionLevel: meta.Compaction.Level,
+	}
+}
+
+// blockSizeBytes returns the total size of the block files tracked in the meta.json.
+func blockSizeBytes(meta metadata.Meta) int64 {
+	var size int64
+	for _, f := range meta.Thanos.Files {
+		size += f.SizeBytes
 	}
+	return size
 }
 
 func detectBlockSegmentsFormat(meta metadata.Meta) (string, int) {
@@ -234,6 +234,60 @@ func TestBlockFromThanosMeta(t *testing.T) {
 				ChunkMaxSize:   1000,
 			},
 		},
+		"meta.json with block stats": {
+			meta: metadata.Meta{
+				BlockMeta: tsdb.BlockMeta{
+					ULID:    blockID,
+					MinTime: 10,
+					MaxTime: 20,
+					Stats: tsdb.BlockStats{
+						NumSamples: 3000,
+						NumSeries:  100,
+						NumChunks:  200,
+					},
+				},
+				Thanos: metadata.Thanos{
+					Files: []metadata.File{
+						{RelPath: "index", SizeBytes: 1024},
+						{RelPath: "chunks/000001", SizeBytes: 4096},
+						{RelPath: "meta.json"},
+					},
+				},
+			},
+			expected: Block{
+				ID:             blockID,
+				MinTime:        10,
+				MaxTime:        20,
+				SegmentsFormat: SegmentsFormat1Based6Digits,
+				SegmentsNum:    1,
+				NumSeries:      100,
+				NumChunks:      200,
+				SizeBytes:      5120,
+			},
+		},
+		"meta.json with out-of-order compaction hint": {
+			meta: metadata.Meta{
+				BlockMeta: tsdb.BlockMeta{
+					ULID:    blockID,
+					MinTime: 10,
+					MaxTime: 20,
+					Compaction: tsdb.BlockMetaCompaction{
+						Level: 2,
+						Hints: []string{tsdb.CompactionHintFromOutOfOrder},
+					},
+				},
+				Thanos: metadata.Thanos{},
+			},
+			expected: Block{
+				ID:              blockID,
+				MinTime:         10,
+				MaxTime:         20,
+				SegmentsFormat:  SegmentsFormatUnknown,
+				SegmentsNum:     0,
+				OutOfOrder:      true,
+				CompactionLevel: 2,
+			},
+		},
 	}
 
 	for testName, testData := range tests {
@@ -376,6 +430,61 @@ func TestBlock_ThanosMeta(t *testing.T) {
 				},
 			},
 		},
+		"block with block stats": {
+			block: Block{
+				ID:        blockID,
+				MinTime:   10,
+				MaxTime:   20,
+				NumSeries: 100,
+				NumChunks: 200,
+				SizeBytes: 5120,
+			},
+			expected: &metadata.Meta{
+				BlockMeta: tsdb.BlockMeta{
+					ULID:    blockID,
+					MinTime: 10,
+					MaxTime: 20,
+					Version: metadata.TSDBVersion1,
+					Stats: tsdb.BlockStats{
+						NumSeries: 100,
+						NumChunks: 200,
+					},
+				},
+				Thanos: metadata.Thanos{
+					Version: metadata.ThanosVersion1,
+					Labels: map[string]string{
+						"__org_id__": userID,
+					},
+				},
+			},
+		},
+		"out-of-order block": {
+			block: Block{
+				ID:              blockID,
+				MinTime:         10,
+				MaxTime:         20,
+				OutOfOrder:      true,
+				CompactionLevel: 2,
+			},
+			expected: &metadata.Meta{
+				BlockMeta: tsdb.BlockMeta{
+					ULID:    blockID,
+					MinTime: 10,
+					MaxTime: 20,
+					Version: metadata.TSDBVersion1,
+					Compaction: tsdb.BlockMetaCompaction{
+						Level: 2,
+						Hints: []string{tsdb.CompactionHintFromOutOfOrder},
+					},
+				},
+				Thanos: metadata.Thanos{
+					Version: metadata.ThanosVersion1,
+					Labels: map[string]string{
+						"__org_id__": userID,
+					},
+				},
+			},
+		},
 	}
 
 	for testName, testData := range tests {
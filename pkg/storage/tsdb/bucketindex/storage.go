@@ -4,8 +4,12 @@ import (
 	"bytes"
 	"compress/gzip"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"io"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/go-kit/log"
@@ -34,12 +38,18 @@ const (
 	SyncStatusFile = "bucket-index-sync-status.json"
 	// SyncStatusFileVersion is the current supported version of bucket-index-sync-status.json file.
 	SyncStatusFileVersion = 1
+
+	// indexChecksumPrefix is the prefix of the bucket index checksum, stored in the gzip header comment.
+	indexChecksumPrefix = "sha256:"
 )
 
 var (
 	ErrIndexNotFound  = errors.New("bucket index not found")
 	ErrIndexCorrupted = errors.New("bucket index corrupted")
 
+	// missingChecksumWarning is used to log only once that a bucket index without checksum has been read.
+	missingChecksumWarning sync.Once
+
 	UnknownStatus = Status{
 		Version:            SyncStatusFileVersion,
 		Status:             Unknown,
@@ -90,16 +100,50 @@ func ReadIndex(ctx context.Context, bkt objstore.Bucket, userID string, cfgProvi
 	}
 	defer runutil.CloseWithLogOnErr(logger, gzipReader, "close bucket index gzip reader")
 
+	content, err := io.ReadAll(gzipReader)
+	if err != nil {
+		return nil, ErrIndexCorrupted
+	}
+
+	// Verify the checksum, to not proceed with a partially written index.
+	if err := verifyIndexChecksum(gzipReader.Comment, content, logger); err != nil {
+		return nil, err
+	}
+
 	// Deserialize it.
 	index := &Index{}
-	d := json.NewDecoder(gzipReader)
-	if err := d.Decode(index); err != nil {
+	if err := json.Unmarshal(content, index); err != nil {
 		return nil, ErrIndexCorrupted
 	}
 
 	return index, nil
 }
 
+// indexChecksum returns the checksum of the serialized bucket index.
+func indexChecksum(content []byte) string {
+	sum := sha256.Sum256(content)
+	return indexChecksumPrefix + hex.EncodeToString(sum[:])
+}
+
+// verifyIndexChecksum checks the serialized bucket index matches the expected checksum. Indexes
+// written before the checksum was introduced don't have one, so they're considered valid.
+func verifyIndexChecksum(expected string, content []byte, logger log.Logger) error {
+	if expected == "" {
+		missingChecksumWarning.Do(func() {
+			level.Warn(logger).Log("msg", "read a bucket index without checksum, it will be added the next time the bucket index is updated")
+		})
+		return nil
+	}
+
+	if !strings.HasPrefix(expected, indexChecksumPrefix) {
+		return errors.Wrapf(ErrIndexCorrupted, "unknown checksum format: %s", expected)
+	}
+	if actual := indexChecksum(content); actual != expected {
+		return errors.Wrapf(ErrIndexCorrupted, "checksum mismatch (expected: %s, actual: %s)", expected, actual)
+	}
+	return nil
+}
+
 // WriteIndex uploads the provided index to the storage.
 func WriteIndex(ctx context.Context, bkt objstore.Bucket, userID string, cfgProvider bucket.TenantConfigProvider, idx *Index) error {
 	bkt = bucket.NewUserBucketClient(userID, bkt, cfgProvider)
@@ -110,10 +154,12 @@ func WriteIndex(ctx context.Context, bkt objstore.Bucket, userID string, cfgProv
 		return errors.Wrap(err, "marshal bucket index")
 	}
 
-	// Compress it.
+	// Compress it, storing the checksum of the content in the gzip header
+	// so that readers can detect a corrupted index.
 	var gzipContent bytes.Buffer
 	gzip := gzip.NewWriter(&gzipContent)
 	gzip.Name = IndexFilename
+	gzip.Comment = indexChecksum(content)
 
 	if _, err := gzip.Write(content); err != nil {
 		return errors.Wrap(err, "gzip bucket index")
@@ -1,7 +1,10 @@
 package bucketindex
 
 import (
+	"bytes"
+	"compress/gzip"
 	"context"
+	"encoding/json"
 	"errors"
 	"path"
 	"strings"
@@ -39,6 +42,60 @@ func TestReadIndex_ShouldReturnErrorIfIndexIsCorrupted(t *testing.T) {
 	require.Nil(t, idx)
 }
 
+func TestReadIndex_ShouldReturnErrorIfIndexChecksumMismatches(t *testing.T) {
+	const userID = "user-1"
+
+	ctx := context.Background()
+	bkt, _ := testutil.PrepareFilesystemBucket(t)
+
+	tests := map[string]struct {
+		checksum func(content []byte) string
+		content  func(content []byte) []byte
+	}{
+		"checksum of another content": {
+			checksum: func([]byte) string { return indexChecksum([]byte("{}")) },
+			content:  func(content []byte) []byte { return content },
+		},
+		"truncated content": {
+			checksum: indexChecksum,
+			content:  func(content []byte) []byte { return content[:len(content)/2] },
+		},
+		"unknown checksum format": {
+			checksum: func([]byte) string { return "unknown" },
+			content:  func(content []byte) []byte { return content },
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			content, err := json.Marshal(&Index{Version: IndexVersion2, Blocks: Blocks{}})
+			require.NoError(t, err)
+
+			uploadGzippedIndex(t, bkt, userID, testData.checksum(content), testData.content(content))
+
+			idx, err := ReadIndex(ctx, bkt, userID, nil, log.NewNopLogger())
+			require.ErrorIs(t, err, ErrIndexCorrupted)
+			require.Nil(t, idx)
+		})
+	}
+}
+
+func TestReadIndex_ShouldAcceptIndexWithoutChecksum(t *testing.T) {
+	const userID = "user-1"
+
+	ctx := context.Background()
+	bkt, _ := testutil.PrepareFilesystemBucket(t)
+
+	expectedIdx := &Index{Version: IndexVersion1, Blocks: Blocks{}, BlockDeletionMarks: BlockDeletionMarks{}, UpdatedAt: 10}
+	content, err := json.Marshal(expectedIdx)
+	require.NoError(t, err)
+	uploadGzippedIndex(t, bkt, userID, "", content)
+
+	actualIdx, err := ReadIndex(ctx, bkt, userID, nil, log.NewNopLogger())
+	require.NoError(t, err)
+	assert.Equal(t, expectedIdx, actualIdx)
+}
+
 func TestReadIndex_ShouldReturnErrorIfKeyAccessDeniedErr(t *testing.T) {
 	bkt, _ := testutil.PrepareFilesystemBucket(t)
 	bkt = &testutil.MockBucketFailure{
@@ -149,3 +206,14 @@ func TestDeleteIndex_ShouldNotReturnErrorIfIndexDoesNotExist(t *testing.T) {
 
 	assert.NoError(t, DeleteIndex(ctx, bkt, "user-1", nil))
 }
+
+func uploadGzippedIndex(t *testing.T, bkt objstore.Bucket, userID, comment string, content []byte) {
+	var gzipContent bytes.Buffer
+	w := gzip.NewWriter(&gzipContent)
+	w.Comment = comment
+	_, err := w.Write(content)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	require.NoError(t, bkt.Upload(context.Background(), path.Join(userID, IndexCompressedFilename), &gzipContent))
+}
@@ -3,6 +3,7 @@ package bucketindex
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"io"
 	"path"
 	"time"
@@ -26,15 +27,17 @@ var (
 	ErrBlockMetaCorrupted         = block.ErrorSyncMetaCorrupted
 	ErrBlockDeletionMarkNotFound  = errors.New("block deletion mark not found")
 	ErrBlockDeletionMarkCorrupted = errors.New("block deletion mark corrupted")
+	ErrBlockIncomplete            = errors.New("block incomplete")
 
 	errBlockMetaKeyAccessDeniedErr = errors.New("block meta file key access denied error")
 )
 
 // Updater is responsible to generate an update in-memory bucket index.
 type Updater struct {
-	bkt            objstore.InstrumentedBucket
-	logger         log.Logger
-	parquetEnabled bool
+	bkt                   objstore.InstrumentedBucket
+	logger                log.Logger
+	parquetEnabled        bool
+	checkIncompleteBlocks bool
 }
 
 func NewUpdater(bkt objstore.Bucket, userID string, cfgProvider bucket.TenantConfigProvider, logger log.Logger) *Updater {
@@ -49,18 +52,36 @@ func (w *Updater) EnableParquet() *Updater {
 	return w
 }
 
+// EnableIncompleteBlocksCheck makes the updater check the files of the new blocks, and skip
+// the blocks whose files are missing or partially uploaded until they're complete.
+func (w *Updater) EnableIncompleteBlocksCheck() *Updater {
+	w.checkIncompleteBlocks = true
+	return w
+}
+
 // UpdateIndex generates the bucket index and returns it, without storing it to the storage.
 // If the old index is not passed in input, then the bucket index will be generated from scratch.
+//
+// When the old index is passed in input, blocks and deletion marks already in the index are carried
+// forward without being read again, so the number of GET requests only grows with new blocks and
+// marks. The tenant's blocks are still listed on each update: the listing is required to discover
+// blocks uploaded with an older ULID (eg. backfilled blocks) and blocks deleted from the storage,
+// and object storage listing can't be bounded by a ULID lower bound because it's directory based.
 func (w *Updater) UpdateIndex(ctx context.Context, old *Index) (*Index, map[ulid.ULID]error, int64, error) {
 	var (
 		oldBlocks             []*Block
 		oldBlockDeletionMarks []*BlockDeletionMark
+		refreshOldBlocks      bool
 	)
 
 	// Read the old index, if provided.
 	if old != nil {
 		oldBlocks = old.Blocks
 		oldBlockDeletionMarks = old.BlockDeletionMarks
+
+		// Blocks indexed before IndexVersion2 miss the blocks statistics, so we fetch
+		// their meta.json again to get them.
+		refreshOldBlocks = old.Version < IndexVersion2
 	}
 
 	blockDeletionMarks, deletedBlocks, totalBlocksBlocksMarkedForNoCompaction, err := w.updateBlockMarks(ctx, oldBlockDeletionMarks)
@@ -68,7 +89,7 @@ func (w *Updater) UpdateIndex(ctx context.Context, old *Index) (*Index, map[ulid
 		return nil, nil, 0, err
 	}
 
-	blocks, partials, err := w.updateBlocks(ctx, oldBlocks, deletedBlocks)
+	blocks, partials, err := w.updateBlocks(ctx, oldBlocks, deletedBlocks, refreshOldBlocks)
 	if err != nil {
 		return nil, nil, 0, err
 	}
@@ -79,14 +100,14 @@ func (w *Updater) UpdateIndex(ctx context.Context, old *Index) (*Index, map[ulid
 	}
 
 	return &Index{
-		Version:            IndexVersion1,
+		Version:            IndexVersion2,
 		Blocks:             blocks,
 		BlockDeletionMarks: blockDeletionMarks,
 		UpdatedAt:          time.Now().Unix(),
 	}, partials, totalBlocksBlocksMarkedForNoCompaction, nil
 }
 
-func (w *Updater) updateBlocks(ctx context.Context, old []*Block, deletedBlocks map[ulid.ULID]struct{}) (blocks []*Block, partials map[ulid.ULID]error, _ error) {
+func (w *Updater) updateBlocks(ctx context.Context, old []*Block, deletedBlocks map[ulid.ULID]struct{}, refreshOld bool) (blocks []*Block, partials map[ulid.ULID]error, _ error) {
 	discovered := map[ulid.ULID]struct{}{}
 	partials = map[ulid.ULID]error{}
 
@@ -101,15 +122,20 @@ func (w *Updater) updateBlocks(ctx context.Context, old []*Block, deletedBlocks
 		return nil, nil, errors.Wrap(err, "list blocks")
 	}
 
-	// Since blocks are immutable, all blocks already existing in the index can just be copied.
+	// Since blocks are immutable, all blocks already existing in the index can just be copied,
+	// unless they have to be refreshed, in which case they're left in the discovered ones.
 	for _, b := range old {
 		if _, ok := discovered[b.ID]; ok {
-			delete(discovered, b.ID)
-
 			if _, ok := deletedBlocks[b.ID]; ok {
+				delete(discovered, b.ID)
 				level.Warn(w.logger).Log("msg", "skipped block with missing global deletion marker", "block", b.ID.String())
 				continue
 			}
+			if refreshOld {
+				continue
+			}
+
+			delete(discovered, b.ID)
 			blocks = append(blocks, b)
 		}
 	}
@@ -139,6 +165,11 @@ func (w *Updater) updateBlocks(ctx context.Context, old []*Block, deletedBlocks
 			level.Error(w.logger).Log("msg", "skipped block with corrupted meta.json when updating bucket index", "block", id.String(), "err", err)
 			continue
 		}
+		if errors.Is(err, ErrBlockIncomplete) {
+			partials[id] = err
+			level.Warn(w.logger).Log("msg", "skipped incomplete block when updating bucket index, it will be checked again at the next update", "block", id.String(), "err", err)
+			continue
+		}
 		return nil, nil, err
 	}
 
@@ -176,6 +207,16 @@ func (w *Updater) updateBlockIndexEntry(ctx context.Context, id ulid.ULID) (*Blo
 		return nil, errors.Errorf("unexpected block meta version: %s version: %d", metaFile, m.Version)
 	}
 
+	if w.checkIncompleteBlocks {
+		reason, err := CheckBlockFiles(ctx, w.bkt, &m)
+		if err != nil {
+			return nil, err
+		}
+		if reason != "" {
+			return nil, errors.Wrap(ErrBlockIncomplete, reason)
+		}
+	}
+
 	block := BlockFromThanosMeta(m)
 
 	// Get the meta.json attributes.
@@ -313,3 +354,32 @@ func (w *Updater) updateParquetBlocks(ctx context.Context, blocks []*Block) erro
 	}
 	return nil
 }
+
+// CheckBlockFiles returns the reason why the block is incomplete, or an empty string if all its
+// files have been uploaded. The files are checked through their attributes, without reading them.
+// When the meta.json doesn't list the block files, only the existence of the index is checked.
+func CheckBlockFiles(ctx context.Context, bkt objstore.InstrumentedBucketReader, m *metadata.Meta) (string, error) {
+	files := m.Thanos.Files
+	if len(files) == 0 {
+		files = []metadata.File{{RelPath: block.IndexFilename}}
+	}
+
+	for _, file := range files {
+		if file.RelPath == block.MetaFilename {
+			continue
+		}
+
+		attrs, err := bkt.Attributes(ctx, path.Join(m.ULID.String(), file.RelPath))
+		if bkt.IsObjNotFoundErr(err) {
+			return fmt.Sprintf("%s is missing", file.RelPath), nil
+		}
+		if err != nil {
+			return "", errors.Wrapf(err, "get attributes of %s of block %s", file.RelPath, m.ULID)
+		}
+		if file.SizeBytes > 0 && attrs.Size != file.SizeBytes {
+			return fmt.Sprintf("%s has size %d instead of %d", file.RelPath, attrs.Size, file.SizeBytes), nil
+		}
+	}
+
+	return "", nil
+}
@@ -69,6 +69,44 @@ func TestUpdater_UpdateIndex(t *testing.T) {
 		[]*metadata.DeletionMark{block4Mark})
 }
 
+func TestUpdater_UpdateIndex_ShouldSkipIncompleteBlocks(t *testing.T) {
+	const userID = "user-1"
+
+	bkt, _ := testutil.PrepareFilesystemBucket(t)
+
+	ctx := context.Background()
+	logger := log.NewNopLogger()
+
+	// Mock some blocks in the storage.
+	block1 := testutil.MockStorageBlock(t, bkt, userID, 10, 20)
+	block2 := testutil.MockStorageBlock(t, bkt, userID, 20, 30)
+
+	// Delete a block's index to simulate an interrupted upload.
+	indexPath := path.Join(userID, block2.ULID.String(), block.IndexFilename)
+	require.NoError(t, bkt.Delete(ctx, indexPath))
+
+	// The incomplete block is indexed only if the check is enabled.
+	idx, partials, _, err := NewUpdater(bkt, userID, nil, logger).UpdateIndex(ctx, nil)
+	require.NoError(t, err)
+	assertBucketIndexEqual(t, idx, bkt, userID, []tsdb.BlockMeta{block1, block2}, nil)
+	assert.Empty(t, partials)
+
+	w := NewUpdater(bkt, userID, nil, logger).EnableIncompleteBlocksCheck()
+	idx, partials, _, err = w.UpdateIndex(ctx, nil)
+	require.NoError(t, err)
+	assertBucketIndexEqual(t, idx, bkt, userID, []tsdb.BlockMeta{block1}, nil)
+	assert.Len(t, partials, 1)
+	assert.True(t, errors.Is(partials[block2.ULID], ErrBlockIncomplete))
+
+	// The block is indexed once complete.
+	require.NoError(t, bkt.Upload(ctx, indexPath, strings.NewReader("")))
+
+	idx, partials, _, err = w.UpdateIndex(ctx, idx)
+	require.NoError(t, err)
+	assertBucketIndexEqual(t, idx, bkt, userID, []tsdb.BlockMeta{block1, block2}, nil)
+	assert.Empty(t, partials)
+}
+
 func TestUpdater_UpdateIndex_ShouldSkipPartialBlocks(t *testing.T) {
 	const userID = "user-1"
 
@@ -318,6 +356,59 @@ func TestUpdater_UpdateIndex_ShouldSkipBlockMarkedForDeletionWithMissingGlobalMa
 	assert.Empty(t, nonCompactBlocks)
 }
 
+func TestUpdater_UpdateIndex_ShouldBackfillBlockStatsOfIndexVersion1(t *testing.T) {
+	const userID = "user-1"
+
+	bkt, _ := testutil.PrepareFilesystemBucket(t)
+
+	ctx := context.Background()
+	logger := log.NewNopLogger()
+
+	// Mock a block whose meta.json tracks the block stats.
+	bkt = BucketWithGlobalMarkers(bkt)
+	block1 := testutil.MockStorageBlock(t, bkt, userID, 10, 20)
+	meta := metadata.Meta{
+		BlockMeta: block1,
+		Thanos: metadata.Thanos{
+			Files: []metadata.File{
+				{RelPath: "index", SizeBytes: 1024},
+				{RelPath: "chunks/000001", SizeBytes: 4096},
+			},
+		},
+	}
+	meta.Stats = tsdb.BlockStats{NumSeries: 100, NumChunks: 200}
+	metaContent, err := json.Marshal(meta)
+	require.NoError(t, err)
+	require.NoError(t, bkt.Upload(ctx, path.Join(userID, block1.ULID.String(), block.MetaFilename), bytes.NewReader(metaContent)))
+
+	// Mock an index generated before the block stats were tracked.
+	oldIdx := &Index{
+		Version: IndexVersion1,
+		Blocks: Blocks{&Block{
+			ID:         block1.ULID,
+			MinTime:    block1.MinTime,
+			MaxTime:    block1.MaxTime,
+			UploadedAt: getBlockUploadedAt(t, bkt, userID, block1.ULID),
+		}},
+	}
+
+	w := NewUpdater(bkt, userID, nil, logger)
+	idx, _, _, err := w.UpdateIndex(ctx, oldIdx)
+	require.NoError(t, err)
+	assert.Equal(t, IndexVersion2, idx.Version)
+	require.Len(t, idx.Blocks, 1)
+	assert.Equal(t, uint64(100), idx.Blocks[0].NumSeries)
+	assert.Equal(t, uint64(200), idx.Blocks[0].NumChunks)
+	assert.Equal(t, int64(5120), idx.Blocks[0].SizeBytes)
+
+	// Once the index is upgraded, the blocks are copied from the old index.
+	idx.Blocks[0].NumSeries = 1
+	idx, _, _, err = w.UpdateIndex(ctx, idx)
+	require.NoError(t, err)
+	require.Len(t, idx.Blocks, 1)
+	assert.Equal(t, uint64(1), idx.Blocks[0].NumSeries)
+}
+
 func TestUpdater_UpdateIndex_NoTenantInTheBucket(t *testing.T) {
 	const userID = "user-1"
 
@@ -329,7 +420,7 @@ func TestUpdater_UpdateIndex_NoTenantInTheBucket(t *testing.T) {
 		idx, partials, _, err := w.UpdateIndex(ctx, oldIdx)
 
 		require.NoError(t, err)
-		assert.Equal(t, IndexVersion1, idx.Version)
+		assert.Equal(t, IndexVersion2, idx.Version)
 		assert.InDelta(t, time.Now().Unix(), idx.UpdatedAt, 2)
 		assert.Len(t, idx.Blocks, 0)
 		assert.Len(t, idx.BlockDeletionMarks, 0)
@@ -507,17 +598,18 @@ func getBlockUploadedAt(t testing.TB, bkt objstore.Bucket, userID string, blockI
 }
 
 func assertBucketIndexEqual(t testing.TB, idx *Index, bkt objstore.Bucket, userID string, expectedBlocks []tsdb.BlockMeta, expectedDeletionMarks []*metadata.DeletionMark) {
-	assert.Equal(t, IndexVersion1, idx.Version)
+	assert.Equal(t, IndexVersion2, idx.Version)
 	assert.InDelta(t, time.Now().Unix(), idx.UpdatedAt, 2)
 
 	// Build the list of expected block index entries.
 	var expectedBlockEntries []*Block
 	for _, b := range expectedBlocks {
 		expectedBlockEntries = append(expectedBlockEntries, &Block{
-			ID:         b.ULID,
-			MinTime:    b.MinTime,
-			MaxTime:    b.MaxTime,
-			UploadedAt: getBlockUploadedAt(t, bkt, userID, b.ULID),
+			ID:              b.ULID,
+			MinTime:         b.MinTime,
+			MaxTime:         b.MaxTime,
+			UploadedAt:      getBlockUploadedAt(t, bkt, userID, b.ULID),
+			CompactionLevel: b.Compaction.Level,
 		})
 	}
 
@@ -536,17 +628,18 @@ func assertBucketIndexEqual(t testing.TB, idx *Index, bkt objstore.Bucket, userI
 }
 
 func assertBucketIndexEqualWithParquet(t testing.TB, idx *Index, bkt objstore.Bucket, userID string, expectedBlocks []tsdb.BlockMeta, expectedDeletionMarks []*metadata.DeletionMark, parquetBlocks map[string]*parquet.ConverterMarkMeta) {
-	assert.Equal(t, IndexVersion1, idx.Version)
+	assert.Equal(t, IndexVersion2, idx.Version)
 	assert.InDelta(t, time.Now().Unix(), idx.UpdatedAt, 2)
 
 	// Build the list of expected block index entries.
 	var expectedBlockEntries []*Block
 	for _, b := range expectedBlocks {
 		block := &Block{
-			ID:         b.ULID,
-			MinTime:    b.MinTime,
-			MaxTime:    b.MaxTime,
-			UploadedAt: getBlockUploadedAt(t, bkt, userID, b.ULID),
+			ID:              b.ULID,
+			MinTime:         b.MinTime,
+			MaxTime:         b.MaxTime,
+			UploadedAt:      getBlockUploadedAt(t, bkt, userID, b.ULID),
+			CompactionLevel: b.Compaction.Level,
 		}
 		if meta, ok := parquetBlocks[b.ULID.String()]; ok {
 			block.Parquet = meta
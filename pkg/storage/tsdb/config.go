@@ -49,16 +49,17 @@ const (
 
 // Validation errors
 var (
-	errInvalidShipConcurrency         = errors.New("invalid TSDB ship concurrency")
-	errInvalidOpeningConcurrency      = errors.New("invalid TSDB opening concurrency")
-	errInvalidCompactionInterval      = errors.New("invalid TSDB compaction interval")
-	errInvalidCompactionConcurrency   = errors.New("invalid TSDB compaction concurrency")
-	errInvalidWALSegmentSizeBytes     = errors.New("invalid TSDB WAL segment size bytes")
-	errInvalidStripeSize              = errors.New("invalid TSDB stripe size")
-	errInvalidOutOfOrderCapMax        = errors.New("invalid TSDB OOO chunks capacity (in samples)")
-	errEmptyBlockranges               = errors.New("empty block ranges for TSDB")
-	errUnSupportedWALCompressionType  = errors.New("unsupported WAL compression type, valid types are (zstd, snappy and '')")
-	errInvalidParquetQueryConcurrency = errors.New("invalid parquet query concurrency, the value must be greater than 0")
+	errInvalidShipConcurrency             = errors.New("invalid TSDB ship concurrency")
+	errInvalidOpeningConcurrency          = errors.New("invalid TSDB opening concurrency")
+	errInvalidCompactionInterval          = errors.New("invalid TSDB compaction interval")
+	errInvalidCompactionConcurrency       = errors.New("invalid TSDB compaction concurrency")
+	errInvalidSeriesCompactionMinInterval = errors.New("invalid TSDB series compaction min interval, the value must be equal or greater than 0")
+	errInvalidWALSegmentSizeBytes         = errors.New("invalid TSDB WAL segment size bytes")
+	errInvalidStripeSize                  = errors.New("invalid TSDB stripe size")
+	errInvalidOutOfOrderCapMax            = errors.New("invalid TSDB OOO chunks capacity (in samples)")
+	errEmptyBlockranges                   = errors.New("empty block ranges for TSDB")
+	errUnSupportedWALCompressionType      = errors.New("unsupported WAL compression type, valid types are (zstd, snappy and '')")
+	errInvalidParquetQueryConcurrency     = errors.New("invalid parquet query concurrency, the value must be greater than 0")
 
 	ErrInvalidBucketIndexBlockDiscoveryStrategy         = errors.New("bucket index block discovery strategy can only be enabled when bucket index is enabled")
 	ErrBlockDiscoveryStrategy                           = errors.New("invalid block discovery strategy")
@@ -151,12 +152,15 @@ type TSDBConfig struct {
 	HeadCompactionInterval    time.Duration `yaml:"head_compaction_interval"`
 	HeadCompactionConcurrency int           `yaml:"head_compaction_concurrency"`
 	HeadCompactionIdleTimeout time.Duration `yaml:"head_compaction_idle_timeout"`
-	HeadChunksWriteBufferSize int           `yaml:"head_chunks_write_buffer_size_bytes"`
-	StripeSize                int           `yaml:"stripe_size"`
-	WALCompressionType        string        `yaml:"wal_compression_type"`
-	WALSegmentSizeBytes       int           `yaml:"wal_segment_size_bytes"`
-	FlushBlocksOnShutdown     bool          `yaml:"flush_blocks_on_shutdown"`
-	CloseIdleTSDBTimeout      time.Duration `yaml:"close_idle_tsdb_timeout"`
+	// Number of in-memory series triggering the head compaction, independently of HeadCompactionInterval.
+	HeadCompactionSeriesThreshold int           `yaml:"head_compaction_series_threshold"`
+	SeriesCompactionMinInterval   time.Duration `yaml:"series_compaction_min_interval"`
+	HeadChunksWriteBufferSize     int           `yaml:"head_chunks_write_buffer_size_bytes"`
+	StripeSize                    int           `yaml:"stripe_size"`
+	WALCompressionType            string        `yaml:"wal_compression_type"`
+	WALSegmentSizeBytes           int           `yaml:"wal_segment_size_bytes"`
+	FlushBlocksOnShutdown         bool          `yaml:"flush_blocks_on_shutdown"`
+	CloseIdleTSDBTimeout          time.Duration `yaml:"close_idle_tsdb_timeout"`
 	// The size of the in-memory queue used before flushing chunks to the disk.
 	HeadChunksWriteQueueSize int `yaml:"head_chunks_write_queue_size"`
 
@@ -201,6 +205,8 @@ func (cfg *TSDBConfig) RegisterFlags(f *flag.FlagSet) {
 	f.DurationVar(&cfg.HeadCompactionInterval, "blocks-storage.tsdb.head-compaction-interval", 1*time.Minute, "How frequently does Cortex try to compact TSDB head. Block is only created if data covers smallest block range. Must be greater than 0 and max 30 minutes. Note that up to 50% jitter is added to the value for the first compaction to avoid ingesters compacting concurrently.")
 	f.IntVar(&cfg.HeadCompactionConcurrency, "blocks-storage.tsdb.head-compaction-concurrency", 5, "Maximum number of tenants concurrently compacting TSDB head into a new block")
 	f.DurationVar(&cfg.HeadCompactionIdleTimeout, "blocks-storage.tsdb.head-compaction-idle-timeout", 1*time.Hour, "If TSDB head is idle for this duration, it is compacted. Note that up to 25% jitter is added to the value to avoid ingesters compacting concurrently. 0 means disabled.")
+	f.IntVar(&cfg.HeadCompactionSeriesThreshold, "blocks-storage.tsdb.head-compaction-series-threshold", 0, "EXPERIMENTAL: If the number of in-memory series of a tenant's TSDB head reaches this threshold, the head block ranges older than the one of the most recent sample are compacted without waiting for the next head compaction interval, to release the memory used by the series with no sample left in the head. 0 means disabled.")
+	f.DurationVar(&cfg.SeriesCompactionMinInterval, "blocks-storage.tsdb.series-compaction-min-interval", 15*time.Minute, "EXPERIMENTAL: Minimum interval between two head compactions of a tenant's TSDB triggered by its number of in-memory series, that is by -blocks-storage.tsdb.head-compaction-series-threshold or by the evict-idle max series policy. These compactions only compact the block ranges older than the one of the most recent sample, so they run at most once per block range, and not more often than this interval.")
 	f.IntVar(&cfg.HeadChunksWriteBufferSize, "blocks-storage.tsdb.head-chunks-write-buffer-size-bytes", chunks.DefaultWriteBufferSize, "The write buffer size used by the head chunks mapper. Lower values reduce memory utilisation on clusters with a large number of tenants at the cost of increased disk I/O operations.")
 	f.IntVar(&cfg.StripeSize, "blocks-storage.tsdb.stripe-size", 16384, "The number of shards of series to use in TSDB (must be a power of 2). Reducing this will decrease memory footprint, but can negatively impact performance.")
 	f.StringVar(&cfg.WALCompressionType, "blocks-storage.tsdb.wal-compression-type", "", "TSDB WAL type. Supported values are: 'snappy', 'zstd' and '' (disable compression)")
@@ -235,6 +241,10 @@ func (cfg *TSDBConfig) Validate() error {
 		return errInvalidCompactionConcurrency
 	}
 
+	if cfg.SeriesCompactionMinInterval < 0 {
+		return errInvalidSeriesCompactionMinInterval
+	}
+
 	if cfg.HeadChunksWriteBufferSize < chunks.MinWriteBufferSize || cfg.HeadChunksWriteBufferSize > chunks.MaxWriteBufferSize || cfg.HeadChunksWriteBufferSize%1024 != 0 {
 		return errors.Errorf("head chunks write buffer size must be a multiple of 1024 between %d and %d", chunks.MinWriteBufferSize, chunks.MaxWriteBufferSize)
 	}
@@ -278,26 +288,33 @@ func (cfg *TSDBConfig) IsBlocksShippingEnabled() bool {
 
 // BucketStoreConfig holds the config information for Bucket Stores used by the querier and store-gateway.
 type BucketStoreConfig struct {
-	SyncDir                  string                      `yaml:"sync_dir"`
-	SyncInterval             time.Duration               `yaml:"sync_interval"`
-	MaxConcurrent            int                         `yaml:"max_concurrent"`
-	MaxInflightRequests      int                         `yaml:"max_inflight_requests"`
-	TenantSyncConcurrency    int                         `yaml:"tenant_sync_concurrency"`
-	BlockSyncConcurrency     int                         `yaml:"block_sync_concurrency"`
-	MetaSyncConcurrency      int                         `yaml:"meta_sync_concurrency"`
-	ConsistencyDelay         time.Duration               `yaml:"consistency_delay"`
-	IndexCache               IndexCacheConfig            `yaml:"index_cache"`
-	ChunksCache              ChunksCacheConfig           `yaml:"chunks_cache"`
-	MetadataCache            MetadataCacheConfig         `yaml:"metadata_cache"`
-	ParquetLabelsCache       ParquetLabelsCacheConfig    `yaml:"parquet_labels_cache"`
-	ParquetRowRangesCache    ParquetRowRangesCacheConfig `yaml:"parquet_row_ranges_cache"`
-	MatchersCacheMaxItems    int                         `yaml:"matchers_cache_max_items"`
-	IgnoreDeletionMarksDelay time.Duration               `yaml:"ignore_deletion_mark_delay"`
-	IgnoreBlocksWithin       time.Duration               `yaml:"ignore_blocks_within"`
-	IgnoreBlocksBefore       time.Duration               `yaml:"ignore_blocks_before"`
-	BucketIndex              BucketIndexConfig           `yaml:"bucket_index"`
-	BlockDiscoveryStrategy   string                      `yaml:"block_discovery_strategy"`
-	BucketStoreType          string                      `yaml:"bucket_store_type"`
+	SyncDir                          string                      `yaml:"sync_dir"`
+	SyncInterval                     time.Duration               `yaml:"sync_interval"`
+	MaxConcurrent                    int                         `yaml:"max_concurrent"`
+	MaxInflightRequests              int                         `yaml:"max_inflight_requests"`
+	TenantSyncConcurrency            int                         `yaml:"tenant_sync_concurrency"`
+	BlockSyncConcurrency             int                         `yaml:"block_sync_concurrency"`
+	MetaSyncConcurrency              int                         `yaml:"meta_sync_concurrency"`
+	ConsistencyDelay                 time.Duration               `yaml:"consistency_delay"`
+	IndexCache                       IndexCacheConfig            `yaml:"index_cache"`
+	ChunksCache                      ChunksCacheConfig           `yaml:"chunks_cache"`
+	MetadataCache                    MetadataCacheConfig         `yaml:"metadata_cache"`
+	ParquetLabelsCache               ParquetLabelsCacheConfig    `yaml:"parquet_labels_cache"`
+	ParquetRowRangesCache            ParquetRowRangesCacheConfig `yaml:"parquet_row_ranges_cache"`
+	MatchersCacheMaxItems            int                         `yaml:"matchers_cache_max_items"`
+	IgnoreDeletionMarksDelay         time.Duration               `yaml:"ignore_deletion_mark_delay"`
+	DeletionMarksListingEnabled      bool                        `yaml:"deletion_marks_listing_enabled"`
+	IgnoreDeletionMarksDryRun        bool                        `yaml:"ignore_deletion_marks_dry_run"`
+	IgnoreBlocksWithin               time.Duration               `yaml:"ignore_blocks_within"`
+	IgnoreBlocksBefore               time.Duration               `yaml:"ignore_blocks_before"`
+	IgnoreBlocksBelowCompactionLevel int                         `yaml:"ignore_blocks_below_compaction_level"`
+	IgnoreOutOfOrderBlocks           bool                        `yaml:"ignore_out_of_order_blocks"`
+	TrackNoCompactMarkedBlocks       bool                        `yaml:"track_no_compact_marked_blocks"`
+	IgnoreNoCompactMarkedBlocks      bool                        `yaml:"ignore_no_compact_marked_blocks"`
+	IgnoreIncompleteBlocks           bool                        `yaml:"ignore_incomplete_blocks"`
+	BucketIndex                      BucketIndexConfig           `yaml:"bucket_index"`
+	BlockDiscoveryStrategy           string                      `yaml:"block_discovery_strategy"`
+	BucketStoreType                  string                      `yaml:"bucket_store_type"`
 
 	// Chunk pool.
 	MaxChunkPoolBytes           uint64 `yaml:"max_chunk_pool_bytes"`
@@ -315,8 +332,13 @@ type BucketStoreConfig struct {
 	LazyExpandedPostingGroupMaxKeySeriesRatio float64 `yaml:"lazy_expanded_posting_group_max_key_series_ratio"`
 
 	// Controls the partitioner, used to aggregate multiple GET object API requests.
-	// The config option is hidden until experimental.
-	PartitionerMaxGapBytes uint64 `yaml:"partitioner_max_gap_bytes" doc:"hidden"`
+	PartitionerMaxGapBytes uint64 `yaml:"partitioner_max_gap_bytes"`
+
+	// Controls the max number of concurrent chunks range reads per request.
+	MaxConcurrentChunkRangeReads int `yaml:"max_concurrent_chunk_range_reads"`
+
+	// Controls the max number of concurrent range reads per block, across requests.
+	MaxConcurrentBlockReads int `yaml:"max_concurrent_block_reads"`
 
 	// Controls the estimated size to fetch for series and chunk in Store Gateway. Using
 	// a large value might cause data overfetch while a small value might need to refetch.
@@ -374,18 +396,27 @@ func (cfg *BucketStoreConfig) RegisterFlags(f *flag.FlagSet) {
 	f.IntVar(&cfg.MaxConcurrent, "blocks-storage.bucket-store.max-concurrent", 100, "Max number of concurrent queries to execute against the long-term storage. The limit is shared across all tenants.")
 	f.IntVar(&cfg.MaxInflightRequests, "blocks-storage.bucket-store.max-inflight-requests", 0, "Max number of inflight queries to execute against the long-term storage. The limit is shared across all tenants. 0 to disable.")
 	f.IntVar(&cfg.TenantSyncConcurrency, "blocks-storage.bucket-store.tenant-sync-concurrency", 10, "Maximum number of concurrent tenants syncing blocks.")
-	f.IntVar(&cfg.BlockSyncConcurrency, "blocks-storage.bucket-store.block-sync-concurrency", 20, "Maximum number of concurrent blocks syncing per tenant.")
+	f.IntVar(&cfg.BlockSyncConcurrency, "blocks-storage.bucket-store.block-sync-concurrency", 20, "Maximum number of concurrent blocks syncing per tenant. It only limits the index-headers loading, while the chunks range reads issued at query time are limited by -blocks-storage.bucket-store.max-concurrent-chunk-range-reads.")
 	f.IntVar(&cfg.MetaSyncConcurrency, "blocks-storage.bucket-store.meta-sync-concurrency", 20, "Number of Go routines to use when syncing block meta files from object storage per tenant.")
 	f.DurationVar(&cfg.ConsistencyDelay, "blocks-storage.bucket-store.consistency-delay", 0, "Minimum age of a block before it's being read. Set it to safe value (e.g 30m) if your object storage is eventually consistent. GCS and S3 are (roughly) strongly consistent.")
 	f.DurationVar(&cfg.IgnoreDeletionMarksDelay, "blocks-storage.bucket-store.ignore-deletion-marks-delay", time.Hour*6, "Duration after which the blocks marked for deletion will be filtered out while fetching blocks. "+
 		"The idea of ignore-deletion-marks-delay is to ignore blocks that are marked for deletion with some delay. This ensures store can still serve blocks that are meant to be deleted but do not have a replacement yet. "+
 		"Default is 6h, half of the default value for -compactor.deletion-delay.")
+	f.BoolVar(&cfg.DeletionMarksListingEnabled, "blocks-storage.bucket-store.deletion-marks-listing-enabled", false, "If enabled, the store-gateway lists the tenant's global markers location to find blocks marked for deletion and then reads the deletion mark of these blocks only, instead of reading the deletion mark of each block. This reduces the number of object storage requests when the bucket index is disabled, but requires deletion marks to be stored in the global markers location.")
+	f.BoolVar(&cfg.IgnoreDeletionMarksDryRun, "blocks-storage.bucket-store.ignore-deletion-marks-dry-run", false, "If enabled, blocks marked for deletion are not filtered out once -blocks-storage.bucket-store.ignore-deletion-marks-delay has expired, but only reported under the 'would-delete' state of the cortex_blocks_meta_synced metric. Blocks deleted by the compactor will fail to be loaded while this is enabled.")
 	f.DurationVar(&cfg.IgnoreBlocksWithin, "blocks-storage.bucket-store.ignore-blocks-within", 0, "The blocks created since `now() - ignore_blocks_within` will not be synced. This should be used together with `-querier.query-store-after` to filter out the blocks that are too new to be queried. A reasonable value for this flag would be `-querier.query-store-after - blocks-storage.bucket-store.bucket-index.max-stale-period` to give some buffer. 0 to disable.")
 	f.DurationVar(&cfg.IgnoreBlocksBefore, "blocks-storage.bucket-store.ignore-blocks-before", 0, "The blocks created before `now() - ignore_blocks_before` will not be synced. 0 to disable.")
+	f.IntVar(&cfg.IgnoreBlocksBelowCompactionLevel, "blocks-storage.bucket-store.ignore-blocks-below-compaction-level", 0, "The blocks with a compaction level lower than this value will not be synced by the store-gateways nor queried by the queriers. This can be used to serve only compacted blocks. 0 to disable.")
+	f.BoolVar(&cfg.IgnoreOutOfOrderBlocks, "blocks-storage.bucket-store.ignore-out-of-order-blocks", false, "If enabled, blocks compacted from out-of-order samples will not be synced by the store-gateways nor queried by the queriers.")
+	f.BoolVar(&cfg.TrackNoCompactMarkedBlocks, "blocks-storage.bucket-store.track-no-compact-marked-blocks", false, "If enabled, the store-gateway reads the no-compact marker of each block and reports blocks marked for no compaction under the 'marked-for-no-compact' state of the cortex_blocks_meta_synced metric.")
+	f.BoolVar(&cfg.IgnoreNoCompactMarkedBlocks, "blocks-storage.bucket-store.ignore-no-compact-marked-blocks", false, "If enabled, blocks marked for no compaction will not be synced. This option is used only if -blocks-storage.bucket-store.track-no-compact-marked-blocks is enabled.")
+	f.BoolVar(&cfg.IgnoreIncompleteBlocks, "blocks-storage.bucket-store.ignore-incomplete-blocks", false, "[EXPERIMENTAL] If enabled, blocks whose index or chunk files are missing, or have a size different than the one recorded in the meta.json, will not be synced until they're complete. The files are checked through their attributes in the object storage. The compactor keeps such blocks out of the bucket index and the queriers exclude them too. The completeness of the blocks is cached in memory only, so at startup the store-gateways and queriers issue an attributes request for each file of each block (only the index when the bucket index is enabled).")
 	f.IntVar(&cfg.PostingOffsetsInMemSampling, "blocks-storage.bucket-store.posting-offsets-in-mem-sampling", store.DefaultPostingOffsetInMemorySampling, "Controls what is the ratio of postings offsets that the store will hold in memory.")
 	f.BoolVar(&cfg.IndexHeaderLazyLoadingEnabled, "blocks-storage.bucket-store.index-header-lazy-loading-enabled", false, "If enabled, store-gateway will lazily memory-map an index-header only once required by a query.")
 	f.DurationVar(&cfg.IndexHeaderLazyLoadingIdleTimeout, "blocks-storage.bucket-store.index-header-lazy-loading-idle-timeout", 20*time.Minute, "If index-header lazy loading is enabled and this setting is > 0, the store-gateway will release memory-mapped index-headers after 'idle timeout' inactivity.")
 	f.Uint64Var(&cfg.PartitionerMaxGapBytes, "blocks-storage.bucket-store.partitioner-max-gap-bytes", store.PartitionerMaxGapSize, "Max size - in bytes - of a gap for which the partitioner aggregates together two bucket GET object requests.")
+	f.IntVar(&cfg.MaxConcurrentChunkRangeReads, "blocks-storage.bucket-store.max-concurrent-chunk-range-reads", 0, "EXPERIMENTAL: Max number of concurrent range reads of chunks issued to the object storage while serving a single series request. The chunk byte ranges of each batch of series are coalesced by the partitioner and fetched in parallel up to this limit, each range with a single range read downloaded in background. The range reads served by the chunks cache are not limited. 0 means no limit.")
+	f.IntVar(&cfg.MaxConcurrentBlockReads, "blocks-storage.bucket-store.max-concurrent-block-reads", 0, "EXPERIMENTAL: Max number of concurrent range reads of the chunks of a single block issued to the object storage, across all the series requests. The range reads exceeding the limit wait for a slot, so that a block queried by many requests at the same time can't saturate the connections to the object storage. A slot is only held while downloading a range, and the range reads served by the chunks cache are not limited. 0 means no limit.")
 	f.Uint64Var(&cfg.EstimatedMaxSeriesSizeBytes, "blocks-storage.bucket-store.estimated-max-series-size-bytes", store.EstimatedMaxSeriesSize, "Estimated max series size in bytes. Setting a large value might result in over fetching data while a small value might result in data refetch. Default value is 64KB.")
 	f.Uint64Var(&cfg.EstimatedMaxChunkSizeBytes, "blocks-storage.bucket-store.estimated-max-chunk-size-bytes", store.EstimatedMaxChunkSize, "Estimated max chunk size in bytes. Setting a large value might result in over fetching data while a small value might result in data refetch. Default value is 16KiB.")
 	f.BoolVar(&cfg.LazyExpandedPostingsEnabled, "blocks-storage.bucket-store.lazy-expanded-postings-enabled", false, "If true, Store Gateway will estimate postings size and try to lazily expand postings if it downloads less data than expanding all postings.")
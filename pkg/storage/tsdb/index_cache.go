@@ -45,6 +45,7 @@ var (
 	errInvalidMaxAsyncConcurrency   = errors.New("invalid max_async_concurrency, must greater than 0")
 	errInvalidMaxAsyncBufferSize    = errors.New("invalid max_async_buffer_size, must greater than 0")
 	errInvalidMaxBackfillItems      = errors.New("invalid max_backfill_items, must greater than 0")
+	errInvalidPerTenantMaxSizeBytes = errors.New("invalid per_tenant_max_size_bytes, must not be greater than max_size_bytes")
 )
 
 type IndexCacheConfig struct {
@@ -139,11 +140,15 @@ func (cfg *MultiLevelIndexCacheConfig) RegisterFlagsWithPrefix(f *flag.FlagSet,
 }
 
 type InMemoryIndexCacheConfig struct {
-	MaxSizeBytes uint64   `yaml:"max_size_bytes"`
-	EnabledItems []string `yaml:"enabled_items"`
+	MaxSizeBytes          uint64   `yaml:"max_size_bytes"`
+	PerTenantMaxSizeBytes uint64   `yaml:"per_tenant_max_size_bytes"`
+	EnabledItems          []string `yaml:"enabled_items"`
 }
 
 func (cfg *InMemoryIndexCacheConfig) Validate() error {
+	if cfg.PerTenantMaxSizeBytes > cfg.MaxSizeBytes {
+		return errInvalidPerTenantMaxSizeBytes
+	}
 	if err := storecache.ValidateEnabledItems(cfg.EnabledItems); err != nil {
 		return err
 	}
@@ -152,6 +157,7 @@ func (cfg *InMemoryIndexCacheConfig) Validate() error {
 
 func (cfg *InMemoryIndexCacheConfig) RegisterFlagsWithPrefix(f *flag.FlagSet, prefix string) {
 	f.Uint64Var(&cfg.MaxSizeBytes, prefix+"max-size-bytes", uint64(1*units.Gibibyte), "Maximum size in bytes of in-memory index cache used to speed up blocks index lookups (shared between all tenants).")
+	f.Uint64Var(&cfg.PerTenantMaxSizeBytes, prefix+"per-tenant-max-size-bytes", 0, "EXPERIMENTAL: When greater than 0, the in-memory index cache is partitioned by tenant and each tenant gets a dedicated cache of this size, so that a tenant can't evict the index entries of other tenants. The partitions are reserved from the max size bytes, and the tenants for which there's no room left are not cached in memory until a partition is released. Must not be greater than the max size bytes. 0 to disable.")
 	f.Var((*flagext.StringSlice)(&cfg.EnabledItems), prefix+"enabled-items", "Selectively cache index item types. Supported values are Postings, ExpandedPostings and Series")
 }
 
@@ -195,6 +201,7 @@ func NewIndexCache(cfg IndexCacheConfig, logger log.Logger, registerer prometheu
 	var (
 		caches       []storecache.IndexCache
 		enabledItems [][]string
+		partitioned  *tenantPartitionedIndexCache
 	)
 
 	for i, backend := range splitBackends {
@@ -207,6 +214,16 @@ func NewIndexCache(cfg IndexCacheConfig, logger log.Logger, registerer prometheu
 
 		switch backend {
 		case IndexCacheBackendInMemory:
+			if cfg.InMemory.PerTenantMaxSizeBytes > 0 {
+				var err error
+				if partitioned, err = newTenantPartitionedIndexCache(cfg.InMemory, logger, iReg); err != nil {
+					return nil, err
+				}
+				caches = append(caches, partitioned)
+				enabledItems = append(enabledItems, cfg.InMemory.EnabledItems)
+				continue
+			}
+
 			c, err := newInMemoryIndexCache(cfg.InMemory, logger, iReg)
 			if err != nil {
 				return nil, err
@@ -242,19 +259,27 @@ func NewIndexCache(cfg IndexCacheConfig, logger log.Logger, registerer prometheu
 		}
 	}
 
-	return newMultiLevelCache(registerer, cfg.MultiLevel, enabledItems, caches...), nil
+	cache := newMultiLevelCache(registerer, cfg.MultiLevel, enabledItems, caches...)
+	if partitioned != nil {
+		return &TenantIndexCache{IndexCache: cache, partitions: partitioned}, nil
+	}
+	return cache, nil
 }
 
 func newInMemoryIndexCache(cfg InMemoryIndexCacheConfig, logger log.Logger, registerer prometheus.Registerer) (storecache.IndexCache, error) {
-	maxCacheSize := model.Bytes(cfg.MaxSizeBytes)
+	return NewInMemoryIndexCacheWithConfig(logger, nil, registerer, inMemoryIndexCacheConfig(cfg.MaxSizeBytes))
+}
+
+func inMemoryIndexCacheConfig(maxSizeBytes uint64) storecache.InMemoryIndexCacheConfig {
+	maxCacheSize := model.Bytes(maxSizeBytes)
 
 	// Calculate the max item size.
 	maxItemSize := min(defaultMaxItemSize, maxCacheSize)
 
-	return NewInMemoryIndexCacheWithConfig(logger, nil, registerer, storecache.InMemoryIndexCacheConfig{
+	return storecache.InMemoryIndexCacheConfig{
 		MaxSize:     maxCacheSize,
 		MaxItemSize: maxItemSize,
-	})
+	}
 }
 
 func newMemcachedIndexCacheClient(cfg MemcachedClientConfig, logger log.Logger, registerer prometheus.Registerer) (cacheutil.RemoteCacheClient, error) {
@@ -64,6 +64,16 @@ func TestIndexCacheConfig_Validate(t *testing.T) {
 			},
 			expected: fmt.Errorf("unsupported item type foo"),
 		},
+		"per tenant max size bytes greater than max size bytes should fail": {
+			cfg: IndexCacheConfig{
+				Backend: "inmemory",
+				InMemory: InMemoryIndexCacheConfig{
+					MaxSizeBytes:          1024 * 1024,
+					PerTenantMaxSizeBytes: 2 * 1024 * 1024,
+				},
+			},
+			expected: errInvalidPerTenantMaxSizeBytes,
+		},
 		"invalid enabled items redis": {
 			cfg: IndexCacheConfig{
 				Backend: "redis",
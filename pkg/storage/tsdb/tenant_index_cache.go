@@ -0,0 +1,282 @@
+package tsdb
+
+import (
+	"context"
+	"sync"
+
+	"github.com/VictoriaMetrics/fastcache"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/oklog/ulid/v2"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/storage"
+	storecache "github.com/thanos-io/thanos/pkg/store/cache"
+	"github.com/thanos-io/thanos/pkg/tenancy"
+)
+
+// TenantIndexCache is the index cache returned by NewIndexCache when the in-memory
+// index cache is partitioned by tenant.
+type TenantIndexCache struct {
+	storecache.IndexCache
+
+	partitions *tenantPartitionedIndexCache
+}
+
+// ForTenant returns an index cache storing and fetching the items of the given tenant
+// in its own partition. The tenant passed to the returned cache methods is ignored.
+func (c *TenantIndexCache) ForTenant(userID string) storecache.IndexCache {
+	return &userIndexCache{IndexCache: c.IndexCache, userID: userID}
+}
+
+// RemoveTenant releases the partition of the given tenant and its metrics.
+func (c *TenantIndexCache) RemoveTenant(userID string) {
+	c.partitions.removePartition(userID)
+}
+
+// userIndexCache overrides the tenant of all the index cache operations.
+type userIndexCache struct {
+	storecache.IndexCache
+
+	userID string
+}
+
+func (c *userIndexCache) StorePostings(blockID ulid.ULID, l labels.Label, v []byte, _ string) {
+	c.IndexCache.StorePostings(blockID, l, v, c.userID)
+}
+
+func (c *userIndexCache) FetchMultiPostings(ctx context.Context, blockID ulid.ULID, keys []labels.Label, _ string) (hits map[labels.Label][]byte, misses []labels.Label) {
+	return c.IndexCache.FetchMultiPostings(ctx, blockID, keys, c.userID)
+}
+
+func (c *userIndexCache) StoreExpandedPostings(blockID ulid.ULID, matchers []*labels.Matcher, v []byte, _ string) {
+	c.IndexCache.StoreExpandedPostings(blockID, matchers, v, c.userID)
+}
+
+func (c *userIndexCache) FetchExpandedPostings(ctx context.Context, blockID ulid.ULID, matchers []*labels.Matcher, _ string) ([]byte, bool) {
+	return c.IndexCache.FetchExpandedPostings(ctx, blockID, matchers, c.userID)
+}
+
+func (c *userIndexCache) StoreSeries(blockID ulid.ULID, id storage.SeriesRef, v []byte, _ string) {
+	c.IndexCache.StoreSeries(blockID, id, v, c.userID)
+}
+
+func (c *userIndexCache) FetchMultiSeries(ctx context.Context, blockID ulid.ULID, ids []storage.SeriesRef, _ string) (hits map[storage.SeriesRef][]byte, misses []storage.SeriesRef) {
+	return c.IndexCache.FetchMultiSeries(ctx, blockID, ids, c.userID)
+}
+
+// tenantPartitionedIndexCache is an in-memory index cache holding a dedicated InMemoryIndexCache
+// for each tenant, so that the items of a tenant can only be evicted by the items of the same tenant.
+// The size of each partition is reserved from the overall max size, and the tenants for which
+// there's no room left are not cached in memory until a partition is released.
+type tenantPartitionedIndexCache struct {
+	logger        log.Logger
+	reg           prometheus.Registerer
+	cfg           storecache.InMemoryIndexCacheConfig
+	commonMetrics *storecache.CommonMetrics
+	maxPartitions int
+
+	mtx           sync.RWMutex
+	partitions    map[string]*tenantPartition
+	unpartitioned map[string]struct{}
+
+	entriesDesc       *prometheus.Desc
+	sizeDesc          *prometheus.Desc
+	evictedDesc       *prometheus.Desc
+	unpartitionedDesc *prometheus.Desc
+}
+
+type tenantPartition struct {
+	*InMemoryIndexCache
+
+	reg prometheus.Registerer
+}
+
+func newTenantPartitionedIndexCache(cfg InMemoryIndexCacheConfig, logger log.Logger, reg prometheus.Registerer) (*tenantPartitionedIndexCache, error) {
+	cacheCfg := inMemoryIndexCacheConfig(cfg.PerTenantMaxSizeBytes)
+	if cacheCfg.MaxItemSize > cacheCfg.MaxSize {
+		return nil, errors.Errorf("max item size (%v) cannot be bigger than overall cache size (%v)", cacheCfg.MaxItemSize, cacheCfg.MaxSize)
+	}
+	if cfg.PerTenantMaxSizeBytes > cfg.MaxSizeBytes {
+		return nil, errInvalidPerTenantMaxSizeBytes
+	}
+
+	c := &tenantPartitionedIndexCache{
+		logger:        logger,
+		reg:           reg,
+		cfg:           cacheCfg,
+		commonMetrics: storecache.NewCommonMetrics(reg),
+		maxPartitions: int(cfg.MaxSizeBytes / cfg.PerTenantMaxSizeBytes),
+		partitions:    map[string]*tenantPartition{},
+		unpartitioned: map[string]struct{}{},
+		entriesDesc: prometheus.NewDesc(
+			"cortex_store_index_cache_tenant_entries",
+			"Number of entries currently stored in the in-memory index cache partition of the tenant.",
+			[]string{"user"}, nil),
+		sizeDesc: prometheus.NewDesc(
+			"cortex_store_index_cache_tenant_size_bytes",
+			"Size in bytes currently used by the in-memory index cache partition of the tenant.",
+			[]string{"user"}, nil),
+		evictedDesc: prometheus.NewDesc(
+			"cortex_store_index_cache_tenant_evicted_entries_total",
+			"Approximate number of entries evicted from the in-memory index cache partition of the tenant to make room for new entries.",
+			[]string{"user"}, nil),
+		unpartitionedDesc: prometheus.NewDesc(
+			"cortex_store_index_cache_tenants_without_partition",
+			"Number of tenants not cached in memory because the partitions reserved by other tenants already take the max size of the in-memory index cache.",
+			nil, nil),
+	}
+
+	if reg != nil {
+		reg.MustRegister(c)
+	}
+	return c, nil
+}
+
+func (c *tenantPartitionedIndexCache) partition(userID string) storecache.IndexCache {
+	c.mtx.RLock()
+	p := c.partitions[userID]
+	_, unpartitioned := c.unpartitioned[userID]
+	c.mtx.RUnlock()
+
+	if p != nil {
+		return p.InMemoryIndexCache
+	}
+	if unpartitioned {
+		return noopIndexCache{}
+	}
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if p = c.partitions[userID]; p != nil {
+		return p.InMemoryIndexCache
+	}
+	if _, ok := c.unpartitioned[userID]; ok {
+		return noopIndexCache{}
+	}
+
+	if len(c.partitions) >= c.maxPartitions {
+		level.Warn(c.logger).Log("msg", "not caching the index of the tenant in memory because the max size of the index cache is fully reserved by other tenants", "user", userID)
+		c.unpartitioned[userID] = struct{}{}
+		return noopIndexCache{}
+	}
+
+	var reg prometheus.Registerer
+	if c.reg != nil {
+		reg = prometheus.WrapRegistererWith(prometheus.Labels{"user": userID}, c.reg)
+	}
+
+	// The config has been validated when creating the partitioned cache, so this can't fail.
+	cache, _ := NewInMemoryIndexCacheWithConfig(log.With(c.logger, "user", userID), c.commonMetrics, reg, c.cfg)
+	c.partitions[userID] = &tenantPartition{InMemoryIndexCache: cache, reg: reg}
+	return cache
+}
+
+func (c *tenantPartitionedIndexCache) removePartition(userID string) {
+	c.mtx.Lock()
+	p := c.partitions[userID]
+	delete(c.partitions, userID)
+	delete(c.unpartitioned, userID)
+	if p != nil {
+		// Let the tenants without a partition get the released room on their next request.
+		clear(c.unpartitioned)
+	}
+	c.mtx.Unlock()
+
+	if p == nil {
+		return
+	}
+
+	if p.reg != nil {
+		p.reg.Unregister(p.added)
+		p.reg.Unregister(p.overflow)
+	}
+
+	tenantLabel := prometheus.Labels{tenancy.MetricLabel: userID}
+	c.commonMetrics.RequestTotal.DeletePartialMatch(tenantLabel)
+	c.commonMetrics.HitsTotal.DeletePartialMatch(tenantLabel)
+	c.commonMetrics.DataSizeBytes.DeletePartialMatch(tenantLabel)
+	c.commonMetrics.FetchLatency.DeletePartialMatch(tenantLabel)
+
+	p.cache.Reset()
+}
+
+func (c *tenantPartitionedIndexCache) StorePostings(blockID ulid.ULID, l labels.Label, v []byte, tenant string) {
+	c.partition(tenant).StorePostings(blockID, l, v, tenant)
+}
+
+func (c *tenantPartitionedIndexCache) FetchMultiPostings(ctx context.Context, blockID ulid.ULID, keys []labels.Label, tenant string) (hits map[labels.Label][]byte, misses []labels.Label) {
+	return c.partition(tenant).FetchMultiPostings(ctx, blockID, keys, tenant)
+}
+
+func (c *tenantPartitionedIndexCache) StoreExpandedPostings(blockID ulid.ULID, matchers []*labels.Matcher, v []byte, tenant string) {
+	c.partition(tenant).StoreExpandedPostings(blockID, matchers, v, tenant)
+}
+
+func (c *tenantPartitionedIndexCache) FetchExpandedPostings(ctx context.Context, blockID ulid.ULID, matchers []*labels.Matcher, tenant string) ([]byte, bool) {
+	return c.partition(tenant).FetchExpandedPostings(ctx, blockID, matchers, tenant)
+}
+
+func (c *tenantPartitionedIndexCache) StoreSeries(blockID ulid.ULID, id storage.SeriesRef, v []byte, tenant string) {
+	c.partition(tenant).StoreSeries(blockID, id, v, tenant)
+}
+
+func (c *tenantPartitionedIndexCache) FetchMultiSeries(ctx context.Context, blockID ulid.ULID, ids []storage.SeriesRef, tenant string) (hits map[storage.SeriesRef][]byte, misses []storage.SeriesRef) {
+	return c.partition(tenant).FetchMultiSeries(ctx, blockID, ids, tenant)
+}
+
+// Describe implements prometheus.Collector.
+func (c *tenantPartitionedIndexCache) Describe(out chan<- *prometheus.Desc) {
+	out <- c.entriesDesc
+	out <- c.sizeDesc
+	out <- c.evictedDesc
+	out <- c.unpartitionedDesc
+}
+
+// Collect implements prometheus.Collector.
+func (c *tenantPartitionedIndexCache) Collect(out chan<- prometheus.Metric) {
+	c.mtx.RLock()
+	defer c.mtx.RUnlock()
+
+	out <- prometheus.MustNewConstMetric(c.unpartitionedDesc, prometheus.GaugeValue, float64(len(c.unpartitioned)))
+
+	for userID, p := range c.partitions {
+		stats := fastcache.Stats{}
+		p.cache.UpdateStats(&stats)
+
+		// Entries are only removed from fastcache when they're overwritten by newer entries,
+		// and items are never stored twice, so the stored entries no longer in the cache
+		// have been evicted.
+		evicted := uint64(0)
+		if stats.SetCalls > stats.EntriesCount {
+			evicted = stats.SetCalls - stats.EntriesCount
+		}
+
+		out <- prometheus.MustNewConstMetric(c.entriesDesc, prometheus.GaugeValue, float64(stats.EntriesCount), userID)
+		out <- prometheus.MustNewConstMetric(c.sizeDesc, prometheus.GaugeValue, float64(stats.BytesSize), userID)
+		out <- prometheus.MustNewConstMetric(c.evictedDesc, prometheus.CounterValue, float64(evicted), userID)
+	}
+}
+
+// noopIndexCache is the index cache of the tenants without a partition.
+type noopIndexCache struct{}
+
+func (noopIndexCache) StorePostings(ulid.ULID, labels.Label, []byte, string) {}
+
+func (noopIndexCache) FetchMultiPostings(_ context.Context, _ ulid.ULID, keys []labels.Label, _ string) (map[labels.Label][]byte, []labels.Label) {
+	return nil, keys
+}
+
+func (noopIndexCache) StoreExpandedPostings(ulid.ULID, []*labels.Matcher, []byte, string) {}
+
+func (noopIndexCache) FetchExpandedPostings(context.Context, ulid.ULID, []*labels.Matcher, string) ([]byte, bool) {
+	return nil, false
+}
+
+func (noopIndexCache) StoreSeries(ulid.ULID, storage.SeriesRef, []byte, string) {}
+
+func (noopIndexCache) FetchMultiSeries(_ context.Context, _ ulid.ULID, ids []storage.SeriesRef, _ string) (map[storage.SeriesRef][]byte, []storage.SeriesRef) {
+	return nil, ids
+}
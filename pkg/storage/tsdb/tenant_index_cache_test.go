@@ -0,0 +1,150 @@
+package tsdb
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/oklog/ulid/v2"
+	"github.com/prometheus/client_golang/prometheus"
+	prom_testutil "github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/thanos/pkg/tenancy"
+
+	"github.com/cortexproject/cortex/pkg/util/flagext"
+)
+
+func TestNewIndexCache_ShouldPartitionInMemoryCacheByTenantWhenEnabled(t *testing.T) {
+	cfg := IndexCacheConfig{}
+	flagext.DefaultValues(&cfg)
+
+	cache, err := NewIndexCache(cfg, log.NewNopLogger(), nil)
+	require.NoError(t, err)
+	_, ok := cache.(*TenantIndexCache)
+	assert.False(t, ok)
+
+	cfg.InMemory.PerTenantMaxSizeBytes = 1024 * 1024
+	cache, err = NewIndexCache(cfg, log.NewNopLogger(), nil)
+	require.NoError(t, err)
+	_, ok = cache.(*TenantIndexCache)
+	assert.True(t, ok)
+}
+
+func TestTenantIndexCache(t *testing.T) {
+	reg := prometheus.NewPedanticRegistry()
+	cfg := IndexCacheConfig{}
+	flagext.DefaultValues(&cfg)
+	cfg.InMemory.PerTenantMaxSizeBytes = 1024 * 1024
+
+	cache, err := NewIndexCache(cfg, log.NewNopLogger(), reg)
+	require.NoError(t, err)
+	tenantCache := cache.(*TenantIndexCache)
+
+	ctx := context.Background()
+	blockID := ulid.MustNew(1, nil)
+	user1 := tenantCache.ForTenant("user-1")
+	user2 := tenantCache.ForTenant("user-2")
+
+	// The tenant passed by the bucket store is overridden by the bound tenant.
+	user1.StoreSeries(blockID, 1, []byte("series-1"), tenancy.DefaultTenant)
+	user1.StorePostings(blockID, labels.Label{Name: "foo", Value: "bar"}, []byte("postings-1"), tenancy.DefaultTenant)
+
+	hits, misses := user1.FetchMultiSeries(ctx, blockID, []storage.SeriesRef{1, 2}, tenancy.DefaultTenant)
+	assert.Equal(t, map[storage.SeriesRef][]byte{1: []byte("series-1")}, hits)
+	assert.Equal(t, []storage.SeriesRef{2}, misses)
+
+	// Items stored by a tenant are not visible to other tenants.
+	hits, misses = user2.FetchMultiSeries(ctx, blockID, []storage.SeriesRef{1}, tenancy.DefaultTenant)
+	assert.Empty(t, hits)
+	assert.Equal(t, []storage.SeriesRef{1}, misses)
+
+	require.NoError(t, prom_testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP cortex_store_index_cache_tenant_entries Number of entries currently stored in the in-memory index cache partition of the tenant.
+		# TYPE cortex_store_index_cache_tenant_entries gauge
+		cortex_store_index_cache_tenant_entries{user="user-1"} 4
+		cortex_store_index_cache_tenant_entries{user="user-2"} 0
+
+		# HELP cortex_store_index_cache_tenant_evicted_entries_total Approximate number of entries evicted from the in-memory index cache partition of the tenant to make room for new entries.
+		# TYPE cortex_store_index_cache_tenant_evicted_entries_total counter
+		cortex_store_index_cache_tenant_evicted_entries_total{user="user-1"} 0
+		cortex_store_index_cache_tenant_evicted_entries_total{user="user-2"} 0
+
+		# HELP thanos_store_index_cache_requests_total Total number of items requests to the cache.
+		# TYPE thanos_store_index_cache_requests_total counter
+		thanos_store_index_cache_requests_total{item_type="ExpandedPostings",tenant="default-tenant"} 0
+		thanos_store_index_cache_requests_total{item_type="Postings",tenant="default-tenant"} 0
+		thanos_store_index_cache_requests_total{item_type="Series",tenant="default-tenant"} 0
+		thanos_store_index_cache_requests_total{item_type="Series",tenant="user-1"} 2
+		thanos_store_index_cache_requests_total{item_type="Series",tenant="user-2"} 1
+
+		# HELP thanos_store_index_cache_hits_total Total number of items requests to the cache that were a hit.
+		# TYPE thanos_store_index_cache_hits_total counter
+		thanos_store_index_cache_hits_total{item_type="ExpandedPostings",tenant="default-tenant"} 0
+		thanos_store_index_cache_hits_total{item_type="Postings",tenant="default-tenant"} 0
+		thanos_store_index_cache_hits_total{item_type="Series",tenant="default-tenant"} 0
+		thanos_store_index_cache_hits_total{item_type="Series",tenant="user-1"} 1
+		thanos_store_index_cache_hits_total{item_type="Series",tenant="user-2"} 0
+	`), "cortex_store_index_cache_tenant_entries", "cortex_store_index_cache_tenant_evicted_entries_total",
+		"thanos_store_index_cache_requests_total", "thanos_store_index_cache_hits_total"))
+
+	// Removing a tenant drops its items and metrics.
+	tenantCache.RemoveTenant("user-1")
+
+	hits, _ = user1.FetchMultiSeries(ctx, blockID, []storage.SeriesRef{1}, tenancy.DefaultTenant)
+	assert.Empty(t, hits)
+
+	tenantCache.RemoveTenant("user-1")
+	tenantCache.RemoveTenant("user-2")
+
+	require.NoError(t, prom_testutil.GatherAndCompare(reg, strings.NewReader(""),
+		"cortex_store_index_cache_tenant_entries", "thanos_store_index_cache_items_added_total"))
+}
+
+func TestTenantIndexCache_ShouldReservePartitionsFromMaxSize(t *testing.T) {
+	reg := prometheus.NewPedanticRegistry()
+	cfg := IndexCacheConfig{}
+	flagext.DefaultValues(&cfg)
+	cfg.InMemory.MaxSizeBytes = 2 * 1024 * 1024
+	cfg.InMemory.PerTenantMaxSizeBytes = 1024 * 1024
+
+	cache, err := NewIndexCache(cfg, log.NewNopLogger(), reg)
+	require.NoError(t, err)
+	tenantCache := cache.(*TenantIndexCache)
+
+	ctx := context.Background()
+	blockID := ulid.MustNew(1, nil)
+
+	for _, userID := range []string{"user-1", "user-2", "user-3"} {
+		tenantCache.ForTenant(userID).StoreSeries(blockID, 1, []byte(userID), tenancy.DefaultTenant)
+	}
+
+	// The max size only leaves room for the partitions of the first two tenants.
+	for userID, expected := range map[string]bool{"user-1": true, "user-2": true, "user-3": false} {
+		hits, _ := tenantCache.ForTenant(userID).FetchMultiSeries(ctx, blockID, []storage.SeriesRef{1}, tenancy.DefaultTenant)
+		assert.Equal(t, expected, len(hits) == 1, userID)
+	}
+
+	require.NoError(t, prom_testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP cortex_store_index_cache_tenants_without_partition Number of tenants not cached in memory because the partitions reserved by other tenants already take the max size of the in-memory index cache.
+		# TYPE cortex_store_index_cache_tenants_without_partition gauge
+		cortex_store_index_cache_tenants_without_partition 1
+	`), "cortex_store_index_cache_tenants_without_partition"))
+
+	// Removing a tenant releases its room for the tenants without a partition.
+	tenantCache.RemoveTenant("user-1")
+
+	user3 := tenantCache.ForTenant("user-3")
+	user3.StoreSeries(blockID, 1, []byte("user-3"), tenancy.DefaultTenant)
+	hits, _ := user3.FetchMultiSeries(ctx, blockID, []storage.SeriesRef{1}, tenancy.DefaultTenant)
+	assert.Equal(t, map[storage.SeriesRef][]byte{1: []byte("user-3")}, hits)
+
+	require.NoError(t, prom_testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP cortex_store_index_cache_tenants_without_partition Number of tenants not cached in memory because the partitions reserved by other tenants already take the max size of the in-memory index cache.
+		# TYPE cortex_store_index_cache_tenants_without_partition gauge
+		cortex_store_index_cache_tenants_without_partition 0
+	`), "cortex_store_index_cache_tenants_without_partition"))
+}
@@ -0,0 +1,110 @@
+package storegateway
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+type blockReadsMetrics struct {
+	inflight     prometheus.Gauge
+	waiting      prometheus.Gauge
+	waitDuration prometheus.Histogram
+}
+
+func newBlockReadsMetrics(reg prometheus.Registerer) *blockReadsMetrics {
+	return &blockReadsMetrics{
+		inflight: promauto.With(reg).NewGauge(prometheus.GaugeOpts{
+			Name: "cortex_bucket_stores_block_reads_inflight",
+			Help: "Number of chunks range reads of the blocks currently issued to the object storage.",
+		}),
+		waiting: promauto.With(reg).NewGauge(prometheus.GaugeOpts{
+			Name: "cortex_bucket_stores_block_reads_waiting",
+			Help: "Number of chunks range reads of the blocks currently waiting for a slot of the per-block concurrency limit.",
+		}),
+		waitDuration: promauto.With(reg).NewHistogram(prometheus.HistogramOpts{
+			Name:    "cortex_bucket_stores_block_reads_wait_duration_seconds",
+			Help:    "Time spent by chunks range reads of the blocks waiting for a slot of the per-block concurrency limit.",
+			Buckets: []float64{0.001, 0.01, 0.1, 0.5, 1, 5, 10},
+		}),
+	}
+}
+
+// blockReadsGates holds the gates limiting the number of concurrent range reads of the chunks of each block,
+// across all the requests, so that a block queried by many requests at the same time can't take all the
+// connections to the object storage. The gate of a block only exists while its range reads are in flight or
+// waiting. A slot is only held while downloading a range (see newGatedRangeReader), so a request reading
+// multiple blocks never holds the slot of a block while waiting for the slot of another.
+type blockReadsGates struct {
+	maxConcurrency int
+	metrics        *blockReadsMetrics
+
+	mu    sync.Mutex
+	gates map[string]*blockReadsGate
+}
+
+type blockReadsGate struct {
+	slots chan struct{}
+	refs  int
+}
+
+func newBlockReadsGates(maxConcurrency int, metrics *blockReadsMetrics) *blockReadsGates {
+	return &blockReadsGates{
+		maxConcurrency: maxConcurrency,
+		metrics:        metrics,
+		gates:          map[string]*blockReadsGate{},
+	}
+}
+
+// gateFor returns the rangeReadsGate of the block.
+func (g *blockReadsGates) gateFor(blockID string) rangeReadsGate {
+	return func(ctx context.Context) (func(), error) {
+		gate := g.ref(blockID)
+
+		start := time.Now()
+		g.metrics.waiting.Inc()
+		select {
+		case gate.slots <- struct{}{}:
+			g.metrics.waiting.Dec()
+		case <-ctx.Done():
+			g.metrics.waiting.Dec()
+			g.unref(blockID)
+			return nil, ctx.Err()
+		}
+		g.metrics.waitDuration.Observe(time.Since(start).Seconds())
+		g.metrics.inflight.Inc()
+
+		return func() {
+			g.metrics.inflight.Dec()
+			<-gate.slots
+			g.unref(blockID)
+		}, nil
+	}
+}
+
+func (g *blockReadsGates) ref(blockID string) *blockReadsGate {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	gate, ok := g.gates[blockID]
+	if !ok {
+		gate = &blockReadsGate{slots: make(chan struct{}, g.maxConcurrency)}
+		g.gates[blockID] = gate
+	}
+	gate.refs++
+	return gate
+}
+
+func (g *blockReadsGates) unref(blockID string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	gate := g.gates[blockID]
+	gate.refs--
+	if gate.refs == 0 {
+		delete(g.gates, blockID)
+	}
+}
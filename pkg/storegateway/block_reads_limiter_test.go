@@ -0,0 +1,129 @@
+package storegateway
+
+import (
+	"context"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/objstore"
+)
+
+func TestBlockReadsLimitingBucket(t *testing.T) {
+	t.Parallel()
+
+	const (
+		block1Chunks = "user-1/01HZ8X6Q2N6J1V6N5Y2A9Q0D3K/chunks/000001"
+		block1Index  = "user-1/01HZ8X6Q2N6J1V6N5Y2A9Q0D3K/index"
+		block2Chunks = "user-1/01HZ8X7B4P0S2X3M6T9R1C8F5E/chunks/000001"
+		otherObject  = "user-1/bucket-index.json.gz"
+	)
+
+	inmem := objstore.NewInMemBucket()
+	for _, name := range []string{block1Chunks, block1Index, block2Chunks, otherObject} {
+		require.NoError(t, inmem.Upload(context.Background(), name, strings.NewReader("0123456789")))
+	}
+
+	tracking := &inflightRangeReadsBucket{Bucket: inmem}
+	reg := prometheus.NewPedanticRegistry()
+	bkt := newChunkRangeReadsLimitingBucket(objstore.WithNoopInstr(tracking), newChunkRangeReadsMetrics(nil), newBlockReadsGates(2, newBlockReadsMetrics(reg)))
+
+	readConcurrently := func(names ...string) {
+		wg := sync.WaitGroup{}
+		for _, name := range names {
+			wg.Go(func() {
+				reader, err := bkt.GetRange(context.Background(), name, 0, 10)
+				require.NoError(t, err)
+				data, err := io.ReadAll(reader)
+				require.NoError(t, err)
+				require.NoError(t, reader.Close())
+				assert.Equal(t, "0123456789", string(data))
+			})
+		}
+		wg.Wait()
+	}
+
+	t.Run("should limit the concurrent chunks range reads of a block across the requests", func(t *testing.T) {
+		tracking.reset(10 * time.Millisecond)
+		readConcurrently(block1Chunks, block1Chunks, block1Chunks, block1Chunks, block1Chunks)
+
+		assert.Equal(t, int64(2), tracking.maxInflight.Load())
+		assert.Empty(t, bkt.blockGates.gates)
+		assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+			# HELP cortex_bucket_stores_block_reads_inflight Number of chunks range reads of the blocks currently issued to the object storage.
+			# TYPE cortex_bucket_stores_block_reads_inflight gauge
+			cortex_bucket_stores_block_reads_inflight 0
+			# HELP cortex_bucket_stores_block_reads_waiting Number of chunks range reads of the blocks currently waiting for a slot of the per-block concurrency limit.
+			# TYPE cortex_bucket_stores_block_reads_waiting gauge
+			cortex_bucket_stores_block_reads_waiting 0
+		`), "cortex_bucket_stores_block_reads_inflight", "cortex_bucket_stores_block_reads_waiting"))
+	})
+
+	t.Run("should not limit the range reads of different blocks together", func(t *testing.T) {
+		tracking.reset(10 * time.Millisecond)
+		readConcurrently(block1Chunks, block1Chunks, block2Chunks, block2Chunks)
+
+		assert.Equal(t, int64(4), tracking.maxInflight.Load())
+	})
+
+	t.Run("should not limit the range reads of the other objects", func(t *testing.T) {
+		tracking.reset(10 * time.Millisecond)
+		readConcurrently(block1Index, block1Index, block1Index, otherObject, otherObject)
+
+		assert.Equal(t, int64(5), tracking.maxInflight.Load())
+	})
+
+	t.Run("should limit the chunks range reads of a block and of a request together", func(t *testing.T) {
+		tracking.reset(10 * time.Millisecond)
+		ctx := withChunkRangeReadsGate(context.Background(), 1)
+
+		wg := sync.WaitGroup{}
+		for _, name := range []string{block1Chunks, block2Chunks, block1Chunks, block2Chunks} {
+			wg.Go(func() {
+				reader, err := bkt.GetRange(ctx, name, 0, 10)
+				require.NoError(t, err)
+				require.NoError(t, reader.Close())
+			})
+		}
+		wg.Wait()
+
+		assert.Equal(t, int64(1), tracking.maxInflight.Load())
+		assert.Empty(t, bkt.blockGates.gates)
+	})
+
+	t.Run("should not deadlock when reading a block while holding readers of the blocks", func(t *testing.T) {
+		tracking.reset(0)
+
+		var readers []io.ReadCloser
+		for _, name := range []string{block1Chunks, block2Chunks, block1Chunks, block2Chunks, block1Chunks} {
+			reader, err := bkt.GetRange(context.Background(), name, 0, 10)
+			require.NoError(t, err)
+			readers = append(readers, reader)
+		}
+		for _, reader := range readers {
+			require.NoError(t, reader.Close())
+		}
+	})
+
+	t.Run("should give up waiting for a slot when the context is canceled", func(t *testing.T) {
+		release1, err := bkt.blockGates.gateFor("01HZ8X6Q2N6J1V6N5Y2A9Q0D3K")(context.Background())
+		require.NoError(t, err)
+		release2, err := bkt.blockGates.gateFor("01HZ8X6Q2N6J1V6N5Y2A9Q0D3K")(context.Background())
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err = bkt.GetRange(ctx, block1Chunks, 0, 10)
+		require.ErrorIs(t, err, context.Canceled)
+
+		release1()
+		release2()
+		assert.Empty(t, bkt.blockGates.gates)
+	})
+}
@@ -55,6 +55,7 @@ type BucketStoreMetrics struct {
 	indexHeaderLazyUnloadCount       *prometheus.Desc
 	indexHeaderLazyUnloadFailedCount *prometheus.Desc
 	indexHeaderLazyLoadDuration      *prometheus.Desc
+	indexHeaderLazyLoaded            *prometheus.Desc
 	indexHeaderDownloadDuration      *prometheus.Desc
 	indexHeaderLoadDuration          *prometheus.Desc
 }
@@ -207,6 +208,10 @@ func NewBucketStoreMetrics() *BucketStoreMetrics {
 			"cortex_bucket_store_indexheader_lazy_load_duration_seconds",
 			"Duration of the index-header lazy loading in seconds.",
 			nil, nil),
+		indexHeaderLazyLoaded: prometheus.NewDesc(
+			"cortex_bucket_store_indexheader_lazy_loaded",
+			"Number of index-headers currently loaded by the index-header lazy reader.",
+			nil, nil),
 		indexHeaderDownloadDuration: prometheus.NewDesc(
 			"cortex_bucket_store_indexheader_download_duration_seconds",
 			"Duration of the index-header download from objstore in seconds.",
@@ -282,6 +287,7 @@ func (m *BucketStoreMetrics) Describe(out chan<- *prometheus.Desc) {
 	out <- m.indexHeaderLazyUnloadCount
 	out <- m.indexHeaderLazyUnloadFailedCount
 	out <- m.indexHeaderLazyLoadDuration
+	out <- m.indexHeaderLazyLoaded
 	out <- m.indexHeaderDownloadDuration
 	out <- m.indexHeaderLoadDuration
 
@@ -335,6 +341,13 @@ func (m *BucketStoreMetrics) Collect(out chan<- prometheus.Metric) {
 	data.SendSumOfCounters(out, m.indexHeaderLazyUnloadCount, "thanos_bucket_store_indexheader_lazy_unload_total")
 	data.SendSumOfCounters(out, m.indexHeaderLazyUnloadFailedCount, "thanos_bucket_store_indexheader_lazy_unload_failed_total")
 	data.SendSumOfHistograms(out, m.indexHeaderLazyLoadDuration, "thanos_bucket_store_indexheader_lazy_load_duration_seconds")
+
+	// A failed load leaves the index-header unloaded, while a failed unload leaves it loaded.
+	loaded := data.GetSumOfCounters("thanos_bucket_store_indexheader_lazy_load_total") -
+		data.GetSumOfCounters("thanos_bucket_store_indexheader_lazy_load_failed_total") -
+		data.GetSumOfCounters("thanos_bucket_store_indexheader_lazy_unload_total") +
+		data.GetSumOfCounters("thanos_bucket_store_indexheader_lazy_unload_failed_total")
+	out <- prometheus.MustNewConstMetric(m.indexHeaderLazyLoaded, prometheus.GaugeValue, loaded)
 	data.SendSumOfHistograms(out, m.indexHeaderDownloadDuration, "thanos_bucket_store_indexheader_download_duration_seconds")
 	data.SendSumOfHistograms(out, m.indexHeaderLoadDuration, "thanos_bucket_store_indexheader_load_duration_seconds")
 
@@ -49,6 +49,12 @@ type BucketStores interface {
 	storepb.StoreServer
 	SyncBlocks(ctx context.Context) error
 	InitialSync(ctx context.Context) error
+
+	// SyncUserBlocks synchronizes the blocks of the input tenant only.
+	SyncUserBlocks(ctx context.Context, userID string) error
+
+	// SyncedBlocks returns the blocks synced for each tenant, or for the input tenant only if not empty.
+	SyncedBlocks(userID string) map[string][]SyncedBlock
 }
 
 // ThanosBucketStores is a multi-tenant wrapper of Thanos BucketStore.
@@ -77,14 +83,19 @@ type ThanosBucketStores struct {
 	// Gate used to limit query concurrency across all tenants.
 	queryGate gate.Gate
 
-	// Keeps a bucket store for each tenant.
-	storesMu sync.RWMutex
-	stores   map[string]*store.BucketStore
+	// Keeps a bucket store, and the tracker of its synced blocks, for each tenant.
+	storesMu             sync.RWMutex
+	stores               map[string]*store.BucketStore
+	syncedBlocksTrackers map[string]*SyncedBlocksTracker
 
 	// Keeps the last sync error for the bucket store for each tenant.
 	storesErrorsMu sync.RWMutex
 	storesErrors   map[string]error
 
+	// Serializes the syncs of the bucket store of each tenant.
+	userSyncMusMu sync.Mutex
+	userSyncMus   map[string]*sync.Mutex
+
 	instanceTokenBucket *util.TokenBucket
 
 	userTokenBucketsMu sync.RWMutex
@@ -118,8 +129,18 @@ func NewBucketStores(cfg tsdb.BlocksStorageConfig, shardingStrategy ShardingStra
 
 // newThanosBucketStores creates a new TSDB-based bucket stores
 func newThanosBucketStores(cfg tsdb.BlocksStorageConfig, shardingStrategy ShardingStrategy, bucketClient objstore.InstrumentedBucket, limits *validation.Overrides, logLevel logging.Level, logger log.Logger, reg prometheus.Registerer) (*ThanosBucketStores, error) {
+	// The chunks range reads are limited under the caching bucket, so that the cache hits are never limited.
+	var storeBucketClient objstore.InstrumentedBucket = bucketClient
+	if cfg.BucketStore.MaxConcurrentChunkRangeReads > 0 || cfg.BucketStore.MaxConcurrentBlockReads > 0 {
+		var blockGates *blockReadsGates
+		if cfg.BucketStore.MaxConcurrentBlockReads > 0 {
+			blockGates = newBlockReadsGates(cfg.BucketStore.MaxConcurrentBlockReads, newBlockReadsMetrics(reg))
+		}
+		storeBucketClient = newChunkRangeReadsLimitingBucket(storeBucketClient, newChunkRangeReadsMetrics(reg), blockGates)
+	}
+
 	matchers := tsdb.NewMatchers()
-	cachingBucket, err := tsdb.CreateCachingBucket(cfg.BucketStore.ChunksCache, cfg.BucketStore.MetadataCache, tsdb.ParquetLabelsCacheConfig{}, matchers, bucketClient, logger, reg)
+	cachingBucket, err := tsdb.CreateCachingBucket(cfg.BucketStore.ChunksCache, cfg.BucketStore.MetadataCache, tsdb.ParquetLabelsCacheConfig{}, matchers, storeBucketClient, logger, reg)
 	if err != nil {
 		return nil, errors.Wrapf(err, "create caching bucket")
 	}
@@ -132,21 +153,37 @@ func newThanosBucketStores(cfg tsdb.BlocksStorageConfig, shardingStrategy Shardi
 		Help: "Number of maximum concurrent queries allowed.",
 	}).Set(float64(cfg.BucketStore.MaxConcurrent))
 
+	// The blocks loading at sync time and the chunks range reads at query time are limited independently.
+	promauto.With(reg).NewGauge(prometheus.GaugeOpts{
+		Name: "cortex_bucket_stores_block_sync_concurrent_max",
+		Help: "Number of maximum concurrent blocks loading per tenant while syncing.",
+	}).Set(float64(cfg.BucketStore.BlockSyncConcurrency))
+	promauto.With(reg).NewGauge(prometheus.GaugeOpts{
+		Name: "cortex_bucket_stores_chunk_range_reads_concurrent_max",
+		Help: "Number of maximum concurrent chunks range reads allowed per series request. 0 means no limit.",
+	}).Set(float64(cfg.BucketStore.MaxConcurrentChunkRangeReads))
+	promauto.With(reg).NewGauge(prometheus.GaugeOpts{
+		Name: "cortex_bucket_stores_block_reads_concurrent_max",
+		Help: "Number of maximum concurrent chunks range reads allowed per block. 0 means no limit.",
+	}).Set(float64(cfg.BucketStore.MaxConcurrentBlockReads))
+
 	u := &ThanosBucketStores{
-		logger:             logger,
-		cfg:                cfg,
-		limits:             limits,
-		bucket:             cachingBucket,
-		shardingStrategy:   shardingStrategy,
-		stores:             map[string]*store.BucketStore{},
-		storesErrors:       map[string]error{},
-		logLevel:           logLevel,
-		bucketStoreMetrics: NewBucketStoreMetrics(),
-		metaFetcherMetrics: NewMetadataFetcherMetrics(),
-		queryGate:          queryGate,
-		partitioner:        newGapBasedPartitioner(cfg.BucketStore.PartitionerMaxGapBytes, reg),
-		userTokenBuckets:   make(map[string]*util.TokenBucket),
-		inflightRequests:   util.NewInflightRequestTracker(),
+		logger:               logger,
+		cfg:                  cfg,
+		limits:               limits,
+		bucket:               cachingBucket,
+		shardingStrategy:     shardingStrategy,
+		stores:               map[string]*store.BucketStore{},
+		syncedBlocksTrackers: map[string]*SyncedBlocksTracker{},
+		storesErrors:         map[string]error{},
+		userSyncMus:          map[string]*sync.Mutex{},
+		logLevel:             logLevel,
+		bucketStoreMetrics:   NewBucketStoreMetrics(),
+		metaFetcherMetrics:   NewMetadataFetcherMetrics(),
+		queryGate:            queryGate,
+		partitioner:          newGapBasedPartitioner(cfg.BucketStore.PartitionerMaxGapBytes, reg),
+		userTokenBuckets:     make(map[string]*util.TokenBucket),
+		inflightRequests:     util.NewInflightRequestTracker(),
 		syncTimes: promauto.With(reg).NewHistogram(prometheus.HistogramOpts{
 			Name:                            "cortex_bucket_stores_blocks_sync_seconds",
 			Help:                            "The total time it takes to perform a sync stores",
@@ -293,20 +330,10 @@ func (u *ThanosBucketStores) syncUsersBlocks(ctx context.Context, f func(context
 		wg.Go(func() {
 
 			for job := range jobs {
-				if err := f(ctx, job.store); err != nil {
-					if errors.Is(err, bucket.ErrCustomerManagedKeyAccessDenied) {
-						u.storesErrorsMu.Lock()
-						u.storesErrors[job.userID] = httpgrpc.Errorf(int(codes.PermissionDenied), "store error: %s", err)
-						u.storesErrorsMu.Unlock()
-					} else {
-						errsMx.Lock()
-						errs.Add(errors.Wrapf(err, "failed to synchronize TSDB blocks for user %s", job.userID))
-						errsMx.Unlock()
-					}
-				} else {
-					u.storesErrorsMu.Lock()
-					delete(u.storesErrors, job.userID)
-					u.storesErrorsMu.Unlock()
+				if err := u.syncUserStore(ctx, job.userID, job.store, f); err != nil {
+					errsMx.Lock()
+					errs.Add(errors.Wrapf(err, "failed to synchronize TSDB blocks for user %s", job.userID))
+					errsMx.Unlock()
 				}
 			}
 		})
@@ -349,6 +376,64 @@ func (u *ThanosBucketStores) syncUsersBlocks(ctx context.Context, f func(context
 	return errs.Err()
 }
 
+// SyncUserBlocks implements BucketStores. It synchronizes the bucket store of the input tenant
+// with the bucket, re-running its metadata fetcher and filters, and returns once completed.
+func (u *ThanosBucketStores) SyncUserBlocks(ctx context.Context, userID string) error {
+	// Like the periodic sync, the bucket store is synced anyway if it already exists, so that
+	// its blocks are unloaded if the tenant isn't owned by the store-gateway shard anymore.
+	if len(u.shardingStrategy.FilterUsers(ctx, []string{userID})) == 0 && u.getStore(userID) == nil {
+		return errTenantNotOwned
+	}
+
+	bs, err := u.getOrCreateStore(userID)
+	if err != nil {
+		return err
+	}
+
+	level.Info(u.logger).Log("msg", "synchronizing TSDB blocks for user", "user", userID)
+	if err := u.syncUserStore(ctx, userID, bs, func(ctx context.Context, s *store.BucketStore) error {
+		return s.SyncBlocks(ctx)
+	}); err != nil {
+		return errors.Wrapf(err, "failed to synchronize TSDB blocks for user %s", userID)
+	}
+
+	// A customer managed key access denied error is tracked as a store error instead.
+	if err := u.getStoreError(userID); err != nil {
+		return err
+	}
+	level.Info(u.logger).Log("msg", "successfully synchronized TSDB blocks for user", "user", userID)
+	return nil
+}
+
+// syncUserStore runs f on the bucket store of the user, ensuring the syncs of the same
+// user don't run concurrently, and tracks the store error of the user.
+func (u *ThanosBucketStores) syncUserStore(ctx context.Context, userID string, bs *store.BucketStore, f func(context.Context, *store.BucketStore) error) error {
+	u.userSyncMusMu.Lock()
+	mu, ok := u.userSyncMus[userID]
+	if !ok {
+		mu = &sync.Mutex{}
+		u.userSyncMus[userID] = mu
+	}
+	u.userSyncMusMu.Unlock()
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	err := f(ctx, bs)
+	if err != nil && !errors.Is(err, bucket.ErrCustomerManagedKeyAccessDenied) {
+		return err
+	}
+
+	u.storesErrorsMu.Lock()
+	defer u.storesErrorsMu.Unlock()
+	if err != nil {
+		u.storesErrors[userID] = httpgrpc.Errorf(int(codes.PermissionDenied), "store error: %s", err)
+	} else {
+		delete(u.storesErrors, userID)
+	}
+	return nil
+}
+
 // Series makes a series request to the underlying user bucket store.
 func (u *ThanosBucketStores) Series(req *storepb.SeriesRequest, srv storepb.Store_SeriesServer) error {
 	spanLog, spanCtx := spanlogger.New(srv.Context(), "BucketStores.Series")
@@ -384,6 +469,10 @@ func (u *ThanosBucketStores) Series(req *storepb.SeriesRequest, srv storepb.Stor
 		defer u.inflightRequests.Dec()
 	}
 
+	if u.cfg.BucketStore.MaxConcurrentChunkRangeReads > 0 {
+		spanCtx = withChunkRangeReadsGate(spanCtx, u.cfg.BucketStore.MaxConcurrentChunkRangeReads)
+	}
+
 	err = store.Series(req, spanSeriesServer{
 		Store_SeriesServer: srv,
 		ctx:                spanCtx,
@@ -485,6 +574,22 @@ func (u *ThanosBucketStores) getStore(userID string) *store.BucketStore {
 	return u.stores[userID]
 }
 
+// SyncedBlocks implements BucketStores. The blocks are the ones retained by the metadata fetcher
+// filters on the last successful sync, so the bucket isn't read.
+func (u *ThanosBucketStores) SyncedBlocks(userID string) map[string][]SyncedBlock {
+	u.storesMu.RLock()
+	defer u.storesMu.RUnlock()
+
+	result := map[string][]SyncedBlock{}
+	for user, tracker := range u.syncedBlocksTrackers {
+		if userID != "" && user != userID {
+			continue
+		}
+		result[user] = tracker.Blocks()
+	}
+	return result
+}
+
 func (u *ThanosBucketStores) getStoreError(userID string) error {
 	u.storesErrorsMu.RLock()
 	defer u.storesErrorsMu.RUnlock()
@@ -494,6 +599,7 @@ func (u *ThanosBucketStores) getStoreError(userID string) error {
 var (
 	errBucketStoreNotEmpty = errors.New("bucket store not empty")
 	errBucketStoreNotFound = errors.New("bucket store not found")
+	errTenantNotOwned      = errors.New("tenant not owned by the store-gateway")
 )
 
 // closeEmptyBucketStore closes bucket store for given user, if it is empty,
@@ -520,6 +626,7 @@ func (u *ThanosBucketStores) closeEmptyBucketStore(userID string) error {
 	}
 
 	delete(u.stores, userID)
+	delete(u.syncedBlocksTrackers, userID)
 	unlockInDefer = false
 	u.storesMu.Unlock()
 
@@ -529,16 +636,52 @@ func (u *ThanosBucketStores) closeEmptyBucketStore(userID string) error {
 		u.userTokenBucketsMu.Unlock()
 	}
 
+	if c, ok := u.indexCache.(*tsdb.TenantIndexCache); ok {
+		c.RemoveTenant(userID)
+	}
+
 	u.metaFetcherMetrics.RemoveUserRegistry(userID)
 	u.bucketStoreMetrics.RemoveUserRegistry(userID)
 	return bs.Close()
 }
 
+// indexCacheForUser returns the index cache to be used by the bucket store of the given user.
+func (u *ThanosBucketStores) indexCacheForUser(userID string) storecache.IndexCache {
+	if c, ok := u.indexCache.(*tsdb.TenantIndexCache); ok {
+		return c.ForTenant(userID)
+	}
+	return u.indexCache
+}
+
 func isEmptyBucketStore(bs *store.BucketStore) bool {
 	min, max := bs.TimeRange()
 	return min == math.MaxInt64 && max == math.MinInt64
 }
 
+// ignoreDeletionMarksDelay returns the deletion marks delay for the given tenant,
+// honoring the per-tenant override if set.
+func (u *ThanosBucketStores) ignoreDeletionMarksDelay(userID string) time.Duration {
+	if u.limits != nil {
+		if delay := u.limits.IgnoreDeletionMarksDelay(userID); delay > 0 {
+			return delay
+		}
+	}
+
+	return u.cfg.BucketStore.IgnoreDeletionMarksDelay
+}
+
+// ignoreBlocksWithin returns the duration within which newly created blocks are not synced
+// for the given tenant, honoring the per-tenant override if set.
+func (u *ThanosBucketStores) ignoreBlocksWithin(userID string) time.Duration {
+	if u.limits != nil {
+		if ignoreWithin := u.limits.IgnoreBlocksWithin(userID); ignoreWithin > 0 {
+			return ignoreWithin
+		}
+	}
+
+	return u.cfg.BucketStore.IgnoreBlocksWithin
+}
+
 func (u *ThanosBucketStores) syncDirForUser(userID string) string {
 	return filepath.Join(u.cfg.BucketStore.SyncDir, userID)
 }
@@ -566,6 +709,16 @@ func (u *ThanosBucketStores) getOrCreateStore(userID string) (*store.BucketStore
 	userBkt := bucket.NewUserBucketClient(userID, u.bucket, u.limits)
 	fetcherReg := prometheus.NewRegistry()
 
+	deletionMarksReader := NewPerBlockDeletionMarksReader(userLogger, userBkt, u.cfg.BucketStore.MetaSyncConcurrency)
+	if u.cfg.BucketStore.DeletionMarksListingEnabled {
+		deletionMarksReader = NewGlobalMarkersDeletionMarksReader(userLogger, userBkt, u.cfg.BucketStore.MetaSyncConcurrency)
+	}
+
+	deletionMarkFilter := NewTenantIgnoreDeletionMarkFilter(userLogger, deletionMarksReader, userID, u.ignoreDeletionMarksDelay)
+	if u.cfg.BucketStore.IgnoreDeletionMarksDryRun {
+		deletionMarkFilter.EnableDryRun()
+	}
+
 	// The sharding strategy filter MUST be before the ones we create here (order matters).
 	filters := []block.MetadataFilter{NewShardingMetadataFilterAdapter(userID, u.shardingStrategy)}
 
@@ -583,7 +736,7 @@ func (u *ThanosBucketStores) getOrCreateStore(userID string) (*store.BucketStore
 	filters = append(filters, []block.MetadataFilter{
 		block.NewConsistencyDelayMetaFilter(userLogger, u.cfg.BucketStore.ConsistencyDelay, fetcherReg),
 		// Use our own custom implementation.
-		NewIgnoreDeletionMarkFilter(userLogger, userBkt, u.cfg.BucketStore.IgnoreDeletionMarksDelay, u.cfg.BucketStore.MetaSyncConcurrency),
+		deletionMarkFilter,
 		// The duplicate filter has been intentionally omitted because it could cause troubles with
 		// the consistency check done on the querier. The duplicate filter removes redundant blocks
 		// but if the store-gateway removes redundant blocks before the querier discovers them, the
@@ -595,11 +748,34 @@ func (u *ThanosBucketStores) getOrCreateStore(userID string) (*store.BucketStore
 		// Remove Cortex external labels so that they're not injected when querying blocks.
 	}...)
 
-	if u.cfg.BucketStore.IgnoreBlocksWithin > 0 {
-		// Filter out blocks that are too new to be queried.
-		filters = append(filters, NewIgnoreNonQueryableBlocksFilter(userLogger, u.cfg.BucketStore.IgnoreBlocksWithin))
+	// Filter out blocks that are too new to be queried. The filter is a no-op for tenants
+	// whose resolved duration is 0.
+	filters = append(filters, NewTenantIgnoreNonQueryableBlocksFilter(userLogger, userID, u.ignoreBlocksWithin, fetcherReg))
+
+	if u.cfg.BucketStore.IgnoreBlocksBelowCompactionLevel > 0 {
+		// Filter out blocks which haven't been compacted enough.
+		filters = append(filters, NewMinCompactionLevelFilter(userLogger, u.cfg.BucketStore.IgnoreBlocksBelowCompactionLevel))
 	}
 
+	if u.cfg.BucketStore.IgnoreOutOfOrderBlocks {
+		// Filter out blocks compacted from out-of-order samples.
+		filters = append(filters, NewIgnoreOutOfOrderBlocksFilter(userLogger))
+	}
+
+	if u.cfg.BucketStore.TrackNoCompactMarkedBlocks {
+		// Keep track of (and optionally filter out) blocks marked for no compaction.
+		filters = append(filters, NewNoCompactMarkFilter(userLogger, userBkt, u.cfg.BucketStore.IgnoreNoCompactMarkedBlocks, u.cfg.BucketStore.MetaSyncConcurrency))
+	}
+
+	if u.cfg.BucketStore.IgnoreIncompleteBlocks {
+		// Filter out blocks whose upload hasn't completed.
+		filters = append(filters, NewIgnoreIncompleteBlocksFilter(userLogger, userBkt, u.cfg.BucketStore.MetaSyncConcurrency))
+	}
+
+	// Keep track of the newest block, and of all the blocks, retained by the filters above. They must be the last filters.
+	syncedBlocksTracker := NewSyncedBlocksTracker(u.cfg.BucketStore.IndexHeaderLazyLoadingEnabled)
+	filters = append(filters, NewBlocksMaxTimeTracker(fetcherReg), syncedBlocksTracker)
+
 	// Instantiate a different blocks metadata fetcher based on whether bucket index is enabled or not.
 	var fetcher block.MetadataFetcher
 	if u.cfg.BucketStore.BucketIndex.Enabled {
@@ -656,7 +832,7 @@ func (u *ThanosBucketStores) getOrCreateStore(userID string) (*store.BucketStore
 			return util_log.HeadersFromContext(ctx, logger)
 		}),
 		store.WithRegistry(bucketStoreReg),
-		store.WithIndexCache(u.indexCache),
+		store.WithIndexCache(u.indexCacheForUser(userID)),
 		store.WithQueryGate(u.queryGate),
 		store.WithChunkPool(u.chunksPool),
 		store.WithSeriesBatchSize(u.cfg.BucketStore.SeriesBatchSize),
@@ -715,6 +891,7 @@ func (u *ThanosBucketStores) getOrCreateStore(userID string) (*store.BucketStore
 	}
 
 	u.stores[userID] = bs
+	u.syncedBlocksTrackers[userID] = syncedBlocksTracker
 	u.metaFetcherMetrics.AddUserRegistry(userID, fetcherReg)
 	u.bucketStoreMetrics.AddUserRegistry(userID, bucketStoreReg)
 
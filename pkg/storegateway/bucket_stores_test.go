@@ -7,6 +7,8 @@ import (
 	"fmt"
 	"io"
 	"math"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path"
 	"path/filepath"
@@ -47,6 +49,7 @@ import (
 	cortex_tsdb "github.com/cortexproject/cortex/pkg/storage/tsdb"
 	"github.com/cortexproject/cortex/pkg/storage/tsdb/bucketindex"
 	"github.com/cortexproject/cortex/pkg/util/flagext"
+	"github.com/cortexproject/cortex/pkg/util/services"
 	cortex_testutil "github.com/cortexproject/cortex/pkg/util/testutil"
 	"github.com/cortexproject/cortex/pkg/util/users"
 )
@@ -399,6 +402,79 @@ func TestBucketStores_SyncBlocks(t *testing.T) {
 
 	thanosStores := stores.(*ThanosBucketStores)
 	assert.Greater(t, testutil.ToFloat64(thanosStores.syncLastSuccess), float64(0))
+
+	// The synced blocks are exposed sorted by min time.
+	syncedBlocks := stores.SyncedBlocks("")
+	require.Len(t, syncedBlocks, 1)
+	require.Len(t, syncedBlocks[userID], 2)
+	assert.Equal(t, time.UnixMilli(10).UTC(), syncedBlocks[userID][0].MinTime)
+	assert.Equal(t, time.UnixMilli(100).UTC(), syncedBlocks[userID][1].MinTime)
+	assert.Equal(t, cfg.BucketStore.IndexHeaderLazyLoadingEnabled, syncedBlocks[userID][0].IndexHeaderLazyLoaded)
+	assert.Equal(t, syncedBlocks, stores.SyncedBlocks(userID))
+	assert.Empty(t, stores.SyncedBlocks("user-2"))
+
+	recorder := httptest.NewRecorder()
+	(&StoreGateway{stores: stores}).BlocksHandler(recorder, httptest.NewRequest(http.MethodGet, "/store-gateway/blocks?tenant="+userID, nil))
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var resp syncedBlocksResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &resp))
+	assert.Equal(t, syncedBlocks, resp.Tenants)
+}
+
+func TestBucketStores_SyncUserBlocks(t *testing.T) {
+	t.Parallel()
+	const metricName = "series_1"
+
+	ctx := context.Background()
+	cfg := prepareStorageConfig(t)
+	storageDir := t.TempDir()
+
+	bucket, err := filesystem.NewBucketClient(filesystem.Config{Directory: storageDir})
+	require.NoError(t, err)
+
+	// The user-2 isn't owned by the store-gateway.
+	shardingStrategy := NewNoShardingStrategy(log.NewNopLogger(), users.NewAllowedTenants(nil, []string{"user-2"}))
+	stores, err := NewBucketStores(cfg, shardingStrategy, objstore.WithNoopInstr(bucket), defaultLimitsOverrides(t), mockLoggingLevel(), log.NewNopLogger(), prometheus.NewPedanticRegistry())
+	require.NoError(t, err)
+
+	generateStorageBlock(t, storageDir, "user-1", metricName, 10, 100, 15)
+	generateStorageBlock(t, storageDir, "user-3", metricName, 10, 100, 15)
+	require.NoError(t, stores.InitialSync(ctx))
+
+	// Generate another block for each user, and only sync the user-1.
+	generateStorageBlock(t, storageDir, "user-1", metricName, 100, 200, 15)
+	generateStorageBlock(t, storageDir, "user-3", metricName, 100, 200, 15)
+	require.NoError(t, stores.SyncUserBlocks(ctx, "user-1"))
+	assert.Len(t, stores.SyncedBlocks("user-1")["user-1"], 2)
+	assert.Len(t, stores.SyncedBlocks("user-3")["user-3"], 1)
+
+	// Syncing again is a no-op.
+	require.NoError(t, stores.SyncUserBlocks(ctx, "user-1"))
+	assert.Len(t, stores.SyncedBlocks("user-1")["user-1"], 2)
+
+	require.ErrorIs(t, stores.SyncUserBlocks(ctx, "user-2"), errTenantNotOwned)
+
+	g := &StoreGateway{stores: stores, logger: log.NewNopLogger()}
+	g.Service = services.NewIdleService(nil, nil)
+	require.NoError(t, services.StartAndAwaitRunning(ctx, g))
+	t.Cleanup(func() { _ = services.StopAndAwaitTerminated(ctx, g) })
+
+	for path, expectedCode := range map[string]int{
+		"/store-gateway/sync?tenant=user-3": http.StatusOK,
+		"/store-gateway/sync?tenant=user-2": http.StatusNotFound,
+		"/store-gateway/sync":               http.StatusBadRequest,
+	} {
+		recorder := httptest.NewRecorder()
+		g.SyncHandler(recorder, httptest.NewRequest(http.MethodPost, path, nil))
+		require.Equal(t, expectedCode, recorder.Code, path)
+
+		if expectedCode == http.StatusOK {
+			var resp syncedBlocksResponse
+			require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &resp))
+			assert.Len(t, resp.Tenants["user-3"], 2)
+		}
+	}
 }
 
 func TestBucketStores_syncUsersBlocks(t *testing.T) {
@@ -568,6 +644,109 @@ func testBucketStoresSeriesShouldCorrectlyQuerySeriesSpanningMultipleChunks(t *t
 			assert.Equal(t, testData.expectedSamples, len(samples))
 		})
 	}
+
+	// The index-header of the queried block is loaded only once, and then kept loaded.
+	expectedLoaded := 0
+	if lazyLoadingEnabled {
+		expectedLoaded = 1
+	}
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(fmt.Sprintf(`
+		# HELP cortex_bucket_store_indexheader_lazy_loaded Number of index-headers currently loaded by the index-header lazy reader.
+		# TYPE cortex_bucket_store_indexheader_lazy_loaded gauge
+		cortex_bucket_store_indexheader_lazy_loaded %d
+	`, expectedLoaded)), "cortex_bucket_store_indexheader_lazy_loaded"))
+}
+
+func TestBucketStores_Series_ShouldLookupSetRegexMatchersPostings(t *testing.T) {
+	const (
+		userID     = "user-1"
+		metricName = "http_requests_total"
+	)
+
+	ctx := context.Background()
+	cfg := prepareStorageConfig(t)
+	storageDir := t.TempDir()
+
+	// Generate a single block with a series for each status code.
+	series := make([]labels.Labels, 0, 500)
+	for status := 100; status < 600; status++ {
+		series = append(series, labels.FromStrings(labels.MetricName, metricName, "status", fmt.Sprint(status)))
+	}
+	generateStorageBlockWithSeries(t, storageDir, userID, series, 0, 100, 10)
+
+	bucket, err := filesystem.NewBucketClient(filesystem.Config{Directory: storageDir})
+	require.NoError(t, err)
+
+	reg := prometheus.NewPedanticRegistry()
+	stores, err := NewBucketStores(cfg, NewNoShardingStrategy(log.NewNopLogger(), nil), objstore.WithNoopInstr(bucket), defaultLimitsOverrides(t), mockLoggingLevel(), log.NewNopLogger(), reg)
+	require.NoError(t, err)
+	require.NoError(t, stores.InitialSync(ctx))
+
+	// The anchored alternation is looked up as a set of label values, so only the postings
+	// of the matching values are touched instead of scanning all the status values.
+	req := &storepb.SeriesRequest{
+		MinTime: math.MinInt64,
+		MaxTime: math.MaxInt64,
+		Matchers: []storepb.LabelMatcher{
+			{Type: storepb.LabelMatcher_EQ, Name: labels.MetricName, Value: metricName},
+			{Type: storepb.LabelMatcher_RE, Name: "status", Value: "^(200|404|500)$"},
+		},
+		PartialResponseStrategy: storepb.PartialResponseStrategy_ABORT,
+	}
+	srv := newBucketStoreSeriesServer(setUserIDToGRPCContext(ctx, userID))
+	require.NoError(t, stores.Series(req, srv))
+
+	statuses := make([]string, 0, len(srv.SeriesSet))
+	for _, s := range srv.SeriesSet {
+		statuses = append(statuses, labelpb.ZLabelsToPromLabels(s.Labels).Get("status"))
+	}
+	assert.ElementsMatch(t, []string{"200", "404", "500"}, statuses)
+
+	// The postings of the metric name and of the 3 status values.
+	assert.Equal(t, 4.0, seriesDataTouched(t, reg, "postings"))
+}
+
+func TestBucketStores_ShouldServeBlocksFromFederatedBuckets(t *testing.T) {
+	const userID = "user-1"
+
+	ctx := context.Background()
+	cfg := prepareStorageConfig(t)
+
+	// The same block exists in both buckets, while another block only exists in the secondary one.
+	primaryDir := t.TempDir()
+	secondaryDir := t.TempDir()
+	generateStorageBlock(t, primaryDir, userID, "series_1", 0, 100, 15)
+	require.NoError(t, os.CopyFS(secondaryDir, os.DirFS(primaryDir)))
+	generateStorageBlock(t, secondaryDir, userID, "series_2", 0, 100, 15)
+
+	primaryBucket, err := filesystem.NewBucketClient(filesystem.Config{Directory: primaryDir})
+	require.NoError(t, err)
+	secondaryBucket, err := filesystem.NewBucketClient(filesystem.Config{Directory: secondaryDir})
+	require.NoError(t, err)
+	federatedBucket := bucket.NewFederatedBucketClient(objstore.WithNoopInstr(primaryBucket), objstore.WithNoopInstr(secondaryBucket))
+
+	reg := prometheus.NewPedanticRegistry()
+	stores, err := NewBucketStores(cfg, NewNoShardingStrategy(log.NewNopLogger(), nil), federatedBucket, defaultLimitsOverrides(t), mockLoggingLevel(), log.NewNopLogger(), reg)
+	require.NoError(t, err)
+	require.NoError(t, stores.InitialSync(ctx))
+
+	for _, metricName := range []string{"series_1", "series_2"} {
+		seriesSet, warnings, err := querySeries(stores, userID, metricName, 0, 100)
+		require.NoError(t, err)
+		assert.Empty(t, warnings)
+		require.Len(t, seriesSet, 1)
+
+		samples, err := readSamplesFromChunks(seriesSet[0].Chunks)
+		require.NoError(t, err)
+		assert.Len(t, samples, 7)
+	}
+
+	// The block existing in both buckets is loaded only once.
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP cortex_bucket_store_blocks_loaded Number of currently loaded blocks.
+		# TYPE cortex_bucket_store_blocks_loaded gauge
+		cortex_bucket_store_blocks_loaded{user="user-1"} 2
+	`), "cortex_bucket_store_blocks_loaded"))
 }
 
 func TestBucketStores_Series_ShouldReturnErrorIfMaxInflightRequestIsReached(t *testing.T) {
@@ -700,6 +879,10 @@ func prepareStorageConfig(t testing.TB) cortex_tsdb.BlocksStorageConfig {
 }
 
 func generateStorageBlock(t *testing.T, storageDir, userID string, metricName string, minT, maxT int64, step int) {
+	generateStorageBlockWithSeries(t, storageDir, userID, []labels.Labels{labels.FromStrings(labels.MetricName, metricName)}, minT, maxT, step)
+}
+
+func generateStorageBlockWithSeries(t *testing.T, storageDir, userID string, series []labels.Labels, minT, maxT int64, step int) {
 	// Create a directory for the user (if doesn't already exist).
 	userDir := filepath.Join(storageDir, userID)
 	if _, err := os.Stat(userDir); err != nil {
@@ -708,20 +891,18 @@ func generateStorageBlock(t *testing.T, storageDir, userID string, metricName st
 
 	// Create a temporary directory where the TSDB is opened,
 	// then it will be snapshotted to the storage directory.
-	tmpDir := t.TempDir()
-
-	db, err := tsdb.Open(tmpDir, promslog.NewNopLogger(), nil, tsdb.DefaultOptions(), nil)
+	db, err := tsdb.Open(t.TempDir(), promslog.NewNopLogger(), nil, tsdb.DefaultOptions(), nil)
 	require.NoError(t, err)
 	defer func() {
 		require.NoError(t, db.Close())
 	}()
 
-	series := labels.FromStrings(labels.MetricName, metricName)
-
 	app := db.Appender(context.Background())
-	for ts := minT; ts < maxT; ts += int64(step) {
-		_, err = app.Append(0, series, ts, 1)
-		require.NoError(t, err)
+	for _, s := range series {
+		for ts := minT; ts < maxT; ts += int64(step) {
+			_, err = app.Append(0, s, ts, 1)
+			require.NoError(t, err)
+		}
 	}
 	require.NoError(t, app.Commit())
 
@@ -729,6 +910,26 @@ func generateStorageBlock(t *testing.T, storageDir, userID string, metricName st
 	require.NoError(t, db.Snapshot(userDir, true))
 }
 
+// seriesDataTouched returns the number of items of the given data type touched by the series requests.
+func seriesDataTouched(t *testing.T, reg prometheus.Gatherer, dataType string) float64 {
+	families, err := reg.Gather()
+	require.NoError(t, err)
+
+	for _, family := range families {
+		if family.GetName() != "cortex_bucket_store_series_data_touched" {
+			continue
+		}
+		for _, m := range family.GetMetric() {
+			for _, l := range m.GetLabel() {
+				if l.GetName() == "data_type" && l.GetValue() == dataType {
+					return m.GetHistogram().GetSampleSum()
+				}
+			}
+		}
+	}
+	return 0
+}
+
 func querySeries(stores BucketStores, userID, metricName string, minT, maxT int64, blockIDs ...string) ([]*storepb.Series, annotations.Annotations, error) {
 	var (
 		anyHints *types.Any
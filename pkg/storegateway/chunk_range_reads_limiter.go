@@ -0,0 +1,242 @@
+package storegateway
+
+import (
+	"context"
+	"errors"
+	"io"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/thanos-io/objstore"
+	"github.com/thanos-io/thanos/pkg/block"
+)
+
+// prefetchReadBufferBytes is the size of the buffer used to download the range reads in background.
+const prefetchReadBufferBytes = 32 * 1024
+
+type chunkRangeReadsGateKey struct{}
+
+// withChunkRangeReadsGate returns a context limiting the number of concurrent chunk range reads
+// issued while serving the request to maxConcurrency.
+func withChunkRangeReadsGate(ctx context.Context, maxConcurrency int) context.Context {
+	return context.WithValue(ctx, chunkRangeReadsGateKey{}, make(chan struct{}, maxConcurrency))
+}
+
+type chunkRangeReadsMetrics struct {
+	inflight     prometheus.Gauge
+	waitDuration prometheus.Histogram
+}
+
+func newChunkRangeReadsMetrics(reg prometheus.Registerer) *chunkRangeReadsMetrics {
+	return &chunkRangeReadsMetrics{
+		inflight: promauto.With(reg).NewGauge(prometheus.GaugeOpts{
+			Name: "cortex_bucket_stores_chunk_range_reads_inflight",
+			Help: "Number of chunks range reads currently issued to the object storage while serving series requests.",
+		}),
+		waitDuration: promauto.With(reg).NewHistogram(prometheus.HistogramOpts{
+			Name:    "cortex_bucket_stores_chunk_range_reads_wait_duration_seconds",
+			Help:    "Time spent by chunks range reads waiting for a slot of the per-request concurrency limit.",
+			Buckets: []float64{0.001, 0.01, 0.1, 0.5, 1, 5, 10},
+		}),
+	}
+}
+
+// chunkRangeReadsLimitingBucket is an objstore.InstrumentedBucket limiting the number of concurrent range reads
+// of the chunks segment files issued by a request, if the request context has been set up with
+// withChunkRangeReadsGate, and of the chunks segment files of each block, if the block gates are set. It wraps
+// the object storage client under the caching bucket, so that only the range reads missing from the cache are
+// limited.
+type chunkRangeReadsLimitingBucket struct {
+	objstore.InstrumentedBucket
+
+	metrics    *chunkRangeReadsMetrics
+	blockGates *blockReadsGates
+}
+
+func newChunkRangeReadsLimitingBucket(bkt objstore.InstrumentedBucket, metrics *chunkRangeReadsMetrics, blockGates *blockReadsGates) *chunkRangeReadsLimitingBucket {
+	return &chunkRangeReadsLimitingBucket{InstrumentedBucket: bkt, metrics: metrics, blockGates: blockGates}
+}
+
+func (b *chunkRangeReadsLimitingBucket) GetRange(ctx context.Context, name string, off, length int64) (io.ReadCloser, error) {
+	if length <= 0 || path.Base(path.Dir(name)) != block.ChunksDirname {
+		return b.InstrumentedBucket.GetRange(ctx, name, off, length)
+	}
+
+	// The slot of the request is taken before the slot of the block, and both are released once the
+	// range has been downloaded, so a range read never waits for a slot that could be held forever.
+	var gates []rangeReadsGate
+	if gate, ok := ctx.Value(chunkRangeReadsGateKey{}).(chan struct{}); ok {
+		gates = append(gates, b.requestGate(gate))
+	}
+	if b.blockGates != nil {
+		// The chunks segment files are stored in <user>/<block>/chunks/.
+		if blockID, err := ulid.Parse(path.Base(path.Dir(path.Dir(name)))); err == nil {
+			gates = append(gates, b.blockGates.gateFor(blockID.String()))
+		}
+	}
+
+	switch len(gates) {
+	case 0:
+		return b.InstrumentedBucket.GetRange(ctx, name, off, length)
+	case 1:
+		return newGatedRangeReader(ctx, b.InstrumentedBucket, gates[0], name, off, length)
+	default:
+		return newGatedRangeReader(ctx, b.InstrumentedBucket, allRangeReadsGates(gates), name, off, length)
+	}
+}
+
+// requestGate returns the rangeReadsGate of the request gate.
+func (b *chunkRangeReadsLimitingBucket) requestGate(gate chan struct{}) rangeReadsGate {
+	return func(ctx context.Context) (func(), error) {
+		start := time.Now()
+		select {
+		case gate <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		b.metrics.waitDuration.Observe(time.Since(start).Seconds())
+		b.metrics.inflight.Inc()
+		return func() {
+			b.metrics.inflight.Dec()
+			<-gate
+		}, nil
+	}
+}
+
+func (b *chunkRangeReadsLimitingBucket) WithExpectedErrs(fn objstore.IsOpFailureExpectedFunc) objstore.Bucket {
+	return newChunkRangeReadsLimitingBucket(objstore.WithNoopInstr(b.InstrumentedBucket.WithExpectedErrs(fn)), b.metrics, b.blockGates)
+}
+
+func (b *chunkRangeReadsLimitingBucket) ReaderWithExpectedErrs(fn objstore.IsOpFailureExpectedFunc) objstore.BucketReader {
+	return b.WithExpectedErrs(fn)
+}
+
+// rangeReadsGate waits for a slot of a gate, and returns the function releasing it.
+type rangeReadsGate func(ctx context.Context) (release func(), err error)
+
+// allRangeReadsGates returns a rangeReadsGate waiting for a slot of each gate in order, and releasing them
+// in reverse order.
+func allRangeReadsGates(gates []rangeReadsGate) rangeReadsGate {
+	return func(ctx context.Context) (func(), error) {
+		releases := make([]func(), 0, len(gates))
+		releaseAll := func() {
+			for i := len(releases) - 1; i >= 0; i-- {
+				releases[i]()
+			}
+		}
+
+		for _, gate := range gates {
+			release, err := gate(ctx)
+			if err != nil {
+				releaseAll()
+				return nil, err
+			}
+			releases = append(releases, release)
+		}
+		return releaseAll, nil
+	}
+}
+
+// newGatedRangeReader returns a reader of the given range of an object, fetched with a single range read once
+// a slot of the gate is available. The range is prefetched in background and the slot is released as soon as
+// the range has been downloaded, without waiting for the caller to consume it, because the bucket store may
+// issue another range read while holding a range reader open, which could otherwise never get a slot.
+// The range read is issued before returning, so that its errors are returned by GetRange.
+func newGatedRangeReader(ctx context.Context, bkt objstore.BucketReader, gate rangeReadsGate, name string, off, length int64) (io.ReadCloser, error) {
+	release, err := gate(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	reader, err := bkt.GetRange(ctx, name, off, length)
+	if err != nil {
+		cancel()
+		release()
+		return nil, err
+	}
+
+	r := &prefetchedRangeReader{cancel: cancel, done: make(chan struct{})}
+	r.cond = sync.NewCond(&r.mu)
+	go r.prefetch(reader, release)
+	return r, nil
+}
+
+// prefetchedRangeReader is an io.ReadCloser returning the data of a range read as soon as it has been downloaded.
+type prefetchedRangeReader struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	mu     sync.Mutex
+	cond   *sync.Cond
+	data   []byte
+	off    int
+	err    error
+	closed bool
+}
+
+// prefetch downloads the range from the reader. The slot of the gate is released before the end of
+// the range is returned to the caller.
+func (r *prefetchedRangeReader) prefetch(reader io.ReadCloser, release func()) {
+	defer close(r.done)
+
+	buf := make([]byte, prefetchReadBufferBytes)
+	for {
+		n, err := reader.Read(buf)
+		if err != nil {
+			if closeErr := reader.Close(); errors.Is(err, io.EOF) && closeErr != nil {
+				err = closeErr
+			}
+			r.cancel()
+			release()
+		}
+
+		r.mu.Lock()
+		if !r.closed {
+			r.data = append(r.data, buf[:n]...)
+		}
+		r.err = err
+		r.cond.Broadcast()
+		r.mu.Unlock()
+
+		if err != nil {
+			return
+		}
+	}
+}
+
+func (r *prefetchedRangeReader) Read(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return 0, io.ErrClosedPipe
+	}
+	for r.off == len(r.data) && r.err == nil {
+		r.cond.Wait()
+	}
+	if r.off < len(r.data) {
+		n := copy(p, r.data[r.off:])
+		r.off += n
+		return n, nil
+	}
+	return 0, r.err
+}
+
+// Close stops the download if still in progress, and releases the downloaded data.
+func (r *prefetchedRangeReader) Close() error {
+	r.cancel()
+	<-r.done
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.closed = true
+	r.data = nil
+	r.off = 0
+	return nil
+}
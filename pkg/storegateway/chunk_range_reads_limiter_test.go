@@ -0,0 +1,235 @@
+package storegateway
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/objstore"
+	"go.uber.org/atomic"
+)
+
+func TestChunkRangeReadsLimitingBucket(t *testing.T) {
+	t.Parallel()
+
+	const (
+		chunksObject = "01FAKEBLOCK/chunks/000001"
+		indexObject  = "01FAKEBLOCK/index"
+	)
+
+	inmem := objstore.NewInMemBucket()
+	require.NoError(t, inmem.Upload(context.Background(), chunksObject, strings.NewReader("0123456789")))
+	require.NoError(t, inmem.Upload(context.Background(), indexObject, strings.NewReader("0123456789")))
+
+	tracking := &inflightRangeReadsBucket{Bucket: inmem}
+	reg := prometheus.NewPedanticRegistry()
+	bkt := newChunkRangeReadsLimitingBucket(objstore.WithNoopInstr(tracking), newChunkRangeReadsMetrics(reg), nil)
+
+	t.Run("should read the requested range", func(t *testing.T) {
+		ctx := withChunkRangeReadsGate(context.Background(), 1)
+		reader, err := bkt.GetRange(ctx, chunksObject, 2, 3)
+		require.NoError(t, err)
+		data, err := io.ReadAll(reader)
+		require.NoError(t, err)
+		require.NoError(t, reader.Close())
+		assert.Equal(t, "234", string(data))
+	})
+
+	t.Run("should limit the concurrent chunks range reads", func(t *testing.T) {
+		tracking.reset(10 * time.Millisecond)
+		ctx := withChunkRangeReadsGate(context.Background(), 2)
+
+		wg := sync.WaitGroup{}
+		for range 10 {
+			wg.Go(func() {
+				reader, err := bkt.GetRange(ctx, chunksObject, 0, 10)
+				require.NoError(t, err)
+				require.NoError(t, reader.Close())
+			})
+		}
+		wg.Wait()
+
+		assert.Equal(t, int64(2), tracking.maxInflight.Load())
+		assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+			# HELP cortex_bucket_stores_chunk_range_reads_inflight Number of chunks range reads currently issued to the object storage while serving series requests.
+			# TYPE cortex_bucket_stores_chunk_range_reads_inflight gauge
+			cortex_bucket_stores_chunk_range_reads_inflight 0
+		`), "cortex_bucket_stores_chunk_range_reads_inflight"))
+
+		metrics, err := reg.Gather()
+		require.NoError(t, err)
+		for _, m := range metrics {
+			if m.GetName() == "cortex_bucket_stores_chunk_range_reads_wait_duration_seconds" {
+				// The range read of the previous test case has been observed too.
+				assert.Equal(t, uint64(11), m.GetMetric()[0].GetHistogram().GetSampleCount())
+			}
+		}
+	})
+
+	t.Run("should not limit the range reads of other objects", func(t *testing.T) {
+		tracking.reset(10 * time.Millisecond)
+		ctx := withChunkRangeReadsGate(context.Background(), 1)
+
+		wg := sync.WaitGroup{}
+		for range 5 {
+			wg.Go(func() {
+				reader, err := bkt.GetRange(ctx, indexObject, 0, 10)
+				require.NoError(t, err)
+				require.NoError(t, reader.Close())
+			})
+		}
+		wg.Wait()
+
+		assert.Greater(t, tracking.maxInflight.Load(), int64(1))
+	})
+
+	t.Run("should not limit the requests without gate", func(t *testing.T) {
+		tracking.reset(10 * time.Millisecond)
+
+		wg := sync.WaitGroup{}
+		for range 5 {
+			wg.Go(func() {
+				reader, err := bkt.GetRange(context.Background(), chunksObject, 0, 10)
+				require.NoError(t, err)
+				require.NoError(t, reader.Close())
+			})
+		}
+		wg.Wait()
+
+		assert.Greater(t, tracking.maxInflight.Load(), int64(1))
+	})
+
+	t.Run("should not deadlock when a range is read while holding another range reader", func(t *testing.T) {
+		tracking.reset(0)
+		ctx := withChunkRangeReadsGate(context.Background(), 1)
+
+		outer, err := bkt.GetRange(ctx, chunksObject, 0, 5)
+		require.NoError(t, err)
+		inner, err := bkt.GetRange(ctx, chunksObject, 5, 5)
+		require.NoError(t, err)
+		require.NoError(t, inner.Close())
+		require.NoError(t, outer.Close())
+	})
+
+	t.Run("should prefetch the range with a single range read", func(t *testing.T) {
+		tracking.reset(0)
+		ctx := withChunkRangeReadsGate(context.Background(), 1)
+
+		reader, err := bkt.GetRange(ctx, chunksObject, 1, 8)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), tracking.calls.Load())
+
+		// Another range is read while the first one is partially read, like the bucket store does when refetching a chunk.
+		buf := make([]byte, 2)
+		_, err = io.ReadFull(reader, buf)
+		require.NoError(t, err)
+		inner, err := bkt.GetRange(ctx, chunksObject, 0, 10)
+		require.NoError(t, err)
+		innerData, err := io.ReadAll(inner)
+		require.NoError(t, err)
+		require.NoError(t, inner.Close())
+		assert.Equal(t, "0123456789", string(innerData))
+
+		data, err := io.ReadAll(reader)
+		require.NoError(t, err)
+		require.NoError(t, reader.Close())
+		assert.Equal(t, "12345678", string(buf)+string(data))
+		assert.Equal(t, int64(1), tracking.maxInflight.Load())
+		assert.Equal(t, int64(2), tracking.calls.Load())
+
+		// The range exceeding the object is read up to the end of the object.
+		reader, err = bkt.GetRange(ctx, chunksObject, 5, 10)
+		require.NoError(t, err)
+		data, err = io.ReadAll(reader)
+		require.NoError(t, err)
+		require.NoError(t, reader.Close())
+		assert.Equal(t, "56789", string(data))
+	})
+
+	t.Run("should limit the range reads of the bucket with expected errors", func(t *testing.T) {
+		tracking.reset(10 * time.Millisecond)
+		ctx := withChunkRangeReadsGate(context.Background(), 1)
+		reader := bkt.ReaderWithExpectedErrs(func(error) bool { return true })
+
+		wg := sync.WaitGroup{}
+		for range 5 {
+			wg.Go(func() {
+				r, err := reader.GetRange(ctx, chunksObject, 0, 10)
+				require.NoError(t, err)
+				_, err = io.ReadAll(r)
+				require.NoError(t, err)
+				require.NoError(t, r.Close())
+			})
+		}
+		wg.Wait()
+
+		assert.Equal(t, int64(1), tracking.maxInflight.Load())
+	})
+
+	t.Run("should give up waiting for a slot when the context is canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(withChunkRangeReadsGate(context.Background(), 1))
+		ctx.Value(chunkRangeReadsGateKey{}).(chan struct{}) <- struct{}{} // Take the only slot.
+		cancel()
+
+		_, err := bkt.GetRange(ctx, chunksObject, 0, 10)
+		require.ErrorIs(t, err, context.Canceled)
+	})
+}
+
+// inflightRangeReadsBucket tracks the max number of range readers open at the same time.
+type inflightRangeReadsBucket struct {
+	objstore.Bucket
+
+	delay       time.Duration
+	calls       atomic.Int64
+	inflight    atomic.Int64
+	maxInflight atomic.Int64
+}
+
+func (b *inflightRangeReadsBucket) reset(delay time.Duration) {
+	b.delay = delay
+	b.calls.Store(0)
+	b.inflight.Store(0)
+	b.maxInflight.Store(0)
+}
+
+func (b *inflightRangeReadsBucket) GetRange(ctx context.Context, name string, off, length int64) (io.ReadCloser, error) {
+	reader, err := b.Bucket.GetRange(ctx, name, off, length)
+	if err != nil {
+		return nil, err
+	}
+
+	b.calls.Inc()
+	inflight := b.inflight.Inc()
+	for {
+		maxInflight := b.maxInflight.Load()
+		if inflight <= maxInflight || b.maxInflight.CompareAndSwap(maxInflight, inflight) {
+			break
+		}
+	}
+	time.Sleep(b.delay)
+
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	return &closeCallbackReader{Reader: bytes.NewReader(data), onClose: func() { b.inflight.Dec() }}, nil
+}
+
+type closeCallbackReader struct {
+	io.Reader
+	onClose func()
+}
+
+func (r *closeCallbackReader) Close() error {
+	r.onClose()
+	return nil
+}
@@ -0,0 +1,153 @@
+package storegateway
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sync"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/oklog/ulid/v2"
+	"github.com/pkg/errors"
+	"github.com/thanos-io/objstore"
+	"github.com/thanos-io/thanos/pkg/block/metadata"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/cortexproject/cortex/pkg/storage/tsdb/bucketindex"
+)
+
+// DeletionMarksReader reads the deletion marks of a set of blocks.
+type DeletionMarksReader interface {
+	// ReadDeletionMarks returns the deletion marks of the input blocks. Blocks not marked
+	// for deletion (or whose deletion mark is corrupted) are not included in the output.
+	ReadDeletionMarks(ctx context.Context, blockIDs []ulid.ULID) (map[ulid.ULID]*metadata.DeletionMark, error)
+}
+
+// perBlockDeletionMarksReader is a DeletionMarksReader issuing a GET request for the
+// deletion mark of each input block.
+type perBlockDeletionMarksReader struct {
+	logger      log.Logger
+	bkt         objstore.InstrumentedBucketReader
+	concurrency int
+}
+
+// NewPerBlockDeletionMarksReader creates a DeletionMarksReader reading the deletion mark
+// of each block from the block location.
+func NewPerBlockDeletionMarksReader(logger log.Logger, bkt objstore.InstrumentedBucketReader, concurrency int) DeletionMarksReader {
+	return &perBlockDeletionMarksReader{
+		logger:      logger,
+		bkt:         bkt,
+		concurrency: concurrency,
+	}
+}
+
+// ReadDeletionMarks implements DeletionMarksReader.
+func (r *perBlockDeletionMarksReader) ReadDeletionMarks(ctx context.Context, blockIDs []ulid.ULID) (map[ulid.ULID]*metadata.DeletionMark, error) {
+	return readBlockMarkers(ctx, r.logger, r.bkt, blockIDs, r.concurrency, metadata.DeletionMarkFilename, func() *metadata.DeletionMark {
+		return &metadata.DeletionMark{}
+	})
+}
+
+// readBlockMarkers concurrently reads the marker with the given filename of each input block.
+// Blocks without the marker (or whose marker is corrupted) are not included in the output.
+func readBlockMarkers[M metadata.Marker](ctx context.Context, logger log.Logger, bkt objstore.InstrumentedBucketReader, blockIDs []ulid.ULID, concurrency int, filename string, newMarker func() M) (map[ulid.ULID]M, error) {
+	markers := make(map[ulid.ULID]M)
+
+	var (
+		eg  errgroup.Group
+		ch  = make(chan ulid.ULID, concurrency)
+		mtx sync.Mutex
+	)
+
+	for i := 0; i < concurrency; i++ {
+		eg.Go(func() error {
+			var lastErr error
+			for id := range ch {
+				m := newMarker()
+				if err := metadata.ReadMarker(ctx, logger, bkt, id.String(), m); err != nil {
+					if errors.Cause(err) == metadata.ErrorMarkerNotFound {
+						continue
+					}
+					if errors.Cause(err) == metadata.ErrorUnmarshalMarker {
+						level.Warn(logger).Log("msg", fmt.Sprintf("found partial %s; if we will see it happening often for the same block, consider manually deleting %s from the object storage", filename, filename), "block", id, "err", err)
+						continue
+					}
+					// Remember the last error and continue to drain the channel.
+					lastErr = err
+					continue
+				}
+
+				mtx.Lock()
+				markers[id] = m
+				mtx.Unlock()
+			}
+
+			return lastErr
+		})
+	}
+
+	// Workers scheduled, distribute blocks.
+	eg.Go(func() error {
+		defer close(ch)
+
+		for _, id := range blockIDs {
+			select {
+			case ch <- id:
+				// Nothing to do.
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+
+		return nil
+	})
+
+	if err := eg.Wait(); err != nil {
+		return nil, err
+	}
+
+	return markers, nil
+}
+
+// globalMarkersDeletionMarksReader is a DeletionMarksReader listing the global markers
+// location to find out which blocks are marked for deletion, and then reading the deletion
+// mark of these blocks only. It requires deletion marks to be stored in the global markers
+// location too, which is what bucketindex.BucketWithGlobalMarkers does.
+type globalMarkersDeletionMarksReader struct {
+	bkt      objstore.InstrumentedBucketReader
+	perBlock DeletionMarksReader
+}
+
+// NewGlobalMarkersDeletionMarksReader creates a DeletionMarksReader issuing a single listing
+// of the global markers location, followed by a GET request only for blocks marked for deletion.
+func NewGlobalMarkersDeletionMarksReader(logger log.Logger, bkt objstore.InstrumentedBucketReader, concurrency int) DeletionMarksReader {
+	return &globalMarkersDeletionMarksReader{
+		bkt:      bkt,
+		perBlock: NewPerBlockDeletionMarksReader(logger, bkt, concurrency),
+	}
+}
+
+// ReadDeletionMarks implements DeletionMarksReader.
+func (r *globalMarkersDeletionMarksReader) ReadDeletionMarks(ctx context.Context, blockIDs []ulid.ULID) (map[ulid.ULID]*metadata.DeletionMark, error) {
+	requested := make(map[ulid.ULID]struct{}, len(blockIDs))
+	for _, id := range blockIDs {
+		requested[id] = struct{}{}
+	}
+
+	var marked []ulid.ULID
+	err := r.bkt.Iter(ctx, bucketindex.MarkersPathname+"/", func(name string) error {
+		if id, ok := bucketindex.IsBlockDeletionMarkFilename(path.Base(name)); ok {
+			if _, ok := requested[id]; ok {
+				marked = append(marked, id)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "list block deletion marks")
+	}
+
+	return r.perBlock.ReadDeletionMarks(ctx, marked)
+}
@@ -0,0 +1,106 @@
+package storegateway
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/oklog/ulid/v2"
+	"github.com/prometheus/client_golang/prometheus"
+	promtest "github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/objstore"
+	"github.com/thanos-io/thanos/pkg/block/metadata"
+
+	"github.com/cortexproject/cortex/pkg/storage/bucket"
+	"github.com/cortexproject/cortex/pkg/storage/tsdb/bucketindex"
+	cortex_testutil "github.com/cortexproject/cortex/pkg/util/testutil"
+)
+
+func TestDeletionMarksReaders(t *testing.T) {
+	t.Parallel()
+	const userID = "user-1"
+
+	ctx := context.Background()
+	logger := log.NewNopLogger()
+
+	bkt, _ := cortex_testutil.PrepareFilesystemBucket(t)
+	userBkt := bucket.NewUserBucketClient(userID, bucketindex.BucketWithGlobalMarkers(bkt), nil)
+
+	// Blocks 1 and 2 are marked for deletion, block 3 has a corrupted deletion mark
+	// and all other blocks are not marked for deletion.
+	blockIDs := make([]ulid.ULID, 0, 10)
+	for i := 1; i <= 10; i++ {
+		blockIDs = append(blockIDs, ulid.MustNew(uint64(i), nil))
+	}
+
+	expectedMarks := map[ulid.ULID]*metadata.DeletionMark{}
+	for _, id := range blockIDs[:2] {
+		mark := &metadata.DeletionMark{
+			ID:           id,
+			DeletionTime: time.Now().Add(-time.Hour).Unix(),
+			Version:      metadata.DeletionMarkVersion1,
+		}
+		expectedMarks[id] = mark
+
+		var buf bytes.Buffer
+		require.NoError(t, json.NewEncoder(&buf).Encode(mark))
+		require.NoError(t, userBkt.Upload(ctx, path.Join(id.String(), metadata.DeletionMarkFilename), &buf))
+	}
+	require.NoError(t, userBkt.Upload(ctx, path.Join(blockIDs[2].String(), metadata.DeletionMarkFilename), bytes.NewBufferString("not a valid deletion-mark.json")))
+
+	// A block marked for deletion which is not requested should not be returned.
+	require.NoError(t, userBkt.Upload(ctx, path.Join(ulid.MustNew(100, nil).String(), metadata.DeletionMarkFilename), bytes.NewBufferString("{}")))
+
+	tests := map[string]struct {
+		newReader     func(bkt objstore.InstrumentedBucketReader) DeletionMarksReader
+		expectedGets  int
+		expectedIters int
+	}{
+		"per-block reader": {
+			newReader: func(bkt objstore.InstrumentedBucketReader) DeletionMarksReader {
+				return NewPerBlockDeletionMarksReader(logger, bkt, 32)
+			},
+			expectedGets:  len(blockIDs),
+			expectedIters: 0,
+		},
+		"global markers reader": {
+			newReader: func(bkt objstore.InstrumentedBucketReader) DeletionMarksReader {
+				return NewGlobalMarkersDeletionMarksReader(logger, bkt, 32)
+			},
+			expectedGets:  3,
+			expectedIters: 1,
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			t.Parallel()
+
+			reg := prometheus.NewPedanticRegistry()
+			instrBkt := objstore.WrapWithMetrics(bucket.NewUserBucketClient(userID, bkt, nil), reg, "")
+
+			actual, err := testData.newReader(instrBkt).ReadDeletionMarks(ctx, blockIDs)
+			require.NoError(t, err)
+			assert.Equal(t, expectedMarks, actual)
+
+			assert.NoError(t, promtest.GatherAndCompare(reg, bytes.NewBufferString(fmt.Sprintf(`
+				# HELP objstore_bucket_operations_total Total number of all attempted operations against a bucket.
+				# TYPE objstore_bucket_operations_total counter
+				objstore_bucket_operations_total{bucket="",operation="attributes"} 0
+				objstore_bucket_operations_total{bucket="",operation="delete"} 0
+				objstore_bucket_operations_total{bucket="",operation="exists"} 0
+				objstore_bucket_operations_total{bucket="",operation="get"} %d
+				objstore_bucket_operations_total{bucket="",operation="get_range"} 0
+				objstore_bucket_operations_total{bucket="",operation="iter"} %d
+				objstore_bucket_operations_total{bucket="",operation="upload"} 0
+			`, testData.expectedGets, testData.expectedIters)), "objstore_bucket_operations_total"))
+		})
+	}
+}
@@ -47,17 +47,23 @@ const (
 	// in the ring will be automatically removed.
 	ringAutoForgetUnhealthyPeriods = 10
 
+	// Buckets which can be configured as the primary bucket when bucket federation is enabled.
+	BucketFederationPrimaryBlocksStorage = "blocks-storage"
+	BucketFederationPrimarySecondary     = "secondary"
+
 	instanceLimitsMetric     = "cortex_storegateway_instance_limits"
 	instanceLimitsMetricHelp = "Instance limits used by this store gateway."
 	limitLabel               = "limit"
 )
 
 var (
-	supportedShardingStrategies = []string{util.ShardingStrategyDefault, util.ShardingStrategyShuffle}
+	supportedShardingStrategies  = []string{util.ShardingStrategyDefault, util.ShardingStrategyShuffle}
+	supportedFederationPrimaries = []string{BucketFederationPrimaryBlocksStorage, BucketFederationPrimarySecondary}
 
 	// Validation errors.
-	errInvalidShardingStrategy = errors.New("invalid sharding strategy")
-	errInvalidTenantShardSize  = errors.New("invalid tenant shard size, the value must be greater than 0")
+	errInvalidShardingStrategy  = errors.New("invalid sharding strategy")
+	errInvalidTenantShardSize   = errors.New("invalid tenant shard size, the value must be greater than 0")
+	errInvalidFederationPrimary = errors.New("invalid bucket federation primary bucket")
 )
 
 // Config holds the store gateway config.
@@ -73,6 +79,55 @@ type Config struct {
 
 	// Hedged Request
 	HedgedRequest bucket.HedgedRequestConfig `yaml:"hedged_request"`
+
+	BucketFederation BucketFederationConfig `yaml:"bucket_federation"`
+}
+
+// BucketFederationConfig holds the config to serve blocks from a secondary bucket, in addition
+// to the blocks storage bucket. It's meant to be used while migrating blocks between buckets.
+type BucketFederationConfig struct {
+	Enabled         bool          `yaml:"enabled"`
+	PrimaryBucket   string        `yaml:"primary_bucket"`
+	SecondaryBucket bucket.Config `yaml:"secondary_bucket"`
+}
+
+// RegisterFlagsWithPrefix registers the BucketFederationConfig flags.
+func (cfg *BucketFederationConfig) RegisterFlagsWithPrefix(f *flag.FlagSet, prefix string) {
+	f.BoolVar(&cfg.Enabled, prefix+"bucket-federation.enabled", false, "If enabled, blocks are served from both the blocks storage bucket and the secondary bucket, deduplicating blocks existing in both by block ID. Meant to be used while migrating blocks between buckets. Requires the bucket index to be disabled."+sharedOptionWithQuerier)
+	f.StringVar(&cfg.PrimaryBucket, prefix+"bucket-federation.primary-bucket", BucketFederationPrimaryBlocksStorage, fmt.Sprintf("The bucket from which blocks existing in both buckets are served. Supported values are: %s.", strings.Join(supportedFederationPrimaries, ", ")))
+	cfg.SecondaryBucket.RegisterFlagsWithPrefix(prefix+"bucket-federation.secondary-bucket.", f)
+}
+
+// Validate the BucketFederationConfig.
+func (cfg *BucketFederationConfig) Validate() error {
+	if !cfg.Enabled {
+		return nil
+	}
+
+	if !slices.Contains(supportedFederationPrimaries, cfg.PrimaryBucket) {
+		return errInvalidFederationPrimary
+	}
+
+	return cfg.SecondaryBucket.Validate()
+}
+
+// WrapBucketClient returns a bucket client serving blocks from both the input blocks storage bucket
+// client and the secondary bucket, if bucket federation is enabled. Otherwise, the input bucket
+// client is returned.
+func (cfg *BucketFederationConfig) WrapBucketClient(ctx context.Context, bucketClient objstore.InstrumentedBucket, hedgedRoundTripper func(rt http.RoundTripper) http.RoundTripper, name string, logger log.Logger, reg prometheus.Registerer) (objstore.InstrumentedBucket, error) {
+	if !cfg.Enabled {
+		return bucketClient, nil
+	}
+
+	secondaryClient, err := bucket.NewClient(ctx, cfg.SecondaryBucket, hedgedRoundTripper, name+"-secondary", logger, reg)
+	if err != nil {
+		return nil, errors.Wrap(err, "create secondary bucket client")
+	}
+
+	if cfg.PrimaryBucket == BucketFederationPrimarySecondary {
+		return bucket.NewFederatedBucketClient(secondaryClient, bucketClient), nil
+	}
+	return bucket.NewFederatedBucketClient(bucketClient, secondaryClient), nil
 }
 
 // RegisterFlags registers the Config flags.
@@ -85,6 +140,7 @@ func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
 	f.Var(&cfg.DisabledTenants, "store-gateway.disabled-tenants", "Comma separated list of tenants whose store metrics this storegateway cannot process. If specified, a storegateway that would normally pick the specified tenant(s) for processing will ignore them instead.")
 	cfg.HedgedRequest.RegisterFlagsWithPrefix(f, "store-gateway.")
 	cfg.QueryProtection.RegisterFlagsWithPrefix(f, "store-gateway.")
+	cfg.BucketFederation.RegisterFlagsWithPrefix(f, "store-gateway.")
 }
 
 // Validate the Config.
@@ -107,6 +163,10 @@ func (cfg *Config) Validate(limits validation.Limits, monitoredResources flagext
 		return err
 	}
 
+	if err := cfg.BucketFederation.Validate(); err != nil {
+		return errors.Wrap(err, "invalid bucket federation config")
+	}
+
 	return nil
 }
 
@@ -137,7 +197,7 @@ type StoreGateway struct {
 func NewStoreGateway(gatewayCfg Config, storageCfg cortex_tsdb.BlocksStorageConfig, limits *validation.Overrides, logLevel logging.Level, logger log.Logger, reg prometheus.Registerer, resourceMonitor *resource.Monitor) (*StoreGateway, error) {
 	var ringStore kv.Client
 
-	bucketClient, err := createBucketClient(storageCfg, gatewayCfg.HedgedRequest.GetHedgedRoundTripper(), logger, reg)
+	bucketClient, err := createBucketClient(storageCfg, gatewayCfg, logger, reg)
 	if err != nil {
 		return nil, err
 	}
@@ -466,11 +526,12 @@ func (g *StoreGateway) OnRingInstanceStopping(_ *ring.BasicLifecycler)
 func (g *StoreGateway) OnRingInstanceHeartbeat(_ *ring.BasicLifecycler, _ *ring.Desc, _ *ring.InstanceDesc) {
 }
 
-func createBucketClient(cfg cortex_tsdb.BlocksStorageConfig, hedgedRoundTripper func(rt http.RoundTripper) http.RoundTripper, logger log.Logger, reg prometheus.Registerer) (objstore.InstrumentedBucket, error) {
+func createBucketClient(cfg cortex_tsdb.BlocksStorageConfig, gatewayCfg Config, logger log.Logger, reg prometheus.Registerer) (objstore.InstrumentedBucket, error) {
+	hedgedRoundTripper := gatewayCfg.HedgedRequest.GetHedgedRoundTripper("store-gateway", reg)
 	bucketClient, err := bucket.NewClient(context.Background(), cfg.Bucket, hedgedRoundTripper, "store-gateway", logger, reg)
 	if err != nil {
 		return nil, errors.Wrap(err, "create bucket client")
 	}
 
-	return bucketClient, nil
+	return gatewayCfg.BucketFederation.WrapBucketClient(context.Background(), bucketClient, hedgedRoundTripper, "store-gateway", logger, reg)
 }
@@ -1,11 +1,13 @@
 package storegateway
 
 import (
+	"errors"
 	"html/template"
 	"net/http"
 
 	"github.com/go-kit/log/level"
 
+	"github.com/cortexproject/cortex/pkg/util"
 	util_log "github.com/cortexproject/cortex/pkg/util/log"
 	"github.com/cortexproject/cortex/pkg/util/services"
 )
@@ -51,3 +53,41 @@ func (c *StoreGateway) RingHandler(w http.ResponseWriter, req *http.Request) {
 
 	c.ring.ServeHTTP(w, req)
 }
+
+type syncedBlocksResponse struct {
+	Tenants map[string][]SyncedBlock `json:"tenants"`
+}
+
+// BlocksHandler returns the blocks synced by the store-gateway for each tenant, or for the tenant
+// in the "tenant" parameter only. The blocks are read from memory, so the bucket isn't read.
+func (c *StoreGateway) BlocksHandler(w http.ResponseWriter, req *http.Request) {
+	util.WriteJSONResponse(w, syncedBlocksResponse{Tenants: c.stores.SyncedBlocks(req.FormValue("tenant"))})
+}
+
+// SyncHandler synchronizes the blocks of the tenant in the "tenant" parameter, re-running its metadata
+// fetcher and filters, and returns the blocks synced for the tenant once completed.
+func (c *StoreGateway) SyncHandler(w http.ResponseWriter, req *http.Request) {
+	userID := req.FormValue("tenant")
+	if userID == "" {
+		http.Error(w, "missing tenant parameter", http.StatusBadRequest)
+		return
+	}
+
+	if c.State() != services.Running {
+		http.Error(w, "store-gateway is not running yet", http.StatusServiceUnavailable)
+		return
+	}
+
+	if err := c.stores.SyncUserBlocks(req.Context(), userID); err != nil {
+		level.Warn(util_log.WithUserID(userID, c.logger)).Log("msg", "failed to synchronize TSDB blocks for user", "err", err)
+
+		status := http.StatusInternalServerError
+		if errors.Is(err, errTenantNotOwned) {
+			status = http.StatusNotFound
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	util.WriteJSONResponse(w, syncedBlocksResponse{Tenants: c.stores.SyncedBlocks(userID)})
+}
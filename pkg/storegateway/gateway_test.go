@@ -1013,6 +1013,92 @@ func TestStoreGateway_SeriesQueryingShouldRemoveExternalLabels(t *testing.T) {
 	}
 }
 
+func TestStoreGateway_LabelValuesShouldHonorMatchers(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	logger := log.NewNopLogger()
+	userID := "user-1"
+
+	storageDir := t.TempDir()
+	now := time.Now()
+	minT := now.Add(-1*time.Hour).Unix() * 1000
+	maxT := now.Unix() * 1000
+
+	// Generate 2 TSDB blocks, each one containing the series of a different job. The
+	// job matcher eliminates all series of the other block.
+	for _, job := range []string{"job-a", "job-b"} {
+		db, err := tsdb.Open(t.TempDir(), nil, nil, tsdb.DefaultOptions(), nil)
+		require.NoError(t, err)
+
+		app := db.Appender(ctx)
+		for i := range 3 {
+			_, err := app.Append(0, labels.FromStrings(labels.MetricName, "series", "job", job, "instance", fmt.Sprintf("%s-%d", job, i)), minT+int64(i), float64(i))
+			require.NoError(t, err)
+		}
+		require.NoError(t, app.Commit())
+		require.NoError(t, db.Snapshot(path.Join(storageDir, userID), true))
+		require.NoError(t, db.Close())
+	}
+
+	bucketClient, err := filesystem.NewBucketClient(filesystem.Config{Directory: storageDir})
+	require.NoError(t, err)
+
+	createBucketIndex(t, bucketClient, userID)
+
+	for _, bucketIndexEnabled := range []bool{true, false} {
+		t.Run(fmt.Sprintf("bucket index enabled = %v", bucketIndexEnabled), func(t *testing.T) {
+			t.Parallel()
+			gatewayCfg := mockGatewayConfig()
+			gatewayCfg.ShardingEnabled = false
+			storageCfg := mockStorageConfig(t)
+			storageCfg.BucketStore.BucketIndex.Enabled = bucketIndexEnabled
+
+			g, err := newStoreGateway(gatewayCfg, storageCfg, objstore.WithNoopInstr(bucketClient), nil, defaultLimitsOverrides(t), mockLoggingLevel(), logger, nil, nil)
+			require.NoError(t, err)
+			require.NoError(t, services.StartAndAwaitRunning(ctx, g))
+			defer services.StopAndAwaitTerminated(ctx, g) //nolint:errcheck
+
+			tests := map[string]struct {
+				matchers []storepb.LabelMatcher
+				expected []string
+			}{
+				"no matchers": {
+					expected: []string{"job-a-0", "job-a-1", "job-a-2", "job-b-0", "job-b-1", "job-b-2"},
+				},
+				"matcher eliminating the series of an entire block": {
+					matchers: []storepb.LabelMatcher{{Type: storepb.LabelMatcher_EQ, Name: "job", Value: "job-b"}},
+					expected: []string{"job-b-0", "job-b-1", "job-b-2"},
+				},
+				"matchers selecting a subset of the series of a block": {
+					matchers: []storepb.LabelMatcher{
+						{Type: storepb.LabelMatcher_EQ, Name: "job", Value: "job-a"},
+						{Type: storepb.LabelMatcher_NEQ, Name: "instance", Value: "job-a-1"},
+					},
+					expected: []string{"job-a-0", "job-a-2"},
+				},
+				"matcher not matching any series": {
+					matchers: []storepb.LabelMatcher{{Type: storepb.LabelMatcher_EQ, Name: "job", Value: "job-c"}},
+					expected: nil,
+				},
+			}
+
+			for testName, testData := range tests {
+				t.Run(testName, func(t *testing.T) {
+					resp, err := g.LabelValues(setUserIDToGRPCContext(ctx, userID), &storepb.LabelValuesRequest{
+						Label:    "instance",
+						Start:    minT,
+						End:      maxT,
+						Matchers: testData.matchers,
+					})
+					require.NoError(t, err)
+					assert.Empty(t, resp.Warnings)
+					assert.ElementsMatch(t, testData.expected, resp.Values)
+				})
+			}
+		})
+	}
+}
+
 func TestStoreGateway_SeriesQueryingShouldEnforceMaxChunksPerQueryLimit(t *testing.T) {
 	t.Parallel()
 	const chunksQueried = 10
@@ -2,16 +2,25 @@ package storegateway
 
 import (
 	"context"
+	"maps"
+	"slices"
+	"sync"
 	"time"
 
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
 	"github.com/oklog/ulid/v2"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
 	"github.com/thanos-io/objstore"
 	"github.com/thanos-io/thanos/pkg/block"
 	"github.com/thanos-io/thanos/pkg/block/metadata"
+	"golang.org/x/sync/errgroup"
 
+	cortex_tsdb "github.com/cortexproject/cortex/pkg/storage/tsdb"
 	"github.com/cortexproject/cortex/pkg/storage/tsdb/bucketindex"
+	"github.com/cortexproject/cortex/pkg/util"
 )
 
 type MetadataFilterWithBucketIndex interface {
@@ -19,37 +28,87 @@ type MetadataFilterWithBucketIndex interface {
 	FilterWithBucketIndex(ctx context.Context, metas map[ulid.ULID]*metadata.Meta, idx *bucketindex.Index, synced block.GaugeVec) error
 }
 
+// WouldDeleteMeta is the synced state label value for blocks which would have been filtered out
+// because marked for deletion, if the IgnoreDeletionMarkFilter wasn't running in dry-run mode.
+const WouldDeleteMeta = "would-delete"
+
+// DeletionDelayFunc returns the delay after which blocks marked for deletion are
+// filtered out for the given tenant.
+type DeletionDelayFunc func(userID string) time.Duration
+
 // IgnoreDeletionMarkFilter is like the Thanos IgnoreDeletionMarkFilter, but it also implements
-// the MetadataFilterWithBucketIndex interface.
+// the MetadataFilterWithBucketIndex interface and resolves the deletion delay per tenant.
 type IgnoreDeletionMarkFilter struct {
-	upstream *block.IgnoreDeletionMarkFilter
+	logger log.Logger
+	reader DeletionMarksReader
+	userID string
+	delay  DeletionDelayFunc
+	dryRun bool
 
-	delay           time.Duration
+	mtx             sync.Mutex
 	deletionMarkMap map[ulid.ULID]*metadata.DeletionMark
 }
 
 // NewIgnoreDeletionMarkFilter creates IgnoreDeletionMarkFilter.
 func NewIgnoreDeletionMarkFilter(logger log.Logger, bkt objstore.InstrumentedBucketReader, delay time.Duration, concurrency int) *IgnoreDeletionMarkFilter {
+	return NewTenantIgnoreDeletionMarkFilter(logger, NewPerBlockDeletionMarksReader(logger, bkt, concurrency), "", func(string) time.Duration { return delay })
+}
+
+// NewTenantIgnoreDeletionMarkFilter creates IgnoreDeletionMarkFilter reading deletion marks through
+// the input DeletionMarksReader and resolving the deletion delay for each block through the input
+// DeletionDelayFunc. The tenant owning a block is read from the block's external labels, falling
+// back to the input userID if the label is missing.
+func NewTenantIgnoreDeletionMarkFilter(logger log.Logger, reader DeletionMarksReader, userID string, delay DeletionDelayFunc) *IgnoreDeletionMarkFilter {
 	return &IgnoreDeletionMarkFilter{
-		upstream: block.NewIgnoreDeletionMarkFilter(logger, bkt, delay, concurrency),
-		delay:    delay,
+		logger: logger,
+		reader: reader,
+		userID: userID,
+		delay:  delay,
 	}
 }
 
+// EnableDryRun configures the filter to not filter out blocks whose deletion delay has expired,
+// but only count them under the WouldDeleteMeta synced state.
+func (f *IgnoreDeletionMarkFilter) EnableDryRun() *IgnoreDeletionMarkFilter {
+	f.dryRun = true
+	return f
+}
+
 // DeletionMarkBlocks returns blocks that were marked for deletion.
 func (f *IgnoreDeletionMarkFilter) DeletionMarkBlocks() map[ulid.ULID]*metadata.DeletionMark {
-	// If the cached deletion marks exist it means the filter function was called with the bucket
-	// index, so it's safe to return it.
-	if f.deletionMarkMap != nil {
-		return f.deletionMarkMap
-	}
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+
+	deletionMarkMap := make(map[ulid.ULID]*metadata.DeletionMark, len(f.deletionMarkMap))
+	maps.Copy(deletionMarkMap, f.deletionMarkMap)
 
-	return f.upstream.DeletionMarkBlocks()
+	return deletionMarkMap
 }
 
 // Filter implements block.MetadataFilter.
-func (f *IgnoreDeletionMarkFilter) Filter(ctx context.Context, metas map[ulid.ULID]*metadata.Meta, synced block.GaugeVec, modified block.GaugeVec) error {
-	return f.upstream.Filter(ctx, metas, synced, modified)
+func (f *IgnoreDeletionMarkFilter) Filter(ctx context.Context, metas map[ulid.ULID]*metadata.Meta, synced block.GaugeVec, _ block.GaugeVec) error {
+	blockIDs := make([]ulid.ULID, 0, len(metas))
+	for id := range metas {
+		blockIDs = append(blockIDs, id)
+	}
+
+	deletionMarkMap, err := f.reader.ReadDeletionMarks(ctx, blockIDs)
+	if err != nil {
+		return errors.Wrap(err, "filter blocks marked for deletion")
+	}
+
+	// Filter out blocks whose deletion time is greater than the delay configured for the owning tenant.
+	for id, m := range deletionMarkMap {
+		if f.isDeletionDelayExpired(metas[id], m.DeletionTime) {
+			f.filterOut(id, metas, synced)
+		}
+	}
+
+	f.mtx.Lock()
+	f.deletionMarkMap = deletionMarkMap
+	f.mtx.Unlock()
+
+	return nil
 }
 
 // FilterWithBucketIndex implements MetadataFilterWithBucketIndex.
@@ -61,26 +120,139 @@ func (f *IgnoreDeletionMarkFilter) FilterWithBucketIndex(_ context.Context, meta
 	}
 
 	// Keep it cached.
+	f.mtx.Lock()
 	f.deletionMarkMap = marks
+	f.mtx.Unlock()
 
 	for _, mark := range marks {
-		if _, ok := metas[mark.ID]; !ok {
+		meta, ok := metas[mark.ID]
+		if !ok {
 			continue
 		}
 
-		if time.Since(time.Unix(mark.DeletionTime, 0)).Seconds() > f.delay.Seconds() {
-			synced.WithLabelValues(block.MarkedForDeletionMeta).Inc()
-			delete(metas, mark.ID)
+		if f.isDeletionDelayExpired(meta, mark.DeletionTime) {
+			f.filterOut(mark.ID, metas, synced)
 		}
 	}
 
 	return nil
 }
 
-func NewIgnoreNonQueryableBlocksFilter(logger log.Logger, ignoreWithin time.Duration) *IgnoreNonQueryableBlocksFilter {
+// filterOut removes the input block from metas, unless the filter runs in dry-run mode.
+func (f *IgnoreDeletionMarkFilter) filterOut(id ulid.ULID, metas map[ulid.ULID]*metadata.Meta, synced block.GaugeVec) {
+	if f.dryRun {
+		synced.WithLabelValues(WouldDeleteMeta).Inc()
+		return
+	}
+
+	synced.WithLabelValues(block.MarkedForDeletionMeta).Inc()
+	delete(metas, id)
+}
+
+// isDeletionDelayExpired returns whether the deletion delay of the tenant owning the
+// input block has expired, given the block's deletion time.
+func (f *IgnoreDeletionMarkFilter) isDeletionDelayExpired(meta *metadata.Meta, deletionTime int64) bool {
+	return time.Since(time.Unix(deletionTime, 0)).Seconds() > f.delay(blockTenantID(meta, f.userID)).Seconds()
+}
+
+// blockTenantID returns the tenant owning the input block, as read from the block's external
+// labels, or the input fallback if the label is missing.
+func blockTenantID(meta *metadata.Meta, fallback string) string {
+	if meta != nil {
+		if id, ok := meta.Thanos.Labels[cortex_tsdb.TenantIDExternalLabel]; ok && id != "" {
+			return id
+		}
+	}
+
+	return fallback
+}
+
+// NoCompactMarkFilter keeps track of blocks marked for no compaction. Blocks marked for
+// no compaction are recorded under the block.MarkedForNoCompactionMeta synced state and,
+// if configured, filtered out.
+type NoCompactMarkFilter struct {
+	logger      log.Logger
+	bkt         objstore.InstrumentedBucketReader
+	ignore      bool
+	concurrency int
+
+	mtx              sync.Mutex
+	noCompactMarkMap map[ulid.ULID]*metadata.NoCompactMark
+}
+
+// NewNoCompactMarkFilter creates NoCompactMarkFilter. If ignore is true, blocks marked
+// for no compaction are filtered out, otherwise they're still synced.
+func NewNoCompactMarkFilter(logger log.Logger, bkt objstore.InstrumentedBucketReader, ignore bool, concurrency int) *NoCompactMarkFilter {
+	return &NoCompactMarkFilter{
+		logger:      logger,
+		bkt:         bkt,
+		ignore:      ignore,
+		concurrency: concurrency,
+	}
+}
+
+// NoCompactMarkedBlocks returns blocks that were marked for no compaction.
+func (f *NoCompactMarkFilter) NoCompactMarkedBlocks() map[ulid.ULID]*metadata.NoCompactMark {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+
+	noCompactMarkMap := make(map[ulid.ULID]*metadata.NoCompactMark, len(f.noCompactMarkMap))
+	maps.Copy(noCompactMarkMap, f.noCompactMarkMap)
+
+	return noCompactMarkMap
+}
+
+// Filter implements block.MetadataFilter.
+func (f *NoCompactMarkFilter) Filter(ctx context.Context, metas map[ulid.ULID]*metadata.Meta, synced block.GaugeVec, _ block.GaugeVec) error {
+	blockIDs := make([]ulid.ULID, 0, len(metas))
+	for id := range metas {
+		blockIDs = append(blockIDs, id)
+	}
+
+	noCompactMarkMap, err := readBlockMarkers(ctx, f.logger, f.bkt, blockIDs, f.concurrency, metadata.NoCompactMarkFilename, func() *metadata.NoCompactMark {
+		return &metadata.NoCompactMark{}
+	})
+	if err != nil {
+		return errors.Wrap(err, "filter blocks marked for no compaction")
+	}
+
+	for id := range noCompactMarkMap {
+		synced.WithLabelValues(block.MarkedForNoCompactionMeta).Inc()
+		if f.ignore {
+			delete(metas, id)
+		}
+	}
+
+	f.mtx.Lock()
+	f.noCompactMarkMap = noCompactMarkMap
+	f.mtx.Unlock()
+
+	return nil
+}
+
+// NonQueryableMeta is the synced state label value for blocks filtered out because too new to be queried.
+const NonQueryableMeta = "non-queryable"
+
+// IgnoreWithinFunc returns the duration since now within which newly created blocks are
+// ignored for the given tenant.
+type IgnoreWithinFunc func(userID string) time.Duration
+
+func NewIgnoreNonQueryableBlocksFilter(logger log.Logger, ignoreWithin time.Duration, reg prometheus.Registerer) *IgnoreNonQueryableBlocksFilter {
+	return NewTenantIgnoreNonQueryableBlocksFilter(logger, "", func(string) time.Duration { return ignoreWithin }, reg)
+}
+
+// NewTenantIgnoreNonQueryableBlocksFilter creates IgnoreNonQueryableBlocksFilter resolving the
+// duration for each block through the input IgnoreWithinFunc. The tenant owning a block is read
+// from the block's external labels, falling back to the input userID if the label is missing.
+func NewTenantIgnoreNonQueryableBlocksFilter(logger log.Logger, userID string, ignoreWithin IgnoreWithinFunc, reg prometheus.Registerer) *IgnoreNonQueryableBlocksFilter {
 	return &IgnoreNonQueryableBlocksFilter{
 		logger:       logger,
+		userID:       userID,
 		ignoreWithin: ignoreWithin,
+		filtered: promauto.With(reg).NewGauge(prometheus.GaugeOpts{
+			Name: "non_queryable_blocks_filtered",
+			Help: "Number of blocks filtered out in the last sync because too new to be queried.",
+		}),
 	}
 }
 
@@ -88,20 +260,295 @@ func NewIgnoreNonQueryableBlocksFilter(logger log.Logger, ignoreWithin time.Dura
 // This has be used in conjunction with `-querier.query-store-after` with some buffer.
 type IgnoreNonQueryableBlocksFilter struct {
 	// Blocks that were created since `now() - ignoreWithin` will not be synced.
-	ignoreWithin time.Duration
+	// A duration of 0 disables the filtering for the tenant.
+	ignoreWithin IgnoreWithinFunc
+	userID       string
 	logger       log.Logger
+
+	filtered prometheus.Gauge
 }
 
 // Filter implements block.MetadataFilter.
 func (f *IgnoreNonQueryableBlocksFilter) Filter(ctx context.Context, metas map[ulid.ULID]*metadata.Meta, synced block.GaugeVec, modified block.GaugeVec) error {
-	ignoreWithin := time.Now().Add(-f.ignoreWithin).UnixMilli()
+	now := time.Now()
+	filtered := 0
 
 	for id, m := range metas {
-		if m.MinTime > ignoreWithin {
+		ignoreWithin := f.ignoreWithin(blockTenantID(m, f.userID))
+		if ignoreWithin <= 0 {
+			continue
+		}
+
+		if m.MinTime > now.Add(-ignoreWithin).UnixMilli() {
 			level.Debug(f.logger).Log("msg", "ignoring block because it won't be queried", "id", id)
+			synced.WithLabelValues(NonQueryableMeta).Inc()
 			delete(metas, id)
+			filtered++
 		}
 	}
 
+	f.filtered.Set(float64(filtered))
+
 	return nil
 }
+
+// BelowMinCompactionLevelMeta is the synced state label value for blocks filtered out because
+// their compaction level is below the configured minimum.
+const BelowMinCompactionLevelMeta = "below-min-compaction-level"
+
+// MinCompactionLevelFilter ignores blocks with a compaction level lower than the configured one.
+// This can be used to run a set of store-gateways serving only compacted blocks. Blocks with an
+// unknown compaction level (zero), such as the ones added to the bucket index before the level
+// was tracked, are kept.
+type MinCompactionLevelFilter struct {
+	logger   log.Logger
+	minLevel int
+}
+
+func NewMinCompactionLevelFilter(logger log.Logger, minLevel int) *MinCompactionLevelFilter {
+	return &MinCompactionLevelFilter{
+		logger:   logger,
+		minLevel: minLevel,
+	}
+}
+
+// Filter implements block.MetadataFilter.
+func (f *MinCompactionLevelFilter) Filter(_ context.Context, metas map[ulid.ULID]*metadata.Meta, synced block.GaugeVec, _ block.GaugeVec) error {
+	for id, m := range metas {
+		if m.Compaction.Level > 0 && m.Compaction.Level < f.minLevel {
+			level.Debug(f.logger).Log("msg", "ignoring block because its compaction level is below the minimum", "id", id, "level", m.Compaction.Level, "min_level", f.minLevel)
+			synced.WithLabelValues(BelowMinCompactionLevelMeta).Inc()
+			delete(metas, id)
+		}
+	}
+
+	return nil
+}
+
+// OutOfOrderMeta is the synced state label value for blocks filtered out because compacted
+// from out-of-order samples.
+const OutOfOrderMeta = "out-of-order"
+
+// IgnoreOutOfOrderBlocksFilter ignores blocks compacted from out-of-order samples. It implements
+// the MetadataFilterWithBucketIndex interface too, so that blocks are filtered out the same way
+// whether the bucket index is enabled or not.
+type IgnoreOutOfOrderBlocksFilter struct {
+	logger log.Logger
+}
+
+func NewIgnoreOutOfOrderBlocksFilter(logger log.Logger) *IgnoreOutOfOrderBlocksFilter {
+	return &IgnoreOutOfOrderBlocksFilter{
+		logger: logger,
+	}
+}
+
+// Filter implements block.MetadataFilter.
+func (f *IgnoreOutOfOrderBlocksFilter) Filter(_ context.Context, metas map[ulid.ULID]*metadata.Meta, synced block.GaugeVec, _ block.GaugeVec) error {
+	for id, m := range metas {
+		if m.Compaction.FromOutOfOrder() {
+			f.ignore(id, metas, synced)
+		}
+	}
+
+	return nil
+}
+
+// FilterWithBucketIndex implements MetadataFilterWithBucketIndex.
+func (f *IgnoreOutOfOrderBlocksFilter) FilterWithBucketIndex(_ context.Context, metas map[ulid.ULID]*metadata.Meta, idx *bucketindex.Index, synced block.GaugeVec) error {
+	for _, b := range idx.Blocks {
+		if _, ok := metas[b.ID]; ok && b.OutOfOrder {
+			f.ignore(b.ID, metas, synced)
+		}
+	}
+
+	return nil
+}
+
+func (f *IgnoreOutOfOrderBlocksFilter) ignore(id ulid.ULID, metas map[ulid.ULID]*metadata.Meta, synced block.GaugeVec) {
+	level.Debug(f.logger).Log("msg", "ignoring block because it has been compacted from out-of-order samples", "id", id)
+	synced.WithLabelValues(OutOfOrderMeta).Inc()
+	delete(metas, id)
+}
+
+// BlocksMaxTimeTracker is a block.MetadataFilter which doesn't filter out any block, but keeps
+// track of the newest block retained by the filters run before it. It must be the last filter
+// of the chain in order to reflect only the blocks which will be queried.
+type BlocksMaxTimeTracker struct {
+	maxTime prometheus.Gauge
+}
+
+func NewBlocksMaxTimeTracker(reg prometheus.Registerer) *BlocksMaxTimeTracker {
+	return &BlocksMaxTimeTracker{
+		maxTime: promauto.With(reg).NewGauge(prometheus.GaugeOpts{
+			Name: "blocks_last_successful_sync_max_time_seconds",
+			Help: "Unix timestamp of the maximum MaxTime across the blocks retained by the last successful sync.",
+		}),
+	}
+}
+
+// Filter implements block.MetadataFilter.
+func (f *BlocksMaxTimeTracker) Filter(_ context.Context, metas map[ulid.ULID]*metadata.Meta, _ block.GaugeVec, _ block.GaugeVec) error {
+	maxTime := int64(0)
+	for _, m := range metas {
+		maxTime = max(maxTime, m.MaxTime)
+	}
+
+	f.maxTime.Set(float64(maxTime) / 1000)
+
+	return nil
+}
+
+// SyncedBlock is a block retained by the filters of the last successful sync.
+type SyncedBlock struct {
+	ID              ulid.ULID `json:"id"`
+	MinTime         time.Time `json:"minTime"`
+	MaxTime         time.Time `json:"maxTime"`
+	CompactionLevel int       `json:"compactionLevel"`
+
+	// Whether the block index-header is loaded on the first query and unloaded once idle.
+	IndexHeaderLazyLoaded bool `json:"indexHeaderLazyLoaded"`
+}
+
+// SyncedBlocksTracker is a block.MetadataFilter which doesn't filter out any block, but keeps
+// track of the blocks retained by the filters run before it, so that they can be inspected
+// without reading the bucket. It must be the last filter of the chain in order to reflect
+// only the blocks which will be queried.
+type SyncedBlocksTracker struct {
+	indexHeaderLazyLoaded bool
+
+	mtx    sync.RWMutex
+	blocks []SyncedBlock
+}
+
+func NewSyncedBlocksTracker(indexHeaderLazyLoaded bool) *SyncedBlocksTracker {
+	return &SyncedBlocksTracker{indexHeaderLazyLoaded: indexHeaderLazyLoaded}
+}
+
+// Filter implements block.MetadataFilter.
+func (f *SyncedBlocksTracker) Filter(_ context.Context, metas map[ulid.ULID]*metadata.Meta, _ block.GaugeVec, _ block.GaugeVec) error {
+	blocks := make([]SyncedBlock, 0, len(metas))
+	for id, m := range metas {
+		blocks = append(blocks, SyncedBlock{
+			ID:                    id,
+			MinTime:               util.TimeFromMillis(m.MinTime).UTC(),
+			MaxTime:               util.TimeFromMillis(m.MaxTime).UTC(),
+			CompactionLevel:       m.Compaction.Level,
+			IndexHeaderLazyLoaded: f.indexHeaderLazyLoaded,
+		})
+	}
+
+	slices.SortFunc(blocks, func(a, b SyncedBlock) int {
+		return a.MinTime.Compare(b.MinTime)
+	})
+
+	f.mtx.Lock()
+	f.blocks = blocks
+	f.mtx.Unlock()
+
+	return nil
+}
+
+// Blocks returns the blocks retained by the last successful sync, sorted by min time.
+func (f *SyncedBlocksTracker) Blocks() []SyncedBlock {
+	f.mtx.RLock()
+	defer f.mtx.RUnlock()
+
+	return f.blocks
+}
+
+// IncompleteMeta is the synced state label value for blocks filtered out because some of their
+// files are missing or partially uploaded.
+const IncompleteMeta = "incomplete"
+
+// IgnoreIncompleteBlocksFilter ignores blocks whose index or chunk files are missing from the
+// bucket, or have a size different than the one recorded in the meta.json, e.g. because the
+// upload of the block was interrupted. The files are checked through their attributes, without
+// reading them. Blocks are immutable, so a block is checked again on each sync only until it's
+// found complete.
+type IgnoreIncompleteBlocksFilter struct {
+	logger      log.Logger
+	bkt         objstore.InstrumentedBucketReader
+	concurrency int
+
+	mtx      sync.Mutex
+	complete map[ulid.ULID]struct{}
+}
+
+func NewIgnoreIncompleteBlocksFilter(logger log.Logger, bkt objstore.InstrumentedBucketReader, concurrency int) *IgnoreIncompleteBlocksFilter {
+	return &IgnoreIncompleteBlocksFilter{
+		logger:      logger,
+		bkt:         bkt,
+		concurrency: concurrency,
+		complete:    map[ulid.ULID]struct{}{},
+	}
+}
+
+// Filter implements block.MetadataFilter.
+func (f *IgnoreIncompleteBlocksFilter) Filter(ctx context.Context, metas map[ulid.ULID]*metadata.Meta, synced block.GaugeVec, _ block.GaugeVec) error {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+
+	// Forget the blocks which have been deleted, and collect the ones to check.
+	for id := range f.complete {
+		if _, ok := metas[id]; !ok {
+			delete(f.complete, id)
+		}
+	}
+
+	toCheck := make([]*metadata.Meta, 0, len(metas))
+	for id, m := range metas {
+		if _, ok := f.complete[id]; !ok {
+			toCheck = append(toCheck, m)
+		}
+	}
+
+	var (
+		eg  errgroup.Group
+		ch  = make(chan *metadata.Meta, f.concurrency)
+		mtx sync.Mutex
+	)
+
+	for i := 0; i < f.concurrency; i++ {
+		eg.Go(func() error {
+			var lastErr error
+			for m := range ch {
+				reason, err := bucketindex.CheckBlockFiles(ctx, f.bkt, m)
+				if err != nil {
+					// Remember the last error and continue to drain the channel.
+					lastErr = err
+					continue
+				}
+
+				mtx.Lock()
+				if reason == "" {
+					f.complete[m.ULID] = struct{}{}
+				} else {
+					level.Warn(f.logger).Log("msg", "ignoring incomplete block, it will be checked again at the next sync", "block", m.ULID, "reason", reason)
+					synced.WithLabelValues(IncompleteMeta).Inc()
+					delete(metas, m.ULID)
+				}
+				mtx.Unlock()
+			}
+
+			return lastErr
+		})
+	}
+
+	// Workers scheduled, distribute blocks.
+	eg.Go(func() error {
+		defer close(ch)
+
+		for _, m := range toCheck {
+			select {
+			case ch <- m:
+				// Nothing to do.
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+
+		return nil
+	})
+
+	return errors.Wrap(eg.Wait(), "filter incomplete blocks")
+}
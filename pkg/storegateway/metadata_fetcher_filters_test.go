@@ -4,7 +4,9 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
 	"path"
+	"strings"
 	"testing"
 	"time"
 
@@ -22,21 +24,30 @@ import (
 	"github.com/prometheus/prometheus/tsdb"
 
 	"github.com/cortexproject/cortex/pkg/storage/bucket"
+	cortex_tsdb "github.com/cortexproject/cortex/pkg/storage/tsdb"
 	"github.com/cortexproject/cortex/pkg/storage/tsdb/bucketindex"
 	cortex_testutil "github.com/cortexproject/cortex/pkg/util/testutil"
 )
 
 func TestIgnoreDeletionMarkFilter_Filter(t *testing.T) {
 	t.Parallel()
-	testIgnoreDeletionMarkFilter(t, false)
+	testIgnoreDeletionMarkFilter(t, false, false)
 }
 
 func TestIgnoreDeletionMarkFilter_FilterWithBucketIndex(t *testing.T) {
 	// parallel testing causes data race
-	testIgnoreDeletionMarkFilter(t, true)
+	testIgnoreDeletionMarkFilter(t, true, false)
 }
 
-func testIgnoreDeletionMarkFilter(t *testing.T, bucketIndexEnabled bool) {
+func TestIgnoreDeletionMarkFilter_DryRun(t *testing.T) {
+	for _, bucketIndexEnabled := range []bool{false, true} {
+		t.Run(fmt.Sprintf("bucket index enabled: %t", bucketIndexEnabled), func(t *testing.T) {
+			testIgnoreDeletionMarkFilter(t, bucketIndexEnabled, true)
+		})
+	}
+}
+
+func testIgnoreDeletionMarkFilter(t *testing.T, bucketIndexEnabled, dryRun bool) {
 	// parallel testing causes data race
 	const userID = "user-1"
 
@@ -91,6 +102,10 @@ func testIgnoreDeletionMarkFilter(t *testing.T, bucketIndexEnabled bool) {
 		ulid.MustNew(3, nil): {},
 		ulid.MustNew(4, nil): {},
 	}
+	if dryRun {
+		// Blocks are never filtered out in dry-run mode.
+		expectedMetas[ulid.MustNew(2, nil)] = &metadata.Meta{}
+	}
 
 	expectedDeletionMarks := map[ulid.ULID]*metadata.DeletionMark{
 		ulid.MustNew(1, nil): shouldFetch,
@@ -100,6 +115,9 @@ func testIgnoreDeletionMarkFilter(t *testing.T, bucketIndexEnabled bool) {
 	synced := extprom.NewTxGaugeVec(nil, prometheus.GaugeOpts{Name: "synced"}, []string{"state"})
 	modified := extprom.NewTxGaugeVec(nil, prometheus.GaugeOpts{Name: "modified"}, []string{"state"})
 	f := NewIgnoreDeletionMarkFilter(logger, objstore.WithNoopInstr(userBkt), 48*time.Hour, 32)
+	if dryRun {
+		f.EnableDryRun()
+	}
 
 	if bucketIndexEnabled {
 		require.NoError(t, f.FilterWithBucketIndex(ctx, inputMetas, idx, synced))
@@ -107,11 +125,98 @@ func testIgnoreDeletionMarkFilter(t *testing.T, bucketIndexEnabled bool) {
 		require.NoError(t, f.Filter(ctx, inputMetas, synced, modified))
 	}
 
-	assert.Equal(t, 1.0, promtest.ToFloat64(synced.WithLabelValues(block.MarkedForDeletionMeta)))
+	if dryRun {
+		assert.Equal(t, 0.0, promtest.ToFloat64(synced.WithLabelValues(block.MarkedForDeletionMeta)))
+		assert.Equal(t, 1.0, promtest.ToFloat64(synced.WithLabelValues(WouldDeleteMeta)))
+	} else {
+		assert.Equal(t, 1.0, promtest.ToFloat64(synced.WithLabelValues(block.MarkedForDeletionMeta)))
+		assert.Equal(t, 0.0, promtest.ToFloat64(synced.WithLabelValues(WouldDeleteMeta)))
+	}
 	assert.Equal(t, expectedMetas, inputMetas)
 	assert.Equal(t, expectedDeletionMarks, f.DeletionMarkBlocks())
 }
 
+func TestIgnoreDeletionMarkFilter_PerTenantDelay(t *testing.T) {
+	for _, bucketIndexEnabled := range []bool{false, true} {
+		t.Run(fmt.Sprintf("bucket index enabled: %t", bucketIndexEnabled), func(t *testing.T) {
+			const userID = "user-1"
+
+			now := time.Now()
+			ctx := context.Background()
+			logger := log.NewNopLogger()
+
+			bkt, _ := cortex_testutil.PrepareFilesystemBucket(t)
+			bkt = bucketindex.BucketWithGlobalMarkers(bkt)
+			userBkt := bucket.NewUserBucketClient(userID, bkt, nil)
+
+			// All blocks have been marked for deletion 15h ago.
+			marks := map[ulid.ULID]*metadata.DeletionMark{}
+			for i := uint64(1); i <= 3; i++ {
+				mark := &metadata.DeletionMark{
+					ID:           ulid.MustNew(i, nil),
+					DeletionTime: now.Add(-15 * time.Hour).Unix(),
+					Version:      1,
+				}
+
+				var buf bytes.Buffer
+				require.NoError(t, json.NewEncoder(&buf).Encode(mark))
+				require.NoError(t, userBkt.Upload(ctx, path.Join(mark.ID.String(), metadata.DeletionMarkFilename), &buf))
+				marks[mark.ID] = mark
+			}
+
+			var idx *bucketindex.Index
+			if bucketIndexEnabled {
+				var err error
+
+				u := bucketindex.NewUpdater(bkt, userID, nil, logger)
+				idx, _, _, err = u.UpdateIndex(ctx, nil)
+				require.NoError(t, err)
+			}
+
+			metaForTenant := func(tenantID string) *metadata.Meta {
+				m := &metadata.Meta{}
+				if tenantID != "" {
+					m.Thanos.Labels = map[string]string{cortex_tsdb.TenantIDExternalLabel: tenantID}
+				}
+				return m
+			}
+
+			inputMetas := map[ulid.ULID]*metadata.Meta{
+				ulid.MustNew(1, nil): metaForTenant("user-1"),
+				ulid.MustNew(2, nil): metaForTenant("user-2"),
+				// No tenant label: the filter's tenant is used.
+				ulid.MustNew(3, nil): metaForTenant(""),
+			}
+
+			expectedMetas := map[ulid.ULID]*metadata.Meta{
+				ulid.MustNew(2, nil): metaForTenant("user-2"),
+			}
+
+			delays := map[string]time.Duration{
+				"user-1": 12 * time.Hour,
+				"user-2": 48 * time.Hour,
+			}
+
+			synced := extprom.NewTxGaugeVec(nil, prometheus.GaugeOpts{Name: "synced"}, []string{"state"})
+			modified := extprom.NewTxGaugeVec(nil, prometheus.GaugeOpts{Name: "modified"}, []string{"state"})
+			reader := NewPerBlockDeletionMarksReader(logger, objstore.WithNoopInstr(userBkt), 32)
+			f := NewTenantIgnoreDeletionMarkFilter(logger, reader, userID, func(userID string) time.Duration {
+				return delays[userID]
+			})
+
+			if bucketIndexEnabled {
+				require.NoError(t, f.FilterWithBucketIndex(ctx, inputMetas, idx, synced))
+			} else {
+				require.NoError(t, f.Filter(ctx, inputMetas, synced, modified))
+			}
+
+			assert.Equal(t, 2.0, promtest.ToFloat64(synced.WithLabelValues(block.MarkedForDeletionMeta)))
+			assert.Equal(t, expectedMetas, inputMetas)
+			assert.Equal(t, marks, f.DeletionMarkBlocks())
+		})
+	}
+}
+
 func TestIgnoreNonQueryableBlocksFilter(t *testing.T) {
 	t.Parallel()
 	now := time.Now()
@@ -169,8 +274,393 @@ func TestIgnoreNonQueryableBlocksFilter(t *testing.T) {
 	synced := extprom.NewTxGaugeVec(nil, prometheus.GaugeOpts{Name: "synced"}, []string{"state"})
 	modified := extprom.NewTxGaugeVec(nil, prometheus.GaugeOpts{Name: "modified"}, []string{"state"})
 
-	f := NewIgnoreNonQueryableBlocksFilter(logger, 3*time.Hour)
+	reg := prometheus.NewPedanticRegistry()
+	f := NewIgnoreNonQueryableBlocksFilter(logger, 3*time.Hour, reg)
+
+	require.NoError(t, f.Filter(ctx, inputMetas, synced, modified))
+	assert.Equal(t, expectedMetas, inputMetas)
+	assert.Equal(t, 1.0, promtest.ToFloat64(synced.WithLabelValues(NonQueryableMeta)))
+	assert.NoError(t, promtest.GatherAndCompare(reg, strings.NewReader(`
+		# HELP non_queryable_blocks_filtered Number of blocks filtered out in the last sync because too new to be queried.
+		# TYPE non_queryable_blocks_filtered gauge
+		non_queryable_blocks_filtered 1
+	`), "non_queryable_blocks_filtered"))
+
+	// The gauge reflects the last sync only.
+	require.NoError(t, f.Filter(ctx, inputMetas, synced, modified))
+	assert.Equal(t, 0.0, promtest.ToFloat64(f.filtered))
+}
+
+func TestIgnoreNonQueryableBlocksFilter_PerTenant(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	ctx := context.Background()
+	logger := log.NewNopLogger()
+
+	blockMeta := func(userID string, minTime time.Time) *metadata.Meta {
+		m := &metadata.Meta{
+			BlockMeta: tsdb.BlockMeta{
+				MinTime: minTime.UnixMilli(),
+				MaxTime: minTime.Add(2 * time.Hour).UnixMilli(),
+			},
+		}
+		if userID != "" {
+			m.Thanos.Labels = map[string]string{cortex_tsdb.TenantIDExternalLabel: userID}
+		}
+		return m
+	}
+
+	inputMetas := map[ulid.ULID]*metadata.Meta{
+		// user-1 ignores blocks within 1h.
+		ulid.MustNew(1, nil): blockMeta("user-1", now.Add(-30*time.Minute)),
+		ulid.MustNew(2, nil): blockMeta("user-1", now.Add(-2*time.Hour)),
+		ulid.MustNew(3, nil): blockMeta("user-1", now.Add(-4*time.Hour)),
+		// user-2 ignores blocks within 3h.
+		ulid.MustNew(4, nil): blockMeta("user-2", now.Add(-30*time.Minute)),
+		ulid.MustNew(5, nil): blockMeta("user-2", now.Add(-2*time.Hour)),
+		ulid.MustNew(6, nil): blockMeta("user-2", now.Add(-4*time.Hour)),
+		// user-3 has the filter disabled.
+		ulid.MustNew(7, nil): blockMeta("user-3", now.Add(-30*time.Minute)),
+		// The tenant falls back to the filter's tenant (user-2) if the label is missing.
+		ulid.MustNew(8, nil): blockMeta("", now.Add(-2*time.Hour)),
+	}
+
+	expectedMetas := map[ulid.ULID]*metadata.Meta{
+		ulid.MustNew(2, nil): inputMetas[ulid.MustNew(2, nil)],
+		ulid.MustNew(3, nil): inputMetas[ulid.MustNew(3, nil)],
+		ulid.MustNew(6, nil): inputMetas[ulid.MustNew(6, nil)],
+		ulid.MustNew(7, nil): inputMetas[ulid.MustNew(7, nil)],
+	}
+
+	durations := map[string]time.Duration{
+		"user-1": time.Hour,
+		"user-2": 3 * time.Hour,
+	}
+
+	synced := extprom.NewTxGaugeVec(nil, prometheus.GaugeOpts{Name: "synced"}, []string{"state"})
+	modified := extprom.NewTxGaugeVec(nil, prometheus.GaugeOpts{Name: "modified"}, []string{"state"})
+	f := NewTenantIgnoreNonQueryableBlocksFilter(logger, "user-2", func(userID string) time.Duration {
+		return durations[userID]
+	}, nil)
 
 	require.NoError(t, f.Filter(ctx, inputMetas, synced, modified))
 	assert.Equal(t, expectedMetas, inputMetas)
+	assert.Equal(t, 4.0, promtest.ToFloat64(synced.WithLabelValues(NonQueryableMeta)))
+	assert.Equal(t, 4.0, promtest.ToFloat64(f.filtered))
+}
+
+func TestMinCompactionLevelFilter(t *testing.T) {
+	t.Parallel()
+	const userID = "user-1"
+
+	now := time.Now()
+	ctx := context.Background()
+	logger := log.NewNopLogger()
+
+	bkt, _ := cortex_testutil.PrepareFilesystemBucket(t)
+	userBkt := bucket.NewUserBucketClient(userID, bkt, nil)
+
+	// Block 3 is compacted but marked for deletion beyond the delay.
+	var buf bytes.Buffer
+	require.NoError(t, json.NewEncoder(&buf).Encode(&metadata.DeletionMark{
+		ID:           ulid.MustNew(3, nil),
+		DeletionTime: now.Add(-60 * time.Hour).Unix(),
+		Version:      1,
+	}))
+	require.NoError(t, userBkt.Upload(ctx, path.Join(ulid.MustNew(3, nil).String(), metadata.DeletionMarkFilename), &buf))
+
+	metaWithLevel := func(level int) *metadata.Meta {
+		return &metadata.Meta{BlockMeta: tsdb.BlockMeta{Compaction: tsdb.BlockMetaCompaction{Level: level}}}
+	}
+
+	// Block 5 has an unknown compaction level, so it's kept.
+	inputMetas := map[ulid.ULID]*metadata.Meta{
+		ulid.MustNew(1, nil): metaWithLevel(1),
+		ulid.MustNew(2, nil): metaWithLevel(2),
+		ulid.MustNew(3, nil): metaWithLevel(3),
+		ulid.MustNew(4, nil): metaWithLevel(4),
+		ulid.MustNew(5, nil): metaWithLevel(0),
+	}
+
+	expectedMetas := map[ulid.ULID]*metadata.Meta{
+		ulid.MustNew(2, nil): metaWithLevel(2),
+		ulid.MustNew(4, nil): metaWithLevel(4),
+		ulid.MustNew(5, nil): metaWithLevel(0),
+	}
+
+	synced := extprom.NewTxGaugeVec(nil, prometheus.GaugeOpts{Name: "synced"}, []string{"state"})
+	modified := extprom.NewTxGaugeVec(nil, prometheus.GaugeOpts{Name: "modified"}, []string{"state"})
+
+	filters := []block.MetadataFilter{
+		NewIgnoreDeletionMarkFilter(logger, objstore.WithNoopInstr(userBkt), 48*time.Hour, 32),
+		NewMinCompactionLevelFilter(logger, 2),
+	}
+	for _, f := range filters {
+		require.NoError(t, f.Filter(ctx, inputMetas, synced, modified))
+	}
+
+	assert.Equal(t, expectedMetas, inputMetas)
+	assert.Equal(t, 1.0, promtest.ToFloat64(synced.WithLabelValues(BelowMinCompactionLevelMeta)))
+	assert.Equal(t, 1.0, promtest.ToFloat64(synced.WithLabelValues(block.MarkedForDeletionMeta)))
+}
+
+func TestIgnoreOutOfOrderBlocksFilter_Filter(t *testing.T) {
+	t.Parallel()
+	testIgnoreOutOfOrderBlocksFilter(t, false)
+}
+
+func TestIgnoreOutOfOrderBlocksFilter_FilterWithBucketIndex(t *testing.T) {
+	t.Parallel()
+	testIgnoreOutOfOrderBlocksFilter(t, true)
+}
+
+func testIgnoreOutOfOrderBlocksFilter(t *testing.T, bucketIndexEnabled bool) {
+	const userID = "user-1"
+
+	ctx := context.Background()
+	logger := log.NewNopLogger()
+
+	bkt, _ := cortex_testutil.PrepareFilesystemBucket(t)
+	userBkt := bucket.NewUserBucketClient(userID, bkt, nil)
+
+	// Upload blocks, where block 2 and 4 have been compacted from out-of-order samples.
+	uploadMeta := func(id ulid.ULID, outOfOrder bool) {
+		meta := metadata.Meta{
+			BlockMeta: tsdb.BlockMeta{
+				ULID:    id,
+				MinTime: 10,
+				MaxTime: 20,
+				Version: metadata.TSDBVersion1,
+			},
+			Thanos: metadata.Thanos{
+				Version: metadata.ThanosVersion1,
+				Labels:  map[string]string{cortex_tsdb.TenantIDExternalLabel: userID},
+			},
+		}
+		if outOfOrder {
+			meta.Compaction.Hints = []string{tsdb.CompactionHintFromOutOfOrder}
+		}
+
+		var buf bytes.Buffer
+		require.NoError(t, json.NewEncoder(&buf).Encode(&meta))
+		require.NoError(t, userBkt.Upload(ctx, path.Join(id.String(), block.MetaFilename), &buf))
+	}
+
+	blockIDs := []ulid.ULID{ulid.MustNew(1, nil), ulid.MustNew(2, nil), ulid.MustNew(3, nil), ulid.MustNew(4, nil)}
+	for i, id := range blockIDs {
+		uploadMeta(id, i%2 == 1)
+	}
+
+	// Build the input metas the same way the metadata fetcher does for the given code path.
+	var idx *bucketindex.Index
+	inputMetas := map[ulid.ULID]*metadata.Meta{}
+
+	if bucketIndexEnabled {
+		var err error
+
+		u := bucketindex.NewUpdater(bkt, userID, nil, logger)
+		idx, _, _, err = u.UpdateIndex(ctx, nil)
+		require.NoError(t, err)
+		require.Len(t, idx.Blocks, len(blockIDs))
+
+		for _, b := range idx.Blocks {
+			inputMetas[b.ID] = b.ThanosMeta(userID)
+		}
+	} else {
+		for _, id := range blockIDs {
+			meta, err := block.DownloadMeta(ctx, logger, userBkt, id)
+			require.NoError(t, err)
+			inputMetas[id] = &meta
+		}
+	}
+
+	synced := extprom.NewTxGaugeVec(nil, prometheus.GaugeOpts{Name: "synced"}, []string{"state"})
+	modified := extprom.NewTxGaugeVec(nil, prometheus.GaugeOpts{Name: "modified"}, []string{"state"})
+	f := NewIgnoreOutOfOrderBlocksFilter(logger)
+
+	if bucketIndexEnabled {
+		require.NoError(t, f.FilterWithBucketIndex(ctx, inputMetas, idx, synced))
+	} else {
+		require.NoError(t, f.Filter(ctx, inputMetas, synced, modified))
+	}
+
+	actualIDs := make([]ulid.ULID, 0, len(inputMetas))
+	for id := range inputMetas {
+		actualIDs = append(actualIDs, id)
+	}
+
+	assert.ElementsMatch(t, []ulid.ULID{ulid.MustNew(1, nil), ulid.MustNew(3, nil)}, actualIDs)
+	assert.Equal(t, 2.0, promtest.ToFloat64(synced.WithLabelValues(OutOfOrderMeta)))
+}
+
+func TestNoCompactMarkFilter(t *testing.T) {
+	t.Parallel()
+	const userID = "user-1"
+
+	ctx := context.Background()
+	logger := log.NewNopLogger()
+
+	bkt, _ := cortex_testutil.PrepareFilesystemBucket(t)
+	userBkt := bucket.NewUserBucketClient(userID, bkt, nil)
+
+	noCompactMark := &metadata.NoCompactMark{
+		ID:            ulid.MustNew(1, nil),
+		Version:       metadata.NoCompactMarkVersion1,
+		Details:       "index too big",
+		Reason:        metadata.IndexSizeExceedingNoCompactReason,
+		NoCompactTime: time.Now().Unix(),
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, json.NewEncoder(&buf).Encode(noCompactMark))
+	require.NoError(t, userBkt.Upload(ctx, path.Join(noCompactMark.ID.String(), metadata.NoCompactMarkFilename), &buf))
+	require.NoError(t, userBkt.Upload(ctx, path.Join(ulid.MustNew(2, nil).String(), metadata.NoCompactMarkFilename), bytes.NewBufferString("not a valid no-compact-mark.json")))
+
+	tests := map[string]struct {
+		ignore        bool
+		expectedMetas map[ulid.ULID]*metadata.Meta
+	}{
+		"should keep blocks marked for no compaction if ignore is disabled": {
+			ignore: false,
+			expectedMetas: map[ulid.ULID]*metadata.Meta{
+				ulid.MustNew(1, nil): {},
+				ulid.MustNew(2, nil): {},
+				ulid.MustNew(3, nil): {},
+			},
+		},
+		"should filter out blocks marked for no compaction if ignore is enabled": {
+			ignore: true,
+			expectedMetas: map[ulid.ULID]*metadata.Meta{
+				ulid.MustNew(2, nil): {},
+				ulid.MustNew(3, nil): {},
+			},
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			t.Parallel()
+
+			inputMetas := map[ulid.ULID]*metadata.Meta{
+				ulid.MustNew(1, nil): {},
+				ulid.MustNew(2, nil): {},
+				ulid.MustNew(3, nil): {},
+			}
+
+			synced := extprom.NewTxGaugeVec(nil, prometheus.GaugeOpts{Name: "synced"}, []string{"state"})
+			modified := extprom.NewTxGaugeVec(nil, prometheus.GaugeOpts{Name: "modified"}, []string{"state"})
+			f := NewNoCompactMarkFilter(logger, objstore.WithNoopInstr(userBkt), testData.ignore, 32)
+
+			require.NoError(t, f.Filter(ctx, inputMetas, synced, modified))
+			assert.Equal(t, testData.expectedMetas, inputMetas)
+			assert.Equal(t, 1.0, promtest.ToFloat64(synced.WithLabelValues(block.MarkedForNoCompactionMeta)))
+			assert.Equal(t, map[ulid.ULID]*metadata.NoCompactMark{noCompactMark.ID: noCompactMark}, f.NoCompactMarkedBlocks())
+		})
+	}
+}
+
+func TestIgnoreIncompleteBlocksFilter(t *testing.T) {
+	t.Parallel()
+	const userID = "user-1"
+
+	ctx := context.Background()
+	logger := log.NewNopLogger()
+
+	bkt, _ := cortex_testutil.PrepareFilesystemBucket(t)
+	userBkt := bucket.NewUserBucketClient(userID, bkt, nil)
+
+	var (
+		complete     = ulid.MustNew(1, nil)
+		missingChunk = ulid.MustNew(2, nil)
+		wrongSize    = ulid.MustNew(3, nil)
+		noFiles      = ulid.MustNew(4, nil)
+		noIndex      = ulid.MustNew(5, nil)
+	)
+
+	files := []metadata.File{
+		{RelPath: block.MetaFilename},
+		{RelPath: block.IndexFilename, SizeBytes: 5},
+		{RelPath: "chunks/000001", SizeBytes: 6},
+	}
+	newMeta := func(id ulid.ULID, files []metadata.File) *metadata.Meta {
+		m := &metadata.Meta{}
+		m.ULID = id
+		m.Thanos.Files = files
+		return m
+	}
+
+	for _, id := range []ulid.ULID{complete, missingChunk, wrongSize, noFiles} {
+		require.NoError(t, userBkt.Upload(ctx, path.Join(id.String(), block.IndexFilename), strings.NewReader("index")))
+	}
+	require.NoError(t, userBkt.Upload(ctx, path.Join(complete.String(), "chunks/000001"), strings.NewReader("chunks")))
+	require.NoError(t, userBkt.Upload(ctx, path.Join(wrongSize.String(), "chunks/000001"), strings.NewReader("chu")))
+
+	synced := extprom.NewTxGaugeVec(nil, prometheus.GaugeOpts{Name: "synced"}, []string{"state"})
+	modified := extprom.NewTxGaugeVec(nil, prometheus.GaugeOpts{Name: "modified"}, []string{"state"})
+	f := NewIgnoreIncompleteBlocksFilter(logger, objstore.WithNoopInstr(userBkt), 2)
+
+	inputMetas := map[ulid.ULID]*metadata.Meta{
+		complete:     newMeta(complete, files),
+		missingChunk: newMeta(missingChunk, files),
+		wrongSize:    newMeta(wrongSize, files),
+		noFiles:      newMeta(noFiles, nil),
+		noIndex:      newMeta(noIndex, nil),
+	}
+	require.NoError(t, f.Filter(ctx, inputMetas, synced, modified))
+	assert.Equal(t, map[ulid.ULID]*metadata.Meta{
+		complete: newMeta(complete, files),
+		noFiles:  newMeta(noFiles, nil),
+	}, inputMetas)
+	assert.Equal(t, 3.0, promtest.ToFloat64(synced.WithLabelValues(IncompleteMeta)))
+
+	// Once the upload completes, the block should be synced at the next run.
+	require.NoError(t, userBkt.Upload(ctx, path.Join(missingChunk.String(), "chunks/000001"), strings.NewReader("chunks")))
+
+	synced = extprom.NewTxGaugeVec(nil, prometheus.GaugeOpts{Name: "synced"}, []string{"state"})
+	inputMetas = map[ulid.ULID]*metadata.Meta{
+		complete:     newMeta(complete, files),
+		missingChunk: newMeta(missingChunk, files),
+		wrongSize:    newMeta(wrongSize, files),
+	}
+	require.NoError(t, f.Filter(ctx, inputMetas, synced, modified))
+	assert.Equal(t, map[ulid.ULID]*metadata.Meta{
+		complete:     newMeta(complete, files),
+		missingChunk: newMeta(missingChunk, files),
+	}, inputMetas)
+	assert.Equal(t, 1.0, promtest.ToFloat64(synced.WithLabelValues(IncompleteMeta)))
+}
+
+func TestBlocksMaxTimeTracker(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	ctx := context.Background()
+	logger := log.NewNopLogger()
+
+	inputMetas := map[ulid.ULID]*metadata.Meta{
+		ulid.MustNew(1, nil): {BlockMeta: tsdb.BlockMeta{MinTime: now.Add(-2 * time.Hour).UnixMilli(), MaxTime: now.UnixMilli()}},
+		ulid.MustNew(2, nil): {BlockMeta: tsdb.BlockMeta{MinTime: now.Add(-4 * time.Hour).UnixMilli(), MaxTime: now.Add(-2 * time.Hour).UnixMilli()}},
+		ulid.MustNew(3, nil): {BlockMeta: tsdb.BlockMeta{MinTime: now.Add(-6 * time.Hour).UnixMilli(), MaxTime: now.Add(-4 * time.Hour).UnixMilli()}},
+	}
+
+	synced := extprom.NewTxGaugeVec(nil, prometheus.GaugeOpts{Name: "synced"}, []string{"state"})
+	modified := extprom.NewTxGaugeVec(nil, prometheus.GaugeOpts{Name: "modified"}, []string{"state"})
+	tracker := NewBlocksMaxTimeTracker(nil)
+
+	// The newest block is filtered out, so it should not be taken in account.
+	filters := []block.MetadataFilter{
+		NewIgnoreNonQueryableBlocksFilter(logger, 3*time.Hour, nil),
+		tracker,
+	}
+	for _, f := range filters {
+		require.NoError(t, f.Filter(ctx, inputMetas, synced, modified))
+	}
+
+	require.Len(t, inputMetas, 2)
+	assert.Equal(t, float64(now.Add(-2*time.Hour).UnixMilli())/1000, promtest.ToFloat64(tracker.maxTime))
+
+	// No blocks retained.
+	require.NoError(t, tracker.Filter(ctx, map[ulid.ULID]*metadata.Meta{}, synced, modified))
+	assert.Equal(t, 0.0, promtest.ToFloat64(tracker.maxTime))
 }
@@ -17,6 +17,8 @@ type MetadataFetcherMetrics struct {
 	syncDuration         *prometheus.Desc
 	syncConsistencyDelay *prometheus.Desc
 	synced               *prometheus.Desc
+	nonQueryableFiltered *prometheus.Desc
+	lastSyncMaxTime      *prometheus.Desc
 
 	// Ignored:
 	// blocks_meta_modified
@@ -49,6 +51,14 @@ func NewMetadataFetcherMetrics() *MetadataFetcherMetrics {
 			"cortex_blocks_meta_synced",
 			"Reflects current state of synced blocks (over all tenants).",
 			[]string{"state"}, nil),
+		nonQueryableFiltered: prometheus.NewDesc(
+			"cortex_storegateway_non_queryable_blocks_filtered",
+			"Number of blocks filtered out in the last sync because too new to be queried.",
+			[]string{"user"}, nil),
+		lastSyncMaxTime: prometheus.NewDesc(
+			"cortex_storegateway_blocks_last_successful_sync_max_time_seconds",
+			"Unix timestamp of the maximum MaxTime across the blocks retained by the last successful sync.",
+			[]string{"user"}, nil),
 	}
 }
 
@@ -66,6 +76,8 @@ func (m *MetadataFetcherMetrics) Describe(out chan<- *prometheus.Desc) {
 	out <- m.syncDuration
 	out <- m.syncConsistencyDelay
 	out <- m.synced
+	out <- m.nonQueryableFiltered
+	out <- m.lastSyncMaxTime
 }
 
 func (m *MetadataFetcherMetrics) Collect(out chan<- prometheus.Metric) {
@@ -76,4 +88,6 @@ func (m *MetadataFetcherMetrics) Collect(out chan<- prometheus.Metric) {
 	data.SendSumOfHistograms(out, m.syncDuration, "blocks_meta_sync_duration_seconds")
 	data.SendMaxOfGauges(out, m.syncConsistencyDelay, "consistency_delay_seconds")
 	data.SendSumOfGaugesWithLabels(out, m.synced, "blocks_meta_synced", "state")
+	data.SendSumOfGaugesPerUser(out, m.nonQueryableFiltered, "non_queryable_blocks_filtered")
+	data.SendMaxOfGaugesPerUser(out, m.lastSyncMaxTime, "blocks_last_successful_sync_max_time_seconds")
 }
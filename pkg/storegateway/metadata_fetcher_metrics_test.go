@@ -51,6 +51,18 @@ func TestMetadataFetcherMetrics(t *testing.T) {
 		cortex_blocks_meta_synced{state="corrupted-meta-json"} 75
 		cortex_blocks_meta_synced{state="loaded"} 90
 		cortex_blocks_meta_synced{state="too-fresh"} 105
+
+		# HELP cortex_storegateway_non_queryable_blocks_filtered Number of blocks filtered out in the last sync because too new to be queried.
+		# TYPE cortex_storegateway_non_queryable_blocks_filtered gauge
+		cortex_storegateway_non_queryable_blocks_filtered{user="user1"} 24
+		cortex_storegateway_non_queryable_blocks_filtered{user="user2"} 40
+		cortex_storegateway_non_queryable_blocks_filtered{user="user3"} 56
+
+		# HELP cortex_storegateway_blocks_last_successful_sync_max_time_seconds Unix timestamp of the maximum MaxTime across the blocks retained by the last successful sync.
+		# TYPE cortex_storegateway_blocks_last_successful_sync_max_time_seconds gauge
+		cortex_storegateway_blocks_last_successful_sync_max_time_seconds{user="user1"} 27
+		cortex_storegateway_blocks_last_successful_sync_max_time_seconds{user="user2"} 45
+		cortex_storegateway_blocks_last_successful_sync_max_time_seconds{user="user3"} 63
 `))
 	require.NoError(t, err)
 }
@@ -67,6 +79,8 @@ func populateMetadataFetcherMetrics(base float64) *prometheus.Registry {
 	m.synced.WithLabelValues("corrupted-meta-json").Set(base * 5)
 	m.synced.WithLabelValues("loaded").Set(base * 6)
 	m.synced.WithLabelValues("too-fresh").Set(base * 7)
+	m.nonQueryableFiltered.Set(base * 8)
+	m.lastSyncMaxTime.Set(base * 9)
 
 	return reg
 }
@@ -77,6 +91,8 @@ type metadataFetcherMetricsMock struct {
 	syncDuration         prometheus.Histogram
 	syncConsistencyDelay prometheus.Gauge
 	synced               *prometheus.GaugeVec
+	nonQueryableFiltered prometheus.Gauge
+	lastSyncMaxTime      prometheus.Gauge
 }
 
 func newMetadataFetcherMetricsMock(reg prometheus.Registerer) *metadataFetcherMetricsMock {
@@ -107,6 +123,14 @@ func newMetadataFetcherMetricsMock(reg prometheus.Registerer) *metadataFetcherMe
 		Name:      "synced",
 		Help:      "Number of block metadata synced",
 	}, []string{"state"})
+	m.nonQueryableFiltered = promauto.With(reg).NewGauge(prometheus.GaugeOpts{
+		Name: "non_queryable_blocks_filtered",
+		Help: "Number of blocks filtered out in the last sync because too new to be queried.",
+	})
+	m.lastSyncMaxTime = promauto.With(reg).NewGauge(prometheus.GaugeOpts{
+		Name: "blocks_last_successful_sync_max_time_seconds",
+		Help: "Unix timestamp of the maximum MaxTime across the blocks retained by the last successful sync.",
+	})
 
 	return &m
 }
@@ -221,6 +221,16 @@ func (u *ParquetBucketStores) InitialSync(ctx context.Context) error {
 	return nil
 }
 
+// SyncUserBlocks implements BucketStores. The parquet bucket stores don't sync blocks.
+func (u *ParquetBucketStores) SyncUserBlocks(ctx context.Context, userID string) error {
+	return nil
+}
+
+// SyncedBlocks implements BucketStores. The parquet bucket stores don't sync blocks.
+func (u *ParquetBucketStores) SyncedBlocks(userID string) map[string][]SyncedBlock {
+	return map[string][]SyncedBlock{}
+}
+
 func (u *ParquetBucketStores) getStoreError(userID string) error {
 	u.storesErrorsMu.RLock()
 	defer u.storesErrorsMu.RUnlock()
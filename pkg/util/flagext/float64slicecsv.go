@@ -0,0 +1,58 @@
+package flagext
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Float64SliceCSV is a slice of float64 that is parsed from a comma-separated string.
+// It implements flag.Value and yaml Marshalers.
+type Float64SliceCSV []float64
+
+// String implements flag.Value
+func (v Float64SliceCSV) String() string {
+	values := make([]string, 0, len(v))
+	for _, f := range v {
+		values = append(values, strconv.FormatFloat(f, 'g', -1, 64))
+	}
+
+	return strings.Join(values, ",")
+}
+
+// Set implements flag.Value
+func (v *Float64SliceCSV) Set(s string) error {
+	values := Float64SliceCSV{}
+	for part := range strings.SplitSeq(s, ",") {
+		f, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return errors.Wrapf(err, "float: %s", part)
+		}
+		values = append(values, f)
+	}
+
+	*v = values
+	return nil
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler.
+func (v *Float64SliceCSV) UnmarshalYAML(unmarshal func(any) error) error {
+	var s string
+	if err := unmarshal(&s); err != nil {
+		return err
+	}
+
+	// An empty string means no values has been configured.
+	if s == "" {
+		*v = nil
+		return nil
+	}
+
+	return v.Set(s)
+}
+
+// MarshalYAML implements yaml.Marshaler.
+func (v Float64SliceCSV) MarshalYAML() (any, error) {
+	return v.String(), nil
+}
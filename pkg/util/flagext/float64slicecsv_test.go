@@ -0,0 +1,35 @@
+package flagext
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v2"
+)
+
+func Test_Float64SliceCSV(t *testing.T) {
+	type TestStruct struct {
+		CSV Float64SliceCSV `yaml:"csv"`
+	}
+
+	var testStruct TestStruct
+	s := "0.1,0.5,1,2.5"
+	require.NoError(t, testStruct.CSV.Set(s))
+
+	assert.Equal(t, []float64{0.1, 0.5, 1, 2.5}, []float64(testStruct.CSV))
+	assert.Equal(t, s, testStruct.CSV.String())
+
+	expected := []byte(`csv: 0.1,0.5,1,2.5
+`)
+
+	actual, err := yaml.Marshal(testStruct)
+	require.NoError(t, err)
+	assert.Equal(t, expected, actual)
+
+	var testStruct2 TestStruct
+	require.NoError(t, yaml.Unmarshal(expected, &testStruct2))
+	assert.Equal(t, testStruct, testStruct2)
+
+	assert.Error(t, testStruct.CSV.Set("1,foo"))
+}
@@ -26,6 +26,17 @@ import (
 
 const messageSizeLargerErrFmt = "received message larger than max (%d vs %d)"
 
+// MessageSizeLargerError is returned when the (decompressed) size of a message is larger than
+// the max allowed size.
+type MessageSizeLargerError struct {
+	Size    int
+	MaxSize int
+}
+
+func (e *MessageSizeLargerError) Error() string {
+	return fmt.Sprintf(messageSizeLargerErrFmt, e.Size, e.MaxSize)
+}
+
 // IsRequestBodyTooLarge returns true if the error is "http: request body too large".
 func IsRequestBodyTooLarge(err error) bool {
 	return err != nil && strings.Contains(err.Error(), "http: request body too large")
@@ -182,11 +193,11 @@ func ParseProtoReader(ctx context.Context, reader io.Reader, expectedSize, maxSi
 func decompressRequest(reader io.Reader, expectedSize, maxSize int, compression CompressionType, sp opentracing.Span) (body []byte, err error) {
 	defer func() {
 		if err != nil && len(body) > maxSize {
-			err = fmt.Errorf(messageSizeLargerErrFmt, len(body), maxSize)
+			err = &MessageSizeLargerError{Size: len(body), MaxSize: maxSize}
 		}
 	}()
 	if expectedSize > maxSize {
-		return nil, fmt.Errorf(messageSizeLargerErrFmt, expectedSize, maxSize)
+		return nil, &MessageSizeLargerError{Size: expectedSize, MaxSize: maxSize}
 	}
 	buffer, ok := tryBufferFromReader(reader)
 	if ok {
@@ -232,7 +243,7 @@ func decompressFromReader(reader io.Reader, expectedSize, maxSize int, compressi
 
 func decompressFromBuffer(buffer *bytes.Buffer, maxSize int, compression CompressionType, sp opentracing.Span) ([]byte, error) {
 	if len(buffer.Bytes()) > maxSize {
-		return nil, fmt.Errorf(messageSizeLargerErrFmt, len(buffer.Bytes()), maxSize)
+		return nil, &MessageSizeLargerError{Size: len(buffer.Bytes()), MaxSize: maxSize}
 	}
 	switch compression {
 	case NoCompression:
@@ -247,7 +258,7 @@ func decompressFromBuffer(buffer *bytes.Buffer, maxSize int, compression Compres
 			return nil, err
 		}
 		if size > maxSize {
-			return nil, fmt.Errorf(messageSizeLargerErrFmt, size, maxSize)
+			return nil, &MessageSizeLargerError{Size: size, MaxSize: maxSize}
 		}
 		body, err := snappy.Decode(nil, buffer.Bytes())
 		if err != nil {
@@ -851,6 +851,19 @@ func (r *UserRegistries) softRemoveUserRegistry(ur *UserRegistry) bool {
 	return false
 }
 
+// GetRegistryForUser returns the registry of the given user, or nil if the user has no registry.
+func (r *UserRegistries) GetRegistryForUser(user string) *prometheus.Registry {
+	r.regsMu.Lock()
+	defer r.regsMu.Unlock()
+
+	for _, ur := range r.regs {
+		if ur.user == user && ur.reg != nil {
+			return ur.reg
+		}
+	}
+	return nil
+}
+
 // Registries returns a copy of the user registries list.
 func (r *UserRegistries) Registries() []UserRegistry {
 	r.regsMu.Lock()
@@ -3,8 +3,11 @@ package net
 import (
 	"context"
 	"net"
+	"strings"
 	"syscall"
 
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
 	"github.com/pkg/errors"
 
 	"github.com/cortexproject/cortex/pkg/util/flagext"
@@ -16,33 +19,49 @@ var errInvalidAddress = errors.New("invalid address")
 type FirewallDialerConfigProvider interface {
 	BlockCIDRNetworks() []flagext.CIDR
 	BlockPrivateAddresses() bool
+	BlockHosts() []string
+	AllowCIDRNetworks() []flagext.CIDR
+	AllowHosts() []string
 }
 
 // FirewallDialer is a net dialer which integrates a firewall to block specific addresses.
+// An address is blocked if its host or IP matches a block rule, or if any allow rule is
+// configured and neither its host nor its IP matches one.
 type FirewallDialer struct {
-	parent      *net.Dialer
 	cfgProvider FirewallDialerConfigProvider
+	logger      log.Logger
 }
 
-func NewFirewallDialer(cfgProvider FirewallDialerConfigProvider) *FirewallDialer {
-	d := &FirewallDialer{cfgProvider: cfgProvider}
-	d.parent = &net.Dialer{Control: d.control}
-	return d
+func NewFirewallDialer(cfgProvider FirewallDialerConfigProvider, logger log.Logger) *FirewallDialer {
+	return &FirewallDialer{cfgProvider: cfgProvider, logger: logger}
 }
 
 func (d *FirewallDialer) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
-	return d.parent.DialContext(ctx, network, address)
-}
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, errInvalidAddress
+	}
 
-func (d *FirewallDialer) control(_, address string, _ syscall.RawConn) error {
-	blockPrivateAddresses := d.cfgProvider.BlockPrivateAddresses()
-	blockCIDRNetworks := d.cfgProvider.BlockCIDRNetworks()
+	// The host rules are checked before the DNS resolution, the IP rules once the address is resolved.
+	hostAllowed, err := checkHost(d.cfgProvider, host)
+	if err == nil {
+		dialer := &net.Dialer{Control: func(_, address string, _ syscall.RawConn) error {
+			return d.control(address, hostAllowed)
+		}}
 
-	// Skip any control if no firewall has been configured.
-	if !blockPrivateAddresses && len(blockCIDRNetworks) == 0 {
-		return nil
+		var conn net.Conn
+		if conn, err = dialer.DialContext(ctx, network, address); err == nil {
+			return conn, nil
+		}
+	}
+
+	if errors.Is(err, errBlockedAddress) {
+		level.Warn(d.logger).Log("msg", "blocked connection to a disallowed address", "target", address, "err", err)
 	}
+	return nil, err
+}
 
+func (d *FirewallDialer) control(address string, hostAllowed bool) error {
 	host, _, err := net.SplitHostPort(address)
 	if err != nil {
 		return errInvalidAddress
@@ -54,17 +73,78 @@ func (d *FirewallDialer) control(_, address string, _ syscall.RawConn) error {
 		return errBlockedAddress
 	}
 
-	if blockPrivateAddresses && (isPrivate(ip) || isLocal(ip)) {
-		return errBlockedAddress
+	return checkIP(d.cfgProvider, ip, hostAllowed)
+}
+
+// CheckFirewallHost returns an error if the host (a DNS name or an IP) is blocked by the firewall.
+// The IP rules are only checked if the host is an IP, because DNS names can resolve to different
+// IPs over time: the resolved IPs are checked by the FirewallDialer on each connection.
+func CheckFirewallHost(cfgProvider FirewallDialerConfigProvider, host string) error {
+	hostAllowed, err := checkHost(cfgProvider, host)
+	if err != nil {
+		return err
+	}
+
+	ip := net.ParseIP(strings.Trim(host, "[]"))
+	if ip == nil {
+		if !hostAllowed && len(cfgProvider.AllowHosts()) > 0 && len(cfgProvider.AllowCIDRNetworks()) == 0 {
+			return errors.Wrapf(errBlockedAddress, "host %s is not allowed", host)
+		}
+		return nil
 	}
 
-	for _, cidr := range blockCIDRNetworks {
+	return checkIP(cfgProvider, ip, hostAllowed)
+}
+
+// checkHost returns an error if the host is blocked by the host rules, and whether
+// it's explicitly allowed by them.
+func checkHost(cfgProvider FirewallDialerConfigProvider, host string) (bool, error) {
+	if matchHosts(host, cfgProvider.BlockHosts()) {
+		return false, errors.Wrapf(errBlockedAddress, "host %s is blocked", host)
+	}
+	return matchHosts(host, cfgProvider.AllowHosts()), nil
+}
+
+// checkIP returns an error if the IP is blocked by the IP rules, or if it's not allowed
+// by the allow rules while the host isn't explicitly allowed.
+func checkIP(cfgProvider FirewallDialerConfigProvider, ip net.IP, hostAllowed bool) error {
+	if cfgProvider.BlockPrivateAddresses() && (isPrivate(ip) || isLocal(ip)) {
+		return errors.Wrapf(errBlockedAddress, "private address %s is blocked", ip)
+	}
+
+	for _, cidr := range cfgProvider.BlockCIDRNetworks() {
 		if cidr.Value.Contains(ip) {
-			return errBlockedAddress
+			return errors.Wrapf(errBlockedAddress, "address %s is blocked", ip)
 		}
 	}
 
-	return nil
+	allowCIDRNetworks := cfgProvider.AllowCIDRNetworks()
+	if hostAllowed || (len(allowCIDRNetworks) == 0 && len(cfgProvider.AllowHosts()) == 0) {
+		return nil
+	}
+	for _, cidr := range allowCIDRNetworks {
+		if cidr.Value.Contains(ip) {
+			return nil
+		}
+	}
+	return errors.Wrapf(errBlockedAddress, "address %s is not allowed", ip)
+}
+
+// matchHosts returns whether the host matches any of the patterns. A pattern is either
+// a host name, or *.domain to match all the subdomains of the domain.
+func matchHosts(host string, patterns []string) bool {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	for _, pattern := range patterns {
+		pattern = strings.ToLower(pattern)
+		if suffix, ok := strings.CutPrefix(pattern, "*"); ok {
+			if strings.HasPrefix(suffix, ".") && strings.HasSuffix(host, suffix) {
+				return true
+			}
+		} else if host == pattern {
+			return true
+		}
+	}
+	return false
 }
 
 func isLocal(ip net.IP) bool {
@@ -8,6 +8,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/go-kit/log"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
@@ -71,11 +72,45 @@ func TestFirewallDialer(t *testing.T) {
 				{"::ffff:172.217.168.78", true},     // IPv6 mapped v4 blocked
 			},
 		},
+		"should support blocking hosts": {
+			cfg: firewallCfgProvider{
+				blockHosts: []string{"localhost", "*.internal.example.com"},
+			},
+			cases: []testCase{
+				{"localhost", true},
+				{"LOCALHOST", true},
+				{"127.0.0.1", false},
+				{"api.internal.example.com", true},
+				{"internal.example.com", false},
+			},
+		},
+		"should only allow the allowed CIDRs and hosts": {
+			cfg: firewallCfgProvider{
+				allowCIDRNetworks: []flagext.CIDR{blockedCIDR},
+				allowHosts:        []string{"localhost"},
+			},
+			cases: []testCase{
+				{"localhost", false},
+				{"127.0.0.1", true},
+				{"10.0.0.1", true},
+				{"172.217.168.78", false},
+				{"::ffff:172.217.168.78", false},
+			},
+		},
+		"should give precedence to the block rules over the allow rules": {
+			cfg: firewallCfgProvider{
+				blockPrivateAddresses: true,
+				allowHosts:            []string{"localhost"},
+			},
+			cases: []testCase{
+				{"localhost", true},
+			},
+		},
 	}
 
 	for testName, testData := range tests {
 		t.Run(testName, func(t *testing.T) {
-			d := NewFirewallDialer(testData.cfg)
+			d := NewFirewallDialer(testData.cfg, log.NewNopLogger())
 
 			for _, tc := range testData.cases {
 				t.Run(fmt.Sprintf("address: %s", tc.address), func(t *testing.T) {
@@ -137,6 +172,9 @@ func TestIsPrivate(t *testing.T) {
 type firewallCfgProvider struct {
 	blockCIDRNetworks     []flagext.CIDR
 	blockPrivateAddresses bool
+	blockHosts            []string
+	allowCIDRNetworks     []flagext.CIDR
+	allowHosts            []string
 }
 
 func (p firewallCfgProvider) BlockCIDRNetworks() []flagext.CIDR {
@@ -146,3 +184,78 @@ func (p firewallCfgProvider) BlockCIDRNetworks() []flagext.CIDR {
 func (p firewallCfgProvider) BlockPrivateAddresses() bool {
 	return p.blockPrivateAddresses
 }
+
+func (p firewallCfgProvider) BlockHosts() []string {
+	return p.blockHosts
+}
+
+func (p firewallCfgProvider) AllowCIDRNetworks() []flagext.CIDR {
+	return p.allowCIDRNetworks
+}
+
+func (p firewallCfgProvider) AllowHosts() []string {
+	return p.allowHosts
+}
+
+func TestCheckFirewallHost(t *testing.T) {
+	allowedCIDR := flagext.CIDR{}
+	require.NoError(t, allowedCIDR.Set("172.217.168.64/28"))
+
+	tests := map[string]struct {
+		cfg           firewallCfgProvider
+		host          string
+		expectBlocked bool
+	}{
+		"no firewall config": {
+			cfg:  firewallCfgProvider{},
+			host: "127.0.0.1",
+		},
+		"private IP blocked": {
+			cfg:           firewallCfgProvider{blockPrivateAddresses: true},
+			host:          "10.0.0.1",
+			expectBlocked: true,
+		},
+		"private addresses blocked but DNS name resolved on send": {
+			cfg:  firewallCfgProvider{blockPrivateAddresses: true},
+			host: "localhost",
+		},
+		"blocked host": {
+			cfg:           firewallCfgProvider{blockHosts: []string{"*.svc.cluster.local"}},
+			host:          "ingester.cortex.svc.cluster.local",
+			expectBlocked: true,
+		},
+		"host not in the allowed hosts": {
+			cfg:           firewallCfgProvider{allowHosts: []string{"hooks.slack.com"}},
+			host:          "example.com",
+			expectBlocked: true,
+		},
+		"host in the allowed hosts": {
+			cfg:  firewallCfgProvider{allowHosts: []string{"hooks.slack.com"}},
+			host: "hooks.slack.com",
+		},
+		"DNS name may resolve in the allowed CIDRs": {
+			cfg:  firewallCfgProvider{allowHosts: []string{"hooks.slack.com"}, allowCIDRNetworks: []flagext.CIDR{allowedCIDR}},
+			host: "example.com",
+		},
+		"IP not in the allowed CIDRs": {
+			cfg:           firewallCfgProvider{allowCIDRNetworks: []flagext.CIDR{allowedCIDR}},
+			host:          "10.0.0.1",
+			expectBlocked: true,
+		},
+		"IP in the allowed CIDRs": {
+			cfg:  firewallCfgProvider{allowCIDRNetworks: []flagext.CIDR{allowedCIDR}},
+			host: "172.217.168.78",
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			err := CheckFirewallHost(testData.cfg, testData.host)
+			if testData.expectBlocked {
+				assert.ErrorIs(t, err, errBlockedAddress)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -36,7 +36,8 @@ const (
 )
 
 // OTLPHandler is a http.Handler which accepts OTLP metrics.
-func OTLPHandler(maxRecvMsgSize int, overrides *validation.Overrides, cfg distributor.OTLPConfig, sourceIPs *middleware.SourceIPExtractor, push Func, requestTotal *prometheus.CounterVec) http.Handler {
+// The delta temporality metrics are converted to cumulative by deltaConverter, if not nil and enabled for the tenant.
+func OTLPHandler(maxRecvMsgSize int, overrides *validation.Overrides, cfg distributor.OTLPConfig, sourceIPs *middleware.SourceIPExtractor, push Func, requestTotal *prometheus.CounterVec, deltaConverter *DeltaToCumulativeConverter) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		ctx := r.Context()
 		logger := util_log.WithContext(ctx, util_log.Logger)
@@ -70,6 +71,10 @@ func OTLPHandler(maxRecvMsgSize int, overrides *validation.Overrides, cfg distri
 			SkipLabelNameValidation: false,
 		}
 
+		if deltaConverter != nil && overrides.OTLPConvertDeltaToCumulative(userID) {
+			deltaConverter.Convert(userID, req.Metrics())
+		}
+
 		// otlp to prompb TimeSeries
 		promTsList, promMetadata, err := convertToPromTS(r.Context(), req.Metrics(), cfg, overrides, userID, logger)
 		if err != nil && len(promTsList) == 0 {
@@ -0,0 +1,309 @@
+package push
+
+import (
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/cespare/xxhash/v2"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+)
+
+// DeltaToCumulativeConverter converts delta temporality OTLP sums and histograms to cumulative
+// ones, keeping the running total of each series in memory. The state is local to the distributor,
+// so the deltas of a series must be always sent to the same distributor to be accumulated correctly.
+// The running total of a series restarts from zero (which is seen as a counter reset) when the
+// distributor restarts, when the series state is evicted after not receiving any data point for the
+// state TTL, and when the bucket boundaries of a histogram change. The state is kept per tenant, and
+// the data points of the new series of a tenant are dropped once the tenant reaches the max number
+// of series.
+type DeltaToCumulativeConverter struct {
+	maxSeries int
+	stateTTL  time.Duration
+
+	// The lock only protects the tenants map, while the series of each tenant are protected by the tenant lock.
+	mtx       sync.Mutex
+	tenants   map[string]*deltaTenantState
+	lastPurge time.Time
+
+	convertedPoints prometheus.Counter
+	droppedPoints   prometheus.Counter
+	evictedSeries   prometheus.Counter
+	trackedSeries   prometheus.Gauge
+}
+
+type deltaTenantState struct {
+	// Protected by the converter lock.
+	lastSeen time.Time
+
+	mtx       sync.Mutex
+	series    map[uint64]*deltaSeriesState
+	lastPurge time.Time
+}
+
+type deltaSeriesState struct {
+	lastSeen  time.Time
+	start     pcommon.Timestamp
+	timestamp pcommon.Timestamp
+
+	// Sums.
+	value float64
+
+	// Histograms.
+	count   uint64
+	sum     float64
+	bounds  []float64
+	buckets []uint64
+}
+
+// NewDeltaToCumulativeConverter makes a new DeltaToCumulativeConverter.
+func NewDeltaToCumulativeConverter(maxSeries int, stateTTL time.Duration, reg prometheus.Registerer) *DeltaToCumulativeConverter {
+	return &DeltaToCumulativeConverter{
+		maxSeries: maxSeries,
+		stateTTL:  stateTTL,
+		tenants:   map[string]*deltaTenantState{},
+		lastPurge: time.Now(),
+		convertedPoints: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_distributor_otlp_delta_to_cumulative_converted_points_total",
+			Help: "Total number of OTLP delta temporality data points converted to cumulative.",
+		}),
+		droppedPoints: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_distributor_otlp_delta_to_cumulative_dropped_points_total",
+			Help: "Total number of OTLP delta temporality data points dropped because out of order or because the max number of tracked series has been reached.",
+		}),
+		evictedSeries: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_distributor_otlp_delta_to_cumulative_evicted_series_total",
+			Help: "Total number of series whose delta to cumulative state has been evicted after the state TTL.",
+		}),
+		trackedSeries: promauto.With(reg).NewGauge(prometheus.GaugeOpts{
+			Name: "cortex_distributor_otlp_delta_to_cumulative_series",
+			Help: "Number of series whose delta to cumulative state is currently tracked.",
+		}),
+	}
+}
+
+// Convert converts in place the delta temporality sums and histograms of the input metrics to cumulative.
+func (c *DeltaToCumulativeConverter) Convert(userID string, md pmetric.Metrics) {
+	now := time.Now()
+	tenant := c.getOrCreateTenant(userID, now)
+
+	tenant.mtx.Lock()
+	defer tenant.mtx.Unlock()
+
+	c.purgeExpiredSeries(tenant, now)
+
+	for _, rm := range md.ResourceMetrics().All() {
+		for _, sm := range rm.ScopeMetrics().All() {
+			for _, metric := range sm.Metrics().All() {
+				switch metric.Type() {
+				case pmetric.MetricTypeSum:
+					sum := metric.Sum()
+					if sum.AggregationTemporality() != pmetric.AggregationTemporalityDelta {
+						continue
+					}
+					sum.DataPoints().RemoveIf(func(dp pmetric.NumberDataPoint) bool {
+						key := deltaSeriesKey(rm.Resource(), sm.Scope(), metric, dp.Attributes())
+						return !c.accumulateSum(tenant, key, now, dp)
+					})
+					sum.SetAggregationTemporality(pmetric.AggregationTemporalityCumulative)
+
+				case pmetric.MetricTypeHistogram:
+					histogram := metric.Histogram()
+					if histogram.AggregationTemporality() != pmetric.AggregationTemporalityDelta {
+						continue
+					}
+					histogram.DataPoints().RemoveIf(func(dp pmetric.HistogramDataPoint) bool {
+						key := deltaSeriesKey(rm.Resource(), sm.Scope(), metric, dp.Attributes())
+						return !c.accumulateHistogram(tenant, key, now, dp)
+					})
+					histogram.SetAggregationTemporality(pmetric.AggregationTemporalityCumulative)
+				}
+			}
+		}
+	}
+}
+
+// getOrCreateTenant returns the state of the tenant, creating it if it doesn't exist.
+func (c *DeltaToCumulativeConverter) getOrCreateTenant(userID string, now time.Time) *deltaTenantState {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	c.purgeExpiredTenants(now)
+
+	tenant, ok := c.tenants[userID]
+	if !ok {
+		tenant = &deltaTenantState{series: map[uint64]*deltaSeriesState{}, lastPurge: now}
+		c.tenants[userID] = tenant
+	}
+	tenant.lastSeen = now
+	return tenant
+}
+
+// accumulateSum adds the data point value to the series running total and replaces the data point
+// value with it. Returns false if the data point should be dropped.
+func (c *DeltaToCumulativeConverter) accumulateSum(tenant *deltaTenantState, key uint64, now time.Time, dp pmetric.NumberDataPoint) bool {
+	state, ok := c.getOrCreateState(tenant, key, now, dp.StartTimestamp(), dp.Timestamp())
+	if !ok {
+		return false
+	}
+
+	if !dp.Flags().NoRecordedValue() {
+		switch dp.ValueType() {
+		case pmetric.NumberDataPointValueTypeInt:
+			state.value += float64(dp.IntValue())
+		case pmetric.NumberDataPointValueTypeDouble:
+			state.value += dp.DoubleValue()
+		}
+	}
+
+	dp.SetStartTimestamp(state.start)
+	dp.SetDoubleValue(state.value)
+	c.convertedPoints.Inc()
+	return true
+}
+
+// accumulateHistogram adds the data point count, sum and buckets to the series running totals and
+// replaces the data point ones with them. Returns false if the data point should be dropped.
+func (c *DeltaToCumulativeConverter) accumulateHistogram(tenant *deltaTenantState, key uint64, now time.Time, dp pmetric.HistogramDataPoint) bool {
+	state, ok := c.getOrCreateState(tenant, key, now, dp.StartTimestamp(), dp.Timestamp())
+	if !ok {
+		return false
+	}
+
+	// The buckets can't be accumulated if the boundaries changed, so the running totals restart.
+	bounds := dp.ExplicitBounds().AsRaw()
+	if !slices.Equal(state.bounds, bounds) || len(state.buckets) != dp.BucketCounts().Len() {
+		state.start = deltaStartTimestamp(dp.StartTimestamp(), dp.Timestamp())
+		state.count, state.sum = 0, 0
+		state.bounds = bounds
+		state.buckets = make([]uint64, dp.BucketCounts().Len())
+	}
+
+	if !dp.Flags().NoRecordedValue() {
+		state.count += dp.Count()
+		state.sum += dp.Sum()
+		for i, count := range dp.BucketCounts().All() {
+			state.buckets[i] += count
+		}
+	}
+
+	dp.SetStartTimestamp(state.start)
+	dp.SetCount(state.count)
+	if dp.HasSum() {
+		dp.SetSum(state.sum)
+	}
+	dp.BucketCounts().FromRaw(state.buckets)
+
+	// Min and max can't be accumulated.
+	dp.RemoveMin()
+	dp.RemoveMax()
+
+	c.convertedPoints.Inc()
+	return true
+}
+
+// getOrCreateState returns the state of the series, creating it if it doesn't exist. Returns false if the
+// data point is older than the last one accumulated or if the tenant reached the max number of series.
+func (c *DeltaToCumulativeConverter) getOrCreateState(tenant *deltaTenantState, key uint64, now time.Time, start, timestamp pcommon.Timestamp) (*deltaSeriesState, bool) {
+	state, ok := tenant.series[key]
+	if !ok {
+		if len(tenant.series) >= c.maxSeries {
+			c.droppedPoints.Inc()
+			return nil, false
+		}
+		state = &deltaSeriesState{start: deltaStartTimestamp(start, timestamp)}
+		tenant.series[key] = state
+		c.trackedSeries.Inc()
+	} else if timestamp <= state.timestamp {
+		c.droppedPoints.Inc()
+		return nil, false
+	}
+
+	state.lastSeen = now
+	state.timestamp = timestamp
+	return state, true
+}
+
+// deltaStartTimestamp returns the start timestamp of the cumulative series, falling back
+// to the data point timestamp if the delta start timestamp isn't set.
+func deltaStartTimestamp(start, timestamp pcommon.Timestamp) pcommon.Timestamp {
+	if start == 0 {
+		return timestamp
+	}
+	return start
+}
+
+// purgeExpiredTenants removes the tenants which haven't sent any data point for the state TTL.
+// It must be called with the converter lock held.
+func (c *DeltaToCumulativeConverter) purgeExpiredTenants(now time.Time) {
+	if now.Sub(c.lastPurge) < c.stateTTL/2 {
+		return
+	}
+	c.lastPurge = now
+
+	for userID, tenant := range c.tenants {
+		if now.Sub(tenant.lastSeen) <= c.stateTTL {
+			continue
+		}
+
+		tenant.mtx.Lock()
+		c.evictedSeries.Add(float64(len(tenant.series)))
+		c.trackedSeries.Sub(float64(len(tenant.series)))
+		tenant.mtx.Unlock()
+
+		delete(c.tenants, userID)
+	}
+}
+
+// purgeExpiredSeries removes the series of the tenant which haven't received any data point for
+// the state TTL. It must be called with the tenant lock held.
+func (c *DeltaToCumulativeConverter) purgeExpiredSeries(tenant *deltaTenantState, now time.Time) {
+	// The whole tenant state is scanned, so it's not done more frequently than half of the TTL.
+	if now.Sub(tenant.lastPurge) < c.stateTTL/2 {
+		return
+	}
+	tenant.lastPurge = now
+
+	for key, state := range tenant.series {
+		if now.Sub(state.lastSeen) > c.stateTTL {
+			delete(tenant.series, key)
+			c.evictedSeries.Inc()
+			c.trackedSeries.Dec()
+		}
+	}
+}
+
+// deltaSeriesKey returns the hash identifying the series of a data point within a tenant.
+func deltaSeriesKey(resource pcommon.Resource, scope pcommon.InstrumentationScope, metric pmetric.Metric, attributes pcommon.Map) uint64 {
+	h := xxhash.New()
+	writeHashAttributes(h, resource.Attributes())
+	writeHashString(h, scope.Name())
+	writeHashString(h, scope.Version())
+	writeHashString(h, metric.Name())
+	writeHashString(h, metric.Unit())
+	writeHashAttributes(h, attributes)
+	return h.Sum64()
+}
+
+func writeHashAttributes(h *xxhash.Digest, attributes pcommon.Map) {
+	keys := make([]string, 0, attributes.Len())
+	for key := range attributes.All() {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	for _, key := range keys {
+		value, _ := attributes.Get(key)
+		writeHashString(h, key)
+		writeHashString(h, value.AsString())
+	}
+	writeHashString(h, "")
+}
+
+func writeHashString(h *xxhash.Digest, s string) {
+	_, _ = h.WriteString(s)
+	_, _ = h.Write([]byte{0xff})
+}
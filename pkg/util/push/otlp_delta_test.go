@@ -0,0 +1,230 @@
+package push
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/pmetric/pmetricotlp"
+
+	"github.com/cortexproject/cortex/pkg/cortexpb"
+	"github.com/cortexproject/cortex/pkg/distributor"
+	"github.com/cortexproject/cortex/pkg/util/flagext"
+	"github.com/cortexproject/cortex/pkg/util/validation"
+)
+
+func TestOTLPHandler_ConvertDeltaToCumulative(t *testing.T) {
+	for _, enabled := range []bool{false, true} {
+		limits := validation.Limits{}
+		flagext.DefaultValues(&limits)
+		limits.OTLPConvertDeltaToCumulative = enabled
+		overrides := validation.NewOverrides(limits, nil)
+
+		var pushed []cortexpb.Sample
+		push := func(_ context.Context, req *cortexpb.WriteRequest) (*cortexpb.WriteResponse, error) {
+			for _, ts := range req.Timeseries {
+				pushed = append(pushed, ts.Samples...)
+			}
+			return &cortexpb.WriteResponse{}, nil
+		}
+
+		converter := NewDeltaToCumulativeConverter(100, time.Hour, prometheus.NewPedanticRegistry())
+		handler := OTLPHandler(100000, overrides, distributor.OTLPConfig{}, nil, push, nil, converter)
+
+		now := time.Now()
+		for i := range 2 {
+			md := pmetric.NewMetrics()
+			createOtelSum("test", "", pmetric.AggregationTemporalityDelta, now.Add(time.Duration(i)*time.Minute)).MoveTo(md.ResourceMetrics().AppendEmpty().ScopeMetrics().AppendEmpty().Metrics().AppendEmpty())
+			exportRequest := pmetricotlp.NewExportRequestFromMetrics(md)
+
+			req, err := getOTLPHttpRequest(&exportRequest, pbContentType, "")
+			require.NoError(t, err)
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, req)
+
+			if enabled {
+				assert.Equal(t, http.StatusOK, recorder.Code)
+			} else {
+				assert.Equal(t, http.StatusBadRequest, recorder.Code)
+			}
+		}
+
+		if enabled {
+			// The delta sums are accumulated, and ingested even if delta temporality isn't allowed.
+			assert.Equal(t, []cortexpb.Sample{{Value: 5, TimestampMs: now.UnixMilli()}, {Value: 10, TimestampMs: now.Add(time.Minute).UnixMilli()}}, pushed)
+		} else {
+			assert.Empty(t, pushed)
+		}
+	}
+}
+
+func TestDeltaToCumulativeConverter_Sum(t *testing.T) {
+	converter := NewDeltaToCumulativeConverter(100, time.Hour, prometheus.NewPedanticRegistry())
+
+	// Returns the converted values of a delta sum with a data point per value, for the series of each pod.
+	convert := func(userID string, temporality pmetric.AggregationTemporality, ts time.Time, values map[string]int64) map[string]float64 {
+		md := pmetric.NewMetrics()
+		metric := md.ResourceMetrics().AppendEmpty().ScopeMetrics().AppendEmpty().Metrics().AppendEmpty()
+		metric.SetName("requests")
+		sum := metric.SetEmptySum()
+		sum.SetIsMonotonic(true)
+		sum.SetAggregationTemporality(temporality)
+		for pod, value := range values {
+			dp := sum.DataPoints().AppendEmpty()
+			dp.Attributes().PutStr("pod", pod)
+			dp.SetStartTimestamp(pcommon.NewTimestampFromTime(ts.Add(-time.Minute)))
+			dp.SetTimestamp(pcommon.NewTimestampFromTime(ts))
+			dp.SetIntValue(value)
+		}
+
+		converter.Convert(userID, md)
+		assert.Equal(t, pmetric.AggregationTemporalityCumulative, sum.AggregationTemporality())
+
+		converted := map[string]float64{}
+		for _, dp := range sum.DataPoints().All() {
+			pod, _ := dp.Attributes().Get("pod")
+			if temporality == pmetric.AggregationTemporalityCumulative {
+				converted[pod.AsString()] = float64(dp.IntValue())
+			} else {
+				converted[pod.AsString()] = dp.DoubleValue()
+			}
+		}
+		return converted
+	}
+
+	now := time.Now()
+	assert.Equal(t, map[string]float64{"a": 1, "b": 10}, convert("user-1", pmetric.AggregationTemporalityDelta, now, map[string]int64{"a": 1, "b": 10}))
+	assert.Equal(t, map[string]float64{"a": 3, "b": 15}, convert("user-1", pmetric.AggregationTemporalityDelta, now.Add(time.Minute), map[string]int64{"a": 2, "b": 5}))
+
+	// The series of each tenant are tracked independently.
+	assert.Equal(t, map[string]float64{"a": 4}, convert("user-2", pmetric.AggregationTemporalityDelta, now.Add(time.Minute), map[string]int64{"a": 4}))
+
+	// Out of order data points are dropped.
+	assert.Equal(t, map[string]float64{}, convert("user-1", pmetric.AggregationTemporalityDelta, now, map[string]int64{"a": 2}))
+
+	// Cumulative sums are left untouched.
+	assert.Equal(t, map[string]float64{"a": 7}, convert("user-1", pmetric.AggregationTemporalityCumulative, now.Add(2*time.Minute), map[string]int64{"a": 7}))
+	assert.Equal(t, map[string]float64{"a": 4}, convert("user-1", pmetric.AggregationTemporalityDelta, now.Add(2*time.Minute), map[string]int64{"a": 1}))
+
+	assert.Equal(t, float64(6), testutil.ToFloat64(converter.convertedPoints))
+	assert.Equal(t, float64(1), testutil.ToFloat64(converter.droppedPoints))
+	assert.Equal(t, float64(3), testutil.ToFloat64(converter.trackedSeries))
+}
+
+func TestDeltaToCumulativeConverter_Histogram(t *testing.T) {
+	converter := NewDeltaToCumulativeConverter(100, time.Hour, prometheus.NewPedanticRegistry())
+	now := time.Now()
+
+	convert := func(ts time.Time, bounds []float64, buckets []uint64, sum float64) pmetric.HistogramDataPoint {
+		md := pmetric.NewMetrics()
+		metric := md.ResourceMetrics().AppendEmpty().ScopeMetrics().AppendEmpty().Metrics().AppendEmpty()
+		metric.SetName("latency")
+		histogram := metric.SetEmptyHistogram()
+		histogram.SetAggregationTemporality(pmetric.AggregationTemporalityDelta)
+		dp := histogram.DataPoints().AppendEmpty()
+		dp.SetTimestamp(pcommon.NewTimestampFromTime(ts))
+		dp.ExplicitBounds().FromRaw(bounds)
+		dp.BucketCounts().FromRaw(buckets)
+		count := uint64(0)
+		for _, c := range buckets {
+			count += c
+		}
+		dp.SetCount(count)
+		dp.SetSum(sum)
+		dp.SetMin(0.1)
+		dp.SetMax(sum)
+
+		converter.Convert("user-1", md)
+		require.Equal(t, 1, histogram.DataPoints().Len())
+		return histogram.DataPoints().At(0)
+	}
+
+	dp := convert(now, []float64{1, 10}, []uint64{1, 2, 0}, 5)
+	assert.Equal(t, []uint64{1, 2, 0}, dp.BucketCounts().AsRaw())
+	assert.Equal(t, uint64(3), dp.Count())
+	assert.Equal(t, float64(5), dp.Sum())
+	assert.Equal(t, pcommon.NewTimestampFromTime(now), dp.StartTimestamp())
+
+	dp = convert(now.Add(time.Minute), []float64{1, 10}, []uint64{0, 1, 1}, 20)
+	assert.Equal(t, []uint64{1, 3, 1}, dp.BucketCounts().AsRaw())
+	assert.Equal(t, uint64(5), dp.Count())
+	assert.Equal(t, float64(25), dp.Sum())
+	assert.Equal(t, pcommon.NewTimestampFromTime(now), dp.StartTimestamp())
+	assert.False(t, dp.HasMin())
+	assert.False(t, dp.HasMax())
+
+	// The running totals restart once the bucket boundaries change.
+	dp = convert(now.Add(2*time.Minute), []float64{5}, []uint64{1, 1}, 8)
+	assert.Equal(t, []uint64{1, 1}, dp.BucketCounts().AsRaw())
+	assert.Equal(t, uint64(2), dp.Count())
+	assert.Equal(t, float64(8), dp.Sum())
+}
+
+func TestDeltaToCumulativeConverter_Limits(t *testing.T) {
+	converter := NewDeltaToCumulativeConverter(2, time.Minute, prometheus.NewPedanticRegistry())
+
+	convert := func(userID string, ts time.Time, pods ...string) []string {
+		md := pmetric.NewMetrics()
+		metric := md.ResourceMetrics().AppendEmpty().ScopeMetrics().AppendEmpty().Metrics().AppendEmpty()
+		metric.SetName("requests")
+		sum := metric.SetEmptySum()
+		sum.SetAggregationTemporality(pmetric.AggregationTemporalityDelta)
+		for _, pod := range pods {
+			dp := sum.DataPoints().AppendEmpty()
+			dp.Attributes().PutStr("pod", pod)
+			dp.SetTimestamp(pcommon.NewTimestampFromTime(ts))
+			dp.SetDoubleValue(1)
+		}
+
+		converter.Convert(userID, md)
+
+		var converted []string
+		for _, dp := range sum.DataPoints().All() {
+			pod, _ := dp.Attributes().Get("pod")
+			converted = append(converted, pod.AsString())
+		}
+		return converted
+	}
+
+	// Moves back the last seen time of all the state, as if the TTL elapsed.
+	expire := func() {
+		converter.mtx.Lock()
+		defer converter.mtx.Unlock()
+
+		converter.lastPurge = converter.lastPurge.Add(-2 * time.Minute)
+		for _, tenant := range converter.tenants {
+			tenant.lastSeen = tenant.lastSeen.Add(-2 * time.Minute)
+			tenant.lastPurge = tenant.lastPurge.Add(-2 * time.Minute)
+			for _, state := range tenant.series {
+				state.lastSeen = state.lastSeen.Add(-2 * time.Minute)
+			}
+		}
+	}
+
+	now := time.Now()
+
+	// The data points of new series are dropped once the tenant reaches the max number of series.
+	assert.Equal(t, []string{"a", "b"}, convert("user-1", now, "a", "b", "c"))
+	assert.Equal(t, float64(1), testutil.ToFloat64(converter.droppedPoints))
+
+	// The limit is applied per tenant.
+	assert.Equal(t, []string{"a", "b"}, convert("user-2", now, "a", "b"))
+	assert.Equal(t, float64(1), testutil.ToFloat64(converter.droppedPoints))
+	assert.Equal(t, float64(4), testutil.ToFloat64(converter.trackedSeries))
+
+	// The state of the series is evicted after the TTL, and the tenants without any data point are removed.
+	expire()
+
+	assert.Equal(t, []string{"c"}, convert("user-1", now.Add(time.Minute), "c"))
+	assert.Equal(t, float64(4), testutil.ToFloat64(converter.evictedSeries))
+	assert.Equal(t, float64(1), testutil.ToFloat64(converter.trackedSeries))
+	assert.Len(t, converter.tenants, 1)
+}
@@ -573,6 +573,51 @@ func TestOTLPConvertToPromTS(t *testing.T) {
 	}
 }
 
+func TestOTLPConvertToPromTS_PromoteResourceAttributes(t *testing.T) {
+	logger := log.NewNopLogger()
+	ctx := context.Background()
+	d := pmetric.NewMetrics()
+	resourceMetric := d.ResourceMetrics().AppendEmpty()
+	resourceMetric.Resource().Attributes().PutStr("service.name", "test-service")
+	resourceMetric.Resource().Attributes().PutStr("service.namespace", "test-namespace")
+	resourceMetric.Resource().Attributes().PutStr("k8s.pod.name", "test-pod")
+	resourceMetric.Resource().Attributes().PutStr("conflict", "resource-value")
+	resourceMetric.Resource().Attributes().PutStr("not.promoted", "value")
+
+	counterMetric := resourceMetric.ScopeMetrics().AppendEmpty().Metrics().AppendEmpty()
+	counterMetric.SetName("test-counter")
+	counterMetric.SetEmptySum()
+	counterMetric.Sum().SetAggregationTemporality(pmetric.AggregationTemporalityCumulative)
+	counterMetric.Sum().SetIsMonotonic(true)
+
+	counterDataPoint := counterMetric.Sum().DataPoints().AppendEmpty()
+	counterDataPoint.SetTimestamp(pcommon.NewTimestampFromTime(time.Now()))
+	counterDataPoint.SetDoubleValue(10.0)
+	counterDataPoint.Attributes().PutStr("conflict", "datapoint-value")
+
+	limits := validation.Limits{
+		PromoteResourceAttributes: []string{"service.namespace", "k8s.pod.name", "conflict"},
+	}
+	overrides := validation.NewOverrides(limits, nil)
+	cfg := distributor.OTLPConfig{
+		DisableTargetInfo: true,
+		AddMetricSuffixes: true,
+	}
+
+	tsList, _, err := convertToPromTS(ctx, d, cfg, overrides, "user-1", logger)
+	require.NoError(t, err)
+	require.Len(t, tsList, 1)
+
+	// Promoted attributes are sanitized, and the datapoint attributes take precedence over them.
+	require.ElementsMatch(t, []prompb.Label{
+		{Name: "__name__", Value: "test_counter_total"},
+		{Name: "job", Value: "test-namespace/test-service"},
+		{Name: "service_namespace", Value: "test-namespace"},
+		{Name: "k8s_pod_name", Value: "test-pod"},
+		{Name: "conflict", Value: "datapoint-value"},
+	}, tsList[0].Labels)
+}
+
 func TestOTLPConvertToPromTS_WithoutAddMetricSuffixes(t *testing.T) {
 	logger := log.NewNopLogger()
 	ctx := context.Background()
@@ -874,7 +919,7 @@ func BenchmarkOTLPWriteHandlerCompression(b *testing.B) {
 	mockPushFunc := func(context.Context, *cortexpb.WriteRequest) (*cortexpb.WriteResponse, error) {
 		return &cortexpb.WriteResponse{}, nil
 	}
-	handler := OTLPHandler(10000, overrides, cfg, nil, mockPushFunc, nil)
+	handler := OTLPHandler(10000, overrides, cfg, nil, mockPushFunc, nil, nil)
 
 	b.Run("json with no compression", func(b *testing.B) {
 		req, err := getOTLPHttpRequest(&exportRequest, jsonContentType, "")
@@ -944,7 +989,7 @@ func BenchmarkOTLPWriteHandlerPush(b *testing.B) {
 	mockPushFunc := func(context.Context, *cortexpb.WriteRequest) (*cortexpb.WriteResponse, error) {
 		return &cortexpb.WriteResponse{}, nil
 	}
-	handler := OTLPHandler(1000000, overrides, cfg, nil, mockPushFunc, nil)
+	handler := OTLPHandler(1000000, overrides, cfg, nil, mockPushFunc, nil, nil)
 
 	tests := []struct {
 		description      string
@@ -1036,7 +1081,7 @@ func TestOTLPHandler_MetricCollection(t *testing.T) {
 
 	push := verifyOTLPWriteRequestHandler(t, cortexpb.API)
 	overrides := validation.NewOverrides(querier.DefaultLimitsConfig(), nil)
-	handler := OTLPHandler(100000, overrides, cfg, nil, push, counter)
+	handler := OTLPHandler(100000, overrides, cfg, nil, push, counter, nil)
 
 	recorder := httptest.NewRecorder()
 	handler.ServeHTTP(recorder, req)
@@ -1136,7 +1181,7 @@ func TestOTLPWriteHandler(t *testing.T) {
 
 			push := verifyOTLPWriteRequestHandler(t, cortexpb.API)
 			overrides := validation.NewOverrides(querier.DefaultLimitsConfig(), nil)
-			handler := OTLPHandler(test.maxRecvMsgSize, overrides, cfg, nil, push, nil)
+			handler := OTLPHandler(test.maxRecvMsgSize, overrides, cfg, nil, push, nil, nil)
 
 			recorder := httptest.NewRecorder()
 			handler.ServeHTTP(recorder, req)
@@ -2,11 +2,13 @@ package push
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
 
 	"github.com/go-kit/log/level"
+	"github.com/gogo/protobuf/proto"
 	"github.com/prometheus/client_golang/exp/api/remote"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/common/model"
@@ -46,7 +48,7 @@ const (
 type Func func(context.Context, *cortexpb.WriteRequest) (*cortexpb.WriteResponse, error)
 
 // Handler is a http.Handler which accepts WriteRequests.
-func Handler(remoteWrite2Enabled bool, acceptUnknownRemoteWriteContentType bool, maxRecvMsgSize int, overrides *validation.Overrides, sourceIPs *middleware.SourceIPExtractor, push Func, requestTotal *prometheus.CounterVec) http.Handler {
+func Handler(remoteWrite2Enabled bool, acceptUnknownRemoteWriteContentType bool, maxRecvMsgSize int, overrides *validation.Overrides, sourceIPs *middleware.SourceIPExtractor, push Func, requestTotal *prometheus.CounterVec, requestBodyTooLarge *prometheus.CounterVec) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		ctx := r.Context()
 		logger := log.WithContext(ctx, log.Logger)
@@ -58,12 +60,45 @@ func Handler(remoteWrite2Enabled bool, acceptUnknownRemoteWriteContentType bool,
 			}
 		}
 
+		// parseRequest reads the request enforcing the per-tenant max request body size, if lower than
+		// maxRecvMsgSize, and writes the error response if it fails. Since the decompressed size of a
+		// snappy block is encoded in its header, a request exceeding the limit is rejected before
+		// being decompressed.
+		parseRequest := func(req proto.Message) bool {
+			userID, _ := users.TenantID(ctx)
+			maxSize := maxRecvMsgSize
+			tenantLimit := 0
+			if userID != "" {
+				tenantLimit = overrides.MaxRequestBodySizeBytes(userID)
+			}
+			if tenantLimit > 0 && tenantLimit < maxSize {
+				maxSize = tenantLimit
+			}
+
+			err := util.ParseProtoReader(ctx, r.Body, int(r.ContentLength), maxSize, req, util.RawSnappy)
+			if err == nil {
+				return true
+			}
+
+			var sizeErr *util.MessageSizeLargerError
+			if maxSize == tenantLimit && errors.As(err, &sizeErr) {
+				if requestBodyTooLarge != nil {
+					requestBodyTooLarge.WithLabelValues(userID).Inc()
+				}
+				errMsg := fmt.Sprintf("the request body size (%d bytes) exceeds the max request body size (%d bytes) configured for the tenant with the max_request_body_size_bytes limit", sizeErr.Size, tenantLimit)
+				level.Warn(logger).Log("msg", "push refused", "err", errMsg)
+				http.Error(w, errMsg, http.StatusRequestEntityTooLarge)
+				return false
+			}
+
+			level.Error(logger).Log("err", err.Error())
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return false
+		}
+
 		handlePRW1 := func() {
 			var req cortexpb.PreallocWriteRequest
-			err := util.ParseProtoReader(ctx, r.Body, int(r.ContentLength), maxRecvMsgSize, &req, util.RawSnappy)
-			if err != nil {
-				level.Error(logger).Log("err", err.Error())
-				http.Error(w, err.Error(), http.StatusBadRequest)
+			if !parseRequest(&req) {
 				return
 			}
 
@@ -98,10 +133,7 @@ func Handler(remoteWrite2Enabled bool, acceptUnknownRemoteWriteContentType bool,
 			// v1 request is put back into the pool by the Distributor.
 			defer cortexpb.ReuseWriteRequestV2(req)
 
-			err = util.ParseProtoReader(ctx, r.Body, int(r.ContentLength), maxRecvMsgSize, req, util.RawSnappy)
-			if err != nil {
-				level.Error(logger).Log("err", err.Error())
-				http.Error(w, err.Error(), http.StatusBadRequest)
+			if !parseRequest(req) {
 				return
 			}
 
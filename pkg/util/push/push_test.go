@@ -177,7 +177,7 @@ func Benchmark_Handler(b *testing.B) {
 	testSeriesNums := []int{10, 100, 500, 1000}
 	for _, seriesNum := range testSeriesNums {
 		b.Run(fmt.Sprintf("PRW1 with %d series", seriesNum), func(b *testing.B) {
-			handler := Handler(true, false, 1000000, overrides, nil, mockHandler, nil)
+			handler := Handler(true, false, 1000000, overrides, nil, mockHandler, nil, nil)
 			req, err := createPRW1HTTPRequest(seriesNum)
 			require.NoError(b, err)
 
@@ -191,7 +191,7 @@ func Benchmark_Handler(b *testing.B) {
 			}
 		})
 		b.Run(fmt.Sprintf("PRW2 with %d series", seriesNum), func(b *testing.B) {
-			handler := Handler(true, false, 1000000, overrides, nil, mockHandler, nil)
+			handler := Handler(true, false, 1000000, overrides, nil, mockHandler, nil, nil)
 			req, err := createPRW2HTTPRequest(seriesNum)
 			require.NoError(b, err)
 
@@ -807,7 +807,7 @@ func TestHandler_remoteWrite(t *testing.T) {
 		t.Run(test.name, func(t *testing.T) {
 			ctx := context.Background()
 			ctx = user.InjectOrgID(ctx, "user-1")
-			handler := Handler(true, false, 100000, overrides, nil, verifyWriteRequestHandler(t, cortexpb.API), nil)
+			handler := Handler(true, false, 100000, overrides, nil, verifyWriteRequestHandler(t, cortexpb.API), nil, nil)
 
 			body, isV2 := test.createBody()
 			req := createRequest(t, body, isV2)
@@ -842,7 +842,7 @@ func TestHandler_remoteWrite(t *testing.T) {
 
 		ctx := context.Background()
 		ctx = user.InjectOrgID(ctx, "user-1")
-		handler := Handler(true, false, 100000, overrides, nil, pushFunc, nil)
+		handler := Handler(true, false, 100000, overrides, nil, pushFunc, nil, nil)
 		req := createRequest(t, createPrometheusRemoteWriteV2Protobuf(t), true)
 		req = req.WithContext(ctx)
 		resp := httptest.NewRecorder()
@@ -1008,7 +1008,7 @@ func TestHandler_ContentTypeAndEncoding(t *testing.T) {
 
 	for _, test := range tests {
 		t.Run(test.description, func(t *testing.T) {
-			handler := Handler(test.remoteWrite2Enabled, test.acceptUnknownRemoteWriteContentType, 100000, overrides, sourceIPs, verifyWriteRequestHandler(t, cortexpb.API), nil)
+			handler := Handler(test.remoteWrite2Enabled, test.acceptUnknownRemoteWriteContentType, 100000, overrides, sourceIPs, verifyWriteRequestHandler(t, cortexpb.API), nil, nil)
 
 			if test.isV2 {
 				ctx := context.Background()
@@ -1035,7 +1035,7 @@ func TestHandler_cortexWriteRequest(t *testing.T) {
 	overrides := validation.NewOverrides(limits, nil)
 
 	sourceIPs, _ := middleware.NewSourceIPs("SomeField", "(.*)")
-	handler := Handler(true, false, 100000, overrides, sourceIPs, verifyWriteRequestHandler(t, cortexpb.API), nil)
+	handler := Handler(true, false, 100000, overrides, sourceIPs, verifyWriteRequestHandler(t, cortexpb.API), nil, nil)
 
 	t.Run("remote write v1", func(t *testing.T) {
 		req := createRequest(t, createCortexWriteRequestProtobuf(t, false, cortexpb.API), false)
@@ -1055,6 +1055,45 @@ func TestHandler_cortexWriteRequest(t *testing.T) {
 	})
 }
 
+func TestHandler_MaxRequestBodySize(t *testing.T) {
+	var limits validation.Limits
+	flagext.DefaultValues(&limits)
+	limits.MaxRequestBodySizeBytes = 10
+	overrides := validation.NewOverrides(limits, nil)
+
+	counter := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "test_counter",
+		Help: "test help",
+	}, []string{"user"})
+
+	handler := Handler(true, false, 100000, overrides, nil, verifyWriteRequestHandler(t, cortexpb.API), nil, counter)
+	ctx := user.InjectOrgID(context.Background(), "user-1")
+
+	t.Run("remote write v1", func(t *testing.T) {
+		req := createRequest(t, createPrometheusRemoteWriteProtobuf(t), false).WithContext(ctx)
+		resp := httptest.NewRecorder()
+		handler.ServeHTTP(resp, req)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, resp.Code)
+		assert.Contains(t, resp.Body.String(), "exceeds the max request body size (10 bytes)")
+	})
+	t.Run("remote write v2", func(t *testing.T) {
+		req := createRequest(t, createPrometheusRemoteWriteV2Protobuf(t), true).WithContext(ctx)
+		resp := httptest.NewRecorder()
+		handler.ServeHTTP(resp, req)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, resp.Code)
+		assert.Contains(t, resp.Body.String(), "exceeds the max request body size (10 bytes)")
+	})
+	t.Run("the global max size applies if lower than the tenant limit", func(t *testing.T) {
+		handler := Handler(true, false, 5, overrides, nil, verifyWriteRequestHandler(t, cortexpb.API), nil, counter)
+		req := createRequest(t, createPrometheusRemoteWriteProtobuf(t), false).WithContext(ctx)
+		resp := httptest.NewRecorder()
+		handler.ServeHTTP(resp, req)
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+
+	assert.Equal(t, 2.0, testutil.ToFloat64(counter.WithLabelValues("user-1")))
+}
+
 func TestHandler_ignoresSkipLabelNameValidationIfSet(t *testing.T) {
 	var limits validation.Limits
 	flagext.DefaultValues(&limits)
@@ -1065,7 +1104,7 @@ func TestHandler_ignoresSkipLabelNameValidationIfSet(t *testing.T) {
 		createRequest(t, createCortexWriteRequestProtobuf(t, true, cortexpb.RULE), false),
 	} {
 		resp := httptest.NewRecorder()
-		handler := Handler(true, false, 100000, overrides, nil, verifyWriteRequestHandler(t, cortexpb.RULE), nil)
+		handler := Handler(true, false, 100000, overrides, nil, verifyWriteRequestHandler(t, cortexpb.RULE), nil, nil)
 		handler.ServeHTTP(resp, req)
 		assert.Equal(t, 200, resp.Code)
 	}
@@ -1081,7 +1120,7 @@ func TestHandler_MetricCollection(t *testing.T) {
 		Help: "test help",
 	}, []string{"type"})
 
-	handler := Handler(true, false, 100000, overrides, nil, verifyWriteRequestHandler(t, cortexpb.API), counter)
+	handler := Handler(true, false, 100000, overrides, nil, verifyWriteRequestHandler(t, cortexpb.API), counter, nil)
 
 	t.Run("counts v1 requests", func(t *testing.T) {
 		req := createRequest(t, createPrometheusRemoteWriteProtobuf(t), false)
@@ -1108,7 +1147,7 @@ func TestHandler_MetricCollection(t *testing.T) {
 	})
 
 	t.Run("counts unknown or invalid content-type as unknown when acceptUnknownRemoteWriteContentType is true", func(t *testing.T) {
-		handlerWithUnknown := Handler(true, true, 100000, overrides, nil, verifyWriteRequestHandler(t, cortexpb.API), counter)
+		handlerWithUnknown := Handler(true, true, 100000, overrides, nil, verifyWriteRequestHandler(t, cortexpb.API), counter, nil)
 		req := createRequestWithHeaders(t, map[string]string{
 			"Content-Type":     "yolo",
 			"Content-Encoding": "snappy",
@@ -1267,7 +1306,7 @@ func TestHandler_RemoteWriteV2_MetadataPoolReset(t *testing.T) {
 		return &cortexpb.WriteResponse{}, nil
 	}
 
-	handler := Handler(true, false, 1000000, overrides, nil, mockPush, nil)
+	handler := Handler(true, false, 1000000, overrides, nil, mockPush, nil, nil)
 	ctx := user.InjectOrgID(context.Background(), "user-1")
 
 	sendRequest := func(reqProto *writev2.Request) *httptest.ResponseRecorder {
@@ -1492,7 +1531,7 @@ func TestHandler_remoteWriteV2_UnauthorizedWithoutTenantID(t *testing.T) {
 		return &cortexpb.WriteResponse{}, nil
 	}
 
-	handler := Handler(true, false, 100000, overrides, nil, pushFunc, nil)
+	handler := Handler(true, false, 100000, overrides, nil, pushFunc, nil, nil)
 
 	req := createRequest(t, createPrometheusRemoteWriteV2Protobuf(t), true)
 
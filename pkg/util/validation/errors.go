@@ -158,6 +158,22 @@ func (e *invalidMetricNameError) Error() string {
 	return fmt.Sprintf("sample invalid metric name: %.200q", e.metricName)
 }
 
+type metricNameNotAllowedError struct {
+	metricName string
+	list       string
+}
+
+func newMetricNameNotAllowedError(metricName, list string) ValidationError {
+	return &metricNameNotAllowedError{
+		metricName: metricName,
+		list:       list,
+	}
+}
+
+func (e *metricNameNotAllowedError) Error() string {
+	return fmt.Sprintf("metric name not allowed by the tenant metric names %s: %.200q", e.list, e.metricName)
+}
+
 // sampleValidationError is a ValidationError implementation suitable for sample validation errors.
 type sampleValidationError struct {
 	message    string
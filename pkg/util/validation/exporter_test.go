@@ -37,6 +37,7 @@ func TestOverridesExporter_withConfig(t *testing.T) {
 		# HELP cortex_overrides Resource limit overrides applied to tenants
 		# TYPE cortex_overrides gauge
 		cortex_overrides{limit_name="accept_ha_samples",user="tenant-a"} 0
+		cortex_overrides{limit_name="accept_identical_duplicate_samples",user="tenant-a"} 0
 		cortex_overrides{limit_name="accept_mixed_ha_samples",user="tenant-a"} 0
 		cortex_overrides{limit_name="alertmanager_max_alerts_count",user="tenant-a"} 0
 		cortex_overrides{limit_name="alertmanager_max_alerts_size_bytes",user="tenant-a"} 0
@@ -47,25 +48,37 @@ func TestOverridesExporter_withConfig(t *testing.T) {
 		cortex_overrides{limit_name="alertmanager_max_template_size_bytes",user="tenant-a"} 0
 		cortex_overrides{limit_name="alertmanager_max_templates_count",user="tenant-a"} 0
 		cortex_overrides{limit_name="alertmanager_notification_rate_limit",user="tenant-a"} 0
+		cortex_overrides{limit_name="alertmanager_notification_rate_limit_queue_size",user="tenant-a"} 0
 		cortex_overrides{limit_name="alertmanager_receivers_firewall_block_private_addresses",user="tenant-a"} 0
 		cortex_overrides{limit_name="compactor_blocks_retention_period",user="tenant-a"} 0
+		cortex_overrides{limit_name="compactor_max_compaction_level",user="tenant-a"} 0
+		cortex_overrides{limit_name="compactor_min_block_age",user="tenant-a"} 0
 		cortex_overrides{limit_name="compactor_partition_index_size_bytes",user="tenant-a"} 6.8719476736e+10
 		cortex_overrides{limit_name="compactor_partition_series_count",user="tenant-a"} 0
 		cortex_overrides{limit_name="compactor_tenant_shard_size",user="tenant-a"} 0
+		cortex_overrides{limit_name="compactor_vertical_compaction_only",user="tenant-a"} 0
 		cortex_overrides{limit_name="creation_grace_period",user="tenant-a"} 600
 		cortex_overrides{limit_name="enable_native_histograms",user="tenant-a"} 0
 		cortex_overrides{limit_name="enable_start_timestamp",user="tenant-a"} 0
 		cortex_overrides{limit_name="enable_type_and_unit_labels",user="tenant-a"} 0
 		cortex_overrides{limit_name="enforce_metadata_metric_name",user="tenant-a"} 1
 		cortex_overrides{limit_name="enforce_metric_name",user="tenant-a"} 1
+		cortex_overrides{limit_name="future_sample_clamp_tolerance",user="tenant-a"} 0
 		cortex_overrides{limit_name="ha_max_clusters",user="tenant-a"} 0
 		cortex_overrides{limit_name="ha_tracker_failover_timeout",user="tenant-a"} 30
+		cortex_overrides{limit_name="ha_tracker_fast_failover_timeout",user="tenant-a"} 0
+		cortex_overrides{limit_name="head_retention_period",user="tenant-a"} 0
+		cortex_overrides{limit_name="ignore_blocks_within",user="tenant-a"} 0
+		cortex_overrides{limit_name="ignore_deletion_marks_delay",user="tenant-a"} 0
 		cortex_overrides{limit_name="ingestion_burst_size",user="tenant-a"} 50000
 		cortex_overrides{limit_name="ingestion_rate",user="tenant-a"} 25000
+		cortex_overrides{limit_name="ingestion_rate_native_histogram_bucket_weight",user="tenant-a"} 0
 		cortex_overrides{limit_name="ingestion_tenant_shard_size",user="tenant-a"} 0
+		cortex_overrides{limit_name="kafka_export_enabled",user="tenant-a"} 1
 		cortex_overrides{limit_name="max_cache_freshness",user="tenant-a"} 60
 		cortex_overrides{limit_name="max_downloaded_bytes_per_request",user="tenant-a"} 0
 		cortex_overrides{limit_name="max_exemplars",user="tenant-a"} 0
+		cortex_overrides{limit_name="max_exemplars_per_query",user="tenant-a"} 0
 		cortex_overrides{limit_name="max_fetched_chunk_bytes_per_query",user="tenant-a"} 0
 		cortex_overrides{limit_name="max_fetched_chunks_per_query",user="tenant-a"} 2e+06
 		cortex_overrides{limit_name="max_fetched_data_bytes_per_query",user="tenant-a"} 0
@@ -93,11 +106,16 @@ func TestOverridesExporter_withConfig(t *testing.T) {
 		cortex_overrides{limit_name="max_query_parallelism",user="tenant-a"} 14
 		cortex_overrides{limit_name="max_query_response_size",user="tenant-a"} 0
 		cortex_overrides{limit_name="max_regex_pattern_length",user="tenant-a"} 0
+		cortex_overrides{limit_name="max_request_body_size_bytes",user="tenant-a"} 0
 		cortex_overrides{limit_name="max_series_per_metric",user="tenant-a"} 50000
 		cortex_overrides{limit_name="max_series_per_user",user="tenant-a"} 5e+06
 		cortex_overrides{limit_name="max_total_label_value_length_for_unoptimized_regex",user="tenant-a"} 0
+		cortex_overrides{limit_name="metric_quarantine_series_low_water_mark",user="tenant-a"} 0
+		cortex_overrides{limit_name="metric_quarantine_series_threshold",user="tenant-a"} 0
+		cortex_overrides{limit_name="mirror_writes_ratio",user="tenant-a"} 0
 		cortex_overrides{limit_name="native_histogram_ingestion_burst_size",user="tenant-a"} 0
 		cortex_overrides{limit_name="native_histogram_ingestion_rate",user="tenant-a"} 1.7976931348623157e+308
+		cortex_overrides{limit_name="otlp_convert_delta_to_cumulative",user="tenant-a"} 0
 		cortex_overrides{limit_name="out_of_order_results_cache_ttl",user="tenant-a"} 0
 		cortex_overrides{limit_name="out_of_order_time_window",user="tenant-a"} 0
 		cortex_overrides{limit_name="parquet_converter_enabled",user="tenant-a"} 0
@@ -106,6 +124,7 @@ func TestOverridesExporter_withConfig(t *testing.T) {
 		cortex_overrides{limit_name="parquet_max_fetched_data_bytes",user="tenant-a"} 0
 		cortex_overrides{limit_name="parquet_max_fetched_row_count",user="tenant-a"} 0
 		cortex_overrides{limit_name="query_ingesters_within",user="tenant-a"} 0
+		cortex_overrides{limit_name="query_lookback_delta",user="tenant-a"} 0
 		cortex_overrides{limit_name="query_partial_data",user="tenant-a"} 0
 		cortex_overrides{limit_name="query_store_after",user="tenant-a"} 0
 		cortex_overrides{limit_name="query_vertical_shard_size",user="tenant-a"} 0
@@ -113,6 +132,7 @@ func TestOverridesExporter_withConfig(t *testing.T) {
 		cortex_overrides{limit_name="reject_old_samples_max_age",user="tenant-a"} 1.2096e+06
 		cortex_overrides{limit_name="results_cache_ttl",user="tenant-a"} 0
 		cortex_overrides{limit_name="ruler_evaluation_delay_duration",user="tenant-a"} 0
+		cortex_overrides{limit_name="ruler_max_concurrent_rule_group_evaluations",user="tenant-a"} 0
 		cortex_overrides{limit_name="ruler_max_rule_groups_per_tenant",user="tenant-a"} 0
 		cortex_overrides{limit_name="ruler_max_rules_per_rule_group",user="tenant-a"} 0
 		cortex_overrides{limit_name="ruler_query_offset",user="tenant-a"} 0
@@ -8,7 +8,9 @@ import (
 	"fmt"
 	"maps"
 	"math"
+	"path"
 	"regexp"
+	"slices"
 	"strings"
 	"text/template"
 	"time"
@@ -16,6 +18,7 @@ import (
 	"github.com/cespare/xxhash/v2"
 	"github.com/go-kit/log/level"
 	"github.com/prometheus/common/model"
+	"github.com/prometheus/otlptranslator"
 	"github.com/prometheus/prometheus/model/labels"
 	"github.com/prometheus/prometheus/model/relabel"
 	"github.com/segmentio/fasthash/fnv1a"
@@ -27,18 +30,32 @@ import (
 
 var errMaxGlobalSeriesPerUserValidation = errors.New("the ingester.max-global-series-per-user limit is unsupported if distributor.shard-by-all-labels is disabled")
 var errMaxGlobalNativeHistogramSeriesPerUserValidation = errors.New("the ingester.max-global-native-histogram-series-per-user limit is unsupported if distributor.shard-by-all-labels or ingester.active-series-metrics-enabled is disabled")
+var errNativeHistogramClassicBucketsNotIncreasing = errors.New("the distributor.native-histogram-classic-buckets upper bounds must be in increasing order")
+var errNegativeIngestionRateNativeHistogramBucketWeight = errors.New("the distributor.ingestion-rate-native-histogram-bucket-weight must not be negative")
+var errInvalidQueryEngine = errors.New("the querier.query-engine must be empty, prometheus or thanos")
+var errInvalidMirrorWritesRatio = errors.New("the distributor.mirror-writes-ratio must be between 0 and 1")
+var errMetricQuarantineSeriesLowWaterMark = errors.New("the ingester.metric-quarantine-series-low-water-mark must be lower than ingester.metric-quarantine-series-threshold")
+var errInvalidMaxSeriesPolicy = errors.New("the ingester.max-series-policy must be reject-new or evict-idle")
+var errMaxSeriesPolicyEvictIdleValidation = errors.New("the ingester.max-series-policy evict-idle is unsupported if ingester.active-series-metrics-enabled is disabled")
 var errMaxLocalNativeHistogramSeriesPerUserValidation = errors.New("the ingester.max-local-native-histogram-series-per-user limit is unsupported if ingester.active-series-metrics-enabled is disabled")
 var errDuplicateQueryPriorities = errors.New("duplicate entry of priorities found. Make sure they are all unique, including the default priority")
 var errCompilingQueryPriorityRegex = errors.New("error compiling query priority regex")
+var errNegativeQueryPriorityWeight = errors.New("query priority weight must be greater than or equal to 0")
 var errDuplicatePerLabelSetLimit = errors.New("duplicate per labelSet limits found. Make sure they are all unique")
 var errInvalidLabelName = errors.New("invalid label name")
 var errInvalidLabelValue = errors.New("invalid label value")
 var errInvalidMetricRelabelConfigs = errors.New("invalid metric_relabel_configs")
+var errInvalidPromoteResourceAttribute = errors.New("invalid promote_resource_attributes entry")
+var errDuplicatePromoteResourceAttribute = errors.New("promote_resource_attributes entries are converted to the same label name")
+var errInvalidMetricNamePattern = errors.New("invalid metric name pattern")
 
 // Supported values for enum limits
 const (
 	LocalIngestionRateStrategy  = "local"
 	GlobalIngestionRateStrategy = "global"
+
+	MaxSeriesPolicyRejectNew = "reject-new"
+	MaxSeriesPolicyEvictIdle = "evict-idle"
 )
 
 // AccessDeniedError are errors that do not comply with the limits specified.
@@ -77,6 +94,7 @@ type QueryPriority struct {
 type PriorityDef struct {
 	Priority         int64            `yaml:"priority" json:"priority" doc:"nocli|description=Priority level. Must be a unique value.|default=0"`
 	ReservedQueriers float64          `yaml:"reserved_queriers" json:"reserved_queriers" doc:"nocli|description=Number of reserved queriers to handle priorities higher or equal to the priority level. Value between 0 and 1 will be used as a percentage.|default=0"`
+	Weight           int64            `yaml:"weight" json:"weight" doc:"nocli|description=Relative share of the tenant's dequeued queries this priority gets while queries of different priorities are queued. If any priority has a weight set, queries are dequeued with weighted fairness across priorities, and priorities without a weight (including the default priority) get a weight of 1, so that they are never starved. If no priority has a weight set, queries are dequeued in strict priority order.|default=0"`
 	QueryAttributes  []QueryAttribute `yaml:"query_attributes" json:"query_attributes" doc:"nocli|description=List of query_attributes to match and assign priority to queries. A query is assigned to this priority if it matches any query_attribute in this list. Each query_attribute has several properties (e.g., regex, time_window, user_agent), and all specified properties must match for a query_attribute to be considered a match. Only the specified properties are checked, and an AND operator is applied to them."`
 }
 
@@ -128,35 +146,46 @@ type LimitsPerLabelSet struct {
 // limits via flags, or per-user limits via yaml config.
 type Limits struct {
 	// Distributor enforced limits.
-	IngestionRate                     float64             `yaml:"ingestion_rate" json:"ingestion_rate"`
-	NativeHistogramIngestionRate      float64             `yaml:"native_histogram_ingestion_rate" json:"native_histogram_ingestion_rate"`
-	IngestionRateStrategy             string              `yaml:"ingestion_rate_strategy" json:"ingestion_rate_strategy"`
-	IngestionBurstSize                int                 `yaml:"ingestion_burst_size" json:"ingestion_burst_size"`
-	NativeHistogramIngestionBurstSize int                 `yaml:"native_histogram_ingestion_burst_size" json:"native_histogram_ingestion_burst_size"`
-	AcceptHASamples                   bool                `yaml:"accept_ha_samples" json:"accept_ha_samples"`
-	AcceptMixedHASamples              bool                `yaml:"accept_mixed_ha_samples" json:"accept_mixed_ha_samples"`
-	HAClusterLabel                    string              `yaml:"ha_cluster_label" json:"ha_cluster_label"`
-	HAReplicaLabel                    string              `yaml:"ha_replica_label" json:"ha_replica_label"`
-	HAMaxClusters                     int                 `yaml:"ha_max_clusters" json:"ha_max_clusters"`
-	HATrackerFailoverTimeout          model.Duration      `yaml:"ha_tracker_failover_timeout" json:"ha_tracker_failover_timeout"`
-	DropLabels                        flagext.StringSlice `yaml:"drop_labels" json:"drop_labels"`
-	MaxLabelNameLength                int                 `yaml:"max_label_name_length" json:"max_label_name_length"`
-	MaxLabelValueLength               int                 `yaml:"max_label_value_length" json:"max_label_value_length"`
-	MaxLabelNamesPerSeries            int                 `yaml:"max_label_names_per_series" json:"max_label_names_per_series"`
-	MaxLabelsSizeBytes                int                 `yaml:"max_labels_size_bytes" json:"max_labels_size_bytes"`
-	MaxNativeHistogramSampleSizeBytes int                 `yaml:"max_native_histogram_sample_size_bytes" json:"max_native_histogram_sample_size_bytes"`
-	MaxMetadataLength                 int                 `yaml:"max_metadata_length" json:"max_metadata_length"`
-	RejectOldSamples                  bool                `yaml:"reject_old_samples" json:"reject_old_samples"`
-	RejectOldSamplesMaxAge            model.Duration      `yaml:"reject_old_samples_max_age" json:"reject_old_samples_max_age"`
-	CreationGracePeriod               model.Duration      `yaml:"creation_grace_period" json:"creation_grace_period"`
-	EnforceMetadataMetricName         bool                `yaml:"enforce_metadata_metric_name" json:"enforce_metadata_metric_name"`
-	EnforceMetricName                 bool                `yaml:"enforce_metric_name" json:"enforce_metric_name"`
-	IngestionTenantShardSize          int                 `yaml:"ingestion_tenant_shard_size" json:"ingestion_tenant_shard_size"`
-	MetricRelabelConfigs              []*relabel.Config   `yaml:"metric_relabel_configs,omitempty" json:"metric_relabel_configs,omitempty" doc:"nocli|description=List of metric relabel configurations. Note that in most situations, it is more effective to use metrics relabeling directly in the Prometheus server, e.g. remote_write.write_relabel_configs."`
-	MaxNativeHistogramBuckets         int                 `yaml:"max_native_histogram_buckets" json:"max_native_histogram_buckets"`
-	PromoteResourceAttributes         []string            `yaml:"promote_resource_attributes" json:"promote_resource_attributes"`
-	EnableTypeAndUnitLabels           bool                `yaml:"enable_type_and_unit_labels" json:"enable_type_and_unit_labels"`
-	EnableStartTimestamp              bool                `yaml:"enable_start_timestamp" json:"enable_start_timestamp"`
+	IngestionRate                     float64                 `yaml:"ingestion_rate" json:"ingestion_rate"`
+	NativeHistogramIngestionRate      float64                 `yaml:"native_histogram_ingestion_rate" json:"native_histogram_ingestion_rate"`
+	IngestionRateStrategy             string                  `yaml:"ingestion_rate_strategy" json:"ingestion_rate_strategy"`
+	IngestionBurstSize                int                     `yaml:"ingestion_burst_size" json:"ingestion_burst_size"`
+	NativeHistogramIngestionBurstSize int                     `yaml:"native_histogram_ingestion_burst_size" json:"native_histogram_ingestion_burst_size"`
+	AcceptHASamples                   bool                    `yaml:"accept_ha_samples" json:"accept_ha_samples"`
+	AcceptMixedHASamples              bool                    `yaml:"accept_mixed_ha_samples" json:"accept_mixed_ha_samples"`
+	HAClusterLabel                    string                  `yaml:"ha_cluster_label" json:"ha_cluster_label"`
+	HAReplicaLabel                    string                  `yaml:"ha_replica_label" json:"ha_replica_label"`
+	HAMaxClusters                     int                     `yaml:"ha_max_clusters" json:"ha_max_clusters"`
+	HATrackerFailoverTimeout          model.Duration          `yaml:"ha_tracker_failover_timeout" json:"ha_tracker_failover_timeout"`
+	HATrackerFastFailoverTimeout      model.Duration          `yaml:"ha_tracker_fast_failover_timeout" json:"ha_tracker_fast_failover_timeout"`
+	DropLabels                        flagext.StringSlice     `yaml:"drop_labels" json:"drop_labels"`
+	MaxRequestBodySizeBytes           int                     `yaml:"max_request_body_size_bytes" json:"max_request_body_size_bytes"`
+	MaxLabelNameLength                int                     `yaml:"max_label_name_length" json:"max_label_name_length"`
+	MaxLabelValueLength               int                     `yaml:"max_label_value_length" json:"max_label_value_length"`
+	MaxLabelNamesPerSeries            int                     `yaml:"max_label_names_per_series" json:"max_label_names_per_series"`
+	MaxLabelsSizeBytes                int                     `yaml:"max_labels_size_bytes" json:"max_labels_size_bytes"`
+	MaxNativeHistogramSampleSizeBytes int                     `yaml:"max_native_histogram_sample_size_bytes" json:"max_native_histogram_sample_size_bytes"`
+	MaxMetadataLength                 int                     `yaml:"max_metadata_length" json:"max_metadata_length"`
+	RejectOldSamples                  bool                    `yaml:"reject_old_samples" json:"reject_old_samples"`
+	RejectOldSamplesMaxAge            model.Duration          `yaml:"reject_old_samples_max_age" json:"reject_old_samples_max_age"`
+	CreationGracePeriod               model.Duration          `yaml:"creation_grace_period" json:"creation_grace_period"`
+	FutureSampleClampTolerance        model.Duration          `yaml:"future_sample_clamp_tolerance" json:"future_sample_clamp_tolerance"`
+	EnforceMetadataMetricName         bool                    `yaml:"enforce_metadata_metric_name" json:"enforce_metadata_metric_name"`
+	EnforceMetricName                 bool                    `yaml:"enforce_metric_name" json:"enforce_metric_name"`
+	MetricNameAllowlist               []string                `yaml:"metric_name_allowlist" json:"metric_name_allowlist"`
+	MetricNameDenylist                []string                `yaml:"metric_name_denylist" json:"metric_name_denylist"`
+	IngestionTenantShardSize          int                     `yaml:"ingestion_tenant_shard_size" json:"ingestion_tenant_shard_size"`
+	MetricRelabelConfigs              []*relabel.Config       `yaml:"metric_relabel_configs,omitempty" json:"metric_relabel_configs,omitempty" doc:"nocli|description=List of metric relabel configurations. Note that in most situations, it is more effective to use metrics relabeling directly in the Prometheus server, e.g. remote_write.write_relabel_configs."`
+	MaxNativeHistogramBuckets         int                     `yaml:"max_native_histogram_buckets" json:"max_native_histogram_buckets"`
+	PromoteResourceAttributes         []string                `yaml:"promote_resource_attributes" json:"promote_resource_attributes"`
+	EnableTypeAndUnitLabels           bool                    `yaml:"enable_type_and_unit_labels" json:"enable_type_and_unit_labels"`
+	EnableStartTimestamp              bool                    `yaml:"enable_start_timestamp" json:"enable_start_timestamp"`
+	OTLPConvertDeltaToCumulative      bool                    `yaml:"otlp_convert_delta_to_cumulative" json:"otlp_convert_delta_to_cumulative"`
+	NativeHistogramClassicBuckets     flagext.Float64SliceCSV `yaml:"native_histogram_classic_buckets" json:"native_histogram_classic_buckets"`
+
+	IngestionRateNativeHistogramBucketWeight float64 `yaml:"ingestion_rate_native_histogram_bucket_weight" json:"ingestion_rate_native_histogram_bucket_weight"`
+	MirrorWritesRatio                        float64 `yaml:"mirror_writes_ratio" json:"mirror_writes_ratio"`
+	KafkaExportEnabled                       bool    `yaml:"kafka_export_enabled" json:"kafka_export_enabled"`
 
 	// Ingester enforced limits.
 	// Series
@@ -166,6 +195,9 @@ type Limits struct {
 	MaxGlobalSeriesPerUser                int                        `yaml:"max_global_series_per_user" json:"max_global_series_per_user"`
 	MaxGlobalSeriesPerMetric              int                        `yaml:"max_global_series_per_metric" json:"max_global_series_per_metric"`
 	MaxGlobalNativeHistogramSeriesPerUser int                        `yaml:"max_global_native_histogram_series_per_user" json:"max_global_native_histogram_series_per_user"`
+	MetricQuarantineSeriesThreshold       int                        `yaml:"metric_quarantine_series_threshold" json:"metric_quarantine_series_threshold"`
+	MetricQuarantineSeriesLowWaterMark    int                        `yaml:"metric_quarantine_series_low_water_mark" json:"metric_quarantine_series_low_water_mark"`
+	MaxSeriesPolicy                       string                     `yaml:"max_series_policy" json:"max_series_policy"`
 	LimitsPerLabelSet                     []LimitsPerLabelSet        `yaml:"limits_per_label_set" json:"limits_per_label_set" doc:"nocli|description=[Experimental] Enable limits per LabelSet. Supported limits per labelSet: [max_series]"`
 	ActiveSeriesTrackers                  ActiveSeriesTrackersConfig `yaml:"active_series_trackers,omitempty" json:"active_series_trackers,omitempty" doc:"nocli|description=List of active series tracker configurations. Each tracker counts active series matching its matchers and exposes the count as a metric."`
 	EnableNativeHistograms                bool                       `yaml:"enable_native_histograms" json:"enable_native_histograms"`
@@ -181,9 +213,12 @@ type Limits struct {
 	MaxGlobalMetricsWithMetadataPerUser int `yaml:"max_global_metadata_per_user" json:"max_global_metadata_per_user"`
 	MaxGlobalMetadataPerMetric          int `yaml:"max_global_metadata_per_metric" json:"max_global_metadata_per_metric"`
 	// Out-of-order
-	OutOfOrderTimeWindow model.Duration `yaml:"out_of_order_time_window" json:"out_of_order_time_window"`
+	OutOfOrderTimeWindow            model.Duration `yaml:"out_of_order_time_window" json:"out_of_order_time_window"`
+	AcceptIdenticalDuplicateSamples bool           `yaml:"accept_identical_duplicate_samples" json:"accept_identical_duplicate_samples"`
+	HeadRetentionPeriod             model.Duration `yaml:"head_retention_period" json:"head_retention_period"`
 	// Exemplars
-	MaxExemplars int `yaml:"max_exemplars" json:"max_exemplars"`
+	MaxExemplars         int `yaml:"max_exemplars" json:"max_exemplars"`
+	MaxExemplarsPerQuery int `yaml:"max_exemplars_per_query" json:"max_exemplars_per_query"`
 
 	// Querier enforced limits.
 	MaxChunksPerQuery            int            `yaml:"max_fetched_chunks_per_query" json:"max_fetched_chunks_per_query"`
@@ -191,6 +226,7 @@ type Limits struct {
 	MaxFetchedChunkBytesPerQuery int            `yaml:"max_fetched_chunk_bytes_per_query" json:"max_fetched_chunk_bytes_per_query"`
 	MaxFetchedDataBytesPerQuery  int            `yaml:"max_fetched_data_bytes_per_query" json:"max_fetched_data_bytes_per_query"`
 	MaxQueryLookback             model.Duration `yaml:"max_query_lookback" json:"max_query_lookback"`
+	QueryLookbackDelta           model.Duration `yaml:"query_lookback_delta" json:"query_lookback_delta"`
 	MaxQueryLength               model.Duration `yaml:"max_query_length" json:"max_query_length"`
 	MaxQueryParallelism          int            `yaml:"max_query_parallelism" json:"max_query_parallelism"`
 	MaxQueryResponseSize         int64          `yaml:"max_query_response_size" json:"max_query_response_size"`
@@ -199,8 +235,9 @@ type Limits struct {
 	OutOfOrderResultsCacheTTL    model.Duration `yaml:"out_of_order_results_cache_ttl" json:"out_of_order_results_cache_ttl"`
 	MaxQueriersPerTenant         float64        `yaml:"max_queriers_per_tenant" json:"max_queriers_per_tenant"`
 	QueryVerticalShardSize       int            `yaml:"query_vertical_shard_size" json:"query_vertical_shard_size"`
-	QueryPartialData             bool           `yaml:"query_partial_data" json:"query_partial_data" doc:"nocli|description=Enable to allow queries to be evaluated with data from a single zone, if other zones are not available.|default=false"`
+	QueryPartialData             bool           `yaml:"query_partial_data" json:"query_partial_data" doc:"nocli|description=Enable to allow queries to be evaluated with data from a single zone, if other zones are not available, and with the blocks queried so far, if some blocks can't be queried from store-gateways. A warning is returned when the query result may contain partial data.|default=false"`
 	QueryIngestersWithin         model.Duration `yaml:"query_ingesters_within" json:"query_ingesters_within"`
+	QueryEngine                  string         `yaml:"query_engine" json:"query_engine"`
 
 	// If set, the querier manipulates the max time to not be greater than
 	// "now - queryStoreAfter" so that most recent blocks are not queried.
@@ -220,25 +257,31 @@ type Limits struct {
 	QueryRejection              QueryRejection `yaml:"query_rejection" json:"query_rejection" doc:"nocli|description=Configuration for query rejection."`
 
 	// Ruler defaults and limits.
-	RulerEvaluationDelay           model.Duration `yaml:"ruler_evaluation_delay_duration" json:"ruler_evaluation_delay_duration"`
-	RulerTenantShardSize           float64        `yaml:"ruler_tenant_shard_size" json:"ruler_tenant_shard_size"`
-	RulerMaxRulesPerRuleGroup      int            `yaml:"ruler_max_rules_per_rule_group" json:"ruler_max_rules_per_rule_group"`
-	RulerMaxRuleGroupsPerTenant    int            `yaml:"ruler_max_rule_groups_per_tenant" json:"ruler_max_rule_groups_per_tenant"`
-	RulerQueryOffset               model.Duration `yaml:"ruler_query_offset" json:"ruler_query_offset"`
-	RulerExternalLabels            labels.Labels  `yaml:"ruler_external_labels" json:"ruler_external_labels" doc:"nocli|description=external labels for alerting rules"`
-	RulerExternalURL               string         `yaml:"ruler_external_url" json:"ruler_external_url" doc:"nocli|description=Per-tenant external URL for the ruler. If set, it overrides the global -ruler.external.url for this tenant's alert notifications."`
-	RulerAlertGeneratorURLTemplate string         `yaml:"ruler_alert_generator_url_template" json:"ruler_alert_generator_url_template" doc:"nocli|description=Go text/template for alert generator URLs. Available variables: .ExternalURL (resolved external URL) and .Expression (PromQL expression). Built-in functions like urlquery are available. A jsonEscape function is also provided for embedding expressions inside JSON-encoded URL parameters. If empty, uses default Prometheus /graph format."`
-	RulesPartialData               bool           `yaml:"rules_partial_data" json:"rules_partial_data" doc:"nocli|description=Enable to allow rules to be evaluated with data from a single zone, if other zones are not available.|default=false"`
+	RulerEvaluationDelay                   model.Duration `yaml:"ruler_evaluation_delay_duration" json:"ruler_evaluation_delay_duration"`
+	RulerTenantShardSize                   float64        `yaml:"ruler_tenant_shard_size" json:"ruler_tenant_shard_size"`
+	RulerMaxRulesPerRuleGroup              int            `yaml:"ruler_max_rules_per_rule_group" json:"ruler_max_rules_per_rule_group"`
+	RulerMaxRuleGroupsPerTenant            int            `yaml:"ruler_max_rule_groups_per_tenant" json:"ruler_max_rule_groups_per_tenant"`
+	RulerMaxConcurrentRuleGroupEvaluations int            `yaml:"ruler_max_concurrent_rule_group_evaluations" json:"ruler_max_concurrent_rule_group_evaluations"`
+	RulerQueryOffset                       model.Duration `yaml:"ruler_query_offset" json:"ruler_query_offset"`
+	RulerExternalLabels                    labels.Labels  `yaml:"ruler_external_labels" json:"ruler_external_labels" doc:"nocli|description=external labels for alerting rules"`
+	RulerExternalURL                       string         `yaml:"ruler_external_url" json:"ruler_external_url" doc:"nocli|description=Per-tenant external URL for the ruler. If set, it overrides the global -ruler.external.url for this tenant's alert notifications."`
+	RulerAlertGeneratorURLTemplate         string         `yaml:"ruler_alert_generator_url_template" json:"ruler_alert_generator_url_template" doc:"nocli|description=Go text/template for alert generator URLs. Available variables: .ExternalURL (resolved external URL) and .Expression (PromQL expression). Built-in functions like urlquery are available. A jsonEscape function is also provided for embedding expressions inside JSON-encoded URL parameters. If empty, uses default Prometheus /graph format."`
+	RulesPartialData                       bool           `yaml:"rules_partial_data" json:"rules_partial_data" doc:"nocli|description=Enable to allow rules to be evaluated with data from a single zone, if other zones are not available, and with the blocks queried so far, if some blocks can't be queried from store-gateways.|default=false"`
 
 	// Store-gateway.
-	StoreGatewayTenantShardSize  float64 `yaml:"store_gateway_tenant_shard_size" json:"store_gateway_tenant_shard_size"`
-	MaxDownloadedBytesPerRequest int     `yaml:"max_downloaded_bytes_per_request" json:"max_downloaded_bytes_per_request"`
+	StoreGatewayTenantShardSize  float64        `yaml:"store_gateway_tenant_shard_size" json:"store_gateway_tenant_shard_size"`
+	MaxDownloadedBytesPerRequest int            `yaml:"max_downloaded_bytes_per_request" json:"max_downloaded_bytes_per_request"`
+	IgnoreDeletionMarksDelay     model.Duration `yaml:"ignore_deletion_marks_delay" json:"ignore_deletion_marks_delay"`
+	IgnoreBlocksWithin           model.Duration `yaml:"ignore_blocks_within" json:"ignore_blocks_within"`
 
 	// Compactor.
 	CompactorBlocksRetentionPeriod   model.Duration `yaml:"compactor_blocks_retention_period" json:"compactor_blocks_retention_period"`
 	CompactorTenantShardSize         float64        `yaml:"compactor_tenant_shard_size" json:"compactor_tenant_shard_size"`
 	CompactorPartitionIndexSizeBytes int64          `yaml:"compactor_partition_index_size_bytes" json:"compactor_partition_index_size_bytes"`
 	CompactorPartitionSeriesCount    int64          `yaml:"compactor_partition_series_count" json:"compactor_partition_series_count"`
+	CompactorMaxCompactionLevel      int            `yaml:"compactor_max_compaction_level" json:"compactor_max_compaction_level"`
+	CompactorVerticalCompactionOnly  bool           `yaml:"compactor_vertical_compaction_only" json:"compactor_vertical_compaction_only"`
+	CompactorMinBlockAge             model.Duration `yaml:"compactor_min_block_age" json:"compactor_min_block_age"`
 
 	// Parquet converter
 	ParquetConverterEnabled         bool     `yaml:"parquet_converter_enabled" json:"parquet_converter_enabled"`
@@ -253,9 +296,13 @@ type Limits struct {
 	// Alertmanager.
 	AlertmanagerReceiversBlockCIDRNetworks     flagext.CIDRSliceCSV `yaml:"alertmanager_receivers_firewall_block_cidr_networks" json:"alertmanager_receivers_firewall_block_cidr_networks"`
 	AlertmanagerReceiversBlockPrivateAddresses bool                 `yaml:"alertmanager_receivers_firewall_block_private_addresses" json:"alertmanager_receivers_firewall_block_private_addresses"`
+	AlertmanagerReceiversBlockHosts            []string             `yaml:"alertmanager_receivers_firewall_block_hosts" json:"alertmanager_receivers_firewall_block_hosts"`
+	AlertmanagerReceiversAllowCIDRNetworks     flagext.CIDRSliceCSV `yaml:"alertmanager_receivers_firewall_allow_cidr_networks" json:"alertmanager_receivers_firewall_allow_cidr_networks"`
+	AlertmanagerReceiversAllowHosts            []string             `yaml:"alertmanager_receivers_firewall_allow_hosts" json:"alertmanager_receivers_firewall_allow_hosts"`
 
 	NotificationRateLimit               float64                  `yaml:"alertmanager_notification_rate_limit" json:"alertmanager_notification_rate_limit"`
 	NotificationRateLimitPerIntegration NotificationRateLimitMap `yaml:"alertmanager_notification_rate_limit_per_integration" json:"alertmanager_notification_rate_limit_per_integration"`
+	NotificationRateLimitQueueSize      int                      `yaml:"alertmanager_notification_rate_limit_queue_size" json:"alertmanager_notification_rate_limit_queue_size"`
 
 	AlertmanagerMaxConfigSizeBytes             int                `yaml:"alertmanager_max_config_size_bytes" json:"alertmanager_max_config_size_bytes"`
 	AlertmanagerMaxTemplatesCount              int                `yaml:"alertmanager_max_templates_count" json:"alertmanager_max_templates_count"`
@@ -278,6 +325,9 @@ func (l *Limits) RegisterFlags(f *flag.FlagSet) {
 	f.StringVar(&l.IngestionRateStrategy, "distributor.ingestion-rate-limit-strategy", "local", "Whether the ingestion rate limit should be applied individually to each distributor instance (local), or evenly shared across the cluster (global).")
 	f.IntVar(&l.IngestionBurstSize, "distributor.ingestion-burst-size", 50000, "Per-user allowed ingestion burst size (in number of samples).")
 	f.IntVar(&l.NativeHistogramIngestionBurstSize, "distributor.native-histogram-ingestion-burst-size", 0, "Per-user allowed native histogram ingestion burst size (in number of samples)")
+	f.Float64Var(&l.IngestionRateNativeHistogramBucketWeight, "distributor.ingestion-rate-native-histogram-bucket-weight", 0, "Per-user weight of each native histogram bucket in the ingestion rate limit. Each native histogram sample counts as 1 + weight * number of buckets samples, rounded up, so that the ingestion rate limit reflects the cost of native histograms. 0 to count each native histogram sample as a single sample, like float samples.")
+	f.Float64Var(&l.MirrorWritesRatio, "distributor.mirror-writes-ratio", 0, "EXPERIMENTAL: Per-user ratio of the series mirrored to the -distributor.mirror.url remote write endpoint, between 0 and 1. The series are selected by the hash of their labels, so a given series is either always or never mirrored. 0 to disable the mirroring.")
+	f.BoolVar(&l.KafkaExportEnabled, "distributor.kafka-export-enabled", true, "EXPERIMENTAL: Per-user flag to export the accepted series to the Kafka topic. It only takes effect when -distributor.kafka-export.addresses is set.")
 	f.BoolVar(&l.AcceptHASamples, "distributor.ha-tracker.enable-for-all-users", false, "Flag to enable, for all users, handling of samples with external labels identifying replicas in an HA Prometheus setup.")
 	f.BoolVar(&l.AcceptMixedHASamples, "experimental.distributor.ha-tracker.mixed-ha-samples", false, "[Experimental] Flag to enable handling of samples with mixed external labels identifying replicas in an HA Prometheus setup. Supported only if -distributor.ha-tracker.enable-for-all-users is true.")
 	f.StringVar(&l.HAClusterLabel, "distributor.ha-tracker.cluster", "cluster", "Prometheus label to look for in samples to identify a Prometheus HA cluster.")
@@ -285,9 +335,12 @@ func (l *Limits) RegisterFlags(f *flag.FlagSet) {
 	f.IntVar(&l.HAMaxClusters, "distributor.ha-tracker.max-clusters", 0, "Maximum number of clusters that HA tracker will keep track of for single user. 0 to disable the limit.")
 	_ = l.HATrackerFailoverTimeout.Set("30s")
 	f.Var(&l.HATrackerFailoverTimeout, "distributor.ha-tracker.failover-timeout", "If the elected replica doesn't send samples in this time, the HA tracker will accept a new replica. This value must be greater than the update timeout plus the maximum jitter.")
+	f.Var(&l.HATrackerFastFailoverTimeout, "distributor.ha-tracker.fast-failover-timeout", "[Experimental] If greater than 0, the HA tracker keeps track of the last time samples were received from every replica of a cluster, and accepts a new replica if the elected one doesn't send samples in this time while the majority of the cluster replicas is still sending samples. This allows to failover faster for clusters with more than two replicas, while the failover timeout is still applied to clusters with two replicas. This value must be greater than the update timeout plus the maximum jitter, and lower than the failover timeout. 0 to disable.")
 	f.Var((*flagext.StringSliceCSV)(&l.PromoteResourceAttributes), "distributor.promote-resource-attributes", "Comma separated list of resource attributes that should be converted to labels.")
 	f.Var(&l.DropLabels, "distributor.drop-label", "This flag can be used to specify label names that to drop during sample ingestion within the distributor and can be repeated in order to drop multiple labels.")
+	f.IntVar(&l.MaxRequestBodySizeBytes, "distributor.max-request-body-size-bytes", 0, "Per-user maximum size in bytes of the decompressed body of a remote write request. The requests exceeding it are rejected with 413 before being unmarshalled. It's effective only if lower than -distributor.max-recv-msg-size. 0 to disable the limit.")
 	f.BoolVar(&l.EnableTypeAndUnitLabels, "distributor.enable-type-and-unit-labels", false, "EXPERIMENTAL: If true, the __type__ and __unit__ labels are added to metrics. This applies to remote write v2 and OTLP requests.")
+	f.BoolVar(&l.OTLPConvertDeltaToCumulative, "distributor.otlp.convert-delta-to-cumulative", false, "EXPERIMENTAL: If true, the distributor converts the delta temporality OTLP sums and histograms to cumulative, keeping the running total of each series in memory. The deltas of a series must be always sent to the same distributor, and the running total restarts from zero (seen as a counter reset) when the distributor restarts or the series state is evicted.")
 	f.BoolVar(&l.EnableStartTimestamp, "distributor.enable-start-timestamp", false, "EXPERIMENTAL: If true, StartTimestampMs (ST) is handled for remote write v2 samples and histograms. CreatedTimestamp (CT) is used as a fallback when ST is not set.")
 	f.IntVar(&l.MaxLabelNameLength, "validation.max-length-label-name", 1024, "Maximum length accepted for label names")
 	f.IntVar(&l.MaxLabelValueLength, "validation.max-length-label-value", 2048, "Maximum length accepted for label value. This setting also applies to the metric name")
@@ -300,8 +353,12 @@ func (l *Limits) RegisterFlags(f *flag.FlagSet) {
 	f.Var(&l.RejectOldSamplesMaxAge, "validation.reject-old-samples.max-age", "Maximum accepted sample age before rejecting.")
 	_ = l.CreationGracePeriod.Set("10m")
 	f.Var(&l.CreationGracePeriod, "validation.create-grace-period", "Duration which table will be created/deleted before/after it's needed; we won't accept sample from before this time.")
+	f.Var(&l.FutureSampleClampTolerance, "validation.future-sample-clamp-tolerance", "EXPERIMENTAL: Samples with a timestamp in the future by no more than this tolerance have their timestamp set to the current time, before being validated. When multiple samples of a series are clamped, only the newest one is kept. Samples further in the future are still rejected if their timestamp is beyond -validation.create-grace-period. 0 to disable.")
 	f.BoolVar(&l.EnforceMetricName, "validation.enforce-metric-name", true, "Enforce every sample has a metric name.")
+	f.Var((*flagext.StringSliceCSV)(&l.MetricNameAllowlist), "validation.metric-name-allowlist", "Comma separated list of the metric names the tenant is allowed to push. An entry is either a metric name or a glob pattern, where * matches any sequence of characters (eg. node_*). The series whose metric name doesn't match any entry are rejected. If empty, all the metric names are allowed.")
+	f.Var((*flagext.StringSliceCSV)(&l.MetricNameDenylist), "validation.metric-name-denylist", "Comma separated list of the metric names the tenant is not allowed to push. An entry is either a metric name or a glob pattern, where * matches any sequence of characters (eg. node_*). The series whose metric name matches an entry are rejected, even if the metric name is in the allowlist.")
 	f.BoolVar(&l.EnforceMetadataMetricName, "validation.enforce-metadata-metric-name", true, "Enforce every metadata has a metric name.")
+	f.Var(&l.NativeHistogramClassicBuckets, "distributor.native-histogram-classic-buckets", "EXPERIMENTAL: Comma separated list of the upper bounds of the classic histogram buckets materialized from the received native histograms, to let queriers not supporting native histograms query them. For each native histogram sample, a <name>_bucket series per upper bound (plus +Inf), a <name>_count and a <name>_sum series are ingested in addition to the native histogram. The upper bounds must be in increasing order. If empty, classic histograms are not materialized.")
 	f.IntVar(&l.MaxNativeHistogramBuckets, "validation.max-native-histogram-buckets", 0, "Limit on total number of positive and negative buckets allowed in a single native histogram. The resolution of a histogram with more buckets will be reduced until the number of buckets is within the limit. If the limit cannot be reached, the sample will be discarded. 0 means no limit. Enforced at Distributor.")
 
 	// Regex limits.
@@ -315,9 +372,15 @@ func (l *Limits) RegisterFlags(f *flag.FlagSet) {
 	f.IntVar(&l.MaxGlobalSeriesPerMetric, "ingester.max-global-series-per-metric", 0, "The maximum number of active series per metric name, across the cluster before replication. 0 to disable.")
 	f.IntVar(&l.MaxLocalNativeHistogramSeriesPerUser, "ingester.max-native-histogram-series-per-user", 0, "The maximum number of active native histogram series per user, per ingester. 0 to disable. Supported only if ingester.active-series-metrics-enabled is true.")
 	f.IntVar(&l.MaxGlobalNativeHistogramSeriesPerUser, "ingester.max-global-native-histogram-series-per-user", 0, "The maximum number of active native histogram series per user, across the cluster before replication. 0 to disable. Supported only if -distributor.shard-by-all-labels and ingester.active-series-metrics-enabled is true.")
+	f.IntVar(&l.MetricQuarantineSeriesThreshold, "ingester.metric-quarantine-series-threshold", 0, "The number of active series per metric name, per ingester, above which the metric is quarantined: new series for the metric are rejected, while the existing ones are still ingested and queried. The cortex_ingester_quarantined_metrics metric reports the quarantined metrics. 0 to disable.")
+	f.IntVar(&l.MetricQuarantineSeriesLowWaterMark, "ingester.metric-quarantine-series-low-water-mark", 0, "The number of active series per metric name, per ingester, at or below which the quarantine of a metric is cleared. 0 to clear the quarantine as soon as the metric has fewer series than -ingester.metric-quarantine-series-threshold.")
+	f.StringVar(&l.MaxSeriesPolicy, "ingester.max-series-policy", MaxSeriesPolicyRejectNew, "EXPERIMENTAL: Behavior of the ingester once the user reached the max series per user limit. Supported values: reject-new, evict-idle. reject-new rejects the new series. evict-idle also rejects the new series, and, if at least 10% of the series are not appended within -ingester.active-series-metrics-idle-timeout, triggers a compaction of the TSDB head block ranges older than the one of the most recent sample, to evict the series with no sample left in the head: their samples are compacted into a block, so they are not lost, and the new series are admitted once these series are removed from the head. These compactions run at most once per block range, and not more often than -blocks-storage.tsdb.series-compaction-min-interval. evict-idle is supported only if -ingester.active-series-metrics-enabled is true.")
 	f.BoolVar(&l.EnableNativeHistograms, "blocks-storage.tsdb.enable-native-histograms", false, "[EXPERIMENTAL] True to enable native histogram.")
 	f.IntVar(&l.MaxExemplars, "ingester.max-exemplars", 0, "Enables support for exemplars in TSDB and sets the maximum number that will be stored. less than zero means disabled. If the value is set to zero, cortex will fallback to blocks-storage.tsdb.max-exemplars value.")
-	f.Var(&l.OutOfOrderTimeWindow, "ingester.out-of-order-time-window", "[Experimental] Configures the allowed time window for ingestion of out-of-order samples. Disabled (0s) by default.")
+	f.IntVar(&l.MaxExemplarsPerQuery, "ingester.max-exemplars-per-query", 0, "The maximum number of exemplars each ingester returns for a single exemplar query. Exemplars in excess are truncated, and the response is marked as truncated. 0 to disable.")
+	f.Var(&l.OutOfOrderTimeWindow, "ingester.out-of-order-time-window", "[Experimental] Configures the allowed time window for ingestion of out-of-order samples. Changes of the per-tenant override are applied by the next push. Disabled (0s) by default.")
+	f.BoolVar(&l.AcceptIdenticalDuplicateSamples, "ingester.accept-identical-duplicate-samples", false, "[Experimental] True to accept the out-of-order and too old samples which are exact duplicates (same series, timestamp and value) of a sample already in the TSDB head, so that retrying a write request is idempotent. Samples with the same timestamp but a different value are still rejected.")
+	f.Var(&l.HeadRetentionPeriod, "ingester.head-retention-period", "[Experimental] Max time range of the samples kept in the TSDB head of the tenant. Once the head spans more than 1.5 times this period, its samples older than the period are compacted into blocks at the next head compaction interval, without blocking the pushes, truncating the head to free its memory sooner. The blocks are shipped to the storage like the other blocks, and aren't deleted from the ingester before being shipped. The period must be lower than the smallest -blocks-storage.tsdb.block-ranges-period to have any effect, since the head is already truncated to it, and periods lower than half of it are applied as half of it, since the samples can still be appended within it. 0 to disable.")
 
 	f.IntVar(&l.MaxLocalMetricsWithMetadataPerUser, "ingester.max-metadata-per-user", 8000, "The maximum number of active metrics with metadata per user, per ingester. 0 to disable.")
 	f.IntVar(&l.MaxLocalMetadataPerMetric, "ingester.max-metadata-per-metric", 10, "The maximum number of metadata per metric, per ingester. 0 to disable.")
@@ -337,8 +400,9 @@ func (l *Limits) RegisterFlags(f *flag.FlagSet) {
 	_ = l.ShuffleShardingIngestersLookbackPeriod.Set("0")
 	f.Var(&l.ShuffleShardingIngestersLookbackPeriod, "limits.shuffle-sharding-ingesters-lookback-period", "Lookback period for shuffle sharding of ingesters. This is a per-tenant limit that can be overridden in the runtime configuration. Should be greater than or equal to query-ingesters-within.")
 
-	f.Var(&l.MaxQueryLength, "store.max-query-length", "Limit the query time range (end - start time of range query parameter and max - min of data fetched time range). This limit is enforced in the query-frontend and ruler (on the received query). 0 to disable.")
+	f.Var(&l.MaxQueryLength, "store.max-query-length", "Limit the query time range (end - start time of range query parameter and max - min of data fetched time range). This limit is enforced in the query-frontend and ruler on the received query, before it is split and sharded, and in the querier on each query it evaluates unless -querier.ignore-max-query-length is enabled. 0 to disable.")
 	f.Var(&l.MaxQueryLookback, "querier.max-query-lookback", "Limit how long back data (series and metadata) can be queried, up until <lookback> duration ago. This limit is enforced in the query-frontend, querier and ruler. If the requested time range is outside the allowed range, the request will not fail but will be manipulated to only query data within the allowed time range. 0 to disable.")
+	f.Var(&l.QueryLookbackDelta, "querier.tenant-lookback-delta", "Per-tenant time since the last sample after which a time series is considered stale and ignored by expression evaluations in the querier and ruler. It overrides -querier.lookback-delta for the tenant, while the lookback_delta query parameter still takes precedence. The query-frontend results cache doesn't take the lookback delta into account: the results cached before changing it are served until they expire. 0 to use -querier.lookback-delta.")
 	f.IntVar(&l.MaxQueryParallelism, "querier.max-query-parallelism", 14, "Maximum number of split queries will be scheduled in parallel by the frontend.")
 	_ = l.MaxCacheFreshness.Set("1m")
 	f.Int64Var(&l.MaxQueryResponseSize, "frontend.max-query-response-size", 0, "The maximum total uncompressed query response size. If the query was sharded the limit is applied to the total response size of all shards. This limit is enforced in query-frontend for `query` and `query_range` APIs. 0 to disable.")
@@ -347,6 +411,7 @@ func (l *Limits) RegisterFlags(f *flag.FlagSet) {
 	f.Var(&l.ResultsCacheTTL, "frontend.results-cache-ttl", "Per-tenant TTL for cached query results in the cache backend (Memcached/Redis/FIFO). This is the standard TTL for results that do not overlap with the out-of-order time window. 0 (default) means use the global cache backend TTL configuration.")
 	f.Var(&l.OutOfOrderResultsCacheTTL, "frontend.out-of-order-results-cache-ttl", "Per-tenant TTL for cached query results that overlap with the out-of-order time window. These results may still receive out-of-order samples, so they typically use a shorter TTL. 0 (default) means use the global cache backend TTL configuration.")
 	f.Float64Var(&l.MaxQueriersPerTenant, "frontend.max-queriers-per-tenant", 0, "Maximum number of queriers that can handle requests for a single tenant. If set to 0 or value higher than number of available queriers, *all* queriers will handle requests for the tenant. If the value is < 1, it will be treated as a percentage and the gets a percentage of the total queriers. Each frontend (or query-scheduler, if used) will select the same set of queriers for the same tenant (given that all queriers are connected to all frontends / query-schedulers). This option only works with queriers connecting to the query-frontend / query-scheduler, not when using downstream URL.")
+	f.StringVar(&l.QueryEngine, "querier.query-engine", "", "[Experimental] Per-user PromQL engine evaluating the queries and the rules. Supported values: prometheus, thanos. Empty to use the engine selected by -querier.thanos-engine and -ruler.thanos-engine.")
 	f.IntVar(&l.QueryVerticalShardSize, "frontend.query-vertical-shard-size", 0, "[Experimental] Number of shards to use when distributing shardable PromQL queries.")
 	f.BoolVar(&l.QueryPriority.Enabled, "frontend.query-priority.enabled", false, "Whether queries are assigned with priorities.")
 	f.Int64Var(&l.QueryPriority.DefaultPriority, "frontend.query-priority.default-priority", 0, "Priority assigned to all queries by default. Must be a unique value. Use this as a baseline to make certain queries higher/lower priority.")
@@ -358,6 +423,7 @@ func (l *Limits) RegisterFlags(f *flag.FlagSet) {
 	f.Float64Var(&l.RulerTenantShardSize, "ruler.tenant-shard-size", 0, "The default tenant's shard size when the shuffle-sharding strategy is used by ruler. When this setting is specified in the per-tenant overrides, a value of 0 disables shuffle sharding for the tenant. If the value is < 1 the shard size will be a percentage of the total rulers.")
 	f.IntVar(&l.RulerMaxRulesPerRuleGroup, "ruler.max-rules-per-rule-group", 0, "Maximum number of rules per rule group per-tenant. 0 to disable.")
 	f.IntVar(&l.RulerMaxRuleGroupsPerTenant, "ruler.max-rule-groups-per-tenant", 0, "Maximum number of rule groups per-tenant. 0 to disable.")
+	f.IntVar(&l.RulerMaxConcurrentRuleGroupEvaluations, "ruler.max-concurrent-rule-group-evaluations", 0, "EXPERIMENTAL: Maximum number of rule groups per-tenant evaluated concurrently by each ruler. The rule group evaluations exceeding the limit are delayed until a running one completes, which may cause missed iterations and is reflected in the rule group last evaluation timestamp. 0 to disable.")
 	f.Var(&l.RulerQueryOffset, "ruler.query-offset", "Duration to offset all rule evaluation queries per-tenant.")
 
 	f.Var(&l.CompactorBlocksRetentionPeriod, "compactor.blocks-retention-period", "Delete blocks containing samples older than the specified retention period. 0 to disable.")
@@ -365,6 +431,9 @@ func (l *Limits) RegisterFlags(f *flag.FlagSet) {
 	// Default to 64GB because this is the hard limit of index size in Cortex
 	f.Int64Var(&l.CompactorPartitionIndexSizeBytes, "compactor.partition-index-size-bytes", 68719476736, "Index size limit in bytes for each compaction partition. 0 means no limit")
 	f.Int64Var(&l.CompactorPartitionSeriesCount, "compactor.partition-series-count", 0, "Time series count limit for each compaction partition. 0 means no limit")
+	f.IntVar(&l.CompactorMaxCompactionLevel, "compactor.max-compaction-level", 0, "Maximum compaction level of the blocks produced by the compactor for the tenant. Blocks which have already reached this level are not merged any further. Only supported by the shuffle-sharding strategy. 0 means no limit")
+	f.BoolVar(&l.CompactorVerticalCompactionOnly, "compactor.vertical-compaction-only", false, "EXPERIMENTAL: If enabled, the compactor only merges blocks overlapping each other (vertical compaction) for the tenant, and skips the groups of blocks which would require merging adjacent time ranges (horizontal compaction). Only supported by the shuffle-sharding strategy.")
+	f.Var(&l.CompactorMinBlockAge, "compactor.min-block-age", "EXPERIMENTAL: Minimum age of a block, based on its creation time, before the compactor considers it for compaction for the tenant. Younger blocks are excluded from the compaction groups until they age in, giving the store-gateways and the late overlapping blocks a stabilization window. The blocks produced by the compactor are not affected. Only supported by the shuffle-sharding strategy. 0 to disable.")
 
 	f.Float64Var(&l.ParquetConverterTenantShardSize, "parquet-converter.tenant-shard-size", 0, "The default tenant's shard size when the shuffle-sharding strategy is used by the parquet converter. When this setting is specified in the per-tenant overrides, a value of 0 disables shuffle sharding for the tenant. If the value is < 1 and > 0 the shard size will be a percentage of the total parquet converters.")
 	f.BoolVar(&l.ParquetConverterEnabled, "parquet-converter.enabled", false, "If set, enables the Parquet converter to create the parquet files.")
@@ -378,10 +447,15 @@ func (l *Limits) RegisterFlags(f *flag.FlagSet) {
 	// Store-gateway.
 	f.Float64Var(&l.StoreGatewayTenantShardSize, "store-gateway.tenant-shard-size", 0, "The default tenant's shard size when the shuffle-sharding strategy is used. Must be set when the store-gateway sharding is enabled with the shuffle-sharding strategy. When this setting is specified in the per-tenant overrides, a value of 0 disables shuffle sharding for the tenant. If the value is < 1 the shard size will be a percentage of the total store-gateways.")
 	f.IntVar(&l.MaxDownloadedBytesPerRequest, "store-gateway.max-downloaded-bytes-per-request", 0, "The maximum number of data bytes to download per gRPC request in Store Gateway, including Series/LabelNames/LabelValues requests. 0 to disable.")
+	f.Var(&l.IgnoreDeletionMarksDelay, "store-gateway.ignore-deletion-marks-delay", "Per-tenant duration after which the blocks marked for deletion will be filtered out while fetching blocks in the store-gateway and the querier. 0 (default) means use the value of -blocks-storage.bucket-store.ignore-deletion-marks-delay.")
+	f.Var(&l.IgnoreBlocksWithin, "store-gateway.ignore-blocks-within", "Per-tenant duration: the blocks created since `now() - ignore_blocks_within` will not be synced by the store-gateway. 0 (default) means use the value of -blocks-storage.bucket-store.ignore-blocks-within.")
 
 	// Alertmanager.
 	f.Var(&l.AlertmanagerReceiversBlockCIDRNetworks, "alertmanager.receivers-firewall-block-cidr-networks", "Comma-separated list of network CIDRs to block in Alertmanager receiver integrations.")
 	f.BoolVar(&l.AlertmanagerReceiversBlockPrivateAddresses, "alertmanager.receivers-firewall-block-private-addresses", false, "True to block private and local addresses in Alertmanager receiver integrations. It blocks private addresses defined by  RFC 1918 (IPv4 addresses) and RFC 4193 (IPv6 addresses), as well as loopback, local unicast and local multicast addresses.")
+	f.Var((*flagext.StringSliceCSV)(&l.AlertmanagerReceiversBlockHosts), "alertmanager.receivers-firewall-block-hosts", "Comma-separated list of hosts to block in Alertmanager receiver integrations. An entry is either a host name, or *.domain to block all the subdomains of the domain.")
+	f.Var(&l.AlertmanagerReceiversAllowCIDRNetworks, "alertmanager.receivers-firewall-allow-cidr-networks", "Comma-separated list of network CIDRs allowed in Alertmanager receiver integrations. If this list or -alertmanager.receivers-firewall-allow-hosts is not empty, only the addresses matching either of them are allowed. The block rules take precedence over the allow rules.")
+	f.Var((*flagext.StringSliceCSV)(&l.AlertmanagerReceiversAllowHosts), "alertmanager.receivers-firewall-allow-hosts", "Comma-separated list of hosts allowed in Alertmanager receiver integrations. An entry is either a host name, or *.domain to allow all the subdomains of the domain. If this list or -alertmanager.receivers-firewall-allow-cidr-networks is not empty, only the addresses matching either of them are allowed. The block rules take precedence over the allow rules.")
 
 	f.Float64Var(&l.NotificationRateLimit, "alertmanager.notification-rate-limit", 0, "Per-user rate limit for sending notifications from Alertmanager in notifications/sec. 0 = rate limit disabled. Negative value = no notifications are allowed.")
 
@@ -389,6 +463,7 @@ func (l *Limits) RegisterFlags(f *flag.FlagSet) {
 		l.NotificationRateLimitPerIntegration = NotificationRateLimitMap{}
 	}
 	f.Var(&l.NotificationRateLimitPerIntegration, "alertmanager.notification-rate-limit-per-integration", "Per-integration notification rate limits. Value is a map, where each key is integration name and value is a rate-limit (float). On command line, this map is given in JSON format. Rate limit has the same meaning as -alertmanager.notification-rate-limit, but only applies for specific integration. Allowed integration names: "+strings.Join(allowedIntegrationNames, ", ")+".")
+	f.IntVar(&l.NotificationRateLimitQueueSize, "alertmanager.notification-rate-limit-queue-size", 0, "Per-user maximum number of rate-limited notifications per integration which wait for the rate limit to allow them, instead of being dropped immediately. Once the queue is full, or a queued notification can't be sent before its timeout, the notification is dropped. 0 = rate-limited notifications are dropped immediately.")
 	f.IntVar(&l.AlertmanagerMaxConfigSizeBytes, "alertmanager.max-config-size-bytes", 0, "Maximum size of configuration file for Alertmanager that tenant can upload via Alertmanager API. 0 = no limit.")
 	f.IntVar(&l.AlertmanagerMaxTemplatesCount, "alertmanager.max-templates-count", 0, "Maximum number of templates in tenant's Alertmanager configuration uploaded via Alertmanager API. 0 = no limit.")
 	f.IntVar(&l.AlertmanagerMaxTemplateSizeBytes, "alertmanager.max-template-size-bytes", 0, "Maximum size of single template in tenant's Alertmanager configuration uploaded via Alertmanager API. 0 = no limit.")
@@ -419,6 +494,40 @@ func (l *Limits) Validate(nameValidationScheme model.ValidationScheme, shardByAl
 		return errMaxLocalNativeHistogramSeriesPerUserValidation
 	}
 
+	switch l.MaxSeriesPolicy {
+	case "", MaxSeriesPolicyRejectNew:
+	case MaxSeriesPolicyEvictIdle:
+		if !activeSeriesMetricsEnabled {
+			return errMaxSeriesPolicyEvictIdleValidation
+		}
+	default:
+		return errInvalidMaxSeriesPolicy
+	}
+
+	for i := 1; i < len(l.NativeHistogramClassicBuckets); i++ {
+		if l.NativeHistogramClassicBuckets[i] <= l.NativeHistogramClassicBuckets[i-1] {
+			return errNativeHistogramClassicBucketsNotIncreasing
+		}
+	}
+
+	if l.IngestionRateNativeHistogramBucketWeight < 0 {
+		return errNegativeIngestionRateNativeHistogramBucketWeight
+	}
+
+	if l.MirrorWritesRatio < 0 || l.MirrorWritesRatio > 1 {
+		return errInvalidMirrorWritesRatio
+	}
+
+	switch l.QueryEngine {
+	case "", "prometheus", "thanos":
+	default:
+		return errInvalidQueryEngine
+	}
+
+	if l.MetricQuarantineSeriesThreshold > 0 && l.MetricQuarantineSeriesLowWaterMark >= l.MetricQuarantineSeriesThreshold {
+		return errMetricQuarantineSeriesLowWaterMark
+	}
+
 	if err := l.RulerExternalLabels.Validate(func(l labels.Label) error {
 		if !nameValidationScheme.IsValidLabelName(l.Name) {
 			return fmt.Errorf("%w: %q", errInvalidLabelName, l.Name)
@@ -442,6 +551,16 @@ func (l *Limits) Validate(nameValidationScheme model.ValidationScheme, shardByAl
 		}
 	}
 
+	if err := validatePromoteResourceAttributes(l.PromoteResourceAttributes); err != nil {
+		return err
+	}
+
+	for _, pattern := range append(slices.Clone(l.MetricNameAllowlist), l.MetricNameDenylist...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("%w: %q", errInvalidMetricNamePattern, pattern)
+		}
+	}
+
 	if l.RulerAlertGeneratorURLTemplate != "" {
 		// Register custom functions so that templates using them pass validation.
 		// The actual implementations are in the ruler package; these stubs just
@@ -459,10 +578,42 @@ func (l *Limits) Validate(nameValidationScheme model.ValidationScheme, shardByAl
 		if time.Duration(l.HATrackerFailoverTimeout) < minFailoverTimeout {
 			return fmt.Errorf("HA Tracker failover timeout (%v) must be at least 1s greater than update timeout - max jitter (%v)", time.Duration(l.HATrackerFailoverTimeout), minFailoverTimeout)
 		}
+
+		if l.HATrackerFastFailoverTimeout > 0 {
+			if time.Duration(l.HATrackerFastFailoverTimeout) < minFailoverTimeout {
+				return fmt.Errorf("HA Tracker fast failover timeout (%v) must be at least 1s greater than update timeout - max jitter (%v)", time.Duration(l.HATrackerFastFailoverTimeout), minFailoverTimeout)
+			}
+			if l.HATrackerFastFailoverTimeout >= l.HATrackerFailoverTimeout {
+				return fmt.Errorf("HA Tracker fast failover timeout (%v) must be lower than the failover timeout (%v)", time.Duration(l.HATrackerFastFailoverTimeout), time.Duration(l.HATrackerFailoverTimeout))
+			}
+		}
+	}
+
+	return nil
+}
+
+// validatePromoteResourceAttributes checks the promoted resource attributes are converted to distinct
+// label names, because the OTLP translation sanitizes them and only one of the attributes colliding on
+// the same label name would be kept.
+func validatePromoteResourceAttributes(attrs []string) error {
+	// The OTLP translation doesn't allow UTF-8 label names, so attributes are always sanitized.
+	namer := otlptranslator.LabelNamer{}
+	seen := make(map[string]string, len(attrs))
+
+	for _, attr := range attrs {
+		name, err := namer.Build(attr)
+		if err != nil {
+			return fmt.Errorf("%w: %q: %v", errInvalidPromoteResourceAttribute, attr, err)
+		}
+		if other, ok := seen[name]; ok {
+			return fmt.Errorf("%w: %q and %q are both converted to %q", errDuplicatePromoteResourceAttribute, other, attr, name)
+		}
+		seen[name] = attr
 	}
 
 	return nil
 }
+
 func (l *Limits) ValidateQueryLimits(userID string, closeIdleTSDBTimeout time.Duration) error {
 	queryIngestersWithin := time.Duration(l.QueryIngestersWithin)
 	queryStoreAfter := time.Duration(l.QueryStoreAfter)
@@ -626,6 +777,10 @@ func (l *Limits) compileQueryAttributeRegex() error {
 			}
 			prioritySet[priority.Priority] = struct{}{}
 
+			if priority.Weight < 0 {
+				return errNegativeQueryPriorityWeight
+			}
+
 			err := l.compileQueryAttributeRegexes(l.QueryPriority.Priorities[i].QueryAttributes, regexChanged, newCompiledRegex)
 			if err != nil {
 				return err
@@ -718,6 +873,21 @@ func (o *Overrides) NativeHistogramIngestionRate(userID string) float64 {
 	return o.GetOverridesForUser(userID).NativeHistogramIngestionRate
 }
 
+// IngestionRateNativeHistogramBucketWeight returns the weight of each native histogram bucket in the ingestion rate limit.
+func (o *Overrides) IngestionRateNativeHistogramBucketWeight(userID string) float64 {
+	return o.GetOverridesForUser(userID).IngestionRateNativeHistogramBucketWeight
+}
+
+// KafkaExportEnabled returns whether the accepted series of the user are exported to Kafka.
+func (o *Overrides) KafkaExportEnabled(userID string) bool {
+	return o.GetOverridesForUser(userID).KafkaExportEnabled
+}
+
+// MirrorWritesRatio returns the ratio of the user series mirrored to the secondary remote write endpoint.
+func (o *Overrides) MirrorWritesRatio(userID string) float64 {
+	return o.GetOverridesForUser(userID).MirrorWritesRatio
+}
+
 // IngestionRateStrategy returns whether the ingestion rate limit should be individually applied
 // to each distributor instance (local) or evenly shared across the cluster (global).
 func (o *Overrides) IngestionRateStrategy() string {
@@ -781,6 +951,11 @@ func (o *Overrides) MaxLabelsSizeBytes(userID string) int {
 	return o.GetOverridesForUser(userID).MaxLabelsSizeBytes
 }
 
+// MaxRequestBodySizeBytes returns the maximum size of the decompressed body of a remote write request.
+func (o *Overrides) MaxRequestBodySizeBytes(userID string) int {
+	return o.GetOverridesForUser(userID).MaxRequestBodySizeBytes
+}
+
 // MaxMetadataLength returns maximum length metadata can be. Metadata refers
 // to the Metric Name, HELP and UNIT.
 func (o *Overrides) MaxMetadataLength(userID string) int {
@@ -798,6 +973,11 @@ func (o *Overrides) RejectOldSamplesMaxAge(userID string) time.Duration {
 	return time.Duration(o.GetOverridesForUser(userID).RejectOldSamplesMaxAge)
 }
 
+// FutureSampleClampTolerance returns how far into the future the timestamp of the samples is clamped to the current time.
+func (o *Overrides) FutureSampleClampTolerance(userID string) time.Duration {
+	return time.Duration(o.GetOverridesForUser(userID).FutureSampleClampTolerance)
+}
+
 // CreationGracePeriod is misnamed, and actually returns how far into the future
 // we should accept samples.
 func (o *Overrides) CreationGracePeriod(userID string) time.Duration {
@@ -839,11 +1019,36 @@ func (o *Overrides) OutOfOrderTimeWindow(userID string) model.Duration {
 	return o.GetOverridesForUser(userID).OutOfOrderTimeWindow
 }
 
+// HeadRetentionPeriod returns the max time range of the samples kept in the TSDB head of the user.
+func (o *Overrides) HeadRetentionPeriod(userID string) model.Duration {
+	return o.GetOverridesForUser(userID).HeadRetentionPeriod
+}
+
+// AcceptIdenticalDuplicateSamples returns whether the ingester accepts the rejected samples which are exact duplicates of an ingested sample.
+func (o *Overrides) AcceptIdenticalDuplicateSamples(userID string) bool {
+	return o.GetOverridesForUser(userID).AcceptIdenticalDuplicateSamples
+}
+
 // MaxGlobalSeriesPerMetric returns the maximum number of series allowed per metric across the cluster.
 func (o *Overrides) MaxGlobalSeriesPerMetric(userID string) int {
 	return o.GetOverridesForUser(userID).MaxGlobalSeriesPerMetric
 }
 
+// MetricQuarantineSeriesThreshold returns the number of series per metric in a single ingester above which the metric is quarantined.
+func (o *Overrides) MetricQuarantineSeriesThreshold(userID string) int {
+	return o.GetOverridesForUser(userID).MetricQuarantineSeriesThreshold
+}
+
+// MetricQuarantineSeriesLowWaterMark returns the number of series per metric in a single ingester at or below which the metric quarantine is cleared.
+func (o *Overrides) MetricQuarantineSeriesLowWaterMark(userID string) int {
+	return o.GetOverridesForUser(userID).MetricQuarantineSeriesLowWaterMark
+}
+
+// MaxSeriesPolicy returns the behavior of the ingester once the user reached the max series per user limit.
+func (o *Overrides) MaxSeriesPolicy(userID string) string {
+	return o.GetOverridesForUser(userID).MaxSeriesPolicy
+}
+
 // LimitsPerLabelSet returns the user limits per labelset across the cluster.
 func (o *Overrides) LimitsPerLabelSet(userID string) []LimitsPerLabelSet {
 	return o.GetOverridesForUser(userID).LimitsPerLabelSet
@@ -893,6 +1098,11 @@ func (o *Overrides) MaxQueryLookback(userID string) time.Duration {
 	return time.Duration(o.GetOverridesForUser(userID).MaxQueryLookback)
 }
 
+// QueryLookbackDelta returns the lookback delta of the queries of the user.
+func (o *Overrides) QueryLookbackDelta(userID string) time.Duration {
+	return time.Duration(o.GetOverridesForUser(userID).QueryLookbackDelta)
+}
+
 // MaxQueryLength returns the limit of the length (in time) of a query.
 func (o *Overrides) MaxQueryLength(userID string) time.Duration {
 	return time.Duration(o.GetOverridesForUser(userID).MaxQueryLength)
@@ -926,12 +1136,18 @@ func (o *Overrides) MaxQueriersPerUser(userID string) float64 {
 	return o.GetOverridesForUser(userID).MaxQueriersPerTenant
 }
 
+// QueryEngine returns the PromQL engine evaluating the queries of the user, or an empty string to use
+// the engine selected by the configuration.
+func (o *Overrides) QueryEngine(userID string) string {
+	return o.GetOverridesForUser(userID).QueryEngine
+}
+
 // QueryVerticalShardSize returns the number of shards to use when distributing shardable PromQL queries.
 func (o *Overrides) QueryVerticalShardSize(userID string) int {
 	return o.GetOverridesForUser(userID).QueryVerticalShardSize
 }
 
-// QueryPartialData returns whether query may be evaluated with data from a single zone, if other zones are not available.
+// QueryPartialData returns whether query may be evaluated with partial data, if some zones or store-gateways are not available.
 func (o *Overrides) QueryPartialData(userID string) bool {
 	return o.GetOverridesForUser(userID).QueryPartialData
 }
@@ -963,6 +1179,16 @@ func (o *Overrides) EnforceMetricName(userID string) bool {
 	return o.GetOverridesForUser(userID).EnforceMetricName
 }
 
+// MetricNameAllowlist returns the metric names the user is allowed to push. Empty means all.
+func (o *Overrides) MetricNameAllowlist(userID string) []string {
+	return o.GetOverridesForUser(userID).MetricNameAllowlist
+}
+
+// MetricNameDenylist returns the metric names the user is not allowed to push.
+func (o *Overrides) MetricNameDenylist(userID string) []string {
+	return o.GetOverridesForUser(userID).MetricNameDenylist
+}
+
 // EnforceMetadataMetricName whether to enforce the presence of a metric name on metadata.
 func (o *Overrides) EnforceMetadataMetricName(userID string) bool {
 	return o.GetOverridesForUser(userID).EnforceMetadataMetricName
@@ -974,6 +1200,12 @@ func (o *Overrides) MaxNativeHistogramBuckets(userID string) int {
 	return o.GetOverridesForUser(userID).MaxNativeHistogramBuckets
 }
 
+// NativeHistogramClassicBuckets returns the upper bounds of the classic histogram buckets materialized
+// from the native histograms received by the distributor. Empty if disabled.
+func (o *Overrides) NativeHistogramClassicBuckets(userID string) []float64 {
+	return o.GetOverridesForUser(userID).NativeHistogramClassicBuckets
+}
+
 // MaxLocalMetricsWithMetadataPerUser returns the maximum number of metrics with metadata a user is allowed to store in a single ingester.
 func (o *Overrides) MaxLocalMetricsWithMetadataPerUser(userID string) int {
 	return o.GetOverridesForUser(userID).MaxLocalMetricsWithMetadataPerUser
@@ -1054,6 +1286,21 @@ func (o *Overrides) CompactorPartitionSeriesCount(userID string) int64 {
 	return o.GetOverridesForUser(userID).CompactorPartitionSeriesCount
 }
 
+// CompactorMaxCompactionLevel returns the maximum compaction level of the blocks produced by the compactor for a given user.
+func (o *Overrides) CompactorMaxCompactionLevel(userID string) int {
+	return o.GetOverridesForUser(userID).CompactorMaxCompactionLevel
+}
+
+// CompactorVerticalCompactionOnly returns whether the compactor should only merge overlapping blocks for a given user.
+func (o *Overrides) CompactorVerticalCompactionOnly(userID string) bool {
+	return o.GetOverridesForUser(userID).CompactorVerticalCompactionOnly
+}
+
+// CompactorMinBlockAge returns the minimum age of a block before the compactor considers it for compaction for a given user.
+func (o *Overrides) CompactorMinBlockAge(userID string) time.Duration {
+	return time.Duration(o.GetOverridesForUser(userID).CompactorMinBlockAge)
+}
+
 // MetricRelabelConfigs returns the metric relabel configs for a given user.
 func (o *Overrides) MetricRelabelConfigs(userID string) []*relabel.Config {
 	return o.GetOverridesForUser(userID).MetricRelabelConfigs
@@ -1074,6 +1321,11 @@ func (o *Overrides) RulerMaxRuleGroupsPerTenant(userID string) int {
 	return o.GetOverridesForUser(userID).RulerMaxRuleGroupsPerTenant
 }
 
+// RulerMaxConcurrentRuleGroupEvaluations returns the maximum number of rule groups evaluated concurrently for a given user.
+func (o *Overrides) RulerMaxConcurrentRuleGroupEvaluations(userID string) int {
+	return o.GetOverridesForUser(userID).RulerMaxConcurrentRuleGroupEvaluations
+}
+
 // RulerQueryOffset returns the rule query offset for a given user.
 func (o *Overrides) RulerQueryOffset(userID string) time.Duration {
 	ruleOffset := time.Duration(o.GetOverridesForUser(userID).RulerQueryOffset)
@@ -1085,7 +1337,7 @@ func (o *Overrides) RulerQueryOffset(userID string) time.Duration {
 	return ruleOffset
 }
 
-// RulesPartialData returns whether rule may be evaluated with data from a single zone, if other zones are not available.
+// RulesPartialData returns whether rule may be evaluated with partial data, if some zones or store-gateways are not available.
 func (o *Overrides) RulesPartialData(userID string) bool {
 	return o.GetOverridesForUser(userID).RulesPartialData
 }
@@ -1095,6 +1347,18 @@ func (o *Overrides) StoreGatewayTenantShardSize(userID string) float64 {
 	return o.GetOverridesForUser(userID).StoreGatewayTenantShardSize
 }
 
+// IgnoreDeletionMarksDelay returns the per-tenant delay after which blocks marked for deletion
+// are filtered out by the store-gateway. 0 means the global setting is used.
+func (o *Overrides) IgnoreDeletionMarksDelay(userID string) time.Duration {
+	return time.Duration(o.GetOverridesForUser(userID).IgnoreDeletionMarksDelay)
+}
+
+// IgnoreBlocksWithin returns the per-tenant duration within which newly created blocks are
+// not synced by the store-gateway. 0 means the global setting is used.
+func (o *Overrides) IgnoreBlocksWithin(userID string) time.Duration {
+	return time.Duration(o.GetOverridesForUser(userID).IgnoreBlocksWithin)
+}
+
 // MaxHAReplicaGroups returns maximum number of clusters that HA tracker will track for a user.
 func (o *Overrides) MaxHAReplicaGroups(user string) int {
 	return o.GetOverridesForUser(user).HAMaxClusters
@@ -1105,6 +1369,11 @@ func (o *Overrides) HATrackerFailoverTimeout(user string) time.Duration {
 	return time.Duration(o.GetOverridesForUser(user).HATrackerFailoverTimeout)
 }
 
+// HATrackerFastFailoverTimeout returns the per-tenant HA tracker fast failover timeout.
+func (o *Overrides) HATrackerFastFailoverTimeout(user string) time.Duration {
+	return time.Duration(o.GetOverridesForUser(user).HATrackerFastFailoverTimeout)
+}
+
 // S3SSEType returns the per-tenant S3 SSE type.
 func (o *Overrides) S3SSEType(user string) string {
 	return o.GetOverridesForUser(user).S3SSEType
@@ -1132,11 +1401,34 @@ func (o *Overrides) AlertmanagerReceiversBlockPrivateAddresses(user string) bool
 	return o.GetOverridesForUser(user).AlertmanagerReceiversBlockPrivateAddresses
 }
 
+// AlertmanagerReceiversBlockHosts returns the list of hosts that should be blocked
+// in the Alertmanager receivers for the given user.
+func (o *Overrides) AlertmanagerReceiversBlockHosts(user string) []string {
+	return o.GetOverridesForUser(user).AlertmanagerReceiversBlockHosts
+}
+
+// AlertmanagerReceiversAllowCIDRNetworks returns the list of network CIDRs allowed
+// in the Alertmanager receivers for the given user.
+func (o *Overrides) AlertmanagerReceiversAllowCIDRNetworks(user string) []flagext.CIDR {
+	return o.GetOverridesForUser(user).AlertmanagerReceiversAllowCIDRNetworks
+}
+
+// AlertmanagerReceiversAllowHosts returns the list of hosts allowed in the Alertmanager
+// receivers for the given user.
+func (o *Overrides) AlertmanagerReceiversAllowHosts(user string) []string {
+	return o.GetOverridesForUser(user).AlertmanagerReceiversAllowHosts
+}
+
 // MaxExemplars gets the maximum number of exemplars that will be stored per user. 0 or less means disabled.
 func (o *Overrides) MaxExemplars(userID string) int {
 	return o.GetOverridesForUser(userID).MaxExemplars
 }
 
+// MaxExemplarsPerQuery returns the maximum number of exemplars an ingester returns for a single query. 0 means unlimited.
+func (o *Overrides) MaxExemplarsPerQuery(userID string) int {
+	return o.GetOverridesForUser(userID).MaxExemplarsPerQuery
+}
+
 // Notification limits are special. Limits are returned in following order:
 // 1. per-tenant limits for given integration
 // 2. default limits for given integration
@@ -1185,6 +1477,12 @@ func (o *Overrides) NotificationBurstSize(user string, integration string) int {
 	return int(l)
 }
 
+// NotificationRateLimitQueueSize returns the maximum number of rate-limited notifications per integration
+// which wait for the rate limit, instead of being dropped immediately. 0 = no queue.
+func (o *Overrides) NotificationRateLimitQueueSize(user string) int {
+	return o.GetOverridesForUser(user).NotificationRateLimitQueueSize
+}
+
 func (o *Overrides) AlertmanagerMaxConfigSize(userID string) int {
 	return o.GetOverridesForUser(userID).AlertmanagerMaxConfigSizeBytes
 }
@@ -1225,6 +1523,11 @@ func (o *Overrides) EnableStartTimestamp(userID string) bool {
 	return o.GetOverridesForUser(userID).EnableStartTimestamp
 }
 
+// OTLPConvertDeltaToCumulative returns whether the delta temporality OTLP metrics are converted to cumulative for a given user.
+func (o *Overrides) OTLPConvertDeltaToCumulative(userID string) bool {
+	return o.GetOverridesForUser(userID).OTLPConvertDeltaToCumulative
+}
+
 func (o *Overrides) DisabledRuleGroups(userID string) DisabledRuleGroups {
 	if o.tenantLimits != nil {
 		l := o.tenantLimits.ByUserID(userID)
@@ -118,6 +118,40 @@ func TestLimits_Validate(t *testing.T) {
 			activeSeriesMetricsEnabled: false,
 			expected:                   errMaxLocalNativeHistogramSeriesPerUserValidation,
 		},
+		"max-series-policy evict-idle and active-series-metrics-enabled=true": {
+			limits:                     Limits{MaxSeriesPolicy: MaxSeriesPolicyEvictIdle},
+			activeSeriesMetricsEnabled: true,
+			expected:                   nil,
+		},
+		"max-series-policy evict-idle and active-series-metrics-enabled=false": {
+			limits:                     Limits{MaxSeriesPolicy: MaxSeriesPolicyEvictIdle},
+			activeSeriesMetricsEnabled: false,
+			expected:                   errMaxSeriesPolicyEvictIdleValidation,
+		},
+		"max-series-policy unsupported": {
+			limits:   Limits{MaxSeriesPolicy: "unknown"},
+			expected: errInvalidMaxSeriesPolicy,
+		},
+		"native-histogram-classic-buckets in increasing order": {
+			limits:   Limits{NativeHistogramClassicBuckets: []float64{0.1, 1, 10}},
+			expected: nil,
+		},
+		"native-histogram-classic-buckets not in increasing order": {
+			limits:   Limits{NativeHistogramClassicBuckets: []float64{0.1, 10, 1}},
+			expected: errNativeHistogramClassicBucketsNotIncreasing,
+		},
+		"negative ingestion-rate-native-histogram-bucket-weight": {
+			limits:   Limits{IngestionRateNativeHistogramBucketWeight: -1},
+			expected: errNegativeIngestionRateNativeHistogramBucketWeight,
+		},
+		"mirror-writes-ratio greater than 1": {
+			limits:   Limits{MirrorWritesRatio: 1.5},
+			expected: errInvalidMirrorWritesRatio,
+		},
+		"metric-quarantine-series-low-water-mark not lower than the threshold": {
+			limits:   Limits{MetricQuarantineSeriesThreshold: 100, MetricQuarantineSeriesLowWaterMark: 100},
+			expected: errMetricQuarantineSeriesLowWaterMark,
+		},
 		"external-labels invalid label name": {
 			limits:   Limits{RulerExternalLabels: labels.FromStrings("123invalid", "good")},
 			expected: errInvalidLabelName,
@@ -200,6 +234,44 @@ func TestLimits_Validate(t *testing.T) {
 			haTrackerUpdateTimeoutJitterMax: 5 * time.Second,
 			expected:                        fmt.Errorf("HA Tracker failover timeout (0s) must be at least 1s greater than update timeout - max jitter (21s)"),
 		},
+		"ha_tracker_fast_failover_timeout too small": {
+			limits:                          Limits{HATrackerFailoverTimeout: model.Duration(30 * time.Second), HATrackerFastFailoverTimeout: model.Duration(5 * time.Second)},
+			haTrackerUpdateTimeout:          4 * time.Second,
+			haTrackerUpdateTimeoutJitterMax: 2 * time.Second,
+			expected:                        fmt.Errorf("HA Tracker fast failover timeout (5s) must be at least 1s greater than update timeout - max jitter (7s)"),
+		},
+		"ha_tracker_fast_failover_timeout not lower than failover timeout": {
+			limits:                          Limits{HATrackerFailoverTimeout: model.Duration(30 * time.Second), HATrackerFastFailoverTimeout: model.Duration(30 * time.Second)},
+			haTrackerUpdateTimeout:          4 * time.Second,
+			haTrackerUpdateTimeoutJitterMax: 2 * time.Second,
+			expected:                        fmt.Errorf("HA Tracker fast failover timeout (30s) must be lower than the failover timeout (30s)"),
+		},
+		"promote_resource_attributes converted to distinct label names": {
+			limits:   Limits{PromoteResourceAttributes: []string{"service.namespace", "k8s.pod.name"}},
+			expected: nil,
+		},
+		"promote_resource_attributes converted to the same label name": {
+			limits:   Limits{PromoteResourceAttributes: []string{"k8s.pod.name", "k8s_pod_name"}},
+			expected: errDuplicatePromoteResourceAttribute,
+		},
+		"promote_resource_attributes with an empty attribute": {
+			limits:   Limits{PromoteResourceAttributes: []string{""}},
+			expected: errInvalidPromoteResourceAttribute,
+		},
+		"metric_name_allowlist with an invalid pattern": {
+			limits:   Limits{MetricNameAllowlist: []string{"node_*", "up["}},
+			expected: errInvalidMetricNamePattern,
+		},
+		"metric_name_denylist with an invalid pattern": {
+			limits:   Limits{MetricNameDenylist: []string{"up["}},
+			expected: errInvalidMetricNamePattern,
+		},
+		"ha_tracker_fast_failover_timeout valid": {
+			limits:                          Limits{HATrackerFailoverTimeout: model.Duration(30 * time.Second), HATrackerFastFailoverTimeout: model.Duration(10 * time.Second)},
+			haTrackerUpdateTimeout:          4 * time.Second,
+			haTrackerUpdateTimeoutJitterMax: 2 * time.Second,
+			expected:                        nil,
+		},
 		"ha_tracker_failover_timeout valid": {
 			limits:                          Limits{HATrackerFailoverTimeout: model.Duration(7 * time.Second)},
 			haTrackerUpdateTimeout:          4 * time.Second,
@@ -943,6 +1015,23 @@ func TestCompileQueryPriorityRegex(t *testing.T) {
 	require.Nil(t, l.QueryPriority.Priorities[0].QueryAttributes[0].CompiledRegex)
 }
 
+func TestCompileQueryPriorityRegex_ShouldRejectNegativeWeight(t *testing.T) {
+	l := Limits{
+		QueryPriority: QueryPriority{
+			Enabled: true,
+			Priorities: []PriorityDef{
+				{Priority: 1, Weight: 3},
+				{Priority: 2, Weight: -1},
+			},
+		},
+	}
+
+	require.ErrorIs(t, l.compileQueryAttributeRegex(), errNegativeQueryPriorityWeight)
+
+	l.QueryPriority.Priorities[1].Weight = 0
+	require.NoError(t, l.compileQueryAttributeRegex())
+}
+
 func TestEvaluationDelayHigherThanRulerQueryOffset(t *testing.T) {
 	tenant := "tenant"
 	evaluationDelay := time.Duration(10)
@@ -3,6 +3,7 @@ package validation
 import (
 	"errors"
 	"net/http"
+	"path"
 	"strings"
 	"time"
 	"unicode/utf8"
@@ -37,10 +38,11 @@ const (
 	unitTooLong       = "unit_too_long"
 
 	// ErrQueryTooLong is used in chunk store, querier and query frontend.
-	ErrQueryTooLong = "the query time range exceeds the limit (query length: %s, limit: %s)"
+	ErrQueryTooLong = "the query time range exceeds the limit (query length: %s, max_query_length: %s)"
 
 	missingMetricName       = "missing_metric_name"
 	invalidMetricName       = "metric_name_invalid"
+	metricNameNotAllowed    = "metric_name_not_allowed"
 	greaterThanMaxSampleAge = "greater_than_max_sample_age"
 	maxLabelNamesPerSeries  = "max_label_names_per_series"
 	tooFarInFuture          = "too_far_in_future"
@@ -293,14 +295,62 @@ func ValidateMetricName(limits *Limits, ls []cortexpb.LabelAdapter, nameValidati
 	return nil, ""
 }
 
-// ValidateLabels returns an err if the labels are invalid.
+// ValidateMetricNameAllowed checks the metric name of ls against the limits.MetricNameAllowlist and
+// limits.MetricNameDenylist. It returns (nil, "") when allowed, or (error, discardReason) when not.
+// Callers should increment DiscardedSamples/DiscardedExemplars with the returned reason when non-empty.
+func ValidateMetricNameAllowed(limits *Limits, ls []cortexpb.LabelAdapter) (ValidationError, string) {
+	if len(limits.MetricNameAllowlist) == 0 && len(limits.MetricNameDenylist) == 0 {
+		return nil, ""
+	}
+
+	unsafeMetricName, _ := extract.UnsafeMetricNameFromLabelAdapters(ls)
+	if matchMetricName(limits.MetricNameDenylist, unsafeMetricName) {
+		return newMetricNameNotAllowedError(unsafeMetricName, "denylist"), metricNameNotAllowed
+	}
+	if len(limits.MetricNameAllowlist) > 0 && !matchMetricName(limits.MetricNameAllowlist, unsafeMetricName) {
+		return newMetricNameNotAllowedError(unsafeMetricName, "allowlist"), metricNameNotAllowed
+	}
+	return nil, ""
+}
+
+// matchMetricName returns whether the metric name matches any of the metric names or glob patterns.
+func matchMetricName(patterns []string, metricName string) bool {
+	for _, pattern := range patterns {
+		// The patterns are validated with the limits.
+		if ok, _ := path.Match(pattern, metricName); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateLabels returns an err if the labels are invalid, accounting for a single discarded sample.
 // The returned error may retain the provided series labels.
 // Callers must validate metric name (e.g. via ValidateMetricName) before calling this when EnforceMetricName is true.
 func ValidateLabels(validateMetrics *ValidateMetrics, limits *Limits, userID string, ls []cortexpb.LabelAdapter, skipLabelNameValidation bool, nameValidationScheme model.ValidationScheme) ValidationError {
+	return ValidateSeriesLabels(validateMetrics, limits, userID, ls, 1, 0, skipLabelNameValidation, nameValidationScheme)
+}
+
+// ValidateSeriesLabels returns an err if the labels of a series are invalid, accounting for all the
+// samples and exemplars of the series as discarded.
+// The returned error may retain the provided series labels.
+// Callers must validate metric name (e.g. via ValidateMetricName) before calling this when EnforceMetricName is true.
+func ValidateSeriesLabels(validateMetrics *ValidateMetrics, limits *Limits, userID string, ls []cortexpb.LabelAdapter, samples, exemplars int, skipLabelNameValidation bool, nameValidationScheme model.ValidationScheme) ValidationError {
+	err, reason := validateLabels(validateMetrics, limits, userID, ls, skipLabelNameValidation, nameValidationScheme)
+	if err != nil {
+		validateMetrics.DiscardedSamples.WithLabelValues(reason, userID).Add(float64(samples))
+		if exemplars > 0 {
+			validateMetrics.DiscardedExemplars.WithLabelValues(reason, userID).Add(float64(exemplars))
+		}
+	}
+	return err
+}
+
+// validateLabels returns an err and the discard reason if the labels are invalid.
+func validateLabels(validateMetrics *ValidateMetrics, limits *Limits, userID string, ls []cortexpb.LabelAdapter, skipLabelNameValidation bool, nameValidationScheme model.ValidationScheme) (ValidationError, string) {
 	numLabelNames := len(ls)
 	if numLabelNames > limits.MaxLabelNamesPerSeries {
-		validateMetrics.DiscardedSamples.WithLabelValues(maxLabelNamesPerSeries, userID).Inc()
-		return newTooManyLabelsError(ls, limits.MaxLabelNamesPerSeries)
+		return newTooManyLabelsError(ls, limits.MaxLabelNamesPerSeries), maxLabelNamesPerSeries
 	}
 
 	maxLabelNameLength := limits.MaxLabelNameLength
@@ -311,22 +361,17 @@ func ValidateLabels(validateMetrics *ValidateMetrics, limits *Limits, userID str
 
 	for _, l := range ls {
 		if !skipLabelNameValidation && !nameValidationScheme.IsValidLabelName(l.Name) {
-			validateMetrics.DiscardedSamples.WithLabelValues(invalidLabel, userID).Inc()
-			return newInvalidLabelError(ls, l.Name)
+			return newInvalidLabelError(ls, l.Name), invalidLabel
 		} else if len(l.Name) > maxLabelNameLength {
-			validateMetrics.DiscardedSamples.WithLabelValues(labelNameTooLong, userID).Inc()
-			return newLabelNameTooLongError(ls, l.Name, maxLabelNameLength)
+			return newLabelNameTooLongError(ls, l.Name, maxLabelNameLength), labelNameTooLong
 		} else if len(l.Value) > maxLabelValueLength {
-			validateMetrics.DiscardedSamples.WithLabelValues(labelValueTooLong, userID).Inc()
-			return newLabelValueTooLongError(ls, l.Name, l.Value, maxLabelValueLength)
+			return newLabelValueTooLongError(ls, l.Name, l.Value, maxLabelValueLength), labelValueTooLong
 		} else if cmp := strings.Compare(lastLabelName, l.Name); cmp >= 0 {
 			if cmp == 0 {
-				validateMetrics.DiscardedSamples.WithLabelValues(duplicateLabelNames, userID).Inc()
-				return newDuplicatedLabelError(ls, l.Name)
+				return newDuplicatedLabelError(ls, l.Name), duplicateLabelNames
 			}
 
-			validateMetrics.DiscardedSamples.WithLabelValues(labelsNotSorted, userID).Inc()
-			return newLabelsNotSortedError(ls, l.Name)
+			return newLabelsNotSortedError(ls, l.Name), labelsNotSorted
 		}
 
 		lastLabelName = l.Name
@@ -334,10 +379,9 @@ func ValidateLabels(validateMetrics *ValidateMetrics, limits *Limits, userID str
 	}
 	validateMetrics.LabelSizeBytes.WithLabelValues(userID).Observe(float64(labelsSizeBytes))
 	if maxLabelsSizeBytes > 0 && labelsSizeBytes > maxLabelsSizeBytes {
-		validateMetrics.DiscardedSamples.WithLabelValues(labelsSizeBytesExceeded, userID).Inc()
-		return labelSizeBytesExceededError(ls, labelsSizeBytes, maxLabelsSizeBytes)
+		return labelSizeBytesExceededError(ls, labelsSizeBytes, maxLabelsSizeBytes), labelsSizeBytesExceeded
 	}
-	return nil
+	return nil, ""
 }
 
 // ValidateMetadata returns an err if a metric metadata is invalid.
@@ -102,6 +102,54 @@ func TestValidateMetricName(t *testing.T) {
 	assert.Empty(t, reason)
 }
 
+func TestValidateMetricNameAllowed(t *testing.T) {
+	for name, c := range map[string]struct {
+		allowlist  []string
+		denylist   []string
+		metricName string
+		expectErr  error
+	}{
+		"should allow any metric name without lists": {
+			metricName: "up",
+		},
+		"should allow a metric name in the allowlist": {
+			allowlist:  []string{"up", "node_*"},
+			metricName: "up",
+		},
+		"should allow a metric name matching a glob of the allowlist": {
+			allowlist:  []string{"up", "node_*"},
+			metricName: "node_cpu_seconds_total",
+		},
+		"should reject a metric name not in the allowlist": {
+			allowlist:  []string{"up", "node_*"},
+			metricName: "process_cpu_seconds_total",
+			expectErr:  newMetricNameNotAllowedError("process_cpu_seconds_total", "allowlist"),
+		},
+		"should reject a metric name matching a glob of the denylist": {
+			denylist:   []string{"go_*"},
+			metricName: "go_goroutines",
+			expectErr:  newMetricNameNotAllowedError("go_goroutines", "denylist"),
+		},
+		"should reject a metric name in both the allowlist and the denylist": {
+			allowlist:  []string{"node_*"},
+			denylist:   []string{"node_network_*"},
+			metricName: "node_network_receive_bytes_total",
+			expectErr:  newMetricNameNotAllowedError("node_network_receive_bytes_total", "denylist"),
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			cfg := &Limits{MetricNameAllowlist: c.allowlist, MetricNameDenylist: c.denylist}
+			err, reason := ValidateMetricNameAllowed(cfg, cortexpb.FromMetricsToLabelAdapters(model.Metric{model.MetricNameLabel: model.LabelValue(c.metricName)}))
+			assert.Equal(t, c.expectErr, err)
+			if c.expectErr != nil {
+				assert.Equal(t, metricNameNotAllowed, reason)
+			} else {
+				assert.Empty(t, reason)
+			}
+		})
+	}
+}
+
 func TestValidateLabels(t *testing.T) {
 	cfg := new(Limits)
 	userID := "testUser"
@@ -335,6 +383,32 @@ func TestValidateMetadata(t *testing.T) {
 	`), "cortex_discarded_metadata_total"))
 }
 
+func TestValidateSeriesLabels(t *testing.T) {
+	cfg := new(Limits)
+	cfg.MaxLabelNameLength = 10
+	cfg.MaxLabelValueLength = 5
+	cfg.MaxLabelNamesPerSeries = 5
+	reg := prometheus.NewRegistry()
+	validateMetrics := NewValidateMetrics(reg)
+	userID := "testUser"
+
+	actual := ValidateSeriesLabels(validateMetrics, cfg, userID, []cortexpb.LabelAdapter{
+		{Name: model.MetricNameLabel, Value: "m"},
+		{Name: "a", Value: "too-long"},
+	}, 3, 2, false, model.LegacyValidation)
+	assert.Error(t, actual)
+
+	// All the samples and exemplars of the series are accounted for.
+	require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+			# HELP cortex_discarded_exemplars_total The total number of exemplars that were discarded.
+			# TYPE cortex_discarded_exemplars_total counter
+			cortex_discarded_exemplars_total{reason="label_value_too_long",user="testUser"} 2
+			# HELP cortex_discarded_samples_total The total number of samples that were discarded.
+			# TYPE cortex_discarded_samples_total counter
+			cortex_discarded_samples_total{reason="label_value_too_long",user="testUser"} 3
+	`), "cortex_discarded_samples_total", "cortex_discarded_exemplars_total"))
+}
+
 func TestValidateLabelOrder(t *testing.T) {
 	cfg := new(Limits)
 	cfg.MaxLabelNameLength = 10
@@ -287,6 +287,8 @@ func getFieldType(t reflect.Type) (string, error) {
 		return "string", nil
 	case "flagext.SecretStringSliceCSV":
 		return "string", nil
+	case "flagext.Float64SliceCSV":
+		return "string", nil
 	case "flagext.CIDRSliceCSV":
 		return "string", nil
 	case "[]*relabel.Config":